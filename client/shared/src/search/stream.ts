@@ -166,6 +166,9 @@ export interface Skipped {
      * - excluded-fork :: we did not search a repository because it is a fork.
      * - excluded-archive :: we did not search a repository because it is archived.
      * - display :: we hit the display limit, so we stopped sending results from the backend.
+     * - query-unknown-field :: a term of the query looks like a misspelled filter, so it was searched for as text.
+     * - query-regexp-in-literal :: a pattern of the query looks like a regular expression, but was searched for literally.
+     * - query-repo-no-match :: a repo: filter of the query matched no repository, but some have a similar name.
      */
     reason:
         | 'document-match-limit'
@@ -178,6 +181,9 @@ export interface Skipped {
         | 'excluded-archive'
         | 'display'
         | 'error'
+        | 'query-unknown-field'
+        | 'query-regexp-in-literal'
+        | 'query-repo-no-match'
     /**
      * A short message. eg 1,200 timed out.
      */
@@ -6,6 +6,10 @@ import GithubIcon from 'mdi-react/GithubIcon'
 import GitIcon from 'mdi-react/GitIcon'
 import GitLabIcon from 'mdi-react/GitlabIcon'
 import LanguageJavaIcon from 'mdi-react/LanguageJavaIcon'
+import LanguagePythonIcon from 'mdi-react/LanguagePythonIcon'
+import LanguageRubyIcon from 'mdi-react/LanguageRubyIcon'
+import LanguageRustIcon from 'mdi-react/LanguageRustIcon'
+import NpmIcon from 'mdi-react/NpmIcon'
 import React from 'react'
 
 import { PhabricatorIcon } from '@sourcegraph/shared/src/components/icons'
@@ -13,13 +17,21 @@ import { PhabricatorIcon } from '@sourcegraph/shared/src/components/icons'
 import awsCodeCommitSchemaJSON from '../../../../../schema/aws_codecommit.schema.json'
 import bitbucketCloudSchemaJSON from '../../../../../schema/bitbucket_cloud.schema.json'
 import bitbucketServerSchemaJSON from '../../../../../schema/bitbucket_server.schema.json'
+import gerritSchemaJSON from '../../../../../schema/gerrit.schema.json'
+import giteaSchemaJSON from '../../../../../schema/gitea.schema.json'
 import githubSchemaJSON from '../../../../../schema/github.schema.json'
 import gitlabSchemaJSON from '../../../../../schema/gitlab.schema.json'
 import gitoliteSchemaJSON from '../../../../../schema/gitolite.schema.json'
 import jvmPackagesSchemaJSON from '../../../../../schema/jvm-packages.schema.json'
+import mercurialSchemaJSON from '../../../../../schema/mercurial.schema.json'
+import npmPackagesSchemaJSON from '../../../../../schema/npm-packages.schema.json'
 import otherExternalServiceSchemaJSON from '../../../../../schema/other_external_service.schema.json'
 import perforceSchemaJSON from '../../../../../schema/perforce.schema.json'
 import phabricatorSchemaJSON from '../../../../../schema/phabricator.schema.json'
+import pythonPackagesSchemaJSON from '../../../../../schema/python-packages.schema.json'
+import rubyPackagesSchemaJSON from '../../../../../schema/ruby-packages.schema.json'
+import rustPackagesSchemaJSON from '../../../../../schema/rust-packages.schema.json'
+import subversionSchemaJSON from '../../../../../schema/subversion.schema.json'
 import { ExternalServiceKind } from '../../graphql-operations'
 import { EditorAction } from '../../site-admin/configHelpers'
 import { PerforceIcon } from '../PerforceIcon'
@@ -1015,6 +1027,277 @@ const GITOLITE: AddExternalServiceOptions = {
         },
     ],
 }
+const GITEA: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.GITEA,
+    title: 'Gitea',
+    icon: GitIcon,
+    jsonSchema: giteaSchemaJSON,
+    defaultDisplayName: 'Gitea',
+    defaultConfig: `{
+  "url": "https://gitea.example.com",
+  "token": "<access token>",
+  "repositoryQuery": ["affiliated"]
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to be the URL of your Gitea instance.
+                </li>
+                <li>
+                    Create a Gitea access token in the <strong>Applications</strong> section of your user settings,
+                    and set it as the <Field>token</Field> field.
+                </li>
+                <li>
+                    Select the repositories to sync with the <Field>repositoryQuery</Field>, <Field>orgs</Field> and{' '}
+                    <Field>repos</Field> fields.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gitea#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'addOrg',
+            label: 'Add organization',
+            run: (config: string) => {
+                const value = '<organization name>'
+                const edits = setProperty(config, ['orgs', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addRepo',
+            label: 'Add a single repository',
+            run: (config: string) => {
+                const value = '<owner>/<repository>'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'excludeRepo',
+            label: 'Exclude a repository',
+            run: (config: string) => {
+                const value = { name: '<owner>/<repository>' }
+                const edits = setProperty(config, ['exclude', -1], value, defaultFormattingOptions)
+                return { edits, selectText: '<owner>/<repository>' }
+            },
+        },
+    ],
+}
+const GERRIT: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.GERRIT,
+    title: 'Gerrit',
+    icon: GitIcon,
+    jsonSchema: gerritSchemaJSON,
+    defaultDisplayName: 'Gerrit',
+    defaultConfig: `{
+  "url": "https://gerrit.example.com"
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to be the URL of your Gerrit instance.
+                </li>
+                <li>
+                    To sync projects that aren't visible anonymously, set the <Field>username</Field> and{' '}
+                    <Field>password</Field> fields to a Gerrit username and its HTTP password.
+                </li>
+                <li>
+                    All projects are synced by default. Set the <Field>projects</Field> field to only sync some of them.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gerrit#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setCredentials',
+            label: 'Set credentials',
+            run: (config: string) => {
+                const value = '<username>'
+                const edits = [
+                    ...setProperty(config, ['username'], value, defaultFormattingOptions),
+                    ...setProperty(config, ['password'], '<HTTP password>', defaultFormattingOptions),
+                ]
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProject',
+            label: 'Add a project',
+            run: (config: string) => {
+                const value = '<project name>'
+                const edits = setProperty(config, ['projects', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+}
+const SUBVERSION: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.SUBVERSION,
+    title: 'Subversion',
+    icon: GitIcon,
+    jsonSchema: subversionSchemaJSON,
+    defaultDisplayName: 'Subversion',
+    defaultConfig: `{
+  "url": "https://svn.example.com/repos/"
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL of the Subversion parent path that
+                    contains your repositories.
+                </li>
+                <li>
+                    If the repositories can't be read anonymously, set the <Field>username</Field> and{' '}
+                    <Field>password</Field> fields.
+                </li>
+                <li>
+                    All repositories listed by the server are synced by default. Set the <Field>repos</Field> field to
+                    only sync some of them, or if the server doesn't list its repositories.
+                </li>
+            </ol>
+            <p>
+                Repositories are converted to Git with git-svn. See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/subversion#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setCredentials',
+            label: 'Set credentials',
+            run: (config: string) => {
+                const value = '<username>'
+                const edits = [
+                    ...setProperty(config, ['username'], value, defaultFormattingOptions),
+                    ...setProperty(config, ['password'], '<password>', defaultFormattingOptions),
+                ]
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addRepo',
+            label: 'Add a repository',
+            run: (config: string) => {
+                const value = '<repository path>'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addAuthor',
+            label: 'Map an author',
+            run: (config: string) => {
+                const value = 'Full Name <email@example.com>'
+                const edits = setProperty(config, ['authors', '<username>'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+}
+const MERCURIAL: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.MERCURIAL,
+    title: 'Mercurial',
+    icon: GitIcon,
+    jsonSchema: mercurialSchemaJSON,
+    defaultDisplayName: 'Mercurial',
+    defaultConfig: `{
+  "url": "https://hg.example.com/"
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to the URL of the hgweb index that lists your
+                    repositories.
+                </li>
+                <li>
+                    If the repositories can't be read anonymously, set the <Field>username</Field> and{' '}
+                    <Field>password</Field> fields.
+                </li>
+                <li>
+                    All repositories listed by hgweb are synced by default. Set the <Field>repos</Field> field to
+                    only sync some of them.
+                </li>
+            </ol>
+            <p>
+                Repositories are converted to Git with hg-fast-export. See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/mercurial#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setCredentials',
+            label: 'Set credentials',
+            run: (config: string) => {
+                const value = '<username>'
+                const edits = [
+                    ...setProperty(config, ['username'], value, defaultFormattingOptions),
+                    ...setProperty(config, ['password'], '<password>', defaultFormattingOptions),
+                ]
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addRepo',
+            label: 'Add a repository',
+            run: (config: string) => {
+                const value = '<repository path>'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addAuthor',
+            label: 'Map an author',
+            run: (config: string) => {
+                const value = 'Full Name <email@example.com>'
+                const edits = setProperty(config, ['authors', '<author>'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+}
 const PHABRICATOR_SERVICE: AddExternalServiceOptions = {
     kind: ExternalServiceKind.PHABRICATOR,
     title: 'Phabricator connection',
@@ -1210,6 +1493,111 @@ const JVM_PACKAGES: AddExternalServiceOptions = {
     ),
     editorActions: [],
 }
+const NPM_PACKAGES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.NPMPACKAGES,
+    title: 'npm Dependencies',
+    icon: NpmIcon,
+    jsonSchema: npmPackagesSchemaJSON,
+    defaultDisplayName: 'npm Dependencies',
+    defaultConfig: `{
+  "registry": "https://registry.npmjs.org",
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>registry</Field> to the URL of the npm registry. For
+                    example, <code>"https://registry.npmjs.org"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of package versions that
+                    you want to manually add. For example, <code>"react@17.0.2"</code> or{' '}
+                    <code>"@types/node@16.11.6"</code>.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
+const RUST_PACKAGES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.RUSTPACKAGES,
+    title: 'Rust Dependencies',
+    icon: LanguageRustIcon,
+    jsonSchema: rustPackagesSchemaJSON,
+    defaultDisplayName: 'Rust Dependencies',
+    defaultConfig: `{
+  "registry": "https://crates.io",
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>registry</Field> to the URL of the package registry. For
+                    example, <code>"https://crates.io"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of package versions that
+                    you want to manually add. For example, <code>"serde_json@1.0.68"</code>.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
+const PYTHON_PACKAGES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.PYTHONPACKAGES,
+    title: 'Python Dependencies',
+    icon: LanguagePythonIcon,
+    jsonSchema: pythonPackagesSchemaJSON,
+    defaultDisplayName: 'Python Dependencies',
+    defaultConfig: `{
+  "registry": "https://pypi.org",
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>registry</Field> to the URL of the package registry. For
+                    example, <code>"https://pypi.org"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of package versions that
+                    you want to manually add. For example, <code>"Flask==2.0.2"</code>.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
+const RUBY_PACKAGES: AddExternalServiceOptions = {
+    kind: ExternalServiceKind.RUBYPACKAGES,
+    title: 'Ruby Dependencies',
+    icon: LanguageRubyIcon,
+    jsonSchema: rubyPackagesSchemaJSON,
+    defaultDisplayName: 'Ruby Dependencies',
+    defaultConfig: `{
+  "registry": "https://rubygems.org",
+  "dependencies": []
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>registry</Field> to the URL of the package registry. For
+                    example, <code>"https://rubygems.org"</code>.
+                </li>
+                <li>
+                    In the configuration below, set <Field>dependencies</Field> to the list of package versions that
+                    you want to manually add. For example, <code>"rails@6.1.4"</code>.
+                </li>
+            </ol>
+        </div>
+    ),
+    editorActions: [],
+}
 
 export const codeHostExternalServices: Record<string, AddExternalServiceOptions> = {
     github: GITHUB_DOTCOM,
@@ -1221,9 +1609,17 @@ export const codeHostExternalServices: Record<string, AddExternalServiceOptions>
     aws_codecommit: AWS_CODE_COMMIT,
     srcservegit: SRC_SERVE_GIT,
     gitolite: GITOLITE,
+    gitea: GITEA,
+    gerrit: GERRIT,
+    subversion: SUBVERSION,
+    mercurial: MERCURIAL,
     git: GENERIC_GIT,
     ...(window.context?.experimentalFeatures?.perforce === 'enabled' ? { perforce: PERFORCE } : {}),
     ...(window.context?.experimentalFeatures?.jvmPackages === 'enabled' ? { jvmPackages: JVM_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.npmPackages === 'enabled' ? { npmPackages: NPM_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.rustPackages === 'enabled' ? { rustPackages: RUST_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.pythonPackages === 'enabled' ? { pythonPackages: PYTHON_PACKAGES } : {}),
+    ...(window.context?.experimentalFeatures?.rubyPackages === 'enabled' ? { rubyPackages: RUBY_PACKAGES } : {}),
 }
 
 export const nonCodeHostExternalServices: Record<string, AddExternalServiceOptions> = {
@@ -1241,9 +1637,17 @@ export const defaultExternalServices: Record<ExternalServiceKind, AddExternalSer
     [ExternalServiceKind.BITBUCKETSERVER]: BITBUCKET_SERVER,
     [ExternalServiceKind.GITLAB]: GITLAB_DOTCOM,
     [ExternalServiceKind.GITOLITE]: GITOLITE,
+    [ExternalServiceKind.GITEA]: GITEA,
+    [ExternalServiceKind.GERRIT]: GERRIT,
     [ExternalServiceKind.PHABRICATOR]: PHABRICATOR_SERVICE,
     [ExternalServiceKind.OTHER]: GENERIC_GIT,
     [ExternalServiceKind.AWSCODECOMMIT]: AWS_CODE_COMMIT,
     [ExternalServiceKind.PERFORCE]: PERFORCE,
     [ExternalServiceKind.JVMPACKAGES]: JVM_PACKAGES,
+    [ExternalServiceKind.NPMPACKAGES]: NPM_PACKAGES,
+    [ExternalServiceKind.RUSTPACKAGES]: RUST_PACKAGES,
+    [ExternalServiceKind.PYTHONPACKAGES]: PYTHON_PACKAGES,
+    [ExternalServiceKind.RUBYPACKAGES]: RUBY_PACKAGES,
+    [ExternalServiceKind.SUBVERSION]: SUBVERSION,
+    [ExternalServiceKind.MERCURIAL]: MERCURIAL,
 }
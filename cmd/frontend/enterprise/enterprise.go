@@ -3,6 +3,7 @@ package enterprise
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
@@ -20,6 +21,7 @@ type Services struct {
 	GitHubWebhook             webhooks.Registerer
 	GitLabWebhook             http.Handler
 	BitbucketServerWebhook    http.Handler
+	BitbucketCloudWebhook     http.Handler
 	NewCodeIntelUploadHandler NewCodeIntelUploadHandler
 	NewExecutorProxyHandler   NewExecutorProxyHandler
 	AuthzResolver             graphqlbackend.AuthzResolver
@@ -30,6 +32,7 @@ type Services struct {
 	LicenseResolver           graphqlbackend.LicenseResolver
 	DotcomResolver            graphqlbackend.DotcomRootResolver
 	SearchContextsResolver    graphqlbackend.SearchContextsResolver
+	SearchExportStore         SearchExportStore
 }
 
 // NewCodeIntelUploadHandler creates a new handler for the LSIF upload endpoint. The
@@ -41,12 +44,26 @@ type NewCodeIntelUploadHandler func(internal bool) http.Handler
 // via a shared username and password.
 type NewExecutorProxyHandler func() http.Handler
 
+// SearchExportStore stores the exported files of search exports. If it is nil,
+// the files are stored on the local disk of the frontend which ran the export.
+type SearchExportStore interface {
+	// Get returns a reader that streams the content of the object at the given key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Upload writes the content in the given reader to the object at the given key.
+	Upload(ctx context.Context, key string, r io.Reader) (int64, error)
+
+	// Delete removes the content at the given key.
+	Delete(ctx context.Context, key string) error
+}
+
 // DefaultServices creates a new Services value that has default implementations for all services.
 func DefaultServices() Services {
 	return Services{
 		GitHubWebhook:             registerFunc(func(webhook *webhooks.GitHubWebhook) {}),
 		GitLabWebhook:             makeNotFoundHandler("gitlab webhook"),
 		BitbucketServerWebhook:    makeNotFoundHandler("bitbucket server webhook"),
+		BitbucketCloudWebhook:     makeNotFoundHandler("bitbucket cloud webhook"),
 		NewCodeIntelUploadHandler: func(_ bool) http.Handler { return makeNotFoundHandler("code intel upload") },
 		NewExecutorProxyHandler:   func() http.Handler { return makeNotFoundHandler("executor proxy") },
 	}
@@ -154,6 +154,7 @@ type CreateBatchChangesCredentialArgs struct {
 	ExternalServiceKind string
 	ExternalServiceURL  string
 	User                *graphql.ID
+	Username            *string
 	Credential          string
 }
 
@@ -211,6 +212,17 @@ type PublishChangesetsArgs struct {
 	Draft bool
 }
 
+type EnableChangesetMergeQueueArgs struct {
+	BatchChange            graphql.ID
+	Order                  string
+	ConcurrencyPerCodeHost int32
+	Squash                 bool
+}
+
+type ChangesetMergeQueueArgs struct {
+	BatchChange graphql.ID
+}
+
 type ResolveWorkspacesForBatchSpecArgs struct {
 	BatchSpec        string
 	AllowIgnored     bool
@@ -260,6 +272,10 @@ type BatchChangesResolver interface {
 	MergeChangesets(ctx context.Context, args *MergeChangesetsArgs) (BulkOperationResolver, error)
 	CloseChangesets(ctx context.Context, args *CloseChangesetsArgs) (BulkOperationResolver, error)
 	PublishChangesets(ctx context.Context, args *PublishChangesetsArgs) (BulkOperationResolver, error)
+	EnableChangesetMergeQueue(ctx context.Context, args *EnableChangesetMergeQueueArgs) (ChangesetMergeQueueResolver, error)
+	PauseChangesetMergeQueue(ctx context.Context, args *ChangesetMergeQueueArgs) (ChangesetMergeQueueResolver, error)
+	ResumeChangesetMergeQueue(ctx context.Context, args *ChangesetMergeQueueArgs) (ChangesetMergeQueueResolver, error)
+	DisableChangesetMergeQueue(ctx context.Context, args *ChangesetMergeQueueArgs) (*EmptyResponse, error)
 
 	// Queries
 	BatchChange(ctx context.Context, args *BatchChangeArgs) (BatchChangeResolver, error)
@@ -292,6 +308,23 @@ type BulkOperationResolver interface {
 	FinishedAt() *DateTime
 }
 
+type ChangesetMergeQueueResolver interface {
+	State() string
+	Order() string
+	ConcurrencyPerCodeHost() int32
+	Squash() bool
+	EnabledBy(ctx context.Context) (*UserResolver, error)
+	RemovedChangesets(ctx context.Context) ([]ChangesetMergeQueueRemovalResolver, error)
+	CreatedAt() DateTime
+	UpdatedAt() DateTime
+}
+
+type ChangesetMergeQueueRemovalResolver interface {
+	Changeset() ChangesetResolver
+	Reason() *string
+	RemovedAt() DateTime
+}
+
 type ChangesetJobErrorResolver interface {
 	Changeset() ChangesetResolver
 	Error() *string
@@ -592,6 +625,7 @@ type BatchChangeResolver interface {
 	ClosedAt() *DateTime
 	DiffStat(ctx context.Context) (*DiffStat, error)
 	CurrentSpec(ctx context.Context) (BatchSpecResolver, error)
+	MergeQueue(ctx context.Context) (ChangesetMergeQueueResolver, error)
 	BulkOperations(ctx context.Context, args *ListBatchChangeBulkOperationArgs) (BulkOperationConnectionResolver, error)
 	BatchSpecs(ctx context.Context, args *ListBatchSpecArgs) (BatchSpecConnectionResolver, error)
 }
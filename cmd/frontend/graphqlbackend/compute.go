@@ -203,6 +203,10 @@ func toResultResolverList(ctx context.Context, cmd compute.Command, matches []re
 			if err != nil {
 				return nil, err
 			}
+			if result == nil {
+				// The command did not produce a result for this file.
+				continue
+			}
 			repoResolver := getRepoResolver(fm.Repo, "")
 			results = append(results, toComputeResultResolver(fm, result, repoResolver))
 		}
@@ -40,3 +40,39 @@ func TestToResultResolverList(t *testing.T) {
 
 	autogold.Want("resolver copies all match results", `["a","b"]`).Equal(t, test("a|b"))
 }
+
+func TestToResultResolverList_Output(t *testing.T) {
+	matches := []result.Match{
+		&result.FileMatch{
+			File: result.File{Path: "a.go"},
+			LineMatches: []*result.LineMatch{
+				{Preview: "version 1"},
+				{Preview: "version 2"},
+			},
+		},
+		&result.FileMatch{
+			File: result.File{Path: "b.go"},
+			LineMatches: []*result.LineMatch{
+				{Preview: "nothing here"},
+			},
+		},
+	}
+	test := func(input string) string {
+		computeQuery, _ := compute.Parse(input)
+		resolvers, _ := toResultResolverList(
+			context.Background(),
+			computeQuery.Command,
+			matches,
+			new(dbtesting.MockDB),
+		)
+		var results []string
+		for _, r := range resolvers {
+			text, _ := r.ToComputeText()
+			results = append(results, *text.Path()+": "+text.Value())
+		}
+		v, _ := json.Marshal(results)
+		return string(v)
+	}
+
+	autogold.Want("output skips files without output", `["a.go: v1\nv2"]`).Equal(t, test(`content:output(version (\d) -> v$1)`))
+}
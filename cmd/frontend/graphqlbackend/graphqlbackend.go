@@ -487,6 +487,9 @@ func newSchemaResolver(db dbutil.DB) *schemaResolver {
 		"SavedSearch": func(ctx context.Context, id graphql.ID) (Node, error) {
 			return r.savedSearchByID(ctx, id)
 		},
+		"SearchExport": func(ctx context.Context, id graphql.ID) (Node, error) {
+			return searchExportByID(ctx, db, id)
+		},
 		"Site": func(ctx context.Context, id graphql.ID) (Node, error) {
 			return r.siteByGQLID(ctx, id)
 		},
@@ -0,0 +1,141 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+type LineHistoryArgs struct {
+	StartLine int32
+	EndLine   int32
+	First     int32
+	After     *string
+}
+
+func (r *GitTreeEntryResolver) LineHistory(ctx context.Context, args *LineHistoryArgs) (*lineHistoryConnectionResolver, error) {
+	if args.StartLine < 1 || args.EndLine < args.StartLine {
+		return nil, errors.Errorf("invalid line range %d-%d", args.StartLine, args.EndLine)
+	}
+	if args.First < 0 {
+		return nil, errors.New("first must not be negative")
+	}
+
+	var offset int
+	if args.After != nil {
+		var err error
+		offset, err = strconv.Atoi(*args.After)
+		if err != nil || offset < 0 {
+			return nil, errors.Errorf("invalid cursor %q", *args.After)
+		}
+	}
+
+	return &lineHistoryConnectionResolver{
+		db:     r.db,
+		commit: r.commit,
+		opt: git.LineHistoryOptions{
+			Commit:    api.CommitID(r.commit.OID()),
+			Path:      r.Path(),
+			StartLine: int(args.StartLine),
+			EndLine:   int(args.EndLine),
+			Skip:      uint(offset),
+		},
+		first: args.First,
+	}, nil
+}
+
+type lineHistoryConnectionResolver struct {
+	db     dbutil.DB
+	commit *GitCommitResolver
+	opt    git.LineHistoryOptions
+	first  int32
+
+	// cache results because they are used by multiple fields
+	once    sync.Once
+	entries []*git.LineHistoryEntry
+	err     error
+}
+
+func (r *lineHistoryConnectionResolver) compute(ctx context.Context) ([]*git.LineHistoryEntry, error) {
+	r.once.Do(func() {
+		opt := r.opt
+		// Request one more commit than needed to know whether there is a next
+		// page.
+		opt.N = uint(r.first) + 1
+		r.entries, r.err = git.LineHistory(ctx, r.commit.repoResolver.RepoName(), opt)
+	})
+	return r.entries, r.err
+}
+
+func (r *lineHistoryConnectionResolver) Nodes(ctx context.Context) ([]*lineHistoryEntryResolver, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > int(r.first) {
+		entries = entries[:r.first]
+	}
+
+	resolvers := make([]*lineHistoryEntryResolver, 0, len(entries))
+	for _, entry := range entries {
+		resolvers = append(resolvers, &lineHistoryEntryResolver{
+			db:     r.db,
+			repo:   r.commit.repoResolver,
+			commit: toGitCommitResolver(r.commit.repoResolver, r.db, entry.Commit.ID, entry.Commit),
+			entry:  entry,
+		})
+	}
+	return resolvers, nil
+}
+
+func (r *lineHistoryConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) <= int(r.first) {
+		return graphqlutil.HasNextPage(false), nil
+	}
+	return graphqlutil.NextPageCursor(strconv.Itoa(int(r.opt.Skip) + int(r.first))), nil
+}
+
+type lineHistoryEntryResolver struct {
+	db     dbutil.DB
+	repo   *RepositoryResolver
+	commit *GitCommitResolver
+	entry  *git.LineHistoryEntry
+}
+
+func (r *lineHistoryEntryResolver) Commit() *GitCommitResolver { return r.commit }
+
+func (r *lineHistoryEntryResolver) Path() string { return r.entry.Path }
+
+func (r *lineHistoryEntryResolver) OldPath() *string {
+	if r.entry.OldPath == "" {
+		return nil
+	}
+	return &r.entry.OldPath
+}
+
+func (r *lineHistoryEntryResolver) Hunks() []*DiffHunk {
+	highlighter := &fileDiffHighlighter{
+		newFile: NewGitTreeEntryResolver(r.commit, r.db, CreateFileInfo(r.entry.Path, false)),
+	}
+	if r.entry.OldPath != "" && len(r.entry.Commit.Parents) > 0 {
+		parent := toGitCommitResolver(r.repo, r.db, r.entry.Commit.Parents[0], nil)
+		highlighter.oldFile = NewGitTreeEntryResolver(parent, r.db, CreateFileInfo(r.entry.OldPath, false))
+	}
+
+	hunks := make([]*DiffHunk, len(r.entry.Hunks))
+	for i, hunk := range r.entry.Hunks {
+		hunks[i] = NewDiffHunk(hunk, highlighter)
+	}
+	return hunks
+}
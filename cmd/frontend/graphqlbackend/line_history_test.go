@@ -0,0 +1,141 @@
+package graphqlbackend
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git/gitapi"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
+)
+
+func TestGitBlobLineHistory(t *testing.T) {
+	resetMocks()
+	database.Mocks.ExternalServices.List = func(opt database.ExternalServicesListOptions) ([]*types.ExternalService, error) {
+		return nil, nil
+	}
+	database.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &gitapi.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (fs.FileInfo, error) {
+		return &util.FileInfo{Name_: path}, nil
+	}
+	git.Mocks.LineHistory = func(repo api.RepoName, opt git.LineHistoryOptions) ([]*git.LineHistoryEntry, error) {
+		want := git.LineHistoryOptions{
+			Commit:    exampleCommitSHA1,
+			Path:      "g.txt",
+			StartLine: 2,
+			EndLine:   3,
+			N:         2,
+			Skip:      1,
+		}
+		if opt != want {
+			t.Errorf("got options %+v, want %+v", opt, want)
+		}
+		return []*git.LineHistoryEntry{
+			{
+				Commit:  &gitapi.Commit{ID: "c2", Parents: []api.CommitID{"c1"}},
+				Path:    "g.txt",
+				OldPath: "f.txt",
+				Hunks: []*diff.Hunk{{
+					OrigStartLine: 2, OrigLines: 2,
+					NewStartLine: 2, NewLines: 2,
+					Body: []byte(" B\n-c\n+C\n"),
+				}},
+			},
+			{
+				Commit: &gitapi.Commit{ID: "c1"},
+				Path:   "f.txt",
+			},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	RunTests(t, []*Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							blob(path: "g.txt") {
+								lineHistory(startLine: 2, endLine: 3, first: 1, after: "1") {
+									nodes {
+										commit {
+											oid
+										}
+										path
+										oldPath
+										hunks {
+											oldRange {
+												startLine
+												lines
+											}
+											newRange {
+												startLine
+												lines
+											}
+											body
+										}
+									}
+									pageInfo {
+										endCursor
+										hasNextPage
+									}
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+{
+  "repository": {
+    "commit": {
+      "blob": {
+        "lineHistory": {
+          "nodes": [
+            {
+              "commit": {
+                "oid": "c2"
+              },
+              "path": "g.txt",
+              "oldPath": "f.txt",
+              "hunks": [
+                {
+                  "oldRange": {
+                    "startLine": 2,
+                    "lines": 2
+                  },
+                  "newRange": {
+                    "startLine": 2,
+                    "lines": 2
+                  },
+                  "body": " B\n-c\n+C\n"
+                }
+              ]
+            }
+          ],
+          "pageInfo": {
+            "endCursor": "2",
+            "hasNextPage": true
+          }
+        }
+      }
+    }
+  }
+}
+			`,
+		},
+	})
+}
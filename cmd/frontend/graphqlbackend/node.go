@@ -191,6 +191,11 @@ func (r *NodeResolver) ToSavedSearch() (*savedSearchResolver, bool) {
 	return n, ok
 }
 
+func (r *NodeResolver) ToSearchExport() (*searchExportResolver, bool) {
+	n, ok := r.Node.(*searchExportResolver)
+	return n, ok
+}
+
 func (r *NodeResolver) ToSearchContext() (SearchContextResolver, bool) {
 	n, ok := r.Node.(SearchContextResolver)
 	return n, ok
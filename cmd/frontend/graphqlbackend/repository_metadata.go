@@ -0,0 +1,82 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+)
+
+type keyValuePairResolver struct {
+	kvp database.KeyValuePair
+}
+
+func (r *keyValuePairResolver) Key() string { return r.kvp.Key }
+
+func (r *keyValuePairResolver) Value() *string { return r.kvp.Value }
+
+func (r *RepositoryResolver) Metadata(ctx context.Context) ([]*keyValuePairResolver, error) {
+	kvps, err := database.RepoKVPs(r.db).List(ctx, r.IDInt32())
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*keyValuePairResolver, 0, len(kvps))
+	for _, kvp := range kvps {
+		resolvers = append(resolvers, &keyValuePairResolver{kvp: kvp})
+	}
+	return resolvers, nil
+}
+
+type setRepositoryMetadataArgs struct {
+	Repository graphql.ID
+	Key        string
+	Value      *string
+}
+
+func (r *schemaResolver) SetRepositoryMetadata(ctx context.Context, args *setRepositoryMetadataArgs) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Repository metadata is visible to and searchable by all
+	// users, so only site admins may change it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
+		return nil, err
+	}
+
+	// repo:has.meta(key:value) splits at the first colon, so keys with a colon
+	// could not be searched.
+	if args.Key == "" || strings.Contains(args.Key, ":") {
+		return nil, errors.Errorf("invalid repository metadata key %q: it must be non-empty and must not contain a colon", args.Key)
+	}
+
+	repo, err := r.repositoryByID(ctx, args.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.RepoKVPs(r.db).Set(ctx, repo.IDInt32(), args.Key, args.Value); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) DeleteRepositoryMetadata(ctx context.Context, args *struct {
+	Repository graphql.ID
+	Key        string
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins may change repository metadata.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
+		return nil, err
+	}
+
+	repo, err := r.repositoryByID(ctx, args.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.RepoKVPs(r.db).Delete(ctx, repo.IDInt32(), args.Key); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
@@ -0,0 +1,101 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestSetRepositoryMetadata(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	database.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+	database.Mocks.Repos.Get = func(ctx context.Context, repoID api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: repoID, Name: "github.com/foo/bar"}, nil
+	}
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return &types.Repo{ID: 123, Name: name}, nil
+	}
+
+	var (
+		gotRepoID api.RepoID
+		gotKey    string
+		gotValue  *string
+	)
+	database.Mocks.RepoKVPs.Set = func(ctx context.Context, repoID api.RepoID, key string, value *string) error {
+		gotRepoID, gotKey, gotValue = repoID, key, value
+		return nil
+	}
+	database.Mocks.RepoKVPs.List = func(ctx context.Context, repoID api.RepoID) ([]database.KeyValuePair, error) {
+		return []database.KeyValuePair{{Key: gotKey, Value: gotValue}}, nil
+	}
+
+	RunTests(t, []*Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					setRepositoryMetadata(repository: "UmVwb3NpdG9yeToxMjM=", key: "team", value: "search") {
+						alwaysNil
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"setRepositoryMetadata": {
+						"alwaysNil": null
+					}
+				}
+			`,
+		},
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					repository(name: "github.com/foo/bar") {
+						metadata {
+							key
+							value
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"metadata": [{"key": "team", "value": "search"}]
+					}
+				}
+			`,
+		},
+	})
+
+	if gotRepoID != 123 || gotKey != "team" || gotValue == nil || *gotValue != "search" {
+		t.Fatalf("unexpected Set call: repo %d, key %q, value %v", gotRepoID, gotKey, gotValue)
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := (&schemaResolver{db: new(dbtesting.MockDB)}).SetRepositoryMetadata(context.Background(), &setRepositoryMetadataArgs{Repository: "UmVwb3NpdG9yeToxMjM=", Key: "team:search"})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("non site admin", func(t *testing.T) {
+		database.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{}, nil
+		}
+		_, err := (&schemaResolver{db: new(dbtesting.MockDB)}).SetRepositoryMetadata(context.Background(), &setRepositoryMetadataArgs{Repository: "UmVwb3NpdG9yeToxMjM=", Key: "team"})
+		if err != backend.ErrMustBeSiteAdmin {
+			t.Fatalf("got error %v, want %v", err, backend.ErrMustBeSiteAdmin)
+		}
+	})
+}
@@ -45,6 +45,10 @@ type SearchArgs struct {
 	// to make it visible in the browser.
 	Stream streaming.Sender
 
+	// Exhaustive if true searches for all results of a query that does not
+	// specify a count, as if it contained count:all.
+	Exhaustive bool
+
 	// For tests
 	Settings *schema.Settings
 }
@@ -91,6 +95,7 @@ func NewSearchImplementer(ctx context.Context, db dbutil.DB, args *SearchArgs) (
 	plan, err = query.Pipeline(
 		query.Init(args.Query, searchType),
 		query.With(globbing, query.Globbing),
+		query.With(args.Exhaustive, query.Exhaustive),
 	)
 	if err != nil {
 		return alertForQuery(args.Query, err).wrapSearchImplementer(db), nil
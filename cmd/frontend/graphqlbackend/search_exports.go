@@ -0,0 +1,115 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type searchExportResolver struct {
+	db dbutil.DB
+	e  *types.SearchExport
+}
+
+func searchExportByID(ctx context.Context, db dbutil.DB, id graphql.ID) (*searchExportResolver, error) {
+	exportID, err := unmarshalSearchExportID(id)
+	if err != nil {
+		return nil, err
+	}
+	e, err := database.SearchExports(db).GetByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user who created the export and site admins may
+	// view it.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, db, e.UserID); err != nil {
+		return nil, err
+	}
+	return &searchExportResolver{db: db, e: e}, nil
+}
+
+func marshalSearchExportID(id int64) graphql.ID { return relay.MarshalID("SearchExport", id) }
+
+func unmarshalSearchExportID(id graphql.ID) (exportID int64, err error) {
+	err = relay.UnmarshalSpec(id, &exportID)
+	return
+}
+
+func (r *searchExportResolver) ID() graphql.ID { return marshalSearchExportID(r.e.ID) }
+
+func (r *searchExportResolver) Query() string { return r.e.Query }
+
+func (r *searchExportResolver) Format() string { return strings.ToUpper(string(r.e.Format)) }
+
+func (r *searchExportResolver) State() string { return strings.ToUpper(string(r.e.State)) }
+
+func (r *searchExportResolver) FailureMessage() *string { return r.e.FailureMessage }
+
+func (r *searchExportResolver) ResultCount() int32 { return r.e.ResultCount }
+
+func (r *searchExportResolver) User(ctx context.Context) (*UserResolver, error) {
+	return UserByIDInt32(ctx, r.db, r.e.UserID)
+}
+
+func (r *searchExportResolver) CreatedAt() DateTime { return DateTime{Time: r.e.CreatedAt} }
+
+func (r *searchExportResolver) FinishedAt() *DateTime { return DateTimeOrNil(r.e.FinishedAt) }
+
+func (r *searchExportResolver) URL() *string {
+	if r.e.State != types.SearchExportStateCompleted {
+		return nil
+	}
+	url := fmt.Sprintf("/.api/search/export/%d", r.e.ID)
+	return &url
+}
+
+type createSearchExportArgs struct {
+	Query       string
+	PatternType string
+	Format      string
+}
+
+func (r *schemaResolver) CreateSearchExport(ctx context.Context, args *createSearchExportArgs) (*searchExportResolver, error) {
+	// 🚨 SECURITY: The search is run on behalf of the current user, so only
+	// authenticated users may create exports.
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, backend.ErrNotAuthenticated
+	}
+
+	format := types.SearchExportFormat(strings.ToLower(args.Format))
+	if format != types.SearchExportFormatCSV && format != types.SearchExportFormatJSONL {
+		return nil, errors.Errorf("unsupported search export format %q", args.Format)
+	}
+
+	// Reject invalid queries up front rather than failing in the background.
+	searchType, err := detectSearchType("V2", &args.PatternType)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := query.Pipeline(query.Init(args.Query, overrideSearchType(args.Query, searchType))); err != nil {
+		return nil, err
+	}
+
+	e, err := database.SearchExports(r.db).Create(ctx, &types.SearchExport{
+		UserID:      a.UID,
+		Query:       args.Query,
+		PatternType: args.PatternType,
+		Format:      format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &searchExportResolver{db: r.db, e: e}, nil
+}
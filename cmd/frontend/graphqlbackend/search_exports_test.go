@@ -0,0 +1,102 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestCreateSearchExport(t *testing.T) {
+	defer resetMocks()
+	db := new(dbtesting.MockDB)
+
+	var created *types.SearchExport
+	database.Mocks.SearchExports.Create = func(ctx context.Context, e *types.SearchExport) (*types.SearchExport, error) {
+		created = e
+		return &types.SearchExport{ID: 1, UserID: e.UserID, Query: e.Query, Format: e.Format, State: types.SearchExportStateQueued}, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(42))
+	r, err := (&schemaResolver{db: db}).CreateSearchExport(ctx, &createSearchExportArgs{
+		Query:       `lib/pq v(\d+)`,
+		PatternType: "regexp",
+		Format:      "JSONL",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &types.SearchExport{
+		UserID:      42,
+		Query:       `lib/pq v(\d+)`,
+		PatternType: "regexp",
+		Format:      types.SearchExportFormatJSONL,
+	}
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Fatalf("unexpected export (-want +got):\n%s", diff)
+	}
+	if r.State() != "QUEUED" || r.Format() != "JSONL" {
+		t.Fatalf("unexpected state %q or format %q", r.State(), r.Format())
+	}
+	if r.URL() != nil {
+		t.Fatalf("unexpected URL %q for queued export", *r.URL())
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := (&schemaResolver{db: db}).CreateSearchExport(context.Background(), &createSearchExportArgs{
+			Query:       "foo",
+			PatternType: "literal",
+			Format:      "CSV",
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := (&schemaResolver{db: db}).CreateSearchExport(ctx, &createSearchExportArgs{
+			Query:       "foo count:nope",
+			PatternType: "literal",
+			Format:      "CSV",
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestSearchExportByID(t *testing.T) {
+	defer resetMocks()
+	db := new(dbtesting.MockDB)
+
+	database.Mocks.SearchExports.GetByID = func(ctx context.Context, id int64) (*types.SearchExport, error) {
+		return &types.SearchExport{ID: id, UserID: 42, State: types.SearchExportStateCompleted}, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(42))
+	r, err := searchExportByID(ctx, db, marshalSearchExportID(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := r.URL(); url == nil || *url != "/.api/search/export/7" {
+		t.Fatalf("unexpected URL %v", url)
+	}
+
+	// Other users may not view the export.
+	database.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 2}, nil
+	}
+	database.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+	ctx = actor.WithActor(context.Background(), actor.FromUser(2))
+	if _, err := searchExportByID(ctx, db, marshalSearchExportID(7)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
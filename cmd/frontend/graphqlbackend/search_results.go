@@ -34,6 +34,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/honey"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/codeownership"
 	"github.com/sourcegraph/sourcegraph/internal/search/commit"
 	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
@@ -324,8 +325,8 @@ loop:
 	for _, r := range sr.Matches {
 		r := r // shadow so it doesn't change in the goroutine
 		switch m := r.(type) {
-		case *result.RepoMatch:
-			// We don't care about repo results here.
+		case *result.RepoMatch, *result.OwnerMatch:
+			// We don't care about repo or owner results here.
 			continue
 		case *result.CommitMatch:
 			// Diff searches are cheap, because we implicitly have author date info.
@@ -501,6 +502,13 @@ func (r *searchResolver) toRepoOptions(q query.Q, opts resolveRepositoriesOpts)
 	visibility := query.ParseVisibility(visibilityStr)
 
 	commitAfter, _ := q.StringValue(query.FieldRepoHasCommitAfter)
+	hasTopics, _ := q.StringValues(query.FieldRepoHasTopic)
+	hasKVPValues, _ := q.StringValues(query.FieldRepoHasKVP)
+	hasKVPs := make([]database.RepoKVPFilter, 0, len(hasKVPValues))
+	for _, v := range hasKVPValues {
+		key, value := query.ParseKeyValuePair(v)
+		hasKVPs = append(hasKVPs, database.RepoKVPFilter{Key: key, Value: value})
+	}
 	searchContextSpec, _ := q.StringValue(query.FieldContext)
 
 	var CacheLookup bool
@@ -521,6 +529,8 @@ func (r *searchResolver) toRepoOptions(q query.Q, opts resolveRepositoriesOpts)
 		NoArchived:        archived == query.No,
 		Visibility:        visibility,
 		CommitAfter:       commitAfter,
+		HasTopics:         hasTopics,
+		HasKVPs:           hasKVPs,
 		Query:             q,
 		Ranked:            true,
 		Limit:             opts.limit,
@@ -587,12 +597,16 @@ func withMode(args search.TextParameters, st query.SearchType) search.TextParame
 // where each job contains its separate state for that kind of search and
 // backend. To complete the migration to jobs in phases, `args` is kept
 // backwards compatibility and represents a generic search.
-func (r *searchResolver) toSearchInputs(q query.Q) (*search.TextParameters, []run.Job, error) {
+func (r *searchResolver) toSearchInputs(q query.Q, stream streaming.Sender) (*search.TextParameters, []run.Job, error) {
 	b, err := query.ToBasicQuery(q)
 	if err != nil {
 		return nil, nil, err
 	}
-	p := search.ToTextPatternInfo(b, r.protocol(), query.Identity)
+	protocol := search.Batch
+	if stream != nil {
+		protocol = search.Streaming
+	}
+	p := search.ToTextPatternInfo(b, protocol, query.Identity)
 
 	forceResultTypes := result.TypeEmpty
 	if r.PatternType == query.SearchTypeStructural {
@@ -613,7 +627,7 @@ func (r *searchResolver) toSearchInputs(q query.Q) (*search.TextParameters, []ru
 		Timeout:     search.TimeoutDuration(b),
 
 		// UseFullDeadline if timeout: set or we are streaming.
-		UseFullDeadline: q.Timeout() != nil || q.Count() != nil || r.stream != nil,
+		UseFullDeadline: q.Timeout() != nil || q.Count() != nil || stream != nil,
 
 		Zoekt:        r.zoekt,
 		SearcherURLs: r.searcherURLs,
@@ -630,7 +644,7 @@ func (r *searchResolver) toSearchInputs(q query.Q) (*search.TextParameters, []ru
 		// across all of Sourcegraph.
 		if r.PatternType == query.SearchTypeStructural && p.Pattern != "" {
 			jobs = append(jobs, &unindexed.StructuralSearch{
-				RepoFetcher: unindexed.NewRepoFetcher(r.stream, &args),
+				RepoFetcher: unindexed.NewRepoFetcher(stream, &args),
 				Mode:        args.Mode,
 				SearcherArgs: search.SearcherParameters{
 					SearcherURLs:    args.SearcherURLs,
@@ -645,14 +659,14 @@ func (r *searchResolver) toSearchInputs(q query.Q) (*search.TextParameters, []ru
 
 // evaluateLeaf performs a single search operation and corresponds to the
 // evaluation of leaf expression in a query.
-func (r *searchResolver) evaluateLeaf(ctx context.Context, args *search.TextParameters, jobs []run.Job) (_ *SearchResults, err error) {
+func (r *searchResolver) evaluateLeaf(ctx context.Context, stream streaming.Sender, args *search.TextParameters, jobs []run.Job) (_ *SearchResults, err error) {
 	tr, ctx := trace.New(ctx, "evaluateLeaf", "")
 	defer func() {
 		tr.SetError(err)
 		tr.Finish()
 	}()
 
-	return r.resultsWithTimeoutSuggestion(ctx, args, jobs)
+	return r.resultsWithTimeoutSuggestion(ctx, stream, args, jobs)
 }
 
 // union returns the union of two sets of search results and merges common search data.
@@ -693,7 +707,7 @@ func intersect(left, right *SearchResults) *SearchResults {
 // and likely yields fewer than N results). If the intersection does not yield N
 // results, and is not exhaustive for every expression, we rerun the search by
 // doubling count again.
-func (r *searchResolver) evaluateAnd(ctx context.Context, q query.Basic) (*SearchResults, error) {
+func (r *searchResolver) evaluateAnd(ctx context.Context, stream streaming.Sender, q query.Basic) (*SearchResults, error) {
 	start := time.Now()
 
 	// Invariant: this function is only reachable from callers that
@@ -735,7 +749,7 @@ func (r *searchResolver) evaluateAnd(ctx context.Context, q query.Basic) (*Searc
 	var exhausted bool
 	for {
 		q = q.MapCount(tryCount)
-		result, err = r.evaluatePatternExpression(ctx, q.MapPattern(operands[0]))
+		result, err = r.evaluatePatternExpression(ctx, stream, q.MapPattern(operands[0]))
 		if err != nil {
 			return nil, err
 		}
@@ -757,7 +771,7 @@ func (r *searchResolver) evaluateAnd(ctx context.Context, q query.Basic) (*Searc
 			default:
 			}
 
-			termResult, err = r.evaluatePatternExpression(ctx, q.MapPattern(term))
+			termResult, err = r.evaluatePatternExpression(ctx, stream, q.MapPattern(term))
 			if err != nil {
 				return nil, err
 			}
@@ -793,7 +807,7 @@ func (r *searchResolver) evaluateAnd(ctx context.Context, q query.Basic) (*Searc
 // expressions that are ORed together by searching for each subexpression. If
 // the maximum number of results are reached after evaluating a subexpression,
 // we shortcircuit and return results immediately.
-func (r *searchResolver) evaluateOr(ctx context.Context, q query.Basic) (*SearchResults, error) {
+func (r *searchResolver) evaluateOr(ctx context.Context, stream streaming.Sender, q query.Basic) (*SearchResults, error) {
 	// Invariant: this function is only reachable from callers that
 	// guarantee a root node with one or more operands.
 	operands := q.Pattern.(query.Operator).Operands
@@ -805,7 +819,7 @@ func (r *searchResolver) evaluateOr(ctx context.Context, q query.Basic) (*Search
 
 	result := &SearchResults{}
 	for _, term := range operands {
-		new, err := r.evaluatePatternExpression(ctx, q.MapPattern(term))
+		new, err := r.evaluatePatternExpression(ctx, stream, q.MapPattern(term))
 		if err != nil {
 			return nil, err
 		}
@@ -833,7 +847,7 @@ func (r *searchResolver) invalidateCache() {
 }
 
 // evaluatePatternExpression evaluates a search pattern containing and/or expressions.
-func (r *searchResolver) evaluatePatternExpression(ctx context.Context, q query.Basic) (*SearchResults, error) {
+func (r *searchResolver) evaluatePatternExpression(ctx context.Context, stream streaming.Sender, q query.Basic) (*SearchResults, error) {
 	switch term := q.Pattern.(type) {
 	case query.Operator:
 		if len(term.Operands) == 0 {
@@ -842,24 +856,24 @@ func (r *searchResolver) evaluatePatternExpression(ctx context.Context, q query.
 
 		switch term.Kind {
 		case query.And:
-			return r.evaluateAnd(ctx, q)
+			return r.evaluateAnd(ctx, stream, q)
 		case query.Or:
-			return r.evaluateOr(ctx, q)
+			return r.evaluateOr(ctx, stream, q)
 		case query.Concat:
 			r.invalidateCache()
-			args, jobs, err := r.toSearchInputs(q.ToParseTree())
+			args, jobs, err := r.toSearchInputs(q.ToParseTree(), stream)
 			if err != nil {
 				return &SearchResults{}, err
 			}
-			return r.evaluateLeaf(ctx, args, jobs)
+			return r.evaluateLeaf(ctx, stream, args, jobs)
 		}
 	case query.Pattern:
 		r.invalidateCache()
-		args, jobs, err := r.toSearchInputs(q.ToParseTree())
+		args, jobs, err := r.toSearchInputs(q.ToParseTree(), stream)
 		if err != nil {
 			return &SearchResults{}, err
 		}
-		return r.evaluateLeaf(ctx, args, jobs)
+		return r.evaluateLeaf(ctx, stream, args, jobs)
 	case query.Parameter:
 		// evaluatePatternExpression does not process Parameter nodes.
 		return &SearchResults{}, nil
@@ -869,16 +883,16 @@ func (r *searchResolver) evaluatePatternExpression(ctx context.Context, q query.
 }
 
 // evaluate evaluates all expressions of a search query.
-func (r *searchResolver) evaluate(ctx context.Context, q query.Basic) (*SearchResults, error) {
+func (r *searchResolver) evaluate(ctx context.Context, stream streaming.Sender, q query.Basic) (*SearchResults, error) {
 	if q.Pattern == nil {
 		r.invalidateCache()
-		args, jobs, err := r.toSearchInputs(query.ToNodes(q.Parameters))
+		args, jobs, err := r.toSearchInputs(query.ToNodes(q.Parameters), stream)
 		if err != nil {
 			return &SearchResults{}, err
 		}
-		return r.evaluateLeaf(ctx, args, jobs)
+		return r.evaluateLeaf(ctx, stream, args, jobs)
 	}
-	return r.evaluatePatternExpression(ctx, q)
+	return r.evaluatePatternExpression(ctx, stream, q)
 }
 
 // shouldInvalidateRepoCache returns whether resolved repos should be invalidated when
@@ -964,22 +978,40 @@ func (r *searchResolver) logBatch(ctx context.Context, srr *SearchResultsResolve
 
 func (r *searchResolver) resultsBatch(ctx context.Context) (*SearchResultsResolver, error) {
 	start := time.Now()
-	sr, err := r.resultsRecursive(ctx, r.Plan)
+	if query.IsStreamingCompatible(r.Plan) && needsOwnership(r.Plan.ToParseTree()) {
+		// Collect the results of a streaming search, which filters results
+		// by owner before they count against the result limit.
+		var (
+			mu      sync.Mutex
+			matches []result.Match
+		)
+		stream := streaming.StreamFunc(func(event streaming.SearchEvent) {
+			mu.Lock()
+			matches = append(matches, event.Results...)
+			mu.Unlock()
+		})
+		srr, err := r.resultsStreaming(ctx, stream)
+		if srr != nil {
+			r.sortResults(matches)
+			srr.Matches = matches
+		}
+		r.logBatch(ctx, srr, start, err)
+		return srr, err
+	}
+	sr, err := r.resultsRecursive(ctx, nil, r.Plan)
 	srr := r.resultsToResolver(sr)
 	r.logBatch(ctx, srr, start, err)
 	return srr, err
 }
 
-func (r *searchResolver) resultsStreaming(ctx context.Context) (*SearchResultsResolver, error) {
+func (r *searchResolver) resultsStreaming(ctx context.Context, stream streaming.Sender) (*SearchResultsResolver, error) {
 	if !query.IsStreamingCompatible(r.Plan) {
 		// The query is not streaming compatible, but we still want to
 		// use the streaming endpoint. Run a batch search then send the
 		// results back on the stream.
-		endpoint := r.stream
-		r.stream = nil // Disables streaming: backends may not use the endpoint.
 		srr, err := r.resultsBatch(ctx)
 		if srr != nil {
-			endpoint.Send(streaming.SearchEvent{
+			stream.Send(streaming.SearchEvent{
 				Results: srr.Matches,
 				Stats:   srr.Stats,
 			})
@@ -989,9 +1021,9 @@ func (r *searchResolver) resultsStreaming(ctx context.Context) (*SearchResultsRe
 	if sp, _ := r.Plan.ToParseTree().StringValue(query.FieldSelect); sp != "" {
 		// Ensure downstream events sent on the stream are processed by `select:`.
 		selectPath, _ := filter.SelectPathFromString(sp) // Invariant: error already checked
-		r.stream = streaming.WithSelect(r.stream, selectPath)
+		stream = streaming.WithSelect(stream, selectPath)
 	}
-	sr, err := r.resultsRecursive(ctx, r.Plan)
+	sr, err := r.resultsRecursive(ctx, stream, r.Plan)
 	srr := r.resultsToResolver(sr)
 	return srr, err
 }
@@ -1012,7 +1044,7 @@ func (r *searchResolver) Results(ctx context.Context) (*SearchResultsResolver, e
 	if r.stream == nil {
 		return r.resultsBatch(ctx)
 	}
-	return r.resultsStreaming(ctx)
+	return r.resultsStreaming(ctx, r.stream)
 }
 
 // DetermineStatusForLogs determines the final status of a search for logging
@@ -1034,7 +1066,7 @@ func DetermineStatusForLogs(srr *SearchResultsResolver, err error) string {
 	}
 }
 
-func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan) (sr *SearchResults, err error) {
+func (r *searchResolver) resultsRecursive(ctx context.Context, stream streaming.Sender, plan query.Plan) (sr *SearchResults, err error) {
 	tr, ctx := trace.New(ctx, "Results", "")
 	defer func() {
 		tr.SetError(err)
@@ -1052,18 +1084,14 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 
 	for _, q := range plan {
 		predicatePlan, err := substitutePredicates(q, func(pred query.Predicate) (*SearchResults, error) {
-			// Disable streaming for subqueries so we can use
-			// the results rather than sending them back to the caller
-			orig := r.stream
-			r.stream = nil
-			defer func() { r.stream = orig }()
-
 			r.invalidateRepoCache = true
 			plan, err := pred.Plan(q)
 			if err != nil {
 				return nil, err
 			}
-			return r.resultsRecursive(ctx, plan)
+			// Disable streaming for subqueries so we can use
+			// the results rather than sending them back to the caller
+			return r.resultsRecursive(ctx, nil, plan)
 		})
 		if errors.Is(err, ErrPredicateNoResults) {
 			continue
@@ -1074,10 +1102,10 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 		}
 		if predicatePlan != nil {
 			// If a predicate filter generated a new plan, evaluate that plan.
-			return r.resultsRecursive(ctx, predicatePlan)
+			return r.resultsRecursive(ctx, stream, predicatePlan)
 		}
 
-		newResult, err := r.evaluate(ctx, q)
+		newResult, err := r.evaluate(ctx, stream, q)
 		if err != nil {
 			// Fail if any subexpression fails.
 			return nil, err
@@ -1099,6 +1127,38 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 	return sr, err
 }
 
+// needsOwnership returns true if the query filters or selects results by code
+// owner.
+func needsOwnership(q query.Q) bool {
+	owners, selectOwners := ownershipParams(q)
+	return len(owners) > 0 || selectOwners
+}
+
+// ownershipFileMatchLimitFactor is how many times more file matches than the
+// result limit backends look for when results are filtered by owner.
+const ownershipFileMatchLimitFactor = 10
+
+// ownershipFileMatchLimit returns the file match limit of backends for a
+// search whose results are filtered by owner and limited to limit results.
+func ownershipFileMatchLimit(limit int, fileMatchLimit int32) int32 {
+	l := int64(limit) * ownershipFileMatchLimitFactor
+	if l > math.MaxInt32 {
+		l = math.MaxInt32
+	}
+	if l < int64(fileMatchLimit) {
+		return fileMatchLimit
+	}
+	return int32(l)
+}
+
+// ownershipParams returns the owners the results of q must be owned by, and
+// whether q selects the owners of file results.
+func ownershipParams(q query.Q) (owners []string, selectOwners bool) {
+	owners, _ = q.StringValues(query.FieldFileHasOwner)
+	sp, _ := q.StringValue(query.FieldSelect)
+	return owners, sp == "file.owners"
+}
+
 // searchResultsToRepoNodes converts a set of search results into repository nodes
 // such that they can be used to replace a repository predicate
 func searchResultsToRepoNodes(matches []result.Match) ([]query.Node, error) {
@@ -1151,9 +1211,9 @@ func searchResultsToFileNodes(matches []result.Match) ([]query.Node, error) {
 // resultsWithTimeoutSuggestion calls doResults, and in case of deadline
 // exceeded returns a search alert with a did-you-mean link for the same
 // query with a longer timeout.
-func (r *searchResolver) resultsWithTimeoutSuggestion(ctx context.Context, args *search.TextParameters, jobs []run.Job) (*SearchResults, error) {
+func (r *searchResolver) resultsWithTimeoutSuggestion(ctx context.Context, stream streaming.Sender, args *search.TextParameters, jobs []run.Job) (*SearchResults, error) {
 	start := time.Now()
-	rr, err := r.doResults(ctx, args, jobs)
+	rr, err := r.doResults(ctx, stream, args, jobs)
 
 	// We have an alert for context timeouts and we have a progress
 	// notification for timeouts. We don't want to show both, so we only show
@@ -1334,11 +1394,11 @@ func (r *searchResolver) Stats(ctx context.Context) (stats *searchResultsStats,
 	for {
 		// Query search results.
 		var err error
-		args, jobs, err := r.toSearchInputs(r.Query)
+		args, jobs, err := r.toSearchInputs(r.Query, r.stream)
 		if err != nil {
 			return nil, err
 		}
-		results, err := r.doResults(ctx, args, jobs)
+		results, err := r.doResults(ctx, r.stream, args, jobs)
 		if err != nil {
 			return nil, err // do not cache errors.
 		}
@@ -1422,7 +1482,7 @@ func withResultTypes(args search.TextParameters, forceTypes result.Types) search
 // regardless of what `type:` is specified in the query string.
 //
 // Partial results AND an error may be returned.
-func (r *searchResolver) doResults(ctx context.Context, args *search.TextParameters, jobs []run.Job) (res *SearchResults, err error) {
+func (r *searchResolver) doResults(ctx context.Context, stream streaming.Sender, args *search.TextParameters, jobs []run.Job) (res *SearchResults, err error) {
 	tr, ctx := trace.New(ctx, "doResults", r.rawQuery())
 	defer func() {
 		tr.SetError(err)
@@ -1458,7 +1518,6 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 	// For streaming search we want to limit based on all results, not just
 	// per backend. This works better than batch based since we have higher
 	// defaults.
-	stream := r.stream
 	if stream != nil {
 		var cancelOnLimit context.CancelFunc
 		ctx, stream, cancelOnLimit = streaming.WithLimit(ctx, stream, limit)
@@ -1467,6 +1526,20 @@ func (r *searchResolver) doResults(ctx context.Context, args *search.TextParamet
 
 	agg := run.NewAggregator(r.db, stream)
 
+	if owners, selectOwners := ownershipParams(args.Query); len(owners) > 0 || selectOwners {
+		// Filter results by owner as they are sent, so that only owned
+		// results count against the limit of the stream.
+		agg.SetFilter(codeownership.DefaultResolver.NewFilter(ctx, owners, selectOwners))
+		if stream != nil && len(owners) > 0 {
+			// The limits of the backends count results before they are
+			// filtered, so search for more results than we need and rely
+			// on the limit of the stream to stop the search.
+			patternInfo := *args.PatternInfo
+			patternInfo.FileMatchLimit = ownershipFileMatchLimit(limit, patternInfo.FileMatchLimit)
+			args.PatternInfo = &patternInfo
+		}
+	}
+
 	// This ensures we properly cleanup in the case of an early return. In
 	// particular we want to cancel global searches before returning early.
 	hasStartedAllBackends := false
@@ -1824,6 +1897,8 @@ func compareSearchResults(left, right result.Match, exactFilePatterns map[string
 			return string(r.Name), "", nil
 		case *result.FileMatch:
 			return string(r.Repo.Name), r.Path, nil
+		case *result.OwnerMatch:
+			return string(r.Repo.Name), r.Owner, nil
 		case *result.CommitMatch:
 			// Commits are relatively sorted by date, and after repo
 			// or path names. We use ~ as the key for repo and
@@ -37,12 +37,12 @@ func (srs *searchResultsStats) Languages(ctx context.Context) ([]*languageStatis
 
 func (srs *searchResultsStats) getResults(ctx context.Context) (*SearchResultsResolver, error) {
 	srs.once.Do(func() {
-		args, jobs, err := srs.sr.toSearchInputs(srs.sr.Query)
+		args, jobs, err := srs.sr.toSearchInputs(srs.sr.Query, srs.sr.stream)
 		if err != nil {
 			srs.srsErr = err
 			return
 		}
-		results, err := srs.sr.doResults(ctx, args, jobs)
+		results, err := srs.sr.doResults(ctx, srs.sr.stream, args, jobs)
 		if err != nil {
 			srs.srsErr = err
 			return
@@ -3,6 +3,7 @@ package graphqlbackend
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"sort"
@@ -1051,7 +1052,7 @@ func TestIsGlobalSearch(t *testing.T) {
 				},
 			}
 
-			p, _, _ := resolver.toSearchInputs(resolver.Query)
+			p, _, _ := resolver.toSearchInputs(resolver.Query, resolver.stream)
 			if p.Mode != tt.mode {
 				t.Fatalf("got %+v, want %+v", p.Mode, tt.mode)
 			}
@@ -1098,3 +1099,21 @@ func TestIsContextError(t *testing.T) {
 		})
 	}
 }
+
+func TestOwnershipFileMatchLimit(t *testing.T) {
+	cases := []struct {
+		limit          int
+		fileMatchLimit int32
+		want           int32
+	}{
+		{limit: 30, fileMatchLimit: 30, want: 300},
+		{limit: 30, fileMatchLimit: 1000, want: 1000},
+		{limit: 99999999, fileMatchLimit: 99999999, want: 999999990},
+		{limit: math.MaxInt32, fileMatchLimit: 30, want: math.MaxInt32},
+	}
+	for _, c := range cases {
+		if got := ownershipFileMatchLimit(c.limit, c.fileMatchLimit); got != c.want {
+			t.Errorf("ownershipFileMatchLimit(%d, %d): got %d, want %d", c.limit, c.fileMatchLimit, got, c.want)
+		}
+	}
+}
@@ -356,13 +356,13 @@ func (r *searchResolver) showSymbolMatches(ctx context.Context) ([]SearchSuggest
 		return nil, nil
 	}
 
-	args, jobs, err := r.toSearchInputs(r.Query)
+	args, jobs, err := r.toSearchInputs(r.Query, r.stream)
 	if err != nil {
 		return nil, err
 	}
 	args.ResultTypes = result.TypeSymbol
 
-	results, err := r.doResults(ctx, args, jobs)
+	results, err := r.doResults(ctx, r.stream, args, jobs)
 	if errors.Is(err, context.DeadlineExceeded) {
 		err = nil
 	}
@@ -441,12 +441,12 @@ func (r *searchResolver) showFilesWithTextMatches(first int32) suggester {
 		ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
 		defer cancel()
 		if len(r.Query.Values(query.FieldDefault)) > 0 {
-			searchArgs, jobs, err := r.toSearchInputs(r.Query)
+			searchArgs, jobs, err := r.toSearchInputs(r.Query, r.stream)
 			if err != nil {
 				return nil, err
 			}
 			searchArgs.ResultTypes = result.TypeFile // only "file" result type
-			results, err := r.doResults(ctx, searchArgs, jobs)
+			results, err := r.doResults(ctx, r.stream, searchArgs, jobs)
 			if err == context.DeadlineExceeded {
 				err = nil // don't log as error below
 			}
@@ -28,6 +28,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/app/updatecheck"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/bg"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cli/loghandlers"
+	searchexport "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/search/export"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/siteid"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/vfsutil"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
@@ -67,9 +68,10 @@ var (
 	prodExtension = "chrome-extension://dgjhfomjieaadpoljlnidmbgkdffpack"
 )
 
+// If CACHE_DIR is specified, use that
+var cacheDir = env.Get("CACHE_DIR", "/tmp", "directory to store cached archives.")
+
 func init() {
-	// If CACHE_DIR is specified, use that
-	cacheDir := env.Get("CACHE_DIR", "/tmp", "directory to store cached archives.")
 	vfsutil.ArchiveCacheDir = filepath.Join(cacheDir, "frontend-archive-cache")
 }
 
@@ -193,6 +195,9 @@ func Main(enterpriseSetupHook func(db dbutil.DB, outOfBandMigrationRunner *oobmi
 
 	// Run enterprise setup hook
 	enterprise := enterpriseSetupHook(db, outOfBandMigrationRunner)
+	if enterprise.SearchExportStore == nil {
+		enterprise.SearchExportStore = searchexport.NewLocalStore(filepath.Join(cacheDir, "search-exports"))
+	}
 
 	ui.InitRouter(db, enterprise.CodeIntelResolver)
 
@@ -284,6 +289,7 @@ func Main(enterpriseSetupHook func(db dbutil.DB, outOfBandMigrationRunner *oobmi
 		server,
 		outOfBandMigrationRunner,
 	}
+	routines = append(routines, searchexport.NewBackgroundRoutines(context.Background(), db, enterprise.SearchExportStore)...)
 	if internalAPI != nil {
 		routines = append(routines, internalAPI)
 	}
@@ -302,7 +308,7 @@ func Main(enterpriseSetupHook func(db dbutil.DB, outOfBandMigrationRunner *oobmi
 
 func makeExternalAPI(db dbutil.DB, schema *graphql.Schema, enterprise enterprise.Services, rateLimiter graphqlbackend.LimitWatcher) (goroutine.BackgroundRoutine, error) {
 	// Create the external HTTP handler.
-	externalHandler, err := newExternalHTTPHandler(db, schema, enterprise.GitHubWebhook, enterprise.GitLabWebhook, enterprise.BitbucketServerWebhook, enterprise.NewCodeIntelUploadHandler, enterprise.NewExecutorProxyHandler, rateLimiter)
+	externalHandler, err := newExternalHTTPHandler(db, schema, enterprise.GitHubWebhook, enterprise.GitLabWebhook, enterprise.BitbucketServerWebhook, enterprise.BitbucketCloudWebhook, enterprise.NewCodeIntelUploadHandler, enterprise.NewExecutorProxyHandler, enterprise.SearchExportStore, rateLimiter)
 	if err != nil {
 		return nil, err
 	}
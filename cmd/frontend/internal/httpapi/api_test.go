@@ -28,7 +28,9 @@ func newTest() *httptestutil.Client {
 		enterpriseServices.GitHubWebhook,
 		enterpriseServices.GitLabWebhook,
 		enterpriseServices.BitbucketServerWebhook,
+		enterpriseServices.BitbucketCloudWebhook,
 		enterpriseServices.NewCodeIntelUploadHandler,
+		enterpriseServices.SearchExportStore,
 		rateLimiter,
 	))
 }
@@ -17,30 +17,8 @@ import (
 	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
-	"github.com/sourcegraph/sourcegraph/schema"
 )
 
-func repoRankFromConfig(siteConfig schema.SiteConfiguration, repoName string) float64 {
-	val := 0.0
-	if siteConfig.ExperimentalFeatures == nil || siteConfig.ExperimentalFeatures.Ranking == nil {
-		return val
-	}
-	scores := siteConfig.ExperimentalFeatures.Ranking.RepoScores
-	if len(scores) == 0 {
-		return val
-	}
-	// try every "directory" in the repo name to assign it a value, so a repoName like
-	// "github.com/sourcegraph/zoekt" will have "github.com", "github.com/sourcegraph",
-	// and "github.com/sourcegraph/zoekt" tested.
-	for i := 0; i < len(repoName); i++ {
-		if repoName[i] == '/' {
-			val += scores[repoName[:i]]
-		}
-	}
-	val += scores[repoName]
-	return val
-}
-
 // serveSearchConfiguration is _only_ used by the zoekt index server. Zoekt does
 // not depend on frontend and therefore does not have access to `conf.Watch`.
 // Additionally, it only cares about certain search specific settings so this
@@ -105,10 +83,21 @@ func serveSearchConfiguration(db dbutil.DB) func(http.ResponseWriter, *http.Requ
 			}
 		}
 
+		// Build list of repo IDs to fetch ranks and revisions for.
+		repoIDs := make([]api.RepoID, len(repos))
+		for i, repo := range repos {
+			repoIDs[i] = repo.ID
+		}
+
+		priorities, loadPrioritiesErr := database.RepoRanks(db).Priorities(ctx, repoIDs)
+
 		getRepoIndexOptions := func(repoName string) (*searchbackend.RepoIndexOptions, error) {
 			if loadReposErr != nil {
 				return nil, loadReposErr
 			}
+			if loadPrioritiesErr != nil {
+				return nil, loadPrioritiesErr
+			}
 			// Replicate what database.Repos.GetByName would do here:
 			repo, ok := reposMap[api.RepoName(repoName)]
 			if !ok {
@@ -126,24 +115,17 @@ func serveSearchConfiguration(db dbutil.DB) func(http.ResponseWriter, *http.Requ
 				return string(commitID), err
 			}
 
-			priority := float64(repo.Stars) + repoRankFromConfig(siteConfig, repoName)
-
 			return &searchbackend.RepoIndexOptions{
 				Name:       string(repo.Name),
 				RepoID:     int32(repo.ID),
 				Public:     !repo.Private,
-				Priority:   priority,
+				Priority:   priorities[repo.ID],
 				Fork:       repo.Fork,
 				Archived:   repo.Archived,
 				GetVersion: getVersion,
 			}, nil
 		}
 
-		// Build list of repo IDs to fetch revisions for.
-		repoIDs := make([]api.RepoID, len(repos))
-		for i, repo := range repos {
-			repoIDs[i] = repo.ID
-		}
 		revisionsForRepo, revisionsForRepoErr := database.SearchContexts(db).GetAllRevisionsForRepos(ctx, repoIDs)
 		getSearchContextRevisions := func(repoID int32) ([]string, error) {
 			if revisionsForRepoErr != nil {
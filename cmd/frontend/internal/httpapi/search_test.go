@@ -17,7 +17,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/types"
-	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestReposIndex(t *testing.T) {
@@ -176,28 +175,3 @@ func (b suffixIndexers) ReposSubset(ctx context.Context, hostname string, indexe
 func (b suffixIndexers) Enabled() bool {
 	return bool(b)
 }
-
-func TestRepoRankFromConfig(t *testing.T) {
-	cases := []struct {
-		name       string
-		rankScores map[string]float64
-		want       float64
-	}{
-		{"gh.test/sg/sg", nil, 0},
-		{"gh.test/sg/sg", map[string]float64{"gh.test": 100}, 100},
-		{"gh.test/sg/sg", map[string]float64{"gh.test": 100, "gh.test/sg": 50}, 150},
-		{"gh.test/sg/sg", map[string]float64{"gh.test": 100, "gh.test/sg": 50, "gh.test/sg/sg": -20}, 130},
-		{"gh.test/sg/ex", map[string]float64{"gh.test": 100, "gh.test/sg": 50, "gh.test/sg/sg": -20}, 150},
-	}
-	for _, tc := range cases {
-		config := schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{
-			Ranking: &schema.Ranking{
-				RepoScores: tc.rankScores,
-			},
-		}}
-		got := repoRankFromConfig(config, tc.name)
-		if got != tc.want {
-			t.Errorf("got score %v, want %v, repo %q config %v", got, tc.want, tc.name, tc.rankScores)
-		}
-	}
-}
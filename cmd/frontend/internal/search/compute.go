@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/compute"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// ComputeStreamHandler is an http handler which streams back the results of
+// a compute query. The result of every file is sent in its own "results"
+// event as soon as it is computed, so that queries over many repositories
+// don't have to wait for the search to finish.
+func ComputeStreamHandler(db dbutil.DB) http.Handler {
+	return &computeStreamHandler{
+		db:                db,
+		newSearchResolver: defaultNewSearchResolver,
+	}
+}
+
+type computeStreamHandler struct {
+	db                dbutil.DB
+	newSearchResolver func(context.Context, dbutil.DB, *graphqlbackend.SearchArgs) (searchResolver, error)
+}
+
+// computeResultEvent is the payload of a "results" event.
+type computeResultEvent struct {
+	Repository string         `json:"repository"`
+	Commit     string         `json:"commit"`
+	Path       string         `json:"path"`
+	Result     compute.Result `json:"result"`
+}
+
+func (h *computeStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "no query found", http.StatusBadRequest)
+		return
+	}
+
+	computeQuery, err := compute.Parse(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	searchQuery, err := computeQuery.ToSearchQuery()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tr, ctx := trace.New(ctx, "compute.ServeStream", q)
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	eventWriter, err := streamhttp.NewWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Always send a final done event so clients know the stream is shutting
+	// down.
+	defer eventWriter.Event("done", map[string]interface{}{})
+
+	search := &streamHandler{db: h.db, newSearchResolver: h.newSearchResolver}
+	events, _, results := search.startSearch(ctx, &args{
+		Query:       searchQuery,
+		Version:     "V2",
+		PatternType: "regexp",
+	})
+
+	sendError := func(err error) {
+		_ = eventWriter.Event("error", streamhttp.EventError{Message: err.Error()})
+	}
+
+	handleEvent := func(event streaming.SearchEvent) error {
+		repoMetadata, err := getEventRepoMetadata(ctx, h.db, event)
+		if err != nil {
+			return err
+		}
+		for _, match := range event.Results {
+			fm, ok := match.(*result.FileMatch)
+			if !ok {
+				continue
+			}
+			// Don't compute results for files we cannot map to a repo the
+			// actor has access to.
+			if md, ok := repoMetadata[fm.Repo.ID]; !ok || md.Name != fm.Repo.Name {
+				continue
+			}
+
+			computeResult, err := computeQuery.Command.Run(ctx, fm)
+			if err != nil {
+				return err
+			}
+			if computeResult == nil {
+				// The command did not produce a result for this file.
+				continue
+			}
+			if err := eventWriter.Event("results", computeResultEvent{
+				Repository: string(fm.Repo.Name),
+				Commit:     string(fm.CommitID),
+				Path:       fm.Path,
+				Result:     computeResult,
+			}); err != nil {
+				// EOF
+				return err
+			}
+		}
+		return nil
+	}
+
+	for event := range events {
+		if err = handleEvent(event); err != nil {
+			// Stop the search and wait for it to shut down.
+			cancel()
+			for range events {
+			}
+			sendError(err)
+			return
+		}
+	}
+
+	if _, err = results(); err != nil {
+		sendError(err)
+	}
+}
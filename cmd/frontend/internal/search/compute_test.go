@@ -0,0 +1,126 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	api2 "github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestServeComputeStream(t *testing.T) {
+	database.Mocks.Repos.Metadata = func(ctx context.Context, ids ...api2.RepoID) (_ []*types.SearchedRepo, err error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			if id == 3 {
+				// The actor has no access to repo3.
+				continue
+			}
+			res = append(res, &types.SearchedRepo{
+				ID:   id,
+				Name: api2.RepoName(fmt.Sprintf("repo%d", id)),
+			})
+		}
+		return res, nil
+	}
+	defer func() { database.Mocks.Repos.Metadata = nil }()
+
+	mock := &mockSearchResolver{
+		done: make(chan struct{}),
+	}
+
+	var (
+		searchQuery   string
+		searchStarted sync.WaitGroup
+	)
+	searchStarted.Add(1)
+	ts := httptest.NewServer(&computeStreamHandler{
+		newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (searchResolver, error) {
+			defer searchStarted.Done()
+			mock.c = args.Stream
+			searchQuery = args.Query
+			return mock, nil
+		}})
+	defer ts.Close()
+
+	mkFileMatch := func(id int, lines ...string) *result.FileMatch {
+		fm := &result.FileMatch{File: result.File{
+			Repo: types.RepoName{ID: api2.RepoID(id), Name: api2.RepoName(fmt.Sprintf("repo%d", id))},
+			Path: "go.mod",
+		}}
+		for _, l := range lines {
+			fm.LineMatches = append(fm.LineMatches, &result.LineMatch{Preview: l})
+		}
+		return fm
+	}
+
+	var got []string
+	gotResult := make(chan struct{}, 1)
+	decoder := streamhttp.FrontendStreamDecoder{
+		OnUnknown: func(event, data []byte) {
+			if string(event) != "results" {
+				t.Errorf("unexpected event %q: %s", event, data)
+				return
+			}
+			var e struct {
+				Repository string
+				Result     struct{ Value string }
+			}
+			if err := json.Unmarshal(data, &e); err != nil {
+				t.Error(err)
+			}
+			got = append(got, e.Repository+": "+e.Result.Value)
+			gotResult <- struct{}{}
+		},
+	}
+
+	// Headers are only sent with the first event, so the request is made in
+	// the background.
+	g := errgroup.Group{}
+	g.Go(func() error {
+		resp, err := http.Get(ts.URL + "?q=" + url.QueryEscape(`content:output(v(\d+) -> $repo $1)`))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return decoder.ReadAll(resp.Body)
+	})
+	searchStarted.Wait()
+
+	// The result of a file is sent before the search is done.
+	mock.c.Send(streaming.SearchEvent{
+		Results: []result.Match{mkFileMatch(1, "v1", "v2"), mkFileMatch(3, "v3")},
+	})
+	<-gotResult
+	mock.c.Send(streaming.SearchEvent{
+		Results: []result.Match{mkFileMatch(2, "none"), mkFileMatch(2, "v4")},
+	})
+	mock.Close()
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `v(\d+)`; strings.TrimSpace(searchQuery) != want {
+		t.Errorf("got search query %q, want %q", searchQuery, want)
+	}
+	want := []string{"repo1: repo1 1\nrepo1 2", "repo2: repo2 4"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+}
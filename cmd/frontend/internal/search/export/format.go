@@ -0,0 +1,171 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// row is a single line of an exported file.
+type row struct {
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+	// Line is the 1-based line number of the match, or nil for matches that
+	// are not on a line of a file, such as repository and path matches.
+	Line    *int   `json:"line"`
+	Commit  string `json:"commit"`
+	Preview string `json:"preview"`
+}
+
+// rowWriter writes the rows of an exported file in a particular format.
+type rowWriter interface {
+	Write(r *row) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// newRowWriter returns a rowWriter writing format to w.
+func newRowWriter(format types.SearchExportFormat, w io.Writer) (rowWriter, error) {
+	switch format {
+	case types.SearchExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"repo", "path", "line", "commit", "preview"}); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case types.SearchExportFormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, errors.Errorf("unsupported search export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) Write(r *row) error {
+	var line string
+	if r.Line != nil {
+		line = strconv.Itoa(*r.Line)
+	}
+	return w.w.Write([]string{csvCell(r.Repo), csvCell(r.Path), line, csvCell(r.Commit), csvCell(r.Preview)})
+}
+
+// csvCell escapes s so that spreadsheet applications don't evaluate it as a
+// formula, by prefixing values which start like one with a single quote.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(r *row) error {
+	// Encode terminates every value with a newline.
+	return w.enc.Encode(r)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+// matchRows returns the rows for a search result. Content matches have a row
+// per matching line, symbol matches a row per symbol and commit matches a row
+// per highlighted line of the message or diff.
+func matchRows(match result.Match) []*row {
+	switch m := match.(type) {
+	case *result.FileMatch:
+		base := row{Repo: string(m.Repo.Name), Path: m.Path, Commit: string(m.CommitID)}
+		var rows []*row
+		for _, lm := range m.LineMatches {
+			r := base
+			r.Line = intPtr(int(lm.LineNumber) + 1)
+			r.Preview = lm.Preview
+			rows = append(rows, &r)
+		}
+		for _, sm := range m.Symbols {
+			r := base
+			r.Line = intPtr(sm.Symbol.Line)
+			r.Preview = sm.Symbol.Name
+			rows = append(rows, &r)
+		}
+		if len(rows) == 0 {
+			// A path match.
+			rows = append(rows, &base)
+		}
+		return rows
+
+	case *result.RepoMatch:
+		return []*row{{Repo: string(m.Name)}}
+
+	case *result.OwnerMatch:
+		return []*row{{Repo: string(m.Repo.Name), Commit: string(m.CommitID), Preview: m.Owner}}
+
+	case *result.CommitMatch:
+		base := row{Repo: string(m.Repo.Name), Commit: string(m.Commit.ID)}
+		preview := m.MessagePreview
+		if m.DiffPreview != nil {
+			preview = m.DiffPreview
+		}
+
+		var rows []*row
+		if preview != nil {
+			for _, line := range highlightedLines(preview) {
+				r := base
+				r.Preview = line
+				rows = append(rows, &r)
+			}
+		}
+		if len(rows) == 0 {
+			base.Preview = m.Commit.Message.Subject()
+			rows = append(rows, &base)
+		}
+		return rows
+	}
+	return nil
+}
+
+// highlightedLines returns the distinct lines of s which contain a highlight,
+// in order.
+func highlightedLines(s *result.HighlightedString) []string {
+	seen := make(map[int32]bool, len(s.Highlights))
+	var lineNumbers []int32
+	for _, h := range s.Highlights {
+		if !seen[h.Line] {
+			seen[h.Line] = true
+			lineNumbers = append(lineNumbers, h.Line)
+		}
+	}
+	sort.Slice(lineNumbers, func(i, j int) bool { return lineNumbers[i] < lineNumbers[j] })
+
+	lines := strings.Split(s.Value, "\n")
+	out := make([]string, 0, len(lineNumbers))
+	for _, n := range lineNumbers {
+		if n >= 0 && int(n) < len(lines) {
+			out = append(out, lines[n])
+		}
+	}
+	return out
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git/gitapi"
+)
+
+func TestMatchRows(t *testing.T) {
+	repo := types.RepoName{ID: 1, Name: "github.com/sourcegraph/sourcegraph"}
+	file := result.File{Repo: repo, CommitID: "deadbeef", Path: "go.mod"}
+
+	cases := []struct {
+		name  string
+		match result.Match
+		want  []*row
+	}{{
+		name: "content",
+		match: &result.FileMatch{
+			File: file,
+			LineMatches: []*result.LineMatch{
+				{Preview: "github.com/lib/pq v1.10.0", LineNumber: 4, OffsetAndLengths: [][2]int32{{0, 6}}},
+				{Preview: "github.com/lib/pq/oid v1.10.0", LineNumber: 9, OffsetAndLengths: [][2]int32{{0, 6}}},
+			},
+		},
+		want: []*row{
+			{Repo: string(repo.Name), Path: "go.mod", Line: intPtr(5), Commit: "deadbeef", Preview: "github.com/lib/pq v1.10.0"},
+			{Repo: string(repo.Name), Path: "go.mod", Line: intPtr(10), Commit: "deadbeef", Preview: "github.com/lib/pq/oid v1.10.0"},
+		},
+	}, {
+		name: "symbol",
+		match: &result.FileMatch{
+			File:    file,
+			Symbols: []*result.SymbolMatch{{Symbol: result.Symbol{Name: "Open", Line: 12}}},
+		},
+		want: []*row{
+			{Repo: string(repo.Name), Path: "go.mod", Line: intPtr(12), Commit: "deadbeef", Preview: "Open"},
+		},
+	}, {
+		name:  "path",
+		match: &result.FileMatch{File: file},
+		want: []*row{
+			{Repo: string(repo.Name), Path: "go.mod", Commit: "deadbeef"},
+		},
+	}, {
+		name:  "repo",
+		match: &result.RepoMatch{ID: 1, Name: repo.Name},
+		want: []*row{
+			{Repo: string(repo.Name)},
+		},
+	}, {
+		name: "diff",
+		match: &result.CommitMatch{
+			Repo:   repo,
+			Commit: gitapi.Commit{ID: "cafe", Message: "Add secret\n\nOops."},
+			DiffPreview: &result.HighlightedString{
+				Value: "config.yml config.yml\n@@ -1,1 +1,2 @@\n foo: bar\n+token: s3cr3t\n",
+				Highlights: []result.HighlightedRange{
+					{Line: 3, Character: 1, Length: 5},
+					{Line: 3, Character: 8, Length: 6},
+				},
+			},
+		},
+		want: []*row{
+			{Repo: string(repo.Name), Commit: "cafe", Preview: "+token: s3cr3t"},
+		},
+	}, {
+		name: "commit without highlights",
+		match: &result.CommitMatch{
+			Repo:   repo,
+			Commit: gitapi.Commit{ID: "cafe", Message: "Add secret\n\nOops."},
+		},
+		want: []*row{
+			{Repo: string(repo.Name), Commit: "cafe", Preview: "Add secret"},
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, matchRows(c.match)); diff != "" {
+				t.Fatalf("unexpected rows (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRowWriter(t *testing.T) {
+	rows := []*row{
+		{Repo: "github.com/foo/bar", Path: "a.go", Line: intPtr(3), Commit: "deadbeef", Preview: `fmt.Println("a, b")`},
+		{Repo: "github.com/foo/baz"},
+		{Repo: "github.com/foo/baz", Path: "@a.sh", Line: intPtr(1), Preview: `=HYPERLINK("http://example.com")`},
+		{Repo: "github.com/foo/baz", Path: "a.diff", Line: intPtr(2), Preview: "-1+1"},
+	}
+
+	cases := []struct {
+		format types.SearchExportFormat
+		want   string
+	}{{
+		format: types.SearchExportFormatCSV,
+		want: `repo,path,line,commit,preview
+github.com/foo/bar,a.go,3,deadbeef,"fmt.Println(""a, b"")"
+github.com/foo/baz,,,,
+github.com/foo/baz,'@a.sh,1,,"'=HYPERLINK(""http://example.com"")"
+github.com/foo/baz,a.diff,2,,'-1+1
+`,
+	}, {
+		format: types.SearchExportFormatJSONL,
+		want: `{"repo":"github.com/foo/bar","path":"a.go","line":3,"commit":"deadbeef","preview":"fmt.Println(\"a, b\")"}
+{"repo":"github.com/foo/baz","path":"","line":null,"commit":"","preview":""}
+{"repo":"github.com/foo/baz","path":"@a.sh","line":1,"commit":"","preview":"=HYPERLINK(\"http://example.com\")"}
+{"repo":"github.com/foo/baz","path":"a.diff","line":2,"commit":"","preview":"-1+1"}
+`,
+	}}
+
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := newRowWriter(c.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range rows {
+				if err := w.Write(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Fatalf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := newRowWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
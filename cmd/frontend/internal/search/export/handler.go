@@ -0,0 +1,82 @@
+package export
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// contentTypes are the content types of the exported files by format.
+var contentTypes = map[types.SearchExportFormat]string{
+	types.SearchExportFormatCSV:   "text/csv; charset=utf-8",
+	types.SearchExportFormatJSONL: "application/x-ndjson; charset=utf-8",
+}
+
+// DownloadHandler serves the exported files of completed search exports. The
+// route must have an "id" variable.
+func DownloadHandler(db dbutil.DB, exportStore Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "invalid search export ID", http.StatusBadRequest)
+			return
+		}
+
+		e, err := database.SearchExports(db).GetByID(ctx, id)
+		if err == database.ErrSearchExportNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// 🚨 SECURITY: Only the user who created the export and site admins may
+		// download it.
+		if err := backend.CheckSiteAdminOrSameUser(ctx, db, e.UserID); err != nil {
+			// Don't reveal that the export exists.
+			http.Error(w, database.ErrSearchExportNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		if e.State != types.SearchExportStateCompleted {
+			http.Error(w, fmt.Sprintf("search export is not completed, its state is %q", e.State), http.StatusConflict)
+			return
+		}
+
+		if e.ObjectKey == nil {
+			http.Error(w, "search export has no exported file", http.StatusInternalServerError)
+			return
+		}
+		rc, err := exportStore.Get(ctx, *e.ObjectKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer gz.Close()
+
+		w.Header().Set("Content-Type", contentTypes[e.Format])
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="search-export-%d.%s"`, e.ID, e.Format))
+		if _, err := io.Copy(w, gz); err != nil {
+			log15.Error("search export: failed to write exported file", "id", e.ID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// Store stores the gzip compressed exported files.
+type Store interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Upload(ctx context.Context, key string, r io.Reader) (int64, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// objectKey returns the key of the exported file of e.
+func objectKey(e *types.SearchExport) string {
+	return fmt.Sprintf("search-exports/%d.%s.gz", e.ID, e.Format)
+}
+
+// NewLocalStore returns a Store which keeps the exported files in dir. It is
+// used when no blob store is available. The files can only be downloaded from
+// the frontend which ran the export.
+func NewLocalStore(dir string) Store {
+	return &localStore{dir: dir}
+}
+
+type localStore struct {
+	dir string
+}
+
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s *localStore) Upload(ctx context.Context, key string, r io.Reader) (_ int64, err error) {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+
+	// Write to a temporary file first so that a failed upload never leaves a
+	// partial file behind.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(f.Name(), path)
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Package export runs exports of all results of a search query in the
+// background and serves the exported files.
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/keegancsmith/sqlf"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker"
+	dbworkerstore "github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker/store"
+)
+
+// retention is how long exported files are kept after the export finished.
+const retention = 7 * 24 * time.Hour
+
+// NewBackgroundRoutines returns the routines which run queued search exports,
+// reset stalled ones and delete old exports. The exported files are kept in
+// exportStore.
+func NewBackgroundRoutines(ctx context.Context, db dbutil.DB, exportStore Store) []goroutine.BackgroundRoutine {
+	observationContext := &observation.Context{
+		Logger:     log15.Root(),
+		Tracer:     &trace.Tracer{Tracer: opentracing.GlobalTracer()},
+		Registerer: prometheus.DefaultRegisterer,
+	}
+
+	// Change the isolation level for every transaction created by the worker
+	// so that multiple frontends can dequeue exports without conflicts.
+	handle := basestore.NewHandleWithDB(db, sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	store := dbworkerstore.New(handle, dbworkerstore.Options{
+		Name:              "search_exports_worker_store",
+		TableName:         "search_exports",
+		ColumnExpressions: database.SearchExportColumns,
+		Scan:              database.ScanSearchExport,
+		OrderByExpression: sqlf.Sprintf("search_exports.id"),
+		StalledMaxAge:     time.Minute,
+		MaxNumResets:      3,
+		RetryAfter:        time.Minute,
+		MaxNumRetries:     1,
+	})
+
+	worker := dbworker.NewWorker(ctx, store, &handler{
+		db:                db,
+		store:             exportStore,
+		newSearchResolver: graphqlbackend.NewSearchImplementer,
+	}, workerutil.WorkerOptions{
+		Name:              "search_exports_worker",
+		NumHandlers:       1,
+		Interval:          5 * time.Second,
+		HeartbeatInterval: 15 * time.Second,
+		Metrics:           workerutil.NewMetrics(observationContext, "search_exports", nil),
+	})
+
+	resetter := dbworker.NewResetter(store, dbworker.ResetterOptions{
+		Name:     "search_exports_worker_resetter",
+		Interval: time.Minute,
+		Metrics:  *dbworker.NewMetrics(observationContext, "search_exports"),
+	})
+
+	janitor := goroutine.NewPeriodicGoroutine(ctx, time.Hour, goroutine.NewHandlerWithErrorMessage(
+		"search_exports_janitor",
+		func(ctx context.Context) error {
+			keys, err := database.SearchExports(db).DeleteFinishedBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := exportStore.Delete(ctx, key); err != nil {
+					log15.Error("search export: failed to delete exported file", "key", key, "error", err)
+				}
+			}
+			return nil
+		},
+	))
+
+	return []goroutine.BackgroundRoutine{worker, resetter, janitor}
+}
+
+type handler struct {
+	db                dbutil.DB
+	store             Store
+	newSearchResolver func(context.Context, dbutil.DB, *graphqlbackend.SearchArgs) (graphqlbackend.SearchImplementer, error)
+}
+
+var _ workerutil.Handler = &handler{}
+
+func (h *handler) Handle(ctx context.Context, record workerutil.Record) error {
+	e, ok := record.(*types.SearchExport)
+	if !ok {
+		return errors.Errorf("unexpected record type %T", record)
+	}
+
+	// 🚨 SECURITY: The search must only return results the user who created
+	// the export has access to.
+	ctx = actor.WithActor(ctx, actor.FromUser(e.UserID))
+
+	key := objectKey(e)
+	resultCount, err := h.export(ctx, e, key)
+	if err != nil {
+		return err
+	}
+	if err := database.SearchExports(h.db).SetResult(ctx, e.ID, resultCount, key); err != nil {
+		h.deleteObject(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// export runs the search of e, streams the gzip compressed exported file to
+// the store at key and returns its number of rows. The export fails if the
+// search did not return all results, so that an export is never silently
+// incomplete.
+func (h *handler) export(ctx context.Context, e *types.SearchExport, key string) (int32, error) {
+	pr, pw := io.Pipe()
+	uploadErrs := make(chan error, 1)
+	go func() {
+		_, err := h.store.Upload(ctx, key, pr)
+		// Unblock writes to the pipe if the upload stops reading early.
+		pr.CloseWithError(err)
+		uploadErrs <- err
+	}()
+
+	resultCount, err := h.writeResults(ctx, e, pw)
+	// Closing the pipe with an error aborts the upload.
+	pw.CloseWithError(err)
+	if uploadErr := <-uploadErrs; err == nil && uploadErr != nil {
+		err = errors.Wrap(uploadErr, "uploading exported file")
+	}
+	if err != nil {
+		h.deleteObject(ctx, key)
+		return 0, err
+	}
+	return resultCount, nil
+}
+
+// writeResults runs the search of e and writes the gzip compressed exported
+// file to out.
+func (h *handler) writeResults(ctx context.Context, e *types.SearchExport, out io.Writer) (int32, error) {
+	gz := gzip.NewWriter(out)
+	w, err := newRowWriter(e.Format, gz)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu          sync.Mutex
+		resultCount int32
+		writeErr    error
+	)
+	stream := streaming.StreamFunc(func(event streaming.SearchEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, match := range event.Results {
+			for _, r := range matchRows(match) {
+				if writeErr != nil {
+					return
+				}
+				writeErr = w.Write(r)
+				resultCount++
+			}
+		}
+	})
+
+	patternType := e.PatternType
+	search, err := h.newSearchResolver(ctx, h.db, &graphqlbackend.SearchArgs{
+		Query:       e.Query,
+		Version:     "V2",
+		PatternType: &patternType,
+		Exhaustive:  true,
+		Stream:      stream,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	results, err := search.Results(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "running search")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if writeErr != nil {
+		return 0, errors.Wrap(writeErr, "writing results")
+	}
+	if alert := results.Alert(); alert != nil {
+		// The search returns a timeout alert even if some results were
+		// streamed before it timed out.
+		if alert.PrometheusType() == "timed_out" {
+			return 0, errors.New("the search timed out. The search of an export is limited by the site configuration value search.limits.maxTimeoutSeconds")
+		}
+		// Alerts for queries without results explain why the query could not
+		// run, e.g. because it is invalid.
+		if resultCount == 0 {
+			return 0, errors.New(alert.Title())
+		}
+	}
+	if err := checkComplete(&results.Stats); err != nil {
+		return 0, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return resultCount, nil
+}
+
+// checkComplete returns an error if the search did not return all results.
+func checkComplete(stats *streaming.Stats) error {
+	timedout := 0
+	stats.Status.Filter(search.RepoStatusTimedout, func(api.RepoID) { timedout++ })
+	if timedout > 0 {
+		return errors.Errorf("the search timed out in %d repositories. The search of an export is limited by the site configuration value search.limits.maxTimeoutSeconds", timedout)
+	}
+	if stats.IsIndexUnavailable {
+		return errors.New("the search index is unavailable")
+	}
+	if stats.IsLimitHit {
+		return errors.New("the search hit a result limit, so not all results were returned")
+	}
+	return nil
+}
+
+// deleteObject deletes the possibly partially uploaded exported file at key.
+func (h *handler) deleteObject(ctx context.Context, key string) {
+	if err := h.store.Delete(ctx, key); err != nil {
+		log15.Error("search export: failed to delete exported file", "key", key, "error", err)
+	}
+}
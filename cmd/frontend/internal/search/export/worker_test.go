@@ -0,0 +1,95 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type fakeSearch struct {
+	graphqlbackend.SearchImplementer
+	stream  streaming.Sender
+	matches []result.Match
+	stats   streaming.Stats
+}
+
+func (s *fakeSearch) Results(context.Context) (*graphqlbackend.SearchResultsResolver, error) {
+	s.stream.Send(streaming.SearchEvent{Results: s.matches})
+	return &graphqlbackend.SearchResultsResolver{
+		SearchResults: &graphqlbackend.SearchResults{Matches: s.matches, Stats: s.stats},
+	}, nil
+}
+
+func TestHandlerExport(t *testing.T) {
+	matches := []result.Match{
+		&result.RepoMatch{Name: "github.com/sourcegraph/sourcegraph", ID: 1},
+		&result.RepoMatch{Name: "github.com/sourcegraph/zoekt", ID: 2},
+	}
+	e := &types.SearchExport{ID: 1, Query: "repo:sourcegraph", PatternType: "literal", Format: types.SearchExportFormatJSONL}
+
+	newHandler := func(t *testing.T, stats streaming.Stats) (*handler, string) {
+		dir := t.TempDir()
+		return &handler{
+			store: NewLocalStore(dir),
+			newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (graphqlbackend.SearchImplementer, error) {
+				return &fakeSearch{stream: args.Stream, matches: matches, stats: stats}, nil
+			},
+		}, dir
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		h, dir := newHandler(t, streaming.Stats{})
+		resultCount, err := h.export(context.Background(), e, objectKey(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resultCount != 2 {
+			t.Errorf("got result count %d, want 2", resultCount)
+		}
+
+		f, err := os.Open(filepath.Join(dir, "search-exports", "1.jsonl.gz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"repo":"github.com/sourcegraph/sourcegraph","path":"","line":null,"commit":"","preview":""}
+{"repo":"github.com/sourcegraph/zoekt","path":"","line":null,"commit":"","preview":""}
+`
+		if string(data) != want {
+			t.Errorf("got exported file\n%s\nwant\n%s", data, want)
+		}
+	})
+
+	t.Run("limit hit", func(t *testing.T) {
+		h, dir := newHandler(t, streaming.Stats{IsLimitHit: true})
+		if _, err := h.export(context.Background(), e, objectKey(e)); err == nil {
+			t.Fatal("expected an error for an incomplete search")
+		}
+
+		// The partially uploaded file must be deleted.
+		files, err := filepath.Glob(filepath.Join(dir, "search-exports", "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 0 {
+			t.Errorf("got files %v, want none", files)
+		}
+	})
+}
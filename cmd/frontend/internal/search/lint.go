@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	searchshared "github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming/api"
+)
+
+// maxSimilarRepos is the number of repositories with a name similar to a
+// repo: filter we consider for suggestions.
+const maxSimilarRepos = 20
+
+// lintPlan returns the likely mistakes in the query plan which can be found
+// without looking at the repositories to search.
+func lintPlan(plan query.Plan) []api.Skipped {
+	var lints []query.Lint
+	for _, b := range plan {
+		lints = append(lints, query.LintBasic(b)...)
+	}
+	return lintsToSkipped(lints)
+}
+
+// lintRepos returns suggestions for the repo: filters of the query plan which
+// match no repository. It is only worth calling if the search found nothing.
+func lintRepos(ctx context.Context, db dbutil.DB, plan query.Plan) []api.Skipped {
+	var lints []query.Lint
+	seen := map[string]struct{}{}
+	for _, b := range plan {
+		b.VisitParameter(query.FieldRepo, func(value string, negated bool, annotation query.Annotation) {
+			if _, ok := seen[value]; ok || negated || annotation.Labels.IsSet(query.IsPredicate) {
+				return
+			}
+			seen[value] = struct{}{}
+
+			l, ok, err := lintRepo(ctx, db, value)
+			if err != nil {
+				log15.Warn("failed to suggest repositories", "filter", value, "error", err)
+				return
+			}
+			if ok {
+				lints = append(lints, l)
+			}
+		})
+	}
+	return lintsToSkipped(lints)
+}
+
+func lintRepo(ctx context.Context, db dbutil.DB, value string) (query.Lint, bool, error) {
+	literal := query.RepoFilterLiteral(value)
+	if literal == "" {
+		return query.Lint{}, false, nil
+	}
+
+	repoPattern, _ := searchshared.ParseRepositoryRevisions(value)
+	matching, err := database.Repos(db).ListRepoNames(ctx, database.ReposListOptions{
+		IncludePatterns: []string{repoPattern},
+		LimitOffset:     &database.LimitOffset{Limit: 1},
+	})
+	if err != nil || len(matching) > 0 {
+		return query.Lint{}, false, err
+	}
+
+	similar, err := database.Repos(db).ListRepoNames(ctx, database.ReposListOptions{
+		SimilarTo:   literal,
+		LimitOffset: &database.LimitOffset{Limit: maxSimilarRepos},
+	})
+	if err != nil {
+		return query.Lint{}, false, err
+	}
+	names := make([]string, 0, len(similar))
+	for _, r := range similar {
+		names = append(names, string(r.Name))
+	}
+
+	l, ok := query.LintRepo(value, names)
+	return l, ok, nil
+}
+
+func lintsToSkipped(lints []query.Lint) []api.Skipped {
+	skipped := make([]api.Skipped, 0, len(lints))
+	for _, l := range lints {
+		sk := api.Skipped{
+			Title:   l.Title,
+			Message: l.Message,
+			Suggested: &api.SkippedSuggested{
+				Title:           l.Title,
+				QueryExpression: l.Suggested,
+			},
+		}
+		switch l.Kind {
+		case query.LintUnknownField:
+			sk.Reason, sk.Severity = api.QueryUnknownField, api.SeverityWarn
+		case query.LintRegexpInLiteral:
+			sk.Reason, sk.Severity = api.QueryRegexpInLiteral, api.SeverityInfo
+		case query.LintRepoNoMatch:
+			sk.Reason, sk.Severity = api.QueryRepoNoMatch, api.SeverityWarn
+		default:
+			continue
+		}
+		skipped = append(skipped, sk)
+	}
+	return skipped
+}
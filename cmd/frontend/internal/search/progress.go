@@ -18,6 +18,9 @@ type progressAggregator struct {
 	DisplayLimit int
 	Trace        string // may be empty
 
+	// Suggestions are likely mistakes in the query with suggested fixes.
+	Suggestions []api.Skipped
+
 	// Dirty is true if p has changed since the last call to Current.
 	Dirty bool
 }
@@ -55,6 +58,7 @@ func (p *progressAggregator) currentStats() api.ProgressStats {
 		SuggestedLimit:      suggestedLimit,
 		Trace:               p.Trace,
 		DisplayLimit:        p.DisplayLimit,
+		Suggestions:         p.Suggestions,
 	}
 }
 
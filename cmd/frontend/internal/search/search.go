@@ -85,6 +85,19 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	events, inputs, results := h.startSearch(ctx, args)
 	events = batchEvents(events, 50*time.Millisecond)
 
+	// In aggregation mode we count all matches instead of sending them.
+	var aggregation *streaming.SearchAggregation
+	if args.AggregationMode != "" {
+		aggregation, err = streaming.NewSearchAggregation(args.AggregationMode, args.AggregationGroup, inputs.Query)
+		if err != nil {
+			cancel()
+			for range events {
+			}
+			_ = eventWriter.Event("error", streamhttp.EventError{Message: err.Error()})
+			return
+		}
+	}
+
 	// Display is the number of results we send down. If display is < 0 we
 	// want to send everything we find before hitting a limit. Otherwise we
 	// can only send up to limit results.
@@ -105,6 +118,7 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Limit:        inputs.MaxResults(),
 		Trace:        trace.URL(trace.ID(ctx)),
 		DisplayLimit: displayLimit,
+		Suggestions:  lintPlan(inputs.Plan),
 	}
 
 	sendProgress := func() {
@@ -140,6 +154,12 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	first := true
 	handleEvent := func(event streaming.SearchEvent) {
 		progress.Update(event)
+
+		if aggregation != nil {
+			aggregation.Update(accessibleMatches(ctx, h.db, event))
+			return
+		}
+
 		filters.Update(event)
 
 		// Truncate the event to the match limit before fetching repo metadata
@@ -201,8 +221,27 @@ LOOP:
 
 	matchesFlush()
 
-	// Send dynamic filters once.
-	if filters := filters.Compute(); len(filters) > 0 {
+	if aggregation != nil {
+		groups := aggregation.Compute()
+		buf := make([]streamhttp.EventAggregationGroup, 0, len(groups))
+		for _, g := range groups {
+			buf = append(buf, streamhttp.EventAggregationGroup{
+				Label:      g.Label,
+				Repository: g.Repository,
+				Count:      g.Count,
+			})
+		}
+
+		if err := eventWriter.Event("aggregations", streamhttp.EventAggregation{
+			Mode:     string(aggregation.Mode),
+			Groups:   buf,
+			LimitHit: aggregation.LimitHit(),
+		}); err != nil {
+			// EOF
+			return
+		}
+	} else if filters := filters.Compute(); len(filters) > 0 {
+		// Send dynamic filters once.
 		buf := make([]streamhttp.EventFilter, 0, len(filters))
 		for _, f := range filters {
 			buf = append(buf, streamhttp.EventFilter{
@@ -244,6 +283,12 @@ LOOP:
 		})
 	}
 
+	// Suggesting repositories requires looking them up, so we only do it if
+	// there is nothing else to show.
+	if progress.MatchCount == 0 {
+		progress.Suggestions = append(progress.Suggestions, lintRepos(ctx, h.db, inputs.Plan)...)
+	}
+
 	_ = eventWriter.Event("progress", progress.Final())
 
 	var status, alertType string
@@ -285,6 +330,7 @@ func (h *streamHandler) startSearch(ctx context.Context, a *args) (events <-chan
 		Query:       a.Query,
 		Version:     a.Version,
 		PatternType: strPtr(a.PatternType),
+		Exhaustive:  a.AggregationMode != "",
 
 		Stream: streaming.StreamFunc(func(event streaming.SearchEvent) {
 			eventsC <- event
@@ -337,6 +383,11 @@ type args struct {
 	DecorationLimit        int    // The initial number of files to decorate in the result set.
 	DecorationKind         string // The kind of decoration to apply (HTML highlighting, plaintext, etc.)
 	DecorationContextLines int    // The number of lines of context to include around lines with matches.
+
+	// Optional aggregation parameters. If set, all matches are counted and
+	// grouped by AggregationMode instead of being sent to the client.
+	AggregationMode  streaming.AggregationMode
+	AggregationGroup string // The name of the capture group for the "capture" mode.
 }
 
 func parseURLQuery(q url.Values) (*args, error) {
@@ -375,9 +426,35 @@ func parseURLQuery(q url.Values) (*args, error) {
 		return nil, errors.Errorf("decorationContextLines must be an integer, got %q: %w", decorationContextLines, err)
 	}
 
+	if aggregate := get("aggregate", ""); aggregate != "" {
+		if a.AggregationMode, a.AggregationGroup, err = streaming.ParseAggregation(aggregate); err != nil {
+			return nil, err
+		}
+	}
+
 	return &a, nil
 }
 
+// accessibleMatches returns event with only the matches in repositories the
+// actor has access to.
+func accessibleMatches(ctx context.Context, db dbutil.DB, event streaming.SearchEvent) streaming.SearchEvent {
+	repoMetadata, err := getEventRepoMetadata(ctx, db, event)
+	if err != nil {
+		log15.Error("failed to get repo metadata", "error", err)
+		event.Results = nil
+		return event
+	}
+
+	matches := make([]result.Match, 0, len(event.Results))
+	for _, match := range event.Results {
+		if md, ok := repoMetadata[match.RepoName().ID]; ok && md.Name == match.RepoName().Name {
+			matches = append(matches, match)
+		}
+	}
+	event.Results = matches
+	return event
+}
+
 func strPtr(s string) *string {
 	if s == "" {
 		return nil
@@ -419,6 +496,8 @@ func fromMatch(match result.Match, repoCache map[api.RepoID]*types.SearchedRepo)
 		return fromRepository(v, repoCache)
 	case *result.CommitMatch:
 		return fromCommit(v, repoCache)
+	case *result.OwnerMatch:
+		return fromOwner(v, repoCache)
 	default:
 		panic(fmt.Sprintf("unknown match type %T", v))
 	}
@@ -575,6 +654,23 @@ func fromCommit(commit *result.CommitMatch, repoCache map[api.RepoID]*types.Sear
 	return commitEvent
 }
 
+func fromOwner(om *result.OwnerMatch, repoCache map[api.RepoID]*types.SearchedRepo) *streamhttp.EventOwnerMatch {
+	ownerEvent := &streamhttp.EventOwnerMatch{
+		Type:         streamhttp.OwnerMatchType,
+		Owner:        om.Owner,
+		RepositoryID: int32(om.Repo.ID),
+		Repository:   string(om.Repo.Name),
+		Commit:       string(om.CommitID),
+	}
+
+	if r, ok := repoCache[om.Repo.ID]; ok {
+		ownerEvent.RepoStars = r.Stars
+		ownerEvent.RepoLastFetched = r.LastFetched
+	}
+
+	return ownerEvent
+}
+
 // eventStreamOTHook returns a StatHook which logs to log.
 func eventStreamOTHook(log func(...otlog.Field)) func(streamhttp.WriterStat) {
 	return func(stat streamhttp.WriterStat) {
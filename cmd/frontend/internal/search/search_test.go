@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
@@ -212,6 +213,130 @@ func TestDisplayLimit(t *testing.T) {
 	}
 }
 
+func TestServeStream_aggregation(t *testing.T) {
+	database.Mocks.Repos.Metadata = func(ctx context.Context, ids ...api2.RepoID) (_ []*types.SearchedRepo, err error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			if id == 3 {
+				// The actor has no access to repo3.
+				continue
+			}
+			res = append(res, &types.SearchedRepo{
+				ID:   id,
+				Name: api2.RepoName(fmt.Sprintf("repo%d", id)),
+			})
+		}
+		return res, nil
+	}
+	defer func() { database.Mocks.Repos.Metadata = nil }()
+
+	cases := []struct {
+		name      string
+		aggregate string
+		want      *streamhttp.EventAggregation
+		wantError string
+	}{{
+		name:      "repo",
+		aggregate: "repo",
+		want: &streamhttp.EventAggregation{
+			Mode: "repo",
+			Groups: []streamhttp.EventAggregationGroup{
+				{Label: "repo1", Count: 2},
+				{Label: "repo2", Count: 1},
+			},
+		},
+	}, {
+		name:      "capture group not in query",
+		aggregate: "capture:version",
+		wantError: `no regular expression pattern in the query has a capture group named "version"`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mock := &mockSearchResolver{
+				done: make(chan struct{}),
+			}
+
+			var exhaustive bool
+			ts := httptest.NewServer(&streamHandler{
+				flushTickerInternal: 1 * time.Millisecond,
+				pingTickerInterval:  1 * time.Millisecond,
+				newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (searchResolver, error) {
+					mock.c = args.Stream
+					exhaustive = args.Exhaustive
+					q, err := query.Parse("foo", query.SearchTypeLiteral)
+					if err != nil {
+						t.Fatal(err)
+					}
+					mock.inputs = &run.SearchInputs{
+						Query: q,
+					}
+					return mock, nil
+				}})
+			defer ts.Close()
+
+			req, _ := streamhttp.NewRequest(ts.URL, "foo")
+			q := req.URL.Query()
+			q.Add("aggregate", c.aggregate)
+			req.URL.RawQuery = q.Encode()
+
+			var (
+				got        *streamhttp.EventAggregation
+				gotError   string
+				gotMatches bool
+			)
+			decoder := streamhttp.FrontendStreamDecoder{
+				OnMatches: func([]streamhttp.EventMatch) {
+					gotMatches = true
+				},
+				OnAggregations: func(a *streamhttp.EventAggregation) {
+					got = a
+				},
+				OnError: func(e *streamhttp.EventError) {
+					gotError = e.Message
+				},
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			g := errgroup.Group{}
+			g.Go(func() error {
+				return decoder.ReadAll(resp.Body)
+			})
+
+			if c.wantError == "" {
+				mock.c.Send(streaming.SearchEvent{
+					Results: []result.Match{mkRepoMatch(1), mkRepoMatch(2), mkRepoMatch(3)},
+				})
+				mock.c.Send(streaming.SearchEvent{
+					Results: []result.Match{mkRepoMatch(1)},
+				})
+			}
+			mock.Close()
+			if err := g.Wait(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !exhaustive {
+				t.Error("expected an exhaustive search")
+			}
+			if gotMatches {
+				t.Error("unexpected matches event")
+			}
+			if gotError != c.wantError {
+				t.Fatalf("got error %q, want %q", gotError, c.wantError)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Fatalf("unexpected aggregation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func mkRepoMatch(id int) *result.RepoMatch {
 	return &result.RepoMatch{
 		ID:   api2.RepoID(id),
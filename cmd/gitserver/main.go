@@ -31,7 +31,11 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages/registry"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/backup"
 	"github.com/sourcegraph/sourcegraph/internal/hostname"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/internal/logging"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
@@ -41,6 +45,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/tracer"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -56,6 +61,9 @@ var (
 func main() {
 	ctx := context.Background()
 
+	backupConfig := &backup.Config{}
+	backupConfig.Load()
+
 	env.Lock()
 	env.HandleHelpFlag()
 
@@ -69,6 +77,10 @@ func main() {
 	sentry.Init()
 	trace.Init()
 
+	if err := backupConfig.Validate(); err != nil {
+		log.Fatalf("failed to load backup config: %s", err)
+	}
+
 	if reposDir == "" {
 		log.Fatal("git-server: SRC_REPOS_DIR is required")
 	}
@@ -99,6 +111,11 @@ func main() {
 		log.Fatalf("failed to initialise keyring: %s", err)
 	}
 
+	backups, err := backup.NewStore(ctx, backupConfig)
+	if err != nil {
+		log.Fatalf("failed to initialize backup store: %s", err)
+	}
+
 	gitserver := server.Server{
 		ReposDir:           reposDir,
 		DesiredPercentFree: wantPctFree2,
@@ -133,21 +150,8 @@ func main() {
 			case extsvc.TypePerforce:
 				// Extract options from external service config
 				var c schema.PerforceConnection
-				for _, info := range r.Sources {
-					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
-					if err != nil {
-						return nil, errors.Wrap(err, "get external service")
-					}
-
-					normalized, err := jsonc.Parse(es.Config)
-					if err != nil {
-						return nil, errors.Wrap(err, "normalize JSON")
-					}
-
-					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
-						return nil, errors.Wrap(err, "unmarshal JSON")
-					}
-					break
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
 				}
 
 				return &server.PerforceDepotSyncer{
@@ -157,29 +161,63 @@ func main() {
 				}, nil
 			case extsvc.TypeJVMPackages:
 				var c schema.JVMPackagesConnection
-				for _, info := range r.Sources {
-					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
-					if err != nil {
-						return nil, errors.Wrap(err, "get external service")
-					}
-
-					normalized, err := jsonc.Parse(es.Config)
-					if err != nil {
-						return nil, errors.Wrap(err, "normalize JSON")
-					}
-
-					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
-						return nil, errors.Wrap(err, "unmarshal JSON")
-					}
-					break
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
 				}
 
 				return &server.JVMPackagesSyncer{Config: &c, DBStore: codeintelDB}, nil
+			case extsvc.TypeNpmPackages:
+				var c schema.NpmPackagesConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return &server.NpmPackagesSyncer{
+					Config:  &c,
+					DBStore: codeintelDB,
+					Client:  npm.NewHTTPClient(c.Registry, c.Credentials, httpcli.ExternalDoer),
+				}, nil
+			case extsvc.TypeRustPackages:
+				var c schema.RustPackagesConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return newRegistryPackagesSyncer("cargo", c.Registry, c.Dependencies, codeintelDB), nil
+			case extsvc.TypePythonPackages:
+				var c schema.PythonPackagesConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return newRegistryPackagesSyncer("pypi", c.Registry, c.Dependencies, codeintelDB), nil
+			case extsvc.TypeRubyPackages:
+				var c schema.RubyPackagesConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return newRegistryPackagesSyncer("gem", c.Registry, c.Dependencies, codeintelDB), nil
+			case extsvc.TypeSubversion:
+				var c schema.SubversionConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return &server.SubversionRepoSyncer{Layout: c.Layout, Authors: c.Authors}, nil
+			case extsvc.TypeMercurial:
+				var c schema.MercurialConnection
+				if err := unmarshalSourceConfig(ctx, externalServiceStore, r, &c); err != nil {
+					return nil, err
+				}
+
+				return &server.MercurialRepoSyncer{Authors: c.Authors}, nil
 			}
 			return &server.GitRepoSyncer{}, nil
 		},
 		Hostname:   hostname.Get(),
 		DB:         db,
+		Backups:    backups,
 		CloneQueue: server.NewCloneQueue(list.New()),
 	}
 	gitserver.RegisterMetrics()
@@ -197,12 +235,19 @@ func main() {
 	// TODO: Why do we set server state as a side effect of creating our handler?
 	handler := ot.Middleware(trace.HTTPTraceMiddleware(gitserver.Handler()))
 
+	// "gitserver restore" restores the repos of this gitserver from their
+	// backups and exits instead of serving requests.
+	if len(os.Args) >= 2 && os.Args[1] == "restore" {
+		os.Exit(restore(ctx, &gitserver))
+	}
+
 	// Ready immediately
 	ready := make(chan struct{})
 	close(ready)
 	go debugserver.NewServerRoutine(ready).Start()
 	go gitserver.Janitor(janitorInterval)
 	go gitserver.SyncRepoState(syncRepoStateInterval, syncRepoStateBatchSize, syncRepoStateUpsertPerSecond)
+	go gitserver.Backup(backupConfig.Interval)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -247,6 +292,58 @@ func main() {
 	gitserver.Stop()
 }
 
+// restore restores the repos which belong on this gitserver from their
+// backups and returns the exit code of the restore subcommand.
+func restore(ctx context.Context, gitserver *server.Server) int {
+	if gitserver.Backups == nil {
+		log.Print("git-server: restore requires SRC_GITSERVER_BACKUP_BACKEND to be set")
+		return 1
+	}
+
+	restored, failed, err := gitserver.RestoreShard(ctx)
+	if err != nil {
+		log.Printf("git-server: restore failed: %s", err)
+		return 1
+	}
+
+	log15.Info("git-server: restore finished", "restored", restored, "failed", failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// unmarshalSourceConfig unmarshals the config of the first external service
+// the repo is a source of into v.
+func unmarshalSourceConfig(ctx context.Context, store *database.ExternalServiceStore, r *types.Repo, v interface{}) error {
+	for _, info := range r.Sources {
+		es, err := store.GetByID(ctx, info.ExternalServiceID())
+		if err != nil {
+			return errors.Wrap(err, "get external service")
+		}
+
+		normalized, err := jsonc.Parse(es.Config)
+		if err != nil {
+			return errors.Wrap(err, "normalize JSON")
+		}
+
+		if err = jsoniter.Unmarshal(normalized, v); err != nil {
+			return errors.Wrap(err, "unmarshal JSON")
+		}
+		break
+	}
+	return nil
+}
+
+func newRegistryPackagesSyncer(scheme, registryURL string, dependencies []string, dbStore repos.RegistryPackagesRepoStore) *server.RegistryPackagesSyncer {
+	return &server.RegistryPackagesSyncer{
+		Scheme:       scheme,
+		Dependencies: dependencies,
+		DBStore:      dbStore,
+		Client:       registry.NewHTTPClient(scheme, registryURL, httpcli.ExternalDoer),
+	}
+}
+
 func configureFusionClient(conn schema.PerforceConnection) server.FusionConfig {
 	// Set up default settings first
 	fc := server.FusionConfig{
@@ -0,0 +1,644 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/backup"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// maxIncrementalBundles is the number of bundles a backup may consist of.
+// Once it is reached the next backup of the repo is a full bundle, which
+// replaces the existing bundles. This bounds the work needed to restore a
+// repo and the space taken up by objects which are no longer reachable.
+const maxIncrementalBundles = 30
+
+// The possible outcomes of backing up a repo.
+const (
+	backupFull      = "full"
+	backupIncrement = "incremental"
+	backupUnchanged = "unchanged"
+	backupFailed    = "failed"
+)
+
+var (
+	backupReposTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_backup_repos_total",
+		Help: "Number of repos backed up, by whether a full or incremental bundle was written.",
+	}, []string{"result"})
+	backupBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_backup_bytes_total",
+		Help: "Number of bytes of git bundles written to the backup store.",
+	})
+	backupRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_backup_running",
+		Help: "Set to 1 when the gitserver backup is running.",
+	})
+	restoreReposTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_restore_repos_total",
+		Help: "Number of repos gitserver attempted to restore from a backup, by whether it succeeded.",
+	}, []string{"success"})
+)
+
+// Backup writes a backup of every repo in s.ReposDir to s.Backups and is
+// expected to run in a background goroutine. It does nothing if s.Backups is
+// nil.
+func (s *Server) Backup(interval time.Duration) {
+	if s.Backups == nil {
+		return
+	}
+
+	for {
+		s.backupRepos()
+		time.Sleep(interval)
+	}
+}
+
+func (s *Server) backupRepos() {
+	backupRunning.Set(1)
+	defer backupRunning.Set(0)
+
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	dirs, err := s.findGitDirs()
+	if err != nil {
+		log15.Error("backup: error iterating over repositories", "error", err)
+		return
+	}
+
+	addrs := conf.Get().ServiceConnections.GitServers
+
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Only back up repos which belong on this gitserver. Stale copies of
+		// repos which moved to another gitserver, eg: after the number of
+		// gitservers changed, are backed up there.
+		repo := s.name(dir)
+		if len(addrs) > 0 && !s.hostnameMatch(gitserver.AddrForRepo(repo, addrs)) {
+			continue
+		}
+
+		result, err := s.backupRepo(ctx, repo, dir)
+		if err != nil {
+			log15.Error("backup: failed to back up repo", "repo", repo, "error", err)
+			result = backupFailed
+		}
+		backupReposTotal.WithLabelValues(result).Inc()
+
+		s.setLastBackupNonFatal(ctx, repo, time.Now(), err)
+	}
+}
+
+// backupRepo brings the backup of repo up to date with its clone in dir. If
+// the refs changed since the last backup, it writes a bundle with the
+// objects which were added since then.
+func (s *Server) backupRepo(ctx context.Context, repo api.RepoName, dir GitDir) (result string, err error) {
+	m, err := backup.ReadManifest(ctx, s.Backups, repo)
+	if backup.IsNotFound(err) {
+		m = &backup.Manifest{Repo: repo}
+	} else if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := s.tempDir("backup-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	bundlePath := filepath.Join(tmpDir, "repo.bundle")
+
+	full := len(m.Bundles) == 0 || len(m.Bundles) >= maxIncrementalBundles
+	snap, err := s.snapshotRepo(ctx, repo, dir, m, full, bundlePath)
+	if err != nil {
+		return "", err
+	}
+	if snap == nil {
+		return backupUnchanged, nil
+	}
+
+	now := time.Now().UTC()
+	staleBundles := m.Bundles
+	if full {
+		m.Bundles = nil
+	} else {
+		staleBundles = nil
+	}
+
+	// When no objects were added, for example because branches were only
+	// deleted, there is no bundle to write and the manifest alone records
+	// the new refs.
+	var b *backup.Bundle
+	if snap.created {
+		bundle, err := s.putBundle(ctx, repo, bundlePath, now)
+		if err != nil {
+			return "", err
+		}
+		b = &bundle
+		m.Bundles = append(m.Bundles, bundle)
+	}
+
+	// The repo may have been deleted while we uploaded the bundle, in which
+	// case writing the manifest would bring its backup back.
+	if !repoCloned(dir) {
+		if b != nil {
+			if err := s.Backups.Delete(ctx, b.Key); err != nil {
+				log15.Warn("backup: failed to delete bundle of deleted repo", "repo", repo, "key", b.Key, "error", err)
+			}
+		}
+		return "", errors.New("repo was deleted during backup")
+	}
+
+	m.Type = snap.repoType
+	m.Head = snap.head
+	m.Refs = snap.refs
+	m.RefHash = snap.refHash
+	m.UpdatedAt = now
+
+	if err := backup.WriteManifest(ctx, s.Backups, m); err != nil {
+		return "", errors.Wrap(err, "writing backup manifest")
+	}
+
+	// The bundles replaced by a full bundle are no longer referenced by the
+	// manifest.
+	for _, b := range staleBundles {
+		if err := s.Backups.Delete(ctx, b.Key); err != nil {
+			log15.Warn("backup: failed to delete stale bundle", "repo", repo, "key", b.Key, "error", err)
+		}
+	}
+
+	if full {
+		return backupFull, nil
+	}
+	return backupIncrement, nil
+}
+
+// repoSnapshot is the state of a repo at the time it was bundled for a
+// backup.
+type repoSnapshot struct {
+	refHash  string
+	refs     map[string]string
+	repoType string
+	head     string
+
+	// created is false if there were no objects to bundle.
+	created bool
+}
+
+// snapshotRepo reads the refs of the clone of repo in dir and writes a bundle
+// of it to bundlePath, which only has the objects added since the backup
+// described by m unless full is true. It returns nil if the refs did not
+// change since the backup.
+func (s *Server) snapshotRepo(ctx context.Context, repo api.RepoName, dir GitDir, m *backup.Manifest, full bool, bundlePath string) (*repoSnapshot, error) {
+	// Updates change the refs while we read them, so we wait for any running
+	// update of the repo and prevent new ones until the bundle is written.
+	// Uploading it can take much longer and only needs the bundle.
+	s.repoUpdateLocksMu.Lock()
+	mu := s.repoUpdateLocksFor(repo).mu
+	s.repoUpdateLocksMu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	refHash, err := computeRefHash(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "computing ref hash")
+	}
+	if !m.UpdatedAt.IsZero() && m.RefHash == string(refHash) {
+		return nil, nil
+	}
+
+	refs, err := showRefs(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var prerequisites []string
+	if !full {
+		// Objects which were reachable from the refs at the time of the last
+		// backup are already in the backup. They may have been removed from
+		// the repo since if the refs were rewritten, in which case git would
+		// refuse to create the bundle.
+		prerequisites, err = existingObjects(ctx, dir, m.Refs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := createBundle(ctx, dir, bundlePath, prerequisites)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &repoSnapshot{
+		refHash: string(refHash),
+		refs:    refs,
+		created: created,
+	}
+	snap.repoType, _ = getRepositoryType(dir)
+	snap.head, _ = quickSymbolicRefHead(dir)
+	return snap, nil
+}
+
+// putBundle uploads the bundle at path to the backup store.
+func (s *Server) putBundle(ctx context.Context, repo api.RepoName, path string, now time.Time) (backup.Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return backup.Bundle{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return backup.Bundle{}, err
+	}
+
+	b := backup.Bundle{
+		Key:       backup.BundleKey(repo, now),
+		Size:      fi.Size(),
+		CreatedAt: now,
+	}
+	if err := s.Backups.Put(ctx, b.Key, f); err != nil {
+		return backup.Bundle{}, errors.Wrap(err, "uploading bundle")
+	}
+	backupBytesTotal.Add(float64(b.Size))
+
+	return b, nil
+}
+
+// showRefs returns a map from every ref in dir to the object it points to.
+func showRefs(ctx context.Context, dir GitDir) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(objectname) %(refname)")
+	dir.Set(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, wrapCmdError(cmd, err)
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if i := strings.IndexByte(line, ' '); i > 0 {
+			refs[line[i+1:]] = line[:i]
+		}
+	}
+	return refs, nil
+}
+
+// existingObjects returns the distinct objects refs point to which exist in
+// dir.
+func existingObjects(ctx context.Context, dir GitDir, refs map[string]string) ([]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(refs))
+	var input bytes.Buffer
+	for _, oid := range refs {
+		if !seen[oid] {
+			seen[oid] = true
+			input.WriteString(oid + "\n")
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch-check=%(objectname)")
+	dir.Set(cmd)
+	cmd.Stdin = &input
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, wrapCmdError(cmd, err)
+	}
+
+	// Missing objects are reported as "<oid> missing".
+	var oids []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if line := sc.Text(); line != "" && !strings.HasSuffix(line, " missing") {
+			oids = append(oids, line)
+		}
+	}
+	return oids, sc.Err()
+}
+
+// createBundle writes a bundle of all refs in dir to path, excluding the
+// objects reachable from prerequisites. It returns false without an error if
+// there are no objects to bundle.
+func createBundle(ctx context.Context, dir GitDir, path string, prerequisites []string) (bool, error) {
+	// The prerequisites are passed on stdin since there may be more of them
+	// than fit on the command line.
+	var input bytes.Buffer
+	for _, oid := range prerequisites {
+		input.WriteString("^" + oid + "\n")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "bundle", "create", path, "--all", "--stdin")
+	dir.Set(cmd)
+	cmd.Stdin = &input
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// git refuses to create a bundle without any refs, which is the case
+		// for an empty repo or when all refs point to prerequisites.
+		if strings.Contains(stderr.String(), "Refusing to create empty bundle") {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "git bundle create failed. Output: %s", stderr.String())
+	}
+	return true, nil
+}
+
+// hasBackup reports whether there is a backup of repo to restore it from.
+func (s *Server) hasBackup(ctx context.Context, repo api.RepoName) bool {
+	if s.Backups == nil {
+		return false
+	}
+	_, err := backup.ReadManifest(ctx, s.Backups, repo)
+	if err != nil && !backup.IsNotFound(err) {
+		log15.Warn("Looking up backup of repo", "repo", repo, "error", err)
+	}
+	return err == nil
+}
+
+// restoreFromBackup creates a bare git repo at tmpPath from the backup of
+// repo. It returns an error for which backup.IsNotFound is true if there is
+// no backup of repo.
+func (s *Server) restoreFromBackup(ctx context.Context, repo api.RepoName, tmpPath string) (err error) {
+	defer func() {
+		if !backup.IsNotFound(err) {
+			restoreReposTotal.WithLabelValues(boolLabel(err == nil)).Inc()
+		}
+	}()
+
+	m, err := backup.ReadManifest(ctx, s.Backups, repo)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", tmpPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git init failed. Output: %s", string(output))
+	}
+	dir := GitDir(tmpPath)
+
+	bundleDir, err := s.tempDir("restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	// Every bundle only has the objects which are missing from the bundles
+	// before it, so they have to be unbundled in order.
+	for _, b := range m.Bundles {
+		if err := s.unbundle(ctx, dir, b.Key, filepath.Join(bundleDir, "repo.bundle")); err != nil {
+			return errors.Wrapf(err, "restoring bundle %s", b.Key)
+		}
+	}
+
+	// Bundles don't update refs, so we point them at the objects recorded at
+	// the time of the last backup.
+	var input bytes.Buffer
+	for ref, oid := range m.Refs {
+		input.WriteString("create " + ref + " " + oid + "\n")
+	}
+	cmd = exec.CommandContext(ctx, "git", "update-ref", "--stdin")
+	dir.Set(cmd)
+	cmd.Stdin = &input
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git update-ref failed. Output: %s", string(output))
+	}
+
+	if m.Head != "" {
+		cmd = exec.CommandContext(ctx, "git", "symbolic-ref", "HEAD", m.Head)
+		dir.Set(cmd)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "git symbolic-ref failed. Output: %s", string(output))
+		}
+	}
+
+	if m.Type != "" {
+		if err := setRepositoryType(dir, m.Type); err != nil {
+			return errors.Wrap(err, `git config set "sourcegraph.type"`)
+		}
+	}
+
+	return nil
+}
+
+// unbundle downloads the bundle with the given key to path and adds its
+// objects to dir.
+func (s *Server) unbundle(ctx context.Context, dir GitDir, key, path string) error {
+	rc, err := s.Backups.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	_, err = io.Copy(f, rc)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "downloading bundle")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "bundle", "unbundle", path)
+	dir.Set(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git bundle unbundle failed. Output: %s", string(output))
+	}
+	return nil
+}
+
+// restoreCandidates returns the repos with a backup which belong on this
+// gitserver and are not cloned yet. If repos is not empty, only those repos
+// are considered.
+func (s *Server) restoreCandidates(ctx context.Context, repos []api.RepoName) ([]api.RepoName, error) {
+	if s.Backups == nil {
+		return nil, errors.New("backups are not enabled")
+	}
+
+	if len(repos) == 0 {
+		var err error
+		repos, err = backup.ListRepos(ctx, s.Backups)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing backups")
+		}
+	}
+
+	addrs := conf.Get().ServiceConnections.GitServers
+
+	var candidates []api.RepoName
+	for _, repo := range repos {
+		repo = protocol.NormalizeRepo(repo)
+		if len(addrs) > 0 && !s.hostnameMatch(gitserver.AddrForRepo(repo, addrs)) {
+			continue
+		}
+		if repoCloned(s.dir(repo)) {
+			continue
+		}
+		candidates = append(candidates, repo)
+	}
+	return s.existingRepos(ctx, candidates)
+}
+
+// existingRepos returns the repos which exist in the database. Backups of
+// deleted repos may remain if gitserver was not reachable when they were
+// deleted, and they can't be restored since there is no remote URL for
+// them.
+func (s *Server) existingRepos(ctx context.Context, repos []api.RepoName) ([]api.RepoName, error) {
+	if s.DB == nil || len(repos) == 0 {
+		return repos, nil
+	}
+
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, string(repo))
+	}
+	rs, err := database.Repos(s.DB).ListRepoNames(ctx, database.ReposListOptions{Names: names})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing repos")
+	}
+
+	// Repo names are compared case insensitively by the database.
+	exists := make(map[string]bool, len(rs))
+	for _, r := range rs {
+		exists[strings.ToLower(string(r.Name))] = true
+	}
+
+	var existing []api.RepoName
+	for _, repo := range repos {
+		if exists[strings.ToLower(string(repo))] {
+			existing = append(existing, repo)
+		} else {
+			log15.Warn("restore: skipping backup of repo which does not exist", "repo", repo)
+		}
+	}
+	return existing, nil
+}
+
+// handleRestore queues the clone of repos which are missing on this
+// gitserver. Clones are restored from their backup before falling back to
+// the code host.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos, err := s.restoreCandidates(r.Context(), req.Repos)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// despite the existence of a context on the request, we don't want to
+	// cancel the clones if the request terminates.
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	resp := protocol.RestoreResponse{Queued: []api.RepoName{}}
+	for _, repo := range repos {
+		if _, err := s.cloneRepo(ctx, repo, nil); err != nil {
+			log15.Warn("restore: failed to queue clone of repo", "repo", repo, "error", err)
+			continue
+		}
+		resp.Queued = append(resp.Queued, repo)
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreShard clones every repo which belongs on this gitserver and has a
+// backup, restoring it from the backup. Repos which are already cloned are
+// skipped. It blocks until all clones finished and returns the number of
+// repos which were restored and which failed.
+func (s *Server) RestoreShard(ctx context.Context) (restored, failed int, err error) {
+	repos, err := s.restoreCandidates(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	log15.Info("restore: restoring repos from backup", "count", len(repos))
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, conf.GitMaxConcurrentClones())
+	)
+	for _, repo := range repos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(repo api.RepoName) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			_, err := s.cloneRepo(ctx, repo, &cloneOptions{Block: true})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log15.Error("restore: failed to restore repo", "repo", repo, "error", err)
+				failed++
+			} else {
+				restored++
+			}
+		}(repo)
+	}
+	wg.Wait()
+
+	return restored, failed, nil
+}
+
+func (s *Server) setLastBackupNonFatal(ctx context.Context, name api.RepoName, backedUpAt time.Time, backupErr error) {
+	if s.DB == nil {
+		return
+	}
+	err := database.GitserverRepos(s.DB).SetLastBackup(ctx, name, database.GitserverBackupData{
+		BackedUpAt: backedUpAt,
+		Error:      errorString(backupErr),
+	})
+	if err != nil {
+		log15.Warn("Setting last backup in DB", "error", err)
+	}
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
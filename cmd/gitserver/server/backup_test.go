@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/backup"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoName := api.RepoName("example.com/foo/bar")
+	store := backup.NewLocalStore(t.TempDir())
+
+	remote := t.TempDir()
+	repo := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, repo, name, arg...)
+	}
+	makeSingleCommitRepo(cmd)
+
+	s := makeTestServer(ctx, t.TempDir(), remote, nil)
+	s.Backups = store
+	s.repoUpdateLocks = make(map[api.RepoName]*locks)
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	dir := s.dir(repoName)
+
+	backupRepo := func(want string, wantBundles int) {
+		t.Helper()
+		result, err := s.backupRepo(ctx, repoName, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != want {
+			t.Fatalf("unexpected backup result: want %q, got %q", want, result)
+		}
+		m, err := backup.ReadManifest(ctx, store, repoName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Bundles) != wantBundles {
+			t.Fatalf("unexpected number of bundles: want %d, got %d", wantBundles, len(m.Bundles))
+		}
+	}
+
+	backupRepo(backupFull, 1)
+	backupRepo(backupUnchanged, 1)
+
+	// New commits are written to a new bundle with only the new objects.
+	cmd("sh", "-c", "echo goodbye > goodbye.txt")
+	cmd("git", "add", "goodbye.txt")
+	cmd("git", "commit", "-m", "goodbye")
+	cmd("git", "tag", "-a", "v1", "-m", "v1")
+	repo = string(dir)
+	cmd("git", "fetch", remote, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	backupRepo(backupIncrement, 2)
+
+	// Refs pointing at objects which are already backed up don't need a
+	// bundle.
+	cmd("git", "branch", "feature", "HEAD~1")
+	backupRepo(backupIncrement, 2)
+
+	wantRefs := cmd("git", "for-each-ref")
+	wantHead := cmd("git", "symbolic-ref", "HEAD")
+
+	// The code host is unreachable, so the only way to clone is from the
+	// backup.
+	restored := makeTestServer(ctx, t.TempDir(), filepath.Join(remote, "missing"), nil)
+	restored.Backups = store
+	n, failed, err := restored.RestoreShard(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || failed != 0 {
+		t.Fatalf("unexpected restore result: restored %d, failed %d", n, failed)
+	}
+
+	repo = string(restored.dir(repoName))
+	if gotRefs := cmd("git", "for-each-ref"); gotRefs != wantRefs {
+		t.Fatalf("unexpected refs after restore:\nwant: %s\ngot: %s", wantRefs, gotRefs)
+	}
+	if gotHead := cmd("git", "symbolic-ref", "HEAD"); gotHead != wantHead {
+		t.Fatalf("unexpected HEAD after restore: want %q, got %q", wantHead, gotHead)
+	}
+	cmd("git", "fsck", "--no-dangling")
+	if gotType, _ := getRepositoryType(GitDir(repo)); gotType != "git" {
+		t.Fatalf("unexpected repository type after restore: %q", gotType)
+	}
+
+	// Repos which are already cloned are not restored again.
+	candidates, err := restored.restoreCandidates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("expected no repos to restore, got %v", candidates)
+	}
+
+	// Deleting the repo deletes its backup, so it isn't restored again.
+	if err := restored.deleteRepo(ctx, repoName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backup.ReadManifest(ctx, store, repoName); !backup.IsNotFound(err) {
+		t.Fatalf("expected backup to be deleted, got %v", err)
+	}
+	if keys, err := store.List(ctx, ""); err != nil || len(keys) != 0 {
+		t.Fatalf("expected no objects in backup store, got %v (error %v)", keys, err)
+	}
+}
+
+func TestBackupReposSkipsOtherShards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addrs := []string{"gitserver-0", "gitserver-1"}
+	conf.Mock(&conf.Unified{
+		SiteConfiguration:  schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{}},
+		ServiceConnections: conftypes.ServiceConnections{GitServers: addrs},
+	})
+	defer conf.Mock(nil)
+
+	remote := t.TempDir()
+	makeSingleCommitRepo(func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, remote, name, arg...)
+	})
+
+	s := makeTestServer(ctx, t.TempDir(), remote, nil)
+	s.Hostname = addrs[0]
+	s.Backups = backup.NewLocalStore(t.TempDir())
+	s.repoUpdateLocks = make(map[api.RepoName]*locks)
+
+	// Find a repo on each shard.
+	var local, other api.RepoName
+	for i := 0; local == "" || other == ""; i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/foo/repo%d", i))
+		if gitserver.AddrForRepo(repo, addrs) == addrs[0] {
+			local = repo
+		} else {
+			other = repo
+		}
+	}
+
+	// A clone of a repo which belongs on another shard is left behind, eg:
+	// after the number of gitservers changed.
+	for _, repo := range []api.RepoName{local, other} {
+		if _, err := s.cloneRepo(ctx, repo, &cloneOptions{Block: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.backupRepos()
+
+	if _, err := backup.ReadManifest(ctx, s.Backups, local); err != nil {
+		t.Fatalf("expected repo %s to be backed up, got %v", local, err)
+	}
+	if _, err := backup.ReadManifest(ctx, s.Backups, other); !backup.IsNotFound(err) {
+		t.Fatalf("expected repo %s of another shard not to be backed up, got %v", other, err)
+	}
+}
+
+func TestRestoreCandidatesSkipsDeletedRepos(t *testing.T) {
+	ctx := context.Background()
+	store := backup.NewLocalStore(t.TempDir())
+	for _, repo := range []api.RepoName{"example.com/foo/bar", "example.com/foo/deleted"} {
+		if err := backup.WriteManifest(ctx, store, &backup.Manifest{Repo: repo}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	database.Mocks.Repos.ListRepoNames = func(_ context.Context, opt database.ReposListOptions) ([]types.RepoName, error) {
+		return []types.RepoName{{ID: 1, Name: "example.com/foo/bar"}}, nil
+	}
+	defer func() { database.Mocks.Repos.ListRepoNames = nil }()
+
+	s := &Server{ReposDir: t.TempDir(), Backups: store, DB: new(sql.DB)}
+	candidates, err := s.restoreCandidates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0] != "example.com/foo/bar" {
+		t.Fatalf("unexpected repos to restore: %v", candidates)
+	}
+}
+
+func TestBackupEmptyRepo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoName := api.RepoName("example.com/foo/empty")
+	store := backup.NewLocalStore(t.TempDir())
+
+	remote := t.TempDir()
+	runCmd(t, remote, "git", "init", ".")
+
+	s := makeTestServer(ctx, t.TempDir(), remote, nil)
+	s.Backups = store
+	s.repoUpdateLocks = make(map[api.RepoName]*locks)
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.backupRepo(ctx, repoName, s.dir(repoName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != backupFull {
+		t.Fatalf("unexpected backup result: %q", result)
+	}
+
+	m, err := backup.ReadManifest(ctx, store, repoName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Bundles) != 0 || len(m.Refs) != 0 {
+		t.Fatalf("expected an empty backup, got %+v", m)
+	}
+
+	result, err = s.backupRepo(ctx, repoName, s.dir(repoName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != backupUnchanged {
+		t.Fatalf("unexpected backup result: %q", result)
+	}
+}
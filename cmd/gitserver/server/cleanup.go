@@ -155,10 +155,11 @@ func (s *Server) cleanupRepos() {
 			}
 		}
 
-		// We believe converting a Perforce depot to a Git repository is generally a
-		// very expensive operation, therefore we do not try to re-clone/redo the
+		// We believe converting a Perforce depot, Subversion repository or
+		// Mercurial repository to a Git repository is generally a very
+		// expensive operation, therefore we do not try to re-clone/redo the
 		// conversion only because it is old or slow to do "git gc".
-		if repoType == "perforce" && reason != maybeCorrupt {
+		if (repoType == "perforce" || repoType == "subversion" || repoType == "mercurial") && reason != maybeCorrupt {
 			reason = ""
 		}
 
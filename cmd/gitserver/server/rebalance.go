@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/url"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// The possible outcomes of moving a repo onto this gitserver.
+const (
+	rebalanceFromPeer     = "peer"
+	rebalanceFromBackup   = "backup"
+	rebalanceFromUpstream = "upstream"
+	rebalanceFailed       = "failed"
+)
+
+var (
+	rebalanceReposTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_rebalance_repos_total",
+		Help: "Number of repos moved onto this gitserver after the set of gitservers changed, by where they were cloned from.",
+	}, []string{"result"})
+	rebalancePending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_rebalance_pending",
+		Help: "Number of repos currently being moved onto this gitserver.",
+	})
+)
+
+// rebalanceStats tracks the progress of repos being moved onto this gitserver
+// since the set of gitservers last changed. The zero value is ready to use.
+type rebalanceStats struct {
+	mu    sync.Mutex
+	stats *protocol.RebalanceStats
+}
+
+// reset starts a new rebalance. Repos which are still being moved remain
+// pending.
+func (r *rebalanceStats) reset(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending int64
+	if r.stats != nil {
+		pending = r.stats.Pending
+	}
+	r.stats = &protocol.RebalanceStats{StartedAt: now, Pending: pending}
+}
+
+// start records that a repo has started moving onto this gitserver.
+func (r *rebalanceStats) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stats == nil {
+		r.stats = &protocol.RebalanceStats{}
+	}
+	r.stats.Pending++
+	rebalancePending.Inc()
+}
+
+// done records that a repo which was moving onto this gitserver has finished
+// with the given result.
+func (r *rebalanceStats) done(result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Pending--
+	rebalancePending.Dec()
+
+	switch result {
+	case rebalanceFromPeer:
+		r.stats.FromPeer++
+	case rebalanceFromBackup:
+		r.stats.FromBackup++
+	case rebalanceFromUpstream:
+		r.stats.FromUpstream++
+	default:
+		r.stats.Failed++
+	}
+	rebalanceReposTotal.WithLabelValues(result).Inc()
+}
+
+// get returns a copy of the current stats, or nil if no repos have been
+// moved and no rebalance has started.
+func (r *rebalanceStats) get() *protocol.RebalanceStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stats == nil {
+		return nil
+	}
+	stats := *r.stats
+	return &stats
+}
+
+// previousOwner returns the address of the gitserver which holds a clone of
+// repo from before the set of gitservers changed. It returns an empty string
+// if there is no such gitserver.
+func (s *Server) previousOwner(ctx context.Context, repo api.RepoName) string {
+	if s.DB == nil {
+		return ""
+	}
+
+	gr, err := database.GitserverRepos(s.DB).GetByName(ctx, repo)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log15.Warn("Looking up previous owner of repo", "repo", repo, "error", err)
+		}
+		return ""
+	}
+
+	return peerAddr(gr, s.Hostname, conf.Get().ServiceConnections.GitServers)
+}
+
+// peerAddr returns the address in addrs of the gitserver which the database
+// reports as holding a clone of the repo, as long as that is not hostname.
+func peerAddr(gr *types.GitserverRepo, hostname string, addrs []string) string {
+	if gr.CloneStatus != types.CloneStatusCloned || gr.ShardID == "" || gr.ShardID == hostname {
+		return ""
+	}
+	for _, addr := range addrs {
+		if hostnameMatch(gr.ShardID, addr) {
+			return addr
+		}
+	}
+	return ""
+}
+
+// cloneFromPeer clones repo into tmpPath from the gitserver at addr using its
+// /git/ smart HTTP endpoint.
+func cloneFromPeer(ctx context.Context, repo api.RepoName, addr, tmpPath string, lock *RepositoryLock) error {
+	peerURL, err := vcs.ParseURL((&url.URL{Scheme: "http", Host: addr, Path: "/git/" + string(repo)}).String())
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", peerURL.String(), tmpPath)
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	go readCloneProgress(newURLRedactor(peerURL), lock, pr, repo)
+
+	if output, err := runWith(ctx, cmd, false, pw); err != nil {
+		return errors.Wrapf(err, "clone from %s failed. Output: %s", addr, string(output))
+	}
+
+	// The peer is not the source of truth for the repo. Future fetches go to
+	// the code host, so we don't want to keep it around as a remote.
+	cmd = exec.CommandContext(ctx, "git", "remote", "remove", "origin")
+	GitDir(tmpPath).Set(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to remove peer remote. Output: %s", string(output))
+	}
+
+	return nil
+}
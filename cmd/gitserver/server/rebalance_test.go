@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestPeerAddr(t *testing.T) {
+	addrs := []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178"}
+
+	testCases := []struct {
+		name string
+		repo types.GitserverRepo
+		want string
+	}{
+		{
+			name: "cloned on peer",
+			repo: types.GitserverRepo{ShardID: "gitserver-1", CloneStatus: types.CloneStatusCloned},
+			want: "gitserver-1:3178",
+		},
+		{
+			name: "cloned on self",
+			repo: types.GitserverRepo{ShardID: "gitserver-0", CloneStatus: types.CloneStatusCloned},
+			want: "",
+		},
+		{
+			name: "not cloned on peer",
+			repo: types.GitserverRepo{ShardID: "gitserver-1", CloneStatus: types.CloneStatusNotCloned},
+			want: "",
+		},
+		{
+			name: "cloning on peer",
+			repo: types.GitserverRepo{ShardID: "gitserver-1", CloneStatus: types.CloneStatusCloning},
+			want: "",
+		},
+		{
+			name: "peer no longer exists",
+			repo: types.GitserverRepo{ShardID: "gitserver-3", CloneStatus: types.CloneStatusCloned},
+			want: "",
+		},
+		{
+			name: "no shard",
+			repo: types.GitserverRepo{CloneStatus: types.CloneStatusCloned},
+			want: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := peerAddr(&tc.repo, "gitserver-0", addrs); got != tc.want {
+				t.Fatalf("Want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCloneRepo_FromPeer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoName := api.RepoName("example.com/foo/bar")
+
+	remote := t.TempDir()
+	repo := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, repo, name, arg...)
+	}
+	wantCommit := makeSingleCommitRepo(cmd)
+
+	// The peer is the previous owner of the repo, so it already has a clone.
+	peer := makeTestServer(ctx, t.TempDir(), remote, nil)
+	if _, err := peer.cloneRepo(ctx, repoName, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	peerServer := httptest.NewServer(peer.Handler())
+	defer peerServer.Close()
+	peerAddr := strings.TrimPrefix(peerServer.URL, "http://")
+
+	// The code host is unreachable, so the only way to clone is via the peer.
+	s := makeTestServer(ctx, t.TempDir(), filepath.Join(remote, "missing"), nil)
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true, MigrateFrom: peerAddr}); err != nil {
+		t.Fatal(err)
+	}
+
+	repo = filepath.Dir(string(s.dir(repoName)))
+	if gotCommit := cmd("git", "rev-parse", "HEAD"); gotCommit != wantCommit {
+		t.Fatalf("failed to clone from peer: %s", gotCommit)
+	}
+	if remotes := cmd("git", "remote"); remotes != "" {
+		t.Fatalf("expected peer remote to be removed, got %q", remotes)
+	}
+
+	stats := s.rebalance.get()
+	if stats == nil || stats.FromPeer != 1 || stats.FromUpstream != 0 || stats.Failed != 0 || stats.Pending != 0 {
+		t.Fatalf("unexpected rebalance stats: %+v", stats)
+	}
+}
+
+func TestCloneRepo_FromPeerFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoName := api.RepoName("example.com/foo/bar")
+
+	remote := t.TempDir()
+	repo := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, repo, name, arg...)
+	}
+	wantCommit := makeSingleCommitRepo(cmd)
+
+	// The peer doesn't have the repo, so we should fall back to the code host.
+	peerServer := httptest.NewServer(http.NotFoundHandler())
+	defer peerServer.Close()
+	peerAddr := strings.TrimPrefix(peerServer.URL, "http://")
+
+	s := makeTestServer(ctx, t.TempDir(), remote, nil)
+	if _, err := s.cloneRepo(ctx, repoName, &cloneOptions{Block: true, MigrateFrom: peerAddr}); err != nil {
+		t.Fatal(err)
+	}
+
+	repo = filepath.Dir(string(s.dir(repoName)))
+	if gotCommit := cmd("git", "rev-parse", "HEAD"); gotCommit != wantCommit {
+		t.Fatalf("failed to clone from code host: %s", gotCommit)
+	}
+
+	stats := s.rebalance.get()
+	if stats == nil || stats.FromPeer != 0 || stats.FromUpstream != 1 || stats.Failed != 0 || stats.Pending != 0 {
+		t.Fatalf("unexpected rebalance stats: %+v", stats)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/backup"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 )
 
@@ -113,8 +114,18 @@ func (s *Server) handleReposStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var stats protocol.ReposStats
+	if err := json.Unmarshal(b, &stats); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode %s: %v", reposStatsName, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	// Rebalance progress is only kept in memory since it is reset whenever
+	// gitserver restarts.
+	stats.Rebalance = s.rebalance.get()
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_, _ = w.Write(b)
+	_ = json.NewEncoder(w).Encode(stats)
 }
 
 func (s *Server) handleRepoCloneProgress(w http.ResponseWriter, r *http.Request) {
@@ -149,7 +160,7 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.deleteRepo(req.Repo); err != nil {
+	if err := s.deleteRepo(r.Context(), req.Repo); err != nil {
 		log15.Error("failed to delete repository", "repo", req.Repo, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -157,6 +168,14 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 	log15.Info("deleted repository", "repo", req.Repo)
 }
 
-func (s *Server) deleteRepo(repo api.RepoName) error {
-	return s.removeRepoDirectory(s.dir(repo))
+// deleteRepo removes the clone of repo and its backup, so that it isn't
+// restored later.
+func (s *Server) deleteRepo(ctx context.Context, repo api.RepoName) error {
+	if err := s.removeRepoDirectory(s.dir(repo)); err != nil {
+		return err
+	}
+	if s.Backups == nil {
+		return nil
+	}
+	return errors.Wrap(backup.DeleteBackup(ctx, s.Backups, protocol.NormalizeRepo(repo)), "deleting backup")
 }
@@ -42,6 +42,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/adapters"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/backup"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/search"
@@ -212,6 +213,10 @@ type Server struct {
 	// shared db handle
 	DB dbutil.DB
 
+	// Backups is the store repos are backed up to and restored from. Backups
+	// are disabled if it is nil.
+	Backups backup.Store
+
 	// CloneQueue is a threadsafe queue used by DoBackgroundClones to process incoming clone
 	// requests asynchronously.
 	CloneQueue *cloneQueue
@@ -242,6 +247,10 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	// rebalance tracks repos being moved onto this gitserver after the set
+	// of gitservers changed.
+	rebalance rebalanceStats
 }
 
 type locks struct {
@@ -351,6 +360,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/repo-clone-progress", s.handleRepoCloneProgress)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
+	mux.HandleFunc("/restore", s.handleRestore)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
@@ -405,6 +415,12 @@ func (s *Server) SyncRepoState(interval time.Duration, batchSize, perSecond int)
 		fullSync := currentAddrs != previousAddrs
 		previousAddrs = currentAddrs
 
+		if fullSync {
+			// The set of gitservers changed (or we just started), so repos
+			// may need to be moved onto this gitserver.
+			s.rebalance.reset(time.Now())
+		}
+
 		if err := s.syncRepoState(addrs, batchSize, perSecond, fullSync); err != nil {
 			log15.Error("Syncing repo state", "error ", err)
 		}
@@ -484,15 +500,21 @@ func (s *Server) cloneJobConsumer(ctx context.Context, jobs <-chan *cloneJob) {
 // hostnameMatch checks whether the hostname matches the given address.
 // If we don't find an exact match, we look at the initial prefix.
 func (s *Server) hostnameMatch(addr string) bool {
-	if !strings.HasPrefix(addr, s.Hostname) {
+	return hostnameMatch(s.Hostname, addr)
+}
+
+// hostnameMatch checks whether hostname matches the given address.
+// If we don't find an exact match, we look at the initial prefix.
+func hostnameMatch(hostname, addr string) bool {
+	if !strings.HasPrefix(addr, hostname) {
 		return false
 	}
-	if addr == s.Hostname {
+	if addr == hostname {
 		return true
 	}
-	// We know that s.Hostname is shorter than addr so we can safely check the next
+	// We know that hostname is shorter than addr so we can safely check the next
 	// char
-	next := addr[len(s.Hostname)]
+	next := addr[len(hostname)]
 	return next == '.' || next == ':'
 }
 
@@ -599,6 +621,20 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 		cloned := repoCloned(dir)
 		_, cloning := s.locker.Status(dir)
 
+		// If the repo was moved onto this shard from a gitserver which is
+		// still around, fetch it from there rather than waiting for it to be
+		// cloned from the code host. We leave the row pointing at the previous
+		// owner so that the repo remains usable until the clone is done.
+		if fullSync && !cloned && !cloning && repo.GitserverRepo != nil {
+			if peer := peerAddr(repo.GitserverRepo, s.Hostname, addrs); peer != "" {
+				repoSyncStateCounter.WithLabelValues("rebalance").Inc()
+				if _, err := s.cloneRepo(ctx, repo.Name, &cloneOptions{MigrateFrom: peer}); err != nil {
+					log15.Warn("Moving repo from previous gitserver", "repo", repo.Name, "from", peer, "error", err)
+				}
+				return nil
+			}
+		}
+
 		var shouldUpdate bool
 		if repo.GitserverRepo == nil {
 			repo.GitserverRepo = &types.GitserverRepo{
@@ -822,8 +858,8 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		// the code path at this point. Since the repo is already not cloned at this point, either
 		// this request was received for a repo migration or a regular clone - for both of which we
 		// want to go ahead and clone the repo. The responsibility of figuring out where to clone
-		// the repo from (upstream URL of the external service or the gitserver instance) lies with
-		// the implementation details of cloneRepo.
+		// the repo from (upstream URL of the external service or the previous gitserver instance)
+		// lies with the implementation details of cloneRepo.
 		_, err := s.cloneRepo(ctx, req.Repo, &cloneOptions{Block: true, MigrateFrom: req.MigrateFrom})
 		if err != nil {
 			log15.Warn("error cloning repo", "repo", req.Repo, "err", err)
@@ -880,6 +916,17 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 		paths   = q["path"]
 	)
 
+	// Archives of many paths are requested with a POST, since the paths may
+	// not fit into the URL.
+	if r.Method == http.MethodPost {
+		var req protocol.ArchiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		treeish, repo, format, paths = req.Treeish, string(req.Repo), req.Format, req.Paths
+	}
+
 	if err := checkSpecArgSafety(treeish); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		log15.Error("gitserver.archive.CheckSpecArgSafety", "error", err)
@@ -916,7 +963,11 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.Args = append(req.Args, treeish, "--")
-	req.Args = append(req.Args, paths...)
+	for _, p := range paths {
+		// Paths are file names, not pathspecs, so glob characters in them
+		// must match literally.
+		req.Args = append(req.Args, ":(literal)"+p)
+	}
 
 	s.exec(w, r, req)
 }
@@ -1564,12 +1615,10 @@ type cloneOptions struct {
 	// Overwrite will overwrite the existing clone.
 	Overwrite bool
 
-	// MigrateFrom is the name of the gitserver instance which is the current owner of the
+	// MigrateFrom is the address of the gitserver instance which was the previous owner of the
 	// repository. If this is a non-zero string, then gitserver will attempt to clone the repo from
-	// the current gitserver instance instead of the upstream repo URL of the external service.
-	//
-	// Once migration is complete for all repos in Sourcegraph, there is no need for this attribute
-	// and it should be removed.
+	// that gitserver instance before falling back to the upstream repo URL of the external
+	// service. If it is empty, cloneRepo looks up the previous owner in the database.
 	MigrateFrom string
 }
 
@@ -1610,13 +1659,24 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, opts *cloneOp
 	}
 	defer cancel()
 
-	if err = s.rpsLimiter.Wait(ctx); err != nil {
-		return "", err
+	if opts == nil {
+		opts = &cloneOptions{}
+	}
+	if opts.MigrateFrom == "" {
+		opts.MigrateFrom = s.previousOwner(ctx, repo)
 	}
 
-	if err := syncer.IsCloneable(ctx, remoteURL); err != nil {
-		redactedErr := newURLRedactor(remoteURL).redact(err.Error())
-		return "", errors.Errorf("error cloning repo: repo %s not cloneable: %s", repo, redactedErr)
+	// When the repo is moving from another gitserver or has a backup we don't need to ask the
+	// code host, since we will fetch it from our peer or restore it from the backup.
+	if opts.MigrateFrom == "" && !s.hasBackup(ctx, repo) {
+		if err = s.rpsLimiter.Wait(ctx); err != nil {
+			return "", err
+		}
+
+		if err := syncer.IsCloneable(ctx, remoteURL); err != nil {
+			redactedErr := newURLRedactor(remoteURL).redact(err.Error())
+			return "", errors.Errorf("error cloning repo: repo %s not cloneable: %s", repo, redactedErr)
+		}
 	}
 
 	// Mark this repo as currently being cloned. We have to check again if someone else isn't already
@@ -1634,12 +1694,20 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, opts *cloneOp
 		return "", nil
 	}
 
+	if opts.MigrateFrom != "" {
+		s.rebalance.start()
+	}
+
 	// We clone to a temporary location first to avoid having incomplete
 	// clones in the repo tree. This also avoids leaving behind corrupt clones
 	// if the clone is interrupted.
-	if opts != nil && opts.Block {
+	if opts.Block {
 		ctx, cancel, err := s.acquireCloneLimiter(ctx)
 		if err != nil {
+			lock.Release()
+			if opts.MigrateFrom != "" {
+				s.rebalance.done(rebalanceFailed)
+			}
 			return "", err
 		}
 		defer cancel()
@@ -1667,9 +1735,25 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, opts *cloneOp
 	return "", nil
 }
 
-func (s *Server) doClone(ctx context.Context, repo api.RepoName, dir GitDir, syncer VCSSyncer, lock *RepositoryLock, remoteURL *vcs.URL, opts *cloneOptions) error {
+func (s *Server) doClone(ctx context.Context, repo api.RepoName, dir GitDir, syncer VCSSyncer, lock *RepositoryLock, remoteURL *vcs.URL, opts *cloneOptions) (err error) {
 	defer lock.Release()
 
+	var fromPeer, fromBackup bool
+	if opts != nil && opts.MigrateFrom != "" {
+		defer func() {
+			switch {
+			case err != nil:
+				s.rebalance.done(rebalanceFailed)
+			case fromPeer:
+				s.rebalance.done(rebalanceFromPeer)
+			case fromBackup:
+				s.rebalance.done(rebalanceFromBackup)
+			default:
+				s.rebalance.done(rebalanceFromUpstream)
+			}
+		}()
+	}
+
 	if err := s.rpsLimiter.Wait(ctx); err != nil {
 		return err
 	}
@@ -1708,25 +1792,54 @@ func (s *Server) doClone(ctx context.Context, repo api.RepoName, dir GitDir, syn
 		s.setCloneStatusNonFatal(context.Background(), repo, cloneStatus(repoCloned(dir), false))
 	}()
 
-	cmd, err := syncer.CloneCommand(ctx, remoteURL, tmpPath)
-	if err != nil {
-		return errors.Wrap(err, "get clone command")
+	if opts != nil && opts.MigrateFrom != "" {
+		log15.Info("cloning repo from previous gitserver", "repo", repo, "from", opts.MigrateFrom, "tmp", tmpPath, "dst", dstPath)
+		if err := cloneFromPeer(ctx, repo, opts.MigrateFrom, tmpPath, lock); err != nil {
+			log15.Warn("Failed to clone repo from previous gitserver, falling back to code host", "repo", repo, "from", opts.MigrateFrom, "error", err)
+			if err := os.RemoveAll(tmpPath); err != nil {
+				return err
+			}
+		} else {
+			fromPeer = true
+		}
 	}
-	if cmd.Env == nil {
-		cmd.Env = os.Environ()
+
+	if !fromPeer && s.Backups != nil {
+		lock.SetStatus("restoring from backup")
+		if err := s.restoreFromBackup(ctx, repo, tmpPath); err != nil {
+			if !backup.IsNotFound(err) {
+				log15.Warn("Failed to restore repo from backup, falling back to code host", "repo", repo, "error", err)
+			}
+			if err := os.RemoveAll(tmpPath); err != nil {
+				return err
+			}
+		} else {
+			log15.Info("restored repo from backup", "repo", repo, "tmp", tmpPath, "dst", dstPath)
+			fromBackup = true
+		}
 	}
 
-	// see issue #7322: skip LFS content in repositories with Git LFS configured
-	cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
-	log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
+	if !fromPeer && !fromBackup {
+		cmd, err := syncer.CloneCommand(ctx, remoteURL, tmpPath)
+		if err != nil {
+			return errors.Wrap(err, "get clone command")
+		}
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+
+		// see issue #7322: skip LFS content in repositories with Git LFS configured
+		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
 
-	pr, pw := io.Pipe()
-	defer pw.Close()
+		pr, pw := io.Pipe()
+		defer pw.Close()
 
-	go readCloneProgress(newURLRedactor(remoteURL), lock, pr, repo)
+		go readCloneProgress(newURLRedactor(remoteURL), lock, pr, repo)
 
-	if output, err := runWithRemoteOpts(ctx, cmd, pw); err != nil {
-		return errors.Wrapf(err, "clone failed. Output: %s", string(output))
+		if output, err := runWithRemoteOpts(ctx, cmd, pw); err != nil {
+			return errors.Wrapf(err, "clone failed. Output: %s", string(output))
+		}
 	}
 
 	if testRepoCorrupter != nil {
@@ -1735,7 +1848,11 @@ func (s *Server) doClone(ctx context.Context, repo api.RepoName, dir GitDir, syn
 
 	removeBadRefs(ctx, tmp)
 
-	if err := setHEAD(ctx, tmp, syncer, repo, remoteURL); err != nil {
+	if fromPeer || fromBackup {
+		// The clone from our peer or backup already has the HEAD it was
+		// using, so there is no need to ask the code host.
+		ensureHEAD(tmp)
+	} else if err := setHEAD(ctx, tmp, syncer, repo, remoteURL); err != nil {
 		log15.Error("Failed to ensure HEAD exists", "repo", repo, "error", err)
 		return errors.Wrap(err, "failed to ensure HEAD exists")
 	}
@@ -1960,14 +2077,7 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName) error {
 	defer span.Finish()
 
 	s.repoUpdateLocksMu.Lock()
-	l, ok := s.repoUpdateLocks[repo]
-	if !ok {
-		l = &locks{
-			once: new(sync.Once),
-			mu:   new(sync.Mutex),
-		}
-		s.repoUpdateLocks[repo] = l
-	}
+	l := s.repoUpdateLocksFor(repo)
 	once := l.once
 	mu := l.mu
 	s.repoUpdateLocksMu.Unlock()
@@ -2006,6 +2116,21 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName) error {
 	}
 }
 
+// repoUpdateLocksFor returns the locks used to consolidate updates of repo
+// and to prevent them from running in parallel. The caller must hold
+// s.repoUpdateLocksMu.
+func (s *Server) repoUpdateLocksFor(repo api.RepoName) *locks {
+	l, ok := s.repoUpdateLocks[repo]
+	if !ok {
+		l = &locks{
+			once: new(sync.Once),
+			mu:   new(sync.Mutex),
+		}
+		s.repoUpdateLocks[repo] = l
+	}
+	return l
+}
+
 var doBackgroundRepoUpdateMock func(api.RepoName) error
 
 func (s *Server) doBackgroundRepoUpdate(repo api.RepoName) error {
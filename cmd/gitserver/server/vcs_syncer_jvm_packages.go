@@ -483,7 +483,13 @@ func roundJVMVersionToNearestStableVersion(javaVersion int) int {
 }
 
 func runCommandInDirectory(ctx context.Context, cmd *exec.Cmd, workingDirectory string, dependency reposource.MavenDependency) (string, error) {
-	gitName := dependency.MavenModule.CoursierSyntax() + " authors"
+	return runCommandWithGitAuthor(ctx, cmd, workingDirectory, dependency.MavenModule.CoursierSyntax()+" authors")
+}
+
+// runCommandWithGitAuthor runs cmd in workingDirectory with a stable author,
+// committer and date so that package repos produce the same git revhashes
+// every time they are created.
+func runCommandWithGitAuthor(ctx context.Context, cmd *exec.Cmd, workingDirectory, gitName string) (string, error) {
 	gitEmail := "code-intel@sourcegraph.com"
 	cmd.Dir = workingDirectory
 	cmd.Env = append(cmd.Env, "EMAIL="+gitEmail)
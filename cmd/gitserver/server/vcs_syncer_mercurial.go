@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// MercurialRepoSyncer is a syncer for Mercurial repositories. It keeps a
+// Mercurial clone next to the converted Git repository and converts new
+// changesets with hg-fast-export.
+//
+// The Mercurial clone and the state of hg-fast-export are not part of the
+// Git repository, so they are missing after the repository is cloned from
+// another gitserver or restored from a backup. The Mercurial changeset of
+// every converted commit is recorded in Git notes, from which the state is
+// rebuilt so that only new changesets are converted.
+type MercurialRepoSyncer struct {
+	// Authors maps Mercurial commit authors to Git authors of the form
+	// "Full Name <email>".
+	Authors map[string]string
+}
+
+var _ VCSSyncer = &MercurialRepoSyncer{}
+
+func (s *MercurialRepoSyncer) Type() string {
+	return "mercurial"
+}
+
+// IsCloneable checks to see if the Mercurial remote URL is cloneable.
+func (s *MercurialRepoSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	cmd := exec.CommandContext(ctx, "hg", "identify", "--noninteractive", remoteURL.String())
+	cmd.Env = hgCommandEnv()
+
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to check remote access: %s", newURLRedactor(remoteURL).redact(string(output)))
+	}
+	return nil
+}
+
+// CloneCommand returns the command to be executed for cloning a Mercurial
+// repository as a Git repository. The conversion needs a Mercurial clone to
+// convert from, so the actual cloning happens inside this method and the
+// returned command is a no-op, like for JVM packages.
+func (s *MercurialRepoSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, tmpPath string) (*exec.Cmd, error) {
+	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "clone failed to create tmp dir")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", ".")
+	cmd.Dir = tmpPath
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "clone setup failed")
+	}
+
+	if err := s.Fetch(ctx, remoteURL, GitDir(tmpPath)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch pulls new changesets into the Mercurial clone kept in dir and converts
+// them. hg-fast-export keeps track of the changesets it already converted in
+// dir, and the state is rebuilt if it is missing.
+func (s *MercurialRepoSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	redactor := newURLRedactor(remoteURL)
+	hgDir := dir.Path("hg")
+
+	var cmd *exec.Cmd
+	if _, err := os.Stat(hgDir); os.IsNotExist(err) {
+		// The clone is missing if the repo has just been created, or has been
+		// copied from another gitserver.
+		cmd = exec.CommandContext(ctx, "hg", "clone", "--noupdate", "--noninteractive", remoteURL.String(), hgDir)
+	} else {
+		cmd = exec.CommandContext(ctx, "hg", "pull", "--noninteractive", remoteURL.String())
+		cmd.Dir = hgDir
+	}
+	cmd.Env = hgCommandEnv()
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to pull with output %q", redactor.redact(string(output)))
+	}
+
+	// hg clone saves the remote URL, including credentials, as the default
+	// path. We always pass the URL explicitly, so it isn't needed.
+	if err := os.Remove(filepath.Join(hgDir, ".hg", "hgrc")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if _, err := os.Stat(dir.Path(hgExportPrefix + "-marks")); os.IsNotExist(err) {
+		if err := rebuildHgExportState(ctx, dir, hgDir); err != nil {
+			return errors.Wrap(err, "failed to rebuild hg-fast-export state")
+		}
+	}
+
+	args := []string{"-r", hgDir, "--force", "--hg-hash"}
+	if len(s.Authors) > 0 {
+		f, err := os.CreateTemp("", "hg-authors-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.Write(hgAuthorsFile(s.Authors))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		args = append(args, "-A", f.Name())
+	}
+
+	cmd = exec.CommandContext(ctx, "hg-fast-export.sh", args...)
+	cmd.Env = hgCommandEnv()
+	dir.Set(cmd)
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to convert with output %q", redactor.redact(string(output)))
+	}
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *MercurialRepoSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	// The converted repository has no Git remote, so there is no remote HEAD
+	// to find and the default of master, which hg-fast-export converts the
+	// default branch to, is used.
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// hgExportPrefix is the prefix of the files hg-fast-export keeps its state
+// in, in the Git directory.
+const hgExportPrefix = "hg2git"
+
+// hgNotesRef is the ref of the notes hg-fast-export --hg-hash writes, which
+// record the Mercurial changeset of each converted commit.
+const hgNotesRef = "refs/notes/hg"
+
+// rebuildHgExportState writes the state of hg-fast-export for the commits in
+// dir which were converted from changesets of the Mercurial clone in hgDir.
+// Nothing is written if there are no such commits, in which case all
+// changesets are converted.
+func rebuildHgExportState(ctx context.Context, dir GitDir, hgDir string) error {
+	cmd := exec.CommandContext(ctx, "git", "log", "--branches", "--notes="+hgNotesRef, "--format=%H %N")
+	dir.Set(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list converted changesets")
+	}
+	converted := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			converted[fields[1]] = fields[0]
+		}
+	}
+	if len(converted) == 0 {
+		return nil
+	}
+
+	cmd = exec.CommandContext(ctx, "hg", "log", "-r", "0:tip", "--template", "{node}\n")
+	cmd.Dir = hgDir
+	cmd.Env = hgCommandEnv()
+	out, err = cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list changesets")
+	}
+	nodes := strings.Fields(string(out))
+
+	files, ok := hgExportState(hgDir, nodes, converted)
+	if !ok {
+		log15.Warn("Converted changesets do not match the Mercurial clone, converting all changesets.", "dir", dir)
+		return nil
+	}
+	for suffix, content := range files {
+		if err := os.WriteFile(dir.Path(hgExportPrefix+"-"+suffix), content, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hgExportState returns the content of the hg-fast-export state files, by
+// suffix, given the changeset nodes of the Mercurial clone in revision order
+// and the Git commit each converted node maps to.
+//
+// hg-fast-export converts revisions in order and resumes after the last
+// converted one, so the converted nodes have to be the first revisions of the
+// clone. ok is false otherwise.
+func hgExportState(hgDir string, nodes []string, converted map[string]string) (files map[string][]byte, ok bool) {
+	tip := 0
+	for tip < len(nodes) && converted[nodes[tip]] != "" {
+		tip++
+	}
+	if tip != len(converted) {
+		return nil, false
+	}
+
+	// Marks map the mark of a revision, which is its number plus one, to the
+	// Git commit. The mapping maps revision numbers to changeset nodes.
+	var marks, mapping bytes.Buffer
+	for rev, node := range nodes[:tip] {
+		fmt.Fprintf(&marks, ":%d %s\n", rev+1, converted[node])
+		fmt.Fprintf(&mapping, ":%d %s\n", rev, node)
+	}
+	return map[string][]byte{
+		"marks":   marks.Bytes(),
+		"mapping": mapping.Bytes(),
+		"state":   []byte(fmt.Sprintf(":repo %s\n:tip %d\n", hgDir, tip)),
+	}, true
+}
+
+// hgCommandEnv returns the environment for hg commands. HGPLAIN disables user
+// configuration which changes the output or behaviour of hg.
+func hgCommandEnv() []string {
+	return append(os.Environ(), "HGPLAIN=1")
+}
+
+// hgAuthorsFile returns the content of an hg-fast-export authors file.
+func hgAuthorsFile(authors map[string]string) []byte {
+	keys := make([]string, 0, len(authors))
+	for k := range authors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q=%q\n", k, authors[k])
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,46 @@
+package server
+
+import "testing"
+
+func TestHgAuthorsFile(t *testing.T) {
+	got := string(hgAuthorsFile(map[string]string{
+		"jdoe":                  "Jane Doe <jane@example.com>",
+		`Jane "JD" <jane@home>`: "Jane Doe <jane@example.com>",
+	}))
+	want := `"Jane \"JD\" <jane@home>"="Jane Doe <jane@example.com>"` + "\n" +
+		`"jdoe"="Jane Doe <jane@example.com>"` + "\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHgExportState(t *testing.T) {
+	nodes := []string{"n0", "n1", "n2"}
+
+	files, ok := hgExportState("/hg", nodes, map[string]string{"n0": "c0", "n1": "c1"})
+	if !ok {
+		t.Fatal("expected state for converted prefix")
+	}
+	want := map[string]string{
+		"marks":   ":1 c0\n:2 c1\n",
+		"mapping": ":0 n0\n:1 n1\n",
+		"state":   ":repo /hg\n:tip 2\n",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %d", len(files), len(want))
+	}
+	for suffix, content := range want {
+		if got := string(files[suffix]); got != content {
+			t.Errorf("%s: got %q, want %q", suffix, got, content)
+		}
+	}
+
+	// Converted changesets which aren't the first revisions of the clone,
+	// e.g. because it orders them differently, can't be resumed from.
+	if _, ok := hgExportState("/hg", nodes, map[string]string{"n0": "c0", "n2": "c2"}); ok {
+		t.Error("expected no state for converted changesets with a gap")
+	}
+	if _, ok := hgExportState("/hg", nodes, map[string]string{"n0": "c0", "n3": "c3"}); ok {
+		t.Error("expected no state for changesets missing from the clone")
+	}
+}
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/repos"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// sourcegraphPackagesGitName is used to set GIT_AUTHOR_NAME for git commands
+// of package syncers that don't create commits or tags. It should never be
+// publicly visible.
+const sourcegraphPackagesGitName = "sourcegraph authors"
+
+// NpmPackagesSyncer creates git repositories from the tarballs of npm
+// packages, with one tag per version.
+type NpmPackagesSyncer struct {
+	Config  *schema.NpmPackagesConnection
+	DBStore repos.NpmPackagesRepoStore
+	Client  *npm.Client
+}
+
+var _ VCSSyncer = &NpmPackagesSyncer{}
+
+func (s *NpmPackagesSyncer) Type() string {
+	return "npm_packages"
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *NpmPackagesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	_, err := s.packageDependencies(ctx, remoteURL.Path)
+	return err
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for JVM packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *NpmPackagesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	err := os.MkdirAll(bareGitDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, bareGitDirectory, sourcegraphPackagesGitName); err != nil {
+		return nil, err
+	}
+
+	// The Fetch method is responsible for cleaning up temporary directories.
+	if err := s.Fetch(ctx, remoteURL, GitDir(bareGitDirectory)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch adds git tags for newly added package versions and removes git tags
+// for deleted versions.
+func (s *NpmPackagesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	dependencies, err := s.packageDependencies(ctx, remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]bool{}
+
+	out, err := runCommandWithGitAuthor(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir), sourcegraphPackagesGitName)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		tags[line] = true
+	}
+
+	for i, dependency := range dependencies {
+		if tags[dependency.GitTagFromVersion()] {
+			continue
+		}
+		// the gitPushDependencyTag method is reponsible for cleaning up temporary directories.
+		if err := s.gitPushDependencyTag(ctx, string(dir), dependency, i == 0); err != nil {
+			return errors.Wrapf(err, "error pushing dependency %q", dependency.PackageManagerSyntax())
+		}
+	}
+
+	dependencyTags := make(map[string]struct{}, len(dependencies))
+	for _, dependency := range dependencies {
+		dependencyTags[dependency.GitTagFromVersion()] = struct{}{}
+	}
+
+	for tag := range tags {
+		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
+			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			if _, err := runCommandWithGitAuthor(ctx, cmd, string(dir), sourcegraphPackagesGitName); err != nil {
+				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *NpmPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// packageDependencies returns the versions of the npm package that belongs to
+// the given URL path, from the configuration and from LSIF uploads. The
+// returned dependencies are sorted with the latest version first.
+func (s *NpmPackagesSyncer) packageDependencies(ctx context.Context, repoUrlPath string) (dependencies []reposource.NpmDependency, err error) {
+	pkg, err := reposource.ParseNpmPackageFromRepoURL(repoUrlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	configDependencies, err := repos.NpmDependencies(*s.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	isAdded := map[string]bool{}
+
+	var totalConfigMatched int
+	for _, dependency := range configDependencies {
+		if dependency.NpmPackage != pkg || isAdded[dependency.Version] {
+			continue
+		}
+		exists, err := s.Client.DoesDependencyExist(ctx, dependency)
+		if err != nil {
+			log15.Warn("error checking npm dependency", "error", err, "dependency", dependency.PackageManagerSyntax())
+		}
+		if exists {
+			totalConfigMatched++
+			isAdded[dependency.Version] = true
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	dbDeps, err := s.DBStore.GetNpmDependencyRepos(ctx, dbstore.GetNpmDependencyReposOpts{
+		PackageName: pkg.PackageSyntax(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get npm dependency repos from database for %s", repoUrlPath)
+	}
+
+	var totalDBMatched int
+	for _, dep := range dbDeps {
+		dependency, err := reposource.ParseNpmDependency(dep.Package + "@" + dep.Version)
+		if err != nil {
+			log15.Warn("error parsing npm dependency", "error", err, "package", dep.Package, "version", dep.Version)
+			continue
+		}
+		if dependency.NpmPackage != pkg || isAdded[dependency.Version] {
+			continue
+		}
+		// we dont call DoesDependencyExist here, as existence should be verified by repo-updater
+		totalDBMatched++
+		isAdded[dependency.Version] = true
+		dependencies = append(dependencies, dependency)
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no npm dependencies for URL path %s", repoUrlPath)
+	}
+
+	log15.Info("fetched npm package versions for repo path", "repoPath", repoUrlPath, "totalDB", totalDBMatched, "totalConfig", totalConfigMatched)
+	reposource.SortNpmDependencies(dependencies)
+	return dependencies, nil
+}
+
+// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
+// tag points to a commit that adds all the files of the package tarball. When
+// isLatestVersion is true, the latest branch of the bare git directory will
+// also be updated to point to the same commit as the git tag.
+func (s *NpmPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDirectory string, dependency reposource.NpmDependency, isLatestVersion bool) error {
+	tmpDirectory, err := os.MkdirTemp("", "npm")
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary directories.
+	defer os.RemoveAll(tmpDirectory)
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "init")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if err := s.commitTarball(ctx, dependency, tmpDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", "--tags")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if isLatestVersion {
+		defaultBranch, err := runCommandWithGitAuthor(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), tmpDirectory, gitName)
+		if err != nil {
+			return err
+		}
+		// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+		cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dependency.GitTagFromVersion())
+		if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitTarball creates a git commit in the given working directory that adds
+// all the files of the tarball of the given dependency, and tags it.
+func (s *NpmPackagesSyncer) commitTarball(ctx context.Context, dependency reposource.NpmDependency, workingDirectory string) error {
+	tarball, err := s.Client.FetchTarball(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	if err := unpackTarball(tarball, workingDirectory, true); err != nil {
+		return errors.Wrapf(err, "failed to unpack tarball for %s", dependency.PackageManagerSyntax())
+	}
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "add", ".")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "commit", "--no-verify", "--allow-empty", "-m", dependency.PackageManagerSyntax(), "--date", stableGitCommitDate)
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dependency.PackageManagerSyntax(), dependency.GitTagFromVersion())
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// unpackTarball extracts the regular files of a gzipped package tarball into
+// destination. If stripTopLevelDirectory is true, the files are expected in a
+// single top-level directory, which is stripped. npm puts the files of a
+// package into "package/", for example.
+func unpackTarball(r io.Reader, destination string, stripTopLevelDirectory bool) error {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	destinationDirectory := strings.TrimSuffix(destination, string(os.PathSeparator)) + string(os.PathSeparator)
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Skip directories, links and other special files. Directories are
+		// created as needed for the files inside them.
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		if path.IsAbs(header.Name) || hasParentDirectoryElement(header.Name) {
+			// Skip absolute paths and paths which go up the tree, like
+			// unzipJarFile does.
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if stripTopLevelDirectory {
+			i := strings.Index(name, "/")
+			if i < 0 {
+				// Files outside of the top-level directory aren't part of the
+				// package.
+				continue
+			}
+			name = name[i+1:]
+		}
+
+		if isUnderGitDirectory(name) {
+			// For security reasons, don't unpack files under `.git/`
+			// directories. See https://github.com/sourcegraph/security-issues/issues/163
+			continue
+		}
+
+		outputPath := filepath.Join(destination, filepath.FromSlash(name))
+		if !strings.HasPrefix(outputPath, destinationDirectory) {
+			// For security reasons, skip file if it's not a child
+			// of the target directory. See "Zip Slip Vulnerability".
+			continue
+		}
+
+		if err := copyTarFileEntry(tarReader, outputPath, header.FileInfo().Mode()); err != nil {
+			return err
+		}
+	}
+}
+
+// hasParentDirectoryElement returns true if any element of the
+// slash-separated path is "..".
+func hasParentDirectoryElement(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderGitDirectory returns true if any directory of the slash-separated
+// path is named ".git".
+func isUnderGitDirectory(name string) bool {
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if strings.EqualFold(part, ".git") {
+			return true
+		}
+	}
+	return false
+}
+
+func copyTarFileEntry(r io.Reader, outputPath string, mode os.FileMode) (err error) {
+	if err = os.MkdirAll(filepath.Dir(outputPath), 0700); err != nil {
+		return err
+	}
+
+	// Only the executable bit is kept, since it's the only permission git
+	// tracks.
+	perm := os.FileMode(0600)
+	if mode&0100 != 0 {
+		perm = 0700
+	}
+
+	outputFile, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err1 := outputFile.Close()
+		if err == nil {
+			err = err1
+		}
+	}()
+
+	_, err = io.Copy(outputFile, r)
+	return err
+}
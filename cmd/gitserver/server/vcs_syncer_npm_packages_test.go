@@ -0,0 +1,193 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	exampleNpmFilePath      = "index.js"
+	exampleNpmFileContents  = "module.exports = 1;\n"
+	exampleNpmFileContents2 = "module.exports = 2;\n"
+	exampleNpmPackageUrl    = "npm/example/pkg"
+)
+
+// createNpmTarball returns a gzipped tarball with the given files, in the
+// layout of an npm package.
+func createNpmTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		assert.Nil(t, tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+		}))
+		_, err := tarWriter.Write([]byte(contents))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// npmRegistryServer returns a registry which serves the @example/pkg package
+// with the given tarballs, keyed by version.
+func npmRegistryServer(t *testing.T, tarballs map[string][]byte) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() == "/@example%2Fpkg" {
+			versions := map[string]interface{}{}
+			for version := range tarballs {
+				versions[version] = map[string]interface{}{
+					"dist": map[string]string{"tarball": fmt.Sprintf("%s/@example/pkg/-/pkg-%s.tgz", srv.URL, version)},
+				}
+			}
+			assert.Nil(t, json.NewEncoder(w).Encode(map[string]interface{}{"versions": versions}))
+			return
+		}
+		for version, tarball := range tarballs {
+			if r.URL.Path == fmt.Sprintf("/@example/pkg/-/pkg-%s.tgz", version) {
+				w.Write(tarball)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	return srv
+}
+
+func (s NpmPackagesSyncer) runCloneCommand(t *testing.T, bareGitDirectory string, dependencies []string) {
+	url := vcs.URL{
+		URL: url.URL{Path: exampleNpmPackageUrl},
+	}
+	s.Config.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestNpmCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := npmRegistryServer(t, map[string][]byte{
+		"1.0.0": createNpmTarball(t, map[string]string{"package/" + exampleNpmFilePath: exampleNpmFileContents}),
+		"2.0.0": createNpmTarball(t, map[string]string{"package/" + exampleNpmFilePath: exampleNpmFileContents2}),
+		"3.0.0": createNpmTarball(t, map[string]string{"package/" + exampleNpmFilePath: exampleNpmFileContents2}),
+	})
+	defer srv.Close()
+
+	s := NpmPackagesSyncer{
+		Config:  &schema.NpmPackagesConnection{Registry: srv.URL},
+		DBStore: simpleNpmPackageDBStoreMock{{ID: 1, Package: "@example/pkg", Version: "3.0.0"}},
+		Client:  npm.NewHTTPClient(srv.URL, "", nil),
+	}
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, bareGitDirectory, []string{"@example/pkg@1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv3.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleNpmFilePath),
+		bareGitDirectory,
+		exampleNpmFileContents,
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{"@example/pkg@1.0.0", "@example/pkg@2.0.0", "@example/pkg@4.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0\nv3.0.0\n", // verify that the v2.0.0 tag got added and v4.0.0, which doesn't exist, was skipped
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v2.0.0:"+exampleNpmFilePath),
+		bareGitDirectory,
+		exampleNpmFileContents2,
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, nil)
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v3.0.0\n", // verify that the tags of versions removed from the config have been removed.
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleNpmFilePath),
+		bareGitDirectory,
+		exampleNpmFileContents2,
+	)
+}
+
+func TestUnpackNpmTarballNoMaliciousFiles(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	tarball := createNpmTarball(t, map[string]string{
+		"package/index.js":         "ok",
+		"package/lib/util.js":      "ok",
+		"package/../../burger":     "bad",
+		"/package/../etc/burger":   "bad",
+		"package/.git/config":      "bad",
+		"package/lib/../../../x":   "bad",
+		"outside-of-package.txt":   "bad",
+		"package/nested/.GIT/head": "bad",
+	})
+	assert.Nil(t, unpackTarball(bytes.NewReader(tarball), dir, true))
+
+	var files []string
+	assert.Nil(t, filepathWalkFiles(dir, func(p string) { files = append(files, p) }))
+	assert.ElementsMatch(t, []string{"index.js", "lib/util.js"}, files)
+}
+
+// filepathWalkFiles calls f with the slash-separated path relative to root of
+// every regular file under root.
+func filepathWalkFiles(root string, f func(string)) error {
+	return fs.WalkDir(os.DirFS(root), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			f(p)
+		}
+		return nil
+	})
+}
+
+type simpleNpmPackageDBStoreMock []dbstore.NpmDependencyRepo
+
+func (m simpleNpmPackageDBStoreMock) GetNpmDependencyRepos(ctx context.Context, filter dbstore.GetNpmDependencyReposOpts) (repos []dbstore.NpmDependencyRepo, _ error) {
+	for _, repo := range m {
+		if filter.PackageName == "" || filter.PackageName == repo.Package {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages/registry"
+	"github.com/sourcegraph/sourcegraph/internal/repos"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// RegistryPackagesSyncer creates git repositories from the sources of
+// packages of a language package registry, with one tag per version. It
+// supports the registries of reposource.RegistryPackage, which are synced
+// like npm packages.
+type RegistryPackagesSyncer struct {
+	// Scheme is the moniker scheme of the packages, such as "cargo".
+	Scheme string
+	// Dependencies are the configured dependencies of the code host
+	// connection, in the syntax of the package manager.
+	Dependencies []string
+	DBStore      repos.RegistryPackagesRepoStore
+	Client       *registry.Client
+}
+
+var _ VCSSyncer = &RegistryPackagesSyncer{}
+
+func (s *RegistryPackagesSyncer) Type() string {
+	return s.Scheme + "_packages"
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *RegistryPackagesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	_, err := s.packageDependencies(ctx, remoteURL.Path)
+	return err
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for npm packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *RegistryPackagesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	err := os.MkdirAll(bareGitDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, bareGitDirectory, sourcegraphPackagesGitName); err != nil {
+		return nil, err
+	}
+
+	// The Fetch method is responsible for cleaning up temporary directories.
+	if err := s.Fetch(ctx, remoteURL, GitDir(bareGitDirectory)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch adds git tags for newly added package versions and removes git tags
+// for deleted versions.
+func (s *RegistryPackagesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	dependencies, err := s.packageDependencies(ctx, remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]bool{}
+
+	out, err := runCommandWithGitAuthor(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir), sourcegraphPackagesGitName)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		tags[line] = true
+	}
+
+	for i, dependency := range dependencies {
+		if tags[dependency.GitTagFromVersion()] {
+			continue
+		}
+		// the gitPushDependencyTag method is reponsible for cleaning up temporary directories.
+		if err := s.gitPushDependencyTag(ctx, string(dir), dependency, i == 0); err != nil {
+			return errors.Wrapf(err, "error pushing dependency %q", dependency.PackageManagerSyntax())
+		}
+	}
+
+	dependencyTags := make(map[string]struct{}, len(dependencies))
+	for _, dependency := range dependencies {
+		dependencyTags[dependency.GitTagFromVersion()] = struct{}{}
+	}
+
+	for tag := range tags {
+		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
+			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			if _, err := runCommandWithGitAuthor(ctx, cmd, string(dir), sourcegraphPackagesGitName); err != nil {
+				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *RegistryPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// packageDependencies returns the versions of the package that belongs to the
+// given URL path, from the configuration and from LSIF uploads. The returned
+// dependencies are sorted with the latest version first.
+func (s *RegistryPackagesSyncer) packageDependencies(ctx context.Context, repoUrlPath string) (dependencies []reposource.RegistryDependency, err error) {
+	pkg, err := reposource.ParseRegistryPackageFromRepoURL(repoUrlPath)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Scheme != s.Scheme {
+		return nil, errors.Errorf("URL path %s is not a %s package", repoUrlPath, s.Scheme)
+	}
+
+	configDependencies, err := repos.RegistryDependencies(s.Scheme, s.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	isAdded := map[string]bool{}
+
+	var totalConfigMatched int
+	for _, dependency := range configDependencies {
+		if dependency.RegistryPackage != pkg || isAdded[dependency.Version] {
+			continue
+		}
+		exists, err := s.Client.DoesDependencyExist(ctx, dependency)
+		if err != nil {
+			log15.Warn("error checking registry dependency", "error", err, "dependency", dependency.PackageManagerSyntax())
+		}
+		if exists {
+			totalConfigMatched++
+			isAdded[dependency.Version] = true
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	// The dependency sync scheduler records normalized package names, so they
+	// can be matched exactly.
+	dbDeps, err := s.DBStore.GetRegistryDependencyRepos(ctx, dbstore.GetRegistryDependencyReposOpts{
+		Scheme:      s.Scheme,
+		PackageName: pkg.PackageSyntax(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get registry dependency repos from database for %s", repoUrlPath)
+	}
+
+	var totalDBMatched int
+	for _, dep := range dbDeps {
+		if isAdded[dep.Version] {
+			continue
+		}
+		// we dont call DoesDependencyExist here, as existence should be verified by repo-updater
+		totalDBMatched++
+		isAdded[dep.Version] = true
+		dependencies = append(dependencies, reposource.RegistryDependency{RegistryPackage: pkg, Version: dep.Version})
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no %s dependencies for URL path %s", s.Scheme, repoUrlPath)
+	}
+
+	log15.Info("fetched registry package versions for repo path", "repoPath", repoUrlPath, "totalDB", totalDBMatched, "totalConfig", totalConfigMatched)
+	reposource.SortRegistryDependencies(dependencies)
+	return dependencies, nil
+}
+
+// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
+// tag points to a commit that adds all the sources of the package. When
+// isLatestVersion is true, the latest branch of the bare git directory will
+// also be updated to point to the same commit as the git tag.
+func (s *RegistryPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDirectory string, dependency reposource.RegistryDependency, isLatestVersion bool) error {
+	tmpDirectory, err := os.MkdirTemp("", s.Scheme)
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary directories.
+	defer os.RemoveAll(tmpDirectory)
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "init")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if err := s.commitTarball(ctx, dependency, tmpDirectory); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", "--tags")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if isLatestVersion {
+		defaultBranch, err := runCommandWithGitAuthor(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), tmpDirectory, gitName)
+		if err != nil {
+			return err
+		}
+		// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+		cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dependency.GitTagFromVersion())
+		if _, err := runCommandWithGitAuthor(ctx, cmd, tmpDirectory, gitName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitTarball creates a git commit in the given working directory that adds
+// all the source files of the given dependency, and tags it.
+func (s *RegistryPackagesSyncer) commitTarball(ctx context.Context, dependency reposource.RegistryDependency, workingDirectory string) error {
+	tarball, topLevelDirectory, err := s.Client.FetchTarball(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	if err := unpackTarball(tarball, workingDirectory, topLevelDirectory); err != nil {
+		return errors.Wrapf(err, "failed to unpack tarball for %s", dependency.PackageManagerSyntax())
+	}
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "add", ".")
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "commit", "--no-verify", "--allow-empty", "-m", dependency.PackageManagerSyntax(), "--date", stableGitCommitDate)
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dependency.PackageManagerSyntax(), dependency.GitTagFromVersion())
+	if _, err := runCommandWithGitAuthor(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	return nil
+}
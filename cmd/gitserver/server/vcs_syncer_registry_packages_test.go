@@ -0,0 +1,170 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages/registry"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+const (
+	exampleCrateFilePath      = "src/lib.rs"
+	exampleCrateFileContents  = "pub fn one() -> u32 { 1 }\n"
+	exampleCrateFileContents2 = "pub fn two() -> u32 { 2 }\n"
+)
+
+// cratesRegistryServer returns a registry which serves the example crate
+// with the given crates, keyed by version.
+func cratesRegistryServer(t *testing.T, crates map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for version, crate := range crates {
+			switch r.URL.Path {
+			case "/api/v1/crates/example/" + version:
+				fmt.Fprintf(w, `{"version": {"num": %q}}`, version)
+				return
+			case "/api/v1/crates/example/" + version + "/download":
+				w.Write(crate)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func (s RegistryPackagesSyncer) runCloneCommand(t *testing.T, repoURLPath, bareGitDirectory string, dependencies []string) {
+	url := vcs.URL{
+		URL: url.URL{Path: repoURLPath},
+	}
+	s.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestRegistryPackagesCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := cratesRegistryServer(t, map[string][]byte{
+		"1.0.0": createNpmTarball(t, map[string]string{"example-1.0.0/" + exampleCrateFilePath: exampleCrateFileContents}),
+		"2.0.0": createNpmTarball(t, map[string]string{"example-2.0.0/" + exampleCrateFilePath: exampleCrateFileContents2}),
+		"3.0.0": createNpmTarball(t, map[string]string{"example-3.0.0/" + exampleCrateFilePath: exampleCrateFileContents2}),
+	})
+	defer srv.Close()
+
+	s := RegistryPackagesSyncer{
+		Scheme: "cargo",
+		DBStore: simpleRegistryPackageDBStoreMock{
+			{ID: 1, Package: "example", Version: "3.0.0"},
+			{ID: 2, Package: "other", Version: "1.0.0"},
+		},
+		Client: registry.NewHTTPClient("cargo", srv.URL, nil),
+	}
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, "crates/example", bareGitDirectory, []string{"example@1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv3.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleCrateFilePath),
+		bareGitDirectory,
+		exampleCrateFileContents,
+	)
+
+	s.runCloneCommand(t, "crates/example", bareGitDirectory, []string{"example@1.0.0", "example@2.0.0", "example@4.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0\nv3.0.0\n", // verify that the v2.0.0 tag got added and v4.0.0, which doesn't exist, was skipped
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v2.0.0:"+exampleCrateFilePath),
+		bareGitDirectory,
+		exampleCrateFileContents2,
+	)
+
+	s.runCloneCommand(t, "crates/example", bareGitDirectory, nil)
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v3.0.0\n", // verify that the tags of versions removed from the config have been removed.
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleCrateFilePath),
+		bareGitDirectory,
+		exampleCrateFileContents2,
+	)
+
+	// Repositories of other registries aren't synced by this syncer.
+	assert.NotNil(t, s.IsCloneable(context.Background(), &vcs.URL{URL: url.URL{Path: "rubygems/example"}}))
+}
+
+func TestRegistryPackagesCloneCommandGem(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Gems contain their files in a data.tar.gz file, without a top-level
+	// directory.
+	var gem bytes.Buffer
+	data := createNpmTarball(t, map[string]string{"lib/example.rb": "module Example; end\n"})
+	tarWriter := tar.NewWriter(&gem)
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "data.tar.gz", Mode: 0644, Size: int64(len(data))}))
+	_, err = tarWriter.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, tarWriter.Close())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/rubygems/example/versions/1.0.0.json":
+			fmt.Fprint(w, `{"number": "1.0.0"}`)
+		case "/downloads/example-1.0.0.gem":
+			w.Write(gem.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s := RegistryPackagesSyncer{
+		Scheme:  "gem",
+		DBStore: simpleRegistryPackageDBStoreMock{},
+		Client:  registry.NewHTTPClient("gem", srv.URL, nil),
+	}
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, "rubygems/example", bareGitDirectory, []string{"example@1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:lib/example.rb"),
+		bareGitDirectory,
+		"module Example; end\n",
+	)
+}
+
+type simpleRegistryPackageDBStoreMock []dbstore.RegistryDependencyRepo
+
+func (m simpleRegistryPackageDBStoreMock) GetRegistryDependencyRepos(ctx context.Context, filter dbstore.GetRegistryDependencyReposOpts) (repos []dbstore.RegistryDependencyRepo, _ error) {
+	for _, repo := range m {
+		if filter.PackageName == "" || filter.PackageName == repo.Package {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// SubversionRepoSyncer is a syncer for Subversion repositories. It converts
+// them to Git repositories with git-svn.
+type SubversionRepoSyncer struct {
+	// Layout is the layout of the repositories, either "standard" or "root".
+	// An empty layout is treated as "standard".
+	Layout string
+
+	// Authors maps Subversion usernames to Git authors of the form
+	// "Full Name <email>".
+	Authors map[string]string
+}
+
+var _ VCSSyncer = &SubversionRepoSyncer{}
+
+func (s *SubversionRepoSyncer) Type() string {
+	return "subversion"
+}
+
+// decomposeSubversionRemoteURL splits the credentials off a clone URL for a
+// Subversion repository. Neither svn nor git-svn read them from the URL.
+func decomposeSubversionRemoteURL(remoteURL *vcs.URL) (username, password, repoURL string) {
+	u := remoteURL.URL
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	u.User = nil
+	return username, password, u.String()
+}
+
+// IsCloneable checks to see if the Subversion remote URL is cloneable.
+func (s *SubversionRepoSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	username, password, repoURL := decomposeSubversionRemoteURL(remoteURL)
+
+	args := []string{"info", "--non-interactive", "--no-auth-cache"}
+	if username != "" {
+		args = append(args, "--username", username, "--password-from-stdin")
+	}
+	cmd := exec.CommandContext(ctx, "svn", append(args, repoURL)...)
+	cmd.Stdin = strings.NewReader(password)
+
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to check remote access: %s", newURLRedactor(remoteURL).redact(string(output)))
+	}
+	return nil
+}
+
+// CloneCommand returns the command to be executed for cloning a Subversion
+// repository as a Git repository. git-svn can only clone into a repository
+// which has been configured for it, so the actual cloning happens inside this
+// method and the returned command is a no-op, like for JVM packages.
+func (s *SubversionRepoSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, tmpPath string) (*exec.Cmd, error) {
+	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "clone failed to create tmp dir")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", ".")
+	cmd.Dir = tmpPath
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "clone setup failed")
+	}
+
+	if err := s.Fetch(ctx, remoteURL, GitDir(tmpPath)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch fetches the revisions of the Subversion repository which haven't been
+// converted yet. git-svn keeps track of the last converted revision in dir.
+func (s *SubversionRepoSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	username, password, repoURL := decomposeSubversionRemoteURL(remoteURL)
+
+	// The configuration is rewritten on every fetch so that changes to the
+	// layout or URL of the external service apply to existing clones.
+	for _, key := range []string{"url", "fetch", "branches", "tags"} {
+		cmd := exec.CommandContext(ctx, "git", "config", "--unset-all", "svn-remote.svn."+key)
+		dir.Set(cmd)
+		// Exit status 5 means the key wasn't set, which is fine.
+		_ = cmd.Run()
+	}
+	for _, kv := range svnRemoteConfig(s.Layout, repoURL) {
+		cmd := exec.CommandContext(ctx, "git", "config", "--add", kv[0], kv[1])
+		dir.Set(cmd)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "failed to configure git-svn: %s", newURLRedactor(remoteURL).redact(string(output)))
+		}
+	}
+
+	helpers, err := os.MkdirTemp("", "git-svn-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(helpers)
+
+	// git-svn asks for the password through GIT_ASKPASS. Authors which aren't
+	// mapped are passed to the authors program, which keeps the username.
+	askpass := filepath.Join(helpers, "askpass")
+	authorsProg := filepath.Join(helpers, "authors-prog")
+	authorsFile := filepath.Join(helpers, "authors")
+	for path, content := range map[string]string{
+		askpass:     "#!/bin/sh\nprintf '%s\\n' \"$SRC_SVN_PASSWORD\"\n",
+		authorsProg: "#!/bin/sh\nprintf '%s <%s>\\n' \"$1\" \"$1\"\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0700); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"svn", "fetch", "--authors-prog", authorsProg}
+	if len(s.Authors) > 0 {
+		if err := os.WriteFile(authorsFile, svnAuthorsFile(s.Authors), 0600); err != nil {
+			return err
+		}
+		args = append(args, "--authors-file", authorsFile)
+	}
+	if username != "" {
+		args = append(args, "--username", username, "--no-auth-cache")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_ASKPASS="+askpass,
+		"SRC_SVN_PASSWORD="+password,
+	)
+	dir.Set(cmd)
+
+	if output, err := runWith(ctx, cmd, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to fetch with output %q", newURLRedactor(remoteURL).redact(string(output)))
+	}
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *SubversionRepoSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	// The converted repository has no Git remote, so there is no remote HEAD
+	// to find and the default of master is used.
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// svnRemoteConfig returns the git-svn configuration which maps the paths of
+// a Subversion repository with the given layout to Git refs.
+func svnRemoteConfig(layout, repoURL string) [][2]string {
+	config := [][2]string{{"svn-remote.svn.url", repoURL}}
+	if layout == "root" {
+		return append(config, [2]string{"svn-remote.svn.fetch", ":refs/heads/master"})
+	}
+	return append(config,
+		[2]string{"svn-remote.svn.fetch", "trunk:refs/heads/master"},
+		[2]string{"svn-remote.svn.branches", "branches/*:refs/heads/*"},
+		[2]string{"svn-remote.svn.tags", "tags/*:refs/tags/*"},
+	)
+}
+
+// svnAuthorsFile returns the content of a git-svn authors file.
+func svnAuthorsFile(authors map[string]string) []byte {
+	users := make([]string, 0, len(authors))
+	for user := range authors {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	var b bytes.Buffer
+	for _, user := range users {
+		fmt.Fprintf(&b, "%s = %s\n", user, authors[user])
+	}
+	return b.Bytes()
+}
@@ -7,12 +7,14 @@ import (
 	"net/http"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/cmd/query-runner/queryrunnerapi"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/rcache"
 )
 
 // diffSavedQueryConfigs takes the old and new saved queries configurations.
@@ -62,6 +64,46 @@ func diffSavedQueryConfigs(oldList, newList map[api.SavedQueryIDSpec]api.ConfigS
 	return deleted, updated, created
 }
 
+// watchSavedQueryChanges notifies the recipients of saved searches when they
+// are subscribed or unsubscribed, by polling the list of saved searches for
+// changes.
+//
+// Only one replica may do this at a time, or recipients would be notified
+// more than once. Replicas take turns through a distributed mutex.
+func watchSavedQueryChanges(ctx context.Context) {
+	for {
+		lockCtx, release, ok := rcache.TryAcquireMutex(ctx, "query-runner-saved-query-changes", rcache.MutexOptions{})
+		if !ok {
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		pollSavedQueryChanges(lockCtx)
+		release()
+	}
+}
+
+// pollSavedQueryChanges polls the list of saved searches for changes until ctx
+// is canceled.
+func pollSavedQueryChanges(ctx context.Context) {
+	var oldList map[api.SavedQueryIDSpec]api.ConfigSavedQuery
+	for ctx.Err() == nil {
+		allSavedQueries, err := api.InternalClient.SavedQueriesListAll(ctx)
+		if err != nil {
+			log15.Error("query-runner: error fetching saved queries list (trying again in 5s)", "error", err)
+		} else {
+			if oldList != nil {
+				sendNotificationsForCreatedOrUpdatedOrDeleted(oldList, allSavedQueries)
+			}
+			oldList = allSavedQueries
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
 func sendNotificationsForCreatedOrUpdatedOrDeleted(oldList, newList map[api.SavedQueryIDSpec]api.ConfigSavedQuery) {
 	deleted, updated, created := diffSavedQueryConfigs(oldList, newList)
 	for oldVal, newVal := range deleted {
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/cockroachdb/errors"
-	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/txemail"
@@ -24,51 +23,45 @@ func canSendEmail(ctx context.Context) error {
 	return nil
 }
 
-func (n *notifier) emailNotify(ctx context.Context) {
+// emailNotify emails the recipient with the given user ID about the new
+// results.
+func (n *notifier) emailNotify(ctx context.Context, userID int32) error {
 	if err := canSendEmail(ctx); err != nil {
-		log15.Error("Failed to send email notification for saved search.", "error", err)
-		return
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	ownership := "the" // example: "new search results have been found for {{.Ownership}} saved search"
+	if n.spec.Subject.User != nil && *n.spec.Subject.User == userID {
+		ownership = "your"
+	}
+	if n.spec.Subject.Org != nil {
+		ownership = "your organization's"
 	}
 
-	// Send tx emails asynchronously.
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		defer cancel()
-
-		for _, recipient := range n.recipients {
-			ownership := "the" // example: "new search results have been found for {{.Ownership}} saved search"
-			if n.spec.Subject.User != nil && *n.spec.Subject.User == recipient.spec.userID {
-				ownership = "your"
-			}
-			if n.spec.Subject.Org != nil {
-				ownership = "your organization's"
-			}
-
-			plural := ""
-			if n.results.Data.Search.Results.ApproximateResultCount != "1" {
-				plural = "s"
-			}
-			if err := sendEmail(ctx, recipient.spec.userID, "results", newSearchResultsEmailTemplates, struct {
-				URL                    string
-				SavedSearchPageURL     string
-				Description            string
-				Query                  string
-				ApproximateResultCount string
-				Ownership              string
-				PluralResults          string
-			}{
-				URL:                    searchURL(n.newQuery, utmSourceEmail),
-				SavedSearchPageURL:     savedSearchListPageURL(utmSourceEmail),
-				Description:            n.query.Description,
-				Query:                  n.query.Query,
-				ApproximateResultCount: n.results.Data.Search.Results.ApproximateResultCount,
-				Ownership:              ownership,
-				PluralResults:          plural,
-			}); err != nil {
-				log15.Error("Failed to send email notification for new saved search results.", "userID", recipient.spec.userID, "error", err)
-			}
-		}
-	}()
+	plural := ""
+	if n.approximateResultCount != "1" {
+		plural = "s"
+	}
+	return sendEmail(ctx, userID, "results", newSearchResultsEmailTemplates, struct {
+		URL                    string
+		SavedSearchPageURL     string
+		Description            string
+		Query                  string
+		ApproximateResultCount string
+		Ownership              string
+		PluralResults          string
+	}{
+		URL:                    searchURL(n.newQuery, utmSourceEmail),
+		SavedSearchPageURL:     savedSearchListPageURL(utmSourceEmail),
+		Description:            n.query.Description,
+		Query:                  n.query.Query,
+		ApproximateResultCount: n.approximateResultCount,
+		Ownership:              ownership,
+		PluralResults:          plural,
+	})
 }
 
 var newSearchResultsEmailTemplates = txemail.MustValidate(txtypes.Templates{
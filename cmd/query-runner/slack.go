@@ -5,31 +5,37 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/errors"
-	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/slack"
 )
 
-func (n *notifier) slackNotify(ctx context.Context) {
+// slackNotify posts a message about the new results to the Slack webhook of
+// the saved search.
+func (n *notifier) slackNotify(ctx context.Context) error {
 	plural := ""
-	if n.results.Data.Search.Results.ApproximateResultCount != "1" {
+	if n.approximateResultCount != "1" {
 		plural = "s"
 	}
 
 	text := fmt.Sprintf(`*%s* new result%s found for saved search <%s|"%s">`,
-		n.results.Data.Search.Results.ApproximateResultCount,
+		n.approximateResultCount,
 		plural,
 		searchURL(n.newQuery, utmSourceSlack),
 		n.query.Description,
 	)
-	for _, recipient := range n.recipients {
-		if err := slackNotify(ctx, recipient, text, n.query.SlackWebhookURL); err != nil {
-			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
-		}
+	r := &recipient{slack: true}
+	if n.spec.Subject.User != nil {
+		r.spec.userID = *n.spec.Subject.User
+	} else if n.spec.Subject.Org != nil {
+		r.spec.orgID = *n.spec.Subject.Org
+	}
+	if err := slackNotify(ctx, r, text, n.query.SlackWebhookURL); err != nil {
+		return err
 	}
 	// TODO(Dan): find all users in the recipient list and log events for all of them
 	logEvent(0, "SavedSearchSlackNotificationSent", "results")
+	return nil
 }
 
 func slackNotifySubscribed(ctx context.Context, recipient *recipient, query api.SavedQuerySpecAndConfig) error {
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/keegancsmith/sqlf"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker"
+	dbworkerstore "github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker/store"
+)
+
+const (
+	// obsoleteRetention is how long executions without new results are kept.
+	obsoleteRetention = 24 * time.Hour
+	// retention is how long all other executions and their action jobs are
+	// kept.
+	retention = 7 * 24 * time.Hour
+)
+
+// newBackgroundRoutines returns the routines which queue executions of the
+// saved searches that are due, run them, send the notifications about their
+// new results, and delete old executions.
+//
+// All state is kept in the database, so any number of query-runner replicas
+// may run these routines concurrently.
+func newBackgroundRoutines(ctx context.Context, db dbutil.DB, forceRunInterval time.Duration) []goroutine.BackgroundRoutine {
+	observationContext := &observation.Context{
+		Logger:     log15.Root(),
+		Tracer:     &trace.Tracer{Tracer: opentracing.GlobalTracer()},
+		Registerer: prometheus.DefaultRegisterer,
+	}
+
+	// Change the isolation level for every transaction created by the workers
+	// so that multiple replicas can dequeue records without conflicts.
+	handle := basestore.NewHandleWithDB(db, sql.TxOptions{Isolation: sql.LevelReadCommitted})
+
+	executionStore := newExecutionStore(handle)
+	actionStore := newActionStore(handle)
+
+	store := database.SavedSearchExecutions(db)
+
+	enqueuer := goroutine.NewPeriodicGoroutine(ctx, 5*time.Second, goroutine.NewHandlerWithErrorMessage(
+		"saved_search_executions_enqueuer",
+		func(ctx context.Context) error {
+			return store.EnqueueDue(ctx)
+		},
+	))
+
+	executionWorker := dbworker.NewWorker(ctx, executionStore, &executionHandler{
+		db:               db,
+		search:           performSearch,
+		forceRunInterval: forceRunInterval,
+	}, workerutil.WorkerOptions{
+		Name:              "saved_search_executions_worker",
+		NumHandlers:       1,
+		Interval:          time.Second,
+		HeartbeatInterval: 15 * time.Second,
+		Metrics:           workerutil.NewMetrics(observationContext, "saved_search_executions", nil),
+	})
+	executionResetter := dbworker.NewResetter(executionStore, dbworker.ResetterOptions{
+		Name:     "saved_search_executions_worker_resetter",
+		Interval: time.Minute,
+		Metrics:  *dbworker.NewMetrics(observationContext, "saved_search_executions"),
+	})
+
+	actionWorker := dbworker.NewWorker(ctx, actionStore, &actionHandler{db: db}, workerutil.WorkerOptions{
+		Name:              "saved_search_action_jobs_worker",
+		NumHandlers:       1,
+		Interval:          5 * time.Second,
+		HeartbeatInterval: 15 * time.Second,
+		Metrics:           workerutil.NewMetrics(observationContext, "saved_search_action_jobs", nil),
+	})
+	actionResetter := dbworker.NewResetter(actionStore, dbworker.ResetterOptions{
+		Name:     "saved_search_action_jobs_worker_resetter",
+		Interval: time.Minute,
+		Metrics:  *dbworker.NewMetrics(observationContext, "saved_search_action_jobs"),
+	})
+
+	janitor := goroutine.NewPeriodicGoroutine(ctx, time.Hour, goroutine.NewHandlerWithErrorMessage(
+		"saved_search_executions_janitor",
+		func(ctx context.Context) error {
+			if err := store.DeleteObsolete(ctx, time.Now().Add(-obsoleteRetention)); err != nil {
+				return err
+			}
+			return store.DeleteFinishedBefore(ctx, time.Now().Add(-retention))
+		},
+	))
+
+	return []goroutine.BackgroundRoutine{
+		enqueuer,
+		executionWorker,
+		executionResetter,
+		actionWorker,
+		actionResetter,
+		janitor,
+	}
+}
+
+// newExecutionStore returns the worker store of the saved search executions.
+func newExecutionStore(handle *basestore.TransactableHandle) dbworkerstore.Store {
+	return dbworkerstore.New(handle, dbworkerstore.Options{
+		Name:              "saved_search_executions_worker_store",
+		TableName:         "saved_search_executions",
+		ColumnExpressions: database.SavedSearchExecutionColumns,
+		Scan:              database.ScanSavedSearchExecution,
+		OrderByExpression: sqlf.Sprintf("saved_search_executions.id"),
+		StalledMaxAge:     time.Minute,
+		MaxNumResets:      3,
+		// Failed executions are not retried: the saved search runs again
+		// once it is due.
+		MaxNumRetries: 1,
+	})
+}
+
+// newActionStore returns the worker store of the notifications about the
+// results of saved search executions.
+func newActionStore(handle *basestore.TransactableHandle) dbworkerstore.Store {
+	return dbworkerstore.New(handle, dbworkerstore.Options{
+		Name:              "saved_search_action_jobs_worker_store",
+		TableName:         "saved_search_action_jobs",
+		ColumnExpressions: database.SavedSearchActionJobColumns,
+		Scan:              database.ScanSavedSearchActionJob,
+		OrderByExpression: sqlf.Sprintf("saved_search_action_jobs.id"),
+		StalledMaxAge:     time.Minute,
+		MaxNumResets:      3,
+		RetryAfter:        time.Minute,
+		MaxNumRetries:     3,
+	})
+}
+
+// executionHandler runs a saved search to find the results that are newer
+// than the latest result we notified about, and queues the notifications
+// about them.
+type executionHandler struct {
+	db               dbutil.DB
+	search           func(ctx context.Context, query string) (*gqlSearchResponse, time.Duration, error)
+	forceRunInterval time.Duration
+}
+
+var _ workerutil.Handler = &executionHandler{}
+
+func (h *executionHandler) Handle(ctx context.Context, record workerutil.Record) (err error) {
+	e, ok := record.(*types.SavedSearchExecution)
+	if !ok {
+		return errors.Errorf("unexpected record type %T", record)
+	}
+
+	savedSearch, err := database.SavedSearches(h.db).GetByID(ctx, e.SavedSearchID)
+	if err != nil {
+		return errors.Wrap(err, "SavedSearches.GetByID")
+	}
+
+	store := database.SavedSearchExecutions(h.db)
+	latestResult, err := store.LatestResult(ctx, e.SavedSearchID)
+	if err != nil {
+		return errors.Wrap(err, "LatestResult")
+	}
+
+	// Perform the search and record when the saved search should run next. We
+	// do this regardless of whether or not the search query fails in order to
+	// avoid e.g. failed saved queries from executing constantly and
+	// potentially causing harm to the system.
+	newQuery := newQueryWithAfterFilter(savedSearch.Config.Query, latestResult, time.Now())
+	v, execDuration, searchErr := h.search(ctx, newQuery)
+	nextRun := time.Now().Add(h.runInterval(execDuration))
+	newLatestResult := latestResultTime(latestResult, v, searchErr)
+
+	if searchErr != nil {
+		if err := store.SetCursor(ctx, e.SavedSearchID, newLatestResult, nextRun); err != nil {
+			return errors.Wrap(err, "SetCursor")
+		}
+		return searchErr
+	}
+
+	tx, err := store.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	if err := tx.SetCursor(ctx, e.SavedSearchID, newLatestResult, nextRun); err != nil {
+		return errors.Wrap(err, "SetCursor")
+	}
+
+	results := v.Data.Search.Results
+	if err := tx.SetResults(ctx, e.ID, newQuery, int32(len(results.Results)), results.ApproximateResultCount); err != nil {
+		return errors.Wrap(err, "SetResults")
+	}
+	if len(results.Results) == 0 {
+		return nil
+	}
+
+	log15.Info("queueing notifications", "new_results", len(results.Results), "description", savedSearch.Config.Description)
+	recipients, err := getNotificationRecipients(ctx, savedSearch.Spec, savedSearch.Config)
+	if err != nil {
+		return errors.Wrap(err, "getNotificationRecipients")
+	}
+	return tx.EnqueueActionJobs(ctx, e.ID, actionJobsForRecipients(recipients))
+}
+
+// runInterval returns how long to wait before running a saved search again,
+// given how long it took to run.
+func (h *executionHandler) runInterval(execDuration time.Duration) time.Duration {
+	if h.forceRunInterval != 0 {
+		return h.forceRunInterval
+	}
+
+	// We assume a run interval of 30x that which it takes to execute the
+	// query. For example, a query which takes 2s to execute will run (2s*30)
+	// every minute.
+	//
+	// Additionally, in case queries run very quickly (e.g. our after: queries
+	// with no results often return in ~15ms), we impose a minimum run interval
+	// of 10s.
+	runInterval := execDuration * 30
+	if runInterval < 10*time.Second {
+		runInterval = 10 * time.Second
+	}
+	return runInterval
+}
+
+// actionJobsForRecipients returns the notifications to send to recipients:
+// an email to every recipient who wants one, and a single Slack message, since
+// a saved search has only one Slack webhook.
+func actionJobsForRecipients(recipients recipients) []*types.SavedSearchActionJob {
+	var (
+		jobs  []*types.SavedSearchActionJob
+		slack bool
+	)
+	for _, r := range recipients {
+		if r.email && r.spec.userID != 0 {
+			userID := r.spec.userID
+			jobs = append(jobs, &types.SavedSearchActionJob{Kind: types.SavedSearchActionEmail, UserID: &userID})
+		}
+		slack = slack || r.slack
+	}
+	if slack {
+		jobs = append(jobs, &types.SavedSearchActionJob{Kind: types.SavedSearchActionSlack})
+	}
+	return jobs
+}
+
+// actionHandler sends a notification about the new results of an execution.
+type actionHandler struct {
+	db dbutil.DB
+}
+
+var _ workerutil.Handler = &actionHandler{}
+
+func (h *actionHandler) Handle(ctx context.Context, record workerutil.Record) error {
+	j, ok := record.(*types.SavedSearchActionJob)
+	if !ok {
+		return errors.Errorf("unexpected record type %T", record)
+	}
+
+	e, err := database.SavedSearchExecutions(h.db).GetByID(ctx, j.ExecutionID)
+	if err != nil {
+		return errors.Wrap(err, "SavedSearchExecutions.GetByID")
+	}
+	savedSearch, err := database.SavedSearches(h.db).GetByID(ctx, e.SavedSearchID)
+	if err != nil {
+		return errors.Wrap(err, "SavedSearches.GetByID")
+	}
+
+	n := &notifier{
+		spec:                   savedSearch.Spec,
+		query:                  savedSearch.Config,
+		newQuery:               zeroOrVal(e.QueryString),
+		approximateResultCount: zeroOrVal(e.ApproximateResultCount),
+	}
+	switch j.Kind {
+	case types.SavedSearchActionEmail:
+		if j.UserID == nil {
+			return errors.Errorf("email action job %d has no recipient", j.ID)
+		}
+		return n.emailNotify(ctx, *j.UserID)
+	case types.SavedSearchActionSlack:
+		return n.slackNotify(ctx)
+	default:
+		return errors.Errorf("action job %d has unknown kind %q", j.ID, j.Kind)
+	}
+}
+
+// newQueryWithAfterFilter constructs a new query which finds search results
+// introduced after latestResult. If latestResult is nil, because the saved
+// search never ran, now is used instead. We'll most certainly find nothing,
+// which is okay.
+func newQueryWithAfterFilter(query string, latestResult *time.Time, now time.Time) string {
+	after := now
+	if latestResult != nil {
+		after = *latestResult
+	}
+	afterTime := after.UTC().Format(time.RFC3339)
+	return strings.Join([]string{query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
+}
+
+func performSearch(ctx context.Context, query string) (v *gqlSearchResponse, execDuration time.Duration, err error) {
+	attempts := 0
+	for {
+		// Query for search results.
+		start := time.Now()
+		v, err := search(ctx, query)
+		execDuration := time.Since(start)
+		if err != nil {
+			return nil, execDuration, errors.Wrap(err, "search")
+		}
+		if len(v.Data.Search.Results.Results) > 0 {
+			return v, execDuration, nil // We have at least some search results, so we're done.
+		}
+
+		cloning := len(v.Data.Search.Results.Cloning)
+		timedout := len(v.Data.Search.Results.Timedout)
+		if cloning == 0 && timedout == 0 {
+			return v, execDuration, nil // zero results, but no cloning or timed out repos. No point in retrying.
+		}
+
+		if attempts > 5 {
+			return nil, execDuration, errors.Errorf("found 0 results due to %d cloning %d timedout repos", cloning, timedout)
+		}
+
+		// We didn't find any search results. Some repos are cloning or timed
+		// out, so try again in a few seconds.
+		attempts++
+		log15.Warn("executor: failed to run query found 0 search results due to cloning or timed out repos (retrying in 5s)", "cloning", cloning, "timedout", timedout, "query", query)
+		select {
+		case <-ctx.Done():
+			return nil, execDuration, ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func latestResultTime(previousLatestResult *time.Time, v *gqlSearchResponse, searchErr error) time.Time {
+	if searchErr != nil || len(v.Data.Search.Results.Results) == 0 {
+		// Error performing the search, or there were no results. Assume the
+		// previous result time.
+		if previousLatestResult != nil {
+			return *previousLatestResult
+		}
+		return time.Now()
+	}
+
+	// Results are ordered chronologically, so first result is the latest.
+	t, err := extractTime(v.Data.Search.Results.Results[0])
+	if err != nil {
+		// Error already logged by extractTime.
+		return time.Now()
+	}
+	return *t
+}
+
+func zeroOrVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	dbworkerstore "github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker/store"
+)
+
+func TestNewQueryWithAfterFilter(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	latestResult := time.Date(2021, 9, 1, 8, 30, 0, 0, time.FixedZone("", 2*60*60))
+
+	if got, want := newQueryWithAfterFilter("type:diff foo", &latestResult, now), `type:diff foo after:"2021-09-01T06:30:00Z"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := newQueryWithAfterFilter("type:diff foo", nil, now), `type:diff foo after:"2021-10-01T12:00:00Z"`; got != want {
+		t.Errorf("got %q, want %q for a saved search that never ran", got, want)
+	}
+}
+
+func TestRunInterval(t *testing.T) {
+	h := &executionHandler{}
+	for execDuration, want := range map[time.Duration]time.Duration{
+		15 * time.Millisecond: 10 * time.Second,
+		2 * time.Second:       time.Minute,
+	} {
+		if got := h.runInterval(execDuration); got != want {
+			t.Errorf("got %s for %s, want %s", got, execDuration, want)
+		}
+	}
+
+	h.forceRunInterval = time.Hour
+	if got := h.runInterval(2 * time.Second); got != time.Hour {
+		t.Errorf("got %s, want forced interval %s", got, time.Hour)
+	}
+}
+
+func TestActionJobsForRecipients(t *testing.T) {
+	one, two := int32(1), int32(2)
+
+	got := actionJobsForRecipients(recipients{
+		{spec: recipientSpec{userID: 1}, email: true},
+		{spec: recipientSpec{userID: 2}, email: true, slack: true},
+		{spec: recipientSpec{userID: 3}},
+		{spec: recipientSpec{orgID: 4}, slack: true},
+	})
+	want := []*types.SavedSearchActionJob{
+		{Kind: types.SavedSearchActionEmail, UserID: &one},
+		{Kind: types.SavedSearchActionEmail, UserID: &two},
+		{Kind: types.SavedSearchActionSlack},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected action jobs (-want +got):\n%s", diff)
+	}
+
+	if got := actionJobsForRecipients(recipients{{spec: recipientSpec{orgID: 4}}}); len(got) != 0 {
+		t.Errorf("got %d action jobs, want none", len(got))
+	}
+}
+
+func TestWorkerStores(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db := dbtest.NewDB(t)
+	ctx := context.Background()
+	handle := basestore.NewHandleWithDB(db, sql.TxOptions{})
+	executionStore := newExecutionStore(handle)
+	actionStore := newActionStore(handle)
+	store := database.SavedSearchExecutions(db)
+
+	user, err := database.Users(db).Create(ctx, database.NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := database.SavedSearches(db).Create(ctx, &types.SavedSearch{Query: "type:diff foo", Notify: true, UserID: &user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	record, ok, err := executionStore.Dequeue(ctx, "test", nil)
+	if err != nil || !ok {
+		t.Fatalf("expected a queued execution, got ok=%t err=%v", ok, err)
+	}
+	e := record.(*types.SavedSearchExecution)
+	if e.SavedSearchID != ss.ID || e.State != "processing" {
+		t.Fatalf("unexpected execution: %+v", e)
+	}
+
+	// The processing execution is not queued again, and not dequeued twice.
+	if err := store.EnqueueDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := executionStore.Dequeue(ctx, "test", nil); err != nil || ok {
+		t.Fatalf("expected no queued execution, got ok=%t err=%v", ok, err)
+	}
+
+	if err := store.EnqueueActionJobs(ctx, e.ID, []*types.SavedSearchActionJob{
+		{Kind: types.SavedSearchActionEmail, UserID: &user.ID},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := executionStore.MarkComplete(ctx, e.RecordID(), dbworkerstore.MarkFinalOptions{WorkerHostname: "test"}); err != nil || !ok {
+		t.Fatalf("failed to mark the execution complete: ok=%t err=%v", ok, err)
+	}
+	if e, err = store.GetByID(ctx, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if e.State != "completed" || e.FinishedAt == nil {
+		t.Fatalf("unexpected execution: %+v", e)
+	}
+
+	record, ok, err = actionStore.Dequeue(ctx, "test", nil)
+	if err != nil || !ok {
+		t.Fatalf("expected a queued action job, got ok=%t err=%v", ok, err)
+	}
+	j := record.(*types.SavedSearchActionJob)
+	if j.ExecutionID != e.ID || j.Kind != types.SavedSearchActionEmail || j.UserID == nil || *j.UserID != user.ID {
+		t.Fatalf("unexpected action job: %+v", j)
+	}
+
+	// Action jobs which failed are retried.
+	if ok, err := actionStore.MarkErrored(ctx, j.RecordID(), "oops", dbworkerstore.MarkFinalOptions{WorkerHostname: "test"}); err != nil || !ok {
+		t.Fatalf("failed to mark the action job errored: ok=%t err=%v", ok, err)
+	}
+	var state string
+	var numFailures int
+	if err := db.QueryRowContext(ctx, "SELECT state, num_failures FROM saved_search_action_jobs WHERE id = $1", j.ID).Scan(&state, &numFailures); err != nil {
+		t.Fatal(err)
+	}
+	if state != "errored" || numFailures != 1 {
+		t.Fatalf("unexpected action job state %q with %d failures", state, numFailures)
+	}
+}
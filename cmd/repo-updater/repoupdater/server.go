@@ -30,8 +30,8 @@ type Server struct {
 	*repos.Syncer
 	SourcegraphDotComMode bool
 	Scheduler             interface {
-		UpdateOnce(id api.RepoID, name api.RepoName)
-		ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult
+		UpdateOnce(ctx context.Context, id api.RepoID, name api.RepoName) error
+		ScheduleInfo(ctx context.Context, id api.RepoID) (*protocol.RepoUpdateSchedulerInfoResult, error)
 	}
 	GitserverClient interface {
 		ListCloned(context.Context) ([]string, error)
@@ -100,7 +100,11 @@ func (s *Server) handleRepoUpdateSchedulerInfo(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	result := s.Scheduler.ScheduleInfo(args.ID)
+	result, err := s.Scheduler.ScheduleInfo(r.Context(), args.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -172,7 +176,9 @@ func (s *Server) enqueueRepoUpdate(ctx context.Context, req *protocol.RepoUpdate
 
 	repo := rs[0]
 
-	s.Scheduler.UpdateOnce(repo.ID, repo.Name)
+	if err := s.Scheduler.UpdateOnce(ctx, repo.ID, repo.Name); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "scheduler.update-once")
+	}
 
 	return &protocol.RepoUpdateResponse{
 		ID:   repo.ID,
@@ -661,9 +661,11 @@ func TestServer_RepoLookup(t *testing.T) {
 
 type fakeScheduler struct{}
 
-func (s *fakeScheduler) UpdateOnce(_ api.RepoID, _ api.RepoName) {}
-func (s *fakeScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult {
-	return &protocol.RepoUpdateSchedulerInfoResult{}
+func (s *fakeScheduler) UpdateOnce(_ context.Context, _ api.RepoID, _ api.RepoName) error {
+	return nil
+}
+func (s *fakeScheduler) ScheduleInfo(_ context.Context, id api.RepoID) (*protocol.RepoUpdateSchedulerInfoResult, error) {
+	return &protocol.RepoUpdateSchedulerInfoResult{}, nil
 }
 
 type fakePermsSyncer struct{}
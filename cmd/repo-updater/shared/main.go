@@ -153,7 +153,7 @@ func Main(enterpriseInit EnterpriseInit) {
 		src = repos.NewSourcer(cf, repos.WithDB(db), repos.ObservedSource(log15.Root(), m))
 	}
 
-	scheduler := repos.NewUpdateScheduler()
+	scheduler := repos.NewUpdateScheduler(db)
 	server := &repoupdater.Server{
 		Store:                 store,
 		Scheduler:             scheduler,
@@ -345,16 +345,16 @@ func Main(enterpriseInit EnterpriseInit) {
 
 type scheduler interface {
 	// UpdateFromDiff updates the scheduled and queued repos from the given sync diff.
-	UpdateFromDiff(repos.Diff)
+	UpdateFromDiff(context.Context, repos.Diff) error
 
 	// PrioritiseUncloned ensures uncloned repos are given priority in the scheduler.
-	PrioritiseUncloned([]string)
+	PrioritiseUncloned(context.Context, []string) error
 
 	// ListRepos lists all the repos managed by the scheduler.
-	ListRepos() []string
+	ListRepos(context.Context) ([]string, error)
 
 	// EnsureScheduled ensures that all the repos provided are known to the scheduler.
-	EnsureScheduled([]types.RepoName)
+	EnsureScheduled(context.Context, []types.RepoName) error
 }
 
 type permsSyncer interface {
@@ -377,7 +377,9 @@ func watchSyncer(
 			return
 		case diff := <-syncer.Synced:
 			if !conf.Get().DisableAutoGitUpdates {
-				sched.UpdateFromDiff(diff)
+				if err := sched.UpdateFromDiff(ctx, diff); err != nil {
+					log15.Error("Updating scheduler from sync diff", "error", err)
+				}
 			}
 
 			// PermsSyncer is only available in enterprise mode.
@@ -438,12 +440,19 @@ func syncScheduler(ctx context.Context, sched scheduler, gitserverClient *gitser
 			return
 		} else {
 			// Ensure that uncloned indexable repos are known to the scheduler
-			sched.EnsureScheduled(u)
+			if err := sched.EnsureScheduled(ctx, u); err != nil {
+				log15.Error("Scheduling uncloned indexable repos", "error", err)
+				return
+			}
 		}
 
 		// Next, move any repos managed by the scheduler that are uncloned to the front
 		// of the queue
-		managed := sched.ListRepos()
+		managed, err := sched.ListRepos(ctx)
+		if err != nil {
+			log15.Error("Listing scheduled repos", "error", err)
+			return
+		}
 
 		uncloned, err := baseRepoStore.ListRepoNames(ctx, database.ReposListOptions{Names: managed, NoCloned: true})
 		if err != nil {
@@ -455,7 +464,9 @@ func syncScheduler(ctx context.Context, sched scheduler, gitserverClient *gitser
 			names[i] = string(uncloned[i].Name)
 		}
 
-		sched.PrioritiseUncloned(names)
+		if err := sched.PrioritiseUncloned(ctx, names); err != nil {
+			log15.Error("Prioritising uncloned repos", "error", err)
+		}
 	}
 
 	for ctx.Err() == nil {
@@ -13,7 +13,7 @@ export GO111MODULE=on
 export GOARCH=amd64
 export GOOS=linux
 
-# go-sqlite3 depends on cgo. Without cgo, it will build but it'll throw an error at query time.
+# go-sqlite3 and go-tree-sitter depend on cgo. Without cgo, go-sqlite3 will build but it'll throw an error at query time.
 export CGO_ENABLED=1
 
 # Ensure musl-gcc is available since we're building to run on Alpine, which uses musl.
@@ -0,0 +1,231 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/diskcache"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// Changes are the paths that differ between two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ParseGitDiffNameStatus parses the output of `git diff --name-status -z`.
+// Renames and copies are reported as a deletion (for renames) and an
+// addition, so they are handled correctly even without --no-renames.
+func ParseGitDiffNameStatus(out []byte) (Changes, error) {
+	var changes Changes
+	if len(out) == 0 {
+		return changes, nil
+	}
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	for i := 0; i < len(fields); i++ {
+		status := string(fields[i])
+		if status == "" {
+			return Changes{}, errors.Errorf("unexpected empty status at field %d", i)
+		}
+		next := func() (string, error) {
+			i++
+			if i >= len(fields) {
+				return "", errors.Errorf("missing path for status %q", status)
+			}
+			return string(fields[i]), nil
+		}
+
+		path, err := next()
+		if err != nil {
+			return Changes{}, err
+		}
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		case 'R', 'C':
+			newPath, err := next()
+			if err != nil {
+				return Changes{}, err
+			}
+			if status[0] == 'R' {
+				changes.Deleted = append(changes.Deleted, path)
+			}
+			changes.Added = append(changes.Added, newPath)
+		default:
+			return Changes{}, errors.Errorf("unexpected status %q for path %q", status, path)
+		}
+	}
+	return changes, nil
+}
+
+// maxIncrementalChangedPaths is the maximum number of paths that may have
+// changed since the nearest ancestor with a database for us to derive the new
+// database from it. Beyond that, fetching the whole archive is cheaper than
+// asking gitserver for every changed path.
+const maxIncrementalChangedPaths = 5000
+
+// writeSymbolsToNewDB writes the symbols of repo@commit to the blank database
+// file `dbFile`. If the database of a recent ancestor is in the cache, it is
+// copied and only the files that changed since are parsed again. Otherwise,
+// or if that fails for any reason, all the symbols of the repository are
+// written from scratch.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) error {
+	if s.Ancestors != nil && s.GitDiff != nil && s.FetchTarPaths != nil {
+		ok, err := s.writeSymbolsToDerivedDB(ctx, dbFile, repoName, commitID)
+		if err == nil && ok {
+			dbBuilds.WithLabelValues("incremental").Inc()
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log15.Warn("Failed to derive symbols database from an ancestor commit, building it from scratch.", "repo", repoName, "commitID", commitID, "error", err)
+			incrementalBuildFailed.Inc()
+		}
+
+		// Start over with a blank database. A left over rollback journal
+		// would otherwise be applied to it.
+		_ = os.Remove(dbFile + "-journal")
+		if err := os.Truncate(dbFile, 0); err != nil {
+			return err
+		}
+	}
+
+	dbBuilds.WithLabelValues("full").Inc()
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repoName, commitID)
+}
+
+// writeSymbolsToDerivedDB writes the symbols of repo@commit to `dbFile` by
+// copying the database of the nearest ancestor in the cache and reparsing the
+// files that changed since. It returns false if there is no such ancestor or
+// too many files changed, in which case `dbFile` is left untouched.
+func (s *Service) writeSymbolsToDerivedDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) (ok bool, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "writeSymbolsToDerivedDB")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.SetTag("ok", ok)
+		span.Finish()
+	}()
+
+	ancestors, err := s.Ancestors(ctx, repoName, commitID, s.MaxAncestors)
+	if err != nil {
+		return false, errors.Wrap(err, "Ancestors")
+	}
+
+	var (
+		base     api.CommitID
+		baseFile *diskcache.File
+	)
+	for _, ancestor := range ancestors {
+		f, err := s.cache.OpenExisting(dbCacheKey(repoName, ancestor))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		base, baseFile = ancestor, f
+		break
+	}
+	if baseFile == nil {
+		return false, nil
+	}
+	defer baseFile.Close()
+	span.SetTag("base", string(base))
+
+	changes, err := s.GitDiff(ctx, repoName, base, commitID)
+	if err != nil {
+		return false, errors.Wrap(err, "GitDiff")
+	}
+	reparse := make([]string, 0, len(changes.Added)+len(changes.Modified))
+	reparse = append(reparse, changes.Added...)
+	reparse = append(reparse, changes.Modified...)
+	span.SetTag("changed", len(reparse)+len(changes.Deleted))
+	if len(reparse)+len(changes.Deleted) > maxIncrementalChangedPaths {
+		return false, nil
+	}
+
+	if err := copyToFile(dbFile, baseFile.File); err != nil {
+		return false, errors.Wrap(err, "copying ancestor database")
+	}
+
+	db, err := sqlx.Open("sqlite3_with_regexp", dbFile)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	// Added paths are deleted too, so that a path that was deleted and added
+	// back between the two commits can never end up with duplicate symbols.
+	for _, paths := range [][]string{changes.Deleted, reparse} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if len(reparse) > 0 {
+		if err := s.insertSymbols(ctx, tx, repoName, commitID, reparse); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// copyToFile overwrites the file at path with the contents of r.
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var (
+	dbBuilds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "symbols_store_db_builds",
+		Help: "The total number of symbols databases built, by whether they were derived from the database of an ancestor commit (incremental) or not (full).",
+	}, []string{"type"})
+	incrementalBuildFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "symbols_store_incremental_build_failed",
+		Help: "The total number of symbols databases that failed to be derived from the database of an ancestor commit and were built from scratch instead.",
+	})
+)
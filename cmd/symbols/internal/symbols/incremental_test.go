@@ -0,0 +1,149 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/go-ctags"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	symbolsclient "github.com/sourcegraph/sourcegraph/internal/symbols"
+)
+
+func TestParseGitDiffNameStatus(t *testing.T) {
+	out := []byte("M\x00a.go\x00A\x00dir/b.go\x00D\x00c.go\x00T\x00d.go\x00R100\x00old.go\x00new.go\x00C75\x00src.go\x00copy.go\x00")
+	got, err := ParseGitDiffNameStatus(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Changes{
+		Added:    []string{"dir/b.go", "new.go", "copy.go"},
+		Modified: []string{"a.go", "d.go"},
+		Deleted:  []string{"c.go", "old.go"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected changes (-want +got):\n%s", diff)
+	}
+
+	if got, err := ParseGitDiffNameStatus(nil); err != nil || !reflect.DeepEqual(got, Changes{}) {
+		t.Errorf("got %+v, %v for empty output", got, err)
+	}
+	for _, out := range []string{"M\x00", "X\x00a.go\x00", "\x00a.go\x00", "R100\x00old.go\x00"} {
+		if _, err := ParseGitDiffNameStatus([]byte(out)); err == nil {
+			t.Errorf("expected an error for %q", out)
+		}
+	}
+}
+
+func TestServiceIncremental(t *testing.T) {
+	commits := map[api.CommitID]map[string]string{
+		"c1": {
+			"a.go": "package a\n\nfunc A() {}\n",
+			"b.go": "package a\n\nfunc B() {}\n",
+		},
+		"c2": {
+			"a.go": "package a\n\nfunc A2() {}\n",
+			"c.go": "package a\n\nfunc C() {}\n",
+		},
+	}
+	parents := map[api.CommitID][]api.CommitID{
+		"c1": nil,
+		"c2": {"c1"},
+	}
+
+	for _, test := range []struct {
+		name        string
+		diffErr     error
+		wantFetches int
+	}{
+		{name: "incremental", wantFetches: 1},
+		{name: "fallback", diffErr: errors.New("boom"), wantFetches: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { os.RemoveAll(tmpDir) }()
+
+			fullFetches := 0
+			var fetchedPaths []string
+			service := Service{
+				FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID) (io.ReadCloser, error) {
+					fullFetches++
+					return createTar(commits[commit])
+				},
+				FetchTarPaths: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+					files := map[string]string{}
+					for _, path := range paths {
+						fetchedPaths = append(fetchedPaths, path)
+						if content, ok := commits[commit][path]; ok {
+							files[path] = content
+						}
+					}
+					return createTar(files)
+				},
+				Ancestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+					return parents[commit], nil
+				},
+				GitDiff: func(ctx context.Context, repo api.RepoName, base, head api.CommitID) (Changes, error) {
+					if base != "c1" || head != "c2" {
+						t.Errorf("unexpected diff %s..%s", base, head)
+					}
+					return Changes{Added: []string{"c.go"}, Modified: []string{"a.go"}, Deleted: []string{"b.go"}}, test.diffErr
+				},
+				NewParser: func() (ctags.Parser, error) {
+					return mockParser{}, nil
+				},
+				LanguageParsers: DefaultLanguageParsers(),
+				Path:            tmpDir,
+			}
+			if err := service.Start(); err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(service.Handler())
+			defer server.Close()
+			client := symbolsclient.Client{
+				URL:        server.URL,
+				HTTPClient: httpcli.InternalDoer,
+			}
+
+			symbolsAt := func(commit api.CommitID) []string {
+				result, err := client.Search(context.Background(), search.SymbolsParameters{Repo: "r", CommitID: commit, Query: "^[A-Z]", IsCaseSensitive: true, First: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var names []string
+				for _, s := range *result {
+					names = append(names, s.Path+":"+s.Name)
+				}
+				sort.Strings(names)
+				return names
+			}
+
+			if diff := cmp.Diff([]string{"a.go:A", "b.go:B"}, symbolsAt("c1")); diff != "" {
+				t.Fatalf("unexpected symbols at c1 (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"a.go:A2", "c.go:C"}, symbolsAt("c2")); diff != "" {
+				t.Fatalf("unexpected symbols at c2 (-want +got):\n%s", diff)
+			}
+			if fullFetches != test.wantFetches {
+				t.Errorf("got %d full fetches, want %d", fullFetches, test.wantFetches)
+			}
+			if test.diffErr == nil {
+				if diff := cmp.Diff([]string{"c.go", "a.go"}, fetchedPaths); diff != "" {
+					t.Errorf("unexpected fetched paths (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package symbols
+
+import (
+	"context"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// A LanguageParser extracts the symbols of files in the languages it
+// supports. Unlike ctags, it knows the full range of each symbol definition
+// and the scopes it is nested in.
+//
+// Implementations must be safe for concurrent use.
+type LanguageParser interface {
+	// Supports returns true if the parser handles the file at path.
+	Supports(path string) bool
+
+	// Parse returns the symbols defined in the file.
+	Parse(path string, content []byte) ([]result.Symbol, error)
+}
+
+// DefaultLanguageParsers returns the language parsers used by the symbols
+// service. Files in other languages are parsed with ctags.
+func DefaultLanguageParsers() []LanguageParser {
+	return []LanguageParser{
+		newGoParser(),
+		newTypeScriptParser(),
+		newTSXParser(),
+		newPythonParser(),
+		newJavaParser(),
+	}
+}
+
+// parseSymbols parses the file with the first language parser that supports
+// it. It falls back to ctags if there is none, or if the language parser
+// fails, e.g. because the file has syntax errors.
+func (s *Service) parseSymbols(ctx context.Context, req parseRequest) ([]result.Symbol, error) {
+	for _, p := range s.LanguageParsers {
+		if !p.Supports(req.path) {
+			continue
+		}
+		symbols, err := p.Parse(req.path, req.data)
+		if err == nil {
+			return symbols, nil
+		}
+		log15.Debug("Language parser failed, falling back to ctags.", "path", req.path, "error", err)
+		languageParserFailed.Inc()
+		break
+	}
+
+	entries, err := s.parse(ctx, req)
+	symbols := make([]result.Symbol, 0, len(entries))
+	for _, e := range entries {
+		symbols = append(symbols, entryToSymbol(e))
+	}
+	return symbols, err
+}
+
+var languageParserFailed = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "symbols_parse_language_parser_failed",
+	Help: "The total number of files that a language parser failed to parse and were parsed with ctags instead.",
+})
@@ -39,7 +39,9 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol result.Symbol) error) (err error) {
+// parseUncached parses the files of repo@commitID and calls callback for each
+// symbol. If paths is non-nil only those paths are parsed.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol result.Symbol) error) (err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -65,7 +67,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -98,21 +100,21 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 				wg.Done()
 				<-sem
 			}()
-			entries, parseErr := s.parse(ctx, req)
+			symbols, parseErr := s.parseSymbols(ctx, req)
 			if parseErr != nil && parseErr != context.Canceled && parseErr != context.DeadlineExceeded {
 				log15.Error("Error parsing symbols.", "repo", repo, "commitID", commitID, "path", req.path, "dataSize", len(req.data), "error", parseErr)
 			}
-			if len(entries) > 0 {
+			if len(symbols) > 0 {
 				mu.Lock()
 				defer mu.Unlock()
-				for _, e := range entries {
-					if e.Name == "" || strings.HasPrefix(e.Name, "__anon") || strings.HasPrefix(e.Parent, "__anon") || strings.HasPrefix(e.Name, "AnonymousFunction") || strings.HasPrefix(e.Parent, "AnonymousFunction") {
+				for _, symbol := range symbols {
+					if symbol.Name == "" || strings.HasPrefix(symbol.Name, "__anon") || strings.HasPrefix(symbol.Parent, "__anon") || strings.HasPrefix(symbol.Name, "AnonymousFunction") || strings.HasPrefix(symbol.Parent, "AnonymousFunction") {
 						continue
 					}
 					totalSymbols++
-					err = callback(entryToSymbol(e))
+					err = callback(symbol)
 					if err != nil {
-						log15.Error("Failed to add symbol", "symbol", e, "error", err)
+						log15.Error("Failed to add symbol", "symbol", symbol, "error", err)
 						return
 					}
 				}
@@ -110,10 +110,11 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (*result
 
 // getDBFile returns the path to the sqlite3 database for the repo@commit
 // specified in `args`. If the database doesn't already exist in the disk cache,
-// it will create a new one and write all the symbols into it.
+// it will create a new one, either from the database of an ancestor commit or
+// by writing all the symbols into it.
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
-		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, dbCacheKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+		err := s.writeSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
 				log15.Error("Unable to parse repository symbols within the context", "repo", args.Repo, "commit", args.CommitID, "query", args.Query)
@@ -230,7 +231,12 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 // filenames to prevent a newer version of the symbols service from attempting
 // to read from a database created by an older (and likely incompatible) symbols
 // service. Increment this when you change the database schema.
-const symbolsDBVersion = 3
+const symbolsDBVersion = 4
+
+// dbCacheKey returns the disk cache key of the database for repo@commit.
+func dbCacheKey(repo api.RepoName, commitID api.CommitID) string {
+	return fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+}
 
 // symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
 // namelowercase and pathlowercase, which enable indexed case insensitive
@@ -247,6 +253,10 @@ type symbolInDB struct {
 	ParentKind    string
 	Signature     string
 	Pattern       string
+	Character     int
+	EndLine       int
+	EndCharacter  int
+	Scope         string
 
 	FileLimited bool
 }
@@ -264,6 +274,10 @@ func symbolToSymbolInDB(symbol result.Symbol) symbolInDB {
 		ParentKind:    symbol.ParentKind,
 		Signature:     symbol.Signature,
 		Pattern:       symbol.Pattern,
+		Character:     symbol.Character,
+		EndLine:       symbol.EndLine,
+		EndCharacter:  symbol.EndCharacter,
+		Scope:         symbol.Scope,
 
 		FileLimited: symbol.FileLimited,
 	}
@@ -281,6 +295,11 @@ func symbolInDBToSymbol(symbolInDB symbolInDB) result.Symbol {
 		Signature:  symbolInDB.Signature,
 		Pattern:    symbolInDB.Pattern,
 
+		Character:    symbolInDB.Character,
+		EndLine:      symbolInDB.EndLine,
+		EndCharacter: symbolInDB.EndCharacter,
+		Scope:        symbolInDB.Scope,
+
 		FileLimited: symbolInDB.FileLimited,
 	}
 }
@@ -323,6 +342,10 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 			parentkind VARCHAR(255) NOT NULL,
 			signature VARCHAR(255) NOT NULL,
 			pattern VARCHAR(255) NOT NULL,
+			character INT NOT NULL,
+			endline INT NOT NULL,
+			endcharacter INT NOT NULL,
+			scope VARCHAR(1024) NOT NULL,
 			filelimited BOOLEAN NOT NULL
 		)`)
 	if err != nil {
@@ -350,16 +373,23 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	return s.insertSymbols(ctx, tx, repoName, commitID, nil)
+}
+
+// insertSymbols parses the files of repo@commit and inserts their symbols. If
+// paths is non-nil only those paths are parsed.
+func (s *Service) insertSymbols(ctx context.Context, tx *sqlx.Tx, repoName api.RepoName, commitID api.CommitID, paths []string) error {
 	insertStatement, err := tx.PrepareNamed(
 		fmt.Sprintf(
 			"INSERT INTO symbols %s VALUES %s",
-			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  filelimited)",
-			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :filelimited)"))
+			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  character,  endline,  endcharacter,  scope,  filelimited)",
+			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :character, :endline, :endcharacter, :scope, :filelimited)"))
 	if err != nil {
 		return err
 	}
+	defer insertStatement.Close()
 
-	return s.parseUncached(ctx, repoName, commitID, func(symbol result.Symbol) error {
+	return s.parseUncached(ctx, repoName, commitID, paths, func(symbol result.Symbol) error {
 		symbolInDBValue := symbolToSymbolInDB(symbol)
 		_, err := insertStatement.Exec(&symbolInDBValue)
 		return err
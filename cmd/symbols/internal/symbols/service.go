@@ -24,12 +24,33 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, api.RepoName, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only contains the given
+	// paths. It is used to parse the files that changed since a commit for
+	// which we already have a database.
+	FetchTarPaths func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
-	// to FetchTar. It defaults to 15.
+	// to FetchTar and FetchTarPaths. It defaults to 15.
 	MaxConcurrentFetchTar int
 
+	// Ancestors returns up to n ancestors of commit, nearest first. Together
+	// with GitDiff and FetchTarPaths it enables building the database of a
+	// commit from the database of an ancestor. If any of them is nil, the
+	// database is always built from scratch.
+	Ancestors func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error)
+
+	// GitDiff returns the paths that changed between two commits.
+	GitDiff func(ctx context.Context, repo api.RepoName, base, head api.CommitID) (Changes, error)
+
+	// MaxAncestors is the number of ancestors to check for an existing
+	// database. It defaults to 100.
+	MaxAncestors int
+
 	NewParser func() (ctags.Parser, error)
 
+	// LanguageParsers are used instead of ctags for the files they support.
+	LanguageParsers []LanguageParser
+
 	// NumParserProcesses is the maximum number of ctags parser child processes to run.
 	NumParserProcesses int
 
@@ -64,6 +85,10 @@ func (s *Service) Start() error {
 	}
 	s.fetchSem = make(chan int, s.MaxConcurrentFetchTar)
 
+	if s.MaxAncestors == 0 {
+		s.MaxAncestors = 100
+	}
+
 	s.cache = &diskcache.Store{
 		Dir:               s.Path,
 		Component:         "symbols",
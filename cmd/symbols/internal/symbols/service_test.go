@@ -147,6 +147,79 @@ func TestService(t *testing.T) {
 	}
 }
 
+func TestServiceLanguageParsers(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"a.go": "package a\n\nfunc F() {\n}\n",
+		// Syntax errors fall back to ctags.
+		"b.go": "package b\n\nfunc (\n",
+	}
+	service := Service{
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID) (io.ReadCloser, error) {
+			return createTar(files)
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return mockParser{"x"}, nil
+		},
+		LanguageParsers: DefaultLanguageParsers(),
+		Path:            tmpDir,
+	}
+
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{
+		URL:        server.URL,
+		HTTPClient: httpcli.InternalDoer,
+	}
+
+	tests := map[string]struct {
+		query string
+		want  result.Symbols
+	}{
+		"language parser": {
+			query: "^F$",
+			want: []result.Symbol{{
+				Name:         "F",
+				Path:         "a.go",
+				Line:         3,
+				Kind:         "func",
+				Language:     "Go",
+				Parent:       "a",
+				ParentKind:   "package",
+				Signature:    "()",
+				Pattern:      "/^func F() {$/",
+				Character:    5,
+				EndLine:      4,
+				EndCharacter: 1,
+				Scope:        "a",
+			}},
+		},
+		"ctags fallback": {
+			query: "^x$",
+			want:  []result.Symbol{{Name: "x", Path: "a.js"}},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			result, err := client.Search(context.Background(), search.SymbolsParameters{Query: test.query, First: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result == nil || !reflect.DeepEqual(*result, test.want) {
+				t.Errorf("got %+v, want %+v", result, test.want)
+			}
+		})
+	}
+}
+
 func createTar(files map[string]string) (io.ReadCloser, error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
@@ -0,0 +1,513 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// treeSitterParser parses files with a tree-sitter grammar. The symbol kinds
+// are the same as the ones of the universal-ctags parser of the language, so
+// that symbol search filters work the same for both.
+type treeSitterParser struct {
+	// language is the name of the language as reported by ctags.
+	language   string
+	extensions []string
+	grammar    *sitter.Language
+
+	// definitions returns the symbols defined by the node n, which is nested
+	// in scope.
+	definitions func(n *sitter.Node, content []byte, scope *treeSitterScope) []treeSitterDefinition
+
+	// packageScope is true if the package of a file is the scope of all its
+	// other symbols.
+	packageScope bool
+
+	// resolve optionally fixes up the symbols of a file once all of them are
+	// known.
+	resolve func(symbols []result.Symbol)
+}
+
+// A treeSitterDefinition is a symbol defined by a syntax tree node.
+type treeSitterDefinition struct {
+	name *sitter.Node
+	kind string
+
+	// function is true if the symbol is a function, whose body only defines
+	// local variables.
+	function bool
+
+	// scope is the scope of the symbol if it is not the scope the node is
+	// nested in, e.g. the receiver type of a Go method.
+	scope *treeSitterScope
+}
+
+// A treeSitterScope is a symbol which other symbols are nested in.
+type treeSitterScope struct {
+	name, kind string
+	parent     *treeSitterScope
+	function   bool
+}
+
+// local returns true if symbols in the scope are local to a function.
+func (s *treeSitterScope) local() bool {
+	for ; s != nil; s = s.parent {
+		if s.function {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the names of the scope and its parents joined by dots.
+func (s *treeSitterScope) String() string {
+	if s == nil {
+		return ""
+	}
+	if s.parent == nil {
+		return s.name
+	}
+	return s.parent.String() + "." + s.name
+}
+
+func (p *treeSitterParser) Supports(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range p.extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *treeSitterParser) Parse(path string, content []byte) ([]result.Symbol, error) {
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(p.grammar)
+
+	tree, err := parser.ParseCtx(context.Background(), nil, content)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+	if root.HasError() {
+		// Symbols around syntax errors would be wrong, ctags recovers better.
+		return nil, errors.Errorf("%s: syntax error", path)
+	}
+
+	w := treeSitterWalker{
+		parser:  p,
+		path:    path,
+		content: content,
+		lines:   bytes.Split(content, []byte("\n")),
+	}
+	w.walk(root, nil)
+	if p.resolve != nil {
+		p.resolve(w.symbols)
+	}
+	return w.symbols, nil
+}
+
+type treeSitterWalker struct {
+	parser  *treeSitterParser
+	path    string
+	content []byte
+	lines   [][]byte
+	pkg     *treeSitterScope
+	symbols []result.Symbol
+}
+
+func (w *treeSitterWalker) walk(n *sitter.Node, scope *treeSitterScope) {
+	inner := scope
+	defs := w.parser.definitions(n, w.content, scope)
+	for _, d := range defs {
+		if d.name == nil {
+			continue
+		}
+		name := d.name.Content(w.content)
+		if name == "" || name == "_" {
+			continue
+		}
+		s := d.scope
+		if s == nil {
+			s = scope
+		}
+		w.add(n, d, name, s)
+
+		// The subtree of a node which defines a single symbol is nested in
+		// that symbol, e.g. the members of a class.
+		if len(defs) == 1 {
+			inner = &treeSitterScope{name: name, kind: d.kind, parent: s, function: d.function}
+		}
+		if d.kind == "package" && w.parser.packageScope {
+			w.pkg = &treeSitterScope{name: name, kind: d.kind}
+		}
+	}
+
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		w.walk(n.NamedChild(i), inner)
+		if inner == nil && w.pkg != nil {
+			// The package clause comes first, all later declarations are in
+			// the package.
+			inner = w.pkg
+		}
+	}
+}
+
+func (w *treeSitterWalker) add(n *sitter.Node, d treeSitterDefinition, name string, scope *treeSitterScope) {
+	start, end := d.name.StartPoint(), n.EndPoint()
+	var line string
+	if int(start.Row) < len(w.lines) {
+		line = strings.TrimSuffix(string(w.lines[start.Row]), "\r")
+	}
+
+	symbol := result.Symbol{
+		Name:         name,
+		Path:         w.path,
+		Line:         int(start.Row) + 1,
+		Character:    int(start.Column),
+		EndLine:      int(end.Row) + 1,
+		EndCharacter: int(end.Column),
+		Kind:         d.kind,
+		Language:     w.parser.language,
+		Scope:        scope.String(),
+		Pattern:      "/^" + ctagsPatternEscaper.Replace(line) + "$/",
+	}
+	if scope != nil {
+		symbol.Parent = scope.name
+		symbol.ParentKind = scope.kind
+	}
+	if params := n.ChildByFieldName("parameters"); params != nil && params.StartPoint().Row == params.EndPoint().Row {
+		// Keep signatures on one line like ctags does.
+		symbol.Signature = params.Content(w.content)
+	}
+	w.symbols = append(w.symbols, symbol)
+}
+
+// childrenByFieldName returns all children of n in the field, e.g. each name
+// of the Go field declaration "a, b int".
+func childrenByFieldName(n *sitter.Node, field string) []*sitter.Node {
+	c := sitter.NewTreeCursor(n)
+	defer c.Close()
+
+	var children []*sitter.Node
+	for ok := c.GoToFirstChild(); ok; ok = c.GoToNextSibling() {
+		if c.CurrentFieldName() == field && c.CurrentNode().IsNamed() {
+			children = append(children, c.CurrentNode())
+		}
+	}
+	return children
+}
+
+// namedDefinitions returns a definition of kind for each child of n in the
+// name field.
+func namedDefinitions(n *sitter.Node, kind string) []treeSitterDefinition {
+	var defs []treeSitterDefinition
+	for _, name := range childrenByFieldName(n, "name") {
+		defs = append(defs, treeSitterDefinition{name: name, kind: kind})
+	}
+	return defs
+}
+
+// ctagsPatternEscaper escapes a line for use in a ctags pattern of the form
+// /^line$/.
+var ctagsPatternEscaper = strings.NewReplacer(`\`, `\\`, `/`, `\/`)
+
+// newGoParser returns a parser for Go. Functions with a receiver are methods,
+// and the receiver type is their scope.
+func newGoParser() *treeSitterParser {
+	return &treeSitterParser{
+		language:     "Go",
+		extensions:   []string{".go"},
+		grammar:      golang.GetLanguage(),
+		definitions:  goDefinitions,
+		packageScope: true,
+		resolve:      resolveGoReceiverKinds,
+	}
+}
+
+func goDefinitions(n *sitter.Node, content []byte, scope *treeSitterScope) []treeSitterDefinition {
+	if scope.local() {
+		// Go symbols in function bodies are local.
+		return nil
+	}
+
+	switch n.Type() {
+	case "package_clause":
+		return []treeSitterDefinition{{name: n.NamedChild(0), kind: "package"}}
+
+	case "function_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "func", function: true}}
+
+	case "method_declaration":
+		var recv string
+		if params := n.ChildByFieldName("receiver"); params != nil && params.NamedChildCount() > 0 {
+			if t := goTypeName(params.NamedChild(0).ChildByFieldName("type")); t != nil {
+				recv = t.Content(content)
+			}
+		}
+		return []treeSitterDefinition{{
+			name:     n.ChildByFieldName("name"),
+			kind:     "method",
+			function: true,
+			// The kind of the receiver type is resolved once all types of
+			// the file are known.
+			scope: &treeSitterScope{name: recv, kind: "type", parent: scope},
+		}}
+
+	case "type_spec", "type_alias":
+		kind := "type"
+		if t := n.ChildByFieldName("type"); t != nil {
+			switch t.Type() {
+			case "struct_type":
+				kind = "struct"
+			case "interface_type":
+				kind = "interface"
+			}
+		}
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: kind}}
+
+	case "field_declaration":
+		if defs := namedDefinitions(n, "member"); len(defs) > 0 {
+			return defs
+		}
+		return []treeSitterDefinition{{name: goTypeName(n.ChildByFieldName("type")), kind: "anonMember"}}
+
+	case "method_spec":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "methodSpec"}}
+
+	case "type_identifier", "qualified_type":
+		if p := n.Parent(); p != nil && p.Type() == "method_spec_list" {
+			// An embedded interface.
+			return []treeSitterDefinition{{name: goTypeName(n), kind: "anonMember"}}
+		}
+
+	case "const_spec":
+		return namedDefinitions(n, "const")
+
+	case "var_spec":
+		return namedDefinitions(n, "var")
+	}
+	return nil
+}
+
+// goTypeName returns the identifier of a receiver or embedded type, e.g.
+// Mutex for *sync.Mutex.
+func goTypeName(n *sitter.Node) *sitter.Node {
+	if n == nil {
+		return nil
+	}
+	switch n.Type() {
+	case "pointer_type", "parenthesized_type":
+		return goTypeName(n.NamedChild(0))
+	case "qualified_type":
+		return n.ChildByFieldName("name")
+	case "generic_type":
+		return goTypeName(n.ChildByFieldName("type"))
+	case "type_identifier":
+		return n
+	}
+	return nil
+}
+
+// resolveGoReceiverKinds sets the parent kind of methods to the kind of their
+// receiver type, which may be declared after them.
+func resolveGoReceiverKinds(symbols []result.Symbol) {
+	typeKinds := map[string]string{}
+	for _, s := range symbols {
+		if s.ParentKind == "package" && (s.Kind == "struct" || s.Kind == "interface" || s.Kind == "type") {
+			typeKinds[s.Name] = s.Kind
+		}
+	}
+	for i, s := range symbols {
+		if kind, ok := typeKinds[s.Parent]; ok && s.Kind == "method" {
+			symbols[i].ParentKind = kind
+		}
+	}
+}
+
+// newPythonParser returns a parser for Python. Functions in classes are
+// members.
+func newPythonParser() *treeSitterParser {
+	return &treeSitterParser{
+		language:    "Python",
+		extensions:  []string{".py", ".pyi"},
+		grammar:     python.GetLanguage(),
+		definitions: pythonDefinitions,
+	}
+}
+
+func pythonDefinitions(n *sitter.Node, content []byte, scope *treeSitterScope) []treeSitterDefinition {
+	switch n.Type() {
+	case "class_definition":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "class"}}
+
+	case "function_definition":
+		kind := "function"
+		if scope != nil && scope.kind == "class" {
+			kind = "member"
+		}
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: kind, function: true}}
+
+	case "assignment":
+		if scope.local() {
+			return nil
+		}
+		left := n.ChildByFieldName("left")
+		if left == nil {
+			return nil
+		}
+		if left.Type() == "identifier" {
+			return []treeSitterDefinition{{name: left, kind: "variable"}}
+		}
+		if left.Type() == "pattern_list" || left.Type() == "tuple_pattern" {
+			// Unpacking such as "a, b = f()".
+			var defs []treeSitterDefinition
+			for i := 0; i < int(left.NamedChildCount()); i++ {
+				if c := left.NamedChild(i); c.Type() == "identifier" {
+					defs = append(defs, treeSitterDefinition{name: c, kind: "variable"})
+				}
+			}
+			return defs
+		}
+	}
+	return nil
+}
+
+// newJavaParser returns a parser for Java. Constructors are methods.
+func newJavaParser() *treeSitterParser {
+	return &treeSitterParser{
+		language:    "Java",
+		extensions:  []string{".java"},
+		grammar:     java.GetLanguage(),
+		definitions: javaDefinitions,
+	}
+}
+
+func javaDefinitions(n *sitter.Node, content []byte, scope *treeSitterScope) []treeSitterDefinition {
+	switch n.Type() {
+	case "package_declaration":
+		return []treeSitterDefinition{{name: n.NamedChild(0), kind: "package"}}
+
+	case "class_declaration", "record_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "class"}}
+
+	case "interface_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "interface"}}
+
+	case "enum_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "enum"}}
+
+	case "annotation_type_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "annotation"}}
+
+	case "enum_constant":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "enumConstant"}}
+
+	case "method_declaration", "constructor_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "method", function: true}}
+
+	case "field_declaration", "constant_declaration":
+		var defs []treeSitterDefinition
+		for _, d := range childrenByFieldName(n, "declarator") {
+			defs = append(defs, treeSitterDefinition{name: d.ChildByFieldName("name"), kind: "field"})
+		}
+		return defs
+	}
+	return nil
+}
+
+// newTypeScriptParser returns a parser for TypeScript. Variables initialized
+// with a function are functions.
+func newTypeScriptParser() *treeSitterParser {
+	return &treeSitterParser{
+		language:    "TypeScript",
+		extensions:  []string{".ts", ".mts", ".cts"},
+		grammar:     typescript.GetLanguage(),
+		definitions: typeScriptDefinitions,
+	}
+}
+
+// newTSXParser returns a parser for TypeScript with JSX.
+func newTSXParser() *treeSitterParser {
+	return &treeSitterParser{
+		language:    "TypeScript",
+		extensions:  []string{".tsx"},
+		grammar:     tsx.GetLanguage(),
+		definitions: typeScriptDefinitions,
+	}
+}
+
+func typeScriptDefinitions(n *sitter.Node, content []byte, scope *treeSitterScope) []treeSitterDefinition {
+	switch n.Type() {
+	case "function_declaration", "function_signature":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "function", function: true}}
+
+	case "generator_function_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "generator", function: true}}
+
+	case "class_declaration", "abstract_class_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "class"}}
+
+	case "interface_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "interface"}}
+
+	case "enum_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "enum"}}
+
+	case "enum_assignment":
+		return []treeSitterDefinition{{name: n.NamedChild(0), kind: "enumerator"}}
+
+	case "property_identifier":
+		if p := n.Parent(); p != nil && p.Type() == "enum_body" {
+			return []treeSitterDefinition{{name: n, kind: "enumerator"}}
+		}
+
+	case "type_alias_declaration":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "alias"}}
+
+	case "internal_module", "module":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "namespace"}}
+
+	case "method_definition", "method_signature", "abstract_method_signature":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "method", function: true}}
+
+	case "public_field_definition", "property_signature":
+		return []treeSitterDefinition{{name: n.ChildByFieldName("name"), kind: "property"}}
+
+	case "variable_declarator":
+		if scope.local() {
+			return nil
+		}
+		name := n.ChildByFieldName("name")
+		if name == nil || name.Type() != "identifier" {
+			// Destructuring patterns.
+			return nil
+		}
+		if value := n.ChildByFieldName("value"); value != nil {
+			switch value.Type() {
+			case "arrow_function", "function", "generator_function":
+				return []treeSitterDefinition{{name: name, kind: "function", function: true}}
+			}
+		}
+		kind := "variable"
+		if p := n.Parent(); p != nil && p.Type() == "lexical_declaration" && p.Child(0).Type() == "const" {
+			kind = "constant"
+		}
+		return []treeSitterDefinition{{name: name, kind: kind}}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+type testSymbol struct {
+	Name, Kind, Parent, ParentKind, Scope string
+	Line, Character, EndLine              int
+	Signature                             string
+}
+
+func parseTestSymbols(t *testing.T, p LanguageParser, path, src string) ([]result.Symbol, []testSymbol) {
+	t.Helper()
+
+	symbols, err := p.Parse(path, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []testSymbol
+	for _, s := range symbols {
+		got = append(got, testSymbol{
+			Name:       s.Name,
+			Kind:       s.Kind,
+			Parent:     s.Parent,
+			ParentKind: s.ParentKind,
+			Scope:      s.Scope,
+			Line:       s.Line,
+			Character:  s.Character,
+			EndLine:    s.EndLine,
+			Signature:  s.Signature,
+		})
+	}
+	return symbols, got
+}
+
+func TestGoParser(t *testing.T) {
+	const src = `package store
+
+// Store stores things.
+type Store struct {
+	*sync.Mutex
+	db, cache string
+}
+
+type Getter interface {
+	Get(key string) (string, error)
+}
+
+func (s *Store) Get(key string) (string, error) {
+	local := 1
+	return "", nil
+}
+
+const maxSize = 10
+
+func New(db string) *Store {
+	return &Store{db: db}
+}
+`
+
+	p := newGoParser()
+	symbols, got := parseTestSymbols(t, p, "store/store.go", src)
+
+	want := []testSymbol{
+		{Name: "store", Kind: "package", Line: 1, Character: 8, EndLine: 1},
+		{Name: "Store", Kind: "struct", Parent: "store", ParentKind: "package", Scope: "store", Line: 4, Character: 5, EndLine: 7},
+		{Name: "Mutex", Kind: "anonMember", Parent: "Store", ParentKind: "struct", Scope: "store.Store", Line: 5, Character: 7, EndLine: 5},
+		{Name: "db", Kind: "member", Parent: "Store", ParentKind: "struct", Scope: "store.Store", Line: 6, Character: 1, EndLine: 6},
+		{Name: "cache", Kind: "member", Parent: "Store", ParentKind: "struct", Scope: "store.Store", Line: 6, Character: 5, EndLine: 6},
+		{Name: "Getter", Kind: "interface", Parent: "store", ParentKind: "package", Scope: "store", Line: 9, Character: 5, EndLine: 11},
+		{Name: "Get", Kind: "methodSpec", Parent: "Getter", ParentKind: "interface", Scope: "store.Getter", Line: 10, Character: 1, EndLine: 10, Signature: "(key string)"},
+		{Name: "Get", Kind: "method", Parent: "Store", ParentKind: "struct", Scope: "store.Store", Line: 13, Character: 16, EndLine: 16, Signature: "(key string)"},
+		{Name: "maxSize", Kind: "const", Parent: "store", ParentKind: "package", Scope: "store", Line: 18, Character: 6, EndLine: 18},
+		{Name: "New", Kind: "func", Parent: "store", ParentKind: "package", Scope: "store", Line: 20, Character: 5, EndLine: 22, Signature: "(db string)"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected symbols (-want +got):\n%s", diff)
+	}
+
+	// The pattern is compatible with ctags, and the range is the symbol name.
+	method := symbols[7]
+	if want := `/^func (s *Store) Get(key string) (string, error) {$/`; method.Pattern != want {
+		t.Errorf("got pattern %q, want %q", method.Pattern, want)
+	}
+	if r := method.Range(); r.Start.Line != 12 || r.Start.Character != 16 || r.End.Character != 19 {
+		t.Errorf("unexpected range %+v", r)
+	}
+
+	if _, err := p.Parse("broken.go", []byte("package broken\n\nfunc (")); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestPythonParser(t *testing.T) {
+	const src = `import os
+
+MAX_SIZE = 10
+a, b = 1, 2
+
+class Store(object):
+    @property
+    def size(self):
+        local = 1
+        return local
+
+def new(path):
+    return Store()
+`
+
+	_, got := parseTestSymbols(t, newPythonParser(), "store.py", src)
+
+	want := []testSymbol{
+		{Name: "MAX_SIZE", Kind: "variable", Line: 3, EndLine: 3},
+		{Name: "a", Kind: "variable", Line: 4, EndLine: 4},
+		{Name: "b", Kind: "variable", Line: 4, Character: 3, EndLine: 4},
+		{Name: "Store", Kind: "class", Line: 6, Character: 6, EndLine: 10},
+		{Name: "size", Kind: "member", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 8, Character: 8, EndLine: 10, Signature: "(self)"},
+		{Name: "new", Kind: "function", Line: 12, Character: 4, EndLine: 13, Signature: "(path)"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected symbols (-want +got):\n%s", diff)
+	}
+}
+
+func TestJavaParser(t *testing.T) {
+	const src = `package com.example;
+
+public class Store {
+    private int a, b;
+
+    public Store() {}
+
+    public String get(String key) {
+        int local = 1;
+        return null;
+    }
+
+    enum Mode { READ, WRITE }
+}
+`
+
+	_, got := parseTestSymbols(t, newJavaParser(), "Store.java", src)
+
+	want := []testSymbol{
+		{Name: "com.example", Kind: "package", Line: 1, Character: 8, EndLine: 1},
+		{Name: "Store", Kind: "class", Line: 3, Character: 13, EndLine: 14},
+		{Name: "a", Kind: "field", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 4, Character: 16, EndLine: 4},
+		{Name: "b", Kind: "field", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 4, Character: 19, EndLine: 4},
+		{Name: "Store", Kind: "method", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 6, Character: 11, EndLine: 6, Signature: "()"},
+		{Name: "get", Kind: "method", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 8, Character: 18, EndLine: 11, Signature: "(String key)"},
+		{Name: "Mode", Kind: "enum", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 13, Character: 9, EndLine: 13},
+		{Name: "READ", Kind: "enumConstant", Parent: "Mode", ParentKind: "enum", Scope: "Store.Mode", Line: 13, Character: 16, EndLine: 13},
+		{Name: "WRITE", Kind: "enumConstant", Parent: "Mode", ParentKind: "enum", Scope: "Store.Mode", Line: 13, Character: 22, EndLine: 13},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected symbols (-want +got):\n%s", diff)
+	}
+}
+
+func TestTypeScriptParser(t *testing.T) {
+	const src = `export const maxSize = 10
+
+export class Store {
+    private size: number
+    get(key: string): string {
+        const local = 1
+        return key
+    }
+}
+
+interface Getter {
+    get(key: string): string
+}
+
+enum Mode { Read, Write = 2 }
+
+type Key = string
+
+export const newStore = (size: number) => new Store()
+`
+
+	_, got := parseTestSymbols(t, newTypeScriptParser(), "store.ts", src)
+
+	want := []testSymbol{
+		{Name: "maxSize", Kind: "constant", Line: 1, Character: 13, EndLine: 1},
+		{Name: "Store", Kind: "class", Line: 3, Character: 13, EndLine: 9},
+		{Name: "size", Kind: "property", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 4, Character: 12, EndLine: 4},
+		{Name: "get", Kind: "method", Parent: "Store", ParentKind: "class", Scope: "Store", Line: 5, Character: 4, EndLine: 8, Signature: "(key: string)"},
+		{Name: "Getter", Kind: "interface", Line: 11, Character: 10, EndLine: 13},
+		{Name: "get", Kind: "method", Parent: "Getter", ParentKind: "interface", Scope: "Getter", Line: 12, Character: 4, EndLine: 12, Signature: "(key: string)"},
+		{Name: "Mode", Kind: "enum", Line: 15, Character: 5, EndLine: 15},
+		{Name: "Read", Kind: "enumerator", Parent: "Mode", ParentKind: "enum", Scope: "Mode", Line: 15, Character: 12, EndLine: 15},
+		{Name: "Write", Kind: "enumerator", Parent: "Mode", ParentKind: "enum", Scope: "Mode", Line: 15, Character: 18, EndLine: 15},
+		{Name: "Key", Kind: "alias", Line: 17, Character: 5, EndLine: 17},
+		{Name: "newStore", Kind: "function", Line: 19, Character: 13, EndLine: 19},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected symbols (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultLanguageParsersSupports(t *testing.T) {
+	for path, want := range map[string]string{
+		"main.go":      "Go",
+		"a/b/c.go":     "Go",
+		"index.ts":     "TypeScript",
+		"App.tsx":      "TypeScript",
+		"setup.py":     "Python",
+		"Main.java":    "Java",
+		"main.go.in":   "",
+		"index.js":     "",
+		"Makefile":     "",
+		"types.d.ts":   "TypeScript",
+		"stubs/os.pyi": "Python",
+	} {
+		var got string
+		for _, p := range DefaultLanguageParsers() {
+			if p.Supports(path) {
+				got = p.(*treeSitterParser).language
+				break
+			}
+		}
+		if got != want {
+			t.Errorf("language of %q = %q, want %q", path, got, want)
+		}
+	}
+}
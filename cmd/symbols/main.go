@@ -13,8 +13,10 @@ import (
 	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
@@ -38,6 +40,8 @@ func main() {
 		cacheSizeMB    = env.Get("SYMBOLS_CACHE_SIZE_MB", "100000", "maximum size of the disk cache in megabytes")
 		ctagsProcesses = env.Get("CTAGS_PROCESSES", strconv.Itoa(runtime.GOMAXPROCS(0)), "number of ctags child processes to run")
 		sanityCheck    = env.Get("SANITY_CHECK", "false", "check that go-sqlite3 works then exit 0 if it's ok or 1 if not")
+		useLangParsers = env.Get("SYMBOLS_LANGUAGE_PARSERS", "true", "parse files in supported languages with a language specific parser instead of ctags")
+		incremental    = env.Get("SYMBOLS_INCREMENTAL", "true", "build the symbols of a commit from the cached symbols of an ancestor commit when possible")
 	)
 
 	if sanityCheck == "true" {
@@ -77,6 +81,16 @@ func main() {
 	} else {
 		service.MaxCacheSizeBytes = mb * 1000 * 1000
 	}
+	if incremental == "true" {
+		service.FetchTarPaths = func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		}
+		service.Ancestors = ancestors
+		service.GitDiff = gitDiff
+	}
+	if useLangParsers == "true" {
+		service.LanguageParsers = symbols.DefaultLanguageParsers()
+	}
 	var err error
 	service.NumParserProcesses, err = strconv.Atoi(ctagsProcesses)
 	if err != nil {
@@ -108,6 +122,36 @@ func main() {
 	}
 }
 
+// ancestors returns up to n first-parent ancestors of commit, nearest first.
+func ancestors(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+	cmd := gitserver.DefaultClient.Command("git", "rev-list", "--first-parent", "--max-count="+strconv.Itoa(n+1), string(commit))
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+
+	var commits []api.CommitID
+	for _, line := range strings.Fields(string(out)) {
+		// The first line is commit itself.
+		if api.CommitID(line) != commit {
+			commits = append(commits, api.CommitID(line))
+		}
+	}
+	return commits, nil
+}
+
+// gitDiff returns the paths that changed between base and head.
+func gitDiff(ctx context.Context, repo api.RepoName, base, head api.CommitID) (symbols.Changes, error) {
+	cmd := gitserver.DefaultClient.Command("git", "diff", "--name-status", "--no-renames", "-z", string(base), string(head), "--")
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return symbols.Changes{}, errors.WithMessage(err, fmt.Sprintf("git command %v failed", cmd.Args))
+	}
+	return symbols.ParseGitDiffNameStatus(out)
+}
+
 func shutdownOnSIGINT(s *http.Server) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -66,8 +66,9 @@ func Init(ctx context.Context, db dbutil.DB, outOfBandMigrationRunner *oobmigrat
 			return nil
 		}
 
-		// We currently support three types of authz providers: GitHub, GitLab and Bitbucket Server.
-		authzTypes := make(map[string]struct{}, 3)
+		// We currently support authz providers for GitHub, GitLab, Bitbucket Server,
+		// Bitbucket Cloud, Gitolite and Perforce.
+		authzTypes := make(map[string]struct{}, len(providers))
 		for _, p := range providers {
 			authzTypes[p.ServiceType()] = struct{}{}
 		}
@@ -81,6 +82,10 @@ func Init(ctx context.Context, db dbutil.DB, outOfBandMigrationRunner *oobmigrat
 				authzNames = append(authzNames, "GitLab")
 			case extsvc.TypeBitbucketServer:
 				authzNames = append(authzNames, "Bitbucket Server")
+			case extsvc.TypeBitbucketCloud:
+				authzNames = append(authzNames, "Bitbucket Cloud")
+			case extsvc.TypeGitolite:
+				authzNames = append(authzNames, "Gitolite")
 			default:
 				authzNames = append(authzNames, t)
 			}
@@ -6,6 +6,7 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	"github.com/sourcegraph/sourcegraph/internal/database"
@@ -18,6 +19,11 @@ const userCredentialMigrationCountPerRun = 5
 type userCredentialMigrator struct {
 	store        *store.Store
 	allowDecrypt bool
+
+	warnedUndecryptable bool
+	// undecryptable are the IDs of the credentials which failed to be
+	// re-encrypted, which are not selected again by Up.
+	undecryptable []int64
 }
 
 var _ oobmigration.Migrator = &userCredentialMigrator{}
@@ -25,10 +31,18 @@ var _ oobmigration.Migrator = &userCredentialMigrator{}
 func (m *userCredentialMigrator) Progress(ctx context.Context) (float64, error) {
 	// What is progress, anyway?
 	//
-	// In this case, there are two things we're trying to do in this migrator.
+	// In this case, there are three things we're trying to do in this migrator.
 	// If encryption is enabled, then we want to pick up and encrypt any
 	// unencrypted credentials. We also want to replace any
 	// "previously-migrated" encryption key IDs with real key ID values.
+	// Finally, after a key rotation, we want to encrypt credentials again with
+	// the current version of the key. Credentials which failed to be
+	// re-encrypted by Up don't count against progress.
+
+	requiresReencryption, err := m.store.UserCredentials().RequiresReencryptionPredicate(ctx)
+	if err != nil {
+		return 0, err
+	}
 
 	progress, _, err := basestore.ScanFirstFloat(
 		m.store.Query(ctx, sqlf.Sprintf(
@@ -36,6 +50,8 @@ func (m *userCredentialMigrator) Progress(ctx context.Context) (float64, error)
 			database.UserCredentialDomainBatches,
 			database.UserCredentialPlaceholderEncryptionKeyID,
 			database.UserCredentialUnmigratedEncryptionKeyID,
+			requiresReencryption,
+			pq.Array(m.undecryptable),
 			database.UserCredentialDomainBatches,
 		)))
 	if err != nil {
@@ -48,7 +64,7 @@ func (m *userCredentialMigrator) Progress(ctx context.Context) (float64, error)
 const userCredentialMigratorProgressQuery = `
 -- source: enterprise/internal/batches/user_credential_migrator.go:Progress
 SELECT CASE c2.count WHEN 0 THEN 1 ELSE CAST((c2.count - c1.count) AS float) / CAST(c2.count AS float) END FROM
-	(SELECT COUNT(*) as count FROM user_credentials WHERE domain = %s AND encryption_key_id IN (%s, %s)) c1,
+	(SELECT COUNT(*) as count FROM user_credentials WHERE domain = %s AND (encryption_key_id IN (%s, %s) OR (%s AND NOT id = ANY(%s)))) c1,
 	(SELECT COUNT(*) as count FROM user_credentials WHERE domain = %s) c2
 `
 
@@ -72,18 +88,40 @@ func (m *userCredentialMigrator) Up(ctx context.Context) error {
 		if err != nil {
 			return errors.Wrap(err, "listing user credentials")
 		}
-		for _, cred := range credentials {
-			a, err := cred.Authenticator(ctx)
+
+		var outdated []*database.UserCredential
+		if remaining := userCredentialMigrationCountPerRun - len(credentials); remaining > 0 {
+			outdated, _, err = tx.UserCredentials().List(ctx, database.UserCredentialsListOpts{
+				Scope: database.UserCredentialScope{
+					Domain: database.UserCredentialDomainBatches,
+				},
+				LimitOffset: &database.LimitOffset{
+					Limit: remaining,
+				},
+				ForUpdate:            true,
+				RequiresReencryption: true,
+				ExcludeIDs:           m.undecryptable,
+			})
 			if err != nil {
-				return errors.Wrapf(err, "retrieving authenticator for ID %d", cred.ID)
+				return errors.Wrap(err, "listing user credentials requiring re-encryption")
 			}
-
-			if err := cred.SetAuthenticator(ctx, a); err != nil {
-				return errors.Wrapf(err, "setting authenticator for ID %d", cred.ID)
+			if len(outdated) < remaining {
+				m.warnUndecryptable(ctx, tx)
 			}
+		}
 
-			if err := tx.UserCredentials().Update(ctx, cred); err != nil {
-				return errors.Wrapf(err, "upserting user credential %d", cred.ID)
+		for _, cred := range credentials {
+			if err := migrateUserCredential(ctx, tx, cred); err != nil {
+				return err
+			}
+		}
+		for _, cred := range outdated {
+			if err := migrateUserCredential(ctx, tx, cred); err != nil {
+				// The credential can't be re-encrypted until the key that can
+				// decrypt it is configured again, which must not block the
+				// others.
+				log15.Warn("user credential migration: skipping credential which cannot be re-encrypted", "id", cred.ID, "encryptionKeyID", cred.EncryptionKeyID, "error", err)
+				m.undecryptable = append(m.undecryptable, cred.ID)
 			}
 		}
 
@@ -92,6 +130,52 @@ func (m *userCredentialMigrator) Up(ctx context.Context) error {
 	return tx.Done(f())
 }
 
+// migrateUserCredential encrypts the given credential with the current key.
+func migrateUserCredential(ctx context.Context, tx *store.Store, cred *database.UserCredential) error {
+	a, err := cred.Authenticator(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "retrieving authenticator for ID %d", cred.ID)
+	}
+
+	if err := cred.SetAuthenticator(ctx, a); err != nil {
+		return errors.Wrapf(err, "setting authenticator for ID %d", cred.ID)
+	}
+
+	if err := tx.UserCredentials().Update(ctx, cred); err != nil {
+		return errors.Wrapf(err, "upserting user credential %d", cred.ID)
+	}
+
+	return nil
+}
+
+// warnUndecryptable logs the number of credentials encrypted with a key other
+// than the current one once per migrator, as they are left as is by Up.
+func (m *userCredentialMigrator) warnUndecryptable(ctx context.Context, tx *store.Store) {
+	if m.warnedUndecryptable {
+		return
+	}
+	m.warnedUndecryptable = true
+
+	pred, err := tx.UserCredentials().EncryptedWithOtherKeyPredicate(ctx)
+	if err != nil {
+		log15.Error("user credential migration: failed to build predicate", "error", err)
+		return
+	}
+
+	count, _, err := basestore.ScanFirstInt(tx.Query(ctx, sqlf.Sprintf(
+		"SELECT COUNT(*) FROM user_credentials WHERE domain = %s AND %s",
+		database.UserCredentialDomainBatches,
+		pred,
+	)))
+	if err != nil {
+		log15.Error("user credential migration: failed to count credentials encrypted with another key", "error", err)
+		return
+	}
+	if count > 0 {
+		log15.Warn("user credential migration: credentials encrypted with another key cannot be re-encrypted with the current key", "count", count)
+	}
+}
+
 func (m *userCredentialMigrator) Down(ctx context.Context) error {
 	if !m.allowDecrypt {
 		log15.Warn("cannot run userCredentialMigrator.Down when decryption isn't allowed")
@@ -24,7 +24,7 @@ func TestUserCredentialMigrator(t *testing.T) {
 
 	cstore := store.New(db, &observation.TestContext, et.TestKey{})
 
-	migrator := &userCredentialMigrator{cstore, true}
+	migrator := &userCredentialMigrator{store: cstore, allowDecrypt: true}
 	a := &auth.BasicAuth{Username: "foo", Password: "bar"}
 
 	t.Run("no user credentials", func(t *testing.T) {
@@ -158,6 +158,134 @@ func TestUserCredentialMigrator(t *testing.T) {
 	})
 }
 
+func TestUserCredentialMigratorKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.NewDB(t)
+
+	key := &et.VersionedTestKey{Revision: "1"}
+	cstore := store.New(db, &observation.TestContext, key)
+
+	migrator := &userCredentialMigrator{store: cstore, allowDecrypt: true}
+	a := &auth.BasicAuth{Username: "foo", Password: "bar"}
+
+	for i := 0; i < userCredentialMigrationCountPerRun; i++ {
+		user := ct.CreateTestUser(t, db, false)
+		if _, err := cstore.UserCredentials().Create(ctx, database.UserCredentialScope{
+			Domain:              database.UserCredentialDomainBatches,
+			UserID:              user.ID,
+			ExternalServiceType: extsvc.TypeGitLab,
+			ExternalServiceID:   "https://gitlab.com/",
+		}, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertProgress(t, ctx, 1.0, migrator)
+
+	key.Revision = "2"
+	assertProgress(t, ctx, 0.0, migrator)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertProgress(t, ctx, 1.0, migrator)
+
+	version, err := key.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, _, err := cstore.UserCredentials().List(ctx, database.UserCredentialsListOpts{
+		Scope: database.UserCredentialScope{Domain: database.UserCredentialDomainBatches},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cred := range credentials {
+		if cred.EncryptionKeyID != version.JSON() {
+			t.Errorf("unexpected encryption key ID: have=%q want=%q", cred.EncryptionKeyID, version.JSON())
+		}
+
+		have, err := cred.Authenticator(ctx)
+		if err != nil {
+			t.Fatalf("cannot get authenticator: %v", err)
+		}
+		if diff := cmp.Diff(have, a); diff != "" {
+			t.Errorf("unexpected authenticator (-have +want):\n%s", diff)
+		}
+	}
+
+	// Credentials encrypted with another key can't be re-encrypted, and
+	// don't prevent the migration from completing.
+	key.Name = "other"
+	assertProgress(t, ctx, 1.0, migrator)
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertProgress(t, ctx, 1.0, migrator)
+}
+
+func TestUserCredentialMigratorUndecryptable(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.NewDB(t)
+
+	key := &et.VersionedTestKey{Revision: "1"}
+	cstore := store.New(db, &observation.TestContext, key)
+
+	migrator := &userCredentialMigrator{store: cstore, allowDecrypt: true}
+	a := &auth.BasicAuth{Username: "foo", Password: "bar"}
+
+	var creds []*database.UserCredential
+	for i := 0; i < userCredentialMigrationCountPerRun+1; i++ {
+		user := ct.CreateTestUser(t, db, false)
+		cred, err := cstore.UserCredentials().Create(ctx, database.UserCredentialScope{
+			Domain:              database.UserCredentialDomainBatches,
+			UserID:              user.ID,
+			ExternalServiceType: extsvc.TypeGitLab,
+			ExternalServiceID:   "https://gitlab.com/",
+		}, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		creds = append(creds, cred)
+	}
+
+	// A full batch of credentials which can't be decrypted anymore comes
+	// first, and must not prevent the last one from being re-encrypted.
+	for _, cred := range creds[:userCredentialMigrationCountPerRun] {
+		if err := cstore.Exec(ctx, sqlf.Sprintf(
+			"UPDATE user_credentials SET credential = %s WHERE id = %s",
+			[]byte("not base64!"),
+			cred.ID,
+		)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key.Revision = "2"
+	assertProgress(t, ctx, 0.0, migrator)
+
+	for i := 0; i < 2; i++ {
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assertProgress(t, ctx, 1.0, migrator)
+
+	version, err := key.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := cstore.UserCredentials().GetByID(ctx, creds[len(creds)-1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.EncryptionKeyID != version.JSON() {
+		t.Errorf("unexpected encryption key ID: have=%q want=%q", last.EncryptionKeyID, version.JSON())
+	}
+}
+
 func assertProgress(t *testing.T, ctx context.Context, want float64, migrator interface {
 	Progress(context.Context) (float64, error)
 }) {
@@ -250,6 +250,18 @@ func (r *batchChangeResolver) CurrentSpec(ctx context.Context) (graphqlbackend.B
 	return &batchSpecResolver{store: r.store, batchSpec: batchSpec}, nil
 }
 
+func (r *batchChangeResolver) MergeQueue(ctx context.Context) (graphqlbackend.ChangesetMergeQueueResolver, error) {
+	mergeQueue, err := r.store.GetChangesetMergeQueue(ctx, store.GetChangesetMergeQueueOpts{BatchChangeID: r.batchChange.ID})
+	if err != nil {
+		if err == store.ErrNoResults {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &changesetMergeQueueResolver{store: r.store, mergeQueue: mergeQueue}, nil
+}
+
 func (r *batchChangeResolver) BulkOperations(
 	ctx context.Context,
 	args *graphqlbackend.ListBatchChangeBulkOperationArgs,
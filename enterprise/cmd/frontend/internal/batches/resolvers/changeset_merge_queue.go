@@ -0,0 +1,123 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type changesetMergeQueueResolver struct {
+	store      *store.Store
+	mergeQueue *btypes.ChangesetMergeQueue
+}
+
+var _ graphqlbackend.ChangesetMergeQueueResolver = &changesetMergeQueueResolver{}
+
+func (r *changesetMergeQueueResolver) State() string {
+	return string(r.mergeQueue.State)
+}
+
+func (r *changesetMergeQueueResolver) Order() string {
+	return string(r.mergeQueue.Order)
+}
+
+func (r *changesetMergeQueueResolver) ConcurrencyPerCodeHost() int32 {
+	return r.mergeQueue.ConcurrencyPerCodeHost
+}
+
+func (r *changesetMergeQueueResolver) Squash() bool {
+	return r.mergeQueue.Squash
+}
+
+func (r *changesetMergeQueueResolver) EnabledBy(ctx context.Context) (*graphqlbackend.UserResolver, error) {
+	user, err := graphqlbackend.UserByIDInt32(ctx, r.store.DB(), r.mergeQueue.UserID)
+	if errcode.IsNotFound(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *changesetMergeQueueResolver) RemovedChangesets(ctx context.Context) ([]graphqlbackend.ChangesetMergeQueueRemovalResolver, error) {
+	removals, err := r.store.ListChangesetMergeQueueRemovals(ctx, r.mergeQueue.BatchChangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]graphqlbackend.ChangesetMergeQueueRemovalResolver, 0, len(removals))
+	if len(removals) == 0 {
+		return res, nil
+	}
+
+	ids := make([]int64, 0, len(removals))
+	for _, removal := range removals {
+		ids = append(ids, removal.ChangesetID)
+	}
+
+	// Load all changesets and repos at once, to avoid N+1 queries.
+	changesets, _, err := r.store.ListChangesets(ctx, store.ListChangesetsOpts{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	changesetsByID := make(map[int64]*btypes.Changeset, len(changesets))
+	for _, ch := range changesets {
+		changesetsByID[ch.ID] = ch
+	}
+	// 🚨 SECURITY: database.Repos.GetReposSetByIDs uses the authzFilter under the hood and
+	// filters out repositories that the user doesn't have access to.
+	reposByID, err := r.store.Repos().GetReposSetByIDs(ctx, changesets.RepoIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, removal := range removals {
+		ch, ok := changesetsByID[removal.ChangesetID]
+		if !ok {
+			continue
+		}
+		res = append(res, &changesetMergeQueueRemovalResolver{
+			store:     r.store,
+			changeset: ch,
+			repo:      reposByID[ch.RepoID],
+			removal:   removal,
+		})
+	}
+
+	return res, nil
+}
+
+func (r *changesetMergeQueueResolver) CreatedAt() graphqlbackend.DateTime {
+	return graphqlbackend.DateTime{Time: r.mergeQueue.CreatedAt}
+}
+
+func (r *changesetMergeQueueResolver) UpdatedAt() graphqlbackend.DateTime {
+	return graphqlbackend.DateTime{Time: r.mergeQueue.UpdatedAt}
+}
+
+type changesetMergeQueueRemovalResolver struct {
+	store     *store.Store
+	changeset *btypes.Changeset
+	repo      *types.Repo
+	removal   *btypes.ChangesetMergeQueueRemoval
+}
+
+var _ graphqlbackend.ChangesetMergeQueueRemovalResolver = &changesetMergeQueueRemovalResolver{}
+
+func (r *changesetMergeQueueRemovalResolver) Changeset() graphqlbackend.ChangesetResolver {
+	return NewChangesetResolver(r.store, r.changeset, r.repo)
+}
+
+func (r *changesetMergeQueueRemovalResolver) Reason() *string {
+	// We only show the reason when the changeset is visible to the requesting user.
+	if r.repo == nil {
+		return nil
+	}
+	return &r.removal.Reason
+}
+
+func (r *changesetMergeQueueRemovalResolver) RemovedAt() graphqlbackend.DateTime {
+	return graphqlbackend.DateTime{Time: r.removal.CreatedAt}
+}
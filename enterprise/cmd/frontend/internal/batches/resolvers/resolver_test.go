@@ -91,6 +91,10 @@ func TestNullIDResilience(t *testing.T) {
 		fmt.Sprintf(`mutation { closeChangesets(batchChange: %q, changesets: [%q]) { id } }`, marshalBatchChangeID(1), marshalChangesetID(0)),
 		fmt.Sprintf(`mutation { publishChangesets(batchChange: %q, changesets: []) { id } }`, marshalBatchChangeID(0)),
 		fmt.Sprintf(`mutation { publishChangesets(batchChange: %q, changesets: [%q]) { id } }`, marshalBatchChangeID(1), marshalChangesetID(0)),
+		fmt.Sprintf(`mutation { enableChangesetMergeQueue(batchChange: %q) { state } }`, marshalBatchChangeID(0)),
+		fmt.Sprintf(`mutation { pauseChangesetMergeQueue(batchChange: %q) { state } }`, marshalBatchChangeID(0)),
+		fmt.Sprintf(`mutation { resumeChangesetMergeQueue(batchChange: %q) { state } }`, marshalBatchChangeID(0)),
+		fmt.Sprintf(`mutation { disableChangesetMergeQueue(batchChange: %q) { alwaysNil } }`, marshalBatchChangeID(0)),
 		fmt.Sprintf(`mutation { executeBatchSpec(batchSpec: %q) { id } }`, marshalBatchSpecRandID("")),
 	}
 
@@ -0,0 +1,207 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	gh "github.com/google/go-github/v28/github"
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type BitbucketCloudWebhook struct {
+	*Webhook
+}
+
+func NewBitbucketCloudWebhook(store *store.Store) *BitbucketCloudWebhook {
+	return &BitbucketCloudWebhook{
+		Webhook: &Webhook{store, extsvc.TypeBitbucketCloud},
+	}
+}
+
+func (h *BitbucketCloudWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, extSvc, hErr := h.parseEvent(r)
+	if hErr != nil {
+		respond(w, hErr.code, hErr)
+		return
+	}
+
+	// 🚨 SECURITY: now that the shared secret has been validated, we can use an
+	// internal actor on the context.
+	ctx := actor.WithInternalActor(r.Context())
+
+	externalServiceID, err := extractExternalServiceID(extSvc)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	prs, ev, err := h.convertEvent(ctx, externalServiceID, e)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	m := new(multierror.Error)
+	for _, pr := range prs {
+		if pr == (PR{}) {
+			log15.Warn("Dropping Bitbucket Cloud webhook event", "type", fmt.Sprintf("%T", e))
+			continue
+		}
+
+		err := h.upsertChangesetEvent(ctx, externalServiceID, pr, ev)
+		if err != nil {
+			m = multierror.Append(m, err)
+		}
+	}
+	if m.ErrorOrNil() != nil {
+		respond(w, http.StatusInternalServerError, m)
+	}
+}
+
+func (h *BitbucketCloudWebhook) parseEvent(r *http.Request) (interface{}, *types.ExternalService, *httpError) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	sig := r.Header.Get("X-Hub-Signature")
+
+	rawID := r.FormValue(extsvc.IDParam)
+	var externalServiceID int64
+	if rawID != "" {
+		externalServiceID, err = strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			return nil, nil, &httpError{http.StatusBadRequest, errors.Wrap(err, "invalid external service id")}
+		}
+	}
+
+	args := database.ExternalServicesListOptions{Kinds: []string{extsvc.KindBitbucketCloud}}
+	if externalServiceID != 0 {
+		args.IDs = append(args.IDs, externalServiceID)
+	}
+	es, err := h.Store.ExternalServices().List(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	var extSvc *types.ExternalService
+	for _, e := range es {
+		if externalServiceID != 0 && e.ID != externalServiceID {
+			continue
+		}
+
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.BitbucketCloudConnection)
+		if !ok {
+			continue
+		}
+
+		if secret := con.WebhookSecret; secret != "" {
+			if err = gh.ValidateSignature(sig, payload, []byte(secret)); err == nil {
+				extSvc = e
+				break
+			}
+		}
+	}
+
+	if extSvc == nil || err != nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, err}
+	}
+
+	e, err := bitbucketcloud.ParseWebhookEvent(bitbucketcloud.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, errors.Wrap(err, "parsing webhook")}
+	}
+	return e, extSvc, nil
+}
+
+func (h *BitbucketCloudWebhook) convertEvent(ctx context.Context, externalServiceID string, theirs interface{}) (prs []PR, ours keyer, err error) {
+	log15.Debug("Bitbucket Cloud webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	switch e := theirs.(type) {
+	case *bitbucketcloud.PullRequestApprovedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestUnapprovedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestChangesRequestCreatedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestChangesRequestRemovedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestCommentCreatedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestFulfilledEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestRejectedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.PullRequestUpdatedEvent:
+		return []PR{bitbucketCloudToPR(&e.PullRequestEvent)}, e, nil
+	case *bitbucketcloud.RepoCommitStatusEvent:
+		// Commit statuses don't reference a pull request, so we have to find
+		// all open changesets whose head commit matches the status.
+		prs, err = h.prsForCommit(ctx, externalServiceID, e.Repository.UUID, e.CommitStatus.CommitHash())
+		if err != nil {
+			return nil, nil, err
+		}
+		return prs, &e.CommitStatus, nil
+	}
+
+	return nil, nil, nil
+}
+
+func (h *BitbucketCloudWebhook) prsForCommit(ctx context.Context, externalServiceID, repoExternalID, commit string) ([]PR, error) {
+	if commit == "" {
+		return nil, nil
+	}
+
+	repo, err := h.getRepoForPR(ctx, h.Store, PR{RepoExternalID: repoExternalID}, externalServiceID)
+	if err != nil {
+		log15.Warn("Webhook event could not be matched to repo", "err", err)
+		return nil, nil
+	}
+
+	cs, _, err := h.Store.ListChangesets(ctx, store.ListChangesetsOpts{
+		RepoID: repo.ID,
+		ExternalStates: []btypes.ChangesetExternalState{
+			btypes.ChangesetExternalStateOpen,
+			btypes.ChangesetExternalStateDraft,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing changesets")
+	}
+
+	var prs []PR
+	for _, c := range cs {
+		pr, ok := c.Metadata.(*bitbucketcloud.PullRequest)
+		if !ok || pr.Source.Commit == nil || pr.Source.Commit.Hash == "" {
+			continue
+		}
+		// Pull requests only contain the abbreviated commit hash.
+		if strings.HasPrefix(commit, pr.Source.Commit.Hash) {
+			prs = append(prs, PR{ID: pr.ID, RepoExternalID: repoExternalID})
+		}
+	}
+	return prs, nil
+}
+
+func bitbucketCloudToPR(e *bitbucketcloud.PullRequestEvent) PR {
+	return PR{
+		ID:             e.PullRequest.ID,
+		RepoExternalID: e.Repository.UUID,
+	}
+}
@@ -0,0 +1,229 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/timeutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Run from integration_test.go
+func testBitbucketCloudWebhook(db *sql.DB, userID int32) func(*testing.T) {
+	return func(t *testing.T) {
+		now := timeutil.Now()
+		clock := func() time.Time { return now }
+
+		ctx := context.Background()
+
+		ct.TruncateTables(t, db, "changeset_events", "changesets")
+
+		secret := "secret"
+		extSvc := &types.ExternalService{
+			Kind:        extsvc.KindBitbucketCloud,
+			DisplayName: "Bitbucket Cloud",
+			Config: ct.MarshalJSON(t, &schema.BitbucketCloudConnection{
+				Url:           "https://bitbucket.org",
+				Username:      "milton",
+				AppPassword:   "test-password",
+				Teams:         []string{"sourcegraph-testing"},
+				WebhookSecret: secret,
+			}),
+		}
+		if err := database.ExternalServices(db).Upsert(ctx, extSvc); err != nil {
+			t.Fatal(err)
+		}
+
+		// The repository is created directly, rather than synced from the
+		// code host: the webhook only needs to match its external ID.
+		repo := &types.Repo{
+			Name: "bitbucket.org/sourcegraph-testing/automation-testing",
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          "{b3e4b9e5-4c4b-4c8e-9d3a-6f4f5a3c2b1a}",
+				ServiceType: extsvc.TypeBitbucketCloud,
+				ServiceID:   "https://bitbucket.org/",
+			},
+		}
+		if err := database.Repos(db).Create(ctx, repo); err != nil {
+			t.Fatal(err)
+		}
+
+		s := store.NewWithClock(db, &observation.TestContext, nil, clock)
+
+		spec := &btypes.BatchSpec{
+			NamespaceUserID: userID,
+			UserID:          userID,
+		}
+		if err := s.CreateBatchSpec(ctx, spec); err != nil {
+			t.Fatal(err)
+		}
+
+		batchChange := &btypes.BatchChange{
+			Name:             "Test batch change for Bitbucket Cloud",
+			Description:      "Testing THE WEBHOOKS",
+			InitialApplierID: userID,
+			NamespaceUserID:  userID,
+			LastApplierID:    userID,
+			LastAppliedAt:    clock(),
+			BatchSpecID:      spec.ID,
+		}
+		if err := s.CreateBatchChange(ctx, batchChange); err != nil {
+			t.Fatal(err)
+		}
+
+		// Set up mocks to prevent the diffstat computation from trying to
+		// use a real gitserver when the changeset state is derived.
+		state := ct.MockChangesetSyncState(&protocol.RepoInfo{
+			Name: "repo",
+			VCS:  protocol.VCSInfo{URL: "https://example.com/repo/"},
+		})
+		defer state.Unmock()
+
+		// Pull requests only contain the abbreviated hash of their source
+		// commit.
+		for i, commit := range []string{"a1b2c3d4e5f6", "f6e5d4c3b2a1"} {
+			pr := &bitbucketcloud.PullRequest{ID: int64(i + 1), State: bitbucketcloud.PullRequestStateOpen}
+			pr.Source.Commit = &bitbucketcloud.PullRequestCommit{Hash: commit}
+			if err := s.CreateChangeset(ctx, &btypes.Changeset{
+				RepoID:              repo.ID,
+				ExternalID:          strconv.FormatInt(pr.ID, 10),
+				ExternalServiceType: extsvc.TypeBitbucketCloud,
+				ExternalState:       btypes.ChangesetExternalStateOpen,
+				Metadata:            pr,
+				BatchChanges:        []btypes.BatchChangeAssoc{{BatchChangeID: batchChange.ID}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		hook := NewBitbucketCloudWebhook(s)
+		u := extsvc.WebhookURL(extsvc.TypeBitbucketCloud, extSvc.ID, "https://example.com/")
+
+		newRequest := func(t *testing.T, eventType string, data []byte, secret string) *http.Request {
+			t.Helper()
+
+			req, err := http.NewRequest("POST", u, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Event-Key", eventType)
+			req.Header.Set("X-Hub-Signature", sign(t, data, []byte(secret)))
+			return req
+		}
+
+		fixtureFiles, err := filepath.Glob("testdata/fixtures/webhooks/bitbucketcloud/*.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, fixtureFile := range fixtureFiles {
+			_, name := path.Split(fixtureFile)
+			name = strings.TrimSuffix(name, ".json")
+			t.Run(name, func(t *testing.T) {
+				ct.TruncateTables(t, db, "changeset_events")
+
+				tc := loadWebhookTestCase(t, fixtureFile)
+
+				// Send all events twice to ensure we are idempotent
+				for i := 0; i < 2; i++ {
+					for _, event := range tc.Payloads {
+						rec := httptest.NewRecorder()
+						hook.ServeHTTP(rec, newRequest(t, event.PayloadType, event.Data, secret))
+						resp := rec.Result()
+
+						if resp.StatusCode != http.StatusOK {
+							t.Fatalf("Non 200 code: %v", resp.StatusCode)
+						}
+					}
+				}
+
+				have, _, err := s.ListChangesetEvents(ctx, store.ListChangesetEventsOpts{})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Overwrite and format test case
+				if *update {
+					tc.ChangesetEvents = have
+					data, err := json.MarshalIndent(tc, "  ", "  ")
+					if err != nil {
+						t.Fatal(err)
+					}
+					err = os.WriteFile(fixtureFile, data, 0666)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				opts := []cmp.Option{
+					cmpopts.IgnoreFields(btypes.ChangesetEvent{}, "CreatedAt"),
+					cmpopts.IgnoreFields(btypes.ChangesetEvent{}, "UpdatedAt"),
+				}
+				if diff := cmp.Diff(tc.ChangesetEvents, have, opts...); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
+
+		t.Run("invalid requests", func(t *testing.T) {
+			ct.TruncateTables(t, db, "changeset_events")
+
+			tc := loadWebhookTestCase(t, "testdata/fixtures/webhooks/bitbucketcloud/approved.json")
+			event := tc.Payloads[0]
+
+			for name, test := range map[string]struct {
+				req  *http.Request
+				want int
+			}{
+				"wrong secret": {
+					req:  newRequest(t, event.PayloadType, event.Data, "not the secret"),
+					want: http.StatusUnauthorized,
+				},
+				"unknown event type": {
+					req:  newRequest(t, "repo:push", event.Data, secret),
+					want: http.StatusBadRequest,
+				},
+			} {
+				t.Run(name, func(t *testing.T) {
+					rec := httptest.NewRecorder()
+					hook.ServeHTTP(rec, test.req)
+					if have := rec.Result().StatusCode; have != test.want {
+						t.Errorf("unexpected status code: have %d, want %d", have, test.want)
+					}
+				})
+			}
+
+			have, _, err := s.ListChangesetEvents(ctx, store.ListChangesetEventsOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(have) != 0 {
+				t.Errorf("unexpected changeset events: %+v", have)
+			}
+		})
+	}
+}
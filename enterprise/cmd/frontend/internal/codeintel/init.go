@@ -37,6 +37,9 @@ func Init(ctx context.Context, db dbutil.DB, outOfBandMigrationRunner *oobmigrat
 
 	enterpriseServices.CodeIntelResolver = resolver
 	enterpriseServices.NewCodeIntelUploadHandler = uploadHandler
+	// Search exports share the bucket of LSIF uploads, which is available to
+	// every frontend.
+	enterpriseServices.SearchExportStore = services.uploadStore
 	return nil
 }
 
@@ -3,6 +3,8 @@ package resolvers
 import (
 	"context"
 	"database/sql"
+	"net/url"
+	"sort"
 	"time"
 
 	"github.com/cockroachdb/errors"
@@ -115,6 +117,11 @@ func (r *Resolver) CreateCodeMonitor(ctx context.Context, args *graphqlbackend.C
 	if err != nil {
 		return nil, err
 	}
+	for i, a := range args.Actions {
+		if err = validateCreateAction(a); err != nil {
+			return nil, errors.Errorf("action %d: %w", i, err)
+		}
+	}
 	var mo *cm.Monitor
 	mo, err = r.store.CreateCodeMonitor(ctx, args)
 	if err != nil {
@@ -175,6 +182,9 @@ func (r *Resolver) UpdateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	}
 
 	toCreate, toDelete, err := splitActionIDs(ctx, args, actionIDs)
+	if err != nil {
+		return nil, err
+	}
 	if len(toDelete) == len(actionIDs) {
 		return nil, errors.Errorf("you tried to delete all actions, but every monitor must be connected to at least 1 action")
 	}
@@ -187,7 +197,7 @@ func (r *Resolver) UpdateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	}
 	defer func() { err = tx.store.Done(err) }()
 
-	err = tx.store.DeleteActionsInt64(ctx, toDelete, monitorID)
+	err = tx.deleteActions(ctx, toDelete, monitorID)
 	if err != nil {
 		return nil, err
 	}
@@ -258,91 +268,284 @@ func sendTestEmail(ctx context.Context, recipient graphql.ID, description string
 }
 
 func (r *Resolver) actionIDsForMonitorIDInt64(ctx context.Context, monitorID int64) (actionIDs []graphql.ID, err error) {
+	actions, err := r.allActionsForMonitorIDInt64(ctx, nil, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]graphql.ID, 0, len(actions))
+	for _, a := range actions {
+		ids = append(ids, actionID(a))
+	}
+	return ids, nil
+}
+
+// allActionsForMonitorIDInt64 returns all actions of a monitor, ordered by
+// their type (emails, Slack webhooks, webhooks) and then by their ID. Monitors
+// only have a handful of actions, so we load all of them and paginate in
+// memory.
+func (r *Resolver) allActionsForMonitorIDInt64(ctx context.Context, triggerEventID *int, monitorID int64) ([]graphqlbackend.MonitorAction, error) {
+	es, err := r.actionEmailsForMonitorIDInt64(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	sws, err := r.store.ListActionSlackWebhooks(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := r.store.ListActionWebhooks(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]graphqlbackend.MonitorAction, 0, len(es)+len(sws)+len(ws))
+	for _, e := range es {
+		actions = append(actions, &action{
+			email: &monitorEmail{
+				Resolver:       r,
+				MonitorEmail:   e,
+				triggerEventID: triggerEventID,
+			},
+		})
+	}
+	for _, w := range sws {
+		actions = append(actions, &action{
+			slackWebhook: &monitorSlackWebhook{
+				Resolver:            r,
+				MonitorSlackWebhook: w,
+				triggerEventID:      triggerEventID,
+			},
+		})
+	}
+	for _, w := range ws {
+		actions = append(actions, &action{
+			webhook: &monitorWebhook{
+				Resolver:       r,
+				MonitorWebhook: w,
+				triggerEventID: triggerEventID,
+			},
+		})
+	}
+	return actions, nil
+}
+
+func (r *Resolver) actionEmailsForMonitorIDInt64(ctx context.Context, monitorID int64) ([]*cm.MonitorEmail, error) {
 	limit := 50
 	var (
-		ids   []graphql.ID
+		all   []*cm.MonitorEmail
 		after *string
-		q     *sqlf.Query
 	)
 	// Paging.
 	for {
-		q, err = r.store.ReadActionEmailQuery(ctx, monitorID, &graphqlbackend.ListActionArgs{
+		q, err := r.store.ReadActionEmailQuery(ctx, monitorID, &graphqlbackend.ListActionArgs{
 			First: int32(limit),
 			After: after,
 		})
 		if err != nil {
 			return nil, err
 		}
-		es, cur, err := r.actionIDsForMonitorIDINT64SinglePage(ctx, q, limit)
+		es, err := r.actionEmailsSinglePage(ctx, q)
 		if err != nil {
 			return nil, err
 		}
-		ids = append(ids, es...)
-		if cur == nil {
+		all = append(all, es...)
+		if len(es) < limit {
 			break
 		}
-		after = cur
+		cursor := string((&monitorEmail{MonitorEmail: es[len(es)-1]}).ID())
+		after = &cursor
 	}
-	return ids, nil
+	sort.Slice(all, func(i, j int) bool { return all[i].Id < all[j].Id })
+	return all, nil
 }
 
-func (r *Resolver) actionIDsForMonitorIDINT64SinglePage(ctx context.Context, q *sqlf.Query, limit int) (ids []graphql.ID, cursor *string, err error) {
-	var rows *sql.Rows
-	rows, err = r.store.Query(ctx, q)
+func (r *Resolver) actionEmailsSinglePage(ctx context.Context, q *sqlf.Query) ([]*cm.MonitorEmail, error) {
+	rows, err := r.store.Query(ctx, q)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	defer rows.Close()
+	return cm.ScanEmails(rows)
+}
 
-	var es []*cm.MonitorEmail
-	es, err = cm.ScanEmails(rows)
-	if err != nil {
-		return nil, nil, err
+// actionKindOrder determines the order of actions of different types.
+var actionKindOrder = map[string]int{
+	monitorActionEmailKind:        0,
+	monitorActionSlackWebhookKind: 1,
+	monitorActionWebhookKind:      2,
+}
+
+func unmarshalActionID(id graphql.ID) (kind string, actionID int64, err error) {
+	kind = relay.UnmarshalKind(id)
+	if _, ok := actionKindOrder[kind]; !ok {
+		return "", 0, errors.Errorf("invalid action ID %q", id)
 	}
-	ids = make([]graphql.ID, 0, len(es))
-	for _, e := range es {
-		ids = append(ids, (&monitorEmail{MonitorEmail: e}).ID())
+	err = relay.UnmarshalSpec(id, &actionID)
+	return kind, actionID, err
+}
+
+func actionID(a graphqlbackend.MonitorAction) graphql.ID {
+	if e, ok := a.ToMonitorEmail(); ok {
+		return e.ID()
 	}
+	if w, ok := a.ToMonitorSlackWebhook(); ok {
+		return w.ID()
+	}
+	if w, ok := a.ToMonitorWebhook(); ok {
+		return w.ID()
+	}
+	return ""
+}
 
-	// Set the cursor if the result size equals limit.
-	if len(ids) == limit {
-		stringID := string(ids[len(ids)-1])
-		cursor = &stringID
+// paginateActions returns the page of actions described by args. actions
+// must be ordered as returned by allActionsForMonitorIDInt64.
+func paginateActions(actions []graphqlbackend.MonitorAction, args *graphqlbackend.ListActionArgs) ([]graphqlbackend.MonitorAction, error) {
+	if args.After != nil {
+		afterKind, afterID, err := unmarshalActionID(graphql.ID(*args.After))
+		if err != nil {
+			return nil, err
+		}
+		pos := sort.Search(len(actions), func(i int) bool {
+			// IDs of actions are always valid.
+			kind, id, _ := unmarshalActionID(actionID(actions[i]))
+			if kind != afterKind {
+				return actionKindOrder[kind] > actionKindOrder[afterKind]
+			}
+			return id > afterID
+		})
+		actions = actions[pos:]
+	}
+	if int(args.First) < len(actions) {
+		actions = actions[:args.First]
 	}
-	return ids, cursor, nil
+	return actions, nil
 }
 
 // splitActionIDs splits actions into three buckets: create, delete and update.
 // Note: args is mutated. After splitActionIDs, args only contains actions to be updated.
-func splitActionIDs(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs, actionIDs []graphql.ID) (toCreate []*graphqlbackend.CreateActionArgs, toDelete []int64, err error) {
+func splitActionIDs(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs, actionIDs []graphql.ID) (toCreate []*graphqlbackend.CreateActionArgs, toDelete []graphql.ID, err error) {
 	aMap := make(map[graphql.ID]struct{}, len(actionIDs))
 	for _, id := range actionIDs {
 		aMap[id] = struct{}{}
 	}
 	var toUpdateActions []*graphqlbackend.EditActionArgs
-	for _, a := range args.Actions {
-		if a.Email.Id == nil {
-			toCreate = append(toCreate, &graphqlbackend.CreateActionArgs{Email: a.Email.Update})
+	for i, a := range args.Actions {
+		id, kind, create, err := splitEditAction(a)
+		if err != nil {
+			return nil, nil, errors.Errorf("action %d: %w", i, err)
+		}
+		if id == nil {
+			toCreate = append(toCreate, create)
 			continue
 		}
-		if _, ok := aMap[*a.Email.Id]; !ok {
-			return nil, nil, errors.Errorf("unknown ID=%s for action", *a.Email.Id)
+		if _, ok := aMap[*id]; !ok {
+			return nil, nil, errors.Errorf("unknown ID=%s for action", *id)
+		}
+		if relay.UnmarshalKind(*id) != kind {
+			return nil, nil, errors.Errorf("ID=%s does not match the type of action %d", *id, i)
 		}
 		toUpdateActions = append(toUpdateActions, a)
-		delete(aMap, *a.Email.Id)
+		delete(aMap, *id)
 	}
-	var actionID int64
 	for k := range aMap {
-		err = relay.UnmarshalSpec(k, &actionID)
-		if err != nil {
-			return nil, nil, err
-		}
-		toDelete = append(toDelete, actionID)
+		toDelete = append(toDelete, k)
 	}
 	args.Actions = toUpdateActions
 	return toCreate, toDelete, nil
 }
 
+// splitEditAction returns the ID and kind of the action that a is editing. If
+// a doesn't have an ID, splitEditAction returns the arguments to create the
+// action instead.
+func splitEditAction(a *graphqlbackend.EditActionArgs) (id *graphql.ID, kind string, create *graphqlbackend.CreateActionArgs, err error) {
+	var n int
+	for _, set := range []bool{a.Email != nil, a.SlackWebhook != nil, a.Webhook != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, "", nil, errors.New("exactly one of email, slackWebhook and webhook must be set")
+	}
+	switch {
+	case a.Email != nil:
+		id, kind, create = a.Email.Id, monitorActionEmailKind, &graphqlbackend.CreateActionArgs{Email: a.Email.Update}
+	case a.SlackWebhook != nil:
+		id, kind, create = a.SlackWebhook.Id, monitorActionSlackWebhookKind, &graphqlbackend.CreateActionArgs{SlackWebhook: a.SlackWebhook.Update}
+	case a.Webhook != nil:
+		id, kind, create = a.Webhook.Id, monitorActionWebhookKind, &graphqlbackend.CreateActionArgs{Webhook: a.Webhook.Update}
+	}
+	if err = validateCreateAction(create); err != nil {
+		return nil, "", nil, err
+	}
+	return id, kind, create, nil
+}
+
+// validateCreateAction checks that exactly one type of action is set and that
+// webhook URLs are valid.
+func validateCreateAction(a *graphqlbackend.CreateActionArgs) error {
+	if a == nil {
+		return errors.New("exactly one of email, slackWebhook and webhook must be set")
+	}
+	var n int
+	if a.Email != nil {
+		n++
+	}
+	if a.SlackWebhook != nil {
+		n++
+		if err := validateWebhookURL(a.SlackWebhook.URL); err != nil {
+			return err
+		}
+	}
+	if a.Webhook != nil {
+		n++
+		if err := validateWebhookURL(a.Webhook.URL); err != nil {
+			return err
+		}
+	}
+	if n != 1 {
+		return errors.New("exactly one of email, slackWebhook and webhook must be set")
+	}
+	return nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Errorf("invalid webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook URL %q: must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
+
+// deleteActions deletes the actions with the given IDs, which can be of any
+// type, from the monitor.
+func (r *Resolver) deleteActions(ctx context.Context, actionIDs []graphql.ID, monitorID int64) error {
+	var emails, slackWebhooks, webhooks []int64
+	for _, id := range actionIDs {
+		kind, intID, err := unmarshalActionID(id)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case monitorActionEmailKind:
+			emails = append(emails, intID)
+		case monitorActionSlackWebhookKind:
+			slackWebhooks = append(slackWebhooks, intID)
+		case monitorActionWebhookKind:
+			webhooks = append(webhooks, intID)
+		}
+	}
+	if err := r.store.DeleteActionsInt64(ctx, emails, monitorID); err != nil {
+		return err
+	}
+	if err := r.store.DeleteActionSlackWebhooksInt64(ctx, slackWebhooks, monitorID); err != nil {
+		return err
+	}
+	return r.store.DeleteActionWebhooksInt64(ctx, webhooks, monitorID)
+}
+
 func (r *Resolver) updateCodeMonitor(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs) (m graphqlbackend.MonitorResolver, err error) {
 	// Update monitor.
 	var mo *cm.Monitor
@@ -365,24 +568,36 @@ func (r *Resolver) updateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	var emailID int64
 	var e *cm.MonitorEmail
 	for i, action := range args.Actions {
-		if action.Email == nil {
-			return nil, errors.Errorf("missing email object for action %d", i)
-		}
-		err = relay.UnmarshalSpec(*action.Email.Id, &emailID)
-		if err != nil {
-			return nil, err
-		}
-		err = r.store.DeleteRecipients(ctx, emailID)
-		if err != nil {
-			return nil, err
-		}
-		e, err = r.store.UpdateActionEmail(ctx, mo.ID, action)
-		if err != nil {
-			return nil, err
-		}
-		err = r.store.CreateRecipients(ctx, action.Email.Update.Recipients, e.Id)
-		if err != nil {
-			return nil, err
+		switch {
+		case action.Email != nil:
+			err = relay.UnmarshalSpec(*action.Email.Id, &emailID)
+			if err != nil {
+				return nil, err
+			}
+			err = r.store.DeleteRecipients(ctx, emailID)
+			if err != nil {
+				return nil, err
+			}
+			e, err = r.store.UpdateActionEmail(ctx, mo.ID, action)
+			if err != nil {
+				return nil, err
+			}
+			err = r.store.CreateRecipients(ctx, action.Email.Update.Recipients, e.Id)
+			if err != nil {
+				return nil, err
+			}
+		case action.SlackWebhook != nil:
+			_, err = r.store.UpdateActionSlackWebhook(ctx, mo.ID, action.SlackWebhook)
+			if err != nil {
+				return nil, err
+			}
+		case action.Webhook != nil:
+			_, err = r.store.UpdateActionWebhook(ctx, mo.ID, action.Webhook)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.Errorf("missing action object for action %d", i)
 		}
 	}
 	return &monitor{
@@ -526,6 +741,8 @@ const (
 	monitorTriggerQueryKind         = "CodeMonitorTriggerQuery"
 	monitorTriggerEventKind         = "CodeMonitorTriggerEvent"
 	monitorActionEmailKind          = "CodeMonitorActionEmail"
+	monitorActionSlackWebhookKind   = "CodeMonitorActionSlackWebhook"
+	monitorActionWebhookKind        = "CodeMonitorActionWebhook"
 	monitorActionEventKind          = "CodeMonitorActionEmailEvent"
 	monitorActionEmailRecipientKind = "CodeMonitorActionEmailRecipient"
 )
@@ -572,36 +789,15 @@ func (m *monitor) Actions(ctx context.Context, args *graphqlbackend.ListActionAr
 }
 
 func (r *Resolver) actionConnectionResolverWithTriggerID(ctx context.Context, triggerEventID *int, monitorID int64, args *graphqlbackend.ListActionArgs) (graphqlbackend.MonitorActionConnectionResolver, error) {
-	// For now, we only support emails as actions. Once we add other actions such as
-	// webhooks, we have to query those tables here too.
-	q, err := r.store.ReadActionEmailQuery(ctx, monitorID, args)
-	if err != nil {
-		return nil, err
-	}
-	rows, err := r.store.Query(ctx, q)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	es, err := cm.ScanEmails(rows)
+	all, err := r.allActionsForMonitorIDInt64(ctx, triggerEventID, monitorID)
 	if err != nil {
 		return nil, err
 	}
-	totalCount, err := r.store.TotalCountActionEmails(ctx, monitorID)
+	actions, err := paginateActions(all, args)
 	if err != nil {
 		return nil, err
 	}
-	actions := make([]graphqlbackend.MonitorAction, 0, len(es))
-	for _, e := range es {
-		actions = append(actions, &action{
-			email: &monitorEmail{
-				Resolver:       r,
-				MonitorEmail:   e,
-				triggerEventID: triggerEventID,
-			},
-		})
-	}
-	return &monitorActionConnection{actions: actions, totalCount: totalCount}, nil
+	return &monitorActionConnection{actions: actions, totalCount: int32(len(all))}, nil
 }
 
 //
@@ -739,24 +935,30 @@ func (a *monitorActionConnection) PageInfo(ctx context.Context) (*graphqlutil.Pa
 	if len(a.actions) == 0 {
 		return graphqlutil.HasNextPage(false), nil
 	}
-	last := a.actions[len(a.actions)-1]
-	if email, ok := last.ToMonitorEmail(); ok {
-		return graphqlutil.NextPageCursor(string(email.ID())), nil
-	}
-	return nil, errors.Errorf("we only support email actions for now")
+	return graphqlutil.NextPageCursor(string(actionID(a.actions[len(a.actions)-1]))), nil
 }
 
 //
 // Action <<UNION>>
 //
 type action struct {
-	email graphqlbackend.MonitorEmailResolver
+	email        graphqlbackend.MonitorEmailResolver
+	slackWebhook graphqlbackend.MonitorSlackWebhookResolver
+	webhook      graphqlbackend.MonitorWebhookResolver
 }
 
 func (a *action) ToMonitorEmail() (graphqlbackend.MonitorEmailResolver, bool) {
 	return a.email, a.email != nil
 }
 
+func (a *action) ToMonitorSlackWebhook() (graphqlbackend.MonitorSlackWebhookResolver, bool) {
+	return a.slackWebhook, a.slackWebhook != nil
+}
+
+func (a *action) ToMonitorWebhook() (graphqlbackend.MonitorWebhookResolver, bool) {
+	return a.webhook, a.webhook != nil
+}
+
 //
 // Email
 //
@@ -831,11 +1033,87 @@ func (m *monitorEmail) Events(ctx context.Context, args *graphqlbackend.ListEven
 	if err != nil {
 		return nil, err
 	}
-	events := make([]graphqlbackend.MonitorActionEventResolver, len(ajs))
-	for i, aj := range ajs {
-		events[i] = &monitorActionEvent{Resolver: m.Resolver, ActionJob: aj}
+	return newMonitorActionEventConnection(m.Resolver, ajs, totalCount), nil
+}
+
+//
+// Slack webhook
+//
+type monitorSlackWebhook struct {
+	*Resolver
+	*cm.MonitorSlackWebhook
+
+	// If triggerEventID == nil, all events of this action will be returned.
+	// Otherwise, only those events of this action which are related to the specified
+	// trigger event will be returned.
+	triggerEventID *int
+}
+
+func (m *monitorSlackWebhook) ID() graphql.ID {
+	return relay.MarshalID(monitorActionSlackWebhookKind, m.Id)
+}
+
+func (m *monitorSlackWebhook) Enabled() bool {
+	return m.MonitorSlackWebhook.Enabled
+}
+
+// URL returns the redacted URL of the Slack webhook, since the URL is the
+// only credential needed to post to it.
+func (m *monitorSlackWebhook) URL() string {
+	return cm.RedactSlackWebhookURL(m.MonitorSlackWebhook.URL)
+}
+
+func (m *monitorSlackWebhook) Events(ctx context.Context, args *graphqlbackend.ListEventsArgs) (graphqlbackend.MonitorActionEventConnectionResolver, error) {
+	ajs, err := m.store.ReadActionSlackWebhookEvents(ctx, m.Id, m.triggerEventID, args)
+	if err != nil {
+		return nil, err
+	}
+	totalCount, err := m.store.TotalActionSlackWebhookEvents(ctx, m.Id, m.triggerEventID)
+	if err != nil {
+		return nil, err
 	}
-	return &monitorActionEventConnection{events: events, totalCount: totalCount}, nil
+	return newMonitorActionEventConnection(m.Resolver, ajs, totalCount), nil
+}
+
+//
+// Webhook
+//
+type monitorWebhook struct {
+	*Resolver
+	*cm.MonitorWebhook
+
+	// If triggerEventID == nil, all events of this action will be returned.
+	// Otherwise, only those events of this action which are related to the specified
+	// trigger event will be returned.
+	triggerEventID *int
+}
+
+func (m *monitorWebhook) ID() graphql.ID {
+	return relay.MarshalID(monitorActionWebhookKind, m.Id)
+}
+
+func (m *monitorWebhook) Enabled() bool {
+	return m.MonitorWebhook.Enabled
+}
+
+func (m *monitorWebhook) URL() string {
+	return m.MonitorWebhook.URL
+}
+
+func (m *monitorWebhook) HasSecret() bool {
+	return m.Secret != ""
+}
+
+func (m *monitorWebhook) Events(ctx context.Context, args *graphqlbackend.ListEventsArgs) (graphqlbackend.MonitorActionEventConnectionResolver, error) {
+	ajs, err := m.store.ReadActionWebhookEvents(ctx, m.Id, m.triggerEventID, args)
+	if err != nil {
+		return nil, err
+	}
+	totalCount, err := m.store.TotalActionWebhookEvents(ctx, m.Id, m.triggerEventID)
+	if err != nil {
+		return nil, err
+	}
+	return newMonitorActionEventConnection(m.Resolver, ajs, totalCount), nil
 }
 
 //
@@ -870,6 +1148,14 @@ type monitorActionEventConnection struct {
 	totalCount int32
 }
 
+func newMonitorActionEventConnection(r *Resolver, ajs []*cm.ActionJob, totalCount int32) *monitorActionEventConnection {
+	events := make([]graphqlbackend.MonitorActionEventResolver, len(ajs))
+	for i, aj := range ajs {
+		events[i] = &monitorActionEvent{Resolver: r, ActionJob: aj}
+	}
+	return &monitorActionEventConnection{events: events, totalCount: totalCount}
+}
+
 func (a *monitorActionEventConnection) Nodes(ctx context.Context) ([]graphqlbackend.MonitorActionEventResolver, error) {
 	return a.events, nil
 }
@@ -906,7 +1192,12 @@ func (m *monitorActionEvent) Status() (string, error) {
 }
 
 func (m *monitorActionEvent) Message() *string {
-	return m.FailureMessage
+	if m.FailureMessage != nil {
+		return m.FailureMessage
+	}
+	// Successful action jobs can still carry details, such as recipients who
+	// could not be notified.
+	return m.LogContents
 }
 
 func (m *monitorActionEvent) Timestamp() graphqlbackend.DateTime {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -1212,3 +1213,117 @@ func TestMonitorKindEqualsResolvers(t *testing.T) {
 		t.Fatal("email.MonitorKind should match resolvers.MonitorKind")
 	}
 }
+
+func TestPaginateActions(t *testing.T) {
+	actions := []graphqlbackend.MonitorAction{
+		&action{email: &monitorEmail{MonitorEmail: &cm.MonitorEmail{Id: 1}}},
+		&action{email: &monitorEmail{MonitorEmail: &cm.MonitorEmail{Id: 3}}},
+		&action{slackWebhook: &monitorSlackWebhook{MonitorSlackWebhook: &cm.MonitorSlackWebhook{Id: 2}}},
+		&action{webhook: &monitorWebhook{MonitorWebhook: &cm.MonitorWebhook{Id: 1}}},
+	}
+	ids := func(as []graphqlbackend.MonitorAction) []graphql.ID {
+		var ids []graphql.ID
+		for _, a := range as {
+			ids = append(ids, actionID(a))
+		}
+		return ids
+	}
+	cursor := func(kind string, id int64) *string {
+		s := string(relay.MarshalID(kind, id))
+		return &s
+	}
+
+	tests := []struct {
+		name string
+		args *graphqlbackend.ListActionArgs
+		want []graphql.ID
+	}{
+		{
+			name: "first page",
+			args: &graphqlbackend.ListActionArgs{First: 2},
+			want: []graphql.ID{relay.MarshalID(monitorActionEmailKind, 1), relay.MarshalID(monitorActionEmailKind, 3)},
+		},
+		{
+			name: "after last email",
+			args: &graphqlbackend.ListActionArgs{First: 2, After: cursor(monitorActionEmailKind, 3)},
+			want: []graphql.ID{relay.MarshalID(monitorActionSlackWebhookKind, 2), relay.MarshalID(monitorActionWebhookKind, 1)},
+		},
+		{
+			name: "after deleted action",
+			args: &graphqlbackend.ListActionArgs{First: 10, After: cursor(monitorActionSlackWebhookKind, 1)},
+			want: []graphql.ID{relay.MarshalID(monitorActionSlackWebhookKind, 2), relay.MarshalID(monitorActionWebhookKind, 1)},
+		},
+		{
+			name: "after last action",
+			args: &graphqlbackend.ListActionArgs{First: 10, After: cursor(monitorActionWebhookKind, 1)},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := paginateActions(actions, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, ids(got)); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		if _, err := paginateActions(actions, &graphqlbackend.ListActionArgs{First: 10, After: cursor(MonitorKind, 1)}); err == nil {
+			t.Fatal("expected error for cursor of unknown kind")
+		}
+	})
+}
+
+func TestSplitActionIDs(t *testing.T) {
+	emailID := relay.MarshalID(monitorActionEmailKind, 1)
+	slackWebhookID := relay.MarshalID(monitorActionSlackWebhookKind, 1)
+	webhookID := relay.MarshalID(monitorActionWebhookKind, 1)
+	existing := []graphql.ID{emailID, slackWebhookID, webhookID}
+
+	newWebhook := &graphqlbackend.CreateActionWebhookArgs{Enabled: true, URL: "https://example.com/hook"}
+	args := &graphqlbackend.UpdateCodeMonitorArgs{
+		Actions: []*graphqlbackend.EditActionArgs{
+			{SlackWebhook: &graphqlbackend.EditActionSlackWebhookArgs{
+				Id:     &slackWebhookID,
+				Update: &graphqlbackend.CreateActionSlackWebhookArgs{Enabled: false, URL: "https://hooks.slack.com/services/x"},
+			}},
+			{Webhook: &graphqlbackend.EditActionWebhookArgs{Update: newWebhook}},
+		},
+	}
+	toCreate, toDelete, err := splitActionIDs(context.Background(), args, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*graphqlbackend.CreateActionArgs{{Webhook: newWebhook}}, toCreate); diff != "" {
+		t.Fatalf("toCreate: %s", diff)
+	}
+	sort.Slice(toDelete, func(i, j int) bool { return toDelete[i] < toDelete[j] })
+	if diff := cmp.Diff([]graphql.ID{emailID, webhookID}, toDelete); diff != "" {
+		t.Fatalf("toDelete: %s", diff)
+	}
+	if len(args.Actions) != 1 || args.Actions[0].SlackWebhook == nil {
+		t.Fatalf("expected only the Slack webhook to be updated, got %+v", args.Actions)
+	}
+
+	for name, action := range map[string]*graphqlbackend.EditActionArgs{
+		"no action": {},
+		"kind mismatch": {Email: &graphqlbackend.EditActionEmailArgs{
+			Id:     &webhookID,
+			Update: &graphqlbackend.CreateActionEmailArgs{},
+		}},
+		"invalid URL": {Webhook: &graphqlbackend.EditActionWebhookArgs{
+			Update: &graphqlbackend.CreateActionWebhookArgs{URL: "example.com/hook"},
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args := &graphqlbackend.UpdateCodeMonitorArgs{Actions: []*graphqlbackend.EditActionArgs{action}}
+			if _, _, err := splitActionIDs(context.Background(), args, existing); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
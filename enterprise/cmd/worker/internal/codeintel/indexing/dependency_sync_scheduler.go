@@ -11,6 +11,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/stores/dbstore"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
@@ -22,6 +23,10 @@ import (
 
 var schemeToExternalService = map[string]string{
 	"semanticdb": extsvc.KindJVMPackages,
+	"npm":        extsvc.KindNpmPackages,
+	"cargo":      extsvc.KindRustPackages,
+	"pypi":       extsvc.KindPythonPackages,
+	"gem":        extsvc.KindRubyPackages,
 }
 
 // NewDependencySyncScheduler returns a new worker instance that processes
@@ -102,6 +107,18 @@ func (h *dependencySyncSchedulerHandler) Handle(ctx context.Context, record work
 			continue
 		}
 
+		if reposource.IsRegistryPackageScheme(pkg.Scheme) {
+			// Record the normalized name that the package registry code
+			// hosts use, so that gitserver can look up the versions of a
+			// package by the name of its repository.
+			registryPackage, err := reposource.ParseRegistryPackage(pkg.Scheme, pkg.Name)
+			if err != nil {
+				log15.Warn("skipping invalid registry package", "error", err, "scheme", pkg.Scheme, "name", pkg.Name)
+				continue
+			}
+			pkg.Name = registryPackage.Name
+		}
+
 		new, err := h.insertDependencyRepo(ctx, pkg)
 		if err != nil {
 			errs = append(errs, err)
@@ -184,14 +201,19 @@ func (h *dependencySyncSchedulerHandler) insertDependencyRepo(ctx context.Contex
 
 // shouldIndexDependencies returns true if the given upload should undergo dependency
 // indexing. Currently, we're only enabling dependency indexing for a repositories that
-// were indexed via lsif-go and lsif-java.
+// were indexed via lsif-go, lsif-java, lsif-tsc and the indexers of the package
+// registries with code hosts.
 func (h *dependencySyncSchedulerHandler) shouldIndexDependencies(ctx context.Context, store DBStore, uploadID int) (bool, error) {
 	upload, _, err := store.GetUploadByID(ctx, uploadID)
 	if err != nil {
 		return false, errors.Wrap(err, "dbstore.GetUploadByID")
 	}
 
-	return upload.Indexer == "lsif-go" || upload.Indexer == "lsif-java", nil
+	switch upload.Indexer {
+	case "lsif-go", "lsif-java", "lsif-tsc", "lsif-rust", "lsif-py", "lsif-ruby":
+		return true, nil
+	}
+	return false, nil
 }
 
 func kindsToArray(k map[string]struct{}) (s []string) {
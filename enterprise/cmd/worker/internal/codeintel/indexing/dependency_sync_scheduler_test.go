@@ -59,6 +59,92 @@ func TestDependencySyncSchedulerJVM(t *testing.T) {
 	}
 }
 
+func TestDependencySyncSchedulerNpm(t *testing.T) {
+	newOperations(&observation.TestContext)
+	mockWorkerStore := NewMockWorkerStore()
+	mockDBStore := NewMockDBStore()
+	mockExtsvcStore := NewMockExternalServiceStore()
+	mockDBStore.WithFunc.SetDefaultReturn(mockDBStore)
+	mockScanner := NewMockPackageReferenceScanner()
+	mockDBStore.ReferencesForUploadFunc.SetDefaultReturn(mockScanner, nil)
+	mockDBStore.GetUploadByIDFunc.SetDefaultReturn(dbstore.Upload{ID: 42, RepositoryID: 50, Indexer: "lsif-tsc"}, true, nil)
+	mockScanner.NextFunc.PushReturn(shared.PackageReference{Package: shared.Package{DumpID: 42, Scheme: "npm", Name: "@types/node", Version: "16.11.6"}}, true, nil)
+
+	handler := dependencySyncSchedulerHandler{
+		dbStore:     mockDBStore,
+		workerStore: mockWorkerStore,
+		extsvcStore: mockExtsvcStore,
+	}
+
+	job := dbstore.DependencySyncingJob{
+		UploadID: 42,
+	}
+	if err := handler.Handle(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error performing update: %s", err)
+	}
+
+	if len(mockDBStore.InsertDependencyIndexingJobFunc.History()) != 1 {
+		t.Errorf("unexpected number of calls to InsertDependencyIndexingJob. want=%d have=%d", 1, len(mockDBStore.InsertDependencyIndexingJobFunc.History()))
+	} else {
+		var kinds []string
+		for _, call := range mockDBStore.InsertDependencyIndexingJobFunc.History() {
+			kinds = append(kinds, call.Arg2)
+		}
+
+		expectedKinds := []string{extsvc.KindNpmPackages}
+		if diff := cmp.Diff(expectedKinds, kinds); diff != "" {
+			t.Errorf("unexpected kinds (-want +got):\n%s", diff)
+		}
+	}
+
+	if len(mockExtsvcStore.ListFunc.History()) != 1 {
+		t.Errorf("unexpected number of calls to extsvc.List. want=%d have=%d", 1, len(mockExtsvcStore.ListFunc.History()))
+	}
+
+	if len(mockDBStore.InsertCloneableDependencyRepoFunc.History()) != 1 {
+		t.Errorf("unexpected number of calls to InsertCloneableDependencyRepo. want=%d have=%d", 1, len(mockDBStore.InsertCloneableDependencyRepoFunc.History()))
+	}
+}
+
+func TestDependencySyncSchedulerPyPI(t *testing.T) {
+	newOperations(&observation.TestContext)
+	mockWorkerStore := NewMockWorkerStore()
+	mockDBStore := NewMockDBStore()
+	mockExtsvcStore := NewMockExternalServiceStore()
+	mockDBStore.WithFunc.SetDefaultReturn(mockDBStore)
+	mockScanner := NewMockPackageReferenceScanner()
+	mockDBStore.ReferencesForUploadFunc.SetDefaultReturn(mockScanner, nil)
+	mockDBStore.GetUploadByIDFunc.SetDefaultReturn(dbstore.Upload{ID: 42, RepositoryID: 50, Indexer: "lsif-py"}, true, nil)
+	mockScanner.NextFunc.PushReturn(shared.PackageReference{Package: shared.Package{DumpID: 42, Scheme: "pypi", Name: "Flask_SQLAlchemy", Version: "2.5.1"}}, true, nil)
+
+	handler := dependencySyncSchedulerHandler{
+		dbStore:     mockDBStore,
+		workerStore: mockWorkerStore,
+		extsvcStore: mockExtsvcStore,
+	}
+
+	job := dbstore.DependencySyncingJob{
+		UploadID: 42,
+	}
+	if err := handler.Handle(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error performing update: %s", err)
+	}
+
+	if len(mockDBStore.InsertDependencyIndexingJobFunc.History()) != 1 {
+		t.Errorf("unexpected number of calls to InsertDependencyIndexingJob. want=%d have=%d", 1, len(mockDBStore.InsertDependencyIndexingJobFunc.History()))
+	} else if kind := mockDBStore.InsertDependencyIndexingJobFunc.History()[0].Arg2; kind != extsvc.KindPythonPackages {
+		t.Errorf("unexpected kind. want=%s have=%s", extsvc.KindPythonPackages, kind)
+	}
+
+	// The package name is normalized like the repository names of the PyPI
+	// code host.
+	if history := mockDBStore.InsertCloneableDependencyRepoFunc.History(); len(history) != 1 {
+		t.Errorf("unexpected number of calls to InsertCloneableDependencyRepo. want=%d have=%d", 1, len(history))
+	} else if name := history[0].Arg1.Name; name != "flask-sqlalchemy" {
+		t.Errorf("unexpected package name. want=%s have=%s", "flask-sqlalchemy", name)
+	}
+}
+
 func TestDependencySyncSchedulerGomod(t *testing.T) {
 	newOperations(&observation.TestContext)
 	mockWorkerStore := NewMockWorkerStore()
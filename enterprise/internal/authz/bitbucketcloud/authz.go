@@ -0,0 +1,109 @@
+package bitbucketcloud
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// NewAuthzProviders returns the set of Bitbucket Cloud authz providers derived from the connections.
+// It also returns any validation problems with the config, separating these into "serious problems" and
+// "warnings". "Serious problems" are those that should make Sourcegraph set authz.allowAccessByDefault
+// to false. "Warnings" are all other validation problems.
+func NewAuthzProviders(
+	conns []*types.BitbucketCloudConnection,
+) (ps []authz.Provider, problems []string, warnings []string) {
+	for _, c := range conns {
+		p, err := newAuthzProvider(c, nil)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if p != nil {
+			ps = append(ps, p)
+		}
+	}
+
+	for _, p := range ps {
+		for _, problem := range p.Validate() {
+			warnings = append(warnings, fmt.Sprintf("BitbucketCloud config for %s was invalid: %s", p.ServiceID(), problem))
+		}
+	}
+
+	return ps, problems, warnings
+}
+
+func newAuthzProvider(
+	c *types.BitbucketCloudConnection,
+	cli httpcli.Doer,
+) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	errs := new(multierror.Error)
+
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		errs = multierror.Append(errs, errors.Wrap(err, "parsing url"))
+	}
+
+	apiURL := c.ApiURL
+	if apiURL == "" {
+		apiURL = "https://api.bitbucket.org"
+	}
+	parsedAPIURL, err := url.Parse(apiURL)
+	if err != nil {
+		errs = multierror.Append(errs, errors.Wrap(err, "parsing apiURL"))
+	}
+
+	if c.Username == "" || c.AppPassword == "" {
+		errs = multierror.Append(errs, errors.New("username and appPassword are required to enforce permissions"))
+	}
+
+	if err := errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	var p authz.Provider
+	switch c.Authorization.IdentityProvider.Type {
+	case "username":
+		client := bitbucketcloud.NewClient(extsvc.NormalizeBaseURL(parsedAPIURL), cli)
+		client.Username = c.Username
+		client.AppPassword = c.AppPassword
+		p = NewProvider(client, c.URN, baseURL, workspaces(c.BitbucketCloudConnection))
+	default:
+		errs = multierror.Append(errs, errors.Errorf("No identityProvider was specified"))
+	}
+
+	return p, errs.ErrorOrNil()
+}
+
+// workspaces returns the deduplicated list of workspaces whose repositories
+// are mirrored by the given connection.
+func workspaces(c *schema.BitbucketCloudConnection) []string {
+	seen := make(map[string]struct{}, len(c.Teams)+1)
+	ws := make([]string, 0, len(c.Teams)+1)
+	for _, w := range append([]string{c.Username}, c.Teams...) {
+		if _, ok := seen[w]; ok || w == "" {
+			continue
+		}
+		seen[w] = struct{}{}
+		ws = append(ws, w)
+	}
+	return ws
+}
+
+// ValidateAuthz validates the authorization fields of the given Bitbucket Cloud external
+// service config.
+func ValidateAuthz(c *schema.BitbucketCloudConnection) error {
+	_, err := newAuthzProvider(&types.BitbucketCloudConnection{BitbucketCloudConnection: c}, nil)
+	return err
+}
@@ -0,0 +1,230 @@
+// Package bitbucketcloud contains an authorization provider for Bitbucket Cloud.
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// Provider is an implementation of AuthzProvider that provides repository permissions as
+// determined from the workspace permissions API of Bitbucket Cloud.
+type Provider struct {
+	urn        string
+	client     *bitbucketcloud.Client
+	codeHost   *extsvc.CodeHost
+	workspaces []string
+}
+
+var _ authz.Provider = (*Provider)(nil)
+
+// NewProvider returns a new Bitbucket Cloud authorization provider that uses
+// the given bitbucketcloud.Client to read the repository permissions of the
+// given workspaces. The client's credentials must belong to an administrator of
+// every workspace. It assumes usernames of Sourcegraph accounts match 1-1 with
+// nicknames of Bitbucket Cloud workspace members.
+func NewProvider(cli *bitbucketcloud.Client, urn string, baseURL *url.URL, workspaces []string) *Provider {
+	return &Provider{
+		urn:        urn,
+		client:     cli,
+		codeHost:   extsvc.NewCodeHost(baseURL, extsvc.TypeBitbucketCloud),
+		workspaces: workspaces,
+	}
+}
+
+// Validate validates that the Provider has access to the permissions API of
+// every configured workspace with the credentials it was configured with.
+func (p *Provider) Validate() (problems []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, w := range p.workspaces {
+		if _, _, err := p.client.WorkspaceRepoPermissions(ctx, &bitbucketcloud.PageToken{Pagelen: 1}, w, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("cannot read permissions of workspace %q: %s", w, err))
+		}
+	}
+	return problems
+}
+
+func (p *Provider) URN() string {
+	return p.urn
+}
+
+// ServiceID returns the absolute URL that identifies the Bitbucket Cloud instance
+// this provider is configured with.
+func (p *Provider) ServiceID() string { return p.codeHost.ServiceID }
+
+// ServiceType returns the type of this Provider, namely, "bitbucketCloud".
+func (p *Provider) ServiceType() string { return p.codeHost.ServiceType }
+
+// FetchAccount looks up the member of the configured workspaces whose nickname
+// matches the username of the given user. It returns nil if there is no such
+// member, or if the nickname is ambiguous across workspaces.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, _ []*extsvc.Account, _ []string) (acct *extsvc.Account, err error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	tr, ctx := trace.New(ctx, "bitbucketcloud.authz.provider.FetchAccount", "")
+	defer func() {
+		tr.LogFields(
+			otlog.String("user.name", user.Username),
+			otlog.Int32("user.id", user.ID),
+		)
+
+		if err != nil {
+			tr.SetError(err)
+		}
+
+		tr.Finish()
+	}()
+
+	member, err := p.member(ctx, user.Username)
+	if err != nil || member == nil {
+		return nil, err
+	}
+
+	accountData, err := json.Marshal(member)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extsvc.Account{
+		UserID: user.ID,
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: p.codeHost.ServiceType,
+			ServiceID:   p.codeHost.ServiceID,
+			AccountID:   member.UUID,
+		},
+		AccountData: extsvc.AccountData{
+			Data: (*json.RawMessage)(&accountData),
+		},
+	}, nil
+}
+
+// member returns the workspace member with the given nickname.
+func (p *Provider) member(ctx context.Context, nickname string) (*bitbucketcloud.Account, error) {
+	var found *bitbucketcloud.Account
+	for _, w := range p.workspaces {
+		t := &bitbucketcloud.PageToken{Pagelen: 100}
+		for {
+			members, next, err := p.client.WorkspaceMembers(ctx, t, w)
+			if err != nil {
+				return nil, errors.Wrapf(err, "list members of workspace %q", w)
+			}
+
+			for _, m := range members {
+				if m.Nickname != nickname {
+					continue
+				}
+				if found != nil && found.UUID != m.UUID {
+					// Nicknames are not unique on Bitbucket Cloud, so we refuse to
+					// guess which account belongs to the user.
+					return nil, nil
+				}
+				found = m
+			}
+
+			if !next.HasMore() {
+				break
+			}
+			t = next
+		}
+	}
+	return found, nil
+}
+
+// FetchUserPerms returns a list of repository UUIDs that the given account has
+// read access to in the configured workspaces. The repository UUID has the same
+// value as it would be used as api.ExternalRepoSpec.ID. The workspace
+// permissions API does not report visibility, so the list may include public
+// repositories, which callers ignore.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+//
+// API docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-workspaces/#api-workspaces-workspace-permissions-repositories-get
+func (p *Provider) FetchUserPerms(ctx context.Context, account *extsvc.Account, opts authz.FetchPermsOptions) (*authz.ExternalUserPermissions, error) {
+	switch {
+	case account == nil:
+		return nil, errors.New("no account provided")
+	case !extsvc.IsHostOfAccount(p.codeHost, account):
+		return nil, errors.Errorf("not a code host of the account: want %q but have %q",
+			p.codeHost.ServiceID, account.AccountSpec.ServiceID)
+	}
+
+	query := fmt.Sprintf("user.uuid=%q", account.AccountID)
+
+	var ids []extsvc.RepoID
+	err := p.permissions(ctx, query, func(perm *bitbucketcloud.RepoPermission) {
+		if perm.Repository != nil {
+			ids = append(ids, extsvc.RepoID(perm.Repository.UUID))
+		}
+	})
+
+	return &authz.ExternalUserPermissions{
+		Exacts: ids,
+	}, err
+}
+
+// FetchRepoPerms returns a list of user UUIDs who have read access to the given
+// repository on Bitbucket Cloud. The UUID has the same value as it would be used
+// as extsvc.Account.AccountID. The returned list includes both direct access and
+// access inherited from group membership.
+//
+// This method may return partial but valid results in case of error, and it is up to
+// callers to decide whether to discard.
+func (p *Provider) FetchRepoPerms(ctx context.Context, repo *extsvc.Repository, opts authz.FetchPermsOptions) ([]extsvc.AccountID, error) {
+	switch {
+	case repo == nil:
+		return nil, errors.New("no repo provided")
+	case !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepoSpec):
+		return nil, errors.Errorf("not a code host of the repo: want %q but have %q",
+			p.codeHost.ServiceID, repo.ServiceID)
+	}
+
+	query := fmt.Sprintf("repository.uuid=%q", repo.ID)
+
+	var ids []extsvc.AccountID
+	err := p.permissions(ctx, query, func(perm *bitbucketcloud.RepoPermission) {
+		if perm.User != nil {
+			ids = append(ids, extsvc.AccountID(perm.User.UUID))
+		}
+	})
+	return ids, err
+}
+
+// permissions calls fn for every repository permission matching the query in
+// all configured workspaces. Every permission level implies read access.
+func (p *Provider) permissions(ctx context.Context, query string, fn func(*bitbucketcloud.RepoPermission)) error {
+	for _, w := range p.workspaces {
+		t := &bitbucketcloud.PageToken{Pagelen: 100}
+		for {
+			perms, next, err := p.client.WorkspaceRepoPermissions(ctx, t, w, query)
+			if err != nil {
+				return errors.Wrapf(err, "list repository permissions of workspace %q", w)
+			}
+
+			for _, perm := range perms {
+				fn(perm)
+			}
+
+			if !next.HasMore() {
+				break
+			}
+			t = next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type permission struct {
+	workspace string
+	user      string
+	repo      string
+}
+
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	members := map[string][]*bitbucketcloud.Account{
+		"alice": {
+			{Nickname: "alice", UUID: "{alice}"},
+			{Nickname: "bob", UUID: "{bob}"},
+		},
+		"acme": {
+			{Nickname: "alice", UUID: "{alice}"},
+			{Nickname: "carol", UUID: "{carol-1}"},
+			{Nickname: "dave", UUID: "{dave}"},
+		},
+		"other": {
+			{Nickname: "carol", UUID: "{carol-2}"},
+		},
+	}
+	perms := []permission{
+		{workspace: "alice", user: "{alice}", repo: "{dotfiles}"},
+		{workspace: "alice", user: "{bob}", repo: "{dotfiles}"},
+		{workspace: "acme", user: "{alice}", repo: "{api}"},
+		{workspace: "acme", user: "{alice}", repo: "{web}"},
+		{workspace: "acme", user: "{dave}", repo: "{web}"},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/workspaces/", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "alice" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/2.0/workspaces/"), "/", 2)
+		if len(parts) != 2 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		workspace, resource := parts[0], parts[1]
+
+		var values []interface{}
+		switch resource {
+		case "members":
+			ms, ok := members[workspace]
+			if !ok {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			for _, m := range ms {
+				values = append(values, map[string]interface{}{"user": m})
+			}
+		case "permissions/repositories":
+			q := r.URL.Query().Get("q")
+			for _, p := range perms {
+				if p.workspace != workspace {
+					continue
+				}
+				if q != "" && q != fmt.Sprintf("user.uuid=%q", p.user) && q != fmt.Sprintf("repository.uuid=%q", p.repo) {
+					continue
+				}
+				values = append(values, &bitbucketcloud.RepoPermission{
+					Permission: "read",
+					User:       &bitbucketcloud.Account{UUID: p.user},
+					Repository: &bitbucketcloud.Repo{UUID: p.repo},
+				})
+			}
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		// Serve one value per page to exercise pagination.
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		resp := map[string]interface{}{"values": []interface{}{}}
+		if page < len(values) {
+			resp["values"] = values[page : page+1]
+		}
+		if page+1 < len(values) {
+			next := *r.URL
+			qry := next.Query()
+			qry.Set("page", fmt.Sprint(page+1))
+			next.RawQuery = qry.Encode()
+			resp["next"] = next.String()
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	p, err := newAuthzProvider(&types.BitbucketCloudConnection{
+		URN: "extsvc:bitbucketcloud:1",
+		BitbucketCloudConnection: &schema.BitbucketCloudConnection{
+			Url:         "https://bitbucket.org",
+			ApiURL:      srv.URL,
+			Username:    "alice",
+			AppPassword: "secret",
+			Teams:       []string{"acme", "alice"},
+			Authorization: &schema.BitbucketCloudAuthorization{
+				IdentityProvider: schema.BitbucketCloudIdentityProvider{Type: "username"},
+			},
+		},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*Provider)
+}
+
+func TestProvider_Validate(t *testing.T) {
+	p := newTestProvider(t)
+	if problems := p.Validate(); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	p.client.AppPassword = "wrong"
+	if problems := p.Validate(); len(problems) != 2 {
+		t.Fatalf("want a problem per workspace, have %v", problems)
+	}
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	p := newTestProvider(t)
+	p.workspaces = append(p.workspaces, "other")
+
+	for _, tc := range []struct {
+		username string
+		want     string
+	}{
+		{username: "alice", want: "{alice}"},
+		{username: "dave", want: "{dave}"},
+		{username: "carol"}, // ambiguous
+		{username: "nobody"},
+	} {
+		t.Run(tc.username, func(t *testing.T) {
+			acct, err := p.FetchAccount(context.Background(), &types.User{ID: 1, Username: tc.username}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have string
+			if acct != nil {
+				have = acct.AccountID
+				if acct.ServiceID != "https://bitbucket.org/" || acct.ServiceType != extsvc.TypeBitbucketCloud {
+					t.Errorf("unexpected account spec: %+v", acct.AccountSpec)
+				}
+			}
+			if have != tc.want {
+				t.Errorf("want account %q, have %q", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestProvider_FetchUserPerms(t *testing.T) {
+	p := newTestProvider(t)
+
+	perms, err := p.FetchUserPerms(context.Background(), &extsvc.Account{
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: extsvc.TypeBitbucketCloud,
+			ServiceID:   "https://bitbucket.org/",
+			AccountID:   "{alice}",
+		},
+	}, authz.FetchPermsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &authz.ExternalUserPermissions{Exacts: []extsvc.RepoID{"{dotfiles}", "{api}", "{web}"}}
+	if diff := cmp.Diff(want, perms); diff != "" {
+		t.Fatalf("unexpected perms (-want +have):\n%s", diff)
+	}
+
+	_, err = p.FetchUserPerms(context.Background(), &extsvc.Account{
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: extsvc.TypeBitbucketCloud,
+			ServiceID:   "https://bitbucket.example.com/",
+		},
+	}, authz.FetchPermsOptions{})
+	if err == nil {
+		t.Fatal("want error for account of another code host")
+	}
+}
+
+func TestProvider_FetchRepoPerms(t *testing.T) {
+	p := newTestProvider(t)
+
+	ids, err := p.FetchRepoPerms(context.Background(), &extsvc.Repository{
+		URI: "bitbucket.org/acme/web",
+		ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          "{web}",
+			ServiceType: extsvc.TypeBitbucketCloud,
+			ServiceID:   "https://bitbucket.org/",
+		},
+	}, authz.FetchPermsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]extsvc.AccountID{"{alice}", "{dave}"}, ids); diff != "" {
+		t.Fatalf("unexpected account IDs (-want +have):\n%s", diff)
+	}
+}
+
+func TestValidateAuthz(t *testing.T) {
+	err := ValidateAuthz(&schema.BitbucketCloudConnection{
+		Url:           "https://bitbucket.org",
+		Authorization: &schema.BitbucketCloudAuthorization{},
+	})
+	if err == nil {
+		t.Fatal("want error for missing credentials and identity provider")
+	}
+}
@@ -0,0 +1,54 @@
+package gitolite
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// NewAuthzProviders returns the set of Gitolite authz providers derived from
+// the connections. It also returns any validation problems with the config,
+// separating these into "serious problems" and "warnings". "Serious problems"
+// are those that should make Sourcegraph set authz.allowAccessByDefault to
+// false. "Warnings" are all other validation problems.
+func NewAuthzProviders(conns []*types.GitoliteConnection) (ps []authz.Provider, problems []string, warnings []string) {
+	for _, c := range conns {
+		p, err := newAuthzProvider(c.URN, c.GitoliteConnection)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if p != nil {
+			ps = append(ps, p)
+		}
+	}
+
+	for _, p := range ps {
+		for _, problem := range p.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Gitolite config for %s was invalid: %s", p.ServiceID(), problem))
+		}
+	}
+
+	return ps, problems, warnings
+}
+
+func newAuthzProvider(urn string, c *schema.GitoliteConnection) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	if c.Host == "" {
+		return nil, errors.New("host is required to enforce permissions")
+	}
+
+	return NewProvider(urn, c.Host, c.Prefix), nil
+}
+
+// ValidateAuthz validates the authorization fields of the given Gitolite
+// external service config.
+func ValidateAuthz(cfg *schema.GitoliteConnection) error {
+	_, err := newAuthzProvider("", cfg)
+	return err
+}
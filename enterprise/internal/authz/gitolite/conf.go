@@ -0,0 +1,372 @@
+package gitolite
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// The gitolite-admin repository layout, see
+// https://gitolite.com/gitolite/basic-admin.html.
+const (
+	confDir  = "conf"
+	confFile = "conf/gitolite.conf"
+	keyDir   = "keydir"
+)
+
+// Special names that may appear in access rules.
+const (
+	allName     = "@all"
+	creatorName = "CREATOR"
+)
+
+// plainRepoName matches repository names that are not patterns. Anything else
+// appearing on a repo line is a regular expression (a "wild repo"), matched
+// against the whole repository name.
+var plainRepoName = regexp.MustCompile(`^@?[[:alnum:]][-0-9a-zA-Z._@/+]*$`)
+
+// permPattern matches the permission field of an access rule.
+var permPattern = regexp.MustCompile(`^(-|C|R|RW\+?C?D?M?)$`)
+
+// config is the parsed representation of the access rules of a Gitolite
+// instance. It only models what is needed to answer whether a user may read a
+// repository, i.e. the first of Gitolite's two access checks.
+type config struct {
+	// groups maps a group name (including the leading "@") to its members.
+	// Groups are cumulative and may contain other groups, users or repositories.
+	groups map[string][]string
+	// rules are the access rules, in the order Gitolite evaluates them.
+	rules []*rule
+	// options are the deny-rules options, in the order they were set.
+	options []*denyRulesOption
+	// repos are the repositories named explicitly, without groups or patterns.
+	repos []string
+	// users are the users that have a public key in the keydir.
+	users []string
+
+	// wild is true if some rule applies to repositories that are not named
+	// explicitly, either via a pattern or via @all.
+	wild bool
+	// patterns are the compiled repository patterns that do not refer to
+	// CREATOR. Invalid patterns map to nil and never match.
+	patterns map[string]*regexp.Regexp
+}
+
+type rule struct {
+	repo  string
+	perm  string
+	users []string
+}
+
+type denyRulesOption struct {
+	repo    string
+	enabled bool
+}
+
+// parseConfig parses the access rules and users of the gitolite-admin
+// repository. files is the list of all files in the repository and readFile
+// returns the content of one of them.
+func parseConfig(files []string, readFile func(name string) ([]byte, error)) (*config, error) {
+	c := &config{
+		groups:   make(map[string][]string),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	for _, f := range files {
+		if !strings.HasPrefix(f, keyDir+"/") || !strings.HasSuffix(f, ".pub") {
+			continue
+		}
+		c.users = append(c.users, keyUser(path.Base(f)))
+	}
+	c.users = dedupe(c.users)
+
+	p := &confParser{config: c, files: files, readFile: readFile, seen: make(map[string]bool)}
+	if err := p.parseFile(confFile); err != nil {
+		return nil, err
+	}
+
+	named := make(map[string]bool)
+	for _, r := range c.rules {
+		for _, name := range c.expandRepo(r.repo) {
+			switch {
+			case name == allName || strings.Contains(name, creatorName):
+				c.wild = true
+			case !plainRepoName.MatchString(name):
+				c.wild = true
+				c.patterns[name] = compilePattern(name)
+			default:
+				named[name] = true
+			}
+		}
+	}
+	for _, o := range c.options {
+		for _, name := range c.expandRepo(o.repo) {
+			if name != allName && !strings.Contains(name, creatorName) && !plainRepoName.MatchString(name) {
+				c.patterns[name] = compilePattern(name)
+			}
+		}
+	}
+	for name := range named {
+		c.repos = append(c.repos, name)
+	}
+	sort.Strings(c.repos)
+
+	return c, nil
+}
+
+// keyUser returns the user name of a public key file name. Users with several
+// keys use names like "alice@laptop.pub", while names like
+// "alice@example.com.pub" denote the user "alice@example.com".
+func keyUser(name string) string {
+	name = strings.TrimSuffix(name, ".pub")
+	if i := strings.LastIndex(name, "@"); i > 0 && !strings.Contains(name[i+1:], ".") {
+		name = name[:i]
+	}
+	return name
+}
+
+type confParser struct {
+	*config
+	files    []string
+	readFile func(name string) ([]byte, error)
+	seen     map[string]bool
+
+	// block are the repository names of the current repo line.
+	block []string
+}
+
+func (p *confParser) parseFile(name string) error {
+	if p.seen[name] {
+		return errors.Errorf("%s: included more than once", name)
+	}
+	p.seen[name] = true
+
+	data, err := p.readFile(name)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", name)
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := p.parseLine(fields); err != nil {
+			return errors.Wrapf(err, "%s:%d", name, lineNo)
+		}
+	}
+	return s.Err()
+}
+
+func (p *confParser) parseLine(fields []string) error {
+	switch {
+	case fields[0] == "include" || fields[0] == "subconf":
+		if len(fields) != 2 {
+			return errors.Errorf("malformed %s statement", fields[0])
+		}
+		return p.include(strings.Trim(fields[1], `"'`))
+
+	case fields[0] == "repo":
+		p.block = fields[1:]
+		return nil
+
+	case fields[0] == "option":
+		// option deny-rules = 1
+		if len(fields) == 4 && fields[1] == "deny-rules" && fields[2] == "=" {
+			for _, r := range p.block {
+				p.options = append(p.options, &denyRulesOption{repo: r, enabled: fields[3] == "1"})
+			}
+		}
+		return nil
+
+	case fields[0] == "config":
+		return nil
+
+	case strings.HasPrefix(fields[0], "@"):
+		// @group = member...
+		if len(fields) < 2 || fields[1] != "=" {
+			return errors.Errorf("malformed group definition of %s", fields[0])
+		}
+		p.groups[fields[0]] = append(p.groups[fields[0]], fields[2:]...)
+		return nil
+
+	case permPattern.MatchString(fields[0]):
+		// PERM [refex...] = user...
+		eq := -1
+		for i, f := range fields {
+			if f == "=" {
+				eq = i
+				break
+			}
+		}
+		if eq < 0 {
+			return errors.Errorf("malformed access rule")
+		}
+		for _, r := range p.block {
+			p.rules = append(p.rules, &rule{repo: r, perm: fields[0], users: fields[eq+1:]})
+		}
+		return nil
+	}
+
+	return errors.Errorf("unrecognized statement %q", strings.Join(fields, " "))
+}
+
+// include parses all files matching the glob, relative to the conf directory.
+func (p *confParser) include(glob string) error {
+	pattern := path.Join(confDir, glob)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.Wrapf(err, "include %q", glob)
+	}
+
+	for _, f := range p.files {
+		if ok, _ := path.Match(pattern, f); ok {
+			if err := p.parseFile(f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandRepo returns the repository names and patterns a name on a repo line
+// stands for.
+func (c *config) expandRepo(name string) []string {
+	if name == allName || !strings.HasPrefix(name, "@") {
+		return []string{name}
+	}
+	return c.expandGroup(name, make(map[string]bool))
+}
+
+func (c *config) expandGroup(group string, seen map[string]bool) (members []string) {
+	if seen[group] {
+		return nil
+	}
+	seen[group] = true
+
+	for _, m := range c.groups[group] {
+		if strings.HasPrefix(m, "@") && m != allName {
+			members = append(members, c.expandGroup(m, seen)...)
+		} else {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// reader answers whether a single user may read repositories.
+type reader struct {
+	*config
+	user string
+	// names are the names the user is known by in access rules, i.e. the user
+	// name, @all and the groups the user belongs to.
+	names map[string]bool
+}
+
+// reader returns the reader for the given user.
+func (c *config) reader(user string) *reader {
+	names := map[string]bool{user: true, allName: true}
+	for group := range c.groups {
+		for _, m := range c.expandGroup(group, make(map[string]bool)) {
+			if m == user {
+				names[group] = true
+				break
+			}
+		}
+	}
+	return &reader{config: c, user: user, names: names}
+}
+
+// canRead returns true if the user is allowed to read the given repository.
+func (rd *reader) canRead(repo string) bool {
+	c, user := rd.config, rd.user
+
+	denyRules := false
+	for _, o := range c.options {
+		if ok, _ := c.matchRepo(o.repo, user, repo); ok {
+			denyRules = o.enabled
+		}
+	}
+
+	for _, r := range c.rules {
+		ok, created := c.matchRepo(r.repo, user, repo)
+		if !ok {
+			continue
+		}
+
+		applies := false
+		for _, u := range r.users {
+			if rd.names[u] || (u == creatorName && created) {
+				applies = true
+				break
+			}
+		}
+		if !applies {
+			continue
+		}
+
+		switch {
+		case r.perm == "-":
+			if denyRules {
+				return false
+			}
+		case strings.HasPrefix(r.perm, "R"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchRepo returns true if the name on a repo line applies to the given
+// repository. The second return value is true if the name is a pattern that
+// only matched because the user was substituted for CREATOR, i.e. if the user
+// would be the creator of that wild repository.
+func (c *config) matchRepo(name, user, repo string) (matched, created bool) {
+	for _, n := range c.expandRepo(name) {
+		switch {
+		case n == allName || n == repo:
+			return true, false
+		case strings.Contains(n, creatorName):
+			re := compilePattern(strings.ReplaceAll(n, creatorName, regexp.QuoteMeta(user)))
+			if re != nil && re.MatchString(repo) {
+				return true, true
+			}
+		case !plainRepoName.MatchString(n):
+			if re := c.patterns[n]; re != nil && re.MatchString(repo) {
+				return true, false
+			}
+		}
+	}
+	return false, false
+}
+
+// compilePattern compiles a repository pattern such that it must match the
+// whole repository name. It returns nil for invalid patterns.
+func compilePattern(pattern string) *regexp.Regexp {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+func dedupe(ss []string) []string {
+	sort.Strings(ss)
+	out := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
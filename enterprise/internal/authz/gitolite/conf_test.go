@@ -0,0 +1,152 @@
+package gitolite
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testConf = `
+# Groups are cumulative and may be nested.
+@admins     = alice
+@devs       = @admins bob
+@devs       = carol
+@services   = api web
+
+repo gitolite-admin
+    RW+     =   @admins
+
+repo @services
+    RW+ master  =   @devs
+    R           =   dave
+
+repo secret
+    option deny-rules = 1
+    -           =   carol
+    RW          =   @devs
+
+repo public
+    R           =   @all
+
+repo users/CREATOR/..*
+    C           =   @devs
+    RW+         =   CREATOR
+
+include "subs/*.conf"
+`
+
+const testSubConf = `
+repo legacy/..*
+    -           =   bob   # deny rules are off by default
+    R           =   bob
+`
+
+var testFiles = map[string]string{
+	"conf/gitolite.conf":          testConf,
+	"conf/subs/legacy.conf":       testSubConf,
+	"keydir/alice.pub":            "",
+	"keydir/laptop/bob.pub":       "",
+	"keydir/bob@desktop.pub":      "",
+	"keydir/carol.pub":            "",
+	"keydir/dave@example.com.pub": "",
+	"README":                      "",
+}
+
+func parseTestConfig(t *testing.T, files map[string]string) *config {
+	t.Helper()
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+
+	c, err := parseConfig(names, func(name string) ([]byte, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(data), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestParseConfig(t *testing.T) {
+	c := parseTestConfig(t, testFiles)
+
+	if diff := cmp.Diff([]string{"alice", "bob", "carol", "dave@example.com"}, c.users); diff != "" {
+		t.Errorf("unexpected users (-want +have):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"api", "gitolite-admin", "public", "secret", "web"}, c.repos); diff != "" {
+		t.Errorf("unexpected repos (-want +have):\n%s", diff)
+	}
+	if !c.wild {
+		t.Error("want wild rules")
+	}
+}
+
+func TestConfig_canRead(t *testing.T) {
+	c := parseTestConfig(t, testFiles)
+
+	for _, tc := range []struct {
+		user string
+		want []string
+	}{
+		{user: "alice", want: []string{"api", "gitolite-admin", "public", "secret", "users/alice/dotfiles", "web"}},
+		{user: "bob", want: []string{"api", "legacy/tools", "public", "secret", "users/bob/notes", "web"}},
+		{user: "carol", want: []string{"api", "public", "web"}},
+		{user: "dave", want: []string{"api", "public", "web"}},
+		{user: "mallory", want: []string{"public"}},
+	} {
+		t.Run(tc.user, func(t *testing.T) {
+			rd := c.reader(tc.user)
+
+			var have []string
+			for _, repo := range []string{
+				"api", "gitolite-admin", "legacy/tools", "public", "secret",
+				"users/alice/dotfiles", "users/bob/notes", "web",
+			} {
+				if rd.canRead(repo) {
+					have = append(have, repo)
+				}
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf("unexpected readable repos (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	for name, conf := range map[string]string{
+		"unknown statement": "repo foo\n    frobnicate = alice\n",
+		"malformed rule":    "repo foo\n    RW alice\n",
+		"malformed group":   "@devs alice\n",
+		"include loop":      "include \"gitolite.conf\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseConfig([]string{confFile}, func(string) ([]byte, error) {
+				return []byte(conf), nil
+			})
+			if err == nil {
+				t.Fatal("want error")
+			}
+		})
+	}
+}
+
+func TestKeyUser(t *testing.T) {
+	for name, want := range map[string]string{
+		"alice.pub":                  "alice",
+		"alice@laptop.pub":           "alice",
+		"alice@example.com.pub":      "alice@example.com",
+		"alice@example.com@home.pub": "alice@example.com",
+	} {
+		if have := keyUser(name); have != want {
+			t.Errorf("keyUser(%q): want %q, have %q", name, want, have)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package gitolite contains an authorization provider for Gitolite.
+package gitolite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// adminRepoName is the name of the repository holding the Gitolite
+// configuration.
+const adminRepoName = "gitolite-admin"
+
+var _ authz.Provider = (*Provider)(nil)
+
+// Provider implements authz.Provider for Gitolite repository permissions, as
+// defined by the access rules in the gitolite-admin repository.
+type Provider struct {
+	urn      string
+	codeHost *extsvc.CodeHost
+
+	host      string
+	adminRepo api.RepoName
+	lister    gitoliteLister
+
+	mu           sync.Mutex
+	cachedCommit api.CommitID
+	cachedConfig *config
+}
+
+type gitoliteLister interface {
+	ListGitolite(ctx context.Context, gitoliteHost string) ([]*gitolite.Repo, error)
+}
+
+// NewProvider returns a new Gitolite authorization provider for the given
+// host. The access rules are read from the gitolite-admin repository as
+// mirrored by Sourcegraph under the given prefix, so that repository must not
+// be excluded. It assumes usernames of Sourcegraph accounts match 1-1 with
+// Gitolite user names. It uses our default gitserver client.
+func NewProvider(urn, host, prefix string) *Provider {
+	return &Provider{
+		urn: urn,
+		codeHost: &extsvc.CodeHost{
+			ServiceID:   gitolite.ServiceID(host),
+			ServiceType: extsvc.TypeGitolite,
+		},
+		host:      host,
+		adminRepo: reposource.GitoliteRepoName(prefix, adminRepoName),
+		lister:    gitserver.DefaultClient,
+	}
+}
+
+// Validate validates that the Provider can read the access rules from the
+// gitolite-admin repository.
+func (p *Provider) Validate() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := p.config(ctx); err != nil {
+		if gitdomain.IsRepoNotExist(err) {
+			return []string{fmt.Sprintf("%s must be mirrored to enforce permissions, but it has not been cloned", p.adminRepo)}
+		}
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func (p *Provider) URN() string {
+	return p.urn
+}
+
+// ServiceID returns the host that identifies the Gitolite instance this
+// provider is configured with.
+func (p *Provider) ServiceID() string { return p.codeHost.ServiceID }
+
+// ServiceType returns the type of this Provider, namely, "gitolite".
+func (p *Provider) ServiceType() string { return p.codeHost.ServiceType }
+
+// FetchAccount returns the Gitolite account of the user with the same name as
+// the given user, if there is a public key for that name in the keydir.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, _ []*extsvc.Account, _ []string) (_ *extsvc.Account, err error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	tr, ctx := trace.New(ctx, "gitolite.authz.provider.FetchAccount", "")
+	defer func() {
+		tr.LogFields(
+			otlog.String("user.name", user.Username),
+			otlog.Int32("user.id", user.ID),
+		)
+
+		if err != nil {
+			tr.SetError(err)
+		}
+
+		tr.Finish()
+	}()
+
+	c, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range c.users {
+		if u == user.Username {
+			return &extsvc.Account{
+				UserID: user.ID,
+				AccountSpec: extsvc.AccountSpec{
+					ServiceType: p.codeHost.ServiceType,
+					ServiceID:   p.codeHost.ServiceID,
+					AccountID:   u,
+				},
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// FetchUserPerms returns the Gitolite names of the repositories the given
+// account may read. The name has the same value as it would be used as
+// api.ExternalRepoSpec.ID.
+func (p *Provider) FetchUserPerms(ctx context.Context, account *extsvc.Account, opts authz.FetchPermsOptions) (*authz.ExternalUserPermissions, error) {
+	switch {
+	case account == nil:
+		return nil, errors.New("no account provided")
+	case !extsvc.IsHostOfAccount(p.codeHost, account):
+		return nil, errors.Errorf("not a code host of the account: want %q but have %q",
+			p.codeHost.ServiceID, account.AccountSpec.ServiceID)
+	}
+
+	c, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := p.repos(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := c.reader(account.AccountID)
+	perms := &authz.ExternalUserPermissions{}
+	for _, r := range repos {
+		if rd.canRead(r) {
+			perms.Exacts = append(perms.Exacts, extsvc.RepoID(r))
+		}
+	}
+	return perms, nil
+}
+
+// FetchRepoPerms returns the names of the Gitolite users who may read the given
+// repository. The name has the same value as it would be used as
+// extsvc.Account.AccountID.
+func (p *Provider) FetchRepoPerms(ctx context.Context, repo *extsvc.Repository, opts authz.FetchPermsOptions) ([]extsvc.AccountID, error) {
+	switch {
+	case repo == nil:
+		return nil, errors.New("no repo provided")
+	case !extsvc.IsHostOfRepo(p.codeHost, &repo.ExternalRepoSpec):
+		return nil, errors.Errorf("not a code host of the repo: want %q but have %q",
+			p.codeHost.ServiceID, repo.ServiceID)
+	}
+
+	c, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []extsvc.AccountID
+	for _, u := range c.users {
+		if c.reader(u).canRead(repo.ID) {
+			ids = append(ids, extsvc.AccountID(u))
+		}
+	}
+	return ids, nil
+}
+
+// repos returns the names of all repositories the access rules may apply to.
+// The repositories are only listed from the Gitolite host when some rule
+// applies to repositories that are not named explicitly.
+func (p *Provider) repos(ctx context.Context, c *config) ([]string, error) {
+	if !c.wild {
+		return c.repos, nil
+	}
+
+	listed, err := p.lister.ListGitolite(ctx, p.host)
+	if err != nil {
+		return nil, errors.Wrap(err, "list gitolite repositories")
+	}
+
+	repos := append([]string{}, c.repos...)
+	for _, r := range listed {
+		repos = append(repos, r.Name)
+	}
+	return dedupe(repos), nil
+}
+
+// config returns the access rules at the current HEAD of the gitolite-admin
+// repository. The parsed rules are cached until HEAD changes.
+func (p *Provider) config(ctx context.Context) (*config, error) {
+	commit, err := git.ResolveRevision(ctx, p.adminRepo, "HEAD", git.ResolveRevisionOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve HEAD of %s", p.adminRepo)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cachedConfig != nil && p.cachedCommit == commit {
+		return p.cachedConfig, nil
+	}
+
+	files, err := git.LsFiles(ctx, p.adminRepo, commit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list files of %s", p.adminRepo)
+	}
+
+	c, err := parseConfig(files, func(name string) ([]byte, error) {
+		return git.ReadFile(ctx, p.adminRepo, commit, name, 0)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse access rules of %s", p.adminRepo)
+	}
+
+	p.cachedCommit, p.cachedConfig = commit, c
+	return c, nil
+}
@@ -0,0 +1,134 @@
+package gitolite
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+type gitoliteListerFunc func(ctx context.Context, gitoliteHost string) ([]*gitolite.Repo, error)
+
+func (f gitoliteListerFunc) ListGitolite(ctx context.Context, gitoliteHost string) ([]*gitolite.Repo, error) {
+	return f(ctx, gitoliteHost)
+}
+
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	var parses int
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "deadbeef", nil
+	}
+	git.Mocks.LsFiles = func(repo api.RepoName, commit api.CommitID) ([]string, error) {
+		if repo != "gitolite.example.com/gitolite-admin" {
+			t.Fatalf("unexpected admin repo %q", repo)
+		}
+		parses++
+		var names []string
+		for name := range testFiles {
+			names = append(names, name)
+		}
+		return names, nil
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		data, ok := testFiles[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(data), nil
+	}
+	t.Cleanup(func() {
+		git.ResetMocks()
+		if parses != 1 {
+			t.Errorf("want config to be parsed once, was parsed %d times", parses)
+		}
+	})
+
+	p := NewProvider("extsvc:gitolite:1", "git@gitolite.example.com", "gitolite.example.com/")
+	p.lister = gitoliteListerFunc(func(ctx context.Context, host string) ([]*gitolite.Repo, error) {
+		if host != "git@gitolite.example.com" {
+			t.Fatalf("unexpected host %q", host)
+		}
+		return []*gitolite.Repo{
+			{Name: "api"},
+			{Name: "legacy/tools"},
+			{Name: "users/bob/notes"},
+		}, nil
+	})
+	return p
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	p := newTestProvider(t)
+
+	for username, want := range map[string]*extsvc.Account{
+		"bob": {
+			UserID: 1,
+			AccountSpec: extsvc.AccountSpec{
+				ServiceType: extsvc.TypeGitolite,
+				ServiceID:   "git@gitolite.example.com",
+				AccountID:   "bob",
+			},
+		},
+		"mallory": nil,
+	} {
+		have, err := p.FetchAccount(context.Background(), &types.User{ID: 1, Username: username}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, have); diff != "" {
+			t.Errorf("%s: unexpected account (-want +have):\n%s", username, diff)
+		}
+	}
+}
+
+func TestProvider_FetchUserPerms(t *testing.T) {
+	p := newTestProvider(t)
+
+	perms, err := p.FetchUserPerms(context.Background(), &extsvc.Account{
+		AccountSpec: extsvc.AccountSpec{
+			ServiceType: extsvc.TypeGitolite,
+			ServiceID:   "git@gitolite.example.com",
+			AccountID:   "bob",
+		},
+	}, authz.FetchPermsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &authz.ExternalUserPermissions{
+		Exacts: []extsvc.RepoID{"api", "legacy/tools", "public", "secret", "users/bob/notes", "web"},
+	}
+	if diff := cmp.Diff(want, perms); diff != "" {
+		t.Fatalf("unexpected perms (-want +have):\n%s", diff)
+	}
+}
+
+func TestProvider_FetchRepoPerms(t *testing.T) {
+	p := newTestProvider(t)
+
+	ids, err := p.FetchRepoPerms(context.Background(), &extsvc.Repository{
+		URI: "gitolite.example.com/secret",
+		ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          "secret",
+			ServiceType: extsvc.TypeGitolite,
+			ServiceID:   "git@gitolite.example.com",
+		},
+	}, authz.FetchPermsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]extsvc.AccountID{"alice", "bob"}, ids); diff != "" {
+		t.Fatalf("unexpected account IDs (-want +have):\n%s", diff)
+	}
+}
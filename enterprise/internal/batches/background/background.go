@@ -33,6 +33,8 @@ func Routines(ctx context.Context, batchesStore *store.Store, cf *httpcli.Factor
 		newBulkOperationWorker(ctx, batchesStore, bulkProcessorWorkerStore, sourcer, metrics),
 		newBulkOperationWorkerResetter(bulkProcessorWorkerStore, metrics),
 
+		newChangesetMergeQueueJob(ctx, batchesStore),
+
 		newBatchSpecResolutionWorker(ctx, batchesStore, batchSpecResolutionWorkerStore, metrics),
 		newBatchSpecResolutionWorkerResetter(batchSpecResolutionWorkerStore, metrics),
 
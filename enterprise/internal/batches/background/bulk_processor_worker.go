@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
 	"github.com/keegancsmith/sqlf"
 
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/processor"
@@ -98,6 +100,16 @@ func (b *bulkProcessorWorker) HandlerFunc() workerutil.HandlerFunc {
 	return func(ctx context.Context, record workerutil.Record) (err error) {
 		job := record.(*btypes.ChangesetJob)
 
+		defer func() {
+			// The transaction is rolled back when the merge fails, so the
+			// changeset is removed from the merge queue outside of it.
+			if err != nil && isMergeQueueJob(job) && isChangesetNotMergeable(err) {
+				if removeErr := b.store.RemoveChangesetFromMergeQueue(ctx, job.BatchChangeID, job.ChangesetID, err.Error()); removeErr != nil {
+					log15.Error("removing changeset from merge queue", "changeset", job.ChangesetID, "err", removeErr)
+				}
+			}
+		}()
+
 		tx, err := b.store.Transact(ctx)
 		if err != nil {
 			return err
@@ -109,3 +121,19 @@ func (b *bulkProcessorWorker) HandlerFunc() workerutil.HandlerFunc {
 		return p.Process(ctx, job)
 	}
 }
+
+// isMergeQueueJob returns true if the given job is a merge enqueued by a merge
+// queue.
+func isMergeQueueJob(job *btypes.ChangesetJob) bool {
+	payload, ok := job.Payload.(*btypes.ChangesetJobMergePayload)
+	return ok && job.JobType == btypes.ChangesetJobTypeMerge && payload.MergeQueue
+}
+
+// isChangesetNotMergeable returns true if the code host refused to merge the
+// changeset. Sources return ChangesetNotMergeableError both as a value and as a
+// pointer.
+func isChangesetNotMergeable(err error) bool {
+	var e sources.ChangesetNotMergeableError
+	var pe *sources.ChangesetNotMergeableError
+	return errors.As(err, &e) || errors.As(err, &pe)
+}
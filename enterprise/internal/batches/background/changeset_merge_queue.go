@@ -0,0 +1,126 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+)
+
+const mergeQueueInterval = 30 * time.Second
+
+// mergeQueueRetryDelay is the time after which a merge queue retries to merge a
+// changeset whose merge failed, eg: because it was not mergeable anymore after
+// another changeset of the queue was merged into the same base branch. This
+// gives the code host time to update the mergeability and checks of the
+// changeset.
+const mergeQueueRetryDelay = 10 * time.Minute
+
+// newChangesetMergeQueueJob creates a background routine which periodically
+// enqueues merge jobs for the eligible changesets of all active merge queues.
+func newChangesetMergeQueueJob(ctx context.Context, s *store.Store) goroutine.BackgroundRoutine {
+	return goroutine.NewPeriodicGoroutine(
+		ctx,
+		mergeQueueInterval,
+		goroutine.NewHandlerWithErrorMessage("process changeset merge queues", func(ctx context.Context) (err error) {
+			tx, err := s.Transact(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() { err = tx.Done(err) }()
+
+			active := btypes.ChangesetMergeQueueStateActive
+			// Lock the queues, so that they are not processed concurrently.
+			queues, err := tx.ListChangesetMergeQueues(ctx, store.ListChangesetMergeQueuesOpts{
+				State:     &active,
+				ForUpdate: true,
+			})
+			if err != nil {
+				return errors.Wrap(err, "ListChangesetMergeQueues")
+			}
+
+			var errs error
+			for _, q := range queues {
+				if err := processChangesetMergeQueue(ctx, tx, q); err != nil {
+					errs = multierror.Append(errs, errors.Wrapf(err, "processing merge queue of batch change %d", q.BatchChangeID))
+				}
+			}
+			return errs
+		}),
+	)
+}
+
+// processChangesetMergeQueue enqueues a merge job for the next eligible
+// changesets of the given merge queue, without exceeding its concurrency limit
+// on any code host.
+func processChangesetMergeQueue(ctx context.Context, tx *store.Store, q *btypes.ChangesetMergeQueue) error {
+	candidates, err := tx.ListChangesetMergeQueueCandidates(ctx, store.ListChangesetMergeQueueCandidatesOpts{
+		BatchChangeID: q.BatchChangeID,
+		Order:         q.Order,
+		RetryAfter:    tx.Clock()().Add(-mergeQueueRetryDelay),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	inProgress, err := tx.CountChangesetMergesInProgress(ctx, q.BatchChangeID)
+	if err != nil {
+		return err
+	}
+
+	ids := selectChangesetsToMerge(candidates, inProgress, int(q.ConcurrencyPerCodeHost))
+	if len(ids) == 0 {
+		return nil
+	}
+
+	bulkGroupID, err := store.RandomID()
+	if err != nil {
+		return errors.Wrap(err, "creating bulkGroupID failed")
+	}
+
+	jobs := make([]*btypes.ChangesetJob, 0, len(ids))
+	for _, id := range ids {
+		jobs = append(jobs, &btypes.ChangesetJob{
+			BulkGroup:     bulkGroupID,
+			ChangesetID:   id,
+			BatchChangeID: q.BatchChangeID,
+			UserID:        q.UserID,
+			State:         btypes.ChangesetJobStateQueued,
+			JobType:       btypes.ChangesetJobTypeMerge,
+			Payload:       &btypes.ChangesetJobMergePayload{Squash: q.Squash, MergeQueue: true},
+		})
+	}
+
+	return tx.CreateChangesetJob(ctx, jobs...)
+}
+
+// selectChangesetsToMerge returns the IDs of the candidates to merge next, in
+// order, so that no more than concurrency changesets are being merged at the
+// same time on any code host.
+func selectChangesetsToMerge(candidates []*btypes.ChangesetMergeQueueCandidate, inProgress map[string]int, concurrency int) []int64 {
+	slots := make(map[string]int)
+	var ids []int64
+	for _, c := range candidates {
+		if _, ok := slots[c.CodeHost]; !ok {
+			slots[c.CodeHost] = concurrency - inProgress[c.CodeHost]
+		}
+
+		if slots[c.CodeHost] <= 0 {
+			continue
+		}
+
+		slots[c.CodeHost]--
+		ids = append(ids, c.ChangesetID)
+	}
+
+	return ids
+}
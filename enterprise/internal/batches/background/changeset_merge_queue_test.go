@@ -0,0 +1,75 @@
+package background
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/sources"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+)
+
+func TestSelectChangesetsToMerge(t *testing.T) {
+	candidates := []*btypes.ChangesetMergeQueueCandidate{
+		{ChangesetID: 1, CodeHost: "https://github.com/"},
+		{ChangesetID: 2, CodeHost: "https://gitlab.com/"},
+		{ChangesetID: 3, CodeHost: "https://github.com/"},
+		{ChangesetID: 4, CodeHost: "https://github.com/"},
+		{ChangesetID: 5, CodeHost: "https://gitlab.com/"},
+		{ChangesetID: 6, CodeHost: "https://bitbucket.org/"},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		inProgress  map[string]int
+		concurrency int
+		want        []int64
+	}{
+		{
+			name:        "nothing in progress",
+			concurrency: 1,
+			want:        []int64{1, 2, 6},
+		},
+		{
+			name:        "higher concurrency",
+			concurrency: 2,
+			want:        []int64{1, 2, 3, 5, 6},
+		},
+		{
+			name:        "merges in progress",
+			inProgress:  map[string]int{"https://github.com/": 1, "https://gitlab.com/": 3},
+			concurrency: 2,
+			want:        []int64{1, 6},
+		},
+		{
+			name:        "all code hosts busy",
+			inProgress:  map[string]int{"https://github.com/": 1, "https://gitlab.com/": 1, "https://bitbucket.org/": 1},
+			concurrency: 1,
+			want:        nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			have := selectChangesetsToMerge(candidates, tc.inProgress, tc.concurrency)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf("unexpected changesets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsChangesetNotMergeable(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: sources.ChangesetNotMergeableError{ErrorMsg: "conflict"}, want: true},
+		{err: &sources.ChangesetNotMergeableError{ErrorMsg: "conflict"}, want: true},
+		{err: errors.Wrap(sources.ChangesetNotMergeableError{ErrorMsg: "conflict"}, "merging"), want: true},
+		{err: errors.New("rate limited"), want: false},
+	} {
+		if have := isChangesetNotMergeable(tc.err); have != tc.want {
+			t.Errorf("unexpected result for %v: have %t, want %t", tc.err, have, tc.want)
+		}
+	}
+}
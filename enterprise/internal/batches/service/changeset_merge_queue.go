@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// ErrMergeQueueClosedBatchChange is returned when enabling or resuming the
+// merge queue of a closed batch change.
+var ErrMergeQueueClosedBatchChange = errors.New("cannot merge the changesets of a closed batch change")
+
+// ErrInvalidMergeQueueConcurrency is returned when enabling a merge queue with
+// a concurrency limit smaller than 1.
+var ErrInvalidMergeQueueConcurrency = errors.New("the merge queue concurrency per code host must be at least 1")
+
+type EnableChangesetMergeQueueOpts struct {
+	BatchChangeID          int64
+	Order                  btypes.ChangesetMergeQueueOrder
+	ConcurrencyPerCodeHost int32
+	Squash                 bool
+}
+
+// EnableChangesetMergeQueue enables, or reconfigures, the merge queue of the
+// given batch change, checking whether the actor in the context has permission
+// to merge its changesets. Changesets are merged on behalf of the actor.
+func (s *Service) EnableChangesetMergeQueue(ctx context.Context, opts EnableChangesetMergeQueueOpts) (q *btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.enableChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(opts.BatchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if !opts.Order.Valid() {
+		return nil, errors.Errorf("invalid merge order %q", opts.Order)
+	}
+	if opts.ConcurrencyPerCodeHost < 1 {
+		return nil, ErrInvalidMergeQueueConcurrency
+	}
+
+	batchChange, err := s.checkMergeQueueAccess(ctx, opts.BatchChangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if batchChange.Closed() {
+		return nil, ErrMergeQueueClosedBatchChange
+	}
+
+	q = &btypes.ChangesetMergeQueue{
+		BatchChangeID:          batchChange.ID,
+		UserID:                 actor.FromContext(ctx).UID,
+		State:                  btypes.ChangesetMergeQueueStateActive,
+		Order:                  opts.Order,
+		ConcurrencyPerCodeHost: opts.ConcurrencyPerCodeHost,
+		Squash:                 opts.Squash,
+	}
+	if err := s.store.UpsertChangesetMergeQueue(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// PauseChangesetMergeQueue pauses the merge queue of the given batch change.
+// Merges already in progress are not canceled.
+func (s *Service) PauseChangesetMergeQueue(ctx context.Context, batchChangeID int64) (q *btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.pauseChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if _, err := s.checkMergeQueueAccess(ctx, batchChangeID); err != nil {
+		return nil, err
+	}
+
+	return s.store.UpdateChangesetMergeQueueState(ctx, batchChangeID, btypes.ChangesetMergeQueueStatePaused)
+}
+
+// ResumeChangesetMergeQueue resumes the paused merge queue of the given batch
+// change.
+func (s *Service) ResumeChangesetMergeQueue(ctx context.Context, batchChangeID int64) (q *btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.resumeChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	batchChange, err := s.checkMergeQueueAccess(ctx, batchChangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if batchChange.Closed() {
+		return nil, ErrMergeQueueClosedBatchChange
+	}
+
+	return s.store.UpdateChangesetMergeQueueState(ctx, batchChangeID, btypes.ChangesetMergeQueueStateActive)
+}
+
+// DisableChangesetMergeQueue removes the merge queue of the given batch change.
+// Merges already in progress are not canceled.
+func (s *Service) DisableChangesetMergeQueue(ctx context.Context, batchChangeID int64) (err error) {
+	ctx, endObservation := s.operations.disableChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if _, err := s.checkMergeQueueAccess(ctx, batchChangeID); err != nil {
+		return err
+	}
+
+	return s.store.DeleteChangesetMergeQueue(ctx, batchChangeID)
+}
+
+// checkMergeQueueAccess loads the given batch change and checks whether the
+// actor in the context is allowed to manage its merge queue.
+func (s *Service) checkMergeQueueAccess(ctx context.Context, batchChangeID int64) (*btypes.BatchChange, error) {
+	batchChange, err := s.store.GetBatchChange(ctx, store.GetBatchChangeOpts{ID: batchChangeID})
+	if err != nil {
+		return nil, errors.Wrap(err, "loading batch change")
+	}
+
+	// 🚨 SECURITY: Only the author of the batch change can manage its merge queue,
+	// as for bulk merges.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, s.store.DB(), batchChange.InitialApplierID); err != nil {
+		return nil, err
+	}
+
+	return batchChange, nil
+}
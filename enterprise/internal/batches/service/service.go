@@ -74,6 +74,10 @@ type operations struct {
 	fetchUsernameForBitbucketServerToken *observation.Operation
 	validateAuthenticator                *observation.Operation
 	createChangesetJobs                  *observation.Operation
+	enableChangesetMergeQueue            *observation.Operation
+	pauseChangesetMergeQueue             *observation.Operation
+	resumeChangesetMergeQueue            *observation.Operation
+	disableChangesetMergeQueue           *observation.Operation
 	applyBatchChange                     *observation.Operation
 	reconcileBatchChange                 *observation.Operation
 	validateChangesetSpecs               *observation.Operation
@@ -122,6 +126,10 @@ func newOperations(observationContext *observation.Context) *operations {
 			fetchUsernameForBitbucketServerToken: op("FetchUsernameForBitbucketServerToken"),
 			validateAuthenticator:                op("ValidateAuthenticator"),
 			createChangesetJobs:                  op("CreateChangesetJobs"),
+			enableChangesetMergeQueue:            op("EnableChangesetMergeQueue"),
+			pauseChangesetMergeQueue:             op("PauseChangesetMergeQueue"),
+			resumeChangesetMergeQueue:            op("ResumeChangesetMergeQueue"),
+			disableChangesetMergeQueue:           op("DisableChangesetMergeQueue"),
 			applyBatchChange:                     op("ApplyBatchChange"),
 			reconcileBatchChange:                 op("ReconcileBatchChange"),
 			validateChangesetSpecs:               op("ValidateChangesetSpecs"),
@@ -0,0 +1,272 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type BitbucketCloudSource struct {
+	client *bitbucketcloud.Client
+	au     auth.Authenticator
+}
+
+var _ ChangesetSource = &BitbucketCloudSource{}
+
+// NewBitbucketCloudSource returns a new BitbucketCloudSource from the given external service.
+func NewBitbucketCloudSource(svc *types.ExternalService, cf *httpcli.Factory) (*BitbucketCloudSource, error) {
+	var c schema.BitbucketCloudConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newBitbucketCloudSource(&c, cf, nil)
+}
+
+func newBitbucketCloudSource(c *schema.BitbucketCloudConnection, cf *httpcli.Factory, au auth.Authenticator) (*BitbucketCloudSource, error) {
+	// Don't modify passed-in parameter.
+	rawURL := c.ApiURL
+	if rawURL == "" {
+		rawURL = "https://api.bitbucket.org"
+	}
+	apiURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	apiURL = extsvc.NormalizeBaseURL(apiURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't modify passed-in parameter.
+	var authr auth.Authenticator
+	if au == nil && c.AppPassword != "" {
+		authr = &auth.BasicAuth{Username: c.Username, Password: c.AppPassword}
+	} else {
+		authr = au
+	}
+
+	client := bitbucketcloud.NewClient(apiURL, cli)
+	if authr != nil {
+		client = client.WithAuthenticator(authr)
+	}
+
+	return &BitbucketCloudSource{
+		au:     authr,
+		client: client,
+	}, nil
+}
+
+func (s BitbucketCloudSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s BitbucketCloudSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	switch a.(type) {
+	case *auth.BasicAuth,
+		*auth.BasicAuthWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("BitbucketCloudSource", a)
+	}
+
+	return &BitbucketCloudSource{
+		client: s.client.WithAuthenticator(a),
+		au:     a,
+	}, nil
+}
+
+// AuthenticatedUsername uses the underlying bitbucketcloud.Client to get the
+// username belonging to the credentials associated with the
+// BitbucketCloudSource.
+func (s BitbucketCloudSource) AuthenticatedUsername(ctx context.Context) (string, error) {
+	user, err := s.client.CurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func (s BitbucketCloudSource) ValidateAuthenticator(ctx context.Context) error {
+	_, err := s.client.CurrentUser(ctx)
+	return err
+}
+
+// CreateChangeset creates the given *Changeset in the code host.
+func (s BitbucketCloudSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	var exists bool
+
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	input := pullRequestInput(c)
+
+	// Bitbucket Cloud silently updates an existing pull request with the same
+	// source and destination branches instead of returning an error, so we
+	// have to look for one before creating the pull request.
+	pr, err := s.client.FindOpenPullRequest(ctx, repo, input)
+	if err == nil {
+		exists = true
+	} else if err == bitbucketcloud.ErrPullRequestNotFound {
+		pr, err = s.client.CreatePullRequest(ctx, repo, input)
+		if err != nil {
+			return exists, errors.Wrap(err, "creating pull request")
+		}
+	} else {
+		return exists, errors.Wrap(err, "looking up existing pull request")
+	}
+
+	if err := s.setChangesetMetadata(ctx, repo, pr, c); err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+// CloseChangeset closes the given *Changeset on the code host and updates the
+// Metadata column in the *batches.Changeset to the newly closed pull request.
+func (s BitbucketCloudSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	declined, err := s.client.DeclinePullRequest(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrap(err, "declining pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, declined, c)
+}
+
+// LoadChangeset loads the latest state of the given Changeset from the codehost.
+func (s BitbucketCloudSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	repo := cs.Repo.Metadata.(*bitbucketcloud.Repo)
+	number, err := strconv.ParseInt(cs.ExternalID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "converting external ID")
+	}
+
+	pr, err := s.client.GetPullRequest(ctx, repo, number)
+	if err != nil {
+		if err == bitbucketcloud.ErrPullRequestNotFound {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrap(err, "getting pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, pr, cs)
+}
+
+// UpdateChangeset can update Changesets.
+func (s BitbucketCloudSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	updated, err := s.client.UpdatePullRequest(ctx, repo, pr.ID, pullRequestInput(c))
+	if err != nil {
+		return errors.Wrap(err, "updating pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, updated, c)
+}
+
+// ReopenChangeset reopens the *Changeset on the code host and updates the
+// Metadata column in the *batches.Changeset.
+//
+// Bitbucket Cloud can't reopen a declined pull request under any
+// circumstances, so a new pull request for the same branches is opened
+// instead.
+func (s BitbucketCloudSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	pr, err := s.client.CreatePullRequest(ctx, repo, pullRequestInput(c))
+	if err != nil {
+		return errors.Wrap(err, "reopening pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, pr, c)
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s BitbucketCloudSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	return s.client.CreatePullRequestComment(ctx, repo, pr.ID, text)
+}
+
+// MergeChangeset merges a Changeset on the code host, if in a mergeable state.
+// If squash is true, a squash merge is performed, otherwise a merge commit is
+// created.
+func (s BitbucketCloudSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	opts := bitbucketcloud.MergePullRequestOpts{
+		CloseSourceBranch: pr.CloseSourceBranch,
+		MergeStrategy:     bitbucketcloud.MergeStrategyMergeCommit,
+	}
+	if squash {
+		opts.MergeStrategy = bitbucketcloud.MergeStrategySquash
+	}
+
+	merged, err := s.client.MergePullRequest(ctx, repo, pr.ID, opts)
+	if err != nil {
+		if errors.Is(err, bitbucketcloud.ErrNotMergeable) {
+			return &ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return err
+	}
+
+	return s.setChangesetMetadata(ctx, repo, merged, c)
+}
+
+// setChangesetMetadata loads the commit statuses of the given pull request,
+// which aren't part of the pull request responses, and sets the pull request
+// as the metadata of the changeset.
+func (s BitbucketCloudSource) setChangesetMetadata(ctx context.Context, repo *bitbucketcloud.Repo, pr *bitbucketcloud.PullRequest, c *Changeset) error {
+	if err := s.client.LoadPullRequestStatuses(ctx, repo, pr); err != nil {
+		return errors.Wrap(err, "loading pull request statuses")
+	}
+	if err := c.SetMetadata(pr); err != nil {
+		return errors.Wrap(err, "setting changeset metadata")
+	}
+	return nil
+}
+
+func pullRequestInput(c *Changeset) bitbucketcloud.PullRequestInput {
+	return bitbucketcloud.PullRequestInput{
+		Title:             c.Title,
+		Description:       c.Body,
+		SourceBranch:      git.AbbreviateRef(c.HeadRef),
+		DestinationBranch: git.AbbreviateRef(c.BaseRef),
+	}
+}
@@ -67,6 +67,14 @@ var RequiredEventTypesForHistory = []btypes.ChangesetEventKind{
 	btypes.ChangesetEventKindBitbucketServerUnapproved,
 	btypes.ChangesetEventKindBitbucketServerDismissed,
 	btypes.ChangesetEventKindGitLabUnapproved,
+	btypes.ChangesetEventKindBitbucketCloudApproved,
+	btypes.ChangesetEventKindBitbucketCloudChangesRequested,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestApproved,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestChangesRequestCreated,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestUnapproved,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestChangesRequestRemoved,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestFulfilled,
+	btypes.ChangesetEventKindBitbucketCloudPullRequestRejected,
 }
 
 type changesetStatesAtTime struct {
@@ -121,7 +129,8 @@ func computeHistory(ch *btypes.Changeset, ce ChangesetEvents) (changesetHistory,
 		switch e.Kind {
 		case btypes.ChangesetEventKindGitHubClosed,
 			btypes.ChangesetEventKindBitbucketServerDeclined,
-			btypes.ChangesetEventKindGitLabClosed:
+			btypes.ChangesetEventKindGitLabClosed,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestRejected:
 			// Merged is a final state. We can ignore everything after.
 			if currentExtState != btypes.ChangesetExternalStateMerged {
 				currentExtState = btypes.ChangesetExternalStateClosed
@@ -130,7 +139,8 @@ func computeHistory(ch *btypes.Changeset, ce ChangesetEvents) (changesetHistory,
 
 		case btypes.ChangesetEventKindGitHubMerged,
 			btypes.ChangesetEventKindBitbucketServerMerged,
-			btypes.ChangesetEventKindGitLabMerged:
+			btypes.ChangesetEventKindGitLabMerged,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestFulfilled:
 			currentExtState = btypes.ChangesetExternalStateMerged
 			pushStates(et)
 
@@ -175,7 +185,11 @@ func computeHistory(ch *btypes.Changeset, ce ChangesetEvents) (changesetHistory,
 		case btypes.ChangesetEventKindGitHubReviewed,
 			btypes.ChangesetEventKindBitbucketServerApproved,
 			btypes.ChangesetEventKindBitbucketServerReviewed,
-			btypes.ChangesetEventKindGitLabApproved:
+			btypes.ChangesetEventKindGitLabApproved,
+			btypes.ChangesetEventKindBitbucketCloudApproved,
+			btypes.ChangesetEventKindBitbucketCloudChangesRequested,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestApproved,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestChangesRequestCreated:
 
 			s, err := e.ReviewState()
 			if err != nil {
@@ -217,29 +231,33 @@ func computeHistory(ch *btypes.Changeset, ce ChangesetEvents) (changesetHistory,
 
 		case btypes.ChangesetEventKindBitbucketServerUnapproved,
 			btypes.ChangesetEventKindBitbucketServerDismissed,
-			btypes.ChangesetEventKindGitLabUnapproved:
+			btypes.ChangesetEventKindGitLabUnapproved,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestUnapproved,
+			btypes.ChangesetEventKindBitbucketCloudPullRequestChangesRequestRemoved:
 			author := e.ReviewAuthor()
 			// If the user has been deleted, skip their reviews, as they don't count towards the final state anymore.
 			if author == "" {
 				continue
 			}
 
-			if e.Type() == btypes.ChangesetEventKindBitbucketServerUnapproved {
-				// A BitbucketServer Unapproved can only follow a previous Approved by
+			if e.Type() == btypes.ChangesetEventKindBitbucketServerUnapproved ||
+				e.Type() == btypes.ChangesetEventKindBitbucketCloudPullRequestUnapproved {
+				// A Bitbucket Unapproved can only follow a previous Approved by
 				// the same author.
 				lastReview, ok := lastReviewByAuthor[author]
 				if !ok || lastReview != btypes.ChangesetReviewStateApproved {
-					log15.Warn("Bitbucket Server Unapproval not following an Approval", "event", e)
+					log15.Warn("Bitbucket Unapproval not following an Approval", "event", e)
 					continue
 				}
 			}
 
-			if e.Type() == btypes.ChangesetEventKindBitbucketServerDismissed {
-				// A BitbucketServer Dismissed event can only follow a previous "Changes Requested" review by
+			if e.Type() == btypes.ChangesetEventKindBitbucketServerDismissed ||
+				e.Type() == btypes.ChangesetEventKindBitbucketCloudPullRequestChangesRequestRemoved {
+				// A Bitbucket Dismissed event can only follow a previous "Changes Requested" review by
 				// the same author.
 				lastReview, ok := lastReviewByAuthor[author]
 				if !ok || lastReview != btypes.ChangesetReviewStateChangesRequested {
-					log15.Warn("Bitbucket Server Dismissal not following a Review", "event", e)
+					log15.Warn("Bitbucket Dismissal not following a Review", "event", e)
 					continue
 				}
 			}
@@ -9,6 +9,7 @@ import (
 
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -276,6 +277,95 @@ func TestComputeBitbucketBuildStatus(t *testing.T) {
 	}
 }
 
+func TestComputeBitbucketCloudBuildStatus(t *testing.T) {
+	t.Parallel()
+
+	now := timeutil.Now()
+	sha := "abcdef"
+	status := func(commit, key string, state bitbucketcloud.PullRequestStatusState) *bitbucketcloud.PullRequestStatus {
+		s := &bitbucketcloud.PullRequestStatus{
+			StatusKey: key,
+			State:     state,
+			UpdatedOn: now,
+		}
+		s.Links.Commit.Href = "https://api.bitbucket.org/2.0/repositories/sglocal/mux/commit/" + commit
+		return s
+	}
+	statusEvent := func(commit, key string, state bitbucketcloud.PullRequestStatusState) *btypes.ChangesetEvent {
+		return &btypes.ChangesetEvent{
+			Kind:     btypes.ChangesetEventKindBitbucketCloudCommitStatus,
+			Metadata: status(commit, key, state),
+		}
+	}
+
+	lastSynced := now.Add(-1 * time.Minute)
+
+	tests := []struct {
+		name     string
+		statuses []*bitbucketcloud.PullRequestStatus
+		events   []*btypes.ChangesetEvent
+		want     btypes.ChangesetCheckState
+	}{
+		{
+			name: "empty",
+			want: btypes.ChangesetCheckStateUnknown,
+		},
+		{
+			name: "synced success",
+			statuses: []*bitbucketcloud.PullRequestStatus{
+				status(sha+"0123", "ctx1", bitbucketcloud.PullRequestStatusStateSuccessful),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		{
+			name: "statuses of other commits are ignored",
+			statuses: []*bitbucketcloud.PullRequestStatus{
+				status("fedcba", "ctx1", bitbucketcloud.PullRequestStatusStateFailed),
+				status(sha, "ctx2", bitbucketcloud.PullRequestStatusStateSuccessful),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		{
+			name: "stopped is failed",
+			statuses: []*bitbucketcloud.PullRequestStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateStopped),
+			},
+			want: btypes.ChangesetCheckStateFailed,
+		},
+		{
+			name: "pending + success",
+			events: []*btypes.ChangesetEvent{
+				statusEvent(sha, "ctx1", bitbucketcloud.PullRequestStatusStateInProgress),
+				statusEvent(sha, "ctx2", bitbucketcloud.PullRequestStatusStateSuccessful),
+			},
+			want: btypes.ChangesetCheckStatePending,
+		},
+		{
+			name: "events override synced statuses",
+			statuses: []*bitbucketcloud.PullRequestStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateInProgress),
+			},
+			events: []*btypes.ChangesetEvent{
+				statusEvent(sha, "ctx1", bitbucketcloud.PullRequestStatusStateFailed),
+			},
+			want: btypes.ChangesetCheckStateFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := &bitbucketcloud.PullRequest{
+				Source:   bitbucketcloud.PullRequestEndpoint{Commit: &bitbucketcloud.PullRequestCommit{Hash: sha}},
+				Statuses: tc.statuses,
+			}
+			have := computeBitbucketCloudBuildStatus(lastSynced, pr, tc.events)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
 func TestComputeGitLabCheckState(t *testing.T) {
 	t.Parallel()
 
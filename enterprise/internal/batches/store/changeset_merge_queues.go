@@ -0,0 +1,487 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/opentracing/opentracing-go/log"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+var changesetMergeQueueColumns = []*sqlf.Query{
+	sqlf.Sprintf("changeset_merge_queues.id"),
+	sqlf.Sprintf("changeset_merge_queues.batch_change_id"),
+	sqlf.Sprintf("changeset_merge_queues.user_id"),
+	sqlf.Sprintf("changeset_merge_queues.state"),
+	sqlf.Sprintf("changeset_merge_queues.merge_order"),
+	sqlf.Sprintf("changeset_merge_queues.concurrency_per_code_host"),
+	sqlf.Sprintf("changeset_merge_queues.squash"),
+	sqlf.Sprintf("changeset_merge_queues.created_at"),
+	sqlf.Sprintf("changeset_merge_queues.updated_at"),
+}
+
+// UpsertChangesetMergeQueue creates the given merge queue or, if the batch
+// change already has a merge queue, replaces its configuration.
+func (s *Store) UpsertChangesetMergeQueue(ctx context.Context, q *btypes.ChangesetMergeQueue) (err error) {
+	ctx, endObservation := s.operations.upsertChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(q.BatchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if q.CreatedAt.IsZero() {
+		q.CreatedAt = s.now()
+	}
+	q.UpdatedAt = s.now()
+
+	return s.query(ctx, upsertChangesetMergeQueueQuery(q), func(sc dbutil.Scanner) error {
+		return scanChangesetMergeQueue(q, sc)
+	})
+}
+
+var upsertChangesetMergeQueueQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:UpsertChangesetMergeQueue
+INSERT INTO changeset_merge_queues (
+	batch_change_id,
+	user_id,
+	state,
+	merge_order,
+	concurrency_per_code_host,
+	squash,
+	created_at,
+	updated_at
+)
+VALUES
+	(%s, %s, %s, %s, %s, %s, %s, %s)
+ON CONFLICT (batch_change_id) DO UPDATE SET
+	user_id = EXCLUDED.user_id,
+	state = EXCLUDED.state,
+	merge_order = EXCLUDED.merge_order,
+	concurrency_per_code_host = EXCLUDED.concurrency_per_code_host,
+	squash = EXCLUDED.squash,
+	updated_at = EXCLUDED.updated_at
+RETURNING
+	%s
+`
+
+func upsertChangesetMergeQueueQuery(q *btypes.ChangesetMergeQueue) *sqlf.Query {
+	return sqlf.Sprintf(
+		upsertChangesetMergeQueueQueryFmtstr,
+		q.BatchChangeID,
+		q.UserID,
+		q.State.ToDB(),
+		q.Order.ToDB(),
+		q.ConcurrencyPerCodeHost,
+		q.Squash,
+		q.CreatedAt,
+		q.UpdatedAt,
+		sqlf.Join(changesetMergeQueueColumns, ","),
+	)
+}
+
+// UpdateChangesetMergeQueueState sets the state of the merge queue of the given
+// batch change.
+func (s *Store) UpdateChangesetMergeQueueState(ctx context.Context, batchChangeID int64, state btypes.ChangesetMergeQueueState) (q *btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.updateChangesetMergeQueueState.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+		log.String("state", string(state)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	var updated btypes.ChangesetMergeQueue
+	err = s.query(ctx, sqlf.Sprintf(
+		updateChangesetMergeQueueStateQueryFmtstr,
+		state.ToDB(),
+		s.now(),
+		batchChangeID,
+		sqlf.Join(changesetMergeQueueColumns, ","),
+	), func(sc dbutil.Scanner) error {
+		return scanChangesetMergeQueue(&updated, sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.ID == 0 {
+		return nil, ErrNoResults
+	}
+
+	return &updated, nil
+}
+
+var updateChangesetMergeQueueStateQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:UpdateChangesetMergeQueueState
+UPDATE changeset_merge_queues
+SET
+	state = %s,
+	updated_at = %s
+WHERE
+	batch_change_id = %s
+RETURNING
+	%s
+`
+
+// DeleteChangesetMergeQueue deletes the merge queue of the given batch change.
+func (s *Store) DeleteChangesetMergeQueue(ctx context.Context, batchChangeID int64) (err error) {
+	ctx, endObservation := s.operations.deleteChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	res, err := s.ExecResult(ctx, sqlf.Sprintf(deleteChangesetMergeQueueQueryFmtstr, batchChangeID))
+	if err != nil {
+		return err
+	}
+
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return ErrNoResults
+	}
+	return nil
+}
+
+var deleteChangesetMergeQueueQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:DeleteChangesetMergeQueue
+DELETE FROM changeset_merge_queues WHERE batch_change_id = %s
+`
+
+// GetChangesetMergeQueueOpts captures the query options needed for getting a
+// ChangesetMergeQueue.
+type GetChangesetMergeQueueOpts struct {
+	BatchChangeID int64
+}
+
+// GetChangesetMergeQueue gets the merge queue of a batch change.
+func (s *Store) GetChangesetMergeQueue(ctx context.Context, opts GetChangesetMergeQueueOpts) (q *btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.getChangesetMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(opts.BatchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	var queue btypes.ChangesetMergeQueue
+	err = s.query(ctx, sqlf.Sprintf(
+		getChangesetMergeQueueQueryFmtstr,
+		sqlf.Join(changesetMergeQueueColumns, ","),
+		opts.BatchChangeID,
+	), func(sc dbutil.Scanner) error {
+		return scanChangesetMergeQueue(&queue, sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if queue.ID == 0 {
+		return nil, ErrNoResults
+	}
+
+	return &queue, nil
+}
+
+var getChangesetMergeQueueQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:GetChangesetMergeQueue
+SELECT %s FROM changeset_merge_queues
+WHERE batch_change_id = %s
+`
+
+// ListChangesetMergeQueuesOpts captures the query options needed for listing
+// merge queues.
+type ListChangesetMergeQueuesOpts struct {
+	State *btypes.ChangesetMergeQueueState
+	// ForUpdate locks the returned merge queues until the end of the
+	// transaction. Merge queues locked by another transaction are skipped.
+	ForUpdate bool
+}
+
+// ListChangesetMergeQueues lists the merge queues of all open batch changes
+// matching the given options.
+func (s *Store) ListChangesetMergeQueues(ctx context.Context, opts ListChangesetMergeQueuesOpts) (qs []*btypes.ChangesetMergeQueue, err error) {
+	ctx, endObservation := s.operations.listChangesetMergeQueues.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	err = s.query(ctx, listChangesetMergeQueuesQuery(opts), func(sc dbutil.Scanner) error {
+		var q btypes.ChangesetMergeQueue
+		if err := scanChangesetMergeQueue(&q, sc); err != nil {
+			return err
+		}
+		qs = append(qs, &q)
+		return nil
+	})
+
+	return qs, err
+}
+
+var listChangesetMergeQueuesQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:ListChangesetMergeQueues
+SELECT %s FROM changeset_merge_queues
+INNER JOIN batch_changes ON batch_changes.id = changeset_merge_queues.batch_change_id
+WHERE %s
+ORDER BY changeset_merge_queues.id ASC
+%s  -- optional FOR UPDATE
+`
+
+func listChangesetMergeQueuesQuery(opts ListChangesetMergeQueuesOpts) *sqlf.Query {
+	preds := []*sqlf.Query{
+		sqlf.Sprintf("batch_changes.closed_at IS NULL"),
+	}
+	if opts.State != nil {
+		preds = append(preds, sqlf.Sprintf("changeset_merge_queues.state = %s", opts.State.ToDB()))
+	}
+
+	forUpdate := &sqlf.Query{}
+	if opts.ForUpdate {
+		forUpdate = sqlf.Sprintf("FOR UPDATE OF changeset_merge_queues SKIP LOCKED")
+	}
+
+	return sqlf.Sprintf(
+		listChangesetMergeQueuesQueryFmtstr,
+		sqlf.Join(changesetMergeQueueColumns, ","),
+		sqlf.Join(preds, "\n AND "),
+		forUpdate,
+	)
+}
+
+// ListChangesetMergeQueueCandidatesOpts captures the query options needed for
+// listing the changesets a merge queue can merge.
+type ListChangesetMergeQueueCandidatesOpts struct {
+	BatchChangeID int64
+	Order         btypes.ChangesetMergeQueueOrder
+	// RetryAfter excludes changesets whose merge failed after this time.
+	RetryAfter time.Time
+}
+
+// ListChangesetMergeQueueCandidates lists the open, approved changesets of a
+// batch change whose checks passed and which are not already being merged, in
+// the given merge order. Changesets removed from the merge queue are not
+// listed until they have been updated on the code host.
+func (s *Store) ListChangesetMergeQueueCandidates(ctx context.Context, opts ListChangesetMergeQueueCandidatesOpts) (cs []*btypes.ChangesetMergeQueueCandidate, err error) {
+	ctx, endObservation := s.operations.listChangesetMergeQueueCandidates.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(opts.BatchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	err = s.query(ctx, listChangesetMergeQueueCandidatesQuery(opts), func(sc dbutil.Scanner) error {
+		var c btypes.ChangesetMergeQueueCandidate
+		if err := sc.Scan(&c.ChangesetID, &c.CodeHost); err != nil {
+			return err
+		}
+		cs = append(cs, &c)
+		return nil
+	})
+
+	return cs, err
+}
+
+var listChangesetMergeQueueCandidatesQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:ListChangesetMergeQueueCandidates
+SELECT changesets.id, repo.external_service_id FROM changesets
+INNER JOIN repo ON repo.id = changesets.repo_id
+WHERE
+	repo.deleted_at IS NULL
+	AND changesets.batch_change_ids ? %s
+	AND NOT (%s)
+	AND changesets.publication_state = %s
+	AND changesets.reconciler_state = %s
+	AND changesets.external_state = %s
+	AND changesets.external_review_state = %s
+	AND changesets.external_check_state = %s
+	AND NOT EXISTS (
+		SELECT 1 FROM changeset_jobs
+		WHERE
+			changeset_jobs.changeset_id = changesets.id
+			AND changeset_jobs.job_type = %s
+			AND (
+				changeset_jobs.state IN (%s, %s, %s)
+				OR (changeset_jobs.state = %s AND changeset_jobs.finished_at > %s)
+			)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM changeset_merge_queue_removals
+		INNER JOIN changeset_merge_queues ON changeset_merge_queues.id = changeset_merge_queue_removals.merge_queue_id
+		WHERE
+			changeset_merge_queues.batch_change_id = %s
+			AND changeset_merge_queue_removals.changeset_id = changesets.id
+			AND changeset_merge_queue_removals.changeset_updated_at IS NOT DISTINCT FROM changesets.external_updated_at
+	)
+ORDER BY %s
+`
+
+func listChangesetMergeQueueCandidatesQuery(opts ListChangesetMergeQueueCandidatesOpts) *sqlf.Query {
+	batchChangeID := strconv.Itoa(int(opts.BatchChangeID))
+
+	var order *sqlf.Query
+	switch opts.Order {
+	case btypes.ChangesetMergeQueueOrderRepositoryName:
+		order = sqlf.Sprintf("repo.name ASC, changesets.id ASC")
+	case btypes.ChangesetMergeQueueOrderDiffSize:
+		order = sqlf.Sprintf("COALESCE(changesets.diff_stat_added, 0) + COALESCE(changesets.diff_stat_changed, 0) + COALESCE(changesets.diff_stat_deleted, 0) ASC, changesets.id ASC")
+	default:
+		order = sqlf.Sprintf("changesets.created_at ASC, changesets.id ASC")
+	}
+
+	return sqlf.Sprintf(
+		listChangesetMergeQueueCandidatesQueryFmtstr,
+		batchChangeID,
+		archivedInBatchChange(batchChangeID),
+		btypes.ChangesetPublicationStatePublished,
+		btypes.ReconcilerStateCompleted.ToDB(),
+		btypes.ChangesetExternalStateOpen,
+		btypes.ChangesetReviewStateApproved,
+		btypes.ChangesetCheckStatePassed,
+		btypes.ChangesetJobTypeMerge,
+		btypes.ChangesetJobStateQueued.ToDB(),
+		btypes.ChangesetJobStateProcessing.ToDB(),
+		btypes.ChangesetJobStateErrored.ToDB(),
+		btypes.ChangesetJobStateFailed.ToDB(),
+		opts.RetryAfter,
+		opts.BatchChangeID,
+		order,
+	)
+}
+
+// CountChangesetMergesInProgress returns the number of changesets of the given
+// batch change that are currently being merged, by code host.
+func (s *Store) CountChangesetMergesInProgress(ctx context.Context, batchChangeID int64) (counts map[string]int, err error) {
+	ctx, endObservation := s.operations.countChangesetMergesInProgress.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	counts = map[string]int{}
+	err = s.query(ctx, sqlf.Sprintf(
+		countChangesetMergesInProgressQueryFmtstr,
+		batchChangeID,
+		btypes.ChangesetJobTypeMerge,
+		btypes.ChangesetJobStateQueued.ToDB(),
+		btypes.ChangesetJobStateProcessing.ToDB(),
+		btypes.ChangesetJobStateErrored.ToDB(),
+	), func(sc dbutil.Scanner) error {
+		var (
+			codeHost string
+			count    int
+		)
+		if err := sc.Scan(&codeHost, &count); err != nil {
+			return err
+		}
+		counts[codeHost] = count
+		return nil
+	})
+
+	return counts, err
+}
+
+var countChangesetMergesInProgressQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:CountChangesetMergesInProgress
+SELECT repo.external_service_id, COUNT(*) FROM changeset_jobs
+INNER JOIN changesets ON changesets.id = changeset_jobs.changeset_id
+INNER JOIN repo ON repo.id = changesets.repo_id
+WHERE
+	changeset_jobs.batch_change_id = %s
+	AND changeset_jobs.job_type = %s
+	AND changeset_jobs.state IN (%s, %s, %s)
+GROUP BY repo.external_service_id
+`
+
+// RemoveChangesetFromMergeQueue takes the given changeset out of the merge
+// queue of the given batch change, recording the reason. The changeset is
+// listed by ListChangesetMergeQueueCandidates again once it has been updated on
+// the code host. It is a noop if the batch change has no merge queue.
+func (s *Store) RemoveChangesetFromMergeQueue(ctx context.Context, batchChangeID, changesetID int64, reason string) (err error) {
+	ctx, endObservation := s.operations.removeChangesetFromMergeQueue.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+		log.Int("changesetID", int(changesetID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.Exec(ctx, sqlf.Sprintf(
+		removeChangesetFromMergeQueueQueryFmtstr,
+		reason,
+		s.now(),
+		changesetID,
+		batchChangeID,
+	))
+}
+
+var removeChangesetFromMergeQueueQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:RemoveChangesetFromMergeQueue
+INSERT INTO changeset_merge_queue_removals (merge_queue_id, changeset_id, reason, changeset_updated_at, created_at)
+SELECT changeset_merge_queues.id, changesets.id, %s, changesets.external_updated_at, %s
+FROM changeset_merge_queues, changesets
+WHERE
+	changesets.id = %s
+	AND changeset_merge_queues.batch_change_id = %s
+ON CONFLICT (merge_queue_id, changeset_id) DO UPDATE SET
+	reason = EXCLUDED.reason,
+	changeset_updated_at = EXCLUDED.changeset_updated_at,
+	created_at = EXCLUDED.created_at
+`
+
+// ListChangesetMergeQueueRemovals lists the changesets currently removed from
+// the merge queue of the given batch change, most recent first. Changesets
+// which have been updated on the code host since are not listed.
+func (s *Store) ListChangesetMergeQueueRemovals(ctx context.Context, batchChangeID int64) (rs []*btypes.ChangesetMergeQueueRemoval, err error) {
+	ctx, endObservation := s.operations.listChangesetMergeQueueRemovals.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("batchChangeID", int(batchChangeID)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	err = s.query(ctx, sqlf.Sprintf(listChangesetMergeQueueRemovalsQueryFmtstr, batchChangeID), func(sc dbutil.Scanner) error {
+		var r btypes.ChangesetMergeQueueRemoval
+		if err := sc.Scan(
+			&r.MergeQueueID,
+			&r.ChangesetID,
+			&r.Reason,
+			&dbutil.NullTime{Time: &r.ChangesetUpdatedAt},
+			&r.CreatedAt,
+		); err != nil {
+			return err
+		}
+		rs = append(rs, &r)
+		return nil
+	})
+
+	return rs, err
+}
+
+var listChangesetMergeQueueRemovalsQueryFmtstr = `
+-- source: enterprise/internal/batches/store/changeset_merge_queues.go:ListChangesetMergeQueueRemovals
+SELECT
+	changeset_merge_queue_removals.merge_queue_id,
+	changeset_merge_queue_removals.changeset_id,
+	changeset_merge_queue_removals.reason,
+	changeset_merge_queue_removals.changeset_updated_at,
+	changeset_merge_queue_removals.created_at
+FROM changeset_merge_queue_removals
+INNER JOIN changeset_merge_queues ON changeset_merge_queues.id = changeset_merge_queue_removals.merge_queue_id
+INNER JOIN changesets ON changesets.id = changeset_merge_queue_removals.changeset_id
+WHERE
+	changeset_merge_queues.batch_change_id = %s
+	AND changeset_merge_queue_removals.changeset_updated_at IS NOT DISTINCT FROM changesets.external_updated_at
+ORDER BY changeset_merge_queue_removals.created_at DESC, changeset_merge_queue_removals.changeset_id ASC
+`
+
+func scanChangesetMergeQueue(q *btypes.ChangesetMergeQueue, sc dbutil.Scanner) error {
+	var state, order string
+	if err := sc.Scan(
+		&q.ID,
+		&q.BatchChangeID,
+		&q.UserID,
+		&state,
+		&order,
+		&q.ConcurrencyPerCodeHost,
+		&q.Squash,
+		&q.CreatedAt,
+		&q.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	q.State = btypes.ChangesetMergeQueueState(strings.ToUpper(state))
+	q.Order = btypes.ChangesetMergeQueueOrder(strings.ToUpper(order))
+	return nil
+}
@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+func testStoreChangesetMergeQueues(t *testing.T, ctx context.Context, s *Store, clock ct.Clock) {
+	repoStore := database.ReposWith(s)
+	esStore := database.ExternalServicesWith(s)
+
+	repo := ct.TestRepo(t, esStore, extsvc.KindGitHub)
+	if err := repoStore.Create(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	batchChange := ct.CreateBatchChange(t, ctx, s, "merge-queue", 4321, 0)
+	closedBatchChange := ct.CreateBatchChange(t, ctx, s, "closed-merge-queue", 4321, 0)
+	closedBatchChange.ClosedAt = clock.Now()
+	if err := s.UpdateBatchChange(ctx, closedBatchChange); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := &btypes.ChangesetMergeQueue{
+		BatchChangeID:          batchChange.ID,
+		UserID:                 4321,
+		State:                  btypes.ChangesetMergeQueueStateActive,
+		Order:                  btypes.ChangesetMergeQueueOrderCreatedAt,
+		ConcurrencyPerCodeHost: 1,
+	}
+	closedQueue := &btypes.ChangesetMergeQueue{
+		BatchChangeID:          closedBatchChange.ID,
+		UserID:                 4321,
+		State:                  btypes.ChangesetMergeQueueStateActive,
+		Order:                  btypes.ChangesetMergeQueueOrderCreatedAt,
+		ConcurrencyPerCodeHost: 1,
+	}
+
+	t.Run("Upsert", func(t *testing.T) {
+		for _, q := range []*btypes.ChangesetMergeQueue{queue, closedQueue} {
+			if err := s.UpsertChangesetMergeQueue(ctx, q); err != nil {
+				t.Fatal(err)
+			}
+			if q.ID == 0 {
+				t.Fatal("merge queue ID is 0")
+			}
+		}
+
+		t.Run("Update", func(t *testing.T) {
+			updated := *queue
+			updated.ID = 0
+			updated.Order = btypes.ChangesetMergeQueueOrderDiffSize
+			updated.ConcurrencyPerCodeHost = 3
+			updated.Squash = true
+			if err := s.UpsertChangesetMergeQueue(ctx, &updated); err != nil {
+				t.Fatal(err)
+			}
+			if have, want := updated.ID, queue.ID; have != want {
+				t.Fatalf("upsert created new merge queue: have ID %d, want %d", have, want)
+			}
+			queue = &updated
+		})
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		have, err := s.GetChangesetMergeQueue(ctx, GetChangesetMergeQueueOpts{BatchChangeID: batchChange.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(have, queue); diff != "" {
+			t.Fatal(diff)
+		}
+
+		t.Run("NoResults", func(t *testing.T) {
+			_, err := s.GetChangesetMergeQueue(ctx, GetChangesetMergeQueueOpts{BatchChangeID: 0xdeadbeef})
+			if err != ErrNoResults {
+				t.Fatalf("have err %v, want %v", err, ErrNoResults)
+			}
+		})
+	})
+
+	t.Run("List", func(t *testing.T) {
+		active := btypes.ChangesetMergeQueueStateActive
+		have, err := s.ListChangesetMergeQueues(ctx, ListChangesetMergeQueuesOpts{State: &active})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The merge queue of the closed batch change is not listed.
+		if diff := cmp.Diff(have, []*btypes.ChangesetMergeQueue{queue}); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("UpdateState", func(t *testing.T) {
+		have, err := s.UpdateChangesetMergeQueueState(ctx, batchChange.ID, btypes.ChangesetMergeQueueStatePaused)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have.State != btypes.ChangesetMergeQueueStatePaused {
+			t.Fatalf("invalid state: have %q, want %q", have.State, btypes.ChangesetMergeQueueStatePaused)
+		}
+
+		active := btypes.ChangesetMergeQueueStateActive
+		queues, err := s.ListChangesetMergeQueues(ctx, ListChangesetMergeQueuesOpts{State: &active})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queues) != 0 {
+			t.Fatalf("paused merge queue listed as active: %+v", queues)
+		}
+
+		t.Run("NoResults", func(t *testing.T) {
+			_, err := s.UpdateChangesetMergeQueueState(ctx, 0xdeadbeef, btypes.ChangesetMergeQueueStatePaused)
+			if err != ErrNoResults {
+				t.Fatalf("have err %v, want %v", err, ErrNoResults)
+			}
+		})
+	})
+
+	t.Run("Candidates", func(t *testing.T) {
+		eligible := func(opts ct.TestChangesetOpts) ct.TestChangesetOpts {
+			opts.Repo = repo.ID
+			opts.BatchChange = batchChange.ID
+			opts.PublicationState = btypes.ChangesetPublicationStatePublished
+			opts.ReconcilerState = btypes.ReconcilerStateCompleted
+			opts.ExternalState = btypes.ChangesetExternalStateOpen
+			if opts.ExternalReviewState == "" {
+				opts.ExternalReviewState = btypes.ChangesetReviewStateApproved
+			}
+			if opts.ExternalCheckState == "" {
+				opts.ExternalCheckState = btypes.ChangesetCheckStatePassed
+			}
+			return opts
+		}
+
+		large := ct.CreateChangeset(t, ctx, s, eligible(ct.TestChangesetOpts{DiffStatAdded: 100}))
+		small := ct.CreateChangeset(t, ctx, s, eligible(ct.TestChangesetOpts{DiffStatAdded: 1}))
+		merging := ct.CreateChangeset(t, ctx, s, eligible(ct.TestChangesetOpts{}))
+		ct.CreateChangeset(t, ctx, s, eligible(ct.TestChangesetOpts{ExternalReviewState: btypes.ChangesetReviewStatePending}))
+		ct.CreateChangeset(t, ctx, s, eligible(ct.TestChangesetOpts{ExternalCheckState: btypes.ChangesetCheckStateFailed}))
+
+		if err := s.CreateChangesetJob(ctx, &btypes.ChangesetJob{
+			BulkGroup:     "merge-queue",
+			UserID:        4321,
+			BatchChangeID: batchChange.ID,
+			ChangesetID:   merging.ID,
+			JobType:       btypes.ChangesetJobTypeMerge,
+			Payload:       &btypes.ChangesetJobMergePayload{},
+			State:         btypes.ChangesetJobStateQueued,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for order, want := range map[btypes.ChangesetMergeQueueOrder][]int64{
+			btypes.ChangesetMergeQueueOrderCreatedAt: {large.ID, small.ID},
+			btypes.ChangesetMergeQueueOrderDiffSize:  {small.ID, large.ID},
+		} {
+			t.Run(string(order), func(t *testing.T) {
+				candidates, err := s.ListChangesetMergeQueueCandidates(ctx, ListChangesetMergeQueueCandidatesOpts{
+					BatchChangeID: batchChange.ID,
+					Order:         order,
+					RetryAfter:    clock.Now(),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				have := make([]int64, 0, len(candidates))
+				for _, c := range candidates {
+					have = append(have, c.ChangesetID)
+					if c.CodeHost != repo.ExternalRepo.ServiceID {
+						t.Fatalf("invalid code host: have %q, want %q", c.CodeHost, repo.ExternalRepo.ServiceID)
+					}
+				}
+				if diff := cmp.Diff(have, want); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		}
+
+		t.Run("InProgress", func(t *testing.T) {
+			have, err := s.CountChangesetMergesInProgress(ctx, batchChange.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(have, map[string]int{repo.ExternalRepo.ServiceID: 1}); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+
+		t.Run("Removed", func(t *testing.T) {
+			listCandidates := func() []int64 {
+				t.Helper()
+				candidates, err := s.ListChangesetMergeQueueCandidates(ctx, ListChangesetMergeQueueCandidatesOpts{
+					BatchChangeID: batchChange.ID,
+					Order:         btypes.ChangesetMergeQueueOrderCreatedAt,
+					RetryAfter:    clock.Now(),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]int64, 0, len(candidates))
+				for _, c := range candidates {
+					ids = append(ids, c.ChangesetID)
+				}
+				return ids
+			}
+
+			if err := s.RemoveChangesetFromMergeQueue(ctx, batchChange.ID, small.ID, "merge conflict"); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(listCandidates(), []int64{large.ID}); diff != "" {
+				t.Fatal(diff)
+			}
+
+			removals, err := s.ListChangesetMergeQueueRemovals(ctx, batchChange.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(removals) != 1 || removals[0].ChangesetID != small.ID || removals[0].Reason != "merge conflict" {
+				t.Fatalf("unexpected removals: %+v", removals)
+			}
+
+			// Once the changeset is updated on the code host, eg: because it
+			// was rebased, it is merged again.
+			small.ExternalUpdatedAt = clock.Now().Add(time.Hour)
+			if err := s.UpdateChangeset(ctx, small); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(listCandidates(), []int64{large.ID, small.ID}); diff != "" {
+				t.Fatal(diff)
+			}
+			if removals, err := s.ListChangesetMergeQueueRemovals(ctx, batchChange.ID); err != nil {
+				t.Fatal(err)
+			} else if len(removals) != 0 {
+				t.Fatalf("unexpected removals: %+v", removals)
+			}
+		})
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := s.DeleteChangesetMergeQueue(ctx, batchChange.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetChangesetMergeQueue(ctx, GetChangesetMergeQueueOpts{BatchChangeID: batchChange.ID}); err != ErrNoResults {
+			t.Fatalf("have err %v, want %v", err, ErrNoResults)
+		}
+		if err := s.DeleteChangesetMergeQueue(ctx, batchChange.ID); err != ErrNoResults {
+			t.Fatalf("have err %v, want %v", err, ErrNoResults)
+		}
+	})
+}
@@ -193,6 +193,16 @@ type operations struct {
 	createChangesetJob *observation.Operation
 	getChangesetJob    *observation.Operation
 
+	upsertChangesetMergeQueue         *observation.Operation
+	updateChangesetMergeQueueState    *observation.Operation
+	deleteChangesetMergeQueue         *observation.Operation
+	getChangesetMergeQueue            *observation.Operation
+	listChangesetMergeQueues          *observation.Operation
+	listChangesetMergeQueueCandidates *observation.Operation
+	countChangesetMergesInProgress    *observation.Operation
+	removeChangesetFromMergeQueue     *observation.Operation
+	listChangesetMergeQueueRemovals   *observation.Operation
+
 	createChangesetSpec                      *observation.Operation
 	updateChangesetSpec                      *observation.Operation
 	deleteChangesetSpec                      *observation.Operation
@@ -317,6 +327,16 @@ func newOperations(observationContext *observation.Context) *operations {
 			createChangesetJob: op("CreateChangesetJob"),
 			getChangesetJob:    op("GetChangesetJob"),
 
+			upsertChangesetMergeQueue:         op("UpsertChangesetMergeQueue"),
+			updateChangesetMergeQueueState:    op("UpdateChangesetMergeQueueState"),
+			deleteChangesetMergeQueue:         op("DeleteChangesetMergeQueue"),
+			getChangesetMergeQueue:            op("GetChangesetMergeQueue"),
+			listChangesetMergeQueues:          op("ListChangesetMergeQueues"),
+			listChangesetMergeQueueCandidates: op("ListChangesetMergeQueueCandidates"),
+			countChangesetMergesInProgress:    op("CountChangesetMergesInProgress"),
+			removeChangesetFromMergeQueue:     op("RemoveChangesetFromMergeQueue"),
+			listChangesetMergeQueueRemovals:   op("ListChangesetMergeQueueRemovals"),
+
 			createChangesetSpec:                      op("CreateChangesetSpec"),
 			updateChangesetSpec:                      op("UpdateChangesetSpec"),
 			deleteChangesetSpec:                      op("DeleteChangesetSpec"),
@@ -59,6 +59,9 @@ type ChangesetJobReenqueuePayload struct{}
 
 type ChangesetJobMergePayload struct {
 	Squash bool `json:"squash,omitempty"`
+	// MergeQueue is true if the job was enqueued by the merge queue of the
+	// batch change.
+	MergeQueue bool `json:"mergeQueue,omitempty"`
 }
 
 type ChangesetJobClosePayload struct{}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// ChangesetMergeQueueState defines the possible states of a changeset merge
+// queue.
+type ChangesetMergeQueueState string
+
+// ChangesetMergeQueueState constants.
+const (
+	ChangesetMergeQueueStateActive ChangesetMergeQueueState = "ACTIVE"
+	ChangesetMergeQueueStatePaused ChangesetMergeQueueState = "PAUSED"
+)
+
+// Valid returns true if the given ChangesetMergeQueueState is valid.
+func (s ChangesetMergeQueueState) Valid() bool {
+	switch s {
+	case ChangesetMergeQueueStateActive,
+		ChangesetMergeQueueStatePaused:
+		return true
+	default:
+		return false
+	}
+}
+
+// ToDB returns the database representation of the state.
+func (s ChangesetMergeQueueState) ToDB() string { return strings.ToLower(string(s)) }
+
+// ChangesetMergeQueueOrder defines the order in which a merge queue merges
+// eligible changesets.
+type ChangesetMergeQueueOrder string
+
+// ChangesetMergeQueueOrder constants.
+const (
+	// ChangesetMergeQueueOrderCreatedAt merges the oldest changesets first.
+	ChangesetMergeQueueOrderCreatedAt ChangesetMergeQueueOrder = "CREATED_AT"
+	// ChangesetMergeQueueOrderRepositoryName merges changesets in the
+	// alphabetical order of their repository names.
+	ChangesetMergeQueueOrderRepositoryName ChangesetMergeQueueOrder = "REPOSITORY_NAME"
+	// ChangesetMergeQueueOrderDiffSize merges the changesets with the smallest
+	// diffs first.
+	ChangesetMergeQueueOrderDiffSize ChangesetMergeQueueOrder = "DIFF_SIZE"
+)
+
+// Valid returns true if the given ChangesetMergeQueueOrder is valid.
+func (o ChangesetMergeQueueOrder) Valid() bool {
+	switch o {
+	case ChangesetMergeQueueOrderCreatedAt,
+		ChangesetMergeQueueOrderRepositoryName,
+		ChangesetMergeQueueOrderDiffSize:
+		return true
+	default:
+		return false
+	}
+}
+
+// ToDB returns the database representation of the order.
+func (o ChangesetMergeQueueOrder) ToDB() string { return strings.ToLower(string(o)) }
+
+// ChangesetMergeQueue automatically merges the changesets of a batch change
+// once they are approved and their checks pass.
+type ChangesetMergeQueue struct {
+	ID            int64
+	BatchChangeID int64
+	// UserID is the ID of the user who enabled the merge queue. Changesets are
+	// merged on behalf of this user.
+	UserID int32
+
+	State ChangesetMergeQueueState
+	Order ChangesetMergeQueueOrder
+	// ConcurrencyPerCodeHost is the maximum number of changesets being merged
+	// at the same time on a single code host.
+	ConcurrencyPerCodeHost int32
+	Squash                 bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ChangesetMergeQueueCandidate is a changeset which is eligible to be merged
+// by a merge queue.
+type ChangesetMergeQueueCandidate struct {
+	ChangesetID int64
+	// CodeHost is the external service ID of the repository of the changeset,
+	// eg: https://github.com/.
+	CodeHost string
+}
+
+// ChangesetMergeQueueRemoval records that a changeset was taken out of a merge
+// queue because the code host reported that it cannot be merged, eg: because
+// of a conflict with a previously merged changeset.
+type ChangesetMergeQueueRemoval struct {
+	MergeQueueID int64
+	ChangesetID  int64
+	// Reason is the error the code host returned when merging the changeset.
+	Reason string
+	// ChangesetUpdatedAt is the time the changeset was last updated on the
+	// code host when it was removed. The merge queue merges the changeset
+	// again once it has been updated since, eg: because it was rebased.
+	ChangesetUpdatedAt time.Time
+
+	CreatedAt time.Time
+}
@@ -3,6 +3,7 @@ package enqueuer
 import (
 	"strings"
 
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/sourcegraph/sourcegraph/lib/codeintel/precise"
 )
@@ -11,6 +12,10 @@ func InferRepositoryAndRevision(pkg precise.Package) (repoName, gitTagOrCommit s
 	for _, fn := range []func(pkg precise.Package) (string, string, bool){
 		inferGoRepositoryAndRevision,
 		inferJVMRepositoryAndRevision,
+		inferNpmRepositoryAndRevision,
+		inferRustRepositoryAndRevision,
+		inferPythonRepositoryAndRevision,
+		inferRubyRepositoryAndRevision,
 	} {
 		if repoName, gitTagOrCommit, ok := fn(pkg); ok {
 			return repoName, gitTagOrCommit, true
@@ -48,3 +53,47 @@ func inferJVMRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
 	}
 	return pkg.Name, "v" + pkg.Version, true
 }
+
+func inferNpmRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
+	if pkg.Scheme != "npm" {
+		return "", "", false
+	}
+	npmPackage, err := reposource.ParseNpmPackage(pkg.Name)
+	if err != nil {
+		return "", "", false
+	}
+	return string(npmPackage.RepoName()), "v" + pkg.Version, true
+}
+
+func inferRustRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
+	if pkg.Scheme != "cargo" {
+		return "", "", false
+	}
+	return inferRegistryRepositoryAndRevision(pkg)
+}
+
+func inferPythonRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
+	if pkg.Scheme != "pypi" {
+		return "", "", false
+	}
+	return inferRegistryRepositoryAndRevision(pkg)
+}
+
+func inferRubyRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
+	if pkg.Scheme != "gem" {
+		return "", "", false
+	}
+	return inferRegistryRepositoryAndRevision(pkg)
+}
+
+// inferRegistryRepositoryAndRevision returns the repository that the package
+// registry code hosts sync the given package to. Names are normalized the
+// same way, so that PyPI names which only differ in case or separators map to
+// a single repository.
+func inferRegistryRepositoryAndRevision(pkg precise.Package) (string, string, bool) {
+	registryPackage, err := reposource.ParseRegistryPackage(pkg.Scheme, pkg.Name)
+	if err != nil {
+		return "", "", false
+	}
+	return string(registryPackage.RepoName()), "v" + pkg.Version, true
+}
@@ -56,4 +56,72 @@ func TestInferRepositoryAndRevision(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("Packages", func(t *testing.T) {
+		testCases := []struct {
+			pkg      precise.Package
+			repoName string
+			revision string
+		}{
+			{
+				pkg: precise.Package{
+					Scheme:  "npm",
+					Name:    "react",
+					Version: "17.0.2",
+				},
+				repoName: "npm/react",
+				revision: "v17.0.2",
+			},
+			{
+				pkg: precise.Package{
+					Scheme:  "npm",
+					Name:    "@types/node",
+					Version: "16.11.6",
+				},
+				repoName: "npm/types/node",
+				revision: "v16.11.6",
+			},
+			{
+				pkg: precise.Package{
+					Scheme:  "cargo",
+					Name:    "serde_json",
+					Version: "1.0.68",
+				},
+				repoName: "crates/serde_json",
+				revision: "v1.0.68",
+			},
+			{
+				pkg: precise.Package{
+					Scheme:  "pypi",
+					Name:    "Flask_SQLAlchemy",
+					Version: "2.5.1",
+				},
+				repoName: "pypi/flask-sqlalchemy",
+				revision: "v2.5.1",
+			},
+			{
+				pkg: precise.Package{
+					Scheme:  "gem",
+					Name:    "rails",
+					Version: "6.1.4",
+				},
+				repoName: "rubygems/rails",
+				revision: "v6.1.4",
+			},
+		}
+
+		for _, testCase := range testCases {
+			repoName, revision, ok := InferRepositoryAndRevision(testCase.pkg)
+			if !ok {
+				t.Fatalf("expected repository to be inferred")
+			}
+
+			if repoName != testCase.repoName {
+				t.Errorf("unexpected repo name. want=%q have=%q", testCase.repoName, repoName)
+			}
+			if revision != testCase.revision {
+				t.Errorf("unexpected revision. want=%q have=%q", testCase.revision, revision)
+			}
+		}
+	})
 }
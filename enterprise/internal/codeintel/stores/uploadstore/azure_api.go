@@ -0,0 +1,267 @@
+package uploadstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+// azureAPIVersion is the version of the Azure Blob Storage REST API we target.
+const azureAPIVersion = "2020-04-08"
+
+type azureAPI interface {
+	CreateContainer(ctx context.Context, container string) error
+	GetBlob(ctx context.Context, container, blob string) (io.ReadCloser, error)
+	PutBlock(ctx context.Context, container, blob, blockID string, content []byte) error
+	PutBlockList(ctx context.Context, container, blob string, blockIDs []string) error
+	DeleteBlob(ctx context.Context, container, blob string) error
+	ListBlobs(ctx context.Context, container, marker string) (azureBlobList, error)
+}
+
+type azureBlobList struct {
+	Blobs      []azureBlob `xml:"Blobs>Blob"`
+	NextMarker string      `xml:"NextMarker"`
+}
+
+type azureBlob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		LastModified string `xml:"Last-Modified"`
+	} `xml:"Properties"`
+}
+
+// azureRESTClient is a minimal client for the Azure Blob Storage REST API authenticating
+// requests with a storage account shared key.
+type azureRESTClient struct {
+	endpoint    *url.URL
+	accountName string
+	accountKey  []byte
+	doer        httpcli.Doer
+	now         func() time.Time
+}
+
+var _ azureAPI = &azureRESTClient{}
+
+func newAzureRESTClient(config AzureConfig, doer httpcli.Doer) (*azureRESTClient, error) {
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", config.AccountName)
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Azure endpoint")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(config.AccountKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Azure account key")
+	}
+
+	return &azureRESTClient{
+		endpoint:    u,
+		accountName: config.AccountName,
+		accountKey:  key,
+		doer:        doer,
+		now:         time.Now,
+	}, nil
+}
+
+func (c *azureRESTClient) CreateContainer(ctx context.Context, container string) error {
+	resp, err := c.do(ctx, "PUT", container, url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		// The container already exists
+		return nil
+	}
+
+	return checkAzureResponse(resp, http.StatusCreated)
+}
+
+func (c *azureRESTClient) GetBlob(ctx context.Context, container, blob string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, "GET", container+"/"+blob, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAzureResponse(resp, http.StatusOK); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (c *azureRESTClient) PutBlock(ctx context.Context, container, blob, blockID string, content []byte) error {
+	query := url.Values{"comp": {"block"}, "blockid": {blockID}}
+	resp, err := c.do(ctx, "PUT", container+"/"+blob, query, nil, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkAzureResponse(resp, http.StatusCreated)
+}
+
+func (c *azureRESTClient) PutBlockList(ctx context.Context, container, blob string, blockIDs []string) error {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range blockIDs {
+		body.WriteString("<Latest>")
+		if err := xml.EscapeText(&body, []byte(id)); err != nil {
+			return err
+		}
+		body.WriteString("</Latest>")
+	}
+	body.WriteString("</BlockList>")
+
+	headers := http.Header{"Content-Type": {"application/xml"}}
+	resp, err := c.do(ctx, "PUT", container+"/"+blob, url.Values{"comp": {"blocklist"}}, headers, body.Bytes())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkAzureResponse(resp, http.StatusCreated)
+}
+
+func (c *azureRESTClient) DeleteBlob(ctx context.Context, container, blob string) error {
+	resp, err := c.do(ctx, "DELETE", container+"/"+blob, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return checkAzureResponse(resp, http.StatusAccepted)
+}
+
+func (c *azureRESTClient) ListBlobs(ctx context.Context, container, marker string) (azureBlobList, error) {
+	query := url.Values{"restype": {"container"}, "comp": {"list"}}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+
+	resp, err := c.do(ctx, "GET", container, query, nil, nil)
+	if err != nil {
+		return azureBlobList{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkAzureResponse(resp, http.StatusOK); err != nil {
+		return azureBlobList{}, err
+	}
+
+	var list azureBlobList
+	if err := xml.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return azureBlobList{}, errors.Wrap(err, "failed to decode blob list")
+	}
+
+	return list, nil
+}
+
+func (c *azureRESTClient) do(ctx context.Context, method, path string, query url.Values, headers http.Header, body []byte) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = c.endpoint.Path + "/" + path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("x-ms-date", c.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+c.accountName+":"+c.sign(req))
+
+	return c.doer.Do(req.WithContext(ctx))
+}
+
+// sign returns the signature of the request, as described in
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key.
+func (c *azureRESTClient) sign(req *http.Request) string {
+	mac := hmac.New(sha256.New, c.accountKey)
+	mac.Write([]byte(azureStringToSign(c.accountName, req)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func azureStringToSign(accountName string, req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	lines := []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, we always send x-ms-date instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name)
+		}
+	}
+	sort.Strings(msHeaders)
+	for _, name := range msHeaders {
+		lines = append(lines, name+":"+strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	resource := "/" + accountName + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	return strings.Join(append(lines, resource), "\n")
+}
+
+func checkAzureResponse(resp *http.Response, expectedStatus int) error {
+	if resp.StatusCode == expectedStatus {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("unexpected status code %d from Azure Blob Storage (%s): %s", resp.StatusCode, resp.Header.Get("x-ms-error-code"), string(body))
+}
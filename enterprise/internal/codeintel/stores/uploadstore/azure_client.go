@@ -0,0 +1,280 @@
+package uploadstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// azureBlockSize is the size of the blocks staged when writing a blob. A block blob
+// can have at most 50,000 blocks, which allows for blobs of up to ~390GiB.
+const azureBlockSize = 8 * 1024 * 1024
+
+type azureStore struct {
+	container    string
+	ttl          time.Duration
+	manageBucket bool
+	client       azureAPI
+	operations   *operations
+	now          func() time.Time
+	startOnce    sync.Once
+}
+
+var _ Store = &azureStore{}
+
+type AzureConfig struct {
+	AccountName string
+	AccountKey  string
+	Endpoint    string
+}
+
+func (c *AzureConfig) load(parent *env.BaseConfig) {
+	c.AccountName = parent.Get("PRECISE_CODE_INTEL_UPLOAD_AZURE_ACCOUNT_NAME", "", "The name of the Azure storage account containing the container.")
+	c.AccountKey = parent.Get("PRECISE_CODE_INTEL_UPLOAD_AZURE_ACCOUNT_KEY", "", "An access key of the Azure storage account.")
+	c.Endpoint = parent.GetOptional("PRECISE_CODE_INTEL_UPLOAD_AZURE_ENDPOINT", "The Azure Blob Storage endpoint. Defaults to https://{account}.blob.core.windows.net.")
+}
+
+// newAzureFromConfig creates a new store backed by Azure Blob Storage. The configured
+// bucket is used as the container name.
+func newAzureFromConfig(ctx context.Context, config *Config, operations *operations) (Store, error) {
+	client, err := newAzureRESTClient(config.Azure, httpcli.ExternalDoer)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAzureWithClient(client, config.Bucket, config.TTL, config.ManageBucket, operations), nil
+}
+
+func newAzureWithClient(client azureAPI, container string, ttl time.Duration, manageBucket bool, operations *operations) *azureStore {
+	return &azureStore{
+		container:    container,
+		ttl:          ttl,
+		manageBucket: manageBucket,
+		client:       client,
+		operations:   operations,
+		now:          time.Now,
+	}
+}
+
+// Init creates the target container. Lifecycle management policies of Azure are set on the
+// storage account and not on the container, so we expire blobs older than the configured TTL
+// from a background routine instead.
+func (s *azureStore) Init(ctx context.Context) error {
+	if !s.manageBucket {
+		return nil
+	}
+
+	if err := s.client.CreateContainer(ctx, s.container); err != nil {
+		return errors.Wrap(err, "failed to create container")
+	}
+
+	if s.ttl > 0 {
+		s.startOnce.Do(func() { startExpirer("codeintel.uploadstore.azure.expirer", s.expire) })
+	}
+
+	return nil
+}
+
+func (s *azureStore) Get(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	ctx, endObservation := s.operations.get.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	rc, err := s.client.GetBlob(ctx, s.container, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return rc, nil
+}
+
+func (s *azureStore) Upload(ctx context.Context, key string, r io.Reader) (_ int64, err error) {
+	ctx, endObservation := s.operations.upload.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	w := s.newBlockWriter(ctx, key)
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to upload object")
+	}
+	if err := w.Commit(); err != nil {
+		return 0, errors.Wrap(err, "failed to upload object")
+	}
+
+	return n, nil
+}
+
+// Compose concatenates the source blobs into the destination blob. Azure can only copy
+// blocks between blobs server-side when the source is readable via a public or SAS URL,
+// so the sources are streamed through this process instead.
+func (s *azureStore) Compose(ctx context.Context, destination string, sources ...string) (_ int64, err error) {
+	ctx, endObservation := s.operations.compose.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("destination", destination),
+		log.String("sources", strings.Join(sources, ", ")),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	defer func() {
+		if err == nil {
+			// Delete sources on success
+			if err := s.deleteSources(ctx, sources); err != nil {
+				log15.Error("Failed to delete source objects", "error", err)
+			}
+		}
+	}()
+
+	w := s.newBlockWriter(ctx, destination)
+
+	var total int64
+	for _, source := range sources {
+		n, err := s.copyFrom(ctx, w, source)
+		total += n
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to compose objects")
+		}
+	}
+	if err := w.Commit(); err != nil {
+		return 0, errors.Wrap(err, "failed to compose objects")
+	}
+
+	return total, nil
+}
+
+func (s *azureStore) Delete(ctx context.Context, key string) (err error) {
+	ctx, endObservation := s.operations.delete.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return errors.Wrap(s.client.DeleteBlob(ctx, s.container, key), "failed to delete object")
+}
+
+func (s *azureStore) copyFrom(ctx context.Context, w io.Writer, key string) (int64, error) {
+	rc, err := s.client.GetBlob(ctx, s.container, key)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return io.Copy(w, rc)
+}
+
+func (s *azureStore) deleteSources(ctx context.Context, sources []string) error {
+	return goroutine.RunWorkersOverStrings(sources, func(index int, source string) error {
+		if err := s.client.DeleteBlob(ctx, s.container, source); err != nil {
+			return errors.Wrap(err, "failed to delete source object")
+		}
+
+		return nil
+	})
+}
+
+// expire deletes all blobs of the container which were last modified before the TTL.
+func (s *azureStore) expire(ctx context.Context) (err error) {
+	cutoff := s.now().Add(-s.ttl)
+
+	marker := ""
+	for {
+		list, listErr := s.client.ListBlobs(ctx, s.container, marker)
+		if listErr != nil {
+			return multierror.Append(err, errors.Wrap(listErr, "failed to list objects"))
+		}
+
+		for _, blob := range list.Blobs {
+			lastModified, parseErr := time.Parse(http.TimeFormat, blob.Properties.LastModified)
+			if parseErr != nil || !lastModified.Before(cutoff) {
+				continue
+			}
+
+			if deleteErr := s.client.DeleteBlob(ctx, s.container, blob.Name); deleteErr != nil {
+				err = multierror.Append(err, errors.Wrap(deleteErr, "failed to delete expired object"))
+			}
+		}
+
+		if list.NextMarker == "" {
+			return err
+		}
+		marker = list.NextMarker
+	}
+}
+
+// azureBlockWriter buffers written data into blocks which are staged as they fill up.
+// The blob only becomes visible once Commit is called.
+type azureBlockWriter struct {
+	ctx      context.Context
+	store    *azureStore
+	key      string
+	buf      []byte
+	blockIDs []string
+}
+
+func (s *azureStore) newBlockWriter(ctx context.Context, key string) *azureBlockWriter {
+	return &azureBlockWriter{ctx: ctx, store: s, key: key}
+}
+
+func (w *azureBlockWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, azureBlockSize)
+		}
+
+		m := azureBlockSize - len(w.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+
+		if len(w.buf) == azureBlockSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (w *azureBlockWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	// Block IDs must all have the same length within a blob
+	blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(w.blockIDs))))
+	if err := w.store.client.PutBlock(w.ctx, w.store.container, w.key, blockID, w.buf); err != nil {
+		return err
+	}
+
+	w.blockIDs = append(w.blockIDs, blockID)
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Commit stages any buffered data and commits the list of staged blocks as the blob content.
+func (w *azureBlockWriter) Commit() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	return w.store.client.PutBlockList(w.ctx, w.store.container, w.key, w.blockIDs)
+}
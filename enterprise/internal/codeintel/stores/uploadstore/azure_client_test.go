@@ -0,0 +1,335 @@
+package uploadstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// fakeAzure is a minimal in-memory implementation of the Azure Blob Storage REST API
+// which verifies the shared key signature of every request.
+type fakeAzure struct {
+	mu           sync.Mutex
+	accountKey   []byte
+	containers   map[string]bool
+	blobs        map[string][]byte
+	lastModified map[string]time.Time
+	blocks       map[string][]byte
+	pageSize     int
+}
+
+func newFakeAzure() *fakeAzure {
+	return &fakeAzure{
+		accountKey:   []byte("test-account-key"),
+		containers:   map[string]bool{},
+		blobs:        map[string][]byte{},
+		lastModified: map[string]time.Time{},
+		blocks:       map[string][]byte{},
+		pageSize:     2,
+	}
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	client := &azureRESTClient{accountName: "test-account", accountKey: f.accountKey}
+	if r.Header.Get("Authorization") != "SharedKey test-account:"+client.sign(r) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+	container, blob := splitAzurePath(r.URL.Path)
+
+	switch {
+	case r.Method == "PUT" && query.Get("restype") == "container":
+		if f.containers[container] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.containers[container] = true
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "GET" && query.Get("comp") == "list":
+		var names []string
+		for name := range f.blobs {
+			if strings.HasPrefix(name, container+"/") && name > container+"/"+query.Get("marker") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		var list azureBlobList
+		for i, name := range names {
+			if i == f.pageSize {
+				list.NextMarker = strings.TrimPrefix(names[i-1], container+"/") + "\x00"
+				break
+			}
+			b := azureBlob{Name: strings.TrimPrefix(name, container+"/")}
+			b.Properties.LastModified = f.lastModified[name].Format(http.TimeFormat)
+			list.Blobs = append(list.Blobs, b)
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name `xml:"EnumerationResults"`
+			azureBlobList
+		}{azureBlobList: list})
+
+	case r.Method == "PUT" && query.Get("comp") == "block":
+		f.blocks[container+"/"+blob+"/"+query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "PUT" && query.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.Unmarshal(body, &list); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var content []byte
+		for _, id := range list.Latest {
+			block, ok := f.blocks[container+"/"+blob+"/"+id]
+			if !ok {
+				http.Error(w, "unknown block", http.StatusBadRequest)
+				return
+			}
+			content = append(content, block...)
+		}
+		f.blobs[container+"/"+blob] = content
+		f.lastModified[container+"/"+blob] = time.Now()
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "GET":
+		content, ok := f.blobs[container+"/"+blob]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+
+	case r.Method == "DELETE":
+		if _, ok := f.blobs[container+"/"+blob]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, container+"/"+blob)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		http.Error(w, "unsupported operation", http.StatusBadRequest)
+	}
+}
+
+func splitAzurePath(path string) (container, blob string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func TestAzureInit(t *testing.T) {
+	azure, client := testAzureClient(t, true)
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error initializing client: %s", err)
+	}
+
+	if !azure.containers["test-bucket"] {
+		t.Fatalf("expected container to be created")
+	}
+
+	// Creating an existing container is not an error
+	if err := rawAzureClient(t, azure, true).Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error initializing client: %s", err)
+	}
+}
+
+func TestAzureUnmanagedInit(t *testing.T) {
+	azure, client := testAzureClient(t, false)
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error initializing client: %s", err)
+	}
+
+	if len(azure.containers) != 0 {
+		t.Fatalf("unexpected container creation")
+	}
+}
+
+func TestAzureUploadGet(t *testing.T) {
+	azure, client := testAzureClient(t, false)
+
+	// Larger than a single block
+	payload := bytes.Repeat([]byte("0123456789abcdef"), azureBlockSize/16+1)
+
+	size, err := client.Upload(context.Background(), "test-key", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("unexpected error uploading object: %s", err)
+	}
+	if size != int64(len(payload)) {
+		t.Errorf("unexpected size. want=%d have=%d", len(payload), size)
+	}
+	if n := len(azure.blocks); n != 2 {
+		t.Errorf("unexpected number of blocks. want=%d have=%d", 2, n)
+	}
+
+	rc, err := client.Get(context.Background(), "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error getting object: %s", err)
+	}
+	defer rc.Close()
+
+	contents, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error reading object: %s", err)
+	}
+	if !bytes.Equal(contents, payload) {
+		t.Fatalf("unexpected contents")
+	}
+
+	if _, err := client.Get(context.Background(), "missing-key"); err == nil {
+		t.Fatalf("expected error getting missing object")
+	}
+}
+
+func TestAzureCompose(t *testing.T) {
+	azure, client := testAzureClient(t, false)
+
+	for _, key := range []string{"test-src1", "test-src2", "test-src3"} {
+		if _, err := client.Upload(context.Background(), key, strings.NewReader(key+"\n")); err != nil {
+			t.Fatalf("unexpected error uploading object: %s", err)
+		}
+	}
+
+	size, err := client.Compose(context.Background(), "test-key", "test-src1", "test-src2", "test-src3")
+	if err != nil {
+		t.Fatalf("unexpected error composing objects: %s", err)
+	}
+	if size != 30 {
+		t.Errorf("unexpected size. want=%d have=%d", 30, size)
+	}
+
+	if diff := cmp.Diff(map[string][]byte{
+		"test-bucket/test-key": []byte("test-src1\ntest-src2\ntest-src3\n"),
+	}, azure.blobs); diff != "" {
+		t.Fatalf("unexpected blobs (-want +got):\n%s", diff)
+	}
+}
+
+func TestAzureDelete(t *testing.T) {
+	azure, client := testAzureClient(t, false)
+
+	if _, err := client.Upload(context.Background(), "test-key", strings.NewReader("test")); err != nil {
+		t.Fatalf("unexpected error uploading object: %s", err)
+	}
+	if err := client.Delete(context.Background(), "test-key"); err != nil {
+		t.Fatalf("unexpected error deleting object: %s", err)
+	}
+	if len(azure.blobs) != 0 {
+		t.Fatalf("expected object to be deleted")
+	}
+
+	// Deleting a missing object is not an error
+	if err := client.Delete(context.Background(), "test-key"); err != nil {
+		t.Fatalf("unexpected error deleting object: %s", err)
+	}
+}
+
+func TestAzureExpire(t *testing.T) {
+	azure := newFakeAzure()
+	client := rawAzureClient(t, azure, true)
+	client.ttl = time.Hour * 24 * 3
+
+	now := time.Now()
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		azure.blobs["test-bucket/"+key] = []byte(key)
+		azure.lastModified["test-bucket/"+key] = now.Add(-time.Hour*24*time.Duration(i+1) + time.Minute)
+	}
+
+	if err := client.expire(context.Background()); err != nil {
+		t.Fatalf("unexpected error expiring objects: %s", err)
+	}
+
+	var keys []string
+	for key := range azure.blobs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if diff := cmp.Diff([]string{"test-bucket/a", "test-bucket/b", "test-bucket/c"}, keys); diff != "" {
+		t.Fatalf("unexpected remaining blobs (-want +got):\n%s", diff)
+	}
+}
+
+func TestAzureStringToSign(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://test-account.blob.core.windows.net/test-bucket/test-key?comp=block&blockid=YmxvY2s%3D", strings.NewReader("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("x-ms-date", "Mon, 01 Feb 2021 00:00:00 GMT")
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	expected := strings.Join([]string{
+		"PUT",
+		"",
+		"",
+		"4",
+		"",
+		"application/octet-stream",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"x-ms-date:Mon, 01 Feb 2021 00:00:00 GMT",
+		"x-ms-version:2020-04-08",
+		"/test-account/test-bucket/test-key",
+		"blockid:YmxvY2s=",
+		"comp:block",
+	}, "\n")
+
+	if diff := cmp.Diff(expected, azureStringToSign("test-account", req)); diff != "" {
+		t.Fatalf("unexpected string to sign (-want +got):\n%s", diff)
+	}
+}
+
+func testAzureClient(t *testing.T, manageBucket bool) (*fakeAzure, Store) {
+	azure := newFakeAzure()
+	return azure, newLazyStore(rawAzureClient(t, azure, manageBucket))
+}
+
+func rawAzureClient(t *testing.T, azure *fakeAzure, manageBucket bool) *azureStore {
+	server := httptest.NewServer(azure)
+	t.Cleanup(server.Close)
+
+	client, err := newAzureRESTClient(AzureConfig{
+		AccountName: "test-account",
+		AccountKey:  base64.StdEncoding.EncodeToString(azure.accountKey),
+		Endpoint:    server.URL,
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	// Disable the background expirer in tests
+	return newAzureWithClient(client, "test-bucket", 0, manageBucket, newOperations(&observation.TestContext))
+}
@@ -0,0 +1,19 @@
+package uploadstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+)
+
+// expirerInterval is the time between two passes of the expirer.
+const expirerInterval = time.Hour
+
+// startExpirer periodically invokes the given function in the background. This is used
+// by backends which have no server-side lifecycle configuration to remove objects older
+// than the configured TTL.
+func startExpirer(name string, expire func(ctx context.Context) error) {
+	routine := goroutine.NewPeriodicGoroutine(context.Background(), expirerInterval, goroutine.NewHandlerWithErrorMessage(name, expire))
+	goroutine.Go(routine.Start)
+}
@@ -0,0 +1,273 @@
+package uploadstore
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+type localStore struct {
+	root       string
+	ttl        time.Duration
+	operations *operations
+	now        func() time.Time
+	startOnce  sync.Once
+}
+
+var _ Store = &localStore{}
+
+type LocalConfig struct {
+	Dir string
+}
+
+func (c *LocalConfig) load(parent *env.BaseConfig) {
+	c.Dir = parent.Get("PRECISE_CODE_INTEL_UPLOAD_LOCAL_DIR", "", "The directory to store uploads in. It must be shared by all services accessing uploads.")
+}
+
+// newLocalFromConfig creates a new store backed by a directory on the local filesystem.
+// Objects are stored in a subdirectory of the configured directory named after the bucket.
+func newLocalFromConfig(ctx context.Context, config *Config, operations *operations) (Store, error) {
+	return newLocalWithRoot(filepath.Join(config.Local.Dir, config.Bucket), config.TTL, operations), nil
+}
+
+func newLocalWithRoot(root string, ttl time.Duration, operations *operations) *localStore {
+	return &localStore{
+		root:       filepath.Clean(root),
+		ttl:        ttl,
+		operations: operations,
+		now:        time.Now,
+	}
+}
+
+// Init creates the target directory and starts a background routine which removes objects
+// older than the configured TTL. As there is no other process managing the lifecycle of the
+// files, expiry happens regardless of the value of PRECISE_CODE_INTEL_UPLOAD_MANAGE_BUCKET.
+func (s *localStore) Init(ctx context.Context) error {
+	if err := os.MkdirAll(s.root, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	if s.ttl > 0 {
+		s.startOnce.Do(func() { startExpirer("codeintel.uploadstore.local.expirer", s.expire) })
+	}
+
+	return nil
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	ctx, endObservation := s.operations.get.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return f, nil
+}
+
+func (s *localStore) Upload(ctx context.Context, key string, r io.Reader) (_ int64, err error) {
+	ctx, endObservation := s.operations.upload.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	n, err := s.writeAtomically(key, func(w io.Writer) (int64, error) {
+		return io.Copy(w, r)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to upload object")
+	}
+
+	return n, nil
+}
+
+func (s *localStore) Compose(ctx context.Context, destination string, sources ...string) (_ int64, err error) {
+	ctx, endObservation := s.operations.compose.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("destination", destination),
+		log.String("sources", strings.Join(sources, ", ")),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	defer func() {
+		if err == nil {
+			// Delete sources on success
+			if err := s.deleteSources(sources); err != nil {
+				log15.Error("Failed to delete source objects", "error", err)
+			}
+		}
+	}()
+
+	n, err := s.writeAtomically(destination, func(w io.Writer) (int64, error) {
+		var total int64
+		for _, source := range sources {
+			n, err := s.copyFrom(w, source)
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+
+		return total, nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to compose objects")
+	}
+
+	return n, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) (err error) {
+	ctx, endObservation := s.operations.delete.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("key", key),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+// path returns the path of the file backing the object with the given key. Keys which
+// would resolve to a path outside of the root directory are rejected.
+func (s *localStore) path(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid key %q", key)
+	}
+
+	return path, nil
+}
+
+// writeAtomically writes the content produced by the given function into a temporary file
+// and moves it to the path of the given key once complete, so that readers never observe a
+// partially written object.
+func (s *localStore) writeAtomically(key string, write func(w io.Writer) (int64, error)) (_ int64, err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			if removeErr := os.Remove(tmp.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+				err = multierror.Append(err, removeErr)
+			}
+		}
+	}()
+
+	n, err := write(tmp)
+	if closeErr := tmp.Close(); closeErr != nil {
+		err = multierror.Append(err, errors.Wrap(closeErr, "failed to close file"))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (s *localStore) copyFrom(w io.Writer, key string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, f)
+}
+
+func (s *localStore) deleteSources(sources []string) (err error) {
+	for _, source := range sources {
+		path, pathErr := s.path(source)
+		if pathErr != nil {
+			err = multierror.Append(err, pathErr)
+			continue
+		}
+
+		if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = multierror.Append(err, errors.Wrap(removeErr, "failed to delete source object"))
+		}
+	}
+
+	return err
+}
+
+// expire removes all files which have not been modified within the configured TTL.
+func (s *localStore) expire(ctx context.Context) error {
+	cutoff := s.now().Add(-s.ttl)
+
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
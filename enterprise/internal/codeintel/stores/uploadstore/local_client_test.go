@@ -0,0 +1,162 @@
+package uploadstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+func TestLocalUploadGet(t *testing.T) {
+	client := testLocalClient(t)
+
+	size, err := client.Upload(context.Background(), "test-key", bytes.NewReader([]byte("TEST PAYLOAD")))
+	if err != nil {
+		t.Fatalf("unexpected error uploading object: %s", err)
+	}
+	if size != 12 {
+		t.Errorf("unexpected size. want=%d have=%d", 12, size)
+	}
+
+	rc, err := client.Get(context.Background(), "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error getting object: %s", err)
+	}
+	defer rc.Close()
+
+	contents, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error reading object: %s", err)
+	}
+	if string(contents) != "TEST PAYLOAD" {
+		t.Fatalf("unexpected contents. want=%s have=%s", "TEST PAYLOAD", contents)
+	}
+}
+
+func TestLocalCompose(t *testing.T) {
+	client := testLocalClient(t)
+
+	for _, key := range []string{"test-src1", "test-src2", "test-src3"} {
+		if _, err := client.Upload(context.Background(), key, strings.NewReader(key+"\n")); err != nil {
+			t.Fatalf("unexpected error uploading object: %s", err)
+		}
+	}
+
+	size, err := client.Compose(context.Background(), "test-key", "test-src1", "test-src2", "test-src3")
+	if err != nil {
+		t.Fatalf("unexpected error composing objects: %s", err)
+	}
+	if size != 30 {
+		t.Errorf("unexpected size. want=%d have=%d", 30, size)
+	}
+
+	rc, err := client.Get(context.Background(), "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error getting object: %s", err)
+	}
+	defer rc.Close()
+
+	contents, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error reading object: %s", err)
+	}
+	if expected := "test-src1\ntest-src2\ntest-src3\n"; string(contents) != expected {
+		t.Fatalf("unexpected contents. want=%q have=%q", expected, contents)
+	}
+
+	for _, key := range []string{"test-src1", "test-src2", "test-src3"} {
+		if _, err := client.Get(context.Background(), key); err == nil {
+			t.Errorf("expected source object %q to be deleted", key)
+		}
+	}
+}
+
+func TestLocalComposeMissingSource(t *testing.T) {
+	client := testLocalClient(t)
+
+	if _, err := client.Upload(context.Background(), "test-src1", strings.NewReader("test")); err != nil {
+		t.Fatalf("unexpected error uploading object: %s", err)
+	}
+
+	if _, err := client.Compose(context.Background(), "test-key", "test-src1", "test-src2"); err == nil {
+		t.Fatalf("expected error composing objects")
+	}
+	if _, err := client.Get(context.Background(), "test-key"); err == nil {
+		t.Errorf("expected destination object not to exist")
+	}
+	if _, err := client.Get(context.Background(), "test-src1"); err != nil {
+		t.Errorf("expected source object to be retained: %s", err)
+	}
+}
+
+func TestLocalDelete(t *testing.T) {
+	client := testLocalClient(t)
+
+	if _, err := client.Upload(context.Background(), "test-key", strings.NewReader("test")); err != nil {
+		t.Fatalf("unexpected error uploading object: %s", err)
+	}
+	if err := client.Delete(context.Background(), "test-key"); err != nil {
+		t.Fatalf("unexpected error deleting object: %s", err)
+	}
+	if _, err := client.Get(context.Background(), "test-key"); err == nil {
+		t.Errorf("expected object to be deleted")
+	}
+
+	// Deleting a missing object is not an error
+	if err := client.Delete(context.Background(), "test-key"); err != nil {
+		t.Fatalf("unexpected error deleting object: %s", err)
+	}
+}
+
+func TestLocalInvalidKey(t *testing.T) {
+	client := testLocalClient(t)
+
+	for _, key := range []string{"", "..", "../test-key", "a/../../test-key"} {
+		if _, err := client.Upload(context.Background(), key, strings.NewReader("test")); err == nil {
+			t.Errorf("expected error uploading object with key %q", key)
+		}
+	}
+}
+
+func TestLocalExpire(t *testing.T) {
+	client := rawLocalClient(t)
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error initializing client: %s", err)
+	}
+
+	for _, key := range []string{"old", "new"} {
+		if _, err := client.Upload(context.Background(), key, strings.NewReader(key)); err != nil {
+			t.Fatalf("unexpected error uploading object: %s", err)
+		}
+	}
+
+	old := time.Now().Add(-time.Hour * 24 * 4)
+	if err := os.Chtimes(filepath.Join(client.root, "old"), old, old); err != nil {
+		t.Fatalf("unexpected error setting modification time: %s", err)
+	}
+
+	if err := client.expire(context.Background()); err != nil {
+		t.Fatalf("unexpected error expiring objects: %s", err)
+	}
+
+	if _, err := client.Get(context.Background(), "old"); err == nil {
+		t.Errorf("expected old object to be expired")
+	}
+	if _, err := client.Get(context.Background(), "new"); err != nil {
+		t.Errorf("unexpected error getting new object: %s", err)
+	}
+}
+
+func testLocalClient(t *testing.T) Store {
+	return newLazyStore(rawLocalClient(t))
+}
+
+func rawLocalClient(t *testing.T) *localStore {
+	return newLocalWithRoot(filepath.Join(t.TempDir(), "test-bucket"), time.Hour*24*3, newOperations(&observation.TestContext))
+}
@@ -3,6 +3,7 @@ package codemonitors
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/cockroachdb/errors"
@@ -10,12 +11,18 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil"
 )
 
 type ActionJob struct {
-	Id           int
+	Id int
+
+	// Exactly one of Email, SlackWebhook and Webhook is non-zero.
 	Email        int64
+	SlackWebhook int64
+	Webhook      int64
+
 	TriggerEvent int
 
 	// Fields demanded by any dbworker.
@@ -40,11 +47,17 @@ type ActionJobMetadata struct {
 
 	// The query with after: filter.
 	Query string
+
+	// The new search results of the query, as returned by the search API.
+	// Results is nil for trigger jobs which ran before results were stored.
+	Results []interface{}
 }
 
 var ActionJobsColumns = []*sqlf.Query{
 	sqlf.Sprintf("cm_action_jobs.id"),
 	sqlf.Sprintf("cm_action_jobs.email"),
+	sqlf.Sprintf("cm_action_jobs.slack_webhook"),
+	sqlf.Sprintf("cm_action_jobs.webhook"),
 	sqlf.Sprintf("cm_action_jobs.trigger_event"),
 	sqlf.Sprintf("cm_action_jobs.state"),
 	sqlf.Sprintf("cm_action_jobs.failure_message"),
@@ -57,7 +70,7 @@ var ActionJobsColumns = []*sqlf.Query{
 }
 
 const readActionEmailEventsFmtStr = `
-SELECT id, email, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
+SELECT id, email, slack_webhook, webhook, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
 FROM cm_action_jobs
 WHERE %s
 AND id > %s
@@ -66,11 +79,21 @@ LIMIT %s;
 `
 
 func (s *Store) ReadActionEmailEvents(ctx context.Context, emailID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
-	var where *sqlf.Query
-	if triggerEventID == nil {
-		where = sqlf.Sprintf("email = %s", emailID)
-	} else {
-		where = sqlf.Sprintf("email = %s AND trigger_event = %s", emailID, *triggerEventID)
+	return s.readActionEvents(ctx, sqlf.Sprintf("email = %s", emailID), triggerEventID, args)
+}
+
+func (s *Store) ReadActionSlackWebhookEvents(ctx context.Context, slackWebhookID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	return s.readActionEvents(ctx, sqlf.Sprintf("slack_webhook = %s", slackWebhookID), triggerEventID, args)
+}
+
+func (s *Store) ReadActionWebhookEvents(ctx context.Context, webhookID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	return s.readActionEvents(ctx, sqlf.Sprintf("webhook = %s", webhookID), triggerEventID, args)
+}
+
+func (s *Store) readActionEvents(ctx context.Context, action *sqlf.Query, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	where := action
+	if triggerEventID != nil {
+		where = sqlf.Sprintf("%s AND trigger_event = %s", action, *triggerEventID)
 	}
 	var rows *sql.Rows
 	after, err := unmarshalAfter(args.After)
@@ -92,11 +115,21 @@ WHERE %s
 `
 
 func (s *Store) TotalActionEmailEvents(ctx context.Context, emailID int64, triggerEventID *int) (totalCount int32, err error) {
-	var where *sqlf.Query
-	if triggerEventID == nil {
-		where = sqlf.Sprintf("email = %s", emailID)
-	} else {
-		where = sqlf.Sprintf("email = %s AND trigger_event = %s", emailID, *triggerEventID)
+	return s.totalActionEvents(ctx, sqlf.Sprintf("email = %s", emailID), triggerEventID)
+}
+
+func (s *Store) TotalActionSlackWebhookEvents(ctx context.Context, slackWebhookID int64, triggerEventID *int) (totalCount int32, err error) {
+	return s.totalActionEvents(ctx, sqlf.Sprintf("slack_webhook = %s", slackWebhookID), triggerEventID)
+}
+
+func (s *Store) TotalActionWebhookEvents(ctx context.Context, webhookID int64, triggerEventID *int) (totalCount int32, err error) {
+	return s.totalActionEvents(ctx, sqlf.Sprintf("webhook = %s", webhookID), triggerEventID)
+}
+
+func (s *Store) totalActionEvents(ctx context.Context, action *sqlf.Query, triggerEventID *int) (totalCount int32, err error) {
+	where := action
+	if triggerEventID != nil {
+		where = sqlf.Sprintf("%s AND trigger_event = %s", action, *triggerEventID)
 	}
 	err = s.QueryRow(ctx, sqlf.Sprintf(totalActionEmailEventsFmtStr, where)).Scan(&totalCount)
 	if err != nil {
@@ -124,8 +157,58 @@ func (s *Store) EnqueueActionEmailsForQueryIDInt64(ctx context.Context, queryID
 	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionEmailFmtStr, queryID, triggerEventID, triggerEventID))
 }
 
+const enqueueActionSlackWebhookFmtStr = `
+WITH due AS (
+	SELECT w.id
+	FROM cm_slack_webhooks w INNER JOIN cm_queries q ON w.monitor = q.monitor
+	WHERE q.id = %s AND w.enabled = true
+),
+busy AS (
+    SELECT DISTINCT slack_webhook as id FROM cm_action_jobs
+    WHERE slack_webhook IS NOT NULL
+    AND (state = 'queued' OR state = 'processing')
+)
+INSERT INTO cm_action_jobs (slack_webhook, trigger_event)
+SELECT id, %s::integer from due EXCEPT SELECT id, %s::integer from busy ORDER BY id
+`
+
+func (s *Store) EnqueueActionSlackWebhooksForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionSlackWebhookFmtStr, queryID, triggerEventID, triggerEventID))
+}
+
+const enqueueActionWebhookFmtStr = `
+WITH due AS (
+	SELECT w.id
+	FROM cm_webhooks w INNER JOIN cm_queries q ON w.monitor = q.monitor
+	WHERE q.id = %s AND w.enabled = true
+),
+busy AS (
+    SELECT DISTINCT webhook as id FROM cm_action_jobs
+    WHERE webhook IS NOT NULL
+    AND (state = 'queued' OR state = 'processing')
+)
+INSERT INTO cm_action_jobs (webhook, trigger_event)
+SELECT id, %s::integer from due EXCEPT SELECT id, %s::integer from busy ORDER BY id
+`
+
+func (s *Store) EnqueueActionWebhooksForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionWebhookFmtStr, queryID, triggerEventID, triggerEventID))
+}
+
+// EnqueueActionJobsForQueryIDInt64 enqueues a job for every enabled action,
+// regardless of its type, of the monitor the query belongs to.
+func (s *Store) EnqueueActionJobsForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	if err = s.EnqueueActionEmailsForQueryIDInt64(ctx, queryID, triggerEventID); err != nil {
+		return err
+	}
+	if err = s.EnqueueActionSlackWebhooksForQueryIDInt64(ctx, queryID, triggerEventID); err != nil {
+		return err
+	}
+	return s.EnqueueActionWebhooksForQueryIDInt64(ctx, queryID, triggerEventID)
+}
+
 const getActionJobMetadataFmtStr = `
-select cm.description, ctj.query_string, cm.id as monitorID, ctj.num_results from
+select cm.description, ctj.query_string, cm.id as monitorID, ctj.num_results, ctj.search_results from
 cm_action_jobs caj
 inner join cm_trigger_jobs ctj on caj.trigger_event = ctj.id
 inner join cm_queries cq on cq.id = ctj.query
@@ -136,15 +219,33 @@ where caj.id = %s
 func (s *Store) GetActionJobMetadata(ctx context.Context, recordID int) (m *ActionJobMetadata, err error) {
 	row := s.Store.QueryRow(ctx, sqlf.Sprintf(getActionJobMetadataFmtStr, recordID))
 	m = &ActionJobMetadata{}
-	err = row.Scan(&m.Description, &m.Query, &m.MonitorID, &m.NumResults)
+	var results dbutil.NullJSONRawMessage
+	err = row.Scan(&m.Description, &m.Query, &m.MonitorID, &m.NumResults, &results)
 	if err != nil {
 		return nil, err
 	}
+	if results.Raw != nil {
+		if err = json.Unmarshal(results.Raw, &m.Results); err != nil {
+			return nil, err
+		}
+	}
 	return m, nil
 }
 
+const setActionJobLogContentsFmtStr = `
+UPDATE cm_action_jobs
+SET log_contents = %s
+WHERE id = %s
+`
+
+// SetActionJobLogContents records details about the execution of an action
+// job which didn't fail, such as recipients we could not notify.
+func (s *Store) SetActionJobLogContents(ctx context.Context, recordID int, contents string) error {
+	return s.Store.Exec(ctx, sqlf.Sprintf(setActionJobLogContentsFmtStr, contents, recordID))
+}
+
 const actionJobForIDFmtStr = `
-SELECT id, email, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
+SELECT id, email, slack_webhook, webhook, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
 FROM cm_action_jobs
 WHERE id = %s
 `
@@ -189,7 +290,9 @@ func scanActionJobs(rows *sql.Rows, err error) ([]*ActionJob, error) {
 		aj := &ActionJob{}
 		if err := rows.Scan(
 			&aj.Id,
-			&aj.Email,
+			&dbutil.NullInt64{N: &aj.Email},
+			&dbutil.NullInt64{N: &aj.SlackWebhook},
+			&dbutil.NullInt64{N: &aj.Webhook},
 			&aj.TriggerEvent,
 			&aj.State,
 			&aj.FailureMessage,
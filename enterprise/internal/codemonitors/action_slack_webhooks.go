@@ -0,0 +1,199 @@
+package codemonitors
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type MonitorSlackWebhook struct {
+	Id        int64
+	Monitor   int64
+	Enabled   bool
+	URL       string
+	CreatedBy int32
+	CreatedAt time.Time
+	ChangedBy int32
+	ChangedAt time.Time
+}
+
+const createActionSlackWebhookFmtStr = `
+INSERT INTO cm_slack_webhooks
+(monitor, enabled, url, created_by, created_at, changed_by, changed_at)
+VALUES (%s,%s,%s,%s,%s,%s,%s)
+RETURNING %s;
+`
+
+func (s *Store) CreateActionSlackWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.CreateActionSlackWebhookArgs) (*MonitorSlackWebhook, error) {
+	now := s.Now()
+	a := actor.FromContext(ctx)
+	return s.runSlackWebhookQuery(ctx, sqlf.Sprintf(
+		createActionSlackWebhookFmtStr,
+		monitorID,
+		args.Enabled,
+		args.URL,
+		a.UID,
+		now,
+		a.UID,
+		now,
+		sqlf.Join(SlackWebhooksColumns, ", "),
+	))
+}
+
+const updateActionSlackWebhookFmtStr = `
+UPDATE cm_slack_webhooks
+SET enabled = %s,
+	url = COALESCE(%s, url),
+	changed_by = %s,
+	changed_at = %s
+WHERE id = %s
+AND monitor = %s
+RETURNING %s;
+`
+
+func (s *Store) UpdateActionSlackWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.EditActionSlackWebhookArgs) (*MonitorSlackWebhook, error) {
+	if args.Id == nil {
+		return nil, errors.Errorf("nil is not a valid action ID")
+	}
+	var actionID int64
+	if err := relay.UnmarshalSpec(*args.Id, &actionID); err != nil {
+		return nil, err
+	}
+	// The URL is only replaced if it isn't the redacted URL returned by the
+	// API.
+	var webhookURL *string
+	if !IsRedactedSlackWebhookURL(args.Update.URL) {
+		webhookURL = &args.Update.URL
+	}
+	a := actor.FromContext(ctx)
+	return s.runSlackWebhookQuery(ctx, sqlf.Sprintf(
+		updateActionSlackWebhookFmtStr,
+		args.Update.Enabled,
+		webhookURL,
+		a.UID,
+		s.Now(),
+		actionID,
+		monitorID,
+		sqlf.Join(SlackWebhooksColumns, ", "),
+	))
+}
+
+const deleteActionSlackWebhooksFmtStr = `DELETE FROM cm_slack_webhooks WHERE id IN (%s) AND monitor = %s`
+
+func (s *Store) DeleteActionSlackWebhooksInt64(ctx context.Context, actionIDs []int64, monitorID int64) error {
+	if len(actionIDs) == 0 {
+		return nil
+	}
+	ids := make([]*sqlf.Query, 0, len(actionIDs))
+	for _, id := range actionIDs {
+		ids = append(ids, sqlf.Sprintf("%d", id))
+	}
+	return s.Exec(ctx, sqlf.Sprintf(deleteActionSlackWebhooksFmtStr, sqlf.Join(ids, ", "), monitorID))
+}
+
+const actionSlackWebhookByIDFmtStr = `
+SELECT %s
+FROM cm_slack_webhooks
+WHERE id = %s
+`
+
+func (s *Store) ActionSlackWebhookByIDInt64(ctx context.Context, slackWebhookID int64) (*MonitorSlackWebhook, error) {
+	return s.runSlackWebhookQuery(ctx, sqlf.Sprintf(actionSlackWebhookByIDFmtStr, sqlf.Join(SlackWebhooksColumns, ", "), slackWebhookID))
+}
+
+const listActionSlackWebhooksFmtStr = `
+SELECT %s
+FROM cm_slack_webhooks
+WHERE monitor = %s
+ORDER BY id ASC
+`
+
+// ListActionSlackWebhooks returns all Slack webhook actions of a monitor.
+func (s *Store) ListActionSlackWebhooks(ctx context.Context, monitorID int64) ([]*MonitorSlackWebhook, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listActionSlackWebhooksFmtStr, sqlf.Join(SlackWebhooksColumns, ", "), monitorID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return ScanSlackWebhooks(rows)
+}
+
+func (s *Store) runSlackWebhookQuery(ctx context.Context, q *sqlf.Query) (*MonitorSlackWebhook, error) {
+	rows, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ws, err := ScanSlackWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ws) == 0 {
+		return nil, errors.Errorf("operation failed. Query should have returned 1 row")
+	}
+	return ws[0], nil
+}
+
+var SlackWebhooksColumns = []*sqlf.Query{
+	sqlf.Sprintf("cm_slack_webhooks.id"),
+	sqlf.Sprintf("cm_slack_webhooks.monitor"),
+	sqlf.Sprintf("cm_slack_webhooks.enabled"),
+	sqlf.Sprintf("cm_slack_webhooks.url"),
+	sqlf.Sprintf("cm_slack_webhooks.created_by"),
+	sqlf.Sprintf("cm_slack_webhooks.created_at"),
+	sqlf.Sprintf("cm_slack_webhooks.changed_by"),
+	sqlf.Sprintf("cm_slack_webhooks.changed_at"),
+}
+
+func ScanSlackWebhooks(rows *sql.Rows) (ws []*MonitorSlackWebhook, err error) {
+	for rows.Next() {
+		w := &MonitorSlackWebhook{}
+		if err = rows.Scan(
+			&w.Id,
+			&w.Monitor,
+			&w.Enabled,
+			&w.URL,
+			&w.CreatedBy,
+			&w.CreatedAt,
+			&w.ChangedBy,
+			&w.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// RedactSlackWebhookURL returns the given Slack incoming webhook URL with its
+// path, which acts as the credentials of the webhook, replaced by
+// types.RedactedSecret.
+func RedactSlackWebhookURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return types.RedactedSecret
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + types.RedactedSecret}).String()
+}
+
+// IsRedactedSlackWebhookURL returns true if rawURL was returned by
+// RedactSlackWebhookURL.
+func IsRedactedSlackWebhookURL(rawURL string) bool {
+	if rawURL == types.RedactedSecret {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Path == "/"+types.RedactedSecret && u.RawQuery == ""
+}
@@ -0,0 +1,23 @@
+package codemonitors
+
+import "testing"
+
+func TestRedactSlackWebhookURL(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"https://hooks.slack.com/services/T000/B000/XXXX": "https://hooks.slack.com/REDACTED",
+		"https://hooks.slack.com/services/x?token=secret": "https://hooks.slack.com/REDACTED",
+		"not a URL": "REDACTED",
+	} {
+		have := RedactSlackWebhookURL(rawURL)
+		if have != want {
+			t.Errorf("RedactSlackWebhookURL(%q): have %q, want %q", rawURL, have, want)
+		}
+		if !IsRedactedSlackWebhookURL(have) {
+			t.Errorf("IsRedactedSlackWebhookURL(%q) is false", have)
+		}
+	}
+
+	if IsRedactedSlackWebhookURL("https://hooks.slack.com/services/T000/B000/XXXX") {
+		t.Error("expected a Slack webhook URL not to be redacted")
+	}
+}
@@ -0,0 +1,266 @@
+package codemonitors
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+)
+
+type MonitorWebhook struct {
+	Id      int64
+	Monitor int64
+	Enabled bool
+	URL     string
+	// Secret is used to sign the payload. It is empty if the payload is not
+	// signed.
+	Secret    string
+	CreatedBy int32
+	CreatedAt time.Time
+	ChangedBy int32
+	ChangedAt time.Time
+
+	// encryptionKeyID is the ID of the key Secret is encrypted with, or empty
+	// if it is not encrypted.
+	encryptionKeyID string
+}
+
+const createActionWebhookFmtStr = `
+INSERT INTO cm_webhooks
+(monitor, enabled, url, secret, encryption_key_id, created_by, created_at, changed_by, changed_at)
+VALUES (%s,%s,%s,%s,%s,%s,%s,%s,%s)
+RETURNING %s;
+`
+
+func (s *Store) CreateActionWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.CreateActionWebhookArgs) (*MonitorWebhook, error) {
+	secret, keyID, err := s.encryptWebhookSecret(ctx, stringOrEmpty(args.Secret))
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	a := actor.FromContext(ctx)
+	return s.runWebhookQuery(ctx, sqlf.Sprintf(
+		createActionWebhookFmtStr,
+		monitorID,
+		args.Enabled,
+		args.URL,
+		secret,
+		keyID,
+		a.UID,
+		now,
+		a.UID,
+		now,
+		sqlf.Join(WebhooksColumns, ", "),
+	))
+}
+
+const updateActionWebhookFmtStr = `
+UPDATE cm_webhooks
+SET enabled = %s,
+	url = %s,
+	secret = COALESCE(%s, secret),
+	encryption_key_id = COALESCE(%s, encryption_key_id),
+	changed_by = %s,
+	changed_at = %s
+WHERE id = %s
+AND monitor = %s
+RETURNING %s;
+`
+
+func (s *Store) UpdateActionWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.EditActionWebhookArgs) (*MonitorWebhook, error) {
+	if args.Id == nil {
+		return nil, errors.Errorf("nil is not a valid action ID")
+	}
+	var actionID int64
+	if err := relay.UnmarshalSpec(*args.Id, &actionID); err != nil {
+		return nil, err
+	}
+	// The secret is only replaced if a new one is given.
+	var secret, keyID *string
+	if args.Update.Secret != nil {
+		encrypted, id, err := s.encryptWebhookSecret(ctx, *args.Update.Secret)
+		if err != nil {
+			return nil, err
+		}
+		secret, keyID = &encrypted, &id
+	}
+	a := actor.FromContext(ctx)
+	return s.runWebhookQuery(ctx, sqlf.Sprintf(
+		updateActionWebhookFmtStr,
+		args.Update.Enabled,
+		args.Update.URL,
+		secret,
+		keyID,
+		a.UID,
+		s.Now(),
+		actionID,
+		monitorID,
+		sqlf.Join(WebhooksColumns, ", "),
+	))
+}
+
+const deleteActionWebhooksFmtStr = `DELETE FROM cm_webhooks WHERE id IN (%s) AND monitor = %s`
+
+func (s *Store) DeleteActionWebhooksInt64(ctx context.Context, actionIDs []int64, monitorID int64) error {
+	if len(actionIDs) == 0 {
+		return nil
+	}
+	ids := make([]*sqlf.Query, 0, len(actionIDs))
+	for _, id := range actionIDs {
+		ids = append(ids, sqlf.Sprintf("%d", id))
+	}
+	return s.Exec(ctx, sqlf.Sprintf(deleteActionWebhooksFmtStr, sqlf.Join(ids, ", "), monitorID))
+}
+
+const actionWebhookByIDFmtStr = `
+SELECT %s
+FROM cm_webhooks
+WHERE id = %s
+`
+
+func (s *Store) ActionWebhookByIDInt64(ctx context.Context, webhookID int64) (*MonitorWebhook, error) {
+	return s.runWebhookQuery(ctx, sqlf.Sprintf(actionWebhookByIDFmtStr, sqlf.Join(WebhooksColumns, ", "), webhookID))
+}
+
+const listActionWebhooksFmtStr = `
+SELECT %s
+FROM cm_webhooks
+WHERE monitor = %s
+ORDER BY id ASC
+`
+
+// ListActionWebhooks returns all webhook actions of a monitor.
+func (s *Store) ListActionWebhooks(ctx context.Context, monitorID int64) ([]*MonitorWebhook, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listActionWebhooksFmtStr, sqlf.Join(WebhooksColumns, ", "), monitorID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ws, err := ScanWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range ws {
+		if err := s.decryptWebhookSecret(ctx, w); err != nil {
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+func (s *Store) runWebhookQuery(ctx context.Context, q *sqlf.Query) (*MonitorWebhook, error) {
+	rows, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ws, err := ScanWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ws) == 0 {
+		return nil, errors.Errorf("operation failed. Query should have returned 1 row")
+	}
+	if err := s.decryptWebhookSecret(ctx, ws[0]); err != nil {
+		return nil, err
+	}
+	return ws[0], nil
+}
+
+// encryptWebhookSecret encrypts secret with the configured webhook key. It
+// returns the secret as is and an empty key ID if the secret is empty or no key
+// is configured.
+func (s *Store) encryptWebhookSecret(ctx context.Context, secret string) (encrypted, keyID string, err error) {
+	key := s.webhookKey()
+	if secret == "" || key == nil {
+		return secret, "", nil
+	}
+	ciphertext, err := key.Encrypt(ctx, []byte(secret))
+	if err != nil {
+		return "", "", errors.Wrap(err, "encrypting webhook secret")
+	}
+	version, err := key.Version(ctx)
+	if err != nil {
+		return "", "", errors.Wrap(err, "getting key version")
+	}
+	return string(ciphertext), version.JSON(), nil
+}
+
+// decryptWebhookSecret replaces the secret of w with its plaintext if it is
+// encrypted.
+func (s *Store) decryptWebhookSecret(ctx context.Context, w *MonitorWebhook) error {
+	if w.encryptionKeyID == "" {
+		return nil
+	}
+	key := s.webhookKey()
+	if key == nil {
+		return errors.Errorf("couldn't decrypt secret of webhook %d, key is nil", w.Id)
+	}
+	secret, err := key.Decrypt(ctx, []byte(w.Secret))
+	if err != nil {
+		return errors.Wrapf(err, "decrypting secret of webhook %d", w.Id)
+	}
+	w.Secret = secret.Secret()
+	w.encryptionKeyID = ""
+	return nil
+}
+
+func (s *Store) webhookKey() encryption.Key {
+	if s.key != nil {
+		return s.key
+	}
+	return keyring.Default().CodeMonitorWebhookKey
+}
+
+var WebhooksColumns = []*sqlf.Query{
+	sqlf.Sprintf("cm_webhooks.id"),
+	sqlf.Sprintf("cm_webhooks.monitor"),
+	sqlf.Sprintf("cm_webhooks.enabled"),
+	sqlf.Sprintf("cm_webhooks.url"),
+	sqlf.Sprintf("cm_webhooks.secret"),
+	sqlf.Sprintf("cm_webhooks.encryption_key_id"),
+	sqlf.Sprintf("cm_webhooks.created_by"),
+	sqlf.Sprintf("cm_webhooks.created_at"),
+	sqlf.Sprintf("cm_webhooks.changed_by"),
+	sqlf.Sprintf("cm_webhooks.changed_at"),
+}
+
+func ScanWebhooks(rows *sql.Rows) (ws []*MonitorWebhook, err error) {
+	for rows.Next() {
+		w := &MonitorWebhook{}
+		if err = rows.Scan(
+			&w.Id,
+			&w.Monitor,
+			&w.Enabled,
+			&w.URL,
+			&w.Secret,
+			&w.encryptionKeyID,
+			&w.CreatedBy,
+			&w.CreatedAt,
+			&w.ChangedBy,
+			&w.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,70 @@
+package codemonitors
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	et "github.com/sourcegraph/sourcegraph/internal/encryption/testing"
+)
+
+func TestActionWebhookSecretEncryption(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx, s := newTestStore(t)
+	s = s.WithEncryptionKey(et.TestKey{})
+	_, _, _, userCTX := newTestUser(ctx, t)
+	m, err := s.insertTestMonitor(userCTX, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const secret = "s3cr3t"
+	secretArg := secret
+	w, err := s.CreateActionWebhook(userCTX, m.ID, &graphqlbackend.CreateActionWebhookArgs{
+		Enabled: true,
+		URL:     "https://example.com/webhook",
+		Secret:  &secretArg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Secret != secret {
+		t.Fatalf("unexpected secret: want %q, got %q", secret, w.Secret)
+	}
+
+	// The secret is stored encrypted.
+	stored, _, err := basestore.ScanFirstString(s.Query(ctx, sqlf.Sprintf("SELECT secret FROM cm_webhooks WHERE id = %s", w.Id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == secret {
+		t.Fatal("secret is stored in plaintext")
+	}
+
+	w, err = s.ActionWebhookByIDInt64(ctx, w.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Secret != secret {
+		t.Fatalf("unexpected secret: want %q, got %q", secret, w.Secret)
+	}
+
+	// Updating the webhook without a secret keeps the existing one.
+	id := relay.MarshalID("CodeMonitorActionWebhook", w.Id)
+	w, err = s.UpdateActionWebhook(userCTX, m.ID, &graphqlbackend.EditActionWebhookArgs{
+		Id:     &id,
+		Update: &graphqlbackend.CreateActionWebhookArgs{Enabled: true, URL: "https://example.com/other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Secret != secret {
+		t.Fatalf("unexpected secret: want %q, got %q", secret, w.Secret)
+	}
+}
@@ -33,6 +33,13 @@ const gqlSearchQuery = `query Search(
 			results {
 				__typename
 				... on FileMatch {
+					repository {
+						name
+					}
+					file {
+						path
+						url
+					}
 					limitHit
 					lineMatches {
 						preview
@@ -41,6 +48,7 @@ const gqlSearchQuery = `query Search(
 					}
 				}
 				... on CommitSearchResult {
+					url
 					refs {
 						name
 						displayName
@@ -0,0 +1,203 @@
+package background
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+const (
+	utmSourceSlackWebhook = "code-monitoring-slack-webhook"
+	utmSourceWebhook      = "code-monitoring-webhook"
+
+	// signatureHeader is the header that contains the HMAC-SHA256 signature of
+	// the payload sent to a webhook action with a secret.
+	signatureHeader = "X-Sourcegraph-Signature"
+
+	// maxSlackResults is the maximum number of results we list in a Slack
+	// message. Slack truncates long messages anyway, and the message links to
+	// the search results page.
+	maxSlackResults = 10
+)
+
+// webhookDoer is the HTTP client used to send webhook payloads. Webhook URLs
+// are provided by users, so it refuses to connect to internal addresses. It is
+// a variable so that tests can replace it.
+var webhookDoer, _ = httpcli.ExternalClientFactory.Doer(httpcli.DenyPrivateNetworksOpt)
+
+// webhookPayload is the JSON body we POST to generic webhook actions.
+type webhookPayload struct {
+	MonitorDescription string        `json:"monitorDescription"`
+	MonitorURL         string        `json:"monitorURL"`
+	Query              string        `json:"query"`
+	SearchURL          string        `json:"searchURL"`
+	NumResults         int           `json:"numResults"`
+	Results            []interface{} `json:"results"`
+}
+
+func newWebhookPayload(ctx context.Context, m *cm.ActionJobMetadata) (*webhookPayload, error) {
+	searchURL, err := email.SearchURL(ctx, m.Query, utmSourceWebhook)
+	if err != nil {
+		return nil, err
+	}
+	monitorURL, err := email.CodeMonitorURL(ctx, m.MonitorID, utmSourceWebhook)
+	if err != nil {
+		return nil, err
+	}
+	results := m.Results
+	if results == nil {
+		results = []interface{}{}
+	}
+	return &webhookPayload{
+		MonitorDescription: m.Description,
+		MonitorURL:         monitorURL,
+		Query:              m.Query,
+		SearchURL:          searchURL,
+		NumResults:         zeroOrVal(m.NumResults),
+		Results:            results,
+	}, nil
+}
+
+// slackPayload is the body of a message sent to a Slack incoming webhook.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func newSlackPayload(ctx context.Context, m *cm.ActionJobMetadata) (*slackPayload, error) {
+	searchURL, err := email.SearchURL(ctx, m.Query, utmSourceSlackWebhook)
+	if err != nil {
+		return nil, err
+	}
+	monitorURL, err := email.CodeMonitorURL(ctx, m.MonitorID, utmSourceSlackWebhook)
+	if err != nil {
+		return nil, err
+	}
+
+	numResults := zeroOrVal(m.NumResults)
+	noun := "results"
+	if numResults == 1 {
+		noun = "result"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code monitor <%s|%s> found <%s|%d new search %s>", monitorURL, escapeSlack(m.Description), searchURL, numResults, noun)
+	for i, r := range m.Results {
+		if i == maxSlackResults {
+			fmt.Fprintf(&b, "\n… and %d more", len(m.Results)-maxSlackResults)
+			break
+		}
+		label, url := describeResult(r)
+		if url == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n• <%s|%s>", url, escapeSlack(label))
+	}
+	return &slackPayload{Text: b.String()}, nil
+}
+
+// describeResult returns a label and a URL for a search result as returned by
+// gqlSearchQuery. It returns empty strings for results it doesn't know.
+func describeResult(result interface{}) (label, url string) {
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+	switch m["__typename"] {
+	case "FileMatch":
+		repo, _ := m["repository"].(map[string]interface{})
+		file, _ := m["file"].(map[string]interface{})
+		repoName, _ := repo["name"].(string)
+		path, _ := file["path"].(string)
+		url, _ = file["url"].(string)
+		return repoName + "/" + path, url
+	case "CommitSearchResult":
+		commit, _ := m["commit"].(map[string]interface{})
+		repo, _ := commit["repository"].(map[string]interface{})
+		repoName, _ := repo["name"].(string)
+		oid, _ := commit["abbreviatedOID"].(string)
+		url, _ = m["url"].(string)
+		return repoName + "@" + oid, url
+	}
+	return "", ""
+}
+
+// escapeSlack escapes the characters that have a special meaning in Slack
+// message formatting.
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of payload using secret.
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON sends payload to url. If secret is not empty, the request carries
+// the signature of the body in the signatureHeader.
+func postJSON(ctx context.Context, url string, payload interface{}, secret string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshalling payload")
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(signatureHeader, "sha256="+signPayload(secret, body))
+	}
+
+	resp, err := webhookDoer.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("webhook responded with status %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func sendSlackWebhook(ctx context.Context, s *cm.Store, j *cm.ActionJob, m *cm.ActionJobMetadata) error {
+	w, err := s.ActionSlackWebhookByIDInt64(ctx, j.SlackWebhook)
+	if err != nil {
+		return errors.Errorf("store.ActionSlackWebhookByIDInt64: %w", err)
+	}
+	payload, err := newSlackPayload(ctx, m)
+	if err != nil {
+		return errors.Errorf("newSlackPayload: %w", err)
+	}
+	// Slack incoming webhook URLs are secrets themselves, so we don't sign
+	// the payload.
+	return postJSON(ctx, w.URL, payload, "")
+}
+
+func sendWebhook(ctx context.Context, s *cm.Store, j *cm.ActionJob, m *cm.ActionJobMetadata) error {
+	w, err := s.ActionWebhookByIDInt64(ctx, j.Webhook)
+	if err != nil {
+		return errors.Errorf("store.ActionWebhookByIDInt64: %w", err)
+	}
+	payload, err := newWebhookPayload(ctx, m)
+	if err != nil {
+		return errors.Errorf("newWebhookPayload: %w", err)
+	}
+	return postJSON(ctx, w.URL, payload, w.Secret)
+}
@@ -0,0 +1,150 @@
+package background
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
+)
+
+func mockExternalURL(t *testing.T) {
+	email.MockExternalURL = func() *url.URL {
+		externalURL, _ := url.Parse("https://www.sourcegraph.com")
+		return externalURL
+	}
+	t.Cleanup(func() { email.MockExternalURL = nil })
+}
+
+func testActionJobMetadata() *cm.ActionJobMetadata {
+	numResults := 2
+	return &cm.ActionJobMetadata{
+		Description: "<my monitor>",
+		MonitorID:   1,
+		NumResults:  &numResults,
+		Query:       "test",
+		Results: []interface{}{
+			map[string]interface{}{
+				"__typename": "FileMatch",
+				"repository": map[string]interface{}{"name": "github.com/sourcegraph/sourcegraph"},
+				"file": map[string]interface{}{
+					"path": "README.md",
+					"url":  "/github.com/sourcegraph/sourcegraph/-/blob/README.md",
+				},
+			},
+			map[string]interface{}{
+				"__typename": "CommitSearchResult",
+				"url":        "/github.com/sourcegraph/sourcegraph/-/commit/deadbeef",
+				"commit": map[string]interface{}{
+					"repository":     map[string]interface{}{"name": "github.com/sourcegraph/sourcegraph"},
+					"abbreviatedOID": "deadbee",
+				},
+			},
+		},
+	}
+}
+
+func TestNewWebhookPayload(t *testing.T) {
+	mockExternalURL(t)
+
+	m := testActionJobMetadata()
+	got, err := newWebhookPayload(context.Background(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &webhookPayload{
+		MonitorDescription: "<my monitor>",
+		MonitorURL:         "https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==?utm_source=code-monitoring-webhook",
+		Query:              "test",
+		SearchURL:          "https://www.sourcegraph.com/search?q=test&utm_source=code-monitoring-webhook",
+		NumResults:         2,
+		Results:            m.Results,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestNewSlackPayload(t *testing.T) {
+	mockExternalURL(t)
+
+	got, err := newSlackPayload(context.Background(), testActionJobMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Code monitor <https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==?utm_source=code-monitoring-slack-webhook|&lt;my monitor&gt;> " +
+		"found <https://www.sourcegraph.com/search?q=test&utm_source=code-monitoring-slack-webhook|2 new search results>" +
+		"\n• </github.com/sourcegraph/sourcegraph/-/blob/README.md|github.com/sourcegraph/sourcegraph/README.md>" +
+		"\n• </github.com/sourcegraph/sourcegraph/-/commit/deadbeef|github.com/sourcegraph/sourcegraph@deadbee>"
+	if diff := cmp.Diff(want, got.Text); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestPostJSON(t *testing.T) {
+	var (
+		gotBody      []byte
+		gotSignature string
+		status       = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(signatureHeader)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	oldDoer := webhookDoer
+	webhookDoer = srv.Client()
+	t.Cleanup(func() { webhookDoer = oldDoer })
+
+	payload := map[string]string{"hello": "world"}
+	ctx := context.Background()
+
+	t.Run("unsigned", func(t *testing.T) {
+		if err := postJSON(ctx, srv.URL, payload, ""); err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(gotBody, &got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(payload, got); diff != "" {
+			t.Fatal(diff)
+		}
+		if gotSignature != "" {
+			t.Fatalf("unexpected signature %q", gotSignature)
+		}
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		if err := postJSON(ctx, srv.URL, payload, "secret"); err != nil {
+			t.Fatal(err)
+		}
+		if want := "sha256=" + signPayload("secret", gotBody); gotSignature != want {
+			t.Fatalf("wrong signature: want %q, got %q", want, gotSignature)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		if err := postJSON(ctx, srv.URL, payload, ""); err == nil {
+			t.Fatal("expected error for non-2xx response")
+		}
+	})
+}
+
+func TestSignPayload(t *testing.T) {
+	// Computed with `printf '{"a":1}' | openssl dgst -sha256 -hmac secret`.
+	want := "aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494"
+	if got := signPayload("secret", []byte(`{"a":1}`)); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
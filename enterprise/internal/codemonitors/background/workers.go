@@ -12,6 +12,9 @@ import (
 
 	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker"
@@ -165,9 +168,9 @@ func (r *queryRunner) Handle(ctx context.Context, record workerutil.Record) (err
 		numResults = len(results.Data.Search.Results.Results)
 	}
 	if numResults > 0 {
-		err := s.EnqueueActionEmailsForQueryIDInt64(ctx, q.Id, record.RecordID())
+		err := s.EnqueueActionJobsForQueryIDInt64(ctx, q.Id, record.RecordID())
 		if err != nil {
-			return errors.Errorf("store.EnqueueActionEmailsForQueryIDInt64: %w", err)
+			return errors.Errorf("store.EnqueueActionJobsForQueryIDInt64: %w", err)
 		}
 	}
 	// Log next_run and latest_result to table cm_queries.
@@ -181,6 +184,13 @@ func (r *queryRunner) Handle(ctx context.Context, record workerutil.Record) (err
 	if err != nil {
 		return errors.Errorf("LogSearch: %w", err)
 	}
+	// Webhook actions include the new results in their payload.
+	if numResults > 0 {
+		err = s.LogSearchResults(ctx, results.Data.Search.Results.Results, record.RecordID())
+		if err != nil {
+			return errors.Errorf("LogSearchResults: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -203,11 +213,8 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 	defer func() { err = s.Done(err) }()
 
 	var (
-		j    *cm.ActionJob
-		m    *cm.ActionJobMetadata
-		e    *cm.MonitorEmail
-		recs []*cm.Recipient
-		data *email.TemplateDataNewSearchResults
+		j *cm.ActionJob
+		m *cm.ActionJobMetadata
 	)
 
 	var ok bool
@@ -221,6 +228,26 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 		return errors.Errorf("store.GetActionJobMetadata: %w", err)
 	}
 
+	switch {
+	case j.Email != 0:
+		return sendEmails(ctx, s, j, m)
+	case j.SlackWebhook != 0:
+		return sendSlackWebhook(ctx, s, j, m)
+	case j.Webhook != 0:
+		return sendWebhook(ctx, s, j, m)
+	default:
+		return errors.Errorf("action job %d has no action", j.Id)
+	}
+}
+
+func sendEmails(ctx context.Context, s *cm.Store, j *cm.ActionJob, m *cm.ActionJobMetadata) (err error) {
+	var (
+		e       *cm.MonitorEmail
+		recs    []*cm.Recipient
+		userIDs []int32
+		data    *email.TemplateDataNewSearchResults
+	)
+
 	e, err = s.ActionEmailByIDInt64(ctx, j.Email)
 	if err != nil {
 		return errors.Errorf("store.ActionEmailByIDInt64: %w", err)
@@ -231,24 +258,85 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 		return errors.Errorf("store.AllRecipientsForEmailIDInt64: %w", err)
 	}
 
+	userIDs, err = recipientUserIDs(ctx, s.Handle().DB(), recs)
+	if err != nil {
+		return errors.Errorf("recipientUserIDs: %w", err)
+	}
+
 	data, err = email.NewTemplateDataForNewSearchResults(ctx, m.Description, m.Query, e, zeroOrVal(m.NumResults))
 	if err != nil {
 		return errors.Errorf("email.NewTemplateDataForNewSearchResults: %w", err)
 	}
+
+	// A failure to reach a single recipient should not keep the others from
+	// being notified, nor trigger a retry which would notify them again.
+	var failures []string
+	for _, userID := range userIDs {
+		if err := email.SendEmailForNewSearchResult(ctx, userID, data); err != nil {
+			log15.Warn("code monitors: failed to send email", "actionJob", j.Id, "userID", userID, "error", err)
+			failures = append(failures, fmt.Sprintf("user %d: %s", userID, err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	if len(failures) == len(userIDs) {
+		return errors.Errorf("failed to send email to any recipient: %s", strings.Join(failures, "; "))
+	}
+	return s.SetActionJobLogContents(ctx, j.Id, "failed to send email to some recipients:\n"+strings.Join(failures, "\n"))
+}
+
+// recipientUserIDs resolves the recipients of an email action to the users we
+// send the email to. Org recipients are expanded to those members of the org
+// who have a verified primary email address. Every user is returned at most
+// once, even if they are a recipient both directly and through one or more
+// orgs.
+func recipientUserIDs(ctx context.Context, db dbutil.DB, recs []*cm.Recipient) ([]int32, error) {
+	var (
+		userIDs []int32
+		seen    = make(map[int32]struct{})
+	)
+	add := func(userID int32) {
+		if _, ok := seen[userID]; ok {
+			return
+		}
+		seen[userID] = struct{}{}
+		userIDs = append(userIDs, userID)
+	}
+
+	// User recipients come first, so that they are never filtered out as an
+	// org member.
 	for _, rec := range recs {
-		if rec.NamespaceOrgID != nil {
-			// TODO (stefan): Send emails to org members.
-			continue
+		if rec.NamespaceUserID != nil {
+			add(*rec.NamespaceUserID)
+		} else if rec.NamespaceOrgID == nil {
+			return nil, errors.Errorf("nil recipient")
 		}
-		if rec.NamespaceUserID == nil {
-			return errors.Errorf("nil recipient")
+	}
+
+	for _, rec := range recs {
+		if rec.NamespaceOrgID == nil {
+			continue
 		}
-		err = email.SendEmailForNewSearchResult(ctx, *rec.NamespaceUserID, data)
+		members, err := database.OrgMembers(db).GetByOrgID(ctx, *rec.NamespaceOrgID)
 		if err != nil {
-			return err
+			return nil, errors.Errorf("OrgMembers.GetByOrgID: %w", err)
+		}
+		for _, member := range members {
+			if _, ok := seen[member.UserID]; ok {
+				continue
+			}
+			_, verified, err := database.UserEmails(db).GetPrimaryEmail(ctx, member.UserID)
+			if err != nil && !errcode.IsNotFound(err) {
+				return nil, errors.Errorf("UserEmails.GetPrimaryEmail: %w", err)
+			}
+			if err != nil || !verified {
+				continue
+			}
+			add(member.UserID)
 		}
 	}
-	return nil
+	return userIDs, nil
 }
 
 // newQueryWithAfterFilter constructs a new query which finds search results
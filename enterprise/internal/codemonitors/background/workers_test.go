@@ -2,6 +2,7 @@ package background
 
 import (
 	"context"
+	"fmt"
 	"net/url"
 	"testing"
 	"time"
@@ -12,7 +13,10 @@ import (
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/storetest"
+	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 func init() {
@@ -117,3 +121,50 @@ func TestActionRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestRecipientUserIDs(t *testing.T) {
+	database.Mocks.OrgMembers.GetByOrgID = func(ctx context.Context, orgID int32) ([]*types.OrgMembership, error) {
+		switch orgID {
+		case 10:
+			return []*types.OrgMembership{{OrgID: 10, UserID: 1}, {OrgID: 10, UserID: 2}, {OrgID: 10, UserID: 3}}, nil
+		case 20:
+			return []*types.OrgMembership{{OrgID: 20, UserID: 3}, {OrgID: 20, UserID: 4}, {OrgID: 20, UserID: 5}}, nil
+		}
+		return nil, nil
+	}
+	database.Mocks.UserEmails.GetPrimaryEmail = func(ctx context.Context, id int32) (string, bool, error) {
+		switch id {
+		case 4:
+			// Unverified primary email.
+			return "user4@example.com", false, nil
+		case 5:
+			return "", false, &errcode.Mock{IsNotFound: true}
+		}
+		return fmt.Sprintf("user%d@example.com", id), true, nil
+	}
+	t.Cleanup(func() { database.Mocks = database.MockStores{} })
+
+	userID := func(id int32) *int32 { return &id }
+	orgID := userID
+
+	got, err := recipientUserIDs(context.Background(), nil, []*codemonitors.Recipient{
+		{NamespaceOrgID: orgID(10)},
+		{NamespaceUserID: userID(2)},
+		{NamespaceOrgID: orgID(20)},
+		{NamespaceUserID: userID(6)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// User recipients come first, org members are deduplicated against them
+	// and each other, and members without a verified primary email are
+	// skipped.
+	want := []int32{2, 6, 1, 3}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("diff: %s", diff)
+	}
+
+	if _, err := recipientUserIDs(context.Background(), nil, []*codemonitors.Recipient{{}}); err == nil {
+		t.Fatal("expected error for recipient without namespace")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
 )
 
@@ -15,6 +16,9 @@ import (
 type Store struct {
 	*basestore.Store
 	now func() time.Time
+	// key encrypts the secrets of webhook actions. If it is nil, the key of
+	// the keyring is used.
+	key encryption.Key
 }
 
 // NewStore returns a new Store backed by the given database.
@@ -28,6 +32,12 @@ func NewStoreWithClock(db dbutil.DB, clock func() time.Time) *Store {
 	return &Store{Store: basestore.NewWithDB(db, sql.TxOptions{}), now: clock}
 }
 
+// WithEncryptionKey returns a copy of the store which encrypts the secrets of
+// webhook actions with the given key.
+func (s *Store) WithEncryptionKey(key encryption.Key) *Store {
+	return &Store{Store: s.Store, now: s.now, key: key}
+}
+
 // Clock returns the clock of the underlying store.
 func (s *Store) Clock() func() time.Time {
 	return s.now
@@ -45,5 +55,5 @@ func (s *Store) Transact(ctx context.Context) (*Store, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Store{Store: txBase, now: s.now}, nil
+	return &Store{Store: txBase, now: s.now, key: s.key}, nil
 }
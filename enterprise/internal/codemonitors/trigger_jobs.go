@@ -3,6 +3,7 @@ package codemonitors
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/graph-gophers/graphql-go"
@@ -50,6 +51,23 @@ func (s *Store) LogSearch(ctx context.Context, queryString string, numResults in
 	return s.Store.Exec(ctx, sqlf.Sprintf(logSearchFmtStr, queryString, numResults > 0, numResults, recordID))
 }
 
+const logSearchResultsFmtStr = `
+UPDATE cm_trigger_jobs
+SET search_results = %s
+WHERE id = %s
+`
+
+// LogSearchResults stores the new search results of a trigger job, so that
+// actions which include the results in their payload, such as webhooks, can
+// read them later.
+func (s *Store) LogSearchResults(ctx context.Context, results []interface{}, recordID int) error {
+	b, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return s.Store.Exec(ctx, sqlf.Sprintf(logSearchResultsFmtStr, b, recordID))
+}
+
 const deleteObsoleteJobLogsFmtStr = `
 DELETE FROM cm_trigger_jobs
 WHERE results IS NOT TRUE
@@ -8,8 +8,10 @@ import (
 )
 
 type Operations struct {
-	repoName           *observation.Operation
-	getJVMDependencies *observation.Operation
+	repoName                *observation.Operation
+	getJVMDependencies      *observation.Operation
+	getNpmDependencies      *observation.Operation
+	getRegistryDependencies *observation.Operation
 }
 
 func NewOperationsMetrics(observationContext *observation.Context) *metrics.OperationMetrics {
@@ -31,7 +33,9 @@ func NewOperationsFromMetrics(observationContext *observation.Context, metrics *
 	}
 
 	return &Operations{
-		repoName:           op("RepoName"),
-		getJVMDependencies: op("GetJVMDependencies"),
+		repoName:                op("RepoName"),
+		getJVMDependencies:      op("GetJVMDependencies"),
+		getNpmDependencies:      op("GetNpmDependencies"),
+		getRegistryDependencies: op("GetRegistryDependencies"),
 	}
 }
@@ -96,6 +96,138 @@ func scanJVMDependencyRepo(rows *sql.Rows, queryErr error) (dependencies []JVMDe
 	return dependencies, nil
 }
 
+type GetNpmDependencyReposOpts struct {
+	PackageName string
+	After       int
+	Limit       int
+}
+
+type NpmDependencyRepo struct {
+	Package string
+	Version string
+	ID      int
+}
+
+// GetNpmDependencyRepos returns the npm packages referenced by LSIF uploads,
+// which are recorded with the "npm" scheme.
+func (s *Store) GetNpmDependencyRepos(ctx context.Context, filter GetNpmDependencyReposOpts) (repos []NpmDependencyRepo, err error) {
+	ctx, endObservation := s.operations.getNpmDependencies.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.Int("after", filter.After),
+		log.Int("limit", filter.Limit),
+		log.Lazy(func(l log.Encoder) {
+			l.EmitInt("results", len(repos))
+		}),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	conds := make([]*sqlf.Query, 0, 3)
+	conds = append(conds, sqlf.Sprintf("scheme = 'npm'"))
+
+	if filter.After > 0 {
+		conds = append(conds, sqlf.Sprintf("id > %d", filter.After))
+	}
+
+	if filter.PackageName != "" {
+		conds = append(conds, sqlf.Sprintf("name = %s", filter.PackageName))
+	}
+
+	limit := sqlf.Sprintf("")
+	if filter.Limit != 0 {
+		limit = sqlf.Sprintf("LIMIT %s", filter.Limit)
+	}
+
+	return scanNpmDependencyRepo(s.Query(ctx, sqlf.Sprintf(getLSIFDependencyReposQuery, sqlf.Join(conds, "AND"), limit)))
+}
+
+func scanNpmDependencyRepo(rows *sql.Rows, queryErr error) (dependencies []NpmDependencyRepo, err error) {
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	for rows.Next() {
+		var dep NpmDependencyRepo
+		if err = rows.Scan(
+			&dep.ID,
+			&dep.Package,
+			&dep.Version,
+		); err != nil {
+			return nil, err
+		}
+
+		dependencies = append(dependencies, dep)
+	}
+
+	return dependencies, nil
+}
+
+type GetRegistryDependencyReposOpts struct {
+	Scheme      string
+	PackageName string
+	After       int
+	Limit       int
+}
+
+type RegistryDependencyRepo struct {
+	Package string
+	Version string
+	ID      int
+}
+
+// GetRegistryDependencyRepos returns the packages of the given package
+// registry scheme, such as "cargo", that are referenced by LSIF uploads.
+func (s *Store) GetRegistryDependencyRepos(ctx context.Context, filter GetRegistryDependencyReposOpts) (repos []RegistryDependencyRepo, err error) {
+	ctx, endObservation := s.operations.getRegistryDependencies.With(ctx, &err, observation.Args{LogFields: []log.Field{
+		log.String("scheme", filter.Scheme),
+		log.Int("after", filter.After),
+		log.Int("limit", filter.Limit),
+		log.Lazy(func(l log.Encoder) {
+			l.EmitInt("results", len(repos))
+		}),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	conds := make([]*sqlf.Query, 0, 3)
+	conds = append(conds, sqlf.Sprintf("scheme = %s", filter.Scheme))
+
+	if filter.After > 0 {
+		conds = append(conds, sqlf.Sprintf("id > %d", filter.After))
+	}
+
+	if filter.PackageName != "" {
+		conds = append(conds, sqlf.Sprintf("name = %s", filter.PackageName))
+	}
+
+	limit := sqlf.Sprintf("")
+	if filter.Limit != 0 {
+		limit = sqlf.Sprintf("LIMIT %s", filter.Limit)
+	}
+
+	return scanRegistryDependencyRepo(s.Query(ctx, sqlf.Sprintf(getLSIFDependencyReposQuery, sqlf.Join(conds, "AND"), limit)))
+}
+
+func scanRegistryDependencyRepo(rows *sql.Rows, queryErr error) (dependencies []RegistryDependencyRepo, err error) {
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	for rows.Next() {
+		var dep RegistryDependencyRepo
+		if err = rows.Scan(
+			&dep.ID,
+			&dep.Package,
+			&dep.Version,
+		); err != nil {
+			return nil, err
+		}
+
+		dependencies = append(dependencies, dep)
+	}
+
+	return dependencies, nil
+}
+
 const getLSIFDependencyReposQuery = `
 -- source: internal/codeintel/stores/dbstore/repos.go:GetLSIFDependencyRepos
 SELECT id, name, version FROM lsif_dependency_repos
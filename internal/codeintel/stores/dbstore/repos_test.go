@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
 )
 
@@ -26,3 +28,78 @@ func TestRepoName(t *testing.T) {
 		t.Errorf("unexpected repo name. want=%s have=%s", "github.com/foo/bar", name)
 	}
 }
+
+func TestGetNpmDependencyRepos(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	db := dbtesting.GetDB(t)
+	store := testStore(db)
+
+	if _, err := db.Exec(`
+		INSERT INTO lsif_dependency_repos (id, scheme, name, version) VALUES
+			(1, 'npm', 'react', '17.0.2'),
+			(2, 'semanticdb', 'maven/junit/junit', '4.13.2'),
+			(3, 'npm', '@types/node', '16.11.6'),
+			(4, 'npm', 'react', '16.14.0')
+	`); err != nil {
+		t.Fatalf("unexpected error inserting dependency repos: %s", err)
+	}
+
+	repos, err := store.GetNpmDependencyRepos(context.Background(), GetNpmDependencyReposOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error getting npm dependency repos: %s", err)
+	}
+	if diff := cmp.Diff([]NpmDependencyRepo{
+		{ID: 1, Package: "react", Version: "17.0.2"},
+		{ID: 3, Package: "@types/node", Version: "16.11.6"},
+		{ID: 4, Package: "react", Version: "16.14.0"},
+	}, repos); diff != "" {
+		t.Errorf("unexpected npm dependency repos (-want +got):\n%s", diff)
+	}
+
+	repos, err = store.GetNpmDependencyRepos(context.Background(), GetNpmDependencyReposOpts{PackageName: "react", After: 1})
+	if err != nil {
+		t.Fatalf("unexpected error getting npm dependency repos: %s", err)
+	}
+	if diff := cmp.Diff([]NpmDependencyRepo{{ID: 4, Package: "react", Version: "16.14.0"}}, repos); diff != "" {
+		t.Errorf("unexpected npm dependency repos (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetRegistryDependencyRepos(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	db := dbtesting.GetDB(t)
+	store := testStore(db)
+
+	if _, err := db.Exec(`
+		INSERT INTO lsif_dependency_repos (id, scheme, name, version) VALUES
+			(1, 'cargo', 'serde_json', '1.0.68'),
+			(2, 'npm', 'serde_json', '1.0.0'),
+			(3, 'pypi', 'flask', '2.0.2'),
+			(4, 'cargo', 'serde_json', '1.0.69')
+	`); err != nil {
+		t.Fatalf("unexpected error inserting dependency repos: %s", err)
+	}
+
+	repos, err := store.GetRegistryDependencyRepos(context.Background(), GetRegistryDependencyReposOpts{Scheme: "cargo"})
+	if err != nil {
+		t.Fatalf("unexpected error getting registry dependency repos: %s", err)
+	}
+	if diff := cmp.Diff([]RegistryDependencyRepo{
+		{ID: 1, Package: "serde_json", Version: "1.0.68"},
+		{ID: 4, Package: "serde_json", Version: "1.0.69"},
+	}, repos); diff != "" {
+		t.Errorf("unexpected registry dependency repos (-want +got):\n%s", diff)
+	}
+
+	repos, err = store.GetRegistryDependencyRepos(context.Background(), GetRegistryDependencyReposOpts{Scheme: "cargo", PackageName: "serde_json", After: 1})
+	if err != nil {
+		t.Fatalf("unexpected error getting registry dependency repos: %s", err)
+	}
+	if diff := cmp.Diff([]RegistryDependencyRepo{{ID: 4, Package: "serde_json", Version: "1.0.69"}}, repos); diff != "" {
+		t.Errorf("unexpected registry dependency repos (-want +got):\n%s", diff)
+	}
+}
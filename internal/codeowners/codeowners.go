@@ -0,0 +1,240 @@
+// Package codeowners parses CODEOWNERS files and resolves the owners of paths.
+//
+// The GitHub, GitLab and Bitbucket (Code Owners for Bitbucket) dialects are
+// supported. They share the basic syntax of a gitignore style pattern followed
+// by a list of owners, where the last matching rule wins. GitLab additionally
+// groups rules into sections, each of which resolves owners independently.
+// Bitbucket directives such as Check(...) are ignored.
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gobwas/glob"
+)
+
+// Paths are the locations where code hosts look for a CODEOWNERS file, in the
+// order they are tried.
+var Paths = []string{
+	"CODEOWNERS",
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	".bitbucket/CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// Rule is a single pattern and its owners.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	// Section is the lower case name of the GitLab section the rule belongs
+	// to, or empty for rules outside of a section.
+	Section string
+
+	glob glob.Glob
+}
+
+// Match reports whether the rule applies to the repository relative path.
+func (r *Rule) Match(path string) bool {
+	return r.glob.Match(strings.TrimPrefix(path, "/"))
+}
+
+// Ruleset is a parsed CODEOWNERS file.
+type Ruleset struct {
+	Rules []*Rule
+}
+
+// Parse parses the CODEOWNERS file read from r. Lines which cannot be parsed
+// are skipped, as code hosts do.
+func Parse(r io.Reader) (*Ruleset, error) {
+	var (
+		rs            Ruleset
+		section       string
+		sectionOwners = map[string][]string{}
+		scanner       = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, owners, ok := parseSectionHeader(line); ok {
+			section = strings.ToLower(name)
+			if len(owners) > 0 {
+				sectionOwners[section] = owners
+			}
+			continue
+		}
+
+		fields := splitFields(line)
+		if len(fields) == 0 || isDirective(fields[0]) {
+			continue
+		}
+
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = sectionOwners[section]
+		}
+
+		g, err := compilePattern(fields[0])
+		if err != nil {
+			// Skip invalid patterns rather than failing on the whole file.
+			continue
+		}
+		rs.Rules = append(rs.Rules, &Rule{
+			Pattern: fields[0],
+			Owners:  owners,
+			Section: section,
+			glob:    g,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading CODEOWNERS")
+	}
+	return &rs, nil
+}
+
+// Match returns the owners of the repository relative path. Within each
+// section the last matching rule wins, and the owners of all sections are
+// combined. It returns nil if the path has no owners.
+func (rs *Ruleset) Match(path string) []string {
+	if rs == nil {
+		return nil
+	}
+
+	var sections []string
+	matches := map[string]*Rule{}
+	for _, r := range rs.Rules {
+		if !r.Match(path) {
+			continue
+		}
+		if _, ok := matches[r.Section]; !ok {
+			sections = append(sections, r.Section)
+		}
+		matches[r.Section] = r
+	}
+
+	var owners []string
+	seen := map[string]struct{}{}
+	for _, s := range sections {
+		for _, o := range matches[s].Owners {
+			if _, ok := seen[o]; ok {
+				continue
+			}
+			seen[o] = struct{}{}
+			owners = append(owners, o)
+		}
+	}
+	return owners
+}
+
+// OwnerMatches reports whether owner, as written in a CODEOWNERS file, refers
+// to query. The comparison ignores case and leading @ characters, and a team
+// query without an organization matches the team in any organization.
+func OwnerMatches(owner, query string) bool {
+	owner = strings.ToLower(strings.TrimLeft(owner, "@"))
+	query = strings.ToLower(strings.TrimLeft(query, "@"))
+	if owner == query {
+		return true
+	}
+	return !strings.Contains(query, "/") && strings.HasSuffix(owner, "/"+query)
+}
+
+// parseSectionHeader parses a GitLab section header of the form
+// [Name], ^[Name] or [Name][2], optionally followed by default owners.
+func parseSectionHeader(line string) (name string, owners []string, ok bool) {
+	line = strings.TrimPrefix(line, "^")
+	if !strings.HasPrefix(line, "[") {
+		return "", nil, false
+	}
+	end := strings.Index(line, "]")
+	if end < 0 {
+		return "", nil, false
+	}
+	name = strings.TrimSpace(line[1:end])
+	rest := line[end+1:]
+	// Skip the number of required approvals.
+	if strings.HasPrefix(rest, "[") {
+		if i := strings.Index(rest, "]"); i >= 0 {
+			rest = rest[i+1:]
+		}
+	}
+	return name, splitFields(rest), true
+}
+
+// isDirective reports whether the first field of a line is a Bitbucket
+// directive, such as Check(@team >= 2), rather than a pattern.
+func isDirective(field string) bool {
+	return strings.HasPrefix(field, "CODEOWNERS.") || strings.Contains(field, "(")
+}
+
+// splitFields splits a line at unescaped whitespace and drops a trailing
+// comment. Escaped characters are unescaped.
+func splitFields(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+		escaped bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			fields = append(fields, current.String())
+			current.Reset()
+		}
+	}
+	for _, c := range line {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '#' && current.Len() == 0:
+			flush()
+			return fields
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+	return fields
+}
+
+// compilePattern compiles a gitignore style pattern. A pattern matches a path
+// if it matches the path itself or any of its parent directories. Patterns
+// without a slash, except for a trailing one, match at any depth.
+func compilePattern(pattern string) (glob.Glob, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	p := strings.NewReplacer("{", `\{`, "}", `\}`, ",", `\,`).Replace(trimmed)
+	prefixes := []string{p}
+	if p == "" {
+		prefixes = []string{"**"}
+	} else if !anchored {
+		prefixes = append(prefixes, "**/"+p)
+	}
+	suffixes := []string{"", "/**"}
+	if dirOnly {
+		suffixes = suffixes[1:]
+	}
+
+	// The combinations are listed as a flat alternation since the glob
+	// package does not handle empty alternatives in concatenated groups.
+	var alternatives []string
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			alternatives = append(alternatives, prefix+suffix)
+		}
+	}
+	p = "{" + strings.Join(alternatives, ",") + "}"
+	return glob.Compile(p, '/')
+}
@@ -0,0 +1,110 @@
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRulesetMatch(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		path string
+		want []string
+	}{{
+		name: "last match wins",
+		file: "*.go @gophers\n/cmd/ @cmd-team\n",
+		path: "cmd/main.go",
+		want: []string{"@cmd-team"},
+	}, {
+		name: "unanchored pattern matches at any depth",
+		file: "*.go @gophers\n",
+		path: "internal/foo/bar.go",
+		want: []string{"@gophers"},
+	}, {
+		name: "anchored pattern",
+		file: "/docs @writers\n",
+		path: "internal/docs/README.md",
+		want: nil,
+	}, {
+		name: "pattern with slash is anchored",
+		file: "internal/search/ @org/search\n",
+		path: "internal/search/query/parser.go",
+		want: []string{"@org/search"},
+	}, {
+		name: "directory pattern does not match file",
+		file: "docs/ @writers\n",
+		path: "docs",
+		want: nil,
+	}, {
+		name: "double star",
+		file: "**/testdata/** @testers\n",
+		path: "a/b/testdata/c/d.txt",
+		want: []string{"@testers"},
+	}, {
+		name: "comments and multiple owners",
+		file: "# Owners\n*.md @a user@example.com # trailing\n",
+		path: "README.md",
+		want: []string{"@a", "user@example.com"},
+	}, {
+		name: "rule without owners removes ownership",
+		file: "* @everyone\n/vendor/\n",
+		path: "vendor/lib.go",
+		want: nil,
+	}, {
+		name: "escaped space",
+		file: `/my\ file.txt @a` + "\n",
+		path: "my file.txt",
+		want: []string{"@a"},
+	}, {
+		name: "gitlab sections combine owners",
+		file: "* @default\n[Docs]\n*.md @writers\n[Backend][2] @backend\n*.md\n",
+		path: "api/README.md",
+		want: []string{"@default", "@writers", "@backend"},
+	}, {
+		name: "gitlab section names are case insensitive",
+		file: "[Docs]\n*.md @a\n^[docs]\n/README.md @b\n",
+		path: "README.md",
+		want: []string{"@b"},
+	}, {
+		name: "bitbucket directives are ignored",
+		file: "CODEOWNERS.destination_branch_pattern main\nCheck(@payments >= 2)\n/pay/ @payments\n",
+		path: "pay/charge.go",
+		want: []string{"@payments"},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rs, err := Parse(strings.NewReader(c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, rs.Match(c.path)); diff != "" {
+				t.Fatalf("unexpected owners (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOwnerMatches(t *testing.T) {
+	cases := []struct {
+		owner, query string
+		want         bool
+	}{
+		{"@team-payments", "@team-payments", true},
+		{"@team-payments", "team-payments", true},
+		{"@Org/Team-Payments", "@team-payments", true},
+		{"@org/team-payments", "org/team-payments", true},
+		{"@org/team-payments", "other/team-payments", false},
+		{"@team-payments", "payments", false},
+		{"alice@example.com", "alice@example.com", true},
+	}
+
+	for _, c := range cases {
+		if got := OwnerMatches(c.owner, c.query); got != c.want {
+			t.Errorf("OwnerMatches(%q, %q) = %v, want %v", c.owner, c.query, got, c.want)
+		}
+	}
+}
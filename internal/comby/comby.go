@@ -59,6 +59,8 @@ func rawArgs(args Args) (rawArgs []string) {
 		rawArgs = append(rawArgs, "-zip", string(i))
 	case DirPath:
 		rawArgs = append(rawArgs, "-directory", string(i))
+	case FileContent:
+		rawArgs = append(rawArgs, "-stdin")
 	default:
 		log15.Error("unrecognized input type", "type", i)
 		panic("unreachable")
@@ -125,6 +127,10 @@ func PipeTo(ctx context.Context, args Args, w io.Writer) (err error) {
 	// Ensure forked child processes are killed
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
+	if content, ok := args.Input.(FileContent); ok {
+		cmd.Stdin = bytes.NewReader(content)
+	}
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		log15.Error("could not connect to comby command stdout", "error", err.Error())
@@ -7,8 +7,13 @@ type Input interface {
 type ZipPath string
 type DirPath string
 
-func (ZipPath) Value() {}
-func (DirPath) Value() {}
+// FileContent is the content of a single file, which is passed to comby on
+// stdin.
+type FileContent []byte
+
+func (ZipPath) Value()     {}
+func (DirPath) Value()     {}
+func (FileContent) Value() {}
 
 type Args struct {
 	// An Input to process (either a path to a directory or zip file)
@@ -51,8 +56,16 @@ type Range struct {
 
 // Match represents a range of matched characters and the matched content
 type Match struct {
-	Range   Range  `json:"range"`
-	Matched string `json:"matched"`
+	Range       Range         `json:"range"`
+	Environment []Environment `json:"environment"`
+	Matched     string        `json:"matched"`
+}
+
+// Environment is the value bound to a hole (e.g., :[x]) in a match template
+type Environment struct {
+	Variable string `json:"variable"`
+	Value    string `json:"value"`
+	Range    Range  `json:"range"`
 }
 
 // FileMatch represents all the matches in a single file
@@ -3,8 +3,16 @@ package compute
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/comby"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 type Output struct {
@@ -17,6 +25,82 @@ func (c *Output) String() string {
 	return fmt.Sprintf("Output with separator: (%s) -> (%s) separator: %s", c.MatchPattern.String(), c.OutputPattern, c.Separator)
 }
 
-func (c *Output) Run(context.Context, *result.FileMatch) (Result, error) {
-	return nil, nil
+// regexpEnvironment returns the values of the capture groups of a single
+// regexp match, keyed by both their index and name (if any). The whole match
+// is bound to $0.
+func regexpEnvironment(m []int, namedGroups []string, lineValue string) map[string]string {
+	env := make(map[string]string, len(m)/2)
+	for j := 0; j < len(m); j += 2 {
+		start, end := m[j], m[j+1]
+		if start == -1 || end == -1 {
+			// The capture group did not participate in the match.
+			continue
+		}
+		value := lineValue[start:end]
+		env[strconv.Itoa(j/2)] = value
+		if namedGroups[j/2] != "" {
+			env[namedGroups[j/2]] = value
+		}
+	}
+	return env
+}
+
+func outputRegexp(fm *result.FileMatch, r *regexp.Regexp, outputPattern string) []string {
+	var values []string
+	for _, l := range fm.LineMatches {
+		for _, m := range r.FindAllStringSubmatchIndex(l.Preview, -1) {
+			env := builtinEnvironment(fm, int(l.LineNumber))
+			// Capture groups take precedence over builtin variables.
+			for k, v := range regexpEnvironment(m, r.SubexpNames(), l.Preview) {
+				env[k] = v
+			}
+			values = append(values, substitute(outputPattern, env))
+		}
+	}
+	return values
+}
+
+func outputComby(fm *result.FileMatch, matches []comby.FileMatch, outputPattern string) []string {
+	var values []string
+	for _, fileMatch := range matches {
+		for _, m := range fileMatch.Matches {
+			// comby line numbers are 1-based.
+			env := builtinEnvironment(fm, m.Range.Start.Line-1)
+			// Holes take precedence over builtin variables.
+			for _, e := range m.Environment {
+				env[e.Variable] = e.Value
+			}
+			values = append(values, substitute(outputPattern, env))
+		}
+	}
+	return values
+}
+
+func (c *Output) Run(ctx context.Context, fm *result.FileMatch) (Result, error) {
+	var values []string
+	switch p := c.MatchPattern.(type) {
+	case *Regexp:
+		values = outputRegexp(fm, p.Value, c.OutputPattern)
+	case *Comby:
+		content, err := git.ReadFile(ctx, fm.Repo.Name, fm.CommitID, fm.Path, 0)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := comby.Matches(ctx, comby.Args{
+			Input:         comby.FileContent(content),
+			MatchTemplate: p.Value,
+			Matcher:       filepath.Ext(fm.Path),
+		})
+		if err != nil {
+			return nil, err
+		}
+		values = outputComby(fm, matches, c.OutputPattern)
+	default:
+		return nil, errors.Errorf("unsupported output operation for %T", p)
+	}
+	if len(values) == 0 {
+		// Nothing to output for this file.
+		return nil, nil
+	}
+	return &Text{Value: strings.Join(values, c.Separator), Kind: "output"}, nil
 }
@@ -0,0 +1,102 @@
+package compute
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/sourcegraph/internal/comby"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func Test_substitute(t *testing.T) {
+	env := map[string]string{
+		"1":    "one",
+		"name": "value",
+		"repo": "github.com/sourcegraph/sourcegraph",
+	}
+	test := func(template string) string {
+		return substitute(template, env)
+	}
+
+	autogold.Want("dollar variables", "one value github.com/sourcegraph/sourcegraph").Equal(t, test("$1 $name $repo"))
+	autogold.Want("braced variables", "onex valuey").Equal(t, test("${1}x ${name}y"))
+	autogold.Want("comby holes", "value-one").Equal(t, test(":[name]-:[1]"))
+	autogold.Want("unknown variables are kept", "$unknown :[unknown] $").Equal(t, test("$unknown :[unknown] $"))
+	autogold.Want("values are not expanded again", "$name").Equal(t, substitute("$x", map[string]string{"x": "$name", "name": "value"}))
+}
+
+func Test_output(t *testing.T) {
+	data := &result.FileMatch{
+		File: result.File{
+			Repo:     types.RepoName{Name: "github.com/sourcegraph/sourcegraph"},
+			CommitID: "deadbeef",
+			Path:     "go.mod",
+		},
+		LineMatches: []*result.LineMatch{
+			{
+				Preview:    "	github.com/foo/bar v1.2.3",
+				LineNumber: 4,
+			},
+			{
+				Preview:    "	github.com/foo/baz v0.1.0 // indirect",
+				LineNumber: 5,
+			},
+		},
+	}
+
+	test := func(matchPattern, outputPattern string) string {
+		cmd := &Output{
+			MatchPattern:  &Regexp{Value: regexp.MustCompile(matchPattern)},
+			OutputPattern: outputPattern,
+			Separator:     "\n",
+		}
+		r, err := cmd.Run(context.Background(), data)
+		if err != nil {
+			return err.Error()
+		}
+		if r == nil {
+			return "<nil>"
+		}
+		return r.(*Text).Value
+	}
+
+	autogold.Want("capture groups", "bar@1.2.3\nbaz@0.1.0").
+		Equal(t, test(`foo/(\w+) v(\S+)`, "$1@$2"))
+
+	autogold.Want("whole match", "foo/bar\nfoo/baz").
+		Equal(t, test(`foo/\w+`, "$0"))
+
+	autogold.Want("named capture groups", "bar@1.2.3\nbaz@0.1.0").
+		Equal(t, test(`foo/(?P<name>\w+) v(?P<version>\S+)`, "${name}@${version}"))
+
+	autogold.Want("builtin variables", "github.com/sourcegraph/sourcegraph@deadbeef go.mod:5 bar\ngithub.com/sourcegraph/sourcegraph@deadbeef go.mod:6 baz").
+		Equal(t, test(`foo/(\w+)`, "$repo@$commit $path:$line $1"))
+
+	autogold.Want("capture groups shadow builtin variables", "bar\nbaz").
+		Equal(t, test(`foo/(?P<repo>\w+)`, "$repo"))
+
+	autogold.Want("no match", "<nil>").
+		Equal(t, test(`nothing`, "$0"))
+}
+
+func Test_outputComby(t *testing.T) {
+	fm := &result.FileMatch{
+		File: result.File{
+			Repo: types.RepoName{Name: "github.com/sourcegraph/sourcegraph"},
+			Path: "main.go",
+		},
+	}
+	matches := []comby.FileMatch{{
+		Matches: []comby.Match{{
+			Range:       comby.Range{Start: comby.Location{Line: 3}},
+			Environment: []comby.Environment{{Variable: "args", Value: `"hello"`}},
+			Matched:     `fmt.Println("hello")`,
+		}},
+	}}
+
+	autogold.Want("comby holes and builtin variables", []string{`main.go:3 "hello"`}).
+		Equal(t, outputComby(fm, matches, "$path:$line :[args]"))
+}
@@ -25,6 +25,7 @@ func (q Query) String() string {
 
 func (q Query) ToSearchQuery() (string, error) {
 	var searchPattern string
+	parameters := q.Parameters
 	switch c := q.Command.(type) {
 	case *MatchOnly:
 		searchPattern = c.MatchPattern.String()
@@ -32,11 +33,18 @@ func (q Query) ToSearchQuery() (string, error) {
 		searchPattern = c.MatchPattern.String()
 	case *Output:
 		searchPattern = c.MatchPattern.String()
+		if _, ok := c.MatchPattern.(*Comby); ok {
+			// Don't modify the parameters of the compute query.
+			parameters = append(parameters[:len(parameters):len(parameters)], query.Parameter{
+				Field: query.FieldPatternType,
+				Value: "structural",
+			})
+		}
 	default:
 		return "", errors.Errorf("unsupported query conversion for compute command %T", c)
 	}
 	basic := query.Basic{
-		Parameters: q.Parameters,
+		Parameters: parameters,
 		Pattern:    query.Pattern{Value: searchPattern},
 	}
 	return basic.StringHuman(), nil
@@ -104,8 +112,9 @@ func toRegexpPattern(value string) (*Regexp, error) {
 
 var ComputePredicateRegistry = query.PredicateRegistry{
 	query.FieldContent: {
-		"replace": func() query.Predicate { return query.EmptyPredicate{} },
-		"output":  func() query.Predicate { return query.EmptyPredicate{} },
+		"replace":           func() query.Predicate { return query.EmptyPredicate{} },
+		"output":            func() query.Predicate { return query.EmptyPredicate{} },
+		"output.structural": func() query.Predicate { return query.EmptyPredicate{} },
 	},
 }
 
@@ -120,7 +129,10 @@ func parseReplace(pattern *query.Pattern) (Command, bool, error) {
 	if !ok {
 		return nil, false, nil
 	}
-	_, args := query.ParseAsPredicate(value)
+	name, args := query.ParseAsPredicate(value)
+	if name != "replace" {
+		return nil, false, nil
+	}
 	parts := arrowSyntax.Split(args, 2)
 	if len(parts) != 2 {
 		return nil, false, errors.New("invalid replace statement, no left and right hand sides of `->`")
@@ -133,7 +145,37 @@ func parseReplace(pattern *query.Pattern) (Command, bool, error) {
 }
 
 func parseOutput(pattern *query.Pattern) (Command, bool, error) {
-	return nil, false, nil
+	if !pattern.Annotation.Labels.IsSet(query.IsAlias) {
+		// pattern is not set via `content:`, so it cannot be an output command.
+		return nil, false, nil
+	}
+	value, _, ok := query.ScanPredicate("content", []byte(pattern.Value), ComputePredicateRegistry)
+	if !ok {
+		return nil, false, nil
+	}
+	name, args := query.ParseAsPredicate(value)
+	if name != "output" && name != "output.structural" {
+		return nil, false, nil
+	}
+	parts := arrowSyntax.Split(args, 2)
+	if len(parts) != 2 {
+		return nil, false, errors.New("invalid output statement, no left and right hand sides of `->`")
+	}
+
+	var matchPattern MatchPattern
+	if name == "output.structural" {
+		if parts[0] == "" {
+			return nil, false, errors.New("output command: structural match pattern must not be empty")
+		}
+		matchPattern = &Comby{Value: parts[0]}
+	} else {
+		rp, err := toRegexpPattern(parts[0])
+		if err != nil {
+			return nil, false, errors.Wrap(err, "output command")
+		}
+		matchPattern = rp
+	}
+	return &Output{MatchPattern: matchPattern, OutputPattern: parts[1], Separator: "\n"}, true, nil
 }
 
 func parseMatchOnly(pattern *query.Pattern) (Command, bool, error) {
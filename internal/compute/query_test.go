@@ -46,6 +46,18 @@ func TestParse(t *testing.T) {
 	autogold.Want("replace no left hand side",
 		"Command: `Replace in place: () -> (b)`").
 		Equal(t, test("content:replace(->b)"))
+
+	autogold.Want("output",
+		"Command: `Output with separator: (v(\\d+)) -> (version $1) separator: \n`").
+		Equal(t, test("content:output(v(\\d+) -> version $1)"))
+
+	autogold.Want("output structural",
+		"Command: `Output with separator: (fmt.Println(:[args])) -> (:[args]) separator: \n`").
+		Equal(t, test("content:output.structural(fmt.Println(:[args]) -> :[args])"))
+
+	autogold.Want("output no right hand side",
+		"invalid output statement, no left and right hand sides of `->`").
+		Equal(t, test("content:output(a)"))
 }
 
 func TestToSearchQuery(t *testing.T) {
@@ -65,4 +77,12 @@ func TestToSearchQuery(t *testing.T) {
 	autogold.Want("convert replace-in-place to search query",
 		"repo:foo file:bar colarado").
 		Equal(t, test("content:replace(colarado -> colorodo) repo:foo file:bar"))
+
+	autogold.Want("convert output to search query",
+		"repo:foo version").
+		Equal(t, test("content:output(version -> $repo) repo:foo"))
+
+	autogold.Want("convert structural output to search query",
+		"repo:foo patterntype:structural fmt.Println(:[args])").
+		Equal(t, test("content:output.structural(fmt.Println(:[args]) -> :[args]) repo:foo"))
 }
@@ -0,0 +1,50 @@
+package compute
+
+import (
+	"strconv"
+
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// Template variables that are available in output patterns, in addition to
+// the regular expression capture groups or comby holes of a match.
+const (
+	varRepo   = "repo"
+	varPath   = "path"
+	varCommit = "commit"
+	varLine   = "line"
+)
+
+// templateVariable matches $name and ${name} style variables, where numeric
+// names refer to regular expression capture groups, as well as comby holes
+// like :[name].
+var templateVariable = lazyregexp.New(`\$(?:(\w+)|\{(\w+)\})|:\[(\w+)\]`)
+
+// builtinEnvironment returns the template variables that describe where a
+// match was found. lineNumber is 0-based, but rendered 1-based.
+func builtinEnvironment(fm *result.FileMatch, lineNumber int) map[string]string {
+	return map[string]string{
+		varRepo:   string(fm.Repo.Name),
+		varPath:   fm.Path,
+		varCommit: string(fm.CommitID),
+		varLine:   strconv.Itoa(lineNumber + 1),
+	}
+}
+
+// substitute renders template by replacing its variables with their values in
+// env. Variables that are not in env are left untouched, so that literal
+// dollar signs or brackets in the template survive.
+func substitute(template string, env map[string]string) string {
+	return templateVariable.ReplaceAllStringFunc(template, func(match string) string {
+		for _, name := range templateVariable.FindStringSubmatch(match)[1:] {
+			if name == "" {
+				continue
+			}
+			if value, ok := env[name]; ok {
+				return value
+			}
+		}
+		return match
+	})
+}
@@ -0,0 +1,126 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// npmPackageNamePattern matches the names of npm packages, with an optional
+// scope. Legacy packages may contain uppercase letters, so they are allowed.
+// The pattern is stricter than npm itself because names end up in repository
+// names, URL paths and directory names.
+var npmPackageNamePattern = lazyregexp.New(`^(?:@([a-zA-Z0-9~-][a-zA-Z0-9._~-]*)/)?([a-zA-Z0-9~-][a-zA-Z0-9._~-]*)$`)
+
+// npmVersionPattern matches the versions of npm packages, which are semantic
+// versions with optional prerelease and build metadata parts.
+var npmVersionPattern = lazyregexp.New(`^[0-9A-Za-z][0-9A-Za-z.+-]*$`)
+
+// NpmPackage is an npm package, identified by its optional scope (without
+// the leading '@') and name.
+type NpmPackage struct {
+	Scope string
+	Name  string
+}
+
+// ParseNpmPackage parses the name of an npm package, such as "react" or
+// "@types/node".
+func ParseNpmPackage(name string) (NpmPackage, error) {
+	match := npmPackageNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return NpmPackage{}, fmt.Errorf("invalid npm package name %q", name)
+	}
+	return NpmPackage{Scope: match[1], Name: match[2]}, nil
+}
+
+// ParseNpmPackageFromRepoURL returns the npm package for the given URL path,
+// without a leading `/`. It is the inverse of RepoName.
+func ParseNpmPackageFromRepoURL(urlPath string) (NpmPackage, error) {
+	if !strings.HasPrefix(urlPath, "npm/") {
+		return NpmPackage{}, fmt.Errorf("failed to parse an npm package from the path %s", urlPath)
+	}
+	name := strings.TrimPrefix(urlPath, "npm/")
+	if strings.Contains(name, "/") {
+		name = "@" + name
+	}
+	return ParseNpmPackage(name)
+}
+
+// PackageSyntax returns the name of the package as it's used by npm, for
+// example "@types/node".
+func (p *NpmPackage) PackageSyntax() string {
+	if p.Scope == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("@%s/%s", p.Scope, p.Name)
+}
+
+// RepoName returns the name of the repository for the package. The '@' of
+// scoped packages is dropped because Sourcegraph uses it to separate
+// repository names from revisions.
+func (p *NpmPackage) RepoName() api.RepoName {
+	if p.Scope == "" {
+		return api.RepoName("npm/" + p.Name)
+	}
+	return api.RepoName(fmt.Sprintf("npm/%s/%s", p.Scope, p.Name))
+}
+
+func (p *NpmPackage) CloneURL() string {
+	cloneURL := url.URL{Path: string(p.RepoName())}
+	return cloneURL.String()
+}
+
+// NpmDependency is a version of an npm package.
+type NpmDependency struct {
+	NpmPackage
+	Version string
+}
+
+// ParseNpmDependency parses a dependency string of the form
+// "(@scope/)?name@version" into an NpmDependency.
+func ParseNpmDependency(dependency string) (NpmDependency, error) {
+	// The first character is skipped so that the '@' of a scope isn't
+	// mistaken for the version separator.
+	i := strings.LastIndex(dependency, "@")
+	if i <= 0 {
+		return NpmDependency{}, fmt.Errorf("dependency %q must be of the form (@scope/)?name@version", dependency)
+	}
+
+	pkg, err := ParseNpmPackage(dependency[:i])
+	if err != nil {
+		return NpmDependency{}, err
+	}
+
+	version := dependency[i+1:]
+	if !npmVersionPattern.MatchString(version) {
+		return NpmDependency{}, fmt.Errorf("invalid version %q in dependency %q", version, dependency)
+	}
+
+	return NpmDependency{NpmPackage: pkg, Version: version}, nil
+}
+
+// PackageManagerSyntax returns the dependency in the syntax used by npm, for
+// example "@types/node@16.11.6".
+func (d NpmDependency) PackageManagerSyntax() string {
+	return fmt.Sprintf("%s@%s", d.PackageSyntax(), d.Version)
+}
+
+func (d NpmDependency) GitTagFromVersion() string {
+	return "v" + d.Version
+}
+
+// SortNpmDependencies sorts the dependencies by package name and, within a
+// package, by version in descending order. The latest version of a package
+// comes first.
+func SortNpmDependencies(dependencies []NpmDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].NpmPackage == dependencies[j].NpmPackage {
+			return versionGreaterThan(dependencies[i].Version, dependencies[j].Version)
+		}
+		return dependencies[i].PackageSyntax() > dependencies[j].PackageSyntax()
+	})
+}
@@ -0,0 +1,92 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseNpmDependency(t *testing.T) {
+	for _, tc := range []struct {
+		dependency string
+		want       NpmDependency
+		repoName   api.RepoName
+	}{
+		{
+			dependency: "react@17.0.2",
+			want:       NpmDependency{NpmPackage: NpmPackage{Name: "react"}, Version: "17.0.2"},
+			repoName:   "npm/react",
+		},
+		{
+			dependency: "@types/node@16.11.6",
+			want:       NpmDependency{NpmPackage: NpmPackage{Scope: "types", Name: "node"}, Version: "16.11.6"},
+			repoName:   "npm/types/node",
+		},
+		{
+			dependency: "typescript@4.5.0-beta+build.1",
+			want:       NpmDependency{NpmPackage: NpmPackage{Name: "typescript"}, Version: "4.5.0-beta+build.1"},
+			repoName:   "npm/typescript",
+		},
+	} {
+		t.Run(tc.dependency, func(t *testing.T) {
+			have, err := ParseNpmDependency(tc.dependency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want, have)
+			assert.Equal(t, tc.dependency, have.PackageManagerSyntax())
+			assert.Equal(t, tc.repoName, have.RepoName())
+
+			pkg, err := ParseNpmPackageFromRepoURL(string(have.RepoName()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want.NpmPackage, pkg)
+		})
+	}
+
+	for _, dependency := range []string{
+		"react",
+		"@types/node",
+		"@types@1.0.0",
+		"../react@1.0.0",
+		"react@../../1.0.0",
+		"react@",
+		"@types/node/x@1.0.0",
+	} {
+		if _, err := ParseNpmDependency(dependency); err == nil {
+			t.Errorf("expected an error parsing %q", dependency)
+		}
+	}
+}
+
+func TestSortNpmDependencies(t *testing.T) {
+	dependencies := []NpmDependency{
+		parseNpmDependencyOrPanic(t, "ac@1.2.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.2.0"),
+		parseNpmDependencyOrPanic(t, "aa@1.2.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.11.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.2.0-rc.1"),
+		parseNpmDependencyOrPanic(t, "ab@1.1.0"),
+	}
+	expected := []NpmDependency{
+		parseNpmDependencyOrPanic(t, "ac@1.2.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.11.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.2.0"),
+		parseNpmDependencyOrPanic(t, "ab@1.2.0-rc.1"),
+		parseNpmDependencyOrPanic(t, "ab@1.1.0"),
+		parseNpmDependencyOrPanic(t, "aa@1.2.0"),
+	}
+	SortNpmDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
+
+func parseNpmDependencyOrPanic(t *testing.T, value string) NpmDependency {
+	dependency, err := ParseNpmDependency(value)
+	if err != nil {
+		t.Fatalf("error=%s", err)
+	}
+	return dependency
+}
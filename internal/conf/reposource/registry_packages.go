@@ -0,0 +1,185 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// packageRegistry describes how the packages of a language package registry
+// are named, both by the registry and by Sourcegraph. Registries are keyed by
+// the scheme of the monikers that LSIF indexers emit for their packages.
+type packageRegistry struct {
+	// repoPrefix is the prefix of the names of the repositories that packages
+	// of this registry are synced to.
+	repoPrefix string
+
+	// namePattern matches valid package names. The patterns are stricter than
+	// the registries themselves because names end up in repository names, URL
+	// paths and directory names.
+	namePattern *lazyregexp.Regexp
+
+	// normalizeName, if set, maps names that the registry considers equal to
+	// a single name.
+	normalizeName func(string) string
+
+	// versionSeparator separates the name from the version in the syntax of
+	// the package manager.
+	versionSeparator string
+}
+
+var packageRegistries = map[string]packageRegistry{
+	// crates.io
+	"cargo": {
+		repoPrefix:       "crates/",
+		namePattern:      lazyregexp.New(`^[a-zA-Z][a-zA-Z0-9_-]*$`),
+		versionSeparator: "@",
+	},
+	// PyPI
+	"pypi": {
+		repoPrefix:       "pypi/",
+		namePattern:      lazyregexp.New(`^[a-zA-Z0-9](?:[a-zA-Z0-9._-]*[a-zA-Z0-9])?$`),
+		normalizeName:    normalizePythonPackageName,
+		versionSeparator: "==",
+	},
+	// RubyGems
+	"gem": {
+		repoPrefix:       "rubygems/",
+		namePattern:      lazyregexp.New(`^[a-zA-Z0-9_][a-zA-Z0-9._-]*$`),
+		versionSeparator: "@",
+	},
+}
+
+// registryVersionPattern matches the versions of registry packages. It
+// allows the epochs and local versions of PyPI.
+var registryVersionPattern = lazyregexp.New(`^[0-9A-Za-z][0-9A-Za-z.+!_-]*$`)
+
+var pythonNameSeparatorPattern = lazyregexp.New(`[-_.]+`)
+
+// normalizePythonPackageName normalizes the name of a PyPI package. PyPI
+// treats names case-insensitively and does not distinguish between runs of
+// '-', '_' and '.' (PEP 503).
+func normalizePythonPackageName(name string) string {
+	return pythonNameSeparatorPattern.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// IsRegistryPackageScheme returns true if packages with the given moniker
+// scheme are synced from a package registry with RegistryPackage.
+func IsRegistryPackageScheme(scheme string) bool {
+	_, ok := packageRegistries[scheme]
+	return ok
+}
+
+// RegistryPackage is a package published to one of the package registries
+// which are synced in the same way: crates.io (scheme "cargo"), PyPI
+// ("pypi") and RubyGems ("gem").
+type RegistryPackage struct {
+	Scheme string
+	Name   string
+}
+
+// ParseRegistryPackage parses the name of a package of the registry with the
+// given scheme, such as "serde_json" for "cargo".
+func ParseRegistryPackage(scheme, name string) (RegistryPackage, error) {
+	registry, ok := packageRegistries[scheme]
+	if !ok {
+		return RegistryPackage{}, fmt.Errorf("unknown package registry scheme %q", scheme)
+	}
+	if !registry.namePattern.MatchString(name) {
+		return RegistryPackage{}, fmt.Errorf("invalid %s package name %q", scheme, name)
+	}
+	if registry.normalizeName != nil {
+		name = registry.normalizeName(name)
+	}
+	return RegistryPackage{Scheme: scheme, Name: name}, nil
+}
+
+// ParseRegistryPackageFromRepoURL returns the registry package for the given
+// URL path, without a leading `/`. It is the inverse of RepoName.
+func ParseRegistryPackageFromRepoURL(urlPath string) (RegistryPackage, error) {
+	for scheme, registry := range packageRegistries {
+		if strings.HasPrefix(urlPath, registry.repoPrefix) {
+			return ParseRegistryPackage(scheme, strings.TrimPrefix(urlPath, registry.repoPrefix))
+		}
+	}
+	return RegistryPackage{}, fmt.Errorf("failed to parse a registry package from the path %s", urlPath)
+}
+
+// PackageSyntax returns the name of the package as it's used by the
+// registry.
+func (p RegistryPackage) PackageSyntax() string {
+	return p.Name
+}
+
+// RepoName returns the name of the repository for the package, for example
+// "crates/serde_json".
+func (p RegistryPackage) RepoName() api.RepoName {
+	return api.RepoName(packageRegistries[p.Scheme].repoPrefix + p.Name)
+}
+
+func (p RegistryPackage) CloneURL() string {
+	cloneURL := url.URL{Path: string(p.RepoName())}
+	return cloneURL.String()
+}
+
+// RegistryDependency is a version of a registry package.
+type RegistryDependency struct {
+	RegistryPackage
+	Version string
+}
+
+// ParseRegistryDependency parses a dependency string in the syntax of the
+// package manager of the registry with the given scheme, for example
+// "serde_json@1.0.68" for "cargo" or "Flask==2.0.2" for "pypi".
+func ParseRegistryDependency(scheme, dependency string) (RegistryDependency, error) {
+	registry, ok := packageRegistries[scheme]
+	if !ok {
+		return RegistryDependency{}, fmt.Errorf("unknown package registry scheme %q", scheme)
+	}
+
+	i := strings.Index(dependency, registry.versionSeparator)
+	if i < 0 {
+		return RegistryDependency{}, fmt.Errorf("dependency %q must be of the form name%sversion", dependency, registry.versionSeparator)
+	}
+
+	pkg, err := ParseRegistryPackage(scheme, dependency[:i])
+	if err != nil {
+		return RegistryDependency{}, err
+	}
+
+	version := dependency[i+len(registry.versionSeparator):]
+	if !registryVersionPattern.MatchString(version) {
+		return RegistryDependency{}, fmt.Errorf("invalid version %q in dependency %q", version, dependency)
+	}
+
+	return RegistryDependency{RegistryPackage: pkg, Version: version}, nil
+}
+
+// PackageManagerSyntax returns the dependency in the syntax used by the
+// package manager of the registry, for example "serde_json@1.0.68".
+func (d RegistryDependency) PackageManagerSyntax() string {
+	return d.Name + packageRegistries[d.Scheme].versionSeparator + d.Version
+}
+
+func (d RegistryDependency) GitTagFromVersion() string {
+	return "v" + d.Version
+}
+
+// SortRegistryDependencies sorts the dependencies by package and, within a
+// package, by version in descending order. The latest version of a package
+// comes first.
+func SortRegistryDependencies(dependencies []RegistryDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].RegistryPackage == dependencies[j].RegistryPackage {
+			return versionGreaterThan(dependencies[i].Version, dependencies[j].Version)
+		}
+		if dependencies[i].Scheme != dependencies[j].Scheme {
+			return dependencies[i].Scheme > dependencies[j].Scheme
+		}
+		return dependencies[i].Name > dependencies[j].Name
+	})
+}
@@ -0,0 +1,106 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseRegistryDependency(t *testing.T) {
+	for _, tc := range []struct {
+		scheme     string
+		dependency string
+		want       RegistryDependency
+		syntax     string
+		repoName   api.RepoName
+	}{
+		{
+			scheme:     "cargo",
+			dependency: "serde_json@1.0.68",
+			want:       RegistryDependency{RegistryPackage: RegistryPackage{Scheme: "cargo", Name: "serde_json"}, Version: "1.0.68"},
+			syntax:     "serde_json@1.0.68",
+			repoName:   "crates/serde_json",
+		},
+		{
+			scheme:     "pypi",
+			dependency: "Flask_SQLAlchemy==2.5.1",
+			want:       RegistryDependency{RegistryPackage: RegistryPackage{Scheme: "pypi", Name: "flask-sqlalchemy"}, Version: "2.5.1"},
+			syntax:     "flask-sqlalchemy==2.5.1",
+			repoName:   "pypi/flask-sqlalchemy",
+		},
+		{
+			scheme:     "pypi",
+			dependency: "requests==1!2.26.0+local",
+			want:       RegistryDependency{RegistryPackage: RegistryPackage{Scheme: "pypi", Name: "requests"}, Version: "1!2.26.0+local"},
+			syntax:     "requests==1!2.26.0+local",
+			repoName:   "pypi/requests",
+		},
+		{
+			scheme:     "gem",
+			dependency: "rails@6.1.4",
+			want:       RegistryDependency{RegistryPackage: RegistryPackage{Scheme: "gem", Name: "rails"}, Version: "6.1.4"},
+			syntax:     "rails@6.1.4",
+			repoName:   "rubygems/rails",
+		},
+	} {
+		t.Run(tc.dependency, func(t *testing.T) {
+			have, err := ParseRegistryDependency(tc.scheme, tc.dependency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want, have)
+			assert.Equal(t, tc.syntax, have.PackageManagerSyntax())
+			assert.Equal(t, tc.repoName, have.RepoName())
+
+			pkg, err := ParseRegistryPackageFromRepoURL(string(have.RepoName()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want.RegistryPackage, pkg)
+		})
+	}
+
+	for _, tc := range []struct{ scheme, dependency string }{
+		{"cargo", "serde_json"},
+		{"cargo", "serde_json==1.0.68"},
+		{"cargo", "../serde_json@1.0.68"},
+		{"cargo", "serde_json@../1.0.68"},
+		{"pypi", "flask@2.0.2"},
+		{"pypi", "flask=="},
+		{"gem", "rails/x@6.1.4"},
+		{"npm", "react@17.0.2"},
+	} {
+		if _, err := ParseRegistryDependency(tc.scheme, tc.dependency); err == nil {
+			t.Errorf("expected an error parsing %q", tc.dependency)
+		}
+	}
+}
+
+func TestSortRegistryDependencies(t *testing.T) {
+	dependencies := []RegistryDependency{
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.2.0"),
+		parseRegistryDependencyOrPanic(t, "gem", "ab@1.2.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.11.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "aa@1.2.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.2.0-rc.1"),
+	}
+	expected := []RegistryDependency{
+		parseRegistryDependencyOrPanic(t, "gem", "ab@1.2.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.11.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.2.0"),
+		parseRegistryDependencyOrPanic(t, "cargo", "ab@1.2.0-rc.1"),
+		parseRegistryDependencyOrPanic(t, "cargo", "aa@1.2.0"),
+	}
+	SortRegistryDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
+
+func parseRegistryDependencyOrPanic(t *testing.T, scheme, value string) RegistryDependency {
+	dependency, err := ParseRegistryDependency(scheme, value)
+	if err != nil {
+		t.Fatalf("error=%s", err)
+	}
+	return dependency
+}
@@ -198,12 +198,40 @@ SELECT
        last_error,
        last_fetched,
        last_changed,
+       last_backup_at,
+       last_backup_error,
        updated_at
 FROM gitserver_repos
 WHERE repo_id = %s
 `
 
-	row := s.QueryRow(ctx, sqlf.Sprintf(q, id))
+	return scanGitserverRepo(s.QueryRow(ctx, sqlf.Sprintf(q, id)))
+}
+
+// GetByName returns the GitserverRepo for the repo with the given name.
+func (s *GitserverRepoStore) GetByName(ctx context.Context, name api.RepoName) (*types.GitserverRepo, error) {
+	q := `
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.GetByName
+SELECT
+       gr.repo_id,
+       gr.clone_status,
+       gr.shard_id,
+       gr.last_external_service,
+       gr.last_error,
+       gr.last_fetched,
+       gr.last_changed,
+       gr.last_backup_at,
+       gr.last_backup_error,
+       gr.updated_at
+FROM gitserver_repos gr
+JOIN repo ON repo.id = gr.repo_id
+WHERE repo.name = %s
+`
+
+	return scanGitserverRepo(s.QueryRow(ctx, sqlf.Sprintf(q, name)))
+}
+
+func scanGitserverRepo(row *sql.Row) (*types.GitserverRepo, error) {
 	if row.Err() != nil {
 		return nil, errors.Wrap(row.Err(), "getting GitserverRepo")
 	}
@@ -217,6 +245,8 @@ WHERE repo_id = %s
 		&dbutil.NullString{S: &gr.LastError},
 		&dbutil.NullTime{Time: &gr.LastFetched},
 		&dbutil.NullTime{Time: &gr.LastChanged},
+		&dbutil.NullTime{Time: &gr.LastBackupAt},
+		&dbutil.NullString{S: &gr.LastBackupError},
 		&gr.UpdatedAt,
 	)
 	if err != nil {
@@ -294,6 +324,38 @@ SET (last_fetched, last_changed, shard_id, updated_at) =
 	return errors.Wrap(err, "setting last fetched")
 }
 
+// GitserverBackupData is the metadata associated with a backup of a repo on
+// gitserver.
+type GitserverBackupData struct {
+	// BackedUpAt was the time the backup completed (gitserver_repos.last_backup_at).
+	// It is only updated if Error is empty.
+	BackedUpAt time.Time
+	// Error is the error of the backup attempt or empty if it succeeded (gitserver_repos.last_backup_error).
+	Error string
+}
+
+// SetLastBackup will attempt to update ONLY the backup data of a
+// GitServerRepo. If a matching row does not yet exist a new one will be
+// created, without a shard, as only the clone status determines it.
+func (s *GitserverRepoStore) SetLastBackup(ctx context.Context, name api.RepoName, data GitserverBackupData) error {
+	var backedUpAt *time.Time
+	if data.Error == "" {
+		backedUpAt = &data.BackedUpAt
+	}
+
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetLastBackup
+INSERT INTO gitserver_repos(repo_id, last_backup_at, last_backup_error, shard_id, updated_at)
+SELECT id, %s, %s, '', now()
+FROM repo WHERE name = %s
+ON CONFLICT (repo_id) DO UPDATE
+SET (last_backup_at, last_backup_error, updated_at) =
+    (COALESCE(EXCLUDED.last_backup_at, gitserver_repos.last_backup_at), EXCLUDED.last_backup_error, now())
+`, backedUpAt, dbutil.NewNullString(sanitizeToUTF8(data.Error)), name))
+
+	return errors.Wrap(err, "setting last backup")
+}
+
 // sanitizeToUTF8 will remove any null character terminated string. The null character can be
 // represented in one of the following ways in Go:
 //
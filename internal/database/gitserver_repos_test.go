@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -146,6 +147,16 @@ func TestGitserverReposGetByID(t *testing.T) {
 	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt")); diff != "" {
 		t.Fatal(diff)
 	}
+
+	// GetByName should return the same row
+	fromDB, err = GitserverRepos(db).GetByName(ctx, repo1.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt")); diff != "" {
+		t.Fatal(diff)
+	}
 }
 
 func TestSetCloneStatus(t *testing.T) {
@@ -342,6 +353,90 @@ func TestSetLastError(t *testing.T) {
 	}
 }
 
+func TestSetLastBackup(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db := dbtest.NewDB(t)
+	ctx := context.Background()
+	const shardID = "test"
+
+	repo1 := &types.Repo{
+		Name:         "github.com/sourcegraph/repo1",
+		URI:          "github.com/sourcegraph/repo1",
+		ExternalRepo: api.ExternalRepoSpec{},
+	}
+
+	// Create one test repo
+	err := Repos(db).Create(ctx, repo1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitserverRepo := &types.GitserverRepo{
+		RepoID:      repo1.ID,
+		ShardID:     shardID,
+		CloneStatus: types.CloneStatusCloned,
+	}
+
+	// Create GitServerRepo
+	if err := GitserverRepos(db).Upsert(ctx, gitserverRepo); err != nil {
+		t.Fatal(err)
+	}
+
+	backedUpAt := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	err = GitserverRepos(db).SetLastBackup(ctx, repo1.Name, GitserverBackupData{
+		BackedUpAt: backedUpAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB, err := GitserverRepos(db).GetByID(ctx, gitserverRepo.RepoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitserverRepo.LastBackupAt = backedUpAt
+	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt", "LastFetched", "LastChanged")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// A failed backup keeps the time of the last successful backup.
+	err = GitserverRepos(db).SetLastBackup(ctx, repo1.Name, GitserverBackupData{
+		BackedUpAt: backedUpAt.Add(time.Hour),
+		Error:      "oops\x00",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB, err = GitserverRepos(db).GetByID(ctx, gitserverRepo.RepoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitserverRepo.LastBackupError = "oops"
+	if diff := cmp.Diff(gitserverRepo, fromDB, cmpopts.IgnoreFields(types.GitserverRepo{}, "UpdatedAt", "LastFetched", "LastChanged")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Upserting the clone status does not touch the backup columns.
+	if err := GitserverRepos(db).Upsert(ctx, gitserverRepo); err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB, err = GitserverRepos(db).GetByID(ctx, gitserverRepo.RepoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fromDB.LastBackupAt.Equal(backedUpAt) || fromDB.LastBackupError != "oops" {
+		t.Fatalf("backup data was overwritten by Upsert: %+v", fromDB)
+	}
+}
+
 func TestGitserverRepoUpsertNullShard(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
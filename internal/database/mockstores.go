@@ -7,6 +7,8 @@ type MockStores struct {
 	AccessTokens MockAccessTokens
 
 	Repos           MockRepos
+	RepoKVPs        MockRepoKVPs
+	RepoRanks       MockRepoRanks
 	Namespaces      MockNamespaces
 	Orgs            MockOrgs
 	OrgMembers      MockOrgMembers
@@ -17,6 +19,7 @@ type MockStores struct {
 	UserEmails      MockUserEmails
 	UserPublicRepos MockUserPublicRepos
 	SearchContexts  MockSearchContexts
+	SearchExports   MockSearchExports
 
 	Phabricator MockPhabricator
 
@@ -5,10 +5,13 @@ import (
 	"database/sql"
 
 	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
@@ -24,6 +27,11 @@ type ExternalServiceConfigMigrator struct {
 	store        *basestore.Store
 	BatchSize    int
 	AllowDecrypt bool
+
+	warnedUndecryptable bool
+	// undecryptable are the IDs of the external services whose config failed
+	// to decrypt, which are not selected again by Up.
+	undecryptable []int64
 }
 
 func NewExternalServiceConfigMigrator(store *basestore.Store) *ExternalServiceConfigMigrator {
@@ -42,22 +50,39 @@ func (m *ExternalServiceConfigMigrator) ID() int {
 }
 
 // Progress returns a value from 0 to 1 representing the percentage of configuration already migrated.
+// If a key is configured, configuration encrypted with another version of the key, eg: before a key
+// rotation, is not considered migrated. Configuration encrypted with a different key cannot be
+// decrypted by the current one, so it is not migrated again and doesn't count against progress.
+// The same goes for configuration which Up failed to decrypt.
 func (m *ExternalServiceConfigMigrator) Progress(ctx context.Context) (float64, error) {
+	migrated := sqlf.Sprintf("encryption_key_id != ''")
+	if key := keyring.Default().ExternalServiceKey; key != nil {
+		version, err := key.Version(ctx)
+		if err != nil {
+			return 0, err
+		}
+		migrated = sqlf.Sprintf("(NOT %s OR id = ANY(%s))", requiresExternalServiceEncryption(version), pq.Array(m.undecryptable))
+	}
+
 	progress, _, err := basestore.ScanFirstFloat(m.store.Query(ctx, sqlf.Sprintf(`
 		SELECT
 			CASE c2.count WHEN 0 THEN 1 ELSE
 				CAST(c1.count AS float) / CAST(c2.count AS float)
 			END
 		FROM
-			(SELECT COUNT(*) AS count FROM external_services WHERE encryption_key_id != '') c1,
+			(SELECT COUNT(*) AS count FROM external_services WHERE %s) c1,
 			(SELECT COUNT(*) AS count FROM external_services) c2
-	`)))
+	`, migrated)))
 	return progress, err
 }
 
 // Up loads BatchSize external services, locks them, and encrypts their config using the
 // key returned by keyring.Default().
 // If there is no ring, it will periodically try again until the key is setup in the config.
+// Configuration encrypted with another version of the key, eg: before a key rotation, is
+// decrypted and encrypted again with the current version. Configuration encrypted with a
+// different key, or which fails to decrypt, is left as is and logged. Configuration which fails to
+// decrypt is not selected again, so that it doesn't prevent the others from being migrated.
 // Up ensures the configuration can be decrypted with the same key before overwitting it.
 // The key id is stored alongside the encrypted configuration.
 func (m *ExternalServiceConfigMigrator) Up(ctx context.Context) (err error) {
@@ -66,35 +91,56 @@ func (m *ExternalServiceConfigMigrator) Up(ctx context.Context) (err error) {
 		return nil
 	}
 
+	version, err := key.Version(ctx)
+	if err != nil {
+		return err
+	}
+	keyIdent := version.JSON()
+
 	tx, err := m.store.Transact(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { err = tx.Done(err) }()
 
-	services, err := m.listConfigsForUpdate(ctx, tx, false)
+	services, err := m.listConfigsForUpdate(ctx, tx, sqlf.Sprintf(
+		"%s AND NOT id = ANY(%s)",
+		requiresExternalServiceEncryption(version),
+		pq.Array(m.undecryptable),
+	))
 	if err != nil {
 		return err
 	}
 
+	if len(services) < m.BatchSize {
+		m.warnUndecryptable(ctx, tx, version)
+	}
+
 	for _, svc := range services {
-		encryptedCfg, err := key.Encrypt(ctx, []byte(svc.Config))
-		if err != nil {
-			return err
+		config := svc.Config
+		if svc.encryptionKeyID != "" {
+			secret, err := key.Decrypt(ctx, []byte(svc.Config))
+			if err != nil {
+				// The row can't be migrated until the key that can decrypt
+				// it is configured again, which must not block the others.
+				log15.Warn("external service config migration: skipping config which cannot be decrypted", "id", svc.ID, "encryptionKeyID", svc.encryptionKeyID, "error", err)
+				m.undecryptable = append(m.undecryptable, svc.ID)
+				continue
+			}
+			config = secret.Secret()
 		}
 
-		version, err := key.Version(ctx)
+		encryptedCfg, err := key.Encrypt(ctx, []byte(config))
 		if err != nil {
 			return err
 		}
-		keyIdent := version.JSON()
 
 		// ensure encryption round-trip is valid with keyIdent
 		decrypted, err := key.Decrypt(ctx, encryptedCfg)
 		if err != nil {
 			return err
 		}
-		if decrypted.Secret() != svc.Config {
+		if decrypted.Secret() != config {
 			return errors.New("invalid encryption round-trip")
 		}
 
@@ -111,6 +157,36 @@ func (m *ExternalServiceConfigMigrator) Up(ctx context.Context) (err error) {
 	return nil
 }
 
+// requiresExternalServiceEncryption returns a predicate matching external
+// services whose config is either not encrypted or encrypted with another
+// version of the given key.
+func requiresExternalServiceEncryption(version encryption.KeyVersion) *sqlf.Query {
+	return sqlf.Sprintf("(encryption_key_id = '' OR %s)", otherKeyVersionPredicate(version))
+}
+
+// warnUndecryptable logs the number of external services whose config is
+// encrypted with a key other than the given one once per migrator, as they
+// are left as is by Up.
+func (m *ExternalServiceConfigMigrator) warnUndecryptable(ctx context.Context, tx *basestore.Store, version encryption.KeyVersion) {
+	if m.warnedUndecryptable {
+		return
+	}
+	m.warnedUndecryptable = true
+
+	count, _, err := basestore.ScanFirstInt(tx.Query(ctx, sqlf.Sprintf(
+		"SELECT COUNT(*) FROM external_services WHERE encryption_key_id != '' AND NOT %s AND encryption_key_id != %s",
+		otherKeyVersionPredicate(version),
+		version.JSON(),
+	)))
+	if err != nil {
+		log15.Error("external service config migration: failed to count configs encrypted with another key", "error", err)
+		return
+	}
+	if count > 0 {
+		log15.Warn("external service config migration: configs encrypted with another key cannot be re-encrypted with the current key", "count", count)
+	}
+}
+
 func (m *ExternalServiceConfigMigrator) Down(ctx context.Context) (err error) {
 	key := keyring.Default().ExternalServiceKey
 	if key == nil {
@@ -129,7 +205,7 @@ func (m *ExternalServiceConfigMigrator) Down(ctx context.Context) (err error) {
 	}
 	defer func() { err = tx.Done(err) }()
 
-	services, err := m.listConfigsForUpdate(ctx, tx, true)
+	services, err := m.listConfigsForUpdate(ctx, tx, sqlf.Sprintf("encryption_key_id != ''"))
 	if err != nil {
 		return err
 	}
@@ -152,31 +228,32 @@ func (m *ExternalServiceConfigMigrator) Down(ctx context.Context) (err error) {
 	return nil
 }
 
-func (m *ExternalServiceConfigMigrator) listConfigsForUpdate(ctx context.Context, tx *basestore.Store, encrypted bool) ([]*types.ExternalService, error) {
+// encryptedExternalService is an external service along with the ID of the
+// key its configuration is encrypted with.
+type encryptedExternalService struct {
+	types.ExternalService
+	encryptionKeyID string
+}
+
+func (m *ExternalServiceConfigMigrator) listConfigsForUpdate(ctx context.Context, tx *basestore.Store, cond *sqlf.Query) ([]*encryptedExternalService, error) {
 	// Select and lock a few records within this transaction. This ensures
 	// that many frontend instances can run the same migration concurrently
 	// without them all trying to convert the same record.
-	q := "SELECT id, config FROM external_services "
-	if encrypted {
-		q += "WHERE encryption_key_id != ''"
-	} else {
-		q += "WHERE encryption_key_id = ''"
-	}
-
-	q += "ORDER BY id ASC LIMIT %s FOR UPDATE SKIP LOCKED"
-
-	rows, err := tx.Query(ctx, sqlf.Sprintf(q, m.BatchSize))
-
+	rows, err := tx.Query(ctx, sqlf.Sprintf(
+		"SELECT id, config, encryption_key_id FROM external_services WHERE %s ORDER BY id ASC LIMIT %s FOR UPDATE SKIP LOCKED",
+		cond,
+		m.BatchSize,
+	))
 	if err != nil {
 		return nil, err
 	}
 	defer func() { err = basestore.CloseRows(rows, err) }()
 
-	var services []*types.ExternalService
+	var services []*encryptedExternalService
 
 	for rows.Next() {
-		var svc types.ExternalService
-		if err := rows.Scan(&svc.ID, &svc.Config); err != nil {
+		var svc encryptedExternalService
+		if err := rows.Scan(&svc.ID, &svc.Config, &svc.encryptionKeyID); err != nil {
 			return nil, err
 		}
 		services = append(services, &svc)
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/encryption"
@@ -334,6 +335,200 @@ func TestExternalServiceConfigMigrator(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("Up/KeyRotation", func(t *testing.T) {
+		db := dbtest.NewDB(t)
+
+		migrator := NewExternalServiceConfigMigratorWithDB(db)
+		migrator.BatchSize = 10
+
+		// Create 10 external services
+		svcs := types.GenerateExternalServices(10, types.MakeExternalServices()...)
+		confGet := func() *conf.Unified {
+			return &conf.Unified{}
+		}
+		for _, svc := range svcs {
+			if err := ExternalServices(db).Create(ctx, confGet, svc); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		key := &et.VersionedTestKey{Revision: "1"}
+		keyring.MockDefault(keyring.Ring{ExternalServiceKey: key})
+		defer keyring.MockDefault(keyring.Ring{})
+
+		requireProgressEqual := func(want float64) {
+			t.Helper()
+
+			got, err := migrator.Progress(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%.3f", want) != fmt.Sprintf("%.3f", got) {
+				t.Fatalf("invalid progress: want %f, got %f", want, got)
+			}
+		}
+
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		requireProgressEqual(1)
+
+		// rotating the key makes all the services require a migration again
+		key.Revision = "2"
+		requireProgressEqual(0)
+
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		requireProgressEqual(1)
+
+		// the config is encrypted only once, with the new version
+		rows, err := db.Query("SELECT config, encryption_key_id FROM external_services ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		version, _ := key.Version(ctx)
+
+		var i int
+		for rows.Next() {
+			var config, keyID string
+
+			err = rows.Scan(&config, &keyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if keyID != version.JSON() {
+				t.Fatalf("wrong encryption_key_id, want %s, got %s", version.JSON(), keyID)
+			}
+
+			secret, err := key.Decrypt(ctx, []byte(config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secret.Secret() != svcs[i].Config {
+				t.Fatalf("decrypted config is different from the original one")
+			}
+
+			i++
+		}
+		if rows.Err() != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Up/OtherKey", func(t *testing.T) {
+		db := dbtest.NewDB(t)
+
+		migrator := NewExternalServiceConfigMigratorWithDB(db)
+		migrator.BatchSize = 10
+
+		svcs := types.GenerateExternalServices(5, types.MakeExternalServices()...)
+		confGet := func() *conf.Unified {
+			return &conf.Unified{}
+		}
+		for _, svc := range svcs {
+			if err := ExternalServices(db).Create(ctx, confGet, svc); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		key := &et.VersionedTestKey{Name: "a", Revision: "1"}
+		keyring.MockDefault(keyring.Ring{ExternalServiceKey: key})
+		defer keyring.MockDefault(keyring.Ring{})
+
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		oldVersion, _ := key.Version(ctx)
+
+		// configs encrypted with another key are left as is, and don't
+		// prevent the migration from completing
+		key.Name = "b"
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		progress, err := migrator.Progress(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress != 1 {
+			t.Fatalf("invalid progress: want 1, got %f", progress)
+		}
+
+		keyIDs, err := basestore.ScanStrings(db.QueryContext(ctx, "SELECT encryption_key_id FROM external_services"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, keyID := range keyIDs {
+			if keyID != oldVersion.JSON() {
+				t.Fatalf("wrong encryption_key_id, want %s, got %s", oldVersion.JSON(), keyID)
+			}
+		}
+	})
+
+	t.Run("Up/Undecryptable", func(t *testing.T) {
+		db := dbtest.NewDB(t)
+
+		migrator := NewExternalServiceConfigMigratorWithDB(db)
+		migrator.BatchSize = 1
+
+		svcs := types.GenerateExternalServices(3, types.MakeExternalServices()...)
+		confGet := func() *conf.Unified {
+			return &conf.Unified{}
+		}
+		for _, svc := range svcs {
+			if err := ExternalServices(db).Create(ctx, confGet, svc); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		key := &et.VersionedTestKey{Revision: "1"}
+		keyring.MockDefault(keyring.Ring{ExternalServiceKey: key})
+		defer keyring.MockDefault(keyring.Ring{})
+
+		for range svcs {
+			if err := migrator.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the first config can't be decrypted anymore, which must not prevent
+		// the others from being re-encrypted after a key rotation
+		if _, err := db.ExecContext(ctx, "UPDATE external_services SET config = 'not base64!' WHERE id = $1", svcs[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		key.Revision = "2"
+
+		for range svcs {
+			if err := migrator.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		progress, err := migrator.Progress(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress != 1 {
+			t.Fatalf("invalid progress: want 1, got %f", progress)
+		}
+
+		keyIDs, err := basestore.ScanStrings(db.QueryContext(ctx, "SELECT encryption_key_id FROM external_services ORDER BY id"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oldVersion := encryption.KeyVersion{Type: "testkey", Version: "1"}
+		newVersion, _ := key.Version(ctx)
+		want := []string{oldVersion.JSON(), newVersion.JSON(), newVersion.JSON()}
+		if fmt.Sprint(keyIDs) != fmt.Sprint(want) {
+			t.Fatalf("wrong encryption_key_ids, want %v, got %v", want, keyIDs)
+		}
+	})
 }
 
 // invalidKey is an encryption.Key that just base64 encodes the plaintext,
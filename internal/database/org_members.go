@@ -86,6 +86,9 @@ func (m *OrgMemberStore) Remove(ctx context.Context, orgID, userID int32) error
 
 // GetByOrgID returns a list of all members of a given organization.
 func (m *OrgMemberStore) GetByOrgID(ctx context.Context, orgID int32) ([]*types.OrgMembership, error) {
+	if Mocks.OrgMembers.GetByOrgID != nil {
+		return Mocks.OrgMembers.GetByOrgID(ctx, orgID)
+	}
 	org, err := OrgsWith(m).GetByID(ctx, orgID)
 	if err != nil {
 		return nil, err
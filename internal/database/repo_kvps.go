@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// KeyValuePair is a custom metadata entry of a repository. Value is nil if the
+// key is set without a value.
+type KeyValuePair struct {
+	Key   string
+	Value *string
+}
+
+// RepoKVPFilter matches repositories that have the key Key. If Value is
+// non-nil, the key must also have that value.
+type RepoKVPFilter struct {
+	Key   string
+	Value *string
+}
+
+func (f RepoKVPFilter) String() string {
+	if f.Value == nil {
+		return f.Key
+	}
+	return f.Key + ":" + *f.Value
+}
+
+// RepoKVPStore is responsible for data stored in the repo_kvps table.
+type RepoKVPStore struct {
+	*basestore.Store
+}
+
+// RepoKVPs instantiates and returns a new RepoKVPStore.
+func RepoKVPs(db dbutil.DB) *RepoKVPStore {
+	return &RepoKVPStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// RepoKVPsWith instantiates and returns a new RepoKVPStore using the other
+// store handle.
+func RepoKVPsWith(other basestore.ShareableStore) *RepoKVPStore {
+	return &RepoKVPStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *RepoKVPStore) With(other basestore.ShareableStore) *RepoKVPStore {
+	return &RepoKVPStore{Store: s.Store.With(other)}
+}
+
+func (s *RepoKVPStore) Transact(ctx context.Context) (*RepoKVPStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &RepoKVPStore{Store: txBase}, err
+}
+
+// List returns the key-value pairs of the repository, ordered by key.
+func (s *RepoKVPStore) List(ctx context.Context, repoID api.RepoID) (_ []KeyValuePair, err error) {
+	if Mocks.RepoKVPs.List != nil {
+		return Mocks.RepoKVPs.List(ctx, repoID)
+	}
+
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.List
+SELECT key, value FROM repo_kvps WHERE repo_id = %s ORDER BY key
+`, repoID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var kvps []KeyValuePair
+	for rows.Next() {
+		var kvp KeyValuePair
+		if err := rows.Scan(&kvp.Key, &kvp.Value); err != nil {
+			return nil, err
+		}
+		kvps = append(kvps, kvp)
+	}
+	return kvps, rows.Err()
+}
+
+// Set sets the key of the repository to value, overwriting any previous value.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *RepoKVPStore) Set(ctx context.Context, repoID api.RepoID, key string, value *string) error {
+	if Mocks.RepoKVPs.Set != nil {
+		return Mocks.RepoKVPs.Set(ctx, repoID, key, value)
+	}
+
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Set
+INSERT INTO repo_kvps (repo_id, key, value)
+VALUES (%s, %s, %s)
+ON CONFLICT (repo_id, key) DO UPDATE SET value = excluded.value
+`, repoID, key, value))
+}
+
+// Delete removes the key from the repository. It is not an error if the key
+// is not set.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *RepoKVPStore) Delete(ctx context.Context, repoID api.RepoID, key string) error {
+	if Mocks.RepoKVPs.Delete != nil {
+		return Mocks.RepoKVPs.Delete(ctx, repoID, key)
+	}
+
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Delete
+DELETE FROM repo_kvps WHERE repo_id = %s AND key = %s
+`, repoID, key))
+}
@@ -0,0 +1,13 @@
+package database
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type MockRepoKVPs struct {
+	List   func(ctx context.Context, repoID api.RepoID) ([]KeyValuePair, error)
+	Set    func(ctx context.Context, repoID api.RepoID, key string, value *string) error
+	Delete func(ctx context.Context, repoID api.RepoID, key string) error
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// RepoRankStore is responsible for data stored in the repo_ranks table.
+type RepoRankStore struct {
+	*basestore.Store
+}
+
+// RepoRanks instantiates and returns a new RepoRankStore.
+func RepoRanks(db dbutil.DB) *RepoRankStore {
+	return &RepoRankStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// RepoRanksWith instantiates and returns a new RepoRankStore using the other
+// store handle.
+func RepoRanksWith(other basestore.ShareableStore) *RepoRankStore {
+	return &RepoRankStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *RepoRankStore) With(other basestore.ShareableStore) *RepoRankStore {
+	return &RepoRankStore{Store: s.Store.With(other)}
+}
+
+func (s *RepoRankStore) Transact(ctx context.Context) (*RepoRankStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &RepoRankStore{Store: txBase}, err
+}
+
+// Priorities returns the search priorities of the given repositories, which
+// are the same as the rank by which RepoListRank sorts them.
+func (s *RepoRankStore) Priorities(ctx context.Context, repoIDs []api.RepoID) (_ map[api.RepoID]float64, err error) {
+	if Mocks.RepoRanks.Priorities != nil {
+		return Mocks.RepoRanks.Priorities(ctx, repoIDs)
+	}
+
+	if len(repoIDs) == 0 {
+		return map[api.RepoID]float64{}, nil
+	}
+
+	ids := make([]int32, 0, len(repoIDs))
+	for _, id := range repoIDs {
+		ids = append(ids, int32(id))
+	}
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_ranks.go:RepoRankStore.Priorities
+SELECT repo.id, %s FROM repo WHERE repo.id = ANY(%s)
+`, repoPriority(conf.Get().SiteConfiguration), pq.Array(ids)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	priorities := make(map[api.RepoID]float64, len(repoIDs))
+	for rows.Next() {
+		var (
+			id       api.RepoID
+			priority float64
+		)
+		if err := rows.Scan(&id, &priority); err != nil {
+			return nil, err
+		}
+		priorities[id] = priority
+	}
+	return priorities, nil
+}
+
+// repoPriority returns the search priority of the current row of the repo
+// table. It is the score in repo_ranks plus the boost configured by site
+// admins in experimentalFeatures.ranking.repoScores. Repositories which were
+// not ranked yet are scored by their stars, as they would be ranked without
+// activity and references.
+//
+// The boost is applied here rather than stored in repo_ranks, so that
+// configuration changes do not wait for the ranks to be recomputed. The boost
+// of a repository is the sum of the scores configured for its name and for
+// every "directory" of its name, so a repository like
+// "github.com/sourcegraph/zoekt" gets the scores of "github.com",
+// "github.com/sourcegraph" and "github.com/sourcegraph/zoekt".
+func repoPriority(siteConfig schema.SiteConfiguration) *sqlf.Query {
+	var (
+		prefixes []string
+		boosts   []float64
+	)
+	if siteConfig.ExperimentalFeatures != nil && siteConfig.ExperimentalFeatures.Ranking != nil {
+		for prefix, boost := range siteConfig.ExperimentalFeatures.Ranking.RepoScores {
+			prefixes = append(prefixes, prefix)
+			boosts = append(boosts, boost)
+		}
+	}
+
+	return sqlf.Sprintf(`(
+	COALESCE((SELECT score FROM repo_ranks WHERE repo_ranks.repo_id = repo.id), repo.stars, 0) +
+	(
+		SELECT COALESCE(SUM(b.boost), 0)
+		FROM unnest(%s::text[], %s::double precision[]) AS b(prefix, boost)
+		WHERE repo.name::text = b.prefix OR left(repo.name::text, length(b.prefix) + 1) = b.prefix || '/'
+	)
+)`, pq.Array(prefixes), pq.Array(boosts))
+}
+
+// Upsert inserts or updates the ranks of the repositories.
+func (s *RepoRankStore) Upsert(ctx context.Context, ranks ...*types.RepoRank) error {
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	values := make([]*sqlf.Query, 0, len(ranks))
+	for _, r := range ranks {
+		values = append(values, sqlf.Sprintf(
+			"(%s, %s, %s, %s, %s, now())",
+			r.RepoID,
+			r.Score,
+			r.Stars,
+			r.RecentCommitCount,
+			r.InboundReferenceCount,
+		))
+	}
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_ranks.go:RepoRankStore.Upsert
+INSERT INTO repo_ranks (repo_id, score, stars, recent_commit_count, inbound_reference_count, updated_at)
+VALUES %s
+ON CONFLICT (repo_id) DO UPDATE SET
+	score = excluded.score,
+	stars = excluded.stars,
+	recent_commit_count = excluded.recent_commit_count,
+	inbound_reference_count = excluded.inbound_reference_count,
+	updated_at = excluded.updated_at
+`, sqlf.Join(values, ", ")))
+}
+
+// InboundReferenceCounts returns, for every repository which has precise code
+// intel providing packages, the number of other repositories whose precise
+// code intel references one of these packages.
+func (s *RepoRankStore) InboundReferenceCounts(ctx context.Context) (_ map[api.RepoID]int, err error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/repo_ranks.go:RepoRankStore.InboundReferenceCounts
+SELECT pu.repository_id, COUNT(DISTINCT ru.repository_id)
+FROM lsif_packages p
+JOIN lsif_uploads pu ON pu.id = p.dump_id
+JOIN lsif_references r ON r.scheme = p.scheme AND r.name = p.name AND r.version IS NOT DISTINCT FROM p.version
+JOIN lsif_uploads ru ON ru.id = r.dump_id
+WHERE
+	pu.state = 'completed' AND
+	ru.state = 'completed' AND
+	ru.repository_id != pu.repository_id
+GROUP BY pu.repository_id
+`))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	counts := map[api.RepoID]int{}
+	for rows.Next() {
+		var (
+			id    api.RepoID
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, nil
+}
@@ -0,0 +1,11 @@
+package database
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type MockRepoRanks struct {
+	Priorities func(ctx context.Context, repoIDs []api.RepoID) (map[api.RepoID]float64, error)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRepoRanks_Priorities(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db := dbtest.NewDB(t)
+	ctx := context.Background()
+
+	ranked := &types.Repo{Name: "gh.test/sg/sg", Stars: 10}
+	other := &types.Repo{Name: "gh.test/sg/ex", Stars: 20}
+	unranked := &types.Repo{Name: "gh.test/sgx", Stars: 30}
+	if err := Repos(db).Create(ctx, ranked, other, unranked); err != nil {
+		t.Fatal(err)
+	}
+	if err := RepoRanks(db).Upsert(ctx,
+		&types.RepoRank{RepoID: ranked.ID, Score: 100, Stars: 10},
+		&types.RepoRank{RepoID: other.ID, Score: 50, Stars: 20},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []api.RepoID{ranked.ID, other.ID, unranked.ID}
+	rankedNames := func() []string {
+		repos, err := Repos(db).ListRepoNames(ctx, ReposListOptions{
+			OrderBy: RepoListOrderBy{{Field: RepoListRank, Descending: true}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(repos))
+		for _, r := range repos {
+			names = append(names, string(r.Name))
+		}
+		return names
+	}
+
+	// Repositories which were not ranked yet are prioritized by their stars.
+	priorities, err := RepoRanks(db).Priorities(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[api.RepoID]float64{ranked.ID: 100, other.ID: 50, unranked.ID: 30}
+	if diff := cmp.Diff(want, priorities); diff != "" {
+		t.Fatalf("unexpected priorities (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"gh.test/sg/sg", "gh.test/sg/ex", "gh.test/sgx"}, rankedNames()); diff != "" {
+		t.Fatalf("unexpected order (-want +got):\n%s", diff)
+	}
+
+	// Boosts apply to every "directory" of the name, and take effect in
+	// both the priorities and the order of repositories.
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{
+			Ranking: &schema.Ranking{
+				RepoScores: map[string]float64{
+					"gh.test":       100,
+					"gh.test/sg":    50,
+					"gh.test/sg/sg": -120,
+				},
+			},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	priorities, err = RepoRanks(db).Priorities(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[api.RepoID]float64{ranked.ID: 130, other.ID: 200, unranked.ID: 130}
+	if diff := cmp.Diff(want, priorities); diff != "" {
+		t.Fatalf("unexpected priorities (-want +got):\n%s", diff)
+	}
+	if names := rankedNames(); names[0] != "gh.test/sg/ex" {
+		t.Fatalf("expected the boosted repository first, got %v", names)
+	}
+}
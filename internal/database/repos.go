@@ -32,12 +32,18 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/jvmpackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/perforce"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/phabricator"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/subversion"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
@@ -492,6 +498,10 @@ func scanRepo(rows *sql.Rows, r *types.Repo) (err error) {
 		r.Metadata = new(bitbucketcloud.Repo)
 	case extsvc.TypeAWSCodeCommit:
 		r.Metadata = new(awscodecommit.Repository)
+	case extsvc.TypeGitea:
+		r.Metadata = new(gitea.Repository)
+	case extsvc.TypeGerrit:
+		r.Metadata = new(gerrit.Project)
 	case extsvc.TypeGitolite:
 		r.Metadata = new(gitolite.Repo)
 	case extsvc.TypePerforce:
@@ -502,6 +512,14 @@ func scanRepo(rows *sql.Rows, r *types.Repo) (err error) {
 		r.Metadata = new(extsvc.OtherRepoMetadata)
 	case extsvc.TypeJVMPackages:
 		r.Metadata = new(jvmpackages.Metadata)
+	case extsvc.TypeNpmPackages:
+		r.Metadata = new(npmpackages.Metadata)
+	case extsvc.TypeRustPackages, extsvc.TypePythonPackages, extsvc.TypeRubyPackages:
+		r.Metadata = new(registrypackages.Metadata)
+	case extsvc.TypeSubversion:
+		r.Metadata = new(subversion.Repo)
+	case extsvc.TypeMercurial:
+		r.Metadata = new(mercurial.Repo)
 	default:
 		log15.Warn("scanRepo - unknown service type", "typ", typ)
 		return nil
@@ -525,6 +543,11 @@ type ReposListOptions struct {
 	// Direction options are ignored
 	Query string
 
+	// SimilarTo, if non-empty, only includes repositories whose name is
+	// similar to it, as measured by trigram similarity. It is used to
+	// suggest repositories for misspelled names.
+	SimilarTo string
+
 	// IncludePatterns is a list of regular expressions, all of which must match all
 	// repositories returned in the list.
 	IncludePatterns []string
@@ -605,6 +628,14 @@ type ReposListOptions struct {
 	// OnlyPrivate excludes non-private repositories from the list.
 	OnlyPrivate bool
 
+	// Topics, if non empty, will only include repositories which have all of
+	// the given topics. Topics are matched case-insensitively.
+	Topics []string
+
+	// KVPs, if non empty, will only include repositories which match all of
+	// the given key-value pair filters.
+	KVPs []RepoKVPFilter
+
 	// Index when set will only include repositories which should be indexed
 	// if true. If false it will exclude repositories which should be
 	// indexed. An example use case of this is for indexed search only
@@ -681,9 +712,16 @@ type RepoListSort struct {
 }
 
 func (r RepoListSort) SQL() *sqlf.Query {
-	var sb strings.Builder
+	if r.Field == RepoListRank {
+		// The rank depends on the site configuration, so it can't be a
+		// constant column.
+		return sqlf.Sprintf("%s"+r.direction(), repoPriority(conf.Get().SiteConfiguration))
+	}
+	return sqlf.Sprintf(string(r.Field) + r.direction())
+}
 
-	sb.WriteString(string(r.Field))
+func (r RepoListSort) direction() string {
+	var sb strings.Builder
 
 	if r.Descending {
 		sb.WriteString(" DESC")
@@ -693,7 +731,7 @@ func (r RepoListSort) SQL() *sqlf.Query {
 		sb.WriteString(" NULLS " + r.Nulls)
 	}
 
-	return sqlf.Sprintf(sb.String())
+	return sb.String()
 }
 
 // RepoListColumn is a column by which repositories can be sorted. These correspond to columns in the database.
@@ -704,6 +742,10 @@ const (
 	RepoListName      RepoListColumn = "name"
 	RepoListID        RepoListColumn = "id"
 	RepoListStars     RepoListColumn = "stars"
+
+	// RepoListRank sorts by the search priority of repositories, which is
+	// their score in repo_ranks plus the boost configured by site admins.
+	RepoListRank RepoListColumn = "rank"
 )
 
 // List lists repositories in the Sourcegraph repository
@@ -853,6 +895,10 @@ func (s *repoStore) listSQL(ctx context.Context, opt ReposListOptions) (*sqlf.Qu
 		where = append(where, sqlf.Sprintf("lower(name) LIKE %s", "%"+strings.ToLower(opt.Query)+"%"))
 	}
 
+	if opt.SimilarTo != "" {
+		where = append(where, sqlf.Sprintf("lower(name) %% lower(%s)", opt.SimilarTo))
+	}
+
 	for _, includePattern := range opt.IncludePatterns {
 		extraConds, err := parsePattern(includePattern)
 		if err != nil {
@@ -954,6 +1000,17 @@ func (s *repoStore) listSQL(ctx context.Context, opt ReposListOptions) (*sqlf.Qu
 		where = append(where, sqlf.Sprintf("private"))
 	}
 
+	for _, topic := range opt.Topics {
+		where = append(where, sqlf.Sprintf("EXISTS (SELECT 1 FROM repo_topics rt WHERE rt.repo_id = repo.id AND rt.topic = %s)", strings.ToLower(topic)))
+	}
+	for _, kvp := range opt.KVPs {
+		if kvp.Value == nil {
+			where = append(where, sqlf.Sprintf("EXISTS (SELECT 1 FROM repo_kvps rk WHERE rk.repo_id = repo.id AND rk.key = %s)", kvp.Key))
+		} else {
+			where = append(where, sqlf.Sprintf("EXISTS (SELECT 1 FROM repo_kvps rk WHERE rk.repo_id = repo.id AND rk.key = %s AND rk.value = %s)", kvp.Key, *kvp.Value))
+		}
+	}
+
 	if len(opt.Names) > 0 {
 		lowerNames := make([]string, len(opt.Names))
 		for i, name := range opt.Names {
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil"
+)
+
+// ErrSavedSearchExecutionNotFound occurs when a database operation expects a
+// specific saved search execution to exist but it does not exist.
+var ErrSavedSearchExecutionNotFound = errors.New("saved search execution not found")
+
+// SavedSearchExecutionStore is responsible for data stored in the
+// saved_search_executions and saved_search_action_jobs tables, and for the
+// columns of saved_searches which track what we notified about.
+type SavedSearchExecutionStore struct {
+	*basestore.Store
+}
+
+// SavedSearchExecutions instantiates and returns a new SavedSearchExecutionStore.
+func SavedSearchExecutions(db dbutil.DB) *SavedSearchExecutionStore {
+	return &SavedSearchExecutionStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// SavedSearchExecutionsWith instantiates and returns a new
+// SavedSearchExecutionStore using the other store handle.
+func SavedSearchExecutionsWith(other basestore.ShareableStore) *SavedSearchExecutionStore {
+	return &SavedSearchExecutionStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *SavedSearchExecutionStore) With(other basestore.ShareableStore) *SavedSearchExecutionStore {
+	return &SavedSearchExecutionStore{Store: s.Store.With(other)}
+}
+
+func (s *SavedSearchExecutionStore) Transact(ctx context.Context) (*SavedSearchExecutionStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &SavedSearchExecutionStore{Store: txBase}, err
+}
+
+// SavedSearchExecutionColumns are the columns of the saved_search_executions
+// table scanned by ScanSavedSearchExecution.
+var SavedSearchExecutionColumns = []*sqlf.Query{
+	sqlf.Sprintf("saved_search_executions.id"),
+	sqlf.Sprintf("saved_search_executions.saved_search_id"),
+	sqlf.Sprintf("saved_search_executions.query_string"),
+	sqlf.Sprintf("saved_search_executions.num_results"),
+	sqlf.Sprintf("saved_search_executions.approximate_result_count"),
+	sqlf.Sprintf("saved_search_executions.state"),
+	sqlf.Sprintf("saved_search_executions.failure_message"),
+	sqlf.Sprintf("saved_search_executions.started_at"),
+	sqlf.Sprintf("saved_search_executions.finished_at"),
+	sqlf.Sprintf("saved_search_executions.num_resets"),
+	sqlf.Sprintf("saved_search_executions.num_failures"),
+	sqlf.Sprintf("saved_search_executions.created_at"),
+}
+
+// SavedSearchActionJobColumns are the columns of the saved_search_action_jobs
+// table scanned by ScanSavedSearchActionJob.
+var SavedSearchActionJobColumns = []*sqlf.Query{
+	sqlf.Sprintf("saved_search_action_jobs.id"),
+	sqlf.Sprintf("saved_search_action_jobs.execution_id"),
+	sqlf.Sprintf("saved_search_action_jobs.kind"),
+	sqlf.Sprintf("saved_search_action_jobs.user_id"),
+	sqlf.Sprintf("saved_search_action_jobs.state"),
+	sqlf.Sprintf("saved_search_action_jobs.failure_message"),
+	sqlf.Sprintf("saved_search_action_jobs.started_at"),
+	sqlf.Sprintf("saved_search_action_jobs.finished_at"),
+	sqlf.Sprintf("saved_search_action_jobs.num_resets"),
+	sqlf.Sprintf("saved_search_action_jobs.num_failures"),
+	sqlf.Sprintf("saved_search_action_jobs.created_at"),
+}
+
+// EnqueueDue queues an execution for every saved search which has
+// notifications enabled, is due to run and has no queued or running
+// execution yet. Only diff and commit searches are run, since other searches
+// do not support the after: filter.
+//
+// It is safe to call concurrently: a saved search never has more than one
+// queued or running execution.
+func (s *SavedSearchExecutionStore) EnqueueDue(ctx context.Context) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.EnqueueDue
+INSERT INTO saved_search_executions (saved_search_id)
+SELECT id FROM saved_searches
+WHERE
+	(notify_owner OR notify_slack) AND
+	(query LIKE '%%type:diff%%' OR query LIKE '%%type:commit%%') AND
+	(next_run IS NULL OR next_run <= now())
+ORDER BY id
+ON CONFLICT DO NOTHING
+`))
+}
+
+// GetByID returns the saved search execution with the given ID.
+func (s *SavedSearchExecutionStore) GetByID(ctx context.Context, id int64) (*types.SavedSearchExecution, error) {
+	q := sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.GetByID
+SELECT %s FROM saved_search_executions WHERE id = %s
+`, sqlf.Join(SavedSearchExecutionColumns, ", "), id)
+
+	executions, err := scanSavedSearchExecutions(s.Query(ctx, q))
+	if err != nil {
+		return nil, err
+	}
+	if len(executions) == 0 {
+		return nil, ErrSavedSearchExecutionNotFound
+	}
+	return executions[0], nil
+}
+
+// LatestResult returns the time of the latest result of the saved search we
+// notified about, or nil if the saved search never ran.
+func (s *SavedSearchExecutionStore) LatestResult(ctx context.Context, savedSearchID int32) (*time.Time, error) {
+	var latestResult *time.Time
+	err := s.QueryRow(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.LatestResult
+SELECT latest_result FROM saved_searches WHERE id = %s
+`, savedSearchID)).Scan(&latestResult)
+	return latestResult, err
+}
+
+// SetCursor records the time of the latest result of the saved search we
+// notified about, and when the saved search should run next.
+func (s *SavedSearchExecutionStore) SetCursor(ctx context.Context, savedSearchID int32, latestResult, nextRun time.Time) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.SetCursor
+UPDATE saved_searches SET latest_result = %s, next_run = %s WHERE id = %s
+`, latestResult, nextRun, savedSearchID))
+}
+
+// SetResults records the query an execution ran and the number of new results
+// it found.
+func (s *SavedSearchExecutionStore) SetResults(ctx context.Context, id int64, queryString string, numResults int32, approximateResultCount string) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.SetResults
+UPDATE saved_search_executions
+SET query_string = %s, num_results = %s, approximate_result_count = %s
+WHERE id = %s
+`, queryString, numResults, approximateResultCount, id))
+}
+
+// EnqueueActionJobs queues the given notifications about the results of an
+// execution.
+func (s *SavedSearchExecutionStore) EnqueueActionJobs(ctx context.Context, executionID int64, jobs []*types.SavedSearchActionJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	values := make([]*sqlf.Query, 0, len(jobs))
+	for _, j := range jobs {
+		values = append(values, sqlf.Sprintf("(%s, %s, %s)", executionID, j.Kind, j.UserID))
+	}
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.EnqueueActionJobs
+INSERT INTO saved_search_action_jobs (execution_id, kind, user_id) VALUES %s
+`, sqlf.Join(values, ", ")))
+}
+
+// DeleteObsolete deletes the completed executions which found no new results
+// and finished before the given time.
+func (s *SavedSearchExecutionStore) DeleteObsolete(ctx context.Context, before time.Time) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.DeleteObsolete
+DELETE FROM saved_search_executions WHERE state = 'completed' AND num_results = 0 AND finished_at < %s
+`, before))
+}
+
+// DeleteFinishedBefore deletes the executions which finished before the given
+// time, including their action jobs.
+func (s *SavedSearchExecutionStore) DeleteFinishedBefore(ctx context.Context, before time.Time) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/saved_search_executions.go:SavedSearchExecutionStore.DeleteFinishedBefore
+DELETE FROM saved_search_executions WHERE finished_at < %s AND state IN ('completed', 'failed')
+`, before))
+}
+
+// ScanSavedSearchExecution scans a single saved search execution from rows. It
+// is used as the dbworker RecordScanFn of the saved search executions worker.
+func ScanSavedSearchExecution(rows *sql.Rows, err error) (workerutil.Record, bool, error) {
+	executions, err := scanSavedSearchExecutions(rows, err)
+	if err != nil || len(executions) == 0 {
+		return nil, false, err
+	}
+	return executions[0], true, nil
+}
+
+func scanSavedSearchExecutions(rows *sql.Rows, queryErr error) (_ []*types.SavedSearchExecution, err error) {
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var executions []*types.SavedSearchExecution
+	for rows.Next() {
+		var e types.SavedSearchExecution
+		if err := rows.Scan(
+			&e.ID,
+			&e.SavedSearchID,
+			&e.QueryString,
+			&e.NumResults,
+			&e.ApproximateResultCount,
+			&e.State,
+			&e.FailureMessage,
+			&e.StartedAt,
+			&e.FinishedAt,
+			&e.NumResets,
+			&e.NumFailures,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		executions = append(executions, &e)
+	}
+	return executions, nil
+}
+
+// ScanSavedSearchActionJob scans a single saved search action job from rows.
+// It is used as the dbworker RecordScanFn of the saved search actions worker.
+func ScanSavedSearchActionJob(rows *sql.Rows, err error) (workerutil.Record, bool, error) {
+	jobs, err := scanSavedSearchActionJobs(rows, err)
+	if err != nil || len(jobs) == 0 {
+		return nil, false, err
+	}
+	return jobs[0], true, nil
+}
+
+func scanSavedSearchActionJobs(rows *sql.Rows, queryErr error) (_ []*types.SavedSearchActionJob, err error) {
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var jobs []*types.SavedSearchActionJob
+	for rows.Next() {
+		var j types.SavedSearchActionJob
+		if err := rows.Scan(
+			&j.ID,
+			&j.ExecutionID,
+			&j.Kind,
+			&j.UserID,
+			&j.State,
+			&j.FailureMessage,
+			&j.StartedAt,
+			&j.FinishedAt,
+			&j.NumResets,
+			&j.NumFailures,
+			&j.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &j)
+	}
+	return jobs, nil
+}
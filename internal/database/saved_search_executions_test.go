@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestSavedSearchExecutions_EnqueueDue(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	t.Parallel()
+	db := dbtest.NewDB(t)
+	ctx := context.Background()
+	store := SavedSearchExecutions(db)
+
+	user, err := Users(db).Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createSavedSearch := func(query string, notify, notifySlack bool) int32 {
+		ss, err := SavedSearches(db).Create(ctx, &types.SavedSearch{
+			Query:       query,
+			Description: query,
+			Notify:      notify,
+			NotifySlack: notifySlack,
+			UserID:      &user.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ss.ID
+	}
+	diffSearch := createSavedSearch("type:diff foo", true, false)
+	commitSearch := createSavedSearch("type:commit bar", false, true)
+	createSavedSearch("type:diff baz", false, false) // no notifications
+	createSavedSearch("foo", true, true)             // not a diff or commit search
+
+	// queuedSavedSearchIDs returns the saved searches with a queued
+	// execution, in the order of the executions.
+	queuedSavedSearchIDs := func() []int32 {
+		rows, err := db.QueryContext(ctx, "SELECT saved_search_id FROM saved_search_executions WHERE state = 'queued' ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var ids []int32
+		for rows.Next() {
+			var id int32
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	// Enqueueing again doesn't duplicate the queued executions.
+	for i := 0; i < 2; i++ {
+		if err := store.EnqueueDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]int32{diffSearch, commitSearch}, queuedSavedSearchIDs()); diff != "" {
+		t.Fatalf("unexpected queued saved searches (-want +got):\n%s", diff)
+	}
+
+	e, err := store.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.SavedSearchID != diffSearch || e.State != "queued" || e.NumResults != nil {
+		t.Fatalf("unexpected execution: %+v", e)
+	}
+	if _, err := store.GetByID(ctx, 3); err != ErrSavedSearchExecutionNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.SetResults(ctx, e.ID, "type:diff foo after:x", 2, "2"); err != nil {
+		t.Fatal(err)
+	}
+	e, err = store.GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *e.QueryString != "type:diff foo after:x" || *e.NumResults != 2 || *e.ApproximateResultCount != "2" {
+		t.Fatalf("unexpected results: %+v", e)
+	}
+
+	// A saved search is not due before its next run, even once its
+	// execution completed.
+	latestResult, err := store.LatestResult(ctx, diffSearch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latestResult != nil {
+		t.Fatalf("unexpected latest result of a saved search that never ran: %s", latestResult)
+	}
+	latest := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.SetCursor(ctx, diffSearch, latest, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if latestResult, err = store.LatestResult(ctx, diffSearch); err != nil {
+		t.Fatal(err)
+	}
+	if latestResult == nil || !latestResult.Equal(latest) {
+		t.Fatalf("unexpected latest result: have %v, want %s", latestResult, latest)
+	}
+
+	if _, err := db.ExecContext(ctx, "UPDATE saved_search_executions SET state = 'completed', finished_at = now()"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int32{commitSearch}, queuedSavedSearchIDs()); diff != "" {
+		t.Fatalf("unexpected queued saved searches (-want +got):\n%s", diff)
+	}
+
+	// Once it is due, the saved search is queued again.
+	if err := store.SetCursor(ctx, diffSearch, latest, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int32{commitSearch, diffSearch}, queuedSavedSearchIDs()); diff != "" {
+		t.Fatalf("unexpected queued saved searches (-want +got):\n%s", diff)
+	}
+}
+
+func TestSavedSearchExecutions_Delete(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	t.Parallel()
+	db := dbtest.NewDB(t)
+	ctx := context.Background()
+	store := SavedSearchExecutions(db)
+
+	user, err := Users(db).Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{"type:diff a", "type:diff b", "type:diff c"} {
+		if _, err := SavedSearches(db).Create(ctx, &types.SavedSearch{Query: q, Notify: true, UserID: &user.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.EnqueueDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Execution 1 found no results, execution 2 found results and queued
+	// notifications, and execution 3 is still queued.
+	if err := store.SetResults(ctx, 1, "type:diff a", 0, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetResults(ctx, 2, "type:diff b", 1, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueActionJobs(ctx, 2, []*types.SavedSearchActionJob{
+		{Kind: types.SavedSearchActionEmail, UserID: &user.ID},
+		{Kind: types.SavedSearchActionSlack},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueActionJobs(ctx, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	finishedAt := time.Now().Add(-time.Hour)
+	if _, err := db.ExecContext(ctx, "UPDATE saved_search_executions SET state = 'completed', finished_at = $1 WHERE id IN (1, 2)", finishedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(table string) (n int) {
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count("saved_search_action_jobs"); n != 2 {
+		t.Fatalf("got %d action jobs, want 2", n)
+	}
+
+	// Nothing finished before the cutoff.
+	if err := store.DeleteObsolete(ctx, finishedAt.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("saved_search_executions"); n != 3 {
+		t.Fatalf("got %d executions, want 3", n)
+	}
+
+	if err := store.DeleteObsolete(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetByID(ctx, 1); err != ErrSavedSearchExecutionNotFound {
+		t.Fatalf("expected the execution without results to be deleted, got error %v", err)
+	}
+	if n := count("saved_search_executions"); n != 2 {
+		t.Fatalf("got %d executions, want 2", n)
+	}
+
+	if err := store.DeleteFinishedBefore(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetByID(ctx, 3); err != nil {
+		t.Fatalf("expected the queued execution to be kept, got error %v", err)
+	}
+	if n := count("saved_search_executions"); n != 1 {
+		t.Fatalf("got %d executions, want 1", n)
+	}
+	if n := count("saved_search_action_jobs"); n != 0 {
+		t.Fatalf("got %d action jobs, want them deleted with their execution", n)
+	}
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/workerutil"
+)
+
+// ErrSearchExportNotFound occurs when a database operation expects a specific
+// search export to exist but it does not exist.
+var ErrSearchExportNotFound = errors.New("search export not found")
+
+// SearchExportStore is responsible for data stored in the search_exports table.
+type SearchExportStore struct {
+	*basestore.Store
+}
+
+// SearchExports instantiates and returns a new SearchExportStore.
+func SearchExports(db dbutil.DB) *SearchExportStore {
+	return &SearchExportStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// SearchExportsWith instantiates and returns a new SearchExportStore using the
+// other store handle.
+func SearchExportsWith(other basestore.ShareableStore) *SearchExportStore {
+	return &SearchExportStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *SearchExportStore) With(other basestore.ShareableStore) *SearchExportStore {
+	return &SearchExportStore{Store: s.Store.With(other)}
+}
+
+func (s *SearchExportStore) Transact(ctx context.Context) (*SearchExportStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &SearchExportStore{Store: txBase}, err
+}
+
+// SearchExportColumns are the columns of the search_exports table scanned by
+// ScanSearchExport.
+var SearchExportColumns = []*sqlf.Query{
+	sqlf.Sprintf("search_exports.id"),
+	sqlf.Sprintf("search_exports.user_id"),
+	sqlf.Sprintf("search_exports.query"),
+	sqlf.Sprintf("search_exports.pattern_type"),
+	sqlf.Sprintf("search_exports.format"),
+	sqlf.Sprintf("search_exports.result_count"),
+	sqlf.Sprintf("search_exports.object_key"),
+	sqlf.Sprintf("search_exports.state"),
+	sqlf.Sprintf("search_exports.failure_message"),
+	sqlf.Sprintf("search_exports.started_at"),
+	sqlf.Sprintf("search_exports.finished_at"),
+	sqlf.Sprintf("search_exports.num_resets"),
+	sqlf.Sprintf("search_exports.num_failures"),
+	sqlf.Sprintf("search_exports.created_at"),
+	sqlf.Sprintf("search_exports.updated_at"),
+}
+
+// Create queues a new search export.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is the user the export
+// is created for.
+func (s *SearchExportStore) Create(ctx context.Context, e *types.SearchExport) (*types.SearchExport, error) {
+	if Mocks.SearchExports.Create != nil {
+		return Mocks.SearchExports.Create(ctx, e)
+	}
+
+	q := sqlf.Sprintf(`
+-- source: internal/database/search_exports.go:SearchExportStore.Create
+INSERT INTO search_exports (user_id, query, pattern_type, format)
+VALUES (%s, %s, %s, %s)
+RETURNING %s
+`,
+		e.UserID,
+		e.Query,
+		e.PatternType,
+		e.Format,
+		sqlf.Join(SearchExportColumns, ", "),
+	)
+
+	exports, err := scanSearchExports(s.Query(ctx, q))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating search export")
+	}
+	if len(exports) == 0 {
+		return nil, errors.New("creating search export: no row returned")
+	}
+	return exports[0], nil
+}
+
+// GetByID returns the search export with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view the
+// search export.
+func (s *SearchExportStore) GetByID(ctx context.Context, id int64) (*types.SearchExport, error) {
+	if Mocks.SearchExports.GetByID != nil {
+		return Mocks.SearchExports.GetByID(ctx, id)
+	}
+
+	q := sqlf.Sprintf(`
+-- source: internal/database/search_exports.go:SearchExportStore.GetByID
+SELECT %s FROM search_exports WHERE id = %s
+`, sqlf.Join(SearchExportColumns, ", "), id)
+
+	exports, err := scanSearchExports(s.Query(ctx, q))
+	if err != nil {
+		return nil, err
+	}
+	if len(exports) == 0 {
+		return nil, ErrSearchExportNotFound
+	}
+	return exports[0], nil
+}
+
+// SetResult records the key of the gzip compressed exported file in the
+// search export store and its number of rows for the search export with the
+// given ID.
+func (s *SearchExportStore) SetResult(ctx context.Context, id int64, resultCount int32, objectKey string) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/search_exports.go:SearchExportStore.SetResult
+UPDATE search_exports SET result_count = %s, object_key = %s, updated_at = now() WHERE id = %s
+`, resultCount, objectKey, id))
+}
+
+// DeleteFinishedBefore deletes the search exports which finished before the
+// given time. It returns the keys of their exported files, which the caller
+// must delete from the search export store.
+func (s *SearchExportStore) DeleteFinishedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	return basestore.ScanStrings(s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/search_exports.go:SearchExportStore.DeleteFinishedBefore
+WITH deleted AS (
+	DELETE FROM search_exports
+	WHERE finished_at < %s AND state IN ('completed', 'failed')
+	RETURNING object_key
+)
+SELECT object_key FROM deleted WHERE object_key IS NOT NULL
+`, before)))
+}
+
+// ScanSearchExport scans a single search export from rows. It is used as the
+// dbworker RecordScanFn of the search exports worker.
+func ScanSearchExport(rows *sql.Rows, err error) (workerutil.Record, bool, error) {
+	exports, err := scanSearchExports(rows, err)
+	if err != nil || len(exports) == 0 {
+		return nil, false, err
+	}
+	return exports[0], true, nil
+}
+
+func scanSearchExports(rows *sql.Rows, queryErr error) (_ []*types.SearchExport, err error) {
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var exports []*types.SearchExport
+	for rows.Next() {
+		var e types.SearchExport
+		if err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Query,
+			&e.PatternType,
+			&e.Format,
+			&e.ResultCount,
+			&e.ObjectKey,
+			&e.State,
+			&e.FailureMessage,
+			&e.StartedAt,
+			&e.FinishedAt,
+			&e.NumResets,
+			&e.NumFailures,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		exports = append(exports, &e)
+	}
+	return exports, nil
+}
@@ -0,0 +1,12 @@
+package database
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type MockSearchExports struct {
+	Create  func(ctx context.Context, e *types.SearchExport) (*types.SearchExport, error)
+	GetByID func(ctx context.Context, id int64) (*types.SearchExport, error)
+}
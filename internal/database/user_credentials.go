@@ -8,6 +8,7 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
@@ -305,6 +306,13 @@ type UserCredentialsListOpts struct {
 	// TODO(batch-change-credential-encryption): this should be removed once the
 	// OOB user credential migration is removed.
 	OnlyEncrypted bool
+
+	// RequiresReencryption only returns credentials encrypted with a version of
+	// the key other than the current one, eg: before a key rotation.
+	RequiresReencryption bool
+
+	// ExcludeIDs are the IDs of credentials which must not be returned.
+	ExcludeIDs []int64
 }
 
 // sql overrides LimitOffset.SQL() to give a LIMIT clause with one extra value
@@ -354,6 +362,16 @@ func (s *UserCredentialsStore) List(ctx context.Context, opts UserCredentialsLis
 			UserCredentialUnmigratedEncryptionKeyID,
 		))
 	}
+	if opts.RequiresReencryption {
+		pred, err := s.RequiresReencryptionPredicate(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		preds = append(preds, pred)
+	}
+	if len(opts.ExcludeIDs) > 0 {
+		preds = append(preds, sqlf.Sprintf("NOT id = ANY(%s)", pq.Array(opts.ExcludeIDs)))
+	}
 
 	if len(preds) == 0 {
 		preds = append(preds, sqlf.Sprintf("TRUE"))
@@ -398,6 +416,41 @@ func (s *UserCredentialsStore) List(ctx context.Context, opts UserCredentialsLis
 	return creds, next, nil
 }
 
+// RequiresReencryptionPredicate returns a predicate matching credentials
+// encrypted with a version of the key other than the current one. Credentials
+// encrypted with a different key altogether are not matched, since the current
+// key cannot decrypt them. It never matches if no key is configured.
+func (s *UserCredentialsStore) RequiresReencryptionPredicate(ctx context.Context) (*sqlf.Query, error) {
+	if s.key == nil {
+		return sqlf.Sprintf("FALSE"), nil
+	}
+	version, err := s.key.Version(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting key version")
+	}
+	return otherKeyVersionPredicate(version), nil
+}
+
+// EncryptedWithOtherKeyPredicate returns a predicate matching credentials
+// encrypted with a key other than the current one, which the current key
+// cannot decrypt. It never matches if no key is configured.
+func (s *UserCredentialsStore) EncryptedWithOtherKeyPredicate(ctx context.Context) (*sqlf.Query, error) {
+	if s.key == nil {
+		return sqlf.Sprintf("FALSE"), nil
+	}
+	version, err := s.key.Version(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting key version")
+	}
+	return sqlf.Sprintf(
+		"(encryption_key_id NOT IN ('', %s, %s, %s) AND NOT %s)",
+		UserCredentialPlaceholderEncryptionKeyID,
+		UserCredentialUnmigratedEncryptionKeyID,
+		version.JSON(),
+		otherKeyVersionPredicate(version),
+	), nil
+}
+
 // 🐉 This marks the end of the public API. Beyond here are dragons.
 
 // userCredentialsColumns are the columns that must be selected by
@@ -517,3 +570,22 @@ func keyID(ctx context.Context, key encryption.Key) (string, error) {
 
 	return "", nil
 }
+
+// otherKeyVersionPredicate returns a predicate matching encryption_key_id
+// values of other versions of the given key, ie: values which the key can
+// decrypt but which must be encrypted again after a key rotation. The fields of
+// the JSON encoded key version are compared exactly, and placeholder values
+// such as UserCredentialUnmigratedEncryptionKeyID, which aren't JSON, never
+// match.
+func otherKeyVersionPredicate(version encryption.KeyVersion) *sqlf.Query {
+	return sqlf.Sprintf(
+		`(CASE WHEN left(encryption_key_id, 1) = '{' THEN
+			encryption_key_id::jsonb->>'Type' = %s AND
+			encryption_key_id::jsonb->>'Name' = %s AND
+			encryption_key_id::jsonb->>'Version' != %s
+		ELSE FALSE END)`,
+		version.Type,
+		version.Name,
+		version.Version,
+	)
+}
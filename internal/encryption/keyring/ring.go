@@ -13,6 +13,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/encryption/cache"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/cloudkms"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/mounted"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/vault"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -92,6 +93,13 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 		}
 	}
 
+	if keyConfig.CodeMonitorWebhookKey != nil {
+		r.CodeMonitorWebhookKey, err = NewKey(ctx, keyConfig.CodeMonitorWebhookKey, keyConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if keyConfig.ExternalServiceKey != nil {
 		r.ExternalServiceKey, err = NewKey(ctx, keyConfig.ExternalServiceKey, keyConfig)
 		if err != nil {
@@ -111,6 +119,7 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 
 type Ring struct {
 	BatchChangesCredentialKey encryption.Key
+	CodeMonitorWebhookKey     encryption.Key
 	ExternalServiceKey        encryption.Key
 	UserExternalAccountKey    encryption.Key
 }
@@ -130,6 +139,8 @@ func NewKey(ctx context.Context, k *schema.EncryptionKey, config *schema.Encrypt
 		key, err = awskms.NewKey(ctx, *k.Awskms)
 	case k.Mounted != nil:
 		key, err = mounted.NewKey(ctx, *k.Mounted)
+	case k.Vault != nil:
+		key, err = vault.NewKey(ctx, *k.Vault)
 	case k.Noop != nil:
 		key = &encryption.NoopKey{}
 	default:
@@ -29,6 +29,21 @@ func (k TestKey) Version(ctx context.Context) (encryption.KeyVersion, error) {
 	return encryption.KeyVersion{Type: "testkey"}, nil
 }
 
+// VersionedTestKey is a TestKey whose reported version can be changed, eg: to
+// simulate a key rotation. Values encrypted with any version can be decrypted.
+// Changing the name simulates switching to a different key.
+type VersionedTestKey struct {
+	TestKey
+	Name     string
+	Revision string
+}
+
+var _ encryption.Key = &VersionedTestKey{}
+
+func (k *VersionedTestKey) Version(ctx context.Context) (encryption.KeyVersion, error) {
+	return encryption.KeyVersion{Type: "testkey", Name: k.Name, Version: k.Revision}, nil
+}
+
 // BadKey is an encryption.Key that always returns an error when any of its
 // methods are invoked.
 type BadKey struct{ Err error }
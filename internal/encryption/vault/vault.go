@@ -0,0 +1,243 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	defaultTransitMountPath = "transit"
+	defaultAppRoleMountPath = "approle"
+
+	// tokenExpiryLeeway is how long before the expiry of a token obtained via
+	// AppRole we log in again, so that requests in flight don't fail.
+	tokenExpiryLeeway = time.Minute
+)
+
+func NewKey(ctx context.Context, k schema.VaultTransitEncryptionKey) (encryption.Key, error) {
+	return newKey(ctx, k, httpcli.ExternalDoer)
+}
+
+func newKey(ctx context.Context, k schema.VaultTransitEncryptionKey, doer httpcli.Doer) (*Key, error) {
+	if (k.Token == "") == (k.Approle == nil) {
+		return nil, errors.New("vault: exactly one of token and approle must be set")
+	}
+	address, err := url.Parse(k.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "vault: parsing address")
+	}
+	if k.Keyname == "" {
+		return nil, errors.New("vault: keyname must be set")
+	}
+
+	mountPath := k.MountPath
+	if mountPath == "" {
+		mountPath = defaultTransitMountPath
+	}
+
+	key := &Key{
+		address:   address,
+		keyname:   k.Keyname,
+		mountPath: strings.Trim(mountPath, "/"),
+		namespace: k.Namespace,
+		token:     k.Token,
+		appRole:   k.Approle,
+		doer:      doer,
+	}
+	// Test client connection and permissions.
+	_, err = key.Version(ctx)
+	return key, err
+}
+
+// Key is an encryption.Key implementation that delegates encryption and
+// decryption to the transit secrets engine of a HashiCorp Vault server.
+//
+// Vault prefixes ciphertexts with the version of the key that produced them,
+// and can decrypt values encrypted with any version of the key that wasn't
+// trimmed, so values encrypted before a key rotation remain readable until
+// they are re-encrypted.
+type Key struct {
+	address   *url.URL
+	keyname   string
+	mountPath string
+	namespace string
+	appRole   *schema.VaultAppRoleAuth
+	doer      httpcli.Doer
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+var _ encryption.Key = &Key{}
+
+// Version returns the latest version of the transit key, which is the one
+// used to encrypt new values.
+func (k *Key) Version(ctx context.Context) (encryption.KeyVersion, error) {
+	var resp struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, "GET", k.transitPath("keys"), nil, &resp); err != nil {
+		return encryption.KeyVersion{}, errors.Wrap(err, "getting key version")
+	}
+	return encryption.KeyVersion{
+		Type:    "vault",
+		Name:    k.keyname,
+		Version: strconv.Itoa(resp.Data.LatestVersion),
+	}, nil
+}
+
+// Encrypt encrypts plaintext with the latest version of the transit key. The
+// returned ciphertext is the one produced by Vault, eg: vault:v1:<base64>.
+func (k *Key) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	req := struct {
+		Plaintext string `json:"plaintext"`
+	}{
+		Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+	}
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, "POST", k.transitPath("encrypt"), req, &resp); err != nil {
+		return nil, errors.Wrap(err, "encrypting value")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// Decrypt decrypts a value encrypted with any version of the transit key.
+func (k *Key) Decrypt(ctx context.Context, ciphertext []byte) (*encryption.Secret, error) {
+	req := struct {
+		Ciphertext string `json:"ciphertext"`
+	}{
+		Ciphertext: string(ciphertext),
+	}
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, "POST", k.transitPath("decrypt"), req, &resp); err != nil {
+		return nil, errors.Wrap(err, "decrypting value")
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding plaintext")
+	}
+	s := encryption.NewSecret(string(plaintext))
+	return &s, nil
+}
+
+func (k *Key) transitPath(operation string) string {
+	return fmt.Sprintf("%s/%s/%s", k.mountPath, operation, url.PathEscape(k.keyname))
+}
+
+// clientToken returns the token to authenticate requests with, logging in
+// with AppRole first if required.
+func (k *Key) clientToken(ctx context.Context) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.appRole == nil {
+		return k.token, nil
+	}
+	if k.token != "" && (k.tokenExpiry.IsZero() || time.Now().Add(tokenExpiryLeeway).Before(k.tokenExpiry)) {
+		return k.token, nil
+	}
+
+	mountPath := k.appRole.MountPath
+	if mountPath == "" {
+		mountPath = defaultAppRoleMountPath
+	}
+	req := struct {
+		RoleID   string `json:"role_id"`
+		SecretID string `json:"secret_id"`
+	}{
+		RoleID:   k.appRole.RoleID,
+		SecretID: k.appRole.SecretID,
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := k.send(ctx, "POST", fmt.Sprintf("auth/%s/login", strings.Trim(mountPath, "/")), "", req, &resp); err != nil {
+		return "", errors.Wrap(err, "logging in with approle")
+	}
+
+	k.token = resp.Auth.ClientToken
+	k.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		k.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+	return k.token, nil
+}
+
+func (k *Key) do(ctx context.Context, method, path string, body, result interface{}) error {
+	token, err := k.clientToken(ctx)
+	if err != nil {
+		return err
+	}
+	return k.send(ctx, method, path, token, body, result)
+}
+
+// send performs a request against the Vault HTTP API and decodes the JSON
+// response into result.
+func (k *Key) send(ctx context.Context, method, path, token string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+
+	u := k.address.ResolveReference(&url.URL{Path: "/v1/" + path})
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if k.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", k.namespace)
+	}
+
+	resp, err := k.doer.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&vaultErr)
+		return errors.Errorf("vault responded with status %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
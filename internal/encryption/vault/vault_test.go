@@ -0,0 +1,273 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// fakeTransit is a minimal implementation of the Vault transit secrets engine
+// and AppRole login endpoints. Instead of encrypting, it reverses the base64
+// encoded plaintext.
+type fakeTransit struct {
+	mu            sync.Mutex
+	latestVersion int
+	token         string
+	logins        int
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body map[string]string
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		f.logins++
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": f.token, "lease_duration": 3600},
+		})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/transit/keys/my-key":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"latest_version": f.latestVersion},
+		})
+
+	case "/v1/transit/encrypt/my-key":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"ciphertext": fmt.Sprintf("vault:v%d:%s", f.latestVersion, reverse(body["plaintext"])),
+			},
+		})
+
+	case "/v1/transit/decrypt/my-key":
+		parts := strings.SplitN(body["ciphertext"], ":", 3)
+		if len(parts) != 3 || parts[0] != "vault" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid ciphertext"}})
+			return
+		}
+		if version, _ := strconv.Atoi(strings.TrimPrefix(parts[1], "v")); version > f.latestVersion {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid key version"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"plaintext": reverse(parts[2])},
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name   string
+		config schema.VaultTransitEncryptionKey
+	}{
+		{
+			name: "token",
+			config: schema.VaultTransitEncryptionKey{
+				Type:    "vault",
+				Keyname: "my-key",
+				Token:   "s.token",
+			},
+		},
+		{
+			name: "approle",
+			config: schema.VaultTransitEncryptionKey{
+				Type:    "vault",
+				Keyname: "my-key",
+				Approle: &schema.VaultAppRoleAuth{RoleID: "role", SecretID: "secret"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			transit := &fakeTransit{latestVersion: 1, token: "s.token"}
+			srv := httptest.NewServer(transit)
+			t.Cleanup(srv.Close)
+
+			tc.config.Address = srv.URL
+			k, err := newKey(ctx, tc.config, srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plaintext := "hello world"
+			ciphertext, err := k.Encrypt(ctx, []byte(plaintext))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "vault:v1:"; !strings.HasPrefix(string(ciphertext), want) {
+				t.Fatalf("ciphertext %q does not have prefix %q", ciphertext, want)
+			}
+			if strings.Contains(string(ciphertext), base64.StdEncoding.EncodeToString([]byte(plaintext))) {
+				t.Fatal("ciphertext contains plaintext")
+			}
+
+			secret, err := k.Decrypt(ctx, ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secret.Secret() != plaintext {
+				t.Fatalf("want %q, got %q", plaintext, secret.Secret())
+			}
+
+			if tc.config.Approle != nil && transit.logins != 1 {
+				t.Fatalf("want 1 approle login, got %d", transit.logins)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+
+	transit := &fakeTransit{latestVersion: 1, token: "s.token"}
+	srv := httptest.NewServer(transit)
+	t.Cleanup(srv.Close)
+
+	k, err := newKey(ctx, schema.VaultTransitEncryptionKey{
+		Type:    "vault",
+		Address: srv.URL,
+		Keyname: "my-key",
+		Token:   "s.token",
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := k.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(encryption.KeyVersion{Type: "vault", Name: "my-key", Version: "1"}, before); diff != "" {
+		t.Fatal(diff)
+	}
+
+	oldCiphertext, err := k.Encrypt(ctx, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transit.latestVersion = 2
+
+	after, err := k.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.JSON() == before.JSON() {
+		t.Fatal("expected key version to change after rotation")
+	}
+
+	// Values encrypted with the previous version must remain readable.
+	secret, err := k.Decrypt(ctx, oldCiphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Secret() != "secret" {
+		t.Fatalf("want %q, got %q", "secret", secret.Secret())
+	}
+
+	newCiphertext, err := k.Encrypt(ctx, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(newCiphertext), "vault:v2:") {
+		t.Fatalf("expected value to be encrypted with the latest version, got %q", newCiphertext)
+	}
+}
+
+func TestNewKeyErrors(t *testing.T) {
+	ctx := context.Background()
+
+	transit := &fakeTransit{latestVersion: 1, token: "s.token"}
+	srv := httptest.NewServer(transit)
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		name   string
+		config schema.VaultTransitEncryptionKey
+	}{
+		{
+			name:   "no auth",
+			config: schema.VaultTransitEncryptionKey{Address: srv.URL, Keyname: "my-key"},
+		},
+		{
+			name: "token and approle",
+			config: schema.VaultTransitEncryptionKey{
+				Address: srv.URL,
+				Keyname: "my-key",
+				Token:   "s.token",
+				Approle: &schema.VaultAppRoleAuth{RoleID: "role", SecretID: "secret"},
+			},
+		},
+		{
+			name:   "invalid token",
+			config: schema.VaultTransitEncryptionKey{Address: srv.URL, Keyname: "my-key", Token: "s.invalid"},
+		},
+		{
+			name: "invalid approle",
+			config: schema.VaultTransitEncryptionKey{
+				Address: srv.URL,
+				Keyname: "my-key",
+				Approle: &schema.VaultAppRoleAuth{RoleID: "role", SecretID: "invalid"},
+			},
+		},
+		{
+			name:   "unknown key",
+			config: schema.VaultTransitEncryptionKey{Address: srv.URL, Keyname: "other-key", Token: "s.token"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := newKey(ctx, tc.config, srv.Client()); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
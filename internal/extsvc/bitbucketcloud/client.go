@@ -1,6 +1,7 @@
 package bitbucketcloud
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"golang.org/x/time/rate"
 
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
@@ -50,6 +52,10 @@ type Client struct {
 	// The username and app password credentials for accessing the server.
 	Username, AppPassword string
 
+	// Auth is the authenticator used for requests. If nil, Username and
+	// AppPassword are used for basic authentication instead.
+	Auth auth.Authenticator
+
 	// RateLimit is the self-imposed rate limiter (since Bitbucket does not have a concept
 	// of rate limiting in HTTP response headers).
 	RateLimit *rate.Limiter
@@ -86,6 +92,29 @@ func NewClient(apiURL *url.URL, httpClient httpcli.Doer) *Client {
 	}
 }
 
+// WithAuthenticator returns a copy of the original Client authenticated with
+// the given authenticator instead of the configured username and app password.
+func (c *Client) WithAuthenticator(a auth.Authenticator) *Client {
+	cc := *c
+	cc.Auth = a
+	return &cc
+}
+
+// CurrentUser returns the Bitbucket Cloud account associated with the
+// credentials used by the client.
+func (c *Client) CurrentUser(ctx context.Context) (*Account, error) {
+	req, err := http.NewRequest("GET", "/2.0/user", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user Account
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Repos returns a list of repositories that are fetched and populated based on given account
 // name and pagination criteria. If the account requested is a team, results will be filtered
 // down to the ones that the app password's user has access to.
@@ -144,6 +173,23 @@ func (c *Client) reqPage(ctx context.Context, url string, results interface{}) (
 	return &next, nil
 }
 
+func (c *Client) send(ctx context.Context, method, path string, payload, result interface{}) error {
+	var body io.ReadWriter
+	if payload != nil {
+		body = new(bytes.Buffer)
+		if err := json.NewEncoder(body).Encode(payload); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, req, result)
+}
+
 func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
 	req.URL = c.URL.ResolveReference(req.URL)
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
@@ -187,7 +233,7 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 		})
 	}
 
-	if result != nil {
+	if result != nil && len(bs) > 0 {
 		return json.Unmarshal(bs, result)
 	}
 
@@ -195,6 +241,9 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 }
 
 func (c *Client) authenticate(req *http.Request) error {
+	if c.Auth != nil {
+		return c.Auth.Authenticate(req)
+	}
 	req.SetBasicAuth(c.Username, c.AppPassword)
 	return nil
 }
@@ -241,6 +290,16 @@ type Links struct {
 	HTML  Link       `json:"html"`
 }
 
+// Account is a Bitbucket Cloud user or team account.
+type Account struct {
+	Username    string `json:"username"`
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+	UUID        string `json:"uuid"`
+	AccountID   string `json:"account_id"`
+	Links       Links  `json:"links"`
+}
+
 type CloneLinks []struct {
 	Href string `json:"href"`
 	Name string `json:"name"`
@@ -277,3 +336,15 @@ func (e *httpError) Unauthorized() bool {
 func (e *httpError) NotFound() bool {
 	return e.StatusCode == http.StatusNotFound
 }
+
+// IsUnauthorized reports whether err is a Bitbucket Cloud API HTTP 401 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
+
+// IsNotFound reports whether err is a Bitbucket Cloud API HTTP 404 error.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
@@ -0,0 +1,141 @@
+package bitbucketcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	eventTypeHeader = "X-Event-Key"
+)
+
+func WebhookEventType(r *http.Request) string {
+	return r.Header.Get(eventTypeHeader)
+}
+
+func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err error) {
+	switch eventType {
+	case "pullrequest:approved":
+		e = &PullRequestApprovedEvent{}
+	case "pullrequest:unapproved":
+		e = &PullRequestUnapprovedEvent{}
+	case "pullrequest:changes_request_created":
+		e = &PullRequestChangesRequestCreatedEvent{}
+	case "pullrequest:changes_request_removed":
+		e = &PullRequestChangesRequestRemovedEvent{}
+	case "pullrequest:comment_created":
+		e = &PullRequestCommentCreatedEvent{}
+	case "pullrequest:fulfilled":
+		e = &PullRequestFulfilledEvent{}
+	case "pullrequest:rejected":
+		e = &PullRequestRejectedEvent{}
+	case "pullrequest:updated":
+		e = &PullRequestUpdatedEvent{}
+	case "repo:commit_status_created", "repo:commit_status_updated":
+		e = &RepoCommitStatusEvent{}
+	default:
+		return nil, errors.Errorf("unknown webhook event type: %q", eventType)
+	}
+	return e, json.Unmarshal(payload, e)
+}
+
+// PullRequestEvent contains the fields common to all pull request webhook
+// payloads.
+type PullRequestEvent struct {
+	Actor       Account     `json:"actor"`
+	Repository  Repo        `json:"repository"`
+	PullRequest PullRequest `json:"pullrequest"`
+}
+
+// Approval is the approval or change request of a single user on a pull
+// request.
+type Approval struct {
+	Date time.Time `json:"date"`
+	User Account   `json:"user"`
+}
+
+func (a *Approval) key() string {
+	return fmt.Sprintf("%s:%d", a.User.UUID, a.Date.UnixNano())
+}
+
+type PullRequestApprovedEvent struct {
+	PullRequestEvent
+	Approval Approval `json:"approval"`
+}
+
+func (e *PullRequestApprovedEvent) Key() string { return e.Approval.key() }
+
+type PullRequestUnapprovedEvent struct {
+	PullRequestEvent
+	Approval Approval `json:"approval"`
+}
+
+func (e *PullRequestUnapprovedEvent) Key() string { return e.Approval.key() }
+
+type PullRequestChangesRequestCreatedEvent struct {
+	PullRequestEvent
+	ChangesRequest Approval `json:"changes_request"`
+}
+
+func (e *PullRequestChangesRequestCreatedEvent) Key() string { return e.ChangesRequest.key() }
+
+type PullRequestChangesRequestRemovedEvent struct {
+	PullRequestEvent
+	ChangesRequest Approval `json:"changes_request"`
+}
+
+func (e *PullRequestChangesRequestRemovedEvent) Key() string { return e.ChangesRequest.key() }
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	ID      int64 `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	User      Account   `json:"user"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+type PullRequestCommentCreatedEvent struct {
+	PullRequestEvent
+	Comment Comment `json:"comment"`
+}
+
+func (e *PullRequestCommentCreatedEvent) Key() string { return strconv.FormatInt(e.Comment.ID, 10) }
+
+type PullRequestFulfilledEvent struct {
+	PullRequestEvent
+}
+
+func (e *PullRequestFulfilledEvent) Key() string { return e.PullRequest.key() }
+
+type PullRequestRejectedEvent struct {
+	PullRequestEvent
+}
+
+func (e *PullRequestRejectedEvent) Key() string { return e.PullRequest.key() }
+
+type PullRequestUpdatedEvent struct {
+	PullRequestEvent
+}
+
+func (e *PullRequestUpdatedEvent) Key() string { return e.PullRequest.key() }
+
+func (pr *PullRequest) key() string {
+	return fmt.Sprintf("%d:%d", pr.ID, pr.UpdatedOn.UnixNano())
+}
+
+// RepoCommitStatusEvent is sent when a commit status is created or updated.
+// It doesn't reference a pull request: consumers need to match the commit
+// hash against the source commit of the pull requests they know about.
+type RepoCommitStatusEvent struct {
+	Actor        Account           `json:"actor"`
+	Repository   Repo              `json:"repository"`
+	CommitStatus PullRequestStatus `json:"commit_status"`
+}
@@ -0,0 +1,59 @@
+package bitbucketcloud
+
+import (
+	"testing"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	t.Run("unknown event", func(t *testing.T) {
+		if _, err := ParseWebhookEvent("repo:push", []byte(`{}`)); err == nil {
+			t.Error("unexpected nil error")
+		}
+	})
+
+	t.Run("pull request approved", func(t *testing.T) {
+		payload := `{
+			"repository": {"uuid": "{repo}"},
+			"pullrequest": {"id": 42},
+			"approval": {"date": "2021-10-01T12:00:00Z", "user": {"uuid": "{user}"}}
+		}`
+		e, err := ParseWebhookEvent("pullrequest:approved", []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, ok := e.(*PullRequestApprovedEvent)
+		if !ok {
+			t.Fatalf("unexpected event type %T", e)
+		}
+		if ev.Repository.UUID != "{repo}" || ev.PullRequest.ID != 42 {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+		if have, want := ev.Key(), "{user}:1633089600000000000"; have != want {
+			t.Errorf("unexpected key: want %q, have %q", want, have)
+		}
+	})
+
+	t.Run("commit status", func(t *testing.T) {
+		payload := `{
+			"repository": {"uuid": "{repo}"},
+			"commit_status": {
+				"key": "build",
+				"state": "INPROGRESS",
+				"links": {"commit": {"href": "https://api.bitbucket.org/2.0/repositories/sglocal/mux/commit/deadbeef"}}
+			}
+		}`
+		for _, eventType := range []string{"repo:commit_status_created", "repo:commit_status_updated"} {
+			e, err := ParseWebhookEvent(eventType, []byte(payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, ok := e.(*RepoCommitStatusEvent)
+			if !ok {
+				t.Fatalf("unexpected event type %T", e)
+			}
+			if ev.CommitStatus.Key() != "build" || ev.CommitStatus.CommitHash() != "deadbeef" {
+				t.Errorf("unexpected commit status: %+v", ev.CommitStatus)
+			}
+		}
+	})
+}
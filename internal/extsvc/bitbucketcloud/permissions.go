@@ -0,0 +1,67 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// RepoPermission is the effective permission of a user on a repository, as
+// returned by the workspace permissions API. The permission is the highest
+// level granted to the user, whether directly or through a group.
+type RepoPermission struct {
+	// Permission is one of "read", "write" or "admin".
+	Permission string   `json:"permission"`
+	User       *Account `json:"user"`
+	Repository *Repo    `json:"repository"`
+}
+
+// WorkspaceMembers returns a page of the members of the given workspace.
+//
+// API docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-workspaces/#api-workspaces-workspace-members-get
+func (c *Client) WorkspaceMembers(ctx context.Context, pageToken *PageToken, workspace string) ([]*Account, *PageToken, error) {
+	var memberships []struct {
+		User *Account `json:"user"`
+	}
+
+	var next *PageToken
+	var err error
+	if pageToken.HasMore() {
+		next, err = c.reqPage(ctx, pageToken.Next, &memberships)
+	} else {
+		next, err = c.page(ctx, fmt.Sprintf("/2.0/workspaces/%s/members", url.PathEscape(workspace)), nil, pageToken, &memberships)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members := make([]*Account, 0, len(memberships))
+	for _, m := range memberships {
+		if m.User != nil {
+			members = append(members, m.User)
+		}
+	}
+	return members, next, nil
+}
+
+// WorkspaceRepoPermissions returns a page of the effective repository
+// permissions in the given workspace, filtered by the given query (for example
+// `user.uuid="{...}"`). The credentials used by the client must belong to an
+// administrator of the workspace.
+//
+// API docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-workspaces/#api-workspaces-workspace-permissions-repositories-get
+func (c *Client) WorkspaceRepoPermissions(ctx context.Context, pageToken *PageToken, workspace, query string) ([]*RepoPermission, *PageToken, error) {
+	var perms []*RepoPermission
+	var next *PageToken
+	var err error
+	if pageToken.HasMore() {
+		next, err = c.reqPage(ctx, pageToken.Next, &perms)
+	} else {
+		qry := make(url.Values)
+		if query != "" {
+			qry.Set("q", query)
+		}
+		next, err = c.page(ctx, fmt.Sprintf("/2.0/workspaces/%s/permissions/repositories", url.PathEscape(workspace)), qry, pageToken, &perms)
+	}
+	return perms, next, err
+}
@@ -0,0 +1,344 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// PullRequestState is the state of a pull request on Bitbucket Cloud.
+type PullRequestState string
+
+const (
+	PullRequestStateMerged     PullRequestState = "MERGED"
+	PullRequestStateSuperseded PullRequestState = "SUPERSEDED"
+	PullRequestStateOpen       PullRequestState = "OPEN"
+	PullRequestStateDeclined   PullRequestState = "DECLINED"
+)
+
+// PullRequest is a Bitbucket Cloud pull request.
+type PullRequest struct {
+	ID                int64               `json:"id"`
+	Title             string              `json:"title"`
+	Description       string              `json:"description"`
+	State             PullRequestState    `json:"state"`
+	Author            Account             `json:"author"`
+	Source            PullRequestEndpoint `json:"source"`
+	Destination       PullRequestEndpoint `json:"destination"`
+	MergeCommit       *PullRequestCommit  `json:"merge_commit,omitempty"`
+	CommentCount      int64               `json:"comment_count"`
+	CloseSourceBranch bool                `json:"close_source_branch"`
+	ClosedBy          *Account            `json:"closed_by,omitempty"`
+	Reason            string              `json:"reason,omitempty"`
+	CreatedOn         time.Time           `json:"created_on"`
+	UpdatedOn         time.Time           `json:"updated_on"`
+	Reviewers         []Account           `json:"reviewers"`
+	Participants      []Participant       `json:"participants"`
+	Links             Links               `json:"links"`
+
+	// Statuses are not returned by the pull request endpoints. They are
+	// loaded separately through LoadPullRequestStatuses.
+	Statuses []*PullRequestStatus `json:"statuses,omitempty"`
+}
+
+// PullRequestEndpoint is the source or destination of a pull request.
+type PullRequestEndpoint struct {
+	Repository Repo               `json:"repository"`
+	Branch     PullRequestBranch  `json:"branch"`
+	Commit     *PullRequestCommit `json:"commit,omitempty"`
+}
+
+type PullRequestBranch struct {
+	Name string `json:"name"`
+}
+
+type PullRequestCommit struct {
+	Hash string `json:"hash"`
+}
+
+// ParticipantState is the review state of a pull request participant.
+type ParticipantState string
+
+const (
+	ParticipantStateApproved         ParticipantState = "approved"
+	ParticipantStateChangesRequested ParticipantState = "changes_requested"
+	ParticipantStateNone             ParticipantState = ""
+)
+
+// Participant is a user that has interacted with a pull request.
+type Participant struct {
+	User           Account          `json:"user"`
+	Role           string           `json:"role"`
+	Approved       bool             `json:"approved"`
+	State          ParticipantState `json:"state"`
+	ParticipatedOn time.Time        `json:"participated_on"`
+}
+
+// Key is a unique key identifying this participant's review in the context
+// of a single pull request.
+func (p *Participant) Key() string {
+	return fmt.Sprintf("%s:%s", p.User.UUID, p.State)
+}
+
+// PullRequestStatusState is the state of a commit status on Bitbucket Cloud.
+type PullRequestStatusState string
+
+const (
+	PullRequestStatusStateSuccessful PullRequestStatusState = "SUCCESSFUL"
+	PullRequestStatusStateFailed     PullRequestStatusState = "FAILED"
+	PullRequestStatusStateInProgress PullRequestStatusState = "INPROGRESS"
+	PullRequestStatusStateStopped    PullRequestStatusState = "STOPPED"
+)
+
+// PullRequestStatus is a build status attached to the commits of a pull
+// request.
+type PullRequestStatus struct {
+	UUID        string                 `json:"uuid"`
+	StatusKey   string                 `json:"key"`
+	RefName     string                 `json:"refname"`
+	URL         string                 `json:"url"`
+	State       PullRequestStatusState `json:"state"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	CreatedOn   time.Time              `json:"created_on"`
+	UpdatedOn   time.Time              `json:"updated_on"`
+	Links       struct {
+		Commit Link `json:"commit"`
+		Self   Link `json:"self"`
+	} `json:"links"`
+}
+
+// Key is a unique key identifying this status in the context of a single
+// pull request. It's the identifier assigned to the status by the build
+// system, so that newer statuses from the same build replace older ones.
+func (s *PullRequestStatus) Key() string {
+	return s.StatusKey
+}
+
+// CommitHash returns the hash of the commit the status is attached to, as
+// extracted from the status links. An empty string is returned if the links
+// don't reference a commit.
+func (s *PullRequestStatus) CommitHash() string {
+	href := strings.TrimSuffix(s.Links.Commit.Href, "/")
+	if i := strings.LastIndex(href, "/"); i >= 0 {
+		return href[i+1:]
+	}
+	return ""
+}
+
+// PullRequestInput is the set of fields used to create or update a pull
+// request.
+type PullRequestInput struct {
+	Title        string
+	Description  string
+	SourceBranch string
+
+	// SourceRepo is the repository the source branch lives in. If nil, the
+	// destination repository is used.
+	SourceRepo *Repo
+
+	// DestinationBranch is the branch to merge into. If empty, Bitbucket
+	// Cloud uses the main branch of the repository.
+	DestinationBranch string
+}
+
+func (input *PullRequestInput) MarshalJSON() ([]byte, error) {
+	type branch struct {
+		Name string `json:"name"`
+	}
+	type repository struct {
+		FullName string `json:"full_name"`
+	}
+	type source struct {
+		Branch     branch      `json:"branch"`
+		Repository *repository `json:"repository,omitempty"`
+	}
+	type destination struct {
+		Branch branch `json:"branch"`
+	}
+	type request struct {
+		Title       string       `json:"title"`
+		Description string       `json:"description,omitempty"`
+		Source      source       `json:"source"`
+		Destination *destination `json:"destination,omitempty"`
+	}
+
+	req := request{
+		Title:       input.Title,
+		Description: input.Description,
+		Source:      source{Branch: branch{Name: input.SourceBranch}},
+	}
+	if input.SourceRepo != nil {
+		req.Source.Repository = &repository{FullName: input.SourceRepo.FullName}
+	}
+	if input.DestinationBranch != "" {
+		req.Destination = &destination{Branch: branch{Name: input.DestinationBranch}}
+	}
+
+	return json.Marshal(req)
+}
+
+// CreatePullRequest opens a new pull request in the given repository.
+//
+// Note that Bitbucket Cloud doesn't return an error if a pull request already
+// exists for the same source and destination branches: instead, the existing
+// pull request is updated and returned.
+func (c *Client) CreatePullRequest(ctx context.Context, repo *Repo, input PullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.send(ctx, "POST", pullRequestsPath(repo), &input, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ErrPullRequestNotFound is returned by GetPullRequest when the pull request
+// has been deleted on upstream, or never existed.
+var ErrPullRequestNotFound = errors.New("pull request not found")
+
+// FindOpenPullRequest returns the open pull request in the given repository
+// with the same source and destination branches as the input. It returns
+// ErrPullRequestNotFound if there is none.
+func (c *Client) FindOpenPullRequest(ctx context.Context, repo *Repo, input PullRequestInput) (*PullRequest, error) {
+	conditions := []string{
+		`state = "OPEN"`,
+		"source.branch.name = " + quoteQueryString(input.SourceBranch),
+	}
+	if input.SourceRepo != nil {
+		conditions = append(conditions, "source.repository.full_name = "+quoteQueryString(input.SourceRepo.FullName))
+	}
+	if input.DestinationBranch != "" {
+		conditions = append(conditions, "destination.branch.name = "+quoteQueryString(input.DestinationBranch))
+	}
+
+	var prs []*PullRequest
+	qry := url.Values{"q": {strings.Join(conditions, " AND ")}}
+	if _, err := c.page(ctx, pullRequestsPath(repo), qry, &PageToken{Pagelen: 1}, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, ErrPullRequestNotFound
+	}
+	return prs[0], nil
+}
+
+// quoteQueryString quotes s as a string in the query language of the
+// Bitbucket Cloud API.
+func quoteQueryString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// GetPullRequest retrieves the pull request with the given ID.
+func (c *Client) GetPullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.send(ctx, "GET", pullRequestPath(repo, id), nil, &pr); err != nil {
+		if IsNotFound(err) {
+			return nil, ErrPullRequestNotFound
+		}
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequest updates the title, description and destination branch
+// of the pull request with the given ID.
+func (c *Client) UpdatePullRequest(ctx context.Context, repo *Repo, id int64, input PullRequestInput) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.send(ctx, "PUT", pullRequestPath(repo, id), &input, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// DeclinePullRequest declines (closes without merging) the pull request with
+// the given ID.
+func (c *Client) DeclinePullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.send(ctx, "POST", pullRequestPath(repo, id)+"/decline", nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestComment adds a comment with the given text to the pull
+// request.
+func (c *Client) CreatePullRequestComment(ctx context.Context, repo *Repo, id int64, text string) error {
+	payload := map[string]interface{}{
+		"content": map[string]string{"raw": text},
+	}
+	return c.send(ctx, "POST", pullRequestPath(repo, id)+"/comments", payload, nil)
+}
+
+// MergeStrategy is the strategy used to merge a pull request.
+type MergeStrategy string
+
+const (
+	MergeStrategyMergeCommit MergeStrategy = "merge_commit"
+	MergeStrategySquash      MergeStrategy = "squash"
+	MergeStrategyFastForward MergeStrategy = "fast_forward"
+)
+
+// MergePullRequestOpts are the options available when merging a pull request.
+type MergePullRequestOpts struct {
+	Message           string        `json:"message,omitempty"`
+	CloseSourceBranch bool          `json:"close_source_branch"`
+	MergeStrategy     MergeStrategy `json:"merge_strategy,omitempty"`
+}
+
+// ErrNotMergeable is returned by MergePullRequest when the pull request failed
+// to merge, because a precondition is not met.
+var ErrNotMergeable = errors.New("pull request cannot be merged")
+
+// MergePullRequest merges the pull request with the given ID.
+func (c *Client) MergePullRequest(ctx context.Context, repo *Repo, id int64, opts MergePullRequestOpts) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.send(ctx, "POST", pullRequestPath(repo, id)+"/merge", &opts, &pr); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && (e.StatusCode == 400 || e.StatusCode == 409) {
+			return nil, errors.Wrap(ErrNotMergeable, err.Error())
+		}
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// LoadPullRequestStatuses loads all commit statuses attached to the pull
+// request and stores them in pr.Statuses.
+func (c *Client) LoadPullRequestStatuses(ctx context.Context, repo *Repo, pr *PullRequest) error {
+	var statuses []*PullRequestStatus
+
+	page := &PageToken{Pagelen: 100}
+	path := pullRequestPath(repo, pr.ID) + "/statuses"
+	for {
+		var results []*PullRequestStatus
+		var err error
+		if page.HasMore() {
+			page, err = c.reqPage(ctx, page.Next, &results)
+		} else {
+			page, err = c.page(ctx, path, nil, page, &results)
+		}
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, results...)
+
+		if !page.HasMore() {
+			break
+		}
+	}
+
+	pr.Statuses = statuses
+	return nil
+}
+
+func pullRequestsPath(repo *Repo) string {
+	return fmt.Sprintf("/2.0/repositories/%s/pullrequests", repo.FullName)
+}
+
+func pullRequestPath(repo *Repo, id int64) string {
+	return fmt.Sprintf("%s/%d", pullRequestsPath(repo), id)
+}
@@ -0,0 +1,233 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newPullRequestTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(u, srv.Client())
+}
+
+func TestPullRequestInput_MarshalJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input PullRequestInput
+		want  string
+	}{
+		"minimal": {
+			input: PullRequestInput{Title: "title", SourceBranch: "branch"},
+			want:  `{"title":"title","source":{"branch":{"name":"branch"}}}`,
+		},
+		"all fields": {
+			input: PullRequestInput{
+				Title:             "title",
+				Description:       "description",
+				SourceBranch:      "branch",
+				SourceRepo:        &Repo{FullName: "fork/repo"},
+				DestinationBranch: "main",
+			},
+			want: `{"title":"title","description":"description","source":{"branch":{"name":"branch"},"repository":{"full_name":"fork/repo"}},"destination":{"branch":{"name":"main"}}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			have, err := json.Marshal(&tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(have)); diff != "" {
+				t.Errorf("mismatch (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_CreatePullRequest(t *testing.T) {
+	repo := &Repo{FullName: "sglocal/mux"}
+
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := `{"title":"title","source":{"branch":{"name":"branch"}}}`
+		if diff := cmp.Diff(want, string(body[:len(body)-1])); diff != "" {
+			t.Errorf("unexpected body (-want +have):\n%s", diff)
+		}
+		_, _ = w.Write([]byte(`{"id":42,"title":"title","state":"OPEN"}`))
+	})
+
+	pr, err := cli.CreatePullRequest(context.Background(), repo, PullRequestInput{
+		Title:        "title",
+		SourceBranch: "branch",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.ID != 42 || pr.State != PullRequestStateOpen {
+		t.Errorf("unexpected pull request: %+v", pr)
+	}
+}
+
+func TestClient_GetPullRequest(t *testing.T) {
+	repo := &Repo{FullName: "sglocal/mux"}
+
+	t.Run("found", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests/42" {
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+			_, _ = w.Write([]byte(`{"id":42,"state":"MERGED","source":{"commit":{"hash":"deadbeef"}}}`))
+		})
+
+		pr, err := cli.GetPullRequest(context.Background(), repo, 42)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.State != PullRequestStateMerged || pr.Source.Commit.Hash != "deadbeef" {
+			t.Errorf("unexpected pull request: %+v", pr)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := cli.GetPullRequest(context.Background(), repo, 42)
+		if err != ErrPullRequestNotFound {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClient_FindOpenPullRequest(t *testing.T) {
+	repo := &Repo{FullName: "sglocal/mux"}
+	input := PullRequestInput{SourceBranch: `fix-"quotes"`, DestinationBranch: "main"}
+
+	t.Run("found", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests" {
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+			want := `state = "OPEN" AND source.branch.name = "fix-\"quotes\"" AND destination.branch.name = "main"`
+			if q := r.URL.Query().Get("q"); q != want {
+				t.Errorf("unexpected query: have %q want %q", q, want)
+			}
+			_, _ = w.Write([]byte(`{"values":[{"id":42,"state":"OPEN"}]}`))
+		})
+
+		pr, err := cli.FindOpenPullRequest(context.Background(), repo, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.ID != 42 {
+			t.Errorf("unexpected pull request: %+v", pr)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"values":[]}`))
+		})
+
+		_, err := cli.FindOpenPullRequest(context.Background(), repo, input)
+		if err != ErrPullRequestNotFound {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClient_MergePullRequest(t *testing.T) {
+	repo := &Repo{FullName: "sglocal/mux"}
+
+	t.Run("merged", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests/42/merge" {
+				t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			}
+			var opts MergePullRequestOpts
+			if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+				t.Fatal(err)
+			}
+			if opts.MergeStrategy != MergeStrategySquash {
+				t.Errorf("unexpected merge strategy: %q", opts.MergeStrategy)
+			}
+			_, _ = w.Write([]byte(`{"id":42,"state":"MERGED"}`))
+		})
+
+		pr, err := cli.MergePullRequest(context.Background(), repo, 42, MergePullRequestOpts{MergeStrategy: MergeStrategySquash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.State != PullRequestStateMerged {
+			t.Errorf("unexpected state: %q", pr.State)
+		}
+	})
+
+	t.Run("not mergeable", func(t *testing.T) {
+		cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"message":"conflicts"}}`))
+		})
+
+		_, err := cli.MergePullRequest(context.Background(), repo, 42, MergePullRequestOpts{})
+		if !errors.Is(err, ErrNotMergeable) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClient_LoadPullRequestStatuses(t *testing.T) {
+	repo := &Repo{FullName: "sglocal/mux"}
+
+	cli := newPullRequestTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/sglocal/mux/pullrequests/42/statuses" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"size":2,"page":1,"pagelen":100,"values":[{"key":"build","state":"SUCCESSFUL"},{"key":"lint","state":"FAILED"}]}`))
+	})
+
+	pr := &PullRequest{ID: 42}
+	if err := cli.LoadPullRequestStatuses(context.Background(), repo, pr); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*PullRequestStatus{
+		{StatusKey: "build", State: PullRequestStatusStateSuccessful},
+		{StatusKey: "lint", State: PullRequestStatusStateFailed},
+	}
+	if diff := cmp.Diff(want, pr.Statuses); diff != "" {
+		t.Errorf("mismatch (-want +have):\n%s", diff)
+	}
+}
+
+func TestPullRequestStatus_CommitHash(t *testing.T) {
+	for href, want := range map[string]string{
+		"https://api.bitbucket.org/2.0/repositories/sglocal/mux/commit/0123456789abcdef":  "0123456789abcdef",
+		"https://api.bitbucket.org/2.0/repositories/sglocal/mux/commit/0123456789abcdef/": "0123456789abcdef",
+		"": "",
+	} {
+		var s PullRequestStatus
+		s.Links.Commit.Href = href
+		if have := s.CommitHash(); have != want {
+			t.Errorf("%q: want %q, have %q", href, want, have)
+		}
+	}
+}
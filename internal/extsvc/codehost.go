@@ -24,10 +24,26 @@ var (
 	MavenURL    = &url.URL{Host: "maven"}
 	JVMPackages = NewCodeHost(MavenURL, TypeJVMPackages)
 
+	NpmURL      = &url.URL{Host: "npm"}
+	NpmPackages = NewCodeHost(NpmURL, TypeNpmPackages)
+
+	CratesURL    = &url.URL{Host: "crates"}
+	RustPackages = NewCodeHost(CratesURL, TypeRustPackages)
+
+	PyPIURL        = &url.URL{Host: "pypi"}
+	PythonPackages = NewCodeHost(PyPIURL, TypePythonPackages)
+
+	RubyGemsURL  = &url.URL{Host: "rubygems"}
+	RubyPackages = NewCodeHost(RubyGemsURL, TypeRubyPackages)
+
 	PublicCodeHosts = []*CodeHost{
 		GitHubDotCom,
 		GitLabDotCom,
 		JVMPackages,
+		NpmPackages,
+		RustPackages,
+		PythonPackages,
+		RubyPackages,
 	}
 )
 
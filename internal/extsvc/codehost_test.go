@@ -32,6 +32,16 @@ func TestCodeHostOf(t *testing.T) {
 		repo:      "GITHUB.COM/foo/bar",
 		codehosts: PublicCodeHosts,
 		want:      GitHubDotCom,
+	}, {
+		name:      "npm",
+		repo:      "npm/types/node",
+		codehosts: PublicCodeHosts,
+		want:      NpmPackages,
+	}, {
+		name:      "pypi",
+		repo:      "pypi/flask-sqlalchemy",
+		codehosts: PublicCodeHosts,
+		want:      PythonPackages,
 	}, {
 		name:      "invalid",
 		repo:      "github.com.example.com/foo/bar",
@@ -0,0 +1,227 @@
+// Package gerrit implements a Gerrit REST API client.
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gerrit_requests_count", "Total number of requests sent to the Gerrit API.")
+
+// PerPage is the number of projects requested per page. It must not exceed the
+// query limit of Gerrit (500 by default).
+const PerPage = 100
+
+// xssiPrefix is prepended by Gerrit to all JSON responses to prevent cross-site
+// script inclusion.
+var xssiPrefix = []byte(")]}'")
+
+// Client accesses a Gerrit instance via its REST API.
+type Client struct {
+	// HTTP Client used to communicate with the API.
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gerrit instance.
+	URL *url.URL
+
+	// Username and Password are the credentials used for HTTP basic
+	// authentication. The password is the HTTP password generated in the
+	// Gerrit account settings. If Username is empty, requests are anonymous.
+	Username, Password string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewClient creates a new Gerrit API client for the instance at baseURL. If a
+// nil httpClient is provided, httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the path, after the optional /a/ prefix of
+		// authenticated requests, maps to the type of API request we are
+		// making.
+		path := strings.TrimPrefix(strings.TrimPrefix(u.Path, baseURL.Path), "a/")
+		return strings.SplitN(path, "/", 2)[0]
+	})
+
+	return &Client{
+		httpClient:  httpClient,
+		URL:         baseURL,
+		rateLimiter: ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// Anonymous returns a copy of the client which doesn't authenticate requests.
+func (c *Client) Anonymous() *Client {
+	cc := *c
+	cc.Username, cc.Password = "", ""
+	return &cc
+}
+
+// Project is a Gerrit project, as returned by the Gerrit API.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Parent      string    `json:"parent,omitempty"`
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state,omitempty"`
+	WebLinks    []WebLink `json:"web_links,omitempty"`
+}
+
+// WebLink is a link to an external site displaying a Gerrit project, such as
+// gitiles.
+type WebLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Project states.
+const (
+	ProjectStateActive   = "ACTIVE"
+	ProjectStateReadOnly = "READ_ONLY"
+	ProjectStateHidden   = "HIDDEN"
+)
+
+// ListProjects lists the code projects visible to the client, ordered by name,
+// skipping the given number of projects. Projects that are hidden or only hold
+// permissions inherited by other projects are not listed.
+func (c *Client) ListProjects(ctx context.Context, skip int) (projects []*Project, hasNextPage bool, err error) {
+	qry := url.Values{
+		"d":    {""},
+		"type": {"CODE"},
+		"n":    {strconv.Itoa(PerPage)},
+		"S":    {strconv.Itoa(skip)},
+	}
+
+	// Listed projects are keyed by name, which is omitted from the values.
+	var byName map[string]*Project
+	if err := c.get(ctx, "projects/", qry, &byName); err != nil {
+		return nil, false, err
+	}
+
+	projects = make([]*Project, 0, len(byName))
+	for name, p := range byName {
+		p.Name = name
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+
+	return projects, len(byName) >= PerPage, nil
+}
+
+// GetProject returns the project with the given name.
+func (c *Client) GetProject(ctx context.Context, name string) (*Project, error) {
+	var p Project
+	if err := c.get(ctx, "projects/"+url.PathEscape(name), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// get requests the given API path, relative to the base URL, and decodes the
+// response into result.
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) error {
+	if c.Username != "" {
+		// Authenticated requests must be prefixed with /a/.
+		path = "a/" + path
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = qry.Encode()
+
+	req, err := http.NewRequest("GET", c.URL.ResolveReference(u).String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gerrit"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if err := json.Unmarshal(bytes.TrimPrefix(bs, xssiPrefix), result); err != nil {
+		return errors.Wrap(err, "decoding Gerrit API response")
+	}
+	return nil
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gerrit API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a Gerrit API HTTP 401 or 403 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
+
+// IsNotFound reports whether err is a Gerrit API HTTP 404 error.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
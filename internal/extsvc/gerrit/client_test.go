@@ -0,0 +1,86 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL + "/r/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(u, nil)
+}
+
+func TestClient_ListProjects(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/r/a/projects/"; have != want {
+			t.Errorf("wrong path: have %q, want %q", have, want)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			t.Errorf("wrong credentials: %q:%q", user, pass)
+		}
+		if have, want := r.URL.Query().Get("S"), "100"; have != want {
+			t.Errorf("wrong skip: have %q, want %q", have, want)
+		}
+		fmt.Fprint(w, ")]}'\n"+`{
+  "b/project": {"id": "b%2Fproject", "state": "ACTIVE", "description": "B"},
+  "a-project": {"id": "a-project", "state": "READ_ONLY"}
+}`)
+	})
+	cli.Username, cli.Password = "admin", "secret"
+
+	have, hasNextPage, err := cli.ListProjects(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNextPage {
+		t.Fatal("unexpected next page")
+	}
+
+	want := []*Project{
+		{ID: "a-project", Name: "a-project", State: ProjectStateReadOnly},
+		{ID: "b%2Fproject", Name: "b/project", State: ProjectStateActive, Description: "B"},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected projects (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_GetProject(t *testing.T) {
+	cli := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.EscapedPath(), "/r/projects/b%2Fproject"; have != want {
+			http.NotFound(w, r)
+			return
+		}
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Error("unexpected credentials in anonymous request")
+		}
+		fmt.Fprint(w, ")]}'\n"+`{"id": "b%2Fproject", "name": "b/project", "state": "ACTIVE"}`)
+	})
+
+	p, err := cli.GetProject(context.Background(), "b/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "b/project" {
+		t.Fatalf("unexpected project: %+v", p)
+	}
+
+	_, err = cli.GetProject(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package gitea implements a Gitea API client.
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gitea_requests_count", "Total number of requests sent to the Gitea API.")
+
+// PerPage is the number of repositories requested per page. It is the default
+// maximum page size of Gitea (MAX_RESPONSE_ITEMS).
+const PerPage = 50
+
+// Client accesses a Gitea instance via the REST API v1.
+type Client struct {
+	// HTTP Client used to communicate with the API.
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gitea instance.
+	URL *url.URL
+
+	// Token is the access token used for requests. If empty, requests are
+	// anonymous and only public repositories are visible.
+	Token string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewClient creates a new Gitea API client for the instance at baseURL. If a
+// nil httpClient is provided, httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, token string, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The component following /api/v1/ maps to the type of API request
+		// we are making.
+		path := strings.TrimPrefix(u.Path, baseURL.Path)
+		parts := strings.SplitN(strings.TrimPrefix(path, "api/v1/"), "/", 2)
+		return parts[0]
+	})
+
+	return &Client{
+		httpClient:  httpClient,
+		URL:         baseURL,
+		Token:       token,
+		rateLimiter: ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// Repository is a Gitea repository, as returned by the Gitea API.
+type Repository struct {
+	ID            int64  `json:"id"`
+	Owner         User   `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	Empty         bool   `json:"empty"`
+	Private       bool   `json:"private"`
+	Internal      bool   `json:"internal"`
+	Fork          bool   `json:"fork"`
+	Mirror        bool   `json:"mirror"`
+	Archived      bool   `json:"archived"`
+	HTMLURL       string `json:"html_url"`
+	SSHURL        string `json:"ssh_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	StarsCount    int    `json:"stars_count"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// ListAffiliatedRepos lists the repositories the user of the token owns or is
+// a collaborator or an organization member of. Pages start at 1.
+func (c *Client) ListAffiliatedRepos(ctx context.Context, page int) (repos []*Repository, hasNextPage bool, err error) {
+	hasNextPage, err = c.get(ctx, "api/v1/user/repos", pageValues(page), &repos)
+	return repos, hasNextPage, err
+}
+
+// ListOrgRepos lists the repositories of the given organization that are
+// visible to the client. Pages start at 1.
+func (c *Client) ListOrgRepos(ctx context.Context, org string, page int) (repos []*Repository, hasNextPage bool, err error) {
+	hasNextPage, err = c.get(ctx, "api/v1/orgs/"+url.PathEscape(org)+"/repos", pageValues(page), &repos)
+	return repos, hasNextPage, err
+}
+
+// ListAllRepos lists all the repositories that are visible to the client.
+// Pages start at 1.
+func (c *Client) ListAllRepos(ctx context.Context, page int) (repos []*Repository, hasNextPage bool, err error) {
+	var result struct {
+		OK   bool          `json:"ok"`
+		Data []*Repository `json:"data"`
+	}
+	hasNextPage, err = c.get(ctx, "api/v1/repos/search", pageValues(page), &result)
+	if err != nil {
+		return nil, false, err
+	}
+	if !result.OK {
+		return nil, false, errors.New("Gitea repository search failed")
+	}
+	return result.Data, hasNextPage, nil
+}
+
+// GetRepo returns the repository with the given owner and name.
+func (c *Client) GetRepo(ctx context.Context, owner, name string) (*Repository, error) {
+	var repo Repository
+	if _, err := c.get(ctx, "api/v1/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name), nil, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+func pageValues(page int) url.Values {
+	return url.Values{
+		"page":  {strconv.Itoa(page)},
+		"limit": {strconv.Itoa(PerPage)},
+	}
+}
+
+// get requests the given API path, relative to the base URL, and decodes the
+// response into result. It returns whether the response links to a next page.
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) (hasNextPage bool, err error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false, err
+	}
+	u.RawQuery = qry.Encode()
+
+	req, err := http.NewRequest("GET", c.URL.ResolveReference(u).String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return false, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if err := json.Unmarshal(bs, result); err != nil {
+		return false, errors.Wrap(err, "decoding Gitea API response")
+	}
+
+	return hasNextLink(resp.Header.Get("Link")), nil
+}
+
+// hasNextLink reports whether the given Link header contains a link to the
+// next page.
+func hasNextLink(link string) bool {
+	for _, l := range strings.Split(link, ",") {
+		if strings.Contains(l, `rel="next"`) {
+			return true
+		}
+	}
+	return false
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gitea API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a Gitea API HTTP 401 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
+
+// IsNotFound reports whether err is a Gitea API HTTP 404 error.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
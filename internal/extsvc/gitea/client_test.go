@@ -0,0 +1,120 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestClient(t *testing.T, token string, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL + "/gitea/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(u, token, nil)
+}
+
+func TestClient_ListOrgRepos(t *testing.T) {
+	repos := []*Repository{
+		{ID: 1, Owner: User{ID: 10, Login: "org"}, Name: "a", FullName: "org/a"},
+		{ID: 2, Owner: User{ID: 10, Login: "org"}, Name: "b", FullName: "org/b", Private: true},
+	}
+
+	var calls int
+	cli := newTestClient(t, "secret", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if have, want := r.URL.Path, "/gitea/api/v1/orgs/org/repos"; have != want {
+			t.Errorf("wrong path: have %q, want %q", have, want)
+		}
+		if have, want := r.Header.Get("Authorization"), "token secret"; have != want {
+			t.Errorf("wrong Authorization header: have %q, want %q", have, want)
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("Link", `<https://gitea/api/v1/orgs/org/repos?page=2&limit=50>; rel="next",<https://gitea/api/v1/orgs/org/repos?page=2&limit=50>; rel="last"`)
+			json.NewEncoder(w).Encode(repos[:1])
+		case "2":
+			w.Header().Set("Link", `<https://gitea/api/v1/orgs/org/repos?page=1&limit=50>; rel="first",<https://gitea/api/v1/orgs/org/repos?page=1&limit=50>; rel="prev"`)
+			json.NewEncoder(w).Encode(repos[1:])
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	var have []*Repository
+	for page, hasNextPage := 1, true; hasNextPage; page++ {
+		var (
+			rs  []*Repository
+			err error
+		)
+		rs, hasNextPage, err = cli.ListOrgRepos(context.Background(), "org", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have = append(have, rs...)
+	}
+
+	if diff := cmp.Diff(repos, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+	if calls != 2 {
+		t.Fatalf("unexpected number of requests: %d", calls)
+	}
+}
+
+func TestClient_ListAllRepos(t *testing.T) {
+	cli := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/gitea/api/v1/repos/search"; have != want {
+			t.Errorf("wrong path: have %q, want %q", have, want)
+		}
+		if have := r.Header.Get("Authorization"); have != "" {
+			t.Errorf("unexpected Authorization header %q", have)
+		}
+		w.Write([]byte(`{"ok": true, "data": [{"id": 3, "full_name": "user/c"}]}`))
+	})
+
+	have, hasNextPage, err := cli.ListAllRepos(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNextPage {
+		t.Fatal("unexpected next page")
+	}
+	if diff := cmp.Diff([]*Repository{{ID: 3, FullName: "user/c"}}, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_GetRepo(t *testing.T) {
+	cli := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gitea/api/v1/repos/user/c" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id": 3, "full_name": "user/c"}`))
+	})
+
+	repo, err := cli.GetRepo(context.Background(), "user", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.ID != 3 {
+		t.Fatalf("unexpected repo: %+v", repo)
+	}
+
+	_, err = cli.GetRepo(context.Background(), "user", "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -1660,6 +1660,37 @@ type Repository struct {
 	// Metadata retained for ranking
 	StargazerCount int `json:",omitempty"`
 	ForkCount      int `json:",omitempty"`
+
+	// RepositoryTopics are the topics of the repository, in the shape
+	// returned by the GraphQL API.
+	RepositoryTopics *RepositoryTopics `json:",omitempty"`
+}
+
+// RepositoryTopics is a list of topics of a repository.
+type RepositoryTopics struct {
+	Nodes []RepositoryTopic
+}
+
+// RepositoryTopic is a topic of a repository.
+type RepositoryTopic struct {
+	Topic Topic
+}
+
+// Topic is a topic on GitHub.
+type Topic struct {
+	Name string
+}
+
+// Topics returns the names of the topics of the repository.
+func (r *Repository) Topics() []string {
+	if r.RepositoryTopics == nil {
+		return nil
+	}
+	topics := make([]string, 0, len(r.RepositoryTopics.Nodes))
+	for _, n := range r.RepositoryTopics.Nodes {
+		topics = append(topics, n.Topic.Name)
+	}
+	return topics
 }
 
 func ownerNameCacheKey(owner, name string) string       { return "0:" + owner + "/" + name }
@@ -1731,6 +1762,7 @@ type restRepository struct {
 	Permissions restRepositoryPermissions `json:"permissions"`
 	Stars       int                       `json:"stargazers_count"`
 	Forks       int                       `json:"forks_count"`
+	Topics      []string                  `json:"topics"`
 }
 
 // getRepositoryFromAPI attempts to fetch a repository from the GitHub API without use of the redis cache.
@@ -1766,7 +1798,21 @@ func convertRestRepo(restRepo restRepository) *Repository {
 		ViewerPermission: convertRestRepoPermissions(restRepo.Permissions),
 		StargazerCount:   restRepo.Stars,
 		ForkCount:        restRepo.Forks,
+		RepositoryTopics: convertRestRepoTopics(restRepo.Topics),
+	}
+}
+
+// convertRestRepoTopics converts the topic names returned by the rest API to
+// the shape returned by the GraphQL API.
+func convertRestRepoTopics(names []string) *RepositoryTopics {
+	if len(names) == 0 {
+		return nil
+	}
+	topics := &RepositoryTopics{Nodes: make([]RepositoryTopic, 0, len(names))}
+	for _, name := range names {
+		topics.Nodes = append(topics.Nodes, RepositoryTopic{Topic: Topic{Name: name}})
 	}
+	return topics
 }
 
 // convertRestRepoPermissions converts repo information returned by the rest API
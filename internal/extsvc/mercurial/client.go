@@ -0,0 +1,163 @@
+// Package mercurial implements a client that lists the repositories published
+// by an hgweb server.
+package mercurial
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("mercurial_requests_count", "Total number of requests sent to hgweb servers.")
+
+// Repo is a Mercurial repository.
+type Repo struct {
+	// Name is the path of the repository relative to the URL of the hgweb
+	// index.
+	Name string `json:"name"`
+
+	// URL is the URL of the repository.
+	URL string `json:"url"`
+}
+
+// Client lists the repositories of an hgweb server.
+type Client struct {
+	// HTTP Client used to communicate with the server.
+	httpClient httpcli.Doer
+
+	// URL is the URL of the hgweb index.
+	URL *url.URL
+
+	// Username and Password are the credentials used for HTTP basic
+	// authentication. If Username is empty, requests are anonymous.
+	Username, Password string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewClient creates a new client for the hgweb index at baseURL. If a nil
+// httpClient is provided, httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// There is only one kind of request.
+		return "index"
+	})
+
+	return &Client{
+		httpClient:  httpClient,
+		URL:         baseURL,
+		rateLimiter: ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// Repo returns the repository with the given name, relative to the URL of the
+// hgweb index. It doesn't check that the repository exists.
+func (c *Client) Repo(name string) *Repo {
+	u := *c.URL
+	u.Path = strings.TrimSuffix(c.URL.Path, "/") + "/" + strings.Trim(name, "/")
+	return &Repo{Name: strings.Trim(name, "/"), URL: u.String()}
+}
+
+// ListRepos lists the repositories of the hgweb index, ordered by name.
+func (c *Client) ListRepos(ctx context.Context) ([]*Repo, error) {
+	if c.URL.Scheme != "http" && c.URL.Scheme != "https" {
+		return nil, errors.Errorf("listing repositories requires an http(s) URL, got %q", c.URL.Scheme)
+	}
+
+	// The raw style of the index lists the path of one repository per line.
+	u := *c.URL
+	u.RawQuery = url.Values{"style": {"raw"}}.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Mercurial"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	var repos []*Repo
+	basePath := strings.TrimSuffix(c.URL.Path, "/") + "/"
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		// Entries are absolute paths which include the path of the index.
+		name := strings.Trim(strings.TrimPrefix(line, basePath), "/")
+		if name == "" || !strings.HasPrefix(line, basePath) {
+			continue
+		}
+		repos = append(repos, c.Repo(name))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading hgweb index")
+	}
+
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("hgweb HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsUnauthorized reports whether err is an hgweb HTTP 401 or 403 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
@@ -0,0 +1,54 @@
+package mercurial
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/hg/"; have != want {
+			http.NotFound(w, r)
+			return
+		}
+		if have, want := r.URL.Query().Get("style"), "raw"; have != want {
+			t.Errorf("wrong style: have %q, want %q", have, want)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "/hg/zeta/\n/hg/team/alpha/\n\n")
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/hg/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(u, nil)
+
+	if _, err := cli.ListRepos(context.Background()); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	cli.Username, cli.Password = "admin", "secret"
+	have, err := cli.ListRepos(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Repo{
+		{Name: "team/alpha", URL: srv.URL + "/hg/team/alpha"},
+		{Name: "zeta", URL: srv.URL + "/hg/zeta"},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,227 @@
+// Package npm implements a client for the npm registry API, which is used to
+// find published versions of npm packages and download their tarballs.
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("npm_requests_count", "Total number of requests sent to npm registries.")
+
+// abbreviatedMetadataType is the media type of the abbreviated package
+// metadata, which only contains the fields needed to install a package and is
+// much smaller than the full document.
+const abbreviatedMetadataType = "application/vnd.npm.install-v1+json"
+
+// Client talks to an npm registry.
+type Client struct {
+	// HTTP Client used to communicate with the registry.
+	httpClient httpcli.Doer
+
+	// registryURL is the URL of the registry, without a trailing slash.
+	registryURL string
+
+	// credentials is the access token sent as a bearer token to the registry.
+	credentials string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewHTTPClient creates a new client for the npm registry at registryURL. If
+// a nil httpClient is provided, httpcli.ExternalDoer will be used.
+func NewHTTPClient(registryURL, credentials string, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		if strings.HasSuffix(u.Path, ".tgz") {
+			return "tarball"
+		}
+		return "package"
+	})
+
+	// The rate limiter is shared with the rate limit configured for the
+	// external service, which is keyed by the normalized registry URL.
+	limiterKey := registryURL
+	if u, err := url.Parse(registryURL); err == nil {
+		limiterKey = extsvc.NormalizeBaseURL(u).String()
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		registryURL: strings.TrimSuffix(registryURL, "/"),
+		credentials: credentials,
+		rateLimiter: ratelimit.DefaultRegistry.Get(limiterKey),
+	}
+}
+
+type packageInfo struct {
+	Versions map[string]*versionInfo `json:"versions"`
+}
+
+type versionInfo struct {
+	Dist struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+}
+
+// DoesDependencyExist returns true if the version of the package has been
+// published to the registry.
+func (c *Client) DoesDependencyExist(ctx context.Context, dependency reposource.NpmDependency) (bool, error) {
+	_, err := c.versionInfo(ctx, dependency)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FetchTarball downloads the tarball of the given version of a package. The
+// caller must close the returned reader.
+func (c *Client) FetchTarball(ctx context.Context, dependency reposource.NpmDependency) (io.ReadCloser, error) {
+	info, err := c.versionInfo(ctx, dependency)
+	if err != nil {
+		return nil, err
+	}
+	if info.Dist.Tarball == "" {
+		return nil, errors.Errorf("no tarball for npm dependency %s", dependency.PackageManagerSyntax())
+	}
+
+	resp, err := c.get(ctx, info.Dist.Tarball, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) versionInfo(ctx context.Context, dependency reposource.NpmDependency) (*versionInfo, error) {
+	// Scoped packages are requested with an escaped slash, for example
+	// "@types%2Fnode".
+	resp, err := c.get(ctx, c.registryURL+"/"+url.PathEscape(dependency.PackageSyntax()), abbreviatedMetadataType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var pkg packageInfo
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, errors.Wrapf(err, "decoding npm package metadata for %s", dependency.PackageSyntax())
+	}
+
+	info, ok := pkg.Versions[dependency.Version]
+	if !ok || info == nil {
+		return nil, errors.WithStack(&notFoundError{dependency: dependency.PackageManagerSyntax()})
+	}
+	return info, nil
+}
+
+// get sends a GET request to rawURL and returns the response if it was
+// successful. The body of the response must be closed by the caller.
+func (c *Client) get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	// Tarballs may be served by another host, which must not see the token.
+	if c.credentials != "" && c.isRegistryHost(req.URL) {
+		req.Header.Set("Authorization", "Bearer "+c.credentials)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("npm"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errors.WithStack(&notFoundError{url: req.URL})
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+	return resp, nil
+}
+
+func (c *Client) isRegistryHost(u *url.URL) bool {
+	registry, err := url.Parse(c.registryURL)
+	return err == nil && strings.EqualFold(registry.Host, u.Host)
+}
+
+type notFoundError struct {
+	dependency string
+	url        *url.URL
+}
+
+func (e *notFoundError) Error() string {
+	if e.dependency != "" {
+		return fmt.Sprintf("npm dependency %s not found", e.dependency)
+	}
+	return fmt.Sprintf("npm HTTP error: not found url=%q", e.url)
+}
+
+func (e *notFoundError) NotFound() bool {
+	return true
+}
+
+// IsNotFound reports whether err is caused by a package or version which
+// doesn't exist in the registry.
+func IsNotFound(err error) bool {
+	var e *notFoundError
+	return errors.As(err, &e)
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("npm HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsUnauthorized reports whether err is an npm HTTP 401 or 403 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
@@ -0,0 +1,77 @@
+package npm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+)
+
+func TestClient(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/@types%2Fnode":
+			if have, want := r.Header.Get("Accept"), abbreviatedMetadataType; have != want {
+				t.Errorf("wrong Accept header: have %q, want %q", have, want)
+			}
+			fmt.Fprintf(w, `{"versions": {"16.11.6": {"dist": {"tarball": "%s/@types/node/-/node-16.11.6.tgz"}}}}`, srv.URL)
+		case "/@types/node/-/node-16.11.6.tgz":
+			fmt.Fprint(w, "tarball")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	dependency, err := reposource.ParseNpmDependency("@types/node@16.11.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPClient(srv.URL, "", nil).DoesDependencyExist(ctx, dependency); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	cli := NewHTTPClient(srv.URL+"/", "secret", nil)
+
+	for dep, want := range map[string]bool{
+		"@types/node@16.11.6": true,
+		"@types/node@1.0.0":   false,
+		"left-pad@1.3.0":      false,
+	} {
+		dependency, err := reposource.ParseNpmDependency(dep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exists, err := cli.DoesDependencyExist(ctx, dependency)
+		if err != nil {
+			t.Fatalf("%s: %s", dep, err)
+		}
+		if exists != want {
+			t.Errorf("%s: have exists=%t, want %t", dep, exists, want)
+		}
+	}
+
+	rc, err := cli.FetchTarball(ctx, dependency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := string(body), "tarball"; have != want {
+		t.Errorf("wrong tarball: have %q, want %q", have, want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package registry implements a client for the APIs of the language package
+// registries crates.io, PyPI and RubyGems, which is used to find published
+// versions of packages and download their sources.
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("package_registry_requests_count", "Total number of requests sent to package registries.")
+
+// userAgent is sent with every request. crates.io rejects requests without a
+// User-Agent header.
+const userAgent = "Sourcegraph"
+
+// Client talks to the registry of one of the schemes supported by
+// reposource.RegistryPackage.
+type Client struct {
+	// HTTP Client used to communicate with the registry.
+	httpClient httpcli.Doer
+
+	// scheme is the moniker scheme of the packages of the registry.
+	scheme string
+
+	// registryURL is the URL of the registry, without a trailing slash.
+	registryURL string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewHTTPClient creates a new client for the registry at registryURL, which
+// hosts packages of the given scheme. If a nil httpClient is provided,
+// httpcli.ExternalDoer will be used.
+func NewHTTPClient(scheme, registryURL string, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		if strings.HasSuffix(u.Path, "/download") || strings.HasSuffix(u.Path, ".tar.gz") || strings.HasSuffix(u.Path, ".gem") {
+			return scheme + "_archive"
+		}
+		return scheme + "_package"
+	})
+
+	// The rate limiter is shared with the rate limit configured for the
+	// external service, which is keyed by the normalized registry URL.
+	limiterKey := registryURL
+	if u, err := url.Parse(registryURL); err == nil {
+		limiterKey = extsvc.NormalizeBaseURL(u).String()
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		scheme:      scheme,
+		registryURL: strings.TrimSuffix(registryURL, "/"),
+		rateLimiter: ratelimit.DefaultRegistry.Get(limiterKey),
+	}
+}
+
+// DoesDependencyExist returns true if the version of the package has been
+// published to the registry.
+func (c *Client) DoesDependencyExist(ctx context.Context, dependency reposource.RegistryDependency) (bool, error) {
+	var err error
+	switch c.scheme {
+	case "pypi":
+		_, err = c.pythonSourceURL(ctx, dependency)
+	default:
+		var resp *http.Response
+		if resp, err = c.get(ctx, c.versionURL(dependency)); err == nil {
+			resp.Body.Close()
+		}
+	}
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FetchTarball downloads the sources of the given version of a package as a
+// gzipped tarball. The caller must close the returned reader. If
+// topLevelDirectory is true, all files of the tarball are inside a single
+// directory, like "serde_json-1.0.68/", which isn't part of the package.
+func (c *Client) FetchTarball(ctx context.Context, dependency reposource.RegistryDependency) (tarball io.ReadCloser, topLevelDirectory bool, err error) {
+	switch c.scheme {
+	case "cargo":
+		// Crates are gzipped tarballs with a "<name>-<version>/" directory.
+		resp, err := c.get(ctx, c.versionURL(dependency)+"/download")
+		if err != nil {
+			return nil, false, err
+		}
+		return resp.Body, true, nil
+
+	case "pypi":
+		// Source distributions are gzipped tarballs with a
+		// "<name>-<version>/" directory.
+		sourceURL, err := c.pythonSourceURL(ctx, dependency)
+		if err != nil {
+			return nil, false, err
+		}
+		resp, err := c.get(ctx, sourceURL)
+		if err != nil {
+			return nil, false, err
+		}
+		return resp.Body, true, nil
+
+	case "gem":
+		// Gems are plain tarballs which contain the files of the gem as the
+		// gzipped tarball "data.tar.gz", without a top-level directory.
+		resp, err := c.get(ctx, fmt.Sprintf("%s/downloads/%s-%s.gem", c.registryURL, url.PathEscape(dependency.Name), url.PathEscape(dependency.Version)))
+		if err != nil {
+			return nil, false, err
+		}
+		data, err := gemDataTarball(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, false, errors.Wrapf(err, "reading gem %s", dependency.PackageManagerSyntax())
+		}
+		return &readCloser{Reader: data, Closer: resp.Body}, false, nil
+	}
+
+	return nil, false, errors.Errorf("unsupported package registry scheme %q", c.scheme)
+}
+
+// versionURL returns the API URL of the given version of a crate or gem.
+func (c *Client) versionURL(dependency reposource.RegistryDependency) string {
+	name, version := url.PathEscape(dependency.Name), url.PathEscape(dependency.Version)
+	if c.scheme == "gem" {
+		return fmt.Sprintf("%s/api/v2/rubygems/%s/versions/%s.json", c.registryURL, name, version)
+	}
+	return fmt.Sprintf("%s/api/v1/crates/%s/%s", c.registryURL, name, version)
+}
+
+type pythonRelease struct {
+	URLs []struct {
+		PackageType string `json:"packagetype"`
+		URL         string `json:"url"`
+	} `json:"urls"`
+}
+
+// pythonSourceURL returns the URL of the source distribution of the given
+// version of a PyPI package. Wheels are skipped because they may contain
+// compiled code instead of sources.
+func (c *Client) pythonSourceURL(ctx context.Context, dependency reposource.RegistryDependency) (string, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("%s/pypi/%s/%s/json", c.registryURL, url.PathEscape(dependency.Name), url.PathEscape(dependency.Version)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var release pythonRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", errors.Wrapf(err, "decoding PyPI release metadata for %s", dependency.PackageManagerSyntax())
+	}
+
+	for _, u := range release.URLs {
+		if u.PackageType == "sdist" && strings.HasSuffix(u.URL, ".tar.gz") {
+			return u.URL, nil
+		}
+	}
+	return "", errors.WithStack(&notFoundError{dependency: dependency.PackageManagerSyntax()})
+}
+
+// gemDataTarball returns a reader for the "data.tar.gz" file of the given
+// gem.
+func gemDataTarball(r io.Reader) (io.Reader, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("data.tar.gz not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Clean(header.Name) == "data.tar.gz" {
+			return tr, nil
+		}
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// get sends a GET request to rawURL and returns the response if it was
+// successful. The body of the response must be closed by the caller.
+func (c *Client) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName(c.scheme),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errors.WithStack(&notFoundError{url: req.URL})
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+	return resp, nil
+}
+
+type notFoundError struct {
+	dependency string
+	url        *url.URL
+}
+
+func (e *notFoundError) Error() string {
+	if e.dependency != "" {
+		return fmt.Sprintf("package registry dependency %s not found", e.dependency)
+	}
+	return fmt.Sprintf("package registry HTTP error: not found url=%q", e.url)
+}
+
+func (e *notFoundError) NotFound() bool {
+	return true
+}
+
+// IsNotFound reports whether err is caused by a package or version which
+// doesn't exist in the registry.
+func IsNotFound(err error) bool {
+	var e *notFoundError
+	return errors.As(err, &e)
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("package registry HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
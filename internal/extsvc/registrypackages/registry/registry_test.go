@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+)
+
+func TestClient(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.Header.Get("User-Agent"), userAgent; have != want {
+			t.Errorf("wrong User-Agent header: have %q, want %q", have, want)
+		}
+		switch r.URL.Path {
+		case "/api/v1/crates/serde_json/1.0.68":
+			fmt.Fprint(w, `{"version": {"num": "1.0.68"}}`)
+		case "/api/v1/crates/serde_json/1.0.68/download":
+			fmt.Fprint(w, "crate")
+		case "/pypi/flask-sqlalchemy/2.5.1/json":
+			fmt.Fprintf(w, `{"urls": [
+				{"packagetype": "bdist_wheel", "url": "%[1]s/Flask_SQLAlchemy-2.5.1-py2.py3-none-any.whl"},
+				{"packagetype": "sdist", "url": "%[1]s/Flask-SQLAlchemy-2.5.1.tar.gz"}
+			]}`, srv.URL)
+		case "/pypi/wheel-only/1.0.0/json":
+			fmt.Fprintf(w, `{"urls": [{"packagetype": "bdist_wheel", "url": "%s/wheel_only-1.0.0-py3-none-any.whl"}]}`, srv.URL)
+		case "/Flask-SQLAlchemy-2.5.1.tar.gz":
+			fmt.Fprint(w, "sdist")
+		case "/api/v2/rubygems/rails/versions/6.1.4.json":
+			fmt.Fprint(w, `{"number": "6.1.4"}`)
+		case "/downloads/rails-6.1.4.gem":
+			w.Write(makeGem(t, "data"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		scheme     string
+		dependency string
+		exists     bool
+		tarball    string
+		topLevel   bool
+	}{
+		{scheme: "cargo", dependency: "serde_json@1.0.68", exists: true, tarball: "crate", topLevel: true},
+		{scheme: "cargo", dependency: "serde_json@0.1.0"},
+		{scheme: "pypi", dependency: "Flask_SQLAlchemy==2.5.1", exists: true, tarball: "sdist", topLevel: true},
+		{scheme: "pypi", dependency: "wheel-only==1.0.0"},
+		{scheme: "gem", dependency: "rails@6.1.4", exists: true, tarball: "data"},
+		{scheme: "gem", dependency: "rails@0.1.0"},
+	} {
+		t.Run(tc.dependency, func(t *testing.T) {
+			dependency, err := reposource.ParseRegistryDependency(tc.scheme, tc.dependency)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cli := NewHTTPClient(tc.scheme, srv.URL+"/", nil)
+
+			exists, err := cli.DoesDependencyExist(ctx, dependency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != tc.exists {
+				t.Fatalf("have exists=%t, want %t", exists, tc.exists)
+			}
+
+			rc, topLevel, err := cli.FetchTarball(ctx, dependency)
+			if !tc.exists {
+				if !IsNotFound(err) {
+					t.Fatalf("expected not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			body, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := string(body); have != tc.tarball {
+				t.Errorf("wrong tarball: have %q, want %q", have, tc.tarball)
+			}
+			if topLevel != tc.topLevel {
+				t.Errorf("wrong topLevelDirectory: have %t, want %t", topLevel, tc.topLevel)
+			}
+		})
+	}
+}
+
+// makeGem returns a gem whose data.tar.gz file has the given contents.
+func makeGem(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct{ name, body string }{
+		{"metadata.gz", "metadata"},
+		{"data.tar.gz", data},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
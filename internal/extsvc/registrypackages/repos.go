@@ -0,0 +1,7 @@
+package registrypackages
+
+import "github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+
+type Metadata struct {
+	Package reposource.RegistryPackage
+}
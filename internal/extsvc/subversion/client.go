@@ -0,0 +1,194 @@
+// Package subversion implements a client that lists the repositories served by
+// a Subversion server.
+package subversion
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("subversion_requests_count", "Total number of requests sent to Subversion servers.")
+
+// Repo is a Subversion repository.
+type Repo struct {
+	// Name is the path of the repository relative to the URL of the server.
+	Name string `json:"name"`
+
+	// URL is the URL of the repository.
+	URL string `json:"url"`
+}
+
+// Client lists the repositories of a Subversion server.
+type Client struct {
+	// HTTP Client used to communicate with the server.
+	httpClient httpcli.Doer
+
+	// URL is the URL of the parent path which contains the repositories.
+	URL *url.URL
+
+	// Username and Password are the credentials used for HTTP basic
+	// authentication. If Username is empty, requests are anonymous.
+	Username, Password string
+
+	rateLimiter *rate.Limiter
+}
+
+// NewClient creates a new client for the Subversion server with the given
+// parent path URL. If a nil httpClient is provided, httpcli.ExternalDoer will
+// be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// There is only one kind of request.
+		return "list"
+	})
+
+	return &Client{
+		httpClient:  httpClient,
+		URL:         baseURL,
+		rateLimiter: ratelimit.DefaultRegistry.Get(baseURL.String()),
+	}
+}
+
+// Repo returns the repository with the given name, relative to the URL of the
+// server. It doesn't check that the repository exists.
+func (c *Client) Repo(name string) *Repo {
+	u := *c.URL
+	u.Path = strings.TrimSuffix(c.URL.Path, "/") + "/" + strings.Trim(name, "/")
+	return &Repo{Name: strings.Trim(name, "/"), URL: u.String()}
+}
+
+// ListRepos lists the repositories of the server, ordered by name. They are
+// read from the HTML index Apache serves for a parent path when
+// SVNListParentPath is enabled.
+func (c *Client) ListRepos(ctx context.Context) ([]*Repo, error) {
+	if c.URL.Scheme != "http" && c.URL.Scheme != "https" {
+		return nil, errors.Errorf("listing repositories requires an http(s) URL, got %q", c.URL.Scheme)
+	}
+
+	req, err := http.NewRequest("GET", c.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Subversion"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	names, err := parseIndex(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Subversion parent path index")
+	}
+
+	repos := make([]*Repo, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, c.Repo(name))
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+// parseIndex returns the names of the repositories linked from a parent path
+// index. Every repository is linked relative to the index as "name/".
+func parseIndex(r io.Reader) ([]string, error) {
+	var names []string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return names, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken:
+			tag, hasAttr := z.TagName()
+			if string(tag) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				if name, ok := repoName(string(val)); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+}
+
+func repoName(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.IsAbs() || u.Host != "" || u.RawQuery != "" {
+		return "", false
+	}
+	name := strings.TrimSuffix(u.Path, "/")
+	if name == u.Path || name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Subversion HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsUnauthorized reports whether err is a Subversion HTTP 401 or 403 error.
+func IsUnauthorized(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.Unauthorized()
+}
@@ -0,0 +1,75 @@
+package subversion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// index is the listing Apache serves for a parent path with
+// SVNListParentPath on.
+const index = `<html><head><title>Collection of Repositories</title></head>
+<body>
+<h2>Collection of Repositories</h2>
+<ul>
+<li><a href="zeta/">zeta/</a></li>
+<li><a href="alpha/">alpha/</a></li>
+<li><a href="with%20space/">with space/</a></li>
+<li><a href="https://subversion.apache.org/">Powered by Apache Subversion</a></li>
+</ul>
+</body></html>`
+
+func TestClient_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/svn/"; have != want {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, index)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/svn/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(u, nil)
+
+	if _, err := cli.ListRepos(context.Background()); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	cli.Username, cli.Password = "admin", "secret"
+	have, err := cli.ListRepos(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Repo{
+		{Name: "alpha", URL: srv.URL + "/svn/alpha"},
+		{Name: "with space", URL: srv.URL + "/svn/with%20space"},
+		{Name: "zeta", URL: srv.URL + "/svn/zeta"},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
+
+func TestClient_ListRepos_NotHTTP(t *testing.T) {
+	u, err := url.Parse("svn://svn.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(u, nil).ListRepos(context.Background()); err == nil {
+		t.Fatal("expected error listing repos of svn:// URL")
+	}
+}
@@ -79,10 +79,18 @@ const (
 	KindBitbucketCloud  = "BITBUCKETCLOUD"
 	KindGitHub          = "GITHUB"
 	KindGitLab          = "GITLAB"
+	KindGitea           = "GITEA"
+	KindGerrit          = "GERRIT"
 	KindGitolite        = "GITOLITE"
 	KindPerforce        = "PERFORCE"
 	KindPhabricator     = "PHABRICATOR"
 	KindJVMPackages     = "JVMPACKAGES"
+	KindNpmPackages     = "NPMPACKAGES"
+	KindRustPackages    = "RUSTPACKAGES"
+	KindPythonPackages  = "PYTHONPACKAGES"
+	KindRubyPackages    = "RUBYPACKAGES"
+	KindSubversion      = "SUBVERSION"
+	KindMercurial       = "MERCURIAL"
 	KindOther           = "OTHER"
 )
 
@@ -110,6 +118,14 @@ const (
 	// value is the base URL to the GitLab instance (https://gitlab.com or self-hosted GitLab URL).
 	TypeGitLab = "gitlab"
 
+	// TypeGitea is the (api.ExternalRepoSpec).ServiceType value for Gitea repositories. The ServiceID
+	// value is the base URL to the Gitea instance.
+	TypeGitea = "gitea"
+
+	// TypeGerrit is the (api.ExternalRepoSpec).ServiceType value for Gerrit projects. The ServiceID
+	// value is the base URL to the Gerrit instance.
+	TypeGerrit = "gerrit"
+
 	// TypeGitolite is the (api.ExternalRepoSpec).ServiceType value for Gitolite projects.
 	TypeGitolite = "gitolite"
 
@@ -122,6 +138,26 @@ const (
 	// TypeJVMPackages is the (api.ExternalRepoSpec).ServiceType value for Maven packages (Java/JVM ecosystem libraries).
 	TypeJVMPackages = "jvmPackages"
 
+	// TypeNpmPackages is the (api.ExternalRepoSpec).ServiceType value for npm packages (JavaScript/TypeScript ecosystem libraries).
+	TypeNpmPackages = "npmPackages"
+
+	// TypeRustPackages is the (api.ExternalRepoSpec).ServiceType value for crates.io packages (Rust ecosystem libraries).
+	TypeRustPackages = "rustPackages"
+
+	// TypePythonPackages is the (api.ExternalRepoSpec).ServiceType value for PyPI packages (Python ecosystem libraries).
+	TypePythonPackages = "pythonPackages"
+
+	// TypeRubyPackages is the (api.ExternalRepoSpec).ServiceType value for RubyGems packages (Ruby ecosystem libraries).
+	TypeRubyPackages = "rubyPackages"
+
+	// TypeSubversion is the (api.ExternalRepoSpec).ServiceType value for Subversion repositories. The
+	// ServiceID value is the base URL of the Subversion server.
+	TypeSubversion = "subversion"
+
+	// TypeMercurial is the (api.ExternalRepoSpec).ServiceType value for Mercurial repositories. The
+	// ServiceID value is the base URL of the hgweb index.
+	TypeMercurial = "mercurial"
+
 	// TypeOther is the (api.ExternalRepoSpec).ServiceType value for other projects.
 	TypeOther = "other"
 
@@ -142,6 +178,10 @@ func KindToType(kind string) string {
 		return TypeGitHub
 	case KindGitLab:
 		return TypeGitLab
+	case KindGitea:
+		return TypeGitea
+	case KindGerrit:
+		return TypeGerrit
 	case KindGitolite:
 		return TypeGitolite
 	case KindPhabricator:
@@ -150,6 +190,18 @@ func KindToType(kind string) string {
 		return TypePerforce
 	case KindJVMPackages:
 		return TypeJVMPackages
+	case KindNpmPackages:
+		return TypeNpmPackages
+	case KindRustPackages:
+		return TypeRustPackages
+	case KindPythonPackages:
+		return TypePythonPackages
+	case KindRubyPackages:
+		return TypeRubyPackages
+	case KindSubversion:
+		return TypeSubversion
+	case KindMercurial:
+		return TypeMercurial
 	case KindOther:
 		return TypeOther
 	default:
@@ -171,6 +223,10 @@ func TypeToKind(t string) string {
 		return KindGitHub
 	case TypeGitLab:
 		return KindGitLab
+	case TypeGitea:
+		return KindGitea
+	case TypeGerrit:
+		return KindGerrit
 	case TypeGitolite:
 		return KindGitolite
 	case TypePerforce:
@@ -179,6 +235,18 @@ func TypeToKind(t string) string {
 		return KindPhabricator
 	case TypeJVMPackages:
 		return KindJVMPackages
+	case TypeNpmPackages:
+		return KindNpmPackages
+	case TypeRustPackages:
+		return KindRustPackages
+	case TypePythonPackages:
+		return KindPythonPackages
+	case TypeRubyPackages:
+		return KindRubyPackages
+	case TypeSubversion:
+		return KindSubversion
+	case TypeMercurial:
+		return KindMercurial
 	case TypeOther:
 		return KindOther
 	default:
@@ -191,6 +259,11 @@ var (
 	bbsLower = strings.ToLower(TypeBitbucketServer)
 	bbcLower = strings.ToLower(TypeBitbucketCloud)
 	jvmLower = strings.ToLower(TypeJVMPackages)
+	npmLower = strings.ToLower(TypeNpmPackages)
+
+	rustLower   = strings.ToLower(TypeRustPackages)
+	pythonLower = strings.ToLower(TypePythonPackages)
+	rubyLower   = strings.ToLower(TypeRubyPackages)
 )
 
 // ParseServiceType will return a ServiceType constant after doing a case insensitive match on s.
@@ -207,6 +280,10 @@ func ParseServiceType(s string) (string, bool) {
 		return TypeGitHub, true
 	case TypeGitLab:
 		return TypeGitLab, true
+	case TypeGitea:
+		return TypeGitea, true
+	case TypeGerrit:
+		return TypeGerrit, true
 	case TypeGitolite:
 		return TypeGitolite, true
 	case TypePerforce:
@@ -215,6 +292,18 @@ func ParseServiceType(s string) (string, bool) {
 		return TypePhabricator, true
 	case jvmLower:
 		return TypeJVMPackages, true
+	case npmLower:
+		return TypeNpmPackages, true
+	case rustLower:
+		return TypeRustPackages, true
+	case pythonLower:
+		return TypePythonPackages, true
+	case rubyLower:
+		return TypeRubyPackages, true
+	case TypeSubversion:
+		return TypeSubversion, true
+	case TypeMercurial:
+		return TypeMercurial, true
 	case TypeOther:
 		return TypeOther, true
 	default:
@@ -236,6 +325,10 @@ func ParseServiceKind(s string) (string, bool) {
 		return KindGitHub, true
 	case KindGitLab:
 		return KindGitLab, true
+	case KindGitea:
+		return KindGitea, true
+	case KindGerrit:
+		return KindGerrit, true
 	case KindGitolite:
 		return KindGitolite, true
 	case KindPerforce:
@@ -244,6 +337,18 @@ func ParseServiceKind(s string) (string, bool) {
 		return KindPhabricator, true
 	case KindJVMPackages:
 		return KindJVMPackages, true
+	case KindNpmPackages:
+		return KindNpmPackages, true
+	case KindRustPackages:
+		return KindRustPackages, true
+	case KindPythonPackages:
+		return KindPythonPackages, true
+	case KindRubyPackages:
+		return KindRubyPackages, true
+	case KindSubversion:
+		return KindSubversion, true
+	case KindMercurial:
+		return KindMercurial, true
 	case KindOther:
 		return KindOther, true
 	default:
@@ -277,6 +382,10 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 		cfg = &schema.GitHubConnection{}
 	case KindGitLab:
 		cfg = &schema.GitLabConnection{}
+	case KindGitea:
+		cfg = &schema.GiteaConnection{}
+	case KindGerrit:
+		cfg = &schema.GerritConnection{}
 	case KindGitolite:
 		cfg = &schema.GitoliteConnection{}
 	case KindPerforce:
@@ -285,6 +394,18 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 		cfg = &schema.PhabricatorConnection{}
 	case KindJVMPackages:
 		cfg = &schema.JVMPackagesConnection{}
+	case KindNpmPackages:
+		cfg = &schema.NpmPackagesConnection{}
+	case KindRustPackages:
+		cfg = &schema.RustPackagesConnection{}
+	case KindPythonPackages:
+		cfg = &schema.PythonPackagesConnection{}
+	case KindRubyPackages:
+		cfg = &schema.RubyPackagesConnection{}
+	case KindSubversion:
+		cfg = &schema.SubversionConnection{}
+	case KindMercurial:
+		cfg = &schema.MercurialConnection{}
 	case KindOther:
 		cfg = &schema.OtherExternalServiceConnection{}
 	default:
@@ -324,6 +445,8 @@ func ExtractToken(config string, kind string) (string, error) {
 		return c.Token, nil
 	case *schema.BitbucketServerConnection:
 		return c.Token, nil
+	case *schema.GiteaConnection:
+		return c.Token, nil
 	case *schema.PhabricatorConnection:
 		return c.Token, nil
 	default:
@@ -410,6 +533,34 @@ func GetLimitFromConfig(kind string, config interface{}) (rlc RateLimitConfig, e
 			rlc.IsDefault = false
 		}
 		rlc.BaseURL = "maven"
+	case *schema.NpmPackagesConnection:
+		rlc.Limit = rate.Limit(3000.0 / 3600.0)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Registry
+	case *schema.RustPackagesConnection:
+		rlc.Limit = rate.Limit(3000.0 / 3600.0)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Registry
+	case *schema.PythonPackagesConnection:
+		rlc.Limit = rate.Limit(3000.0 / 3600.0)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Registry
+	case *schema.RubyPackagesConnection:
+		rlc.Limit = rate.Limit(3000.0 / 3600.0)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Registry
 	default:
 		return rlc, ErrRateLimitUnsupported{codehostKind: kind}
 	}
@@ -492,8 +643,16 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		rawURL = c.Url
 	case *schema.BitbucketCloudConnection:
 		rawURL = c.Url
+	case *schema.GiteaConnection:
+		rawURL = c.Url
+	case *schema.GerritConnection:
+		rawURL = c.Url
 	case *schema.PhabricatorConnection:
 		rawURL = c.Url
+	case *schema.SubversionConnection:
+		rawURL = c.Url
+	case *schema.MercurialConnection:
+		rawURL = c.Url
 	case *schema.OtherExternalServiceConnection:
 		rawURL = c.Url
 	case *schema.GitoliteConnection:
@@ -507,6 +666,14 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		return c.P4Port, nil
 	case *schema.JVMPackagesConnection:
 		return KindJVMPackages, nil
+	case *schema.NpmPackagesConnection:
+		return KindNpmPackages, nil
+	case *schema.RustPackagesConnection:
+		return KindRustPackages, nil
+	case *schema.PythonPackagesConnection:
+		return KindPythonPackages, nil
+	case *schema.RubyPackagesConnection:
+		return KindRubyPackages, nil
 	default:
 		return "", errors.Errorf("unknown external service kind: %s", kind)
 	}
@@ -156,6 +156,30 @@ func TestExtractRateLimitConfig(t *testing.T) {
 				IsDefault:   false,
 			},
 		},
+		{
+			name:        "npm default",
+			config:      `{"registry": "https://registry.npmjs.org"}`,
+			kind:        KindNpmPackages,
+			displayName: "npm 1",
+			want: RateLimitConfig{
+				BaseURL:     "https://registry.npmjs.org/",
+				DisplayName: "npm 1",
+				Limit:       0.8333333333333334,
+				IsDefault:   true,
+			},
+		},
+		{
+			name:        "crates.io default",
+			config:      `{"registry": "https://crates.io"}`,
+			kind:        KindRustPackages,
+			displayName: "crates.io 1",
+			want: RateLimitConfig{
+				BaseURL:     "https://crates.io/",
+				DisplayName: "crates.io 1",
+				Limit:       0.8333333333333334,
+				IsDefault:   true,
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			rlc, err := ExtractRateLimitConfig(tc.config, tc.kind, tc.displayName)
@@ -304,6 +328,26 @@ func TestUniqueCodeHostIdentifier(t *testing.T) {
 			want:   "https://bitbucket.org/",
 		},
 
+		{
+			kind:   KindGitea,
+			config: `{"url": "https://gitea.sgdev.org/"}`,
+			want:   "https://gitea.sgdev.org/",
+		},
+		{
+			kind:   KindGerrit,
+			config: `{"url": "https://gerrit.sgdev.org"}`,
+			want:   "https://gerrit.sgdev.org/",
+		},
+		{
+			kind:   KindSubversion,
+			config: `{"url": "https://svn.sgdev.org/repos"}`,
+			want:   "https://svn.sgdev.org/repos/",
+		},
+		{
+			kind:   KindMercurial,
+			config: `{"url": "https://hg.sgdev.org"}`,
+			want:   "https://hg.sgdev.org/",
+		},
 		{
 			kind:   KindGitolite,
 			config: `{"host": "ssh://git@gitolite.example.com:2222/"}`,
@@ -324,6 +368,16 @@ func TestUniqueCodeHostIdentifier(t *testing.T) {
 			config: `{"url": "https://phabricator.sgdev.org/"}`,
 			want:   "https://phabricator.sgdev.org/",
 		},
+		{
+			kind:   KindNpmPackages,
+			config: `{"registry": "https://registry.npmjs.org"}`,
+			want:   KindNpmPackages,
+		},
+		{
+			kind:   KindRubyPackages,
+			config: `{"registry": "https://rubygems.org"}`,
+			want:   KindRubyPackages,
+		},
 		{
 			kind:   KindOther,
 			config: `{"url": "ssh://user@host.xz:2333/"}`,
@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+type Config struct {
+	env.BaseConfig
+
+	Backend  string
+	Interval time.Duration
+	Bucket   string
+	S3       S3Config
+	Local    LocalConfig
+}
+
+type loader interface {
+	load(parent *env.BaseConfig)
+}
+
+func (c *Config) Load() {
+	c.Backend = strings.ToLower(c.GetOptional("SRC_GITSERVER_BACKUP_BACKEND", "The blob store gitserver writes repository backups to. Local, S3 and MinIO are supported. Backups are disabled if unset."))
+	c.Interval = c.GetInterval("SRC_GITSERVER_BACKUP_INTERVAL", "24h", "Interval between backups of each repository.")
+	c.Bucket = c.Get("SRC_GITSERVER_BACKUP_BUCKET", "gitserver-backups", "The name of the bucket to store repository backups in.")
+
+	if c.Backend == "" {
+		return
+	}
+
+	loaders := map[string]loader{
+		"local": &c.Local,
+		"s3":    &c.S3,
+		"minio": &c.S3,
+	}
+
+	config, ok := loaders[c.Backend]
+	if !ok {
+		c.AddError(errors.Errorf("invalid backend %q for SRC_GITSERVER_BACKUP_BACKEND: must be Local, S3 or MinIO", c.Backend))
+		return
+	}
+
+	config.load(&c.BaseConfig)
+
+	if c.Backend == "minio" && c.S3.Endpoint == "" {
+		c.AddError(errors.New("SRC_GITSERVER_BACKUP_AWS_ENDPOINT is required for the MinIO backend"))
+	}
+}
@@ -0,0 +1,167 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+type localStore struct {
+	root string
+}
+
+var _ Store = &localStore{}
+
+type LocalConfig struct {
+	Dir string
+}
+
+func (c *LocalConfig) load(parent *env.BaseConfig) {
+	c.Dir = parent.Get("SRC_GITSERVER_BACKUP_LOCAL_DIR", "", "The directory to store repository backups in. It should not be on the same disk as SRC_REPOS_DIR.")
+}
+
+// newLocalFromConfig creates a new store backed by a directory on the local
+// filesystem. Objects are stored in a subdirectory of the configured
+// directory named after the bucket.
+func newLocalFromConfig(ctx context.Context, config *Config) (Store, error) {
+	if config.Local.Dir == "" {
+		return nil, errors.New("SRC_GITSERVER_BACKUP_LOCAL_DIR is required for the local backup store")
+	}
+
+	return NewLocalStore(filepath.Join(config.Local.Dir, config.Bucket)), nil
+}
+
+// NewLocalStore returns a store which keeps objects in files under root.
+func NewLocalStore(root string) Store {
+	return &localStore{root: filepath.Clean(root)}
+}
+
+func (s *localStore) Init(ctx context.Context) error {
+	if err := os.MkdirAll(s.root, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	return nil
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &notFoundError{key: key}
+		}
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return f, nil
+}
+
+// Put writes the content into a temporary file and moves it to the path of
+// the given key once complete, so that readers never observe a partially
+// written object.
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer func() {
+		if err != nil {
+			if removeErr := os.Remove(tmp.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+				err = multierror.Append(err, removeErr)
+			}
+		}
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if syncErr := tmp.Sync(); err == nil && syncErr != nil {
+		err = syncErr
+	}
+	if closeErr := tmp.Close(); closeErr != nil {
+		err = multierror.Append(err, errors.Wrap(closeErr, "failed to close file"))
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+// path returns the path of the file backing the object with the given key.
+// Keys which would resolve to a path outside of the root directory are
+// rejected.
+func (s *localStore) path(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid key %q", key)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+	if err := store.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(ctx, "a/b"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	for key, content := range map[string]string{
+		"a/b":   "ab",
+		"a/c/d": "acd",
+		"e":     "e",
+	} {
+		if err := store.Put(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Put(ctx, "a/b", strings.NewReader("replaced")); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := store.Get(ctx, "a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := string(content), "replaced"; have != want {
+		t.Errorf("wrong content: have %q, want %q", have, want)
+	}
+
+	keys, err := store.List(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a/b", "a/c/d"}, keys); diff != "" {
+		t.Errorf("unexpected keys (-want +have):\n%s", diff)
+	}
+
+	if err := store.Delete(ctx, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "a/b"); err != nil {
+		t.Fatalf("deleting a missing object: %v", err)
+	}
+	if _, err := store.Get(ctx, "a/b"); !IsNotFound(err) {
+		t.Fatalf("expected not found error after delete, got %v", err)
+	}
+
+	for _, key := range []string{"../escape", "a/../../escape", ""} {
+		if err := store.Put(ctx, key, strings.NewReader("bad")); err == nil {
+			t.Errorf("expected error writing key %q", key)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+const (
+	reposPrefix      = "repos/"
+	manifestFileName = "manifest.json"
+)
+
+// A Manifest describes the backup of a single repository. The repository is
+// restored by unbundling each bundle in order and then pointing the refs at
+// the objects recorded in the manifest.
+type Manifest struct {
+	// Repo is the name of the backed up repository.
+	Repo api.RepoName
+
+	// Type is the type of VCS syncer which created the repository, as stored
+	// in the sourcegraph.type git config.
+	Type string `json:",omitempty"`
+
+	// Head is the ref HEAD pointed to at the time of the last backup.
+	Head string
+
+	// Refs maps every ref in the repository at the time of the last backup to
+	// the object it pointed to.
+	Refs map[string]string
+
+	// RefHash is the hash gitserver computed of the refs at the time of the
+	// last backup. If it has not changed there is nothing new to back up.
+	RefHash string
+
+	// Bundles are the git bundles making up the backup, oldest first. Each
+	// bundle only contains the objects which are not reachable from the refs
+	// of the backup before it was created.
+	Bundles []Bundle
+
+	// UpdatedAt is the time of the last backup.
+	UpdatedAt time.Time
+}
+
+// A Bundle is a single git bundle of a repository backup.
+type Bundle struct {
+	// Key is the key of the bundle in the store.
+	Key string
+
+	// Size is the size of the bundle in bytes.
+	Size int64
+
+	// CreatedAt is the time the bundle was created.
+	CreatedAt time.Time
+}
+
+// ManifestKey returns the key of the manifest of the given repository.
+func ManifestKey(repo api.RepoName) string {
+	return reposPrefix + string(repo) + "/" + manifestFileName
+}
+
+// BundleKey returns the key for a new bundle of the given repository created
+// at the given time.
+func BundleKey(repo api.RepoName, createdAt time.Time) string {
+	return reposPrefix + string(repo) + "/bundles/" + createdAt.UTC().Format("20060102T150405.000000000Z") + ".bundle"
+}
+
+// ReadManifest returns the manifest of the backup of the given repository.
+// It returns an error for which IsNotFound is true if the repository has not
+// been backed up.
+func ReadManifest(ctx context.Context, store Store, repo api.RepoName) (*Manifest, error) {
+	rc, err := store.Get(ctx, ManifestKey(repo))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(rc).Decode(&m); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode backup manifest of %s", repo)
+	}
+	return &m, nil
+}
+
+// WriteManifest writes the manifest of the backup of m.Repo. Since the
+// manifest is the only object referencing the bundles of the backup, it
+// should only be written once all bundles it references exist.
+func WriteManifest(ctx context.Context, store Store, m *Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, ManifestKey(m.Repo), bytes.NewReader(b))
+}
+
+// DeleteBackup deletes the backup of the given repository. The manifest is
+// deleted first, so that a partially deleted backup is never restored. It is
+// not an error to delete a backup which does not exist.
+func DeleteBackup(ctx context.Context, store Store, repo api.RepoName) error {
+	m, err := ReadManifest(ctx, store, repo)
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := store.Delete(ctx, ManifestKey(repo)); err != nil {
+		return err
+	}
+	for _, b := range m.Bundles {
+		if err := store.Delete(ctx, b.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRepos returns the names of all repositories with a backup in store.
+func ListRepos(ctx context.Context, store Store) ([]api.RepoName, error) {
+	keys, err := store.List(ctx, reposPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []api.RepoName
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, reposPrefix)
+		if !strings.HasSuffix(name, "/"+manifestFileName) {
+			continue
+		}
+		repos = append(repos, api.RepoName(strings.TrimSuffix(name, "/"+manifestFileName)))
+	}
+	return repos, nil
+}
@@ -0,0 +1,79 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestManifest(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+
+	if _, err := ReadManifest(ctx, store, "github.com/foo/bar"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	createdAt := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	want := &Manifest{
+		Repo:    "github.com/foo/bar",
+		Type:    "git",
+		Head:    "refs/heads/main",
+		Refs:    map[string]string{"refs/heads/main": "1111111111111111111111111111111111111111"},
+		RefHash: "abc",
+		Bundles: []Bundle{{
+			Key:       BundleKey("github.com/foo/bar", createdAt),
+			Size:      42,
+			CreatedAt: createdAt,
+		}},
+		UpdatedAt: createdAt,
+	}
+	if have, want := want.Bundles[0].Key, "repos/github.com/foo/bar/bundles/20211101T120000.000000000Z.bundle"; have != want {
+		t.Errorf("wrong bundle key: have %q, want %q", have, want)
+	}
+
+	if err := WriteManifest(ctx, store, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, want.Bundles[0].Key, strings.NewReader("bundle")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteManifest(ctx, store, &Manifest{Repo: "gitlab.com/baz"}); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := ReadManifest(ctx, store, "github.com/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Errorf("unexpected manifest (-want +have):\n%s", diff)
+	}
+
+	repos, err := ListRepos(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]api.RepoName{"github.com/foo/bar", "gitlab.com/baz"}, repos); diff != "" {
+		t.Errorf("unexpected repos (-want +have):\n%s", diff)
+	}
+
+	if err := DeleteBackup(ctx, store, "github.com/foo/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteBackup(ctx, store, "github.com/foo/missing"); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := store.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{ManifestKey("gitlab.com/baz")}, keys); diff != "" {
+		t.Errorf("unexpected keys after delete (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,169 @@
+package backup
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+type s3Store struct {
+	bucket       string
+	manageBucket bool
+	client       s3API
+	uploader     s3Uploader
+}
+
+var _ Store = &s3Store{}
+
+type S3Config struct {
+	Region          string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	ManageBucket    bool
+}
+
+func (c *S3Config) load(parent *env.BaseConfig) {
+	c.Region = parent.Get("SRC_GITSERVER_BACKUP_AWS_REGION", "us-east-1", "The target AWS region.")
+	c.Endpoint = parent.GetOptional("SRC_GITSERVER_BACKUP_AWS_ENDPOINT", "The endpoint of an S3-compatible blob store. Required for MinIO.")
+	c.AccessKeyID = parent.Get("SRC_GITSERVER_BACKUP_AWS_ACCESS_KEY_ID", "", "An AWS access key associated with a user with access to S3.")
+	c.SecretAccessKey = parent.Get("SRC_GITSERVER_BACKUP_AWS_SECRET_ACCESS_KEY", "", "An AWS secret key associated with a user with access to S3.")
+	c.SessionToken = parent.GetOptional("SRC_GITSERVER_BACKUP_AWS_SESSION_TOKEN", "An optional AWS session token associated with a user with access to S3.")
+	c.ManageBucket = parent.GetBool("SRC_GITSERVER_BACKUP_MANAGE_BUCKET", "false", "Whether or not gitserver should create the target bucket.")
+}
+
+type s3API interface {
+	GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	CreateBucket(ctx context.Context, input *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error)
+}
+
+type s3Uploader interface {
+	Upload(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*manager.Uploader)) (*manager.UploadOutput, error)
+}
+
+// newS3FromConfig creates a new store backed by AWS Simple Storage Service or
+// an S3-compatible blob store such as MinIO.
+func newS3FromConfig(ctx context.Context, config *Config) (Store, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(config.S3.Region),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			config.S3.AccessKeyID,
+			config.S3.SecretAccessKey,
+			config.S3.SessionToken,
+		)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if config.S3.Endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(config.S3.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	// MinIO is typically run alongside Sourcegraph, so there is nobody else to
+	// provision the bucket.
+	manageBucket := config.S3.ManageBucket || config.Backend == "minio"
+
+	return newS3WithClients(client, manager.NewUploader(client), config.Bucket, manageBucket), nil
+}
+
+func newS3WithClients(client s3API, uploader s3Uploader, bucket string, manageBucket bool) *s3Store {
+	return &s3Store{
+		bucket:       bucket,
+		manageBucket: manageBucket,
+		client:       client,
+		uploader:     uploader,
+	}
+}
+
+func (s *s3Store) Init(ctx context.Context) error {
+	if !s.manageBucket {
+		return nil
+	}
+
+	_, err := s.client.CreateBucket(ctx, &s3.CreateBucketInput{
+		Bucket: aws.String(s.bucket),
+	})
+	if errors.HasType(err, &s3types.BucketAlreadyExists{}) || errors.HasType(err, &s3types.BucketAlreadyOwnedByYou{}) {
+		return nil
+	}
+
+	return errors.Wrap(err, "failed to create bucket")
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if errors.HasType(err, &s3types.NoSuchKey{}) {
+			return nil, &notFoundError{key: key}
+		}
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return resp.Body, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader) error {
+	if _, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	}); err != nil {
+		return errors.Wrap(err, "failed to upload object")
+	}
+
+	return nil
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	var continuationToken *string
+	for {
+		resp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(s.bucket),
+			Prefix:            aws.String(prefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+
+		for _, object := range resp.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+
+		if !resp.IsTruncated {
+			return keys, nil
+		}
+		continuationToken = resp.NextContinuationToken
+	}
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeS3 is an in-memory s3API and s3Uploader which returns at most two keys
+// per ListObjectsV2 page.
+type fakeS3 struct {
+	objects map[string]string
+	keys    []string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := f.objects[aws.ToString(input.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	var matching []string
+	for _, key := range f.keys {
+		if strings.HasPrefix(key, aws.ToString(input.Prefix)) {
+			matching = append(matching, key)
+		}
+	}
+
+	start := 0
+	if input.ContinuationToken != nil {
+		start, _ = strconv.Atoi(*input.ContinuationToken)
+	}
+	end := start + 2
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	var out s3.ListObjectsV2Output
+	for _, key := range matching[start:end] {
+		out.Contents = append(out.Contents, s3types.Object{Key: aws.String(key)})
+	}
+	if end < len(matching) {
+		out.IsTruncated = true
+		out.NextContinuationToken = aws.String(strconv.Itoa(end))
+	}
+	return &out, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, aws.ToString(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) CreateBucket(ctx context.Context, input *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	return nil, &s3types.BucketAlreadyOwnedByYou{}
+}
+
+func (f *fakeS3) Upload(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*manager.Uploader)) (*manager.UploadOutput, error) {
+	content, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	key := aws.ToString(input.Key)
+	if _, ok := f.objects[key]; !ok {
+		f.keys = append(f.keys, key)
+	}
+	f.objects[key] = string(content)
+	return &manager.UploadOutput{}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeS3{objects: map[string]string{}}
+	store := newS3WithClients(fake, fake, "backups", true)
+
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("expected existing bucket to be ignored, got %v", err)
+	}
+
+	if _, err := store.Get(ctx, "missing"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	for _, key := range []string{"a/1", "a/2", "b/1", "a/3", "a/4", "a/5"} {
+		if err := store.Put(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := store.List(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a/1", "a/2", "a/3", "a/4", "a/5"}, keys); diff != "" {
+		t.Errorf("unexpected keys (-want +have):\n%s", diff)
+	}
+
+	rc, err := store.Get(ctx, "b/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if content, _ := io.ReadAll(rc); string(content) != "b/1" {
+		t.Errorf("wrong content: %q", content)
+	}
+}
@@ -0,0 +1,83 @@
+// Package backup stores git bundles of gitserver repositories in a blob
+// store, so that a gitserver which lost its disk can be rehydrated without
+// cloning every repository from its code host again.
+package backup
+
+import (
+	"context"
+	"io"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Store is a key/value store for backups backed by a blob store. Keys are
+// slash separated paths.
+type Store interface {
+	// Init ensures that the underlying target directory or bucket exists.
+	Init(ctx context.Context) error
+
+	// Get returns a reader that streams the content of the object at the
+	// given key. It returns an error for which IsNotFound is true if there is
+	// no such object.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Put writes the content in the given reader to the object at the given
+	// key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// List returns the keys of all objects whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	// Delete removes the object at the given key. It is not an error to
+	// delete an object which does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+var storeConstructors = map[string]func(ctx context.Context, config *Config) (Store, error){
+	"local": newLocalFromConfig,
+	"s3":    newS3FromConfig,
+	"minio": newS3FromConfig,
+}
+
+// NewStore creates and initializes a new store from the given
+// configuration. It returns nil if backups are disabled.
+func NewStore(ctx context.Context, config *Config) (Store, error) {
+	if config.Backend == "" {
+		return nil, nil
+	}
+
+	newStore, ok := storeConstructors[config.Backend]
+	if !ok {
+		return nil, errors.Errorf("unknown backup store backend '%s'", config.Backend)
+	}
+
+	store, err := newStore(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Init(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize backup store")
+	}
+
+	return store, nil
+}
+
+type notFoundError struct {
+	key string
+}
+
+func (e *notFoundError) Error() string {
+	return "backup object not found: " + e.key
+}
+
+func (e *notFoundError) NotFound() bool {
+	return true
+}
+
+// IsNotFound reports whether err is returned by Store.Get for an object which
+// does not exist.
+func IsNotFound(err error) bool {
+	var e *notFoundError
+	return errors.As(err, &e)
+}
@@ -3,8 +3,6 @@ package gitserver
 import (
 	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/binary"
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
@@ -99,17 +97,6 @@ func (c *Client) AddrForRepo(repo api.RepoName) string {
 	return AddrForRepo(repo, addrs)
 }
 
-// RendezvousAddrForRepo returns the gitserver address to use for the given repo name using the
-// Rendezvous hashing scheme.
-func (c *Client) RendezvousAddrForRepo(repo api.RepoName) string {
-	addrs := c.Addrs()
-	if len(addrs) == 0 {
-		panic("unexpected state: no gitserver addresses")
-	}
-
-	return RendezvousAddrForRepo(repo, addrs)
-}
-
 // addrForKey returns the gitserver address to use for the given string key,
 // which is hashed for sharding purposes.
 func (c *Client) addrForKey(key string) string {
@@ -127,6 +114,10 @@ var addForRepoInvoked = promauto.NewCounter(prometheus.CounterOpts{
 
 // AddrForRepo returns the gitserver address to use for the given repo name.
 // It should never be called with an empty slice.
+//
+// Repos are assigned using rendezvous hashing, so adding or removing a
+// gitserver only moves the repos owned by that instance (roughly 1/N of all
+// repos) instead of reshuffling almost every repo as modulo hashing would.
 func AddrForRepo(repo api.RepoName, addrs []string) string {
 	addForRepoInvoked.Inc()
 
@@ -134,21 +125,11 @@ func AddrForRepo(repo api.RepoName, addrs []string) string {
 	return addrForKey(string(repo), addrs)
 }
 
-// RendezvousAddrForRepo returns the gitserver address to use for the given repo name using the
-// Rendezvous hashing scheme.
-//
-// It should never be called with an empty slice.
-func RendezvousAddrForRepo(repo api.RepoName, addrs []string) string {
-	r := rendezvous.New(addrs, xxhash.Sum64String)
-	return r.Lookup(string(protocol.NormalizeRepo(repo)))
-}
-
 // addrForKey returns the gitserver address to use for the given string key,
-// which is hashed for sharding purposes.
+// which is hashed for sharding purposes using the rendezvous hashing scheme.
 func addrForKey(key string, addrs []string) string {
-	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	r := rendezvous.New(addrs, xxhash.Sum64String)
+	return r.Lookup(key)
 }
 
 // ArchiveOptions contains options for the Archive func.
@@ -204,6 +185,21 @@ func (c *Client) ArchiveURL(repo api.RepoName, opt ArchiveOptions) *url.URL {
 	}
 }
 
+func (c *Client) archivePost(ctx context.Context, repo api.RepoName, opt ArchiveOptions) (*http.Response, error) {
+	req := &protocol.ArchiveRequest{
+		Repo:    repo,
+		Treeish: opt.Treeish,
+		Format:  opt.Format,
+		Paths:   opt.Paths,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	uri := "http://" + c.AddrForRepo(repo) + "/archive"
+	return c.do(ctx, repo, "POST", uri, payload)
+}
+
 // Archive produces an archive from a Git repository.
 func (c *Client) Archive(ctx context.Context, repo api.RepoName, opt ArchiveOptions) (_ io.ReadCloser, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Git: Archive")
@@ -223,8 +219,15 @@ func (c *Client) Archive(ctx context.Context, repo api.RepoName, opt ArchiveOpti
 		return nil, err
 	}
 
-	u := c.ArchiveURL(repo, opt)
-	resp, err := c.do(ctx, repo, "GET", u.String(), nil)
+	var resp *http.Response
+	if len(opt.Paths) == 0 {
+		u := c.ArchiveURL(repo, opt)
+		resp, err = c.do(ctx, repo, "GET", u.String(), nil)
+	} else {
+		// The paths can be too many to fit into a URL, so we send them in the
+		// request body instead.
+		resp, err = c.archivePost(ctx, repo, opt)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -652,24 +655,23 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo api.RepoName, since
 	return info, err
 }
 
-// RequestRepoMigrate is effectively RequestRepoUpdate but with some additional metadata to aid our
-// migration of gitserver repos to the rendezvous hashing scheme.
-func (c *Client) RequestRepoMigrate(ctx context.Context, repo api.RepoName) (*protocol.RepoUpdateResponse, error) {
+// RequestRepoMigrate is effectively RequestRepoUpdate but with some additional metadata to aid the
+// rebalancing of repos between gitserver instances. from is the address of the gitserver instance
+// that currently holds a clone of repo.
+func (c *Client) RequestRepoMigrate(ctx context.Context, repo api.RepoName, from string) (*protocol.RepoUpdateResponse, error) {
 	// We do not need to set a value for the attribute "Since" because the repo is not expected to
 	// be cloned at the new gitserver instance. And for not cloned repos, this attribute is already
 	// ignored.
 	req := &protocol.RepoUpdateRequest{
 		Repo:        repo,
-		MigrateFrom: c.AddrForRepo(repo),
+		MigrateFrom: from,
 	}
 
-	// We set "uri" to the HTTP URL of the gitserver instance that should be the new owner of this
-	// "repo" based on the rendezvous hashing scheme. This way, when the gitserver instance receives
-	// the request at /repo-update, it will treat it as a new clone operation and attempt to clone
-	// the repo from the URL set in MigrateFrom - the gitserver instance that owns this repo based
-	// on the existing hashing scheme.
-	uri := "http://" + c.RendezvousAddrForRepo(repo) + "/repo-update"
-	resp, err := c.httpPostWithURI(ctx, repo, uri, req)
+	// The request is sent to the gitserver instance that is the new owner of this "repo". When it
+	// receives the request at /repo-update, it will treat it as a new clone operation and attempt
+	// to fetch the repo from the gitserver instance set in MigrateFrom before falling back to the
+	// code host.
+	resp, err := c.httpPost(ctx, repo, "repo-update", req)
 	if err != nil {
 		return nil, err
 	}
@@ -973,9 +975,8 @@ func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	return nil
 }
 
-// httpPost will apply the MD5 hashing scheme on the repo name to determine the gitserver instance
-// to which the HTTP POST request is sent. To use the rendezvous hashing scheme, see
-// httpPostWithURI.
+// httpPost will apply the rendezvous hashing scheme on the repo name to determine the gitserver
+// instance to which the HTTP POST request is sent.
 func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, payload interface{}) (resp *http.Response, err error) {
 	b, err := json.Marshal(payload)
 	if err != nil {
@@ -986,18 +987,6 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, pay
 	return c.do(ctx, repo, "POST", uri, b)
 }
 
-// httpPostWithURI does not apply any transformations to the given URI. This allows the consumer to
-// use the predetermined hashing scheme (md5 or rendezvous) of their choice to derive the gitserver
-// instance to which the HTTP POST request is sent.
-func (c *Client) httpPostWithURI(ctx context.Context, repo api.RepoName, uri string, payload interface{}) (resp *http.Response, err error) {
-	b, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	return c.do(ctx, repo, "POST", uri, b)
-}
-
 // do performs a request to a gitserver instance based on the address in the uri argument.
 func (c *Client) do(ctx context.Context, repo api.RepoName, method, uri string, payload []byte) (resp *http.Response, err error) {
 	parsedURL, err := url.ParseRequestURI(uri)
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 )
 
@@ -38,7 +40,7 @@ func TestClient_ListCloned(t *testing.T) {
 				}, nil
 			case "http://gitserver-1/list?cloned":
 				return &http.Response{
-					Body: io.NopCloser(bytes.NewBufferString(`["repo1-a", "repo1-b"]`)),
+					Body: io.NopCloser(bytes.NewBufferString(`["repo0-b", "repo1-d"]`)),
 				}, nil
 			default:
 				return nil, errors.Errorf("unexpected url: %s", r.URL.String())
@@ -46,7 +48,8 @@ func TestClient_ListCloned(t *testing.T) {
 		}),
 	}
 
-	want := []string{"repo0-a", "repo1-a", "repo1-b"}
+	// repo0-b is present on both instances but only belongs to gitserver-1.
+	want := []string{"repo0-a", "repo0-b", "repo1-d"}
 	got, err := cli.ListCloned(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -62,7 +65,7 @@ func TestClient_RequestRepoMigrate(t *testing.T) {
 	repo := api.RepoName("github.com/sourcegraph/sourcegraph")
 	addrs := []string{"172.16.8.1:8080", "172.16.8.2:8080"}
 
-	expected := "http://" + gitserver.RendezvousAddrForRepo(repo, addrs)
+	expected := "http://" + gitserver.AddrForRepo(repo, addrs)
 
 	cli := &gitserver.Client{
 		Addrs: func() []string {
@@ -71,10 +74,17 @@ func TestClient_RequestRepoMigrate(t *testing.T) {
 
 		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
 			switch r.URL.String() {
-			// Ensure that the request was received by the "expected" gitserver instance - where
-			// expected is the gitserver instance according to the Rendezvous hashing scheme.
-			// For anything else apart from this we return an error.
+			// Ensure that the request was received by the "expected" gitserver instance - the new
+			// owner of the repo - and that it names the previous owner. For anything else apart
+			// from this we return an error.
 			case expected + "/repo-update":
+				var req protocol.RepoUpdateRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					return nil, err
+				}
+				if req.MigrateFrom != "172.16.8.3:8080" {
+					return nil, errors.Newf("unexpected MigrateFrom: %q", req.MigrateFrom)
+				}
 				return &http.Response{
 					StatusCode: 200,
 					Body:       io.NopCloser(bytes.NewBufferString("{}")),
@@ -85,7 +95,7 @@ func TestClient_RequestRepoMigrate(t *testing.T) {
 		}),
 	}
 
-	_, err := cli.RequestRepoMigrate(context.Background(), repo)
+	_, err := cli.RequestRepoMigrate(context.Background(), repo, "172.16.8.3:8080")
 	if err != nil {
 		t.Fatalf("expected URL %q, but got err %q", expected, err)
 	}
@@ -100,6 +110,7 @@ func TestClient_Archive(t *testing.T) {
 
 	tests := map[api.RepoName]struct {
 		remote string
+		paths  []string
 		want   map[string]string
 		err    error
 	}{
@@ -115,6 +126,11 @@ func TestClient_Archive(t *testing.T) {
 			remote: createRepoWithDotGitDir(t, root),
 			want:   map[string]string{"file1": "hello\n", ".git/mydir/file2": "milton\n", ".git/mydir/": "", ".git/": ""},
 		},
+		"repo-with-glob-filenames": {
+			remote: createRepoWithGlobFilenames(t, root),
+			paths:  []string{"[ab].txt", "dir*/file"},
+			want:   map[string]string{"[ab].txt": "brackets", "dir*/": "", "dir*/file": "star"},
+		},
 		"not-found": {
 			err: errors.New("repository does not exist: not-found"),
 		},
@@ -150,7 +166,7 @@ func TestClient_Archive(t *testing.T) {
 				}
 			}
 
-			rc, err := cli.Archive(ctx, name, gitserver.ArchiveOptions{Treeish: "HEAD", Format: "zip"})
+			rc, err := cli.Archive(ctx, name, gitserver.ArchiveOptions{Treeish: "HEAD", Format: "zip", Paths: test.paths})
 			if have, want := fmt.Sprint(err), fmt.Sprint(test.err); have != want {
 				t.Errorf("archive: have err %v, want %v", have, want)
 			}
@@ -254,6 +270,38 @@ filemode=true
 	return dir
 }
 
+// createRepoWithGlobFilenames creates a repository with files whose names
+// contain glob characters, next to files matched by those globs.
+func createRepoWithGlobFilenames(t *testing.T, root string) string {
+	t.Helper()
+	dir := filepath.Join(root, "remotes", "repo-with-glob-filenames")
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range []string{
+		"git init",
+		"echo -n brackets > '[ab].txt'",
+		"echo -n a > a.txt",
+		"echo -n b > b.txt",
+		"mkdir 'dir*' dir1",
+		"echo -n star > 'dir*/file'",
+		"echo -n dir1 > dir1/file",
+		"git add .",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com git commit -m commit1 --author='a <a@a.com>'",
+	} {
+		c := exec.Command("bash", "-c", cmd)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Command %q failed. Output was:\n\n%s", cmd, out)
+		}
+	}
+
+	return dir
+}
+
 func createSimpleGitRepo(t *testing.T, root string) string {
 	t.Helper()
 	dir := filepath.Join(root, "remotes", "simple")
@@ -288,41 +336,6 @@ func createSimpleGitRepo(t *testing.T, root string) string {
 func TestAddrForRepo(t *testing.T) {
 	addrs := []string{"gitserver-1", "gitserver-2", "gitserver-3"}
 
-	testCases := []struct {
-		name string
-		repo api.RepoName
-		want string
-	}{
-		{
-			name: "repo1",
-			repo: api.RepoName("repo1"),
-			want: "gitserver-3",
-		},
-		{
-			name: "check we normalise",
-			repo: api.RepoName("repo1.git"),
-			want: "gitserver-3",
-		},
-		{
-			name: "another repo",
-			repo: api.RepoName("github.com/sourcegraph/sourcegraph.git"),
-			want: "gitserver-2",
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got := gitserver.AddrForRepo(tc.repo, addrs)
-			if got != tc.want {
-				t.Fatalf("Want %q, got %q", tc.want, got)
-			}
-		})
-	}
-}
-
-func TestRendezvousAddrForRepo(t *testing.T) {
-	addrs := []string{"gitserver-1", "gitserver-2", "gitserver-3"}
-
 	testCases := []struct {
 		name string
 		repo api.RepoName
@@ -352,7 +365,7 @@ func TestRendezvousAddrForRepo(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := gitserver.RendezvousAddrForRepo(tc.repo, addrs)
+			got := gitserver.AddrForRepo(tc.repo, addrs)
 			if got != tc.want {
 				t.Fatalf("Want %q, got %q", tc.want, got)
 			}
@@ -132,13 +132,9 @@ type RepoUpdateRequest struct {
 	Repo  api.RepoName  `json:"repo"`  // identifying URL for repo
 	Since time.Duration `json:"since"` // debounce interval for queries, used only with request-repo-update
 
-	// MigrateFrom is the name of the gitserver instance that is the current owner of the
-	// repository. If this is set, then the RepoUpdateRequest is to migrate the repo from the
-	// current gitserver instance to the new home of the repo based on the rendezvous hashing
-	// scheme.
-	//
-	// Once migration is complete for all repos in Sourcegraph, there is no need for this attribute
-	// and it should be removed.
+	// MigrateFrom is the address of the gitserver instance that was the previous owner of the
+	// repository. If this is set and the repo is not yet cloned, the receiving gitserver will
+	// fetch the repo from that instance before falling back to the code host.
 	MigrateFrom string `json:"migrateFrom"`
 }
 
@@ -191,6 +187,31 @@ type RepoDeleteRequest struct {
 	Repo api.RepoName
 }
 
+// ArchiveRequest is a request to produce an archive of some paths of a
+// repository. It is sent as the body of a POST to /archive, because the paths
+// may not fit into the URL.
+type ArchiveRequest struct {
+	Repo    api.RepoName
+	Treeish string
+	Format  string
+	Paths   []string
+}
+
+// RestoreRequest is a request to restore repository clones on gitserver from
+// their backups.
+type RestoreRequest struct {
+	// Repos are the repositories to restore. If empty, all repositories with a
+	// backup which belong on the gitserver are restored.
+	Repos []api.RepoName
+}
+
+// RestoreResponse is the response to a RestoreRequest.
+type RestoreResponse struct {
+	// Queued are the repositories queued to be restored. Repositories which
+	// are already cloned or belong on another gitserver are skipped.
+	Queued []api.RepoName
+}
+
 // RepoInfoRequest is a request for information about multiple repositories on gitserver.
 type RepoInfoRequest struct {
 	// Repos are the repositories to get information about.
@@ -229,6 +250,36 @@ type ReposStats struct {
 
 	// GitDirBytes is the amount of bytes stored in .git directories.
 	GitDirBytes int64
+
+	// Rebalance is the progress of moving repos onto this gitserver after the
+	// set of gitservers last changed. It is nil if no rebalance has happened
+	// since the gitserver started.
+	Rebalance *RebalanceStats `json:",omitempty"`
+}
+
+// RebalanceStats describes the progress of repos being moved onto a gitserver
+// after the set of gitservers changed.
+type RebalanceStats struct {
+	// StartedAt is the time the set of gitservers last changed.
+	StartedAt time.Time
+
+	// Pending is the number of repos currently being moved onto this gitserver.
+	Pending int64
+
+	// FromPeer is the number of repos fetched from their previous gitserver.
+	FromPeer int64
+
+	// FromBackup is the number of repos restored from their backup because
+	// fetching from the previous gitserver failed.
+	FromBackup int64
+
+	// FromUpstream is the number of repos that had to be cloned from the code
+	// host because fetching from the previous gitserver and restoring from a
+	// backup failed.
+	FromUpstream int64
+
+	// Failed is the number of repos that could not be moved at all.
+	Failed int64
 }
 
 // RepoCloneProgressRequest is a request for information about the clone progress of multiple
@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/PuerkitoBio/rehttp"
@@ -442,6 +443,11 @@ func NewRetryPolicy(max int) rehttp.RetryFn {
 		case context.DeadlineExceeded, context.Canceled:
 			return false
 		default:
+			// Don't retry if the address was denied by DenyPrivateNetworksOpt.
+			if errors.Is(a.Error, errPrivateNetwork) {
+				return false
+			}
+
 			// Don't retry more than 3 times for no such host errors.
 			// This affords some resilience to dns unreliability while
 			// preventing 20 attempts with a non existing name.
@@ -570,6 +576,51 @@ func NewTimeoutOpt(timeout time.Duration) Opt {
 	}
 }
 
+// DenyPrivateNetworksOpt is an Opt that makes the transport of an http.Client
+// refuse to connect to loopback, private, link-local and unspecified
+// addresses. It is meant for requests to URLs provided by users, which must not
+// reach internal services. Since the address is checked when it is dialed, it
+// also applies to redirects and to host names resolving to such addresses.
+func DenyPrivateNetworksOpt(cli *http.Client) error {
+	tr, err := getTransportForMutation(cli)
+	if err != nil {
+		return errors.Wrap(err, "httpcli.DenyPrivateNetworksOpt")
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return errors.Wrapf(errPrivateNetwork, "httpcli: connecting to %s is not allowed", address)
+			}
+			return nil
+		},
+	}
+	tr.DialContext = dialer.DialContext
+
+	return nil
+}
+
+// errPrivateNetwork is returned when DenyPrivateNetworksOpt denies a
+// connection. Requests failing with it are not retried.
+var errPrivateNetwork = errors.New("address is not public")
+
+// isPrivateIP returns true if ip is not a public unicast address.
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
+
 // getTransport returns the http.Transport for cli. If Transport is nil, it is
 // set to a copy of the DefaultTransport. If it is the DefaultTransport, it is
 // updated to a copy of the DefaultTransport.
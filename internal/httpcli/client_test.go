@@ -281,6 +281,45 @@ func TestNewTimeoutOpt(t *testing.T) {
 	}
 }
 
+func TestDenyPrivateNetworksOpt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var cli http.Client
+	if err := DenyPrivateNetworksOpt(&cli); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	_, err := cli.Get(srv.URL)
+	if !errors.Is(err, errPrivateNetwork) {
+		t.Fatalf("expected connecting to %s to be denied, got %v", srv.URL, err)
+	}
+
+	// Denied requests are not retried.
+	retry := NewRetryPolicy(20)(rehttp.Attempt{Request: &http.Request{}, Error: err})
+	if retry {
+		t.Fatal("expected denied request not to be retried")
+	}
+
+	for ip, want := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"fe80::1":         true,
+		"8.8.8.8":         false,
+		"2001:4860::8888": false,
+	} {
+		if have := isPrivateIP(net.ParseIP(ip)); have != want {
+			t.Errorf("isPrivateIP(%s): have %t, want %t", ip, have, want)
+		}
+	}
+}
+
 func TestErrorResilience(t *testing.T) {
 	failures := int64(5)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
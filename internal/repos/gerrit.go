@@ -0,0 +1,198 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GerritSource yields repositories from a single Gerrit connection configured
+// in Sourcegraph via the external services configuration.
+type GerritSource struct {
+	svc     *types.ExternalService
+	config  *schema.GerritConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *gerrit.Client
+}
+
+// NewGerritSource returns a new GerritSource from the given external service.
+func NewGerritSource(svc *types.ExternalService, cf *httpcli.Factory) (*GerritSource, error) {
+	var c schema.GerritConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGerritSource(svc, &c, cf)
+}
+
+func newGerritSource(svc *types.ExternalService, c *schema.GerritConnection, cf *httpcli.Factory) (*GerritSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, p := range c.Exclude {
+		eb.Exact(p.Name)
+		eb.Pattern(p.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gerrit.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &GerritSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		exclude: exclude,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Gerrit projects configured with this GerritSource's
+// config.
+func (s *GerritSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	// All projects listed without credentials are readable anonymously.
+	var public map[string]bool
+	if s.client.Username != "" {
+		var err error
+		if public, err = s.listPublicProjects(ctx); err != nil {
+			// Without knowing which projects anonymous users can read, all
+			// projects are treated as private.
+			results <- SourceResult{Source: s, Err: errors.Wrap(err, "gerrit.projects: listing anonymously readable projects")}
+		}
+	}
+
+	emit := func(p *gerrit.Project) {
+		if p.State == gerrit.ProjectStateHidden || s.exclude(p.Name) {
+			return
+		}
+		private := s.client.Username != "" && !public[p.Name]
+		results <- SourceResult{Source: s, Repo: s.makeRepo(p, private)}
+	}
+
+	if len(s.config.Projects) > 0 {
+		for _, name := range s.config.Projects {
+			p, err := s.client.GetProject(ctx, name)
+			if err != nil {
+				if gerrit.IsNotFound(err) {
+					log15.Warn("skipping missing gerrit.projects entry:", "name", name, "err", err)
+					continue
+				}
+				results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.projects: name=%q", name)}
+				continue
+			}
+			emit(p)
+		}
+		return
+	}
+
+	if err := listAllGerritProjects(ctx, s.client, emit); err != nil {
+		results <- SourceResult{Source: s, Err: errors.Wrap(err, "gerrit.projects")}
+	}
+}
+
+// listPublicProjects returns the names of the projects that anonymous users
+// can read. Gerrit doesn't expose the read permissions of a project, so they
+// are determined by listing projects without credentials.
+func (s *GerritSource) listPublicProjects(ctx context.Context) (map[string]bool, error) {
+	public := make(map[string]bool)
+	err := listAllGerritProjects(ctx, s.client.Anonymous(), func(p *gerrit.Project) {
+		public[p.Name] = true
+	})
+	if gerrit.IsUnauthorized(err) {
+		// Anonymous access is disabled on this instance.
+		return public, nil
+	}
+	return public, err
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *GerritSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *GerritSource) makeRepo(p *gerrit.Project, private bool) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.GerritRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			p.Name,
+		),
+		URI: string(reposource.GerritRepoName(
+			"",
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          p.ID,
+			ServiceType: extsvc.TypeGerrit,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: p.Description,
+		Archived:    p.State == gerrit.ProjectStateReadOnly,
+		Private:     private,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: gerritCloneURL(s.baseURL, p, nil),
+			},
+		},
+		Metadata: p,
+	}
+}
+
+func listAllGerritProjects(ctx context.Context, client *gerrit.Client, f func(*gerrit.Project)) error {
+	for skip, hasNextPage := 0, true; hasNextPage; {
+		var projects []*gerrit.Project
+		var err error
+		if projects, hasNextPage, err = client.ListProjects(ctx, skip); err != nil {
+			return errors.Wrapf(err, "skip=%d", skip)
+		}
+		for _, p := range projects {
+			f(p)
+		}
+		skip += len(projects)
+	}
+	return nil
+}
+
+// gerritCloneURL returns the Git remote URL of the project. If user is not nil,
+// the URL is authenticated with it.
+func gerritCloneURL(baseURL *url.URL, p *gerrit.Project, user *url.Userinfo) string {
+	u := *baseURL
+	u.Path = baseURL.Path + p.Name
+	if user != nil {
+		// Authenticated requests must be prefixed with /a/.
+		u.Path = baseURL.Path + "a/" + p.Name
+		u.User = user
+	}
+	return u.String()
+}
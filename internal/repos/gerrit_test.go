@@ -0,0 +1,164 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestGerritSource_ListRepos(t *testing.T) {
+	projects := map[string]*gerrit.Project{
+		"public":          {ID: "public", Name: "public", State: gerrit.ProjectStateActive},
+		"secret/internal": {ID: "secret%2Finternal", Name: "secret/internal", State: gerrit.ProjectStateActive},
+		"old":             {ID: "old", Name: "old", State: gerrit.ProjectStateReadOnly},
+		"gone":            {ID: "gone", Name: "gone", State: gerrit.ProjectStateHidden},
+	}
+	anonymous := map[string]*gerrit.Project{
+		"public": projects["public"],
+		"old":    projects["old"],
+	}
+
+	write := func(w http.ResponseWriter, v interface{}) {
+		// Gerrit prefixes its JSON responses to prevent XSSI.
+		_, _ = w.Write([]byte(")]}'\n"))
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/a/projects/" {
+			write(w, projects)
+			return
+		}
+		name := r.URL.Path[len("/a/projects/"):]
+		p, ok := projects[name]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		write(w, p)
+	})
+	mux.HandleFunc("/projects/", func(w http.ResponseWriter, r *http.Request) {
+		write(w, anonymous)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	type repo struct {
+		Name     string
+		Private  bool
+		Archived bool
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		want   []repo
+	}{
+		{
+			name:   "anonymous",
+			config: `{}`,
+			want: []repo{
+				{Name: "old", Archived: true},
+				{Name: "public"},
+			},
+		},
+		{
+			name:   "authenticated",
+			config: `{"username": "admin", "password": "secret"}`,
+			want: []repo{
+				{Name: "old", Archived: true},
+				{Name: "public"},
+				{Name: "secret/internal", Private: true},
+			},
+		},
+		{
+			name:   "projects and exclude",
+			config: `{"username": "admin", "password": "secret", "projects": ["public", "secret/internal", "old", "missing"], "exclude": [{"pattern": "^ol"}]}`,
+			want: []repo{
+				{Name: "public"},
+				{Name: "secret/internal", Private: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var config map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.config), &config); err != nil {
+				t.Fatal(err)
+			}
+			config["url"] = srv.URL
+			bs, _ := json.Marshal(config)
+
+			svc := &types.ExternalService{
+				ID:     1,
+				Kind:   extsvc.KindGerrit,
+				Config: string(bs),
+			}
+
+			src, err := NewGerritSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []repo
+			for _, r := range repos {
+				have = append(have, repo{
+					Name:     r.Metadata.(*gerrit.Project).Name,
+					Private:  r.Private,
+					Archived: r.Archived,
+				})
+			}
+			sort.Slice(have, func(i, j int) bool { return have[i].Name < have[j].Name })
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGerritSource_makeRepo(t *testing.T) {
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindGerrit,
+		Config: `{"url": "https://review.example.com/r", "repositoryPathPattern": "gerrit/{name}"}`,
+	}
+
+	src, err := NewGerritSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := src.makeRepo(&gerrit.Project{ID: "platform%2Fbuild", Name: "platform/build"}, false)
+
+	if want := "gerrit/platform/build"; string(have.Name) != want {
+		t.Errorf("wrong name, want %q, have %q", want, have.Name)
+	}
+	if want := "review.example.com/platform/build"; have.URI != want {
+		t.Errorf("wrong URI, want %q, have %q", want, have.URI)
+	}
+	if want := "platform%2Fbuild"; have.ExternalRepo.ID != want {
+		t.Errorf("wrong external ID, want %q, have %q", want, have.ExternalRepo.ID)
+	}
+	if want := "https://review.example.com/r/platform/build"; have.Sources[svc.URN()].CloneURL != want {
+		t.Errorf("wrong clone URL, want %q, have %q", want, have.Sources[svc.URN()].CloneURL)
+	}
+}
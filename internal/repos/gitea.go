@@ -0,0 +1,205 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GiteaSource yields repositories from a single Gitea connection configured
+// in Sourcegraph via the external services configuration.
+type GiteaSource struct {
+	svc     *types.ExternalService
+	config  *schema.GiteaConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *gitea.Client
+
+	excludeArchived bool
+	excludeForks    bool
+	excludeMirrors  bool
+}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *types.ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(svc, &c, cf)
+}
+
+func newGiteaSource(svc *types.ExternalService, c *schema.GiteaConnection, cf *httpcli.Factory) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &GiteaSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		client:  gitea.NewClient(baseURL, c.Token, cli),
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		if r.Id != 0 {
+			eb.Exact(strconv.Itoa(r.Id))
+		}
+		eb.Pattern(r.Pattern)
+		s.excludeArchived = s.excludeArchived || r.Archived
+		s.excludeForks = s.excludeForks || r.Forks
+		s.excludeMirrors = s.excludeMirrors || r.Mirrors
+	}
+	if s.exclude, err = eb.Build(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ListRepos returns all Gitea repositories configured with this GiteaSource's
+// config.
+func (s *GiteaSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	seen := make(map[int64]bool)
+	emit := func(repos []*gitea.Repository) {
+		for _, r := range repos {
+			if !seen[r.ID] && !s.excludes(r) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+				seen[r.ID] = true
+			}
+		}
+	}
+
+	type listFunc func(ctx context.Context, page int) ([]*gitea.Repository, bool, error)
+	paginate := func(what string, list listFunc) {
+		for page, hasNextPage := 1, true; hasNextPage; page++ {
+			var repos []*gitea.Repository
+			var err error
+			if repos, hasNextPage, err = list(ctx, page); err != nil {
+				results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.%s: page=%d", what, page)}
+				return
+			}
+			emit(repos)
+		}
+	}
+
+	for _, q := range s.config.RepositoryQuery {
+		switch q {
+		case "affiliated":
+			paginate("affiliated", s.client.ListAffiliatedRepos)
+		case "all":
+			paginate("all", s.client.ListAllRepos)
+		}
+	}
+
+	for _, org := range s.config.Orgs {
+		org := org
+		paginate("orgs", func(ctx context.Context, page int) ([]*gitea.Repository, bool, error) {
+			return s.client.ListOrgRepos(ctx, org, page)
+		})
+	}
+
+	for _, nameWithOwner := range s.config.Repos {
+		parts := strings.SplitN(nameWithOwner, "/", 2)
+		if len(parts) != 2 {
+			results <- SourceResult{Source: s, Err: errors.Errorf("gitea.repos: invalid repository name %q", nameWithOwner)}
+			continue
+		}
+
+		r, err := s.client.GetRepo(ctx, parts[0], parts[1])
+		if err != nil {
+			if gitea.IsNotFound(err) {
+				log15.Warn("skipping missing gitea.repos entry:", "name", nameWithOwner, "err", err)
+				continue
+			}
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.repos: name=%q", nameWithOwner)}
+			continue
+		}
+		emit([]*gitea.Repository{r})
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *GiteaSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *GiteaSource) excludes(r *gitea.Repository) bool {
+	return s.exclude(r.FullName) ||
+		s.exclude(strconv.FormatInt(r.ID, 10)) ||
+		(s.excludeArchived && r.Archived) ||
+		(s.excludeForks && r.Fork) ||
+		(s.excludeMirrors && r.Mirror)
+}
+
+func (s *GiteaSource) makeRepo(r *gitea.Repository) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.GiteaRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			r.FullName,
+		),
+		URI: string(reposource.GiteaRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.FullName,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          strconv.FormatInt(r.ID, 10),
+			ServiceType: extsvc.TypeGitea,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: r.Description,
+		Fork:        r.Fork,
+		Archived:    r.Archived,
+		Stars:       r.StarsCount,
+		// Internal repositories are visible to all signed-in users of the
+		// Gitea instance, but not anonymously.
+		Private: r.Private || r.Internal,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.remoteURL(r),
+			},
+		},
+		Metadata: r,
+	}
+}
+
+// remoteURL returns the repository's Git remote URL
+//
+// note: this used to contain credentials but that is no longer the case
+// if you need to get an authenticated clone url use repos.CloneURL
+func (s *GiteaSource) remoteURL(r *gitea.Repository) string {
+	if s.config.GitURLType == "ssh" {
+		return r.SSHURL
+	}
+	return r.CloneURL
+}
@@ -0,0 +1,147 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestGiteaSource_ListRepos(t *testing.T) {
+	repo := func(id int64, fullName string) *gitea.Repository {
+		return &gitea.Repository{ID: id, FullName: fullName, CloneURL: "https://gitea.example.com/" + fullName + ".git"}
+	}
+
+	affiliated := []*gitea.Repository{repo(1, "alice/dotfiles"), repo(2, "acme/api"), repo(3, "acme/web")}
+	affiliated[2].Archived = true
+	org := []*gitea.Repository{repo(2, "acme/api"), repo(4, "acme/fork")}
+	org[1].Fork = true
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		// Serve one repository per page to exercise pagination.
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		if page < len(affiliated) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+		}
+		_ = json.NewEncoder(w).Encode(affiliated[page-1 : page])
+	})
+	mux.HandleFunc("/api/v1/orgs/acme/repos", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(org)
+	})
+	mux.HandleFunc("/api/v1/repos/bob/tools", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(repo(5, "bob/tools"))
+	})
+	mux.HandleFunc("/api/v1/repos/bob/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "affiliated",
+			config: `"repositoryQuery": ["affiliated"]`,
+			want:   []string{"alice/dotfiles", "acme/api", "acme/web"},
+		},
+		{
+			name:   "orgs and repos deduplicated",
+			config: `"repositoryQuery": ["affiliated"], "orgs": ["acme"], "repos": ["bob/tools", "bob/missing"]`,
+			want:   []string{"alice/dotfiles", "acme/api", "acme/web", "acme/fork", "bob/tools"},
+		},
+		{
+			name:   "exclude",
+			config: `"orgs": ["acme"], "repos": ["bob/tools"], "exclude": [{"name": "bob/tools"}, {"forks": true}]`,
+			want:   []string{"acme/api"},
+		},
+		{
+			name:   "exclude by id, pattern and archived",
+			config: `"repositoryQuery": ["affiliated"], "exclude": [{"id": 1}, {"pattern": "^acme/a"}, {"archived": true}]`,
+			want:   nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &types.ExternalService{
+				ID:     1,
+				Kind:   extsvc.KindGitea,
+				Config: fmt.Sprintf(`{"url": %q, "token": "secret", %s}`, srv.URL, tc.config),
+			}
+
+			src, err := NewGiteaSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []string
+			for _, r := range repos {
+				have = append(have, r.Metadata.(*gitea.Repository).FullName)
+			}
+			sort.Strings(have)
+			sort.Strings(tc.want)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGiteaSource_makeRepo(t *testing.T) {
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindGitea,
+		Config: `{"url": "https://gitea.example.com/git/", "repositoryQuery": ["none"], "repositoryPathPattern": "gitea/{nameWithOwner}"}`,
+	}
+
+	src, err := NewGiteaSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := src.makeRepo(&gitea.Repository{
+		ID:          42,
+		FullName:    "acme/api",
+		Description: "The API",
+		Internal:    true,
+		StarsCount:  7,
+		CloneURL:    "https://gitea.example.com/git/acme/api.git",
+	})
+
+	if want := "gitea/acme/api"; string(have.Name) != want {
+		t.Errorf("wrong name, want %q, have %q", want, have.Name)
+	}
+	if want := "gitea.example.com/acme/api"; have.URI != want {
+		t.Errorf("wrong URI, want %q, have %q", want, have.URI)
+	}
+	if want := "42"; have.ExternalRepo.ID != want {
+		t.Errorf("wrong external ID, want %q, have %q", want, have.ExternalRepo.ID)
+	}
+	if want := "https://gitea.example.com/git/"; have.ExternalRepo.ServiceID != want {
+		t.Errorf("wrong service ID, want %q, have %q", want, have.ExternalRepo.ServiceID)
+	}
+	if !have.Private {
+		t.Error("internal repository should be private")
+	}
+	if want := "https://gitea.example.com/git/acme/api.git"; have.Sources[svc.URN()].CloneURL != want {
+		t.Errorf("wrong clone URL, want %q, have %q", want, have.Sources[svc.URN()].CloneURL)
+	}
+}
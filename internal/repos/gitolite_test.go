@@ -0,0 +1,53 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestGitoliteSource_makeRepo(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		config      string
+		wantPrivate bool
+	}{
+		{
+			name:   "without authorization",
+			config: `{"host": "git@gitolite.example.com", "prefix": "gitolite.example.com/"}`,
+		},
+		{
+			name:        "with authorization",
+			config:      `{"host": "git@gitolite.example.com", "prefix": "gitolite.example.com/", "authorization": {}}`,
+			wantPrivate: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &types.ExternalService{
+				ID:     1,
+				Kind:   extsvc.KindGitolite,
+				Config: tc.config,
+			}
+
+			src, err := NewGitoliteSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			have := src.makeRepo(&gitolite.Repo{Name: "secret", URL: "git@gitolite.example.com:secret"})
+
+			if want := "gitolite.example.com/secret"; string(have.Name) != want {
+				t.Errorf("wrong name, want %q, have %q", want, have.Name)
+			}
+			if want := "secret"; have.ExternalRepo.ID != want {
+				t.Errorf("wrong external ID, want %q, have %q", want, have.ExternalRepo.ID)
+			}
+			if have.Private != tc.wantPrivate {
+				t.Errorf("wrong visibility, want private %v, have %v", tc.wantPrivate, have.Private)
+			}
+		})
+	}
+}
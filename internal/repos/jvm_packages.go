@@ -54,6 +54,12 @@ func NewJVMPackagesSource(svc *types.ExternalService) (*JVMPackagesSource, error
 }
 
 func (s *JVMPackagesSource) SetDB(db dbutil.DB) {
+	s.dbStore = newDependencyReposStore(db)
+}
+
+// newDependencyReposStore returns the code intelligence store used by package
+// sources to list the dependency repos referenced by LSIF uploads.
+func newDependencyReposStore(db dbutil.DB) *dbstore.Store {
 	once.Do(func() {
 		observationContext = &observation.Context{
 			Logger:     log15.Root(),
@@ -62,7 +68,7 @@ func (s *JVMPackagesSource) SetDB(db dbutil.DB) {
 		}
 		operationMetrics = dbstore.NewOperationsMetrics(observationContext)
 	})
-	s.dbStore = dbstore.NewWithDB(db, observationContext, operationMetrics)
+	return dbstore.NewWithDB(db, observationContext, operationMetrics)
 }
 
 func newJVMPackagesSource(svc *types.ExternalService, c *schema.JVMPackagesConnection) (*JVMPackagesSource, error) {
@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A MercurialSource yields repositories from a single Mercurial connection
+// configured in Sourcegraph via the external services configuration.
+type MercurialSource struct {
+	svc     *types.ExternalService
+	config  *schema.MercurialConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *mercurial.Client
+}
+
+// NewMercurialSource returns a new MercurialSource from the given external
+// service.
+func NewMercurialSource(svc *types.ExternalService, cf *httpcli.Factory) (*MercurialSource, error) {
+	var c schema.MercurialConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newMercurialSource(svc, &c, cf)
+}
+
+func newMercurialSource(svc *types.ExternalService, c *schema.MercurialConnection, cf *httpcli.Factory) (*MercurialSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := mercurial.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &MercurialSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		exclude: exclude,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Mercurial repositories configured with this
+// MercurialSource's config.
+func (s *MercurialSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	if len(s.config.Repos) > 0 {
+		for _, name := range s.config.Repos {
+			if r := s.client.Repo(name); !s.exclude(r.Name) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+			}
+		}
+		return
+	}
+
+	repos, err := s.client.ListRepos(ctx)
+	if err != nil {
+		results <- SourceResult{Source: s, Err: errors.Wrap(err, "mercurial.repos")}
+		return
+	}
+	for _, r := range repos {
+		if !s.exclude(r.Name) {
+			results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+		}
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *MercurialSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *MercurialSource) makeRepo(r *mercurial.Repo) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.MercurialRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			r.Name,
+		),
+		URI: string(reposource.MercurialRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          r.Name,
+			ServiceType: extsvc.TypeMercurial,
+			ServiceID:   s.baseURL.String(),
+		},
+		// hgweb doesn't expose who can read a repository, so
+		// repositories that need credentials are treated as private.
+		Private: s.config.Username != "",
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: r.URL,
+			},
+		},
+		Metadata: r,
+	}
+}
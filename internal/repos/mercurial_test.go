@@ -0,0 +1,62 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestMercurialSource_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "/project/\n/team/app/\n/archive/old/\n")
+	}))
+	defer srv.Close()
+
+	config, _ := json.Marshal(map[string]interface{}{
+		"url":      srv.URL,
+		"username": "admin",
+		"password": "secret",
+		"exclude":  []map[string]string{{"pattern": "^archive/"}},
+	})
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindMercurial,
+		Config: string(config),
+	}
+
+	src, err := NewMercurialSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, r := range repos {
+		if !r.Private {
+			t.Errorf("expected repo %q to be private", r.Name)
+		}
+		have = append(have, r.Metadata.(*mercurial.Repo).URL)
+	}
+	want := []string{srv.URL + "/project", srv.URL + "/team/app"}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
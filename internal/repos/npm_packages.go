@@ -0,0 +1,190 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A NpmPackagesSource creates git repositories from the tarballs of npm
+// packages published to an npm registry.
+type NpmPackagesSource struct {
+	svc     *types.ExternalService
+	config  *schema.NpmPackagesConnection
+	dbStore NpmPackagesRepoStore
+	client  *npm.Client
+}
+
+type NpmPackagesRepoStore interface {
+	GetNpmDependencyRepos(ctx context.Context, filter dbstore.GetNpmDependencyReposOpts) ([]dbstore.NpmDependencyRepo, error)
+}
+
+// NewNpmPackagesSource returns a new NpmPackagesSource from the given
+// external service.
+func NewNpmPackagesSource(svc *types.ExternalService, cf *httpcli.Factory) (*NpmPackagesSource, error) {
+	var c schema.NpmPackagesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newNpmPackagesSource(svc, &c, cf)
+}
+
+func (s *NpmPackagesSource) SetDB(db dbutil.DB) {
+	s.dbStore = newDependencyReposStore(db)
+}
+
+func newNpmPackagesSource(svc *types.ExternalService, c *schema.NpmPackagesConnection, cf *httpcli.Factory) (*NpmPackagesSource, error) {
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &NpmPackagesSource{
+		svc:     svc,
+		config:  c,
+		dbStore: nil, // set via SetDB decorator
+		client:  npm.NewHTTPClient(c.Registry, c.Credentials, cli),
+	}, nil
+}
+
+// ListRepos returns the npm packages configured in the external service
+// and the npm packages referenced by LSIF uploads.
+func (s *NpmPackagesSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	packages, err := NpmPackages(*s.config)
+	if err != nil {
+		results <- SourceResult{Err: err}
+		return
+	}
+	for _, pkg := range packages {
+		results <- SourceResult{
+			Source: s,
+			Repo:   s.makeRepo(pkg),
+		}
+	}
+
+	var (
+		totalDBFetched  int
+		totalDBResolved int
+		lastID          int
+	)
+	for {
+		dbDeps, err := s.dbStore.GetNpmDependencyRepos(ctx, dbstore.GetNpmDependencyReposOpts{
+			After: lastID,
+			Limit: 100,
+		})
+		if err != nil {
+			results <- SourceResult{Err: err}
+			return
+		}
+
+		if len(dbDeps) == 0 {
+			break
+		}
+
+		totalDBFetched += len(dbDeps)
+
+		lastID = dbDeps[len(dbDeps)-1].ID
+
+		for _, dep := range dbDeps {
+			dependency, err := reposource.ParseNpmDependency(dep.Package + "@" + dep.Version)
+			if err != nil {
+				log15.Warn("error parsing npm dependency", "error", err, "package", dep.Package, "version", dep.Version)
+				continue
+			}
+
+			// Like for JVM packages, only resolvable dependencies are
+			// returned so that gitserver doesn't try to clone packages
+			// which don't exist in the registry.
+			if exists, err := s.client.DoesDependencyExist(ctx, dependency); !exists {
+				if err != nil {
+					log15.Warn("error checking npm dependency", "error", err, "package", dependency.PackageManagerSyntax())
+				} else {
+					log15.Warn("npm package not resolvable from registry", "package", dependency.PackageManagerSyntax())
+				}
+				continue
+			}
+
+			totalDBResolved++
+			results <- SourceResult{
+				Source: s,
+				Repo:   s.makeRepo(dependency.NpmPackage),
+			}
+		}
+	}
+
+	log15.Info("finished listing resolvable npm packages", "totalDB", totalDBFetched, "resolvedDB", totalDBResolved, "totalConfig", len(packages))
+}
+
+func (s *NpmPackagesSource) makeRepo(pkg reposource.NpmPackage) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: pkg.RepoName(),
+		URI:  string(pkg.RepoName()),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          string(pkg.RepoName()),
+			ServiceID:   extsvc.TypeNpmPackages,
+			ServiceType: extsvc.TypeNpmPackages,
+		},
+		Private: false,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: pkg.CloneURL(),
+			},
+		},
+		Metadata: &npmpackages.Metadata{
+			Package: pkg,
+		},
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *NpmPackagesSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func NpmDependencies(connection schema.NpmPackagesConnection) (dependencies []reposource.NpmDependency, err error) {
+	for _, dep := range connection.Dependencies {
+		dependency, err := reposource.ParseNpmDependency(dep)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, nil
+}
+
+// NpmPackages returns the distinct packages of the dependencies configured in
+// the connection, in the order they first appear.
+func NpmPackages(connection schema.NpmPackagesConnection) ([]reposource.NpmPackage, error) {
+	isAdded := make(map[reposource.NpmPackage]bool)
+	packages := []reposource.NpmPackage{}
+	dependencies, err := NpmDependencies(connection)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range dependencies {
+		if !isAdded[dep.NpmPackage] {
+			packages = append(packages, dep.NpmPackage)
+		}
+		isAdded[dep.NpmPackage] = true
+	}
+	return packages, nil
+}
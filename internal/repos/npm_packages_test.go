@@ -0,0 +1,79 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type npmPackagesRepoStoreMock []dbstore.NpmDependencyRepo
+
+func (m npmPackagesRepoStoreMock) GetNpmDependencyRepos(ctx context.Context, filter dbstore.GetNpmDependencyReposOpts) (repos []dbstore.NpmDependencyRepo, _ error) {
+	for _, repo := range m {
+		if repo.ID > filter.After && (filter.PackageName == "" || filter.PackageName == repo.Package) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+func TestNpmPackagesSource_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/@types%2Fnode":
+			w.Write([]byte(`{"versions": {"16.11.6": {}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config, _ := json.Marshal(map[string]interface{}{
+		"registry":     srv.URL,
+		"dependencies": []string{"react@17.0.2", "react@16.14.0", "@babel/core@7.16.0"},
+	})
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindNpmPackages,
+		Config: string(config),
+	}
+
+	src, err := NewNpmPackagesSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.dbStore = npmPackagesRepoStoreMock{
+		{ID: 1, Package: "@types/node", Version: "16.11.6"},
+		{ID: 2, Package: "left-pad", Version: "1.3.0"},
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, r := range repos {
+		if r.Private {
+			t.Errorf("expected repo %q to be public", r.Name)
+		}
+		if pkg := r.Metadata.(*npmpackages.Metadata).Package; pkg.RepoName() != r.Name {
+			t.Errorf("wrong metadata for repo %q: %+v", r.Name, pkg)
+		}
+		have = append(have, string(r.Name))
+	}
+	want := []string{"npm/react", "npm/babel/core", "npm/types/node"}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,218 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages/registry"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A RegistryPackagesSource creates git repositories from the sources of
+// packages published to a language package registry: crates.io for Rust,
+// PyPI for Python or RubyGems for Ruby. It works like NpmPackagesSource.
+type RegistryPackagesSource struct {
+	svc          *types.ExternalService
+	scheme       string
+	dependencies []string
+	dbStore      RegistryPackagesRepoStore
+	client       *registry.Client
+}
+
+type RegistryPackagesRepoStore interface {
+	GetRegistryDependencyRepos(ctx context.Context, filter dbstore.GetRegistryDependencyReposOpts) ([]dbstore.RegistryDependencyRepo, error)
+}
+
+// NewRustPackagesSource returns a new RegistryPackagesSource for crates from
+// the given external service.
+func NewRustPackagesSource(svc *types.ExternalService, cf *httpcli.Factory) (*RegistryPackagesSource, error) {
+	var c schema.RustPackagesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newRegistryPackagesSource(svc, "cargo", c.Registry, c.Dependencies, cf)
+}
+
+// NewPythonPackagesSource returns a new RegistryPackagesSource for PyPI
+// packages from the given external service.
+func NewPythonPackagesSource(svc *types.ExternalService, cf *httpcli.Factory) (*RegistryPackagesSource, error) {
+	var c schema.PythonPackagesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newRegistryPackagesSource(svc, "pypi", c.Registry, c.Dependencies, cf)
+}
+
+// NewRubyPackagesSource returns a new RegistryPackagesSource for gems from
+// the given external service.
+func NewRubyPackagesSource(svc *types.ExternalService, cf *httpcli.Factory) (*RegistryPackagesSource, error) {
+	var c schema.RubyPackagesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newRegistryPackagesSource(svc, "gem", c.Registry, c.Dependencies, cf)
+}
+
+func (s *RegistryPackagesSource) SetDB(db dbutil.DB) {
+	s.dbStore = newDependencyReposStore(db)
+}
+
+func newRegistryPackagesSource(svc *types.ExternalService, scheme, registryURL string, dependencies []string, cf *httpcli.Factory) (*RegistryPackagesSource, error) {
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryPackagesSource{
+		svc:          svc,
+		scheme:       scheme,
+		dependencies: dependencies,
+		dbStore:      nil, // set via SetDB decorator
+		client:       registry.NewHTTPClient(scheme, registryURL, cli),
+	}, nil
+}
+
+// ListRepos returns the packages configured in the external service and the
+// packages of the same registry referenced by LSIF uploads.
+func (s *RegistryPackagesSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	packages, err := RegistryPackages(s.scheme, s.dependencies)
+	if err != nil {
+		results <- SourceResult{Err: err}
+		return
+	}
+	for _, pkg := range packages {
+		results <- SourceResult{
+			Source: s,
+			Repo:   s.makeRepo(pkg),
+		}
+	}
+
+	var (
+		totalDBFetched  int
+		totalDBResolved int
+		lastID          int
+	)
+	for {
+		dbDeps, err := s.dbStore.GetRegistryDependencyRepos(ctx, dbstore.GetRegistryDependencyReposOpts{
+			Scheme: s.scheme,
+			After:  lastID,
+			Limit:  100,
+		})
+		if err != nil {
+			results <- SourceResult{Err: err}
+			return
+		}
+
+		if len(dbDeps) == 0 {
+			break
+		}
+
+		totalDBFetched += len(dbDeps)
+
+		lastID = dbDeps[len(dbDeps)-1].ID
+
+		for _, dep := range dbDeps {
+			pkg, err := reposource.ParseRegistryPackage(s.scheme, dep.Package)
+			if err != nil {
+				log15.Warn("error parsing registry dependency", "error", err, "scheme", s.scheme, "package", dep.Package, "version", dep.Version)
+				continue
+			}
+			dependency := reposource.RegistryDependency{RegistryPackage: pkg, Version: dep.Version}
+
+			// Like for npm packages, only resolvable dependencies are
+			// returned so that gitserver doesn't try to clone packages
+			// which don't exist in the registry.
+			if exists, err := s.client.DoesDependencyExist(ctx, dependency); !exists {
+				if err != nil {
+					log15.Warn("error checking registry dependency", "error", err, "scheme", s.scheme, "package", dependency.PackageManagerSyntax())
+				} else {
+					log15.Warn("package not resolvable from registry", "scheme", s.scheme, "package", dependency.PackageManagerSyntax())
+				}
+				continue
+			}
+
+			totalDBResolved++
+			results <- SourceResult{
+				Source: s,
+				Repo:   s.makeRepo(dependency.RegistryPackage),
+			}
+		}
+	}
+
+	log15.Info("finished listing resolvable registry packages", "scheme", s.scheme, "totalDB", totalDBFetched, "resolvedDB", totalDBResolved, "totalConfig", len(packages))
+}
+
+func (s *RegistryPackagesSource) makeRepo(pkg reposource.RegistryPackage) *types.Repo {
+	urn := s.svc.URN()
+	serviceType := extsvc.KindToType(s.svc.Kind)
+	return &types.Repo{
+		Name: pkg.RepoName(),
+		URI:  string(pkg.RepoName()),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          string(pkg.RepoName()),
+			ServiceID:   serviceType,
+			ServiceType: serviceType,
+		},
+		Private: false,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: pkg.CloneURL(),
+			},
+		},
+		Metadata: &registrypackages.Metadata{
+			Package: pkg,
+		},
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *RegistryPackagesSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+// RegistryDependencies parses the configured dependencies of a package
+// registry connection for packages of the given scheme.
+func RegistryDependencies(scheme string, configDependencies []string) (dependencies []reposource.RegistryDependency, err error) {
+	for _, dep := range configDependencies {
+		dependency, err := reposource.ParseRegistryDependency(scheme, dep)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, nil
+}
+
+// RegistryPackages returns the distinct packages of the configured
+// dependencies, in the order they first appear.
+func RegistryPackages(scheme string, configDependencies []string) ([]reposource.RegistryPackage, error) {
+	isAdded := make(map[reposource.RegistryPackage]bool)
+	packages := []reposource.RegistryPackage{}
+	dependencies, err := RegistryDependencies(scheme, configDependencies)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range dependencies {
+		if !isAdded[dep.RegistryPackage] {
+			packages = append(packages, dep.RegistryPackage)
+		}
+		isAdded[dep.RegistryPackage] = true
+	}
+	return packages, nil
+}
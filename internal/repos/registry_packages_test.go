@@ -0,0 +1,87 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/stores/dbstore"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/registrypackages"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+type registryPackagesRepoStoreMock map[string][]dbstore.RegistryDependencyRepo
+
+func (m registryPackagesRepoStoreMock) GetRegistryDependencyRepos(ctx context.Context, filter dbstore.GetRegistryDependencyReposOpts) (repos []dbstore.RegistryDependencyRepo, _ error) {
+	for _, repo := range m[filter.Scheme] {
+		if repo.ID > filter.After && (filter.PackageName == "" || filter.PackageName == repo.Package) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+func TestRegistryPackagesSource_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pypi/flask/2.0.2/json":
+			w.Write([]byte(`{"urls": [{"packagetype": "sdist", "url": "https://files.example.com/Flask-2.0.2.tar.gz"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config, _ := json.Marshal(map[string]interface{}{
+		"registry":     srv.URL,
+		"dependencies": []string{"requests==2.26.0", "Requests==2.25.1", "Flask_SQLAlchemy==2.5.1"},
+	})
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindPythonPackages,
+		Config: string(config),
+	}
+
+	src, err := NewPythonPackagesSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.dbStore = registryPackagesRepoStoreMock{
+		"pypi": {
+			{ID: 1, Package: "Flask", Version: "2.0.2"},
+			{ID: 2, Package: "left-pad", Version: "1.3.0"},
+		},
+		"cargo": {
+			{ID: 3, Package: "serde_json", Version: "1.0.68"},
+		},
+	}
+
+	repos, err := listAll(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, r := range repos {
+		if r.Private {
+			t.Errorf("expected repo %q to be public", r.Name)
+		}
+		if r.ExternalRepo.ServiceType != extsvc.TypePythonPackages {
+			t.Errorf("wrong service type for repo %q: %q", r.Name, r.ExternalRepo.ServiceType)
+		}
+		if pkg := r.Metadata.(*registrypackages.Metadata).Package; pkg.RepoName() != r.Name {
+			t.Errorf("wrong metadata for repo %q: %+v", r.Name, pkg)
+		}
+		have = append(have, string(r.Name))
+	}
+	want := []string{"pypi/requests", "pypi/flask-sqlalchemy", "pypi/flask"}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+	}
+}
@@ -1,13 +1,12 @@
 package repos
 
 import (
-	"container/heap"
 	"context"
 	"regexp"
-	"strings"
-	"sync"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -16,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/hostname"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
 	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -103,9 +103,20 @@ const (
 //
 // A worker continuously dequeues repos and sends updates to gitserver, but its concurrency
 // is limited by the gitMaxConcurrentClones site configuration.
+//
+// The schedule and the queue are stored in Postgres (see scheduleStore), so
+// they survive restarts and multiple repo-updater replicas can share the
+// work. Each replica leases the repos it is updating.
 type updateScheduler struct {
-	updateQueue *updateQueue
-	schedule    *schedule
+	store *scheduleStore
+
+	// owner identifies this replica in the leases of the repos it updates.
+	owner string
+
+	// The scheduler performs a non-blocking send on this channel when it
+	// enqueues a repo, so that the update loop can wake up before its next
+	// poll if it is idle.
+	notifyEnqueue chan struct{}
 }
 
 // A configuredRepo represents the configuration data for a given repo from
@@ -121,51 +132,58 @@ type configuredRepo struct {
 // non-blocking sends.
 const notifyChanBuffer = 1
 
+const (
+	// schedulePollInterval is how often the scheduler enqueues the repos which
+	// are due.
+	schedulePollInterval = 5 * time.Second
+
+	// updatePollInterval is how often an idle update loop looks for repos
+	// enqueued by other replicas.
+	updatePollInterval = 5 * time.Second
+)
+
 // NewUpdateScheduler returns a new scheduler.
-func NewUpdateScheduler() *updateScheduler {
+func NewUpdateScheduler(db dbutil.DB) *updateScheduler {
 	return &updateScheduler{
-		updateQueue: &updateQueue{
-			index:         make(map[api.RepoID]*repoUpdate),
-			notifyEnqueue: make(chan struct{}, notifyChanBuffer),
-		},
-		schedule: &schedule{
-			index:  make(map[api.RepoID]*scheduledRepoUpdate),
-			wakeup: make(chan struct{}, notifyChanBuffer),
-		},
+		store:         newScheduleStore(db),
+		owner:         hostname.Get() + "-" + uuid.New().String(),
+		notifyEnqueue: make(chan struct{}, notifyChanBuffer),
 	}
 }
 
-// runScheduleLoop starts the loop that schedules updates by enqueuing them into the updateQueue.
+// runScheduleLoop starts the loop that schedules updates by enqueuing them
+// into the update queue.
 func (s *updateScheduler) runScheduleLoop(ctx context.Context) {
 	for {
+		s.runSchedule(ctx)
+		schedLoops.Inc()
+
 		select {
-		case <-s.schedule.wakeup:
+		case <-timeAfter(schedulePollInterval):
 		case <-ctx.Done():
-			s.schedule.reset()
 			return
 		}
-
-		s.runSchedule()
-		schedLoops.Inc()
 	}
 }
 
-func (s *updateScheduler) runSchedule() {
-	s.schedule.mu.Lock()
-	defer s.schedule.mu.Unlock()
-	defer s.schedule.rescheduleTimer()
-
-	for len(s.schedule.heap) != 0 {
-		repoUpdate := s.schedule.heap[0]
-		if !repoUpdate.Due.Before(timeNow().Add(time.Millisecond)) {
-			break
-		}
+func (s *updateScheduler) runSchedule(ctx context.Context) {
+	n, err := s.store.enqueueDue(ctx, timeNow())
+	if err != nil {
+		log15.Error("scheduler: failed to enqueue due repos", "error", err)
+		return
+	}
+	if n > 0 {
+		schedAutoFetch.Add(float64(n))
+		notify(s.notifyEnqueue)
+	}
 
-		schedAutoFetch.Inc()
-		s.updateQueue.enqueue(repoUpdate.Repo, priorityLow)
-		repoUpdate.Due = timeNow().Add(repoUpdate.Interval)
-		heap.Fix(s.schedule, 0)
+	known, queued, err := s.store.counts(ctx)
+	if err != nil {
+		log15.Warn("scheduler: failed to count repos", "error", err)
+		return
 	}
+	schedKnownRepos.Set(float64(known))
+	schedUpdateQueueLength.Set(float64(queued))
 }
 
 // runUpdateLoop sends repo update requests to gitserver.
@@ -173,13 +191,6 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 	limiter := configuredLimiter()
 
 	for {
-		select {
-		case <-s.updateQueue.notifyEnqueue:
-		case <-ctx.Done():
-			s.updateQueue.reset()
-			return
-		}
-
 		for {
 			ctx, cancel, err := limiter.Acquire(ctx)
 			if err != nil {
@@ -187,37 +198,96 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 				return
 			}
 
-			repo, ok := s.updateQueue.acquireNext()
+			update, ok, err := s.store.acquireNext(ctx, s.owner, timeNow())
+			if err != nil {
+				log15.Error("scheduler: failed to acquire next repo", "error", err)
+			}
 			if !ok {
 				cancel()
 				break
 			}
 
-			go func(ctx context.Context, repo configuredRepo, cancel context.CancelFunc) {
+			go func(ctx context.Context, update *scheduledRepoUpdate, cancel context.CancelFunc) {
 				defer cancel()
-				defer s.updateQueue.remove(repo, true)
 
+				repo := update.Repo
+
+				// Updates can block for as long as gitserver takes to clone
+				// or fetch, so the lease is renewed until the update is done.
+				leaseCtx, stopRenewing := context.WithCancel(ctx)
+				go s.renewLease(leaseCtx, repo)
 				resp, err := requestRepoUpdate(ctx, repo, 1*time.Second)
+				stopRenewing()
 				if err != nil {
 					schedError.Inc()
 					log15.Warn("error requesting repo update", "uri", repo.Name, "err", err)
 				}
-				if interval := getCustomInterval(conf.Get(), string(repo.Name)); interval > 0 {
-					s.schedule.updateInterval(repo, interval)
-				} else if err != nil {
-					// On error we will double the current interval so that we back off and don't
-					// get stuck with problematic repos with low intervals.
-					if currentInterval, ok := s.schedule.getCurrentInterval(repo); ok {
-						s.schedule.updateInterval(repo, currentInterval*2)
-					}
-				} else if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
-					// This is the heuristic that is described in the updateScheduler documentation.
-					// Update that documentation if you update this logic.
-					interval := resp.LastFetched.Sub(*resp.LastChanged) / 2
-					s.schedule.updateInterval(repo, interval)
+
+				interval := nextInterval(conf.Get(), update, resp, err)
+				if err := s.store.complete(ctx, repo.ID, s.owner, interval, err, timeNow()); err != nil {
+					log15.Error("scheduler: failed to complete repo update", "uri", repo.Name, "error", err)
 				}
-			}(ctx, repo, cancel)
+			}(ctx, update, cancel)
 		}
+
+		select {
+		case <-s.notifyEnqueue:
+		case <-timeAfter(updatePollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// renewLease renews the lease on the repo every scheduleLeaseRenewInterval
+// until ctx is done or the lease was lost.
+func (s *updateScheduler) renewLease(ctx context.Context, repo configuredRepo) {
+	for {
+		select {
+		case <-timeAfter(scheduleLeaseRenewInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		ok, err := s.store.renewLease(ctx, repo.ID, s.owner, timeNow())
+		if err != nil {
+			if ctx.Err() == nil {
+				log15.Warn("scheduler: failed to renew lease", "uri", repo.Name, "error", err)
+			}
+			continue
+		}
+		if !ok {
+			log15.Warn("scheduler: lost lease of repo update", "uri", repo.Name)
+			return
+		}
+	}
+}
+
+// nextInterval returns the interval of the repo after the given update, or
+// zero if it stays the same.
+func nextInterval(c *conf.Unified, update *scheduledRepoUpdate, resp *gitserverprotocol.RepoUpdateResponse, err error) time.Duration {
+	var interval time.Duration
+	if custom := getCustomInterval(c, string(update.Repo.Name)); custom > 0 {
+		interval = custom
+	} else if err != nil {
+		// On error we will double the current interval so that we back off and don't
+		// get stuck with problematic repos with low intervals.
+		interval = update.Interval * 2
+	} else if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
+		// This is the heuristic that is described in the updateScheduler documentation.
+		// Update that documentation if you update this logic.
+		interval = resp.LastFetched.Sub(*resp.LastChanged) / 2
+	} else {
+		return 0
+	}
+
+	switch {
+	case interval > maxDelay:
+		return maxDelay
+	case interval < minDelay:
+		return minDelay
+	default:
+		return interval
 	}
 }
 
@@ -272,28 +342,40 @@ var configuredLimiter = func() *mutablelimiter.Limiter {
 //                commits. Enqueue for asap clone (or fetch).
 //   Unmodified - we likely already have this cloned. Just rely on
 //                the scheduler and do not enqueue.
-func (s *updateScheduler) UpdateFromDiff(diff Diff) {
+func (s *updateScheduler) UpdateFromDiff(ctx context.Context, diff Diff) error {
+	var removed, enqueued, scheduled []api.RepoID
 	for _, r := range diff.Deleted {
-		s.remove(r)
+		removed = append(removed, r.ID)
 	}
-
 	for _, r := range diff.Added {
-		s.upsert(r, true)
+		enqueued = append(enqueued, r.ID)
 	}
 	for _, r := range diff.Modified {
-		s.upsert(r, true)
+		enqueued = append(enqueued, r.ID)
 	}
-
-	known := len(diff.Added) + len(diff.Modified)
 	for _, r := range diff.Unmodified {
 		if r.IsDeleted() {
-			s.remove(r)
+			removed = append(removed, r.ID)
 			continue
 		}
+		scheduled = append(scheduled, r.ID)
+	}
 
-		known++
-		s.upsert(r, false)
+	now := timeNow()
+	if err := s.store.remove(ctx, removed); err != nil {
+		return errors.Wrap(err, "removing repos")
+	}
+	if err := s.store.upsert(ctx, enqueued, true, now); err != nil {
+		return errors.Wrap(err, "enqueueing repos")
+	}
+	if err := s.store.upsert(ctx, scheduled, false, now); err != nil {
+		return errors.Wrap(err, "scheduling repos")
 	}
+	if len(enqueued) > 0 {
+		notify(s.notifyEnqueue)
+	}
+	log15.Debug("scheduler.updatedFromDiff", "removed", len(removed), "enqueued", len(enqueued), "scheduled", len(scheduled))
+	return nil
 }
 
 // PrioritiseUncloned will treat any repos listed in names as uncloned, which in effect
@@ -301,75 +383,33 @@ func (s *updateScheduler) UpdateFromDiff(diff Diff) {
 //
 // This method should be called periodically with the list of all repositories
 // managed by the scheduler that are not cloned on gitserver.
-func (s *updateScheduler) PrioritiseUncloned(names []string) {
-	s.schedule.prioritiseUncloned(names)
+func (s *updateScheduler) PrioritiseUncloned(ctx context.Context, names []string) error {
+	return s.store.prioritiseUncloned(ctx, names, timeNow())
 }
 
 // EnsureScheduled ensures that all repos in repos exist in the scheduler.
-func (s *updateScheduler) EnsureScheduled(repos []types.RepoName) {
-	s.schedule.insertNew(repos)
-}
-
-// ListRepos list all repos managed by the scheduler
-func (s *updateScheduler) ListRepos() []string {
-	s.schedule.mu.Lock()
-	defer s.schedule.mu.Unlock()
-
-	names := make([]string, len(s.schedule.heap))
-	for i := range s.schedule.heap {
-		names[i] = string(s.schedule.heap[i].Repo.Name)
-	}
-	return names
-}
-
-// upsert adds r to the scheduler for periodic updates. If r.ID is already in
-// the scheduler, then the fields are updated (upsert).
-//
-// If enqueue is true then r is also enqueued to the update queue for a git
-// fetch/clone soon.
-func (s *updateScheduler) upsert(r *types.Repo, enqueue bool) {
-	repo := configuredRepoFromRepo(r)
-
-	updated := s.schedule.upsert(repo)
-	log15.Debug("scheduler.schedule.upserted", "repo", r.Name, "updated", updated)
-
-	if !enqueue {
-		return
-	}
-	updated = s.updateQueue.enqueue(repo, priorityLow)
-	log15.Debug("scheduler.updateQueue.enqueued", "repo", r.Name, "updated", updated)
-}
-
-func (s *updateScheduler) remove(r *types.Repo) {
-	repo := configuredRepoFromRepo(r)
-
-	if s.schedule.remove(repo) {
-		log15.Debug("scheduler.schedule.removed", "repo", r.Name)
-	}
-
-	if s.updateQueue.remove(repo, false) {
-		log15.Debug("scheduler.updateQueue.removed", "repo", r.Name)
+func (s *updateScheduler) EnsureScheduled(ctx context.Context, repos []types.RepoName) error {
+	ids := make([]api.RepoID, len(repos))
+	for i, r := range repos {
+		ids[i] = r.ID
 	}
+	return s.store.upsert(ctx, ids, false, timeNow())
 }
 
-func configuredRepoFromRepo(r *types.Repo) configuredRepo {
-	repo := configuredRepo{
-		ID:   r.ID,
-		Name: r.Name,
-	}
-
-	return repo
+// ListRepos list all repos managed by the scheduler
+func (s *updateScheduler) ListRepos(ctx context.Context) ([]string, error) {
+	return s.store.listNames(ctx)
 }
 
 // UpdateOnce causes a single update of the given repository.
 // It neither adds nor removes the repo from the schedule.
-func (s *updateScheduler) UpdateOnce(id api.RepoID, name api.RepoName) {
-	repo := configuredRepo{
-		ID:   id,
-		Name: name,
-	}
+func (s *updateScheduler) UpdateOnce(ctx context.Context, id api.RepoID, name api.RepoName) error {
 	schedManualFetch.Inc()
-	s.updateQueue.enqueue(repo, priorityHigh)
+	if err := s.store.enqueue(ctx, id, priorityHigh, timeNow()); err != nil {
+		return err
+	}
+	notify(s.notifyEnqueue)
+	return nil
 }
 
 // DebugDump returns the state of the update scheduler for debugging.
@@ -383,45 +423,17 @@ func (s *updateScheduler) DebugDump(ctx context.Context, db dbutil.DB) interface
 		Name: "repos",
 	}
 
-	s.schedule.mu.Lock()
-	schedule := schedule{
-		heap: make([]*scheduledRepoUpdate, len(s.schedule.heap)),
-	}
-	for i, update := range s.schedule.heap {
-		// Copy the scheduledRepoUpdate as a value so that
-		// popping off the heap here won't update the index value of the real heap, and
-		// we don't do a racy read on the repo pointer which may change concurrently in the real heap.
-		updateCopy := *update
-		schedule.heap[i] = &updateCopy
-	}
-	s.schedule.mu.Unlock()
-
-	for len(schedule.heap) > 0 {
-		update := heap.Pop(&schedule).(*scheduledRepoUpdate)
-		data.Schedule = append(data.Schedule, update)
-	}
-
-	s.updateQueue.mu.Lock()
-	updateQueue := updateQueue{
-		heap: make([]*repoUpdate, len(s.updateQueue.heap)),
-	}
-	for i, update := range s.updateQueue.heap {
-		// Copy the repoUpdate as a value so that
-		// popping off the heap here won't update the index value of the real heap, and
-		// we don't do a racy read on the repo pointer which may change concurrently in the real heap.
-		updateCopy := *update
-		updateQueue.heap[i] = &updateCopy
+	var err error
+	data.Schedule, err = s.store.listSchedule(ctx)
+	if err != nil {
+		log15.Warn("Getting update schedule for debug page", "error", err)
 	}
-	s.updateQueue.mu.Unlock()
 
-	for len(updateQueue.heap) > 0 {
-		// Copy the scheduledRepoUpdate as a value so that the repo pointer
-		// won't change concurrently after we release the lock.
-		update := heap.Pop(&updateQueue).(*repoUpdate)
-		data.UpdateQueue = append(data.UpdateQueue, update)
+	data.UpdateQueue, err = s.store.listQueue(ctx, timeNow())
+	if err != nil {
+		log15.Warn("Getting update queue for debug page", "error", err)
 	}
 
-	var err error
 	data.SyncJobs, err = database.ExternalServices(db).GetSyncJobs(ctx)
 	if err != nil {
 		log15.Warn("Getting external service sync jobs foe debug page", "error", err)
@@ -431,48 +443,29 @@ func (s *updateScheduler) DebugDump(ctx context.Context, db dbutil.DB) interface
 }
 
 // ScheduleInfo returns the current schedule info for a repo.
-func (s *updateScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult {
-	var result protocol.RepoUpdateSchedulerInfoResult
+func (s *updateScheduler) ScheduleInfo(ctx context.Context, id api.RepoID) (*protocol.RepoUpdateSchedulerInfoResult, error) {
+	info, err := s.store.info(ctx, id, timeNow())
+	if err != nil {
+		return nil, err
+	}
 
-	s.schedule.mu.Lock()
-	if update := s.schedule.index[id]; update != nil {
+	var result protocol.RepoUpdateSchedulerInfoResult
+	if info.Scheduled {
 		result.Schedule = &protocol.RepoScheduleState{
-			Index:           update.Index,
-			Total:           len(s.schedule.index),
-			IntervalSeconds: int(update.Interval / time.Second),
-			Due:             update.Due,
+			Index:           info.ScheduleIndex,
+			Total:           info.ScheduleTotal,
+			IntervalSeconds: int(info.Interval / time.Second),
+			Due:             info.Due,
 		}
 	}
-	s.schedule.mu.Unlock()
-
-	s.updateQueue.mu.Lock()
-	if update := s.updateQueue.index[id]; update != nil {
+	if info.Queued {
 		result.Queue = &protocol.RepoQueueState{
-			Index:    update.Index,
-			Total:    len(s.updateQueue.index),
-			Updating: update.Updating,
+			Index:    info.QueueIndex,
+			Total:    info.QueueTotal,
+			Updating: info.Updating,
 		}
 	}
-	s.updateQueue.mu.Unlock()
-
-	return &result
-}
-
-// updateQueue is a priority queue of repos to update.
-// A repo can't have more than one location in the queue.
-// Implements heap.Interface and sort.Interface.
-type updateQueue struct {
-	mu sync.Mutex
-
-	heap  []*repoUpdate
-	index map[api.RepoID]*repoUpdate
-
-	seq uint64
-
-	// The queue performs a non-blocking send on this channel
-	// when a new value is enqueued so that the update loop
-	// can wake up if it is idle.
-	notifyEnqueue chan struct{}
+	return &result, nil
 }
 
 type priority int
@@ -486,405 +479,18 @@ const (
 type repoUpdate struct {
 	Repo     configuredRepo
 	Priority priority
-	Seq      uint64 // the sequence number of the update
-	Updating bool   // whether the repo has been acquired for update
-	Index    int    `json:"-"` // the index in the heap
-}
-
-func (q *updateQueue) reset() {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	q.heap = q.heap[:0]
-	q.index = map[api.RepoID]*repoUpdate{}
-	q.seq = 0
-	q.notifyEnqueue = make(chan struct{}, notifyChanBuffer)
-
-	schedUpdateQueueLength.Set(0)
-}
-
-// enqueue adds the repo to the queue with the given priority.
-//
-// If the repo is already in the queue and it isn't yet updating,
-// the repo is updated.
-//
-// If the given priority is higher than the one in the queue,
-// the repo's position in the queue is updated accordingly.
-func (q *updateQueue) enqueue(repo configuredRepo, p priority) (updated bool) {
-	if repo.ID == 0 {
-		panic("repo.id is zero")
-	}
-
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	update := q.index[repo.ID]
-	if update == nil {
-		heap.Push(q, &repoUpdate{
-			Repo:     repo,
-			Priority: p,
-		})
-		notify(q.notifyEnqueue)
-		return false
-	}
-
-	if update.Updating {
-		return false
-	}
-
-	update.Repo = repo
-	if p <= update.Priority {
-		// Repo is already in the queue with at least as good priority.
-		return true
-	}
-
-	// Repo is in the queue at a lower priority.
-	update.Priority = p      // bump the priority
-	update.Seq = q.nextSeq() // put it after all existing updates with this priority
-	heap.Fix(q, update.Index)
-	notify(q.notifyEnqueue)
-
-	return true
-}
-
-// nextSeq increments and returns the next sequence number.
-// The caller must hold the lock on q.mu.
-func (q *updateQueue) nextSeq() uint64 {
-	q.seq++
-	return q.seq
-}
-
-// remove removes the repo from the queue if the repo.Updating matches the updating argument.
-func (q *updateQueue) remove(repo configuredRepo, updating bool) (removed bool) {
-	if repo.ID == 0 {
-		panic("repo.id is zero")
-	}
-
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	update := q.index[repo.ID]
-	if update != nil && update.Updating == updating {
-		heap.Remove(q, update.Index)
-		return true
-	}
-
-	return false
-}
-
-// acquireNext acquires the next repo for update.
-// The acquired repo must be removed from the queue
-// when the update finishes (independent of success or failure).
-func (q *updateQueue) acquireNext() (configuredRepo, bool) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	if len(q.heap) == 0 {
-		return configuredRepo{}, false
-	}
-	update := q.heap[0]
-	if update.Updating {
-		// Everything in the queue is already updating.
-		return configuredRepo{}, false
-	}
-	update.Updating = true
-	heap.Fix(q, update.Index)
-	return update.Repo, true
-}
-
-// The following methods implement heap.Interface based on the priority queue example:
-// https://golang.org/pkg/container/heap/#example__priorityQueue
-// These methods are not safe for concurrent use. Therefore, it is the caller's
-// responsibility to ensure they're being guarded by a mutex during any heap operation,
-// i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-
-func (q *updateQueue) Len() int {
-	n := len(q.heap)
-	schedUpdateQueueLength.Set(float64(n))
-	return n
-}
-
-func (q *updateQueue) Less(i, j int) bool {
-	qi := q.heap[i]
-	qj := q.heap[j]
-	if qi.Updating != qj.Updating {
-		// Repos that are already updating are sorted last.
-		return qj.Updating
-	}
-	if qi.Priority != qj.Priority {
-		// We want Pop to give us the highest, not lowest, priority so we use greater than here.
-		return qi.Priority > qj.Priority
-	}
-	// Queue semantics for items with the same priority.
-	return qi.Seq < qj.Seq
-}
-
-func (q *updateQueue) Swap(i, j int) {
-	q.heap[i], q.heap[j] = q.heap[j], q.heap[i]
-	q.heap[i].Index = i
-	q.heap[j].Index = j
-}
-
-func (q *updateQueue) Push(x interface{}) {
-	n := len(q.heap)
-	item := x.(*repoUpdate)
-	item.Index = n
-	item.Seq = q.nextSeq()
-	q.heap = append(q.heap, item)
-	q.index[item.Repo.ID] = item
-}
-
-func (q *updateQueue) Pop() interface{} {
-	n := len(q.heap)
-	item := q.heap[n-1]
-	item.Index = -1 // for safety
-	q.heap = q.heap[0 : n-1]
-	delete(q.index, item.Repo.ID)
-	return item
-}
-
-// schedule is the schedule of when repos get enqueued into the updateQueue.
-type schedule struct {
-	mu sync.Mutex
-
-	heap  []*scheduledRepoUpdate // min heap of scheduledRepoUpdates based on their due time.
-	index map[api.RepoID]*scheduledRepoUpdate
-
-	// timer sends a value on the wakeup channel when it is time
-	timer  *time.Timer
-	wakeup chan struct{}
+	Queued   time.Time // when the repo was queued
+	Updating bool      // whether the repo has been acquired for update
+	Owner    string    // the replica which acquired the repo, if any
 }
 
 // scheduledRepoUpdate is the update schedule for a single repo.
 type scheduledRepoUpdate struct {
-	Repo     configuredRepo // the repo to update
-	Interval time.Duration  // how regularly the repo is updated
-	Due      time.Time      // the next time that the repo will be enqueued for a update
-	Index    int            `json:"-"` // the index in the heap
-}
-
-// upsert inserts or updates a repo in the schedule.
-func (s *schedule) upsert(repo configuredRepo) (updated bool) {
-	if repo.ID == 0 {
-		panic("repo.id is zero")
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if update := s.index[repo.ID]; update != nil {
-		update.Repo = repo
-		return true
-	}
-
-	heap.Push(s, &scheduledRepoUpdate{
-		Repo:     repo,
-		Interval: minDelay,
-		Due:      timeNow().Add(minDelay),
-	})
-
-	s.rescheduleTimer()
-
-	return false
-}
-
-func (s *schedule) prioritiseUncloned(names []string) {
-	// Set of names created outside of lock for fast checking.
-	uncloned := make(map[string]struct{}, len(names))
-	for _, n := range names {
-		uncloned[strings.ToLower(n)] = struct{}{}
-	}
-
-	// All non-cloned repos will be due for cloning as if they are newly added
-	// repos.
-	notClonedDue := timeNow().Add(minDelay)
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Iterate over all repos in the scheduler. If it isn't in cloned bump it
-	// up the queue. Note: we iterate over index because we will be mutating
-	// heap.
-	rescheduleTimer := false
-	for _, repoUpdate := range s.index {
-		if _, ok := uncloned[strings.ToLower(string(repoUpdate.Repo.Name))]; !ok {
-			// It not in the uncloned list, skip
-			continue
-		}
-		if repoUpdate.Due.After(notClonedDue) {
-			repoUpdate.Due = notClonedDue
-			heap.Fix(s, repoUpdate.Index)
-			rescheduleTimer = true
-		}
-	}
-
-	// We updated the queue, inform the scheduler loop.
-	if rescheduleTimer {
-		s.rescheduleTimer()
-	}
-}
-
-// insertNew will insert repos only if they are not known to the scheduler
-func (s *schedule) insertNew(repos []types.RepoName) {
-	required := make(map[string]struct{}, len(repos))
-	for _, n := range repos {
-		required[strings.ToLower(string(n.Name))] = struct{}{}
-	}
-
-	configuredRepos := make([]configuredRepo, len(repos))
-	for i := range repos {
-		configuredRepos[i] = configuredRepo{
-			ID:   repos[i].ID,
-			Name: repos[i].Name,
-		}
-	}
-
-	due := timeNow().Add(minDelay)
-	rescheduleTimer := false
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for _, repo := range configuredRepos {
-		if update := s.index[repo.ID]; update != nil {
-			continue
-		}
-		heap.Push(s, &scheduledRepoUpdate{
-			Repo:     repo,
-			Interval: minDelay,
-			Due:      due,
-		})
-		rescheduleTimer = true
-	}
-
-	if rescheduleTimer {
-		s.rescheduleTimer()
-	}
-}
-
-// updateInterval updates the update interval of a repo in the schedule.
-// It does nothing if the repo is not in the schedule.
-func (s *schedule) updateInterval(repo configuredRepo, interval time.Duration) {
-	if repo.ID == 0 {
-		panic("repo.id is zero")
-	}
-
-	s.mu.Lock()
-	if update := s.index[repo.ID]; update != nil {
-		switch {
-		case interval > maxDelay:
-			update.Interval = maxDelay
-		case interval < minDelay:
-			update.Interval = minDelay
-		default:
-			update.Interval = interval
-		}
-		update.Due = timeNow().Add(update.Interval)
-		log15.Debug("updated repo", "repo", repo.Name, "due", update.Due.Sub(timeNow()))
-		heap.Fix(s, update.Index)
-		s.rescheduleTimer()
-	}
-	s.mu.Unlock()
-}
-
-// getCurrentInterval gets the current interval for the supplied repo and a bool
-// indicating whether it was found.
-func (s *schedule) getCurrentInterval(repo configuredRepo) (time.Duration, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	update, ok := s.index[repo.ID]
-	if !ok || update == nil {
-		return 0, false
-	}
-	return update.Interval, true
-}
-
-// remove removes a repo from the schedule.
-func (s *schedule) remove(repo configuredRepo) (removed bool) {
-	if repo.ID == 0 {
-		panic("repo.id is zero")
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	update := s.index[repo.ID]
-	if update == nil {
-		return false
-	}
-
-	reschedule := update.Index == 0
-	if heap.Remove(s, update.Index); reschedule {
-		s.rescheduleTimer()
-	}
-
-	return true
-}
-
-// rescheduleTimer schedules the scheduler to wakeup
-// at the time that the next repo is due for an update.
-// The caller must hold the lock on s.mu.
-func (s *schedule) rescheduleTimer() {
-	if s.timer != nil {
-		s.timer.Stop()
-		s.timer = nil
-	}
-	if len(s.heap) > 0 {
-		delay := s.heap[0].Due.Sub(timeNow())
-		s.timer = timeAfterFunc(delay, func() {
-			notify(s.wakeup)
-		})
-	}
-}
-
-func (s *schedule) reset() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.heap = s.heap[:0]
-	s.index = map[api.RepoID]*scheduledRepoUpdate{}
-	s.wakeup = make(chan struct{}, notifyChanBuffer)
-	if s.timer != nil {
-		s.timer.Stop()
-		s.timer = nil
-	}
-	schedKnownRepos.Set(0)
-}
-
-// The following methods implement heap.Interface based on the priority queue example:
-// https://golang.org/pkg/container/heap/#example__priorityQueue
-// These methods are not safe for concurrent use. Therefore, it is the caller's
-// responsibility to ensure they're being guarded by a mutex during any heap operation,
-// i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-
-func (s *schedule) Len() int { return len(s.heap) }
-func (s *schedule) Less(i, j int) bool {
-	return s.heap[i].Due.Before(s.heap[j].Due)
-}
-
-func (s *schedule) Swap(i, j int) {
-	s.heap[i], s.heap[j] = s.heap[j], s.heap[i]
-	s.heap[i].Index = i
-	s.heap[j].Index = j
-}
-
-func (s *schedule) Push(x interface{}) {
-	n := len(s.heap)
-	item := x.(*scheduledRepoUpdate)
-	item.Index = n
-	s.heap = append(s.heap, item)
-	s.index[item.Repo.ID] = item
-	schedKnownRepos.Inc()
-}
-
-func (s *schedule) Pop() interface{} {
-	n := len(s.heap)
-	item := s.heap[n-1]
-	item.Index = -1 // for safety
-	s.heap = s.heap[0 : n-1]
-	delete(s.index, item.Repo.ID)
-	schedKnownRepos.Dec()
-	return item
+	Repo        configuredRepo // the repo to update
+	Interval    time.Duration  // how regularly the repo is updated
+	Due         time.Time      // the next time that the repo will be enqueued for a update
+	LastUpdated time.Time      // the last time that an update of the repo finished
+	LastError   string         // the error of the last update, if any
 }
 
 // notify performs a non-blocking send on the channel.
@@ -898,6 +504,6 @@ var notify = func(ch chan struct{}) {
 
 // Mockable time functions for testing.
 var (
-	timeNow       = time.Now
-	timeAfterFunc = time.AfterFunc
+	timeNow   = time.Now
+	timeAfter = time.After
 )
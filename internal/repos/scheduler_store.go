@@ -0,0 +1,391 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// scheduleStore persists the schedule and the update queue of the
+// updateScheduler in the repo_update_schedule table, so that they survive
+// restarts and are shared by all repo-updater replicas.
+//
+// A row is in the schedule if it is scheduled, and in the update queue if it
+// is queued. Rows which are only queued are deleted once their update is
+// done. An update is performed by the replica holding its lease. Leases
+// expire, so updates of replicas which went away are taken over by others.
+//
+// All methods take the current time as an argument, rather than using the
+// time of the database, so that tests can control the clock.
+type scheduleStore struct {
+	*basestore.Store
+}
+
+func newScheduleStore(db dbutil.DB) *scheduleStore {
+	return &scheduleStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// scheduleLeaseDuration is how long a lease lasts without being renewed.
+// Replicas renew the leases of their updates every scheduleLeaseRenewInterval,
+// so updates which take long, such as large clones, keep their lease.
+const (
+	scheduleLeaseDuration      = 5 * time.Minute
+	scheduleLeaseRenewInterval = scheduleLeaseDuration / 3
+)
+
+// upsert adds the repos to the schedule. Repos which are new to the schedule
+// are due after minDelay. If enqueue is true, the repos are also queued for an
+// update with low priority unless they are queued already.
+func (s *scheduleStore) upsert(ctx context.Context, ids []api.RepoID, enqueue bool, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var queuedAt *time.Time
+	if enqueue {
+		queuedAt = &now
+	}
+	due := now.Add(minDelay)
+
+	// The ids are passed as a single array, since a parameter per repo would
+	// exceed the limit of bind parameters for large code hosts.
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.upsert
+INSERT INTO repo_update_schedule (repo_id, interval_seconds, due_at, queued_at)
+SELECT id, %s, %s, %s::timestamp with time zone FROM unnest(%s::integer[]) AS id
+ON CONFLICT (repo_id) DO UPDATE SET
+	scheduled = TRUE,
+	queued_at = COALESCE(repo_update_schedule.queued_at, excluded.queued_at)
+`, int(minDelay/time.Second), due, queuedAt, pq.Array(ids)))
+}
+
+// remove removes the repos from the schedule and the update queue.
+func (s *scheduleStore) remove(ctx context.Context, ids []api.RepoID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.remove
+DELETE FROM repo_update_schedule WHERE repo_id = ANY(%s)
+`, pq.Array(ids)))
+}
+
+// enqueue queues the repo for an update with the given priority, without
+// adding it to the schedule. If the repo is queued already with a lower
+// priority, it is moved after all queued repos with the given priority.
+func (s *scheduleStore) enqueue(ctx context.Context, id api.RepoID, p priority, now time.Time) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.enqueue
+INSERT INTO repo_update_schedule (repo_id, scheduled, interval_seconds, due_at, queued_at, priority)
+VALUES (%s, FALSE, %s, %s, %s, %s)
+ON CONFLICT (repo_id) DO UPDATE SET
+	queued_at = CASE
+		WHEN repo_update_schedule.queued_at IS NULL OR excluded.priority > repo_update_schedule.priority THEN excluded.queued_at
+		ELSE repo_update_schedule.queued_at
+	END,
+	priority = GREATEST(repo_update_schedule.priority, excluded.priority)
+`, id, int(minDelay/time.Second), now.Add(minDelay), now, int(p)))
+}
+
+// enqueueDue queues all scheduled repos which are due for an update with low
+// priority, and schedules their next update after their interval. It
+// returns the number of queued repos.
+func (s *scheduleStore) enqueueDue(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.ExecResult(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.enqueueDue
+UPDATE repo_update_schedule SET
+	queued_at = %s,
+	due_at = %s::timestamptz + interval_seconds * interval '1 second'
+WHERE scheduled AND queued_at IS NULL AND due_at <= %s
+`, now, now, now))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// prioritiseUncloned moves the scheduled repos with the given names to be due
+// after minDelay, unless they are due sooner already.
+func (s *scheduleStore) prioritiseUncloned(ctx context.Context, names []string, now time.Time) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	lower := make([]string, len(names))
+	for i, n := range names {
+		lower[i] = strings.ToLower(n)
+	}
+	due := now.Add(minDelay)
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.prioritiseUncloned
+UPDATE repo_update_schedule s SET due_at = %s
+FROM repo
+WHERE
+	repo.id = s.repo_id AND
+	s.scheduled AND
+	s.due_at > %s AND
+	lower(repo.name::text) COLLATE "C" = ANY (%s::text[])
+`, due, due, pq.Array(lower)))
+}
+
+// listNames returns the names of all scheduled repos.
+func (s *scheduleStore) listNames(ctx context.Context) ([]string, error) {
+	return basestore.ScanStrings(s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.listNames
+SELECT repo.name
+FROM repo_update_schedule s
+JOIN repo ON repo.id = s.repo_id
+WHERE s.scheduled
+`)))
+}
+
+// acquireNext leases the queued repo with the highest priority which no
+// replica is updating to the given owner. The lease must be released with
+// complete once the update is done, independent of success or failure.
+func (s *scheduleStore) acquireNext(ctx context.Context, owner string, now time.Time) (_ *scheduledRepoUpdate, ok bool, err error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.acquireNext
+WITH candidate AS (
+	SELECT repo_id
+	FROM repo_update_schedule
+	WHERE queued_at IS NOT NULL AND (lease_expires_at IS NULL OR lease_expires_at <= %s)
+	ORDER BY priority DESC, queued_at, repo_id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+),
+acquired AS (
+	UPDATE repo_update_schedule s SET
+		lease_owner = %s,
+		lease_expires_at = %s
+	FROM candidate
+	WHERE s.repo_id = candidate.repo_id
+	RETURNING s.repo_id, s.interval_seconds, s.due_at
+)
+SELECT acquired.repo_id, repo.name, acquired.interval_seconds, acquired.due_at
+FROM acquired
+JOIN repo ON repo.id = acquired.repo_id
+`, now, owner, now.Add(scheduleLeaseDuration)))
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	if !rows.Next() {
+		return nil, false, nil
+	}
+	var (
+		update          scheduledRepoUpdate
+		intervalSeconds int
+	)
+	if err := rows.Scan(&update.Repo.ID, &update.Repo.Name, &intervalSeconds, &update.Due); err != nil {
+		return nil, false, err
+	}
+	update.Interval = time.Duration(intervalSeconds) * time.Second
+	return &update, true, nil
+}
+
+// renewLease extends the lease of owner on the repo by scheduleLeaseDuration.
+// It returns false if owner doesn't hold the lease anymore, because it
+// expired and another replica took it over.
+func (s *scheduleStore) renewLease(ctx context.Context, id api.RepoID, owner string, now time.Time) (bool, error) {
+	res, err := s.ExecResult(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.renewLease
+UPDATE repo_update_schedule SET lease_expires_at = %s
+WHERE repo_id = %s AND lease_owner = %s
+`, now.Add(scheduleLeaseDuration), id, owner))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// complete releases the lease of owner on the repo and records the outcome of
+// its update. If interval is not zero, it becomes the new interval of the
+// repo and its next update is due after it. Repos which are not scheduled are
+// deleted.
+func (s *scheduleStore) complete(ctx context.Context, id api.RepoID, owner string, interval time.Duration, updateErr error, now time.Time) error {
+	var lastError *string
+	if updateErr != nil {
+		msg := updateErr.Error()
+		lastError = &msg
+	}
+
+	if err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.complete
+DELETE FROM repo_update_schedule WHERE repo_id = %s AND lease_owner = %s AND NOT scheduled
+`, id, owner)); err != nil {
+		return err
+	}
+
+	intervalSeconds := int(interval / time.Second)
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.complete
+UPDATE repo_update_schedule SET
+	queued_at = NULL,
+	priority = 0,
+	lease_owner = NULL,
+	lease_expires_at = NULL,
+	last_updated_at = %s,
+	last_error = %s,
+	interval_seconds = CASE WHEN %s > 0 THEN %s ELSE interval_seconds END,
+	due_at = CASE WHEN %s > 0 THEN %s::timestamptz + %s * interval '1 second' ELSE due_at END
+WHERE repo_id = %s AND lease_owner = %s
+`, now, lastError, intervalSeconds, intervalSeconds, intervalSeconds, now, intervalSeconds, id, owner))
+}
+
+// listSchedule returns all scheduled repos in the order they are due.
+func (s *scheduleStore) listSchedule(ctx context.Context) (_ []*scheduledRepoUpdate, err error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.listSchedule
+SELECT s.repo_id, repo.name, s.interval_seconds, s.due_at, s.last_updated_at, s.last_error
+FROM repo_update_schedule s
+JOIN repo ON repo.id = s.repo_id
+WHERE s.scheduled
+ORDER BY s.due_at, s.repo_id
+`))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var updates []*scheduledRepoUpdate
+	for rows.Next() {
+		var (
+			update          scheduledRepoUpdate
+			intervalSeconds int
+		)
+		if err := rows.Scan(
+			&update.Repo.ID,
+			&update.Repo.Name,
+			&intervalSeconds,
+			&update.Due,
+			&dbutil.NullTime{Time: &update.LastUpdated},
+			&dbutil.NullString{S: &update.LastError},
+		); err != nil {
+			return nil, err
+		}
+		update.Interval = time.Duration(intervalSeconds) * time.Second
+		updates = append(updates, &update)
+	}
+	return updates, nil
+}
+
+// listQueue returns all queued repos in the order they are updated. Repos
+// which are being updated come last.
+func (s *scheduleStore) listQueue(ctx context.Context, now time.Time) (_ []*repoUpdate, err error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.listQueue
+SELECT s.repo_id, repo.name, s.priority, s.queued_at, s.lease_expires_at IS NOT NULL AND s.lease_expires_at > %s, s.lease_owner
+FROM repo_update_schedule s
+JOIN repo ON repo.id = s.repo_id
+WHERE s.queued_at IS NOT NULL
+ORDER BY 5, s.priority DESC, s.queued_at, s.repo_id
+`, now))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var updates []*repoUpdate
+	for rows.Next() {
+		var (
+			update repoUpdate
+			p      int
+		)
+		if err := rows.Scan(
+			&update.Repo.ID,
+			&update.Repo.Name,
+			&p,
+			&update.Queued,
+			&update.Updating,
+			&dbutil.NullString{S: &update.Owner},
+		); err != nil {
+			return nil, err
+		}
+		update.Priority = priority(p)
+		updates = append(updates, &update)
+	}
+	return updates, nil
+}
+
+// counts returns the number of scheduled and queued repos.
+func (s *scheduleStore) counts(ctx context.Context) (scheduled, queued int, err error) {
+	err = s.QueryRow(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.counts
+SELECT
+	COUNT(*) FILTER (WHERE scheduled),
+	COUNT(*) FILTER (WHERE queued_at IS NOT NULL)
+FROM repo_update_schedule
+`)).Scan(&scheduled, &queued)
+	return scheduled, queued, err
+}
+
+// info returns the position of the repo in the schedule and the update
+// queue. The states are nil if the repo is not in either.
+func (s *scheduleStore) info(ctx context.Context, id api.RepoID, now time.Time) (*scheduleInfo, error) {
+	var (
+		info            scheduleInfo
+		intervalSeconds int
+	)
+	err := s.QueryRow(ctx, sqlf.Sprintf(`
+-- source: internal/repos/scheduler_store.go:scheduleStore.info
+SELECT
+	s.scheduled,
+	s.interval_seconds,
+	s.due_at,
+	s.queued_at IS NOT NULL,
+	s.lease_expires_at IS NOT NULL AND s.lease_expires_at > %s,
+	(SELECT COUNT(*) FROM repo_update_schedule o WHERE o.scheduled AND (o.due_at, o.repo_id) < (s.due_at, s.repo_id)),
+	(SELECT COUNT(*) FROM repo_update_schedule WHERE scheduled),
+	(
+		SELECT COUNT(*) FROM repo_update_schedule o
+		WHERE o.queued_at IS NOT NULL AND (o.priority > s.priority OR (o.priority = s.priority AND (o.queued_at, o.repo_id) < (s.queued_at, s.repo_id)))
+	),
+	(SELECT COUNT(*) FROM repo_update_schedule WHERE queued_at IS NOT NULL)
+FROM repo_update_schedule s
+WHERE s.repo_id = %s
+`, now, id)).Scan(
+		&info.Scheduled,
+		&intervalSeconds,
+		&info.Due,
+		&info.Queued,
+		&info.Updating,
+		&info.ScheduleIndex,
+		&info.ScheduleTotal,
+		&info.QueueIndex,
+		&info.QueueTotal,
+	)
+	if err == sql.ErrNoRows {
+		return &scheduleInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Interval = time.Duration(intervalSeconds) * time.Second
+	return &info, nil
+}
+
+// scheduleInfo is the state of a single repo in the schedule and the update
+// queue.
+type scheduleInfo struct {
+	Scheduled     bool
+	Interval      time.Duration
+	Due           time.Time
+	ScheduleIndex int
+	ScheduleTotal int
+
+	Queued     bool
+	Updating   bool
+	QueueIndex int
+	QueueTotal int
+}
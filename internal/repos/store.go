@@ -411,6 +411,10 @@ func (s *Store) CreateExternalServiceRepo(ctx context.Context, svc *types.Extern
 		return err
 	}
 
+	if err = s.syncRepoTopics(ctx, r); err != nil {
+		return err
+	}
+
 	return s.Exec(ctx, sqlf.Sprintf(upsertExternalServiceRepoQuery,
 		svc.ID,
 		r.ID,
@@ -524,6 +528,10 @@ func (s *Store) UpdateExternalServiceRepo(ctx context.Context, svc *types.Extern
 		return err
 	}
 
+	if err = s.syncRepoTopics(ctx, r); err != nil {
+		return err
+	}
+
 	return s.Exec(ctx, sqlf.Sprintf(upsertExternalServiceRepoQuery,
 		svc.ID,
 		r.ID,
@@ -533,6 +541,28 @@ func (s *Store) UpdateExternalServiceRepo(ctx context.Context, svc *types.Extern
 	))
 }
 
+// syncRepoTopics replaces the topics of the repo in the repo_topics table
+// with the topics in its metadata.
+func (s *Store) syncRepoTopics(ctx context.Context, r *types.Repo) error {
+	topics := repoTopics(r)
+	return s.Exec(ctx, sqlf.Sprintf(syncRepoTopicsQuery,
+		r.ID,
+		pq.Array(topics),
+		r.ID,
+		pq.Array(topics),
+	))
+}
+
+const syncRepoTopicsQuery = `
+WITH deleted AS (
+	DELETE FROM repo_topics
+	WHERE repo_id = %s AND NOT topic = ANY (%s::text[])
+)
+INSERT INTO repo_topics (repo_id, topic)
+SELECT %s, unnest(%s::text[])
+ON CONFLICT DO NOTHING
+`
+
 const updateRepoQuery = `
 UPDATE repo
 SET
@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/subversion"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A SubversionSource yields repositories from a single Subversion connection
+// configured in Sourcegraph via the external services configuration.
+type SubversionSource struct {
+	svc     *types.ExternalService
+	config  *schema.SubversionConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *subversion.Client
+}
+
+// NewSubversionSource returns a new SubversionSource from the given external
+// service.
+func NewSubversionSource(svc *types.ExternalService, cf *httpcli.Factory) (*SubversionSource, error) {
+	var c schema.SubversionConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newSubversionSource(svc, &c, cf)
+}
+
+func newSubversionSource(svc *types.ExternalService, c *schema.SubversionConnection, cf *httpcli.Factory) (*SubversionSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := subversion.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &SubversionSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		exclude: exclude,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Subversion repositories configured with this
+// SubversionSource's config.
+func (s *SubversionSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	if len(s.config.Repos) > 0 {
+		for _, name := range s.config.Repos {
+			if r := s.client.Repo(name); !s.exclude(r.Name) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+			}
+		}
+		return
+	}
+
+	repos, err := s.client.ListRepos(ctx)
+	if err != nil {
+		results <- SourceResult{Source: s, Err: errors.Wrap(err, "subversion.repos")}
+		return
+	}
+	for _, r := range repos {
+		if !s.exclude(r.Name) {
+			results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+		}
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *SubversionSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *SubversionSource) makeRepo(r *subversion.Repo) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.SubversionRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			r.Name,
+		),
+		URI: string(reposource.SubversionRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          r.Name,
+			ServiceType: extsvc.TypeSubversion,
+			ServiceID:   s.baseURL.String(),
+		},
+		// Subversion doesn't expose who can read a repository, so
+		// repositories that need credentials are treated as private.
+		Private: s.config.Username != "",
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: r.URL,
+			},
+		},
+		Metadata: r,
+	}
+}
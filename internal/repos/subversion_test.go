@@ -0,0 +1,114 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/subversion"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestSubversionSource_ListRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>
+<li><a href="project/">project/</a></li>
+<li><a href="archive-2010/">archive-2010/</a></li>
+<li><a href="tools/">tools/</a></li>
+</ul></body></html>`)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "listed",
+			config: `{"exclude": [{"name": "tools"}, {"pattern": "^archive-"}]}`,
+			want:   []string{"project"},
+		},
+		{
+			name:   "repos",
+			config: `{"repos": ["team/app", "tools"], "exclude": [{"name": "tools"}]}`,
+			want:   []string{"team/app"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var config map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.config), &config); err != nil {
+				t.Fatal(err)
+			}
+			config["url"] = srv.URL + "/svn"
+			bs, _ := json.Marshal(config)
+
+			svc := &types.ExternalService{
+				ID:     1,
+				Kind:   extsvc.KindSubversion,
+				Config: string(bs),
+			}
+
+			src, err := NewSubversionSource(svc, httpcli.NewFactory(httpcli.NewMiddleware()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var have []string
+			for _, r := range repos {
+				have = append(have, r.Metadata.(*subversion.Repo).Name)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf("unexpected repos (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubversionSource_makeRepo(t *testing.T) {
+	svc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindSubversion,
+		Config: `{"url": "https://SVN.example.com/repos", "username": "admin", "password": "secret", "repositoryPathPattern": "svn/{name}"}`,
+	}
+
+	src, err := NewSubversionSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := src.makeRepo(src.client.Repo("team/app"))
+	want := &types.Repo{
+		Name: "svn/team/app",
+		URI:  "svn.example.com/team/app",
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "team/app",
+			ServiceType: extsvc.TypeSubversion,
+			ServiceID:   "https://svn.example.com/repos/",
+		},
+		Private: true,
+		Sources: map[string]*types.SourceInfo{
+			svc.URN(): {
+				ID:       svc.URN(),
+				CloneURL: "https://svn.example.com/repos/team/app",
+			},
+		},
+		Metadata: &subversion.Repo{Name: "team/app", URL: "https://svn.example.com/repos/team/app"},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("unexpected repo (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,36 @@
+package repos
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// repoTopics returns the sorted, lower case and deduplicated topics of the
+// repo as reported by its code host. Code hosts without topics return none.
+func repoTopics(r *types.Repo) []string {
+	var topics []string
+	switch m := r.Metadata.(type) {
+	case *github.Repository:
+		topics = m.Topics()
+	case *gitlab.Project:
+		topics = append(topics, m.Topics...)
+		topics = append(topics, m.TagList...)
+	}
+
+	seen := make(map[string]struct{}, len(topics))
+	normalized := make([]string, 0, len(topics))
+	for _, t := range topics {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if _, ok := seen[t]; ok || t == "" {
+			continue
+		}
+		seen[t] = struct{}{}
+		normalized = append(normalized, t)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
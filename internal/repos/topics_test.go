@@ -0,0 +1,55 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestRepoTopics(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		metadata interface{}
+		want     []string
+	}{
+		{
+			name: "github",
+			metadata: &github.Repository{
+				RepositoryTopics: &github.RepositoryTopics{Nodes: []github.RepositoryTopic{
+					{Topic: github.Topic{Name: "search"}},
+					{Topic: github.Topic{Name: "code-intelligence"}},
+				}},
+			},
+			want: []string{"code-intelligence", "search"},
+		},
+		{
+			name:     "github without topics",
+			metadata: &github.Repository{},
+			want:     []string{},
+		},
+		{
+			name: "gitlab",
+			metadata: &gitlab.Project{
+				Topics:  []string{"Search", " payments "},
+				TagList: []string{"search", ""},
+			},
+			want: []string{"payments", "search"},
+		},
+		{
+			name:     "unsupported code host",
+			metadata: &struct{}{},
+			want:     []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := repoTopics(&types.Repo{Metadata: tc.metadata})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("unexpected topics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
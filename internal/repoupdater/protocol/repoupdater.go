@@ -11,8 +11,12 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/mercurial"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/subversion"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
@@ -173,6 +177,34 @@ func NewRepoInfo(r *types.Repo) *RepoInfo {
 			Blob:   pathAppend(href, "/src/{rev}/{path}"),
 			Commit: pathAppend(href, "/commits/{commit}"),
 		}
+	case extsvc.TypeGitea:
+		repo := r.Metadata.(*gitea.Repository)
+		if repo.HTMLURL == "" {
+			break
+		}
+
+		info.Links = &RepoLinks{
+			Root:   repo.HTMLURL,
+			Tree:   pathAppend(repo.HTMLURL, "/src/commit/{rev}/{path}"),
+			Blob:   pathAppend(repo.HTMLURL, "/src/commit/{rev}/{path}"),
+			Commit: pathAppend(repo.HTMLURL, "/commit/{commit}"),
+		}
+	case extsvc.TypeGerrit:
+		// Gerrit has no repository browser of its own, we can only link to
+		// gitiles if it's configured.
+		proj := r.Metadata.(*gerrit.Project)
+		for _, l := range proj.WebLinks {
+			if l.Name != "gitiles" || l.URL == "" {
+				continue
+			}
+			info.Links = &RepoLinks{
+				Root:   l.URL,
+				Tree:   pathAppend(l.URL, "/+/{rev}/{path}"),
+				Blob:   pathAppend(l.URL, "/+/{rev}/{path}"),
+				Commit: pathAppend(l.URL, "/+/{commit}"),
+			}
+			break
+		}
 	case extsvc.TypeAWSCodeCommit:
 		repo := r.Metadata.(*awscodecommit.Repository)
 		if repo.ARN == "" {
@@ -195,6 +227,18 @@ func NewRepoInfo(r *types.Repo) *RepoInfo {
 			Blob:   webURL + "/browse/{rev}/--/{path}",
 			Commit: webURL + "/commit/{commit}",
 		}
+	case extsvc.TypeSubversion:
+		// Only the root can be linked to, since revisions and commits on
+		// Sourcegraph are those of the converted Git repository.
+		if u := r.Metadata.(*subversion.Repo).URL; strings.HasPrefix(u, "http") {
+			info.Links = &RepoLinks{Root: u}
+		}
+	case extsvc.TypeMercurial:
+		// Only the root can be linked to, since revisions and commits on
+		// Sourcegraph are those of the converted Git repository.
+		if u := r.Metadata.(*mercurial.Repo).URL; strings.HasPrefix(u, "http") {
+			info.Links = &RepoLinks{Root: u}
+		}
 	}
 
 	return &info
@@ -0,0 +1,183 @@
+// Package codeownership resolves the code owners of search results from the
+// CODEOWNERS file of their repository.
+package codeownership
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/codeowners"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// maxFileSize is the largest CODEOWNERS file that is read. GitHub ignores
+// CODEOWNERS files larger than 3 MB.
+const maxFileSize = 3 << 20
+
+// DefaultResolver is the Resolver used by search.
+var DefaultResolver = NewResolver(1000)
+
+// Resolver resolves the owners of files. The parsed CODEOWNERS files are
+// cached per commit, since they cannot change for a commit.
+type Resolver struct {
+	cache *lru.Cache
+}
+
+// NewResolver returns a Resolver which caches the CODEOWNERS files of up to
+// size commits.
+func NewResolver(size int) *Resolver {
+	c, err := lru.New(size)
+	if err != nil {
+		// Only returns an error for a non-positive size.
+		panic(err)
+	}
+	return &Resolver{cache: c}
+}
+
+// Ruleset returns the CODEOWNERS file of repo at commit. It returns an empty
+// Ruleset if the repository has no CODEOWNERS file or if it cannot be parsed,
+// so that one invalid file does not fail a whole search.
+func (r *Resolver) Ruleset(ctx context.Context, repo api.RepoName, commit api.CommitID) (*codeowners.Ruleset, error) {
+	key := string(repo) + "@" + string(commit)
+	if v, ok := r.cache.Get(key); ok {
+		return v.(*codeowners.Ruleset), nil
+	}
+
+	rs := &codeowners.Ruleset{}
+	for _, name := range codeowners.Paths {
+		content, err := git.ReadFile(ctx, repo, commit, name, maxFileSize)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parsed, err := codeowners.Parse(bytes.NewReader(content)); err != nil {
+			log15.Warn("codeownership: ignoring invalid CODEOWNERS file", "repo", repo, "commit", commit, "path", name, "error", err)
+		} else {
+			rs = parsed
+		}
+		break
+	}
+
+	r.cache.Add(key, rs)
+	return rs, nil
+}
+
+// Owners returns the owners of the file of fm.
+func (r *Resolver) Owners(ctx context.Context, fm *result.FileMatch) ([]string, error) {
+	commit := fm.CommitID
+	if commit == "" {
+		rev := "HEAD"
+		if fm.InputRev != nil && *fm.InputRev != "" {
+			rev = *fm.InputRev
+		}
+		var err error
+		commit, err = git.ResolveRevision(ctx, fm.Repo.Name, rev, git.ResolveRevisionOptions{NoEnsureRevision: true})
+		if err != nil {
+			return nil, err
+		}
+		fm.CommitID = commit
+	}
+
+	rs, err := r.Ruleset(ctx, fm.Repo.Name, commit)
+	if err != nil {
+		return nil, err
+	}
+	return rs.Match(fm.Path), nil
+}
+
+// Filter returns the file matches which are owned by all of owners. Other
+// matches are dropped, since they have no owners. Files whose owners cannot be
+// resolved are treated as having no owners.
+func (r *Resolver) Filter(ctx context.Context, matches []result.Match, owners []string) []result.Match {
+	if len(owners) == 0 {
+		return matches
+	}
+
+	filtered := matches[:0]
+	for _, m := range matches {
+		fm, ok := m.(*result.FileMatch)
+		if !ok {
+			continue
+		}
+		if ownedByAll(r.ownersOrNone(ctx, fm), owners) {
+			filtered = append(filtered, fm)
+		}
+	}
+	return filtered
+}
+
+// SelectOwners replaces file matches with an OwnerMatch for each owner of
+// their file, deduplicated by repository. Other matches are kept as is. Files
+// whose owners cannot be resolved are dropped.
+func (r *Resolver) SelectOwners(ctx context.Context, matches []result.Match) []result.Match {
+	dedup := result.NewDeduper()
+	for _, m := range matches {
+		fm, ok := m.(*result.FileMatch)
+		if !ok {
+			dedup.Add(m)
+			continue
+		}
+		owners := r.ownersOrNone(ctx, fm)
+		sort.Strings(owners)
+		for _, owner := range owners {
+			dedup.Add(&result.OwnerMatch{
+				Repo:     fm.Repo,
+				CommitID: fm.CommitID,
+				Owner:    owner,
+			})
+		}
+	}
+	return dedup.Results()
+}
+
+// NewFilter returns a function which filters the matches of each search event
+// by owners, and replaces file matches with their owners if selectOwners is
+// true. It is applied to results as they are streamed, before they count
+// against the result limit of the search.
+func (r *Resolver) NewFilter(ctx context.Context, owners []string, selectOwners bool) func([]result.Match) []result.Match {
+	return func(matches []result.Match) []result.Match {
+		matches = r.Filter(ctx, matches, owners)
+		if selectOwners {
+			matches = r.SelectOwners(ctx, matches)
+		}
+		return matches
+	}
+}
+
+// ownersOrNone returns the owners of the file of fm, or none if they cannot be
+// resolved.
+func (r *Resolver) ownersOrNone(ctx context.Context, fm *result.FileMatch) []string {
+	owners, err := r.Owners(ctx, fm)
+	if err != nil {
+		if ctx.Err() == nil {
+			log15.Warn("codeownership: failed to resolve owners", "repo", fm.Repo.Name, "path", fm.Path, "error", err)
+		}
+		return nil
+	}
+	return owners
+}
+
+func ownedByAll(fileOwners, owners []string) bool {
+	for _, want := range owners {
+		found := false
+		for _, owner := range fileOwners {
+			if codeowners.OwnerMatches(owner, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package codeownership
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestResolver(t *testing.T) {
+	reads := 0
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		reads++
+		if commit == "c1" && name == ".github/CODEOWNERS" {
+			return []byte("* @org/everyone\n/payments/ @org/team-payments @alice\n"), nil
+		}
+		if commit == "c3" && name == ".github/CODEOWNERS" {
+			// A line too long to be parsed.
+			return []byte("* @" + strings.Repeat("a", 100000) + "\n"), nil
+		}
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	t.Cleanup(git.ResetMocks)
+
+	repo := types.RepoName{ID: 1, Name: "github.com/org/repo"}
+	fileMatch := func(commit api.CommitID, path string) *result.FileMatch {
+		return &result.FileMatch{File: result.File{Repo: repo, CommitID: commit, Path: path}}
+	}
+	paths := func(matches []result.Match) []string {
+		var ps []string
+		for _, m := range matches {
+			ps = append(ps, m.(*result.FileMatch).Path)
+		}
+		return ps
+	}
+
+	ctx := context.Background()
+	r := NewResolver(10)
+
+	t.Run("Filter", func(t *testing.T) {
+		matches := []result.Match{
+			fileMatch("c1", "README.md"),
+			fileMatch("c1", "payments/charge.go"),
+			&result.RepoMatch{ID: 1, Name: repo.Name},
+			fileMatch("c2", "payments/charge.go"),
+		}
+		got := r.Filter(ctx, matches, []string{"team-payments"})
+		if diff := cmp.Diff([]string{"payments/charge.go"}, paths(got)); diff != "" {
+			t.Fatalf("unexpected matches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Filter by multiple owners", func(t *testing.T) {
+		matches := []result.Match{
+			fileMatch("c1", "payments/charge.go"),
+		}
+		got := r.Filter(ctx, matches, []string{"@alice", "@bob"})
+		if len(got) != 0 {
+			t.Fatalf("expected no matches, got %v", paths(got))
+		}
+	})
+
+	t.Run("SelectOwners", func(t *testing.T) {
+		matches := []result.Match{
+			fileMatch("c1", "README.md"),
+			fileMatch("c1", "payments/charge.go"),
+			fileMatch("c1", "payments/refund.go"),
+		}
+		got := r.SelectOwners(ctx, matches)
+		want := []result.Match{
+			&result.OwnerMatch{Repo: repo, CommitID: "c1", Owner: "@org/everyone"},
+			&result.OwnerMatch{Repo: repo, CommitID: "c1", Owner: "@alice"},
+			&result.OwnerMatch{Repo: repo, CommitID: "c1", Owner: "@org/team-payments"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("unexpected matches (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid CODEOWNERS file", func(t *testing.T) {
+		matches := []result.Match{
+			fileMatch("c3", "payments/charge.go"),
+			fileMatch("c1", "payments/charge.go"),
+		}
+		got := r.NewFilter(ctx, []string{"@alice"}, false)(matches)
+		if diff := cmp.Diff([]string{"payments/charge.go"}, paths(got)); diff != "" {
+			t.Fatalf("unexpected matches (-want +got):\n%s", diff)
+		}
+		if got[0].(*result.FileMatch).CommitID != "c1" {
+			t.Fatalf("expected the match of c1, got %s", got[0].(*result.FileMatch).CommitID)
+		}
+	})
+
+	// The CODEOWNERS file of each commit is only looked up once: c1 and c3
+	// find it at the second location, c2 tries all of them.
+	if want := 2 + 5 + 2; reads != want {
+		t.Fatalf("expected %d file reads, got %d", want, reads)
+	}
+}
@@ -15,6 +15,9 @@ const (
 	FieldType               = "type"
 	FieldRepoHasFile        = "repohasfile"
 	FieldRepoHasCommitAfter = "repohascommitafter"
+	FieldRepoHasTopic       = "repohastopic"
+	FieldRepoHasKVP         = "repohaskvp"
+	FieldFileHasOwner       = "filehasowner"
 	FieldPatternType        = "patterntype"
 	FieldContent            = "content"
 	FieldVisibility         = "visibility"
@@ -56,6 +59,9 @@ var allFields = map[string]struct{}{
 	FieldVisibility:         empty,
 	FieldRepoHasFile:        empty,
 	FieldRepoHasCommitAfter: empty,
+	FieldRepoHasTopic:       empty,
+	FieldRepoHasKVP:         empty,
+	FieldFileHasOwner:       empty,
 	FieldBefore:             empty,
 	"until":                 empty,
 	FieldAfter:              empty,
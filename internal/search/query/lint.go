@@ -0,0 +1,268 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// LintKind is the kind of a likely mistake in a query.
+type LintKind string
+
+const (
+	// LintUnknownField is when a term looks like a filter, but its field is
+	// not known and close to one that is. Such terms are searched for as
+	// patterns.
+	LintUnknownField LintKind = "unknown-field"
+
+	// LintRegexpInLiteral is when a pattern looks like a regular expression,
+	// but is searched for literally.
+	LintRegexpInLiteral LintKind = "regexp-in-literal"
+
+	// LintRepoNoMatch is when a repo: filter matches no repository, but the
+	// names of some repositories are close to it.
+	LintRepoNoMatch LintKind = "repo-no-match"
+)
+
+// Lint is a likely mistake in a query, with a suggested fix.
+type Lint struct {
+	Kind LintKind
+
+	// Title is a short description of the mistake, eg "did you mean repo:?".
+	Title string
+
+	// Message explains the mistake to the user.
+	Message string
+
+	// Suggested is a query expression which fixes the mistake, eg
+	// "repo:foo". It replaces the value of the same field in the query.
+	Suggested string
+}
+
+// LintBasic returns the likely mistakes in the query b which can be found
+// without looking at the repositories to search. See LintRepo for the
+// others.
+func LintBasic(b Basic) []Lint {
+	var lints []Lint
+	seen := map[string]struct{}{}
+	add := func(l Lint) {
+		if _, ok := seen[l.Suggested]; ok {
+			return
+		}
+		seen[l.Suggested] = struct{}{}
+		lints = append(lints, l)
+	}
+
+	if b.Pattern == nil {
+		return nil
+	}
+	VisitPattern([]Node{b.Pattern}, func(value string, negated bool, annotation Annotation) {
+		// Literal patterns may span several terms, eg "repoo:foo bar".
+		for _, term := range strings.Fields(value) {
+			if l, ok := lintUnknownField(term, negated); ok {
+				add(l)
+			}
+		}
+		if l, ok := lintRegexpInLiteral(value, annotation); ok {
+			add(l)
+		}
+	})
+	return lints
+}
+
+var fieldLikePattern = lazyregexp.New(`^(-?)([a-zA-Z]+):(.*)$`)
+
+func lintUnknownField(value string, negated bool) (Lint, bool) {
+	m := fieldLikePattern.FindStringSubmatch(value)
+	if m == nil {
+		return Lint{}, false
+	}
+	field, fieldValue := strings.ToLower(m[2]), m[3]
+	negated = negated || m[1] == "-"
+
+	// Short fields are too likely to be part of the pattern, eg "go:generate",
+	// and "//" follows URL schemes.
+	if len(field) < 3 || strings.HasPrefix(fieldValue, "//") {
+		return Lint{}, false
+	}
+
+	suggestion, ok := closestField(field)
+	if !ok {
+		return Lint{}, false
+	}
+
+	suggested := suggestion + ":" + fieldValue
+	if negated {
+		suggested = "-" + suggested
+	}
+	return Lint{
+		Kind:      LintUnknownField,
+		Title:     fmt.Sprintf("did you mean %s:?", suggestion),
+		Message:   fmt.Sprintf("`%s:` is not a filter, so `%s` was searched for as text. Did you mean `%s`?", m[2], value, suggested),
+		Suggested: suggested,
+	}, true
+}
+
+// closestField returns the known field closest to field, if any is close
+// enough to be a likely misspelling.
+func closestField(field string) (string, bool) {
+	// Allow one edit for short fields, two for longer ones.
+	maxDistance := 1
+	if len(field) > 5 {
+		maxDistance = 2
+	}
+
+	candidates := make([]string, 0, len(allFields))
+	for f := range allFields {
+		candidates = append(candidates, f)
+	}
+	// Iterate deterministically so ties resolve the same way every time.
+	sort.Strings(candidates)
+
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		// Aliases of a single letter are too short to be misspelled.
+		if len(candidate) < 2 {
+			continue
+		}
+		if d := editDistance(field, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return resolveFieldAlias(best), true
+}
+
+// regexpLikePattern matches constructs which are common in regular
+// expressions but rare in literal searches.
+var regexpLikePattern = lazyregexp.New(`\.[*+?]|\\[dwsbDWSB]|\[[^\]]+-[^\]]+\]|\([^)]*\|[^)]*\)`)
+
+func lintRegexpInLiteral(value string, annotation Annotation) (Lint, bool) {
+	if !annotation.Labels.IsSet(Literal) || annotation.Labels.IsSet(Quoted) {
+		return Lint{}, false
+	}
+	if !regexpLikePattern.MatchString(value) {
+		return Lint{}, false
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		return Lint{}, false
+	}
+	return Lint{
+		Kind:      LintRegexpInLiteral,
+		Title:     "search with regular expressions",
+		Message:   fmt.Sprintf("`%s` looks like a regular expression, but was searched for literally. Use `patterntype:regexp` to search with regular expressions.", value),
+		Suggested: "patterntype:regexp",
+	}, true
+}
+
+// LintRepo returns a lint for a repo: filter with the given value which
+// matched no repository, suggesting the closest of the names of repositories
+// similar to it. It returns false if none of the names is close enough.
+func LintRepo(value string, similarNames []string) (Lint, bool) {
+	needle := strings.ToLower(RepoFilterLiteral(value))
+	if needle == "" {
+		return Lint{}, false
+	}
+
+	best, bestDistance := "", -1
+	for _, name := range similarNames {
+		d := editDistance(needle, strings.ToLower(name))
+		// The filter may match a suffix of the name, eg "sourcegraph/zoekt"
+		// for "github.com/sourcegraph/zoekt".
+		if i := len(name) - len(needle); i > 0 {
+			if sd := editDistance(needle, strings.ToLower(name[i:])); sd < d {
+				d = sd
+			}
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	// Allow roughly one edit per four characters.
+	if best == "" || bestDistance > 1+len(needle)/4 {
+		return Lint{}, false
+	}
+
+	suggested := "repo:^" + regexp.QuoteMeta(best) + "$"
+	return Lint{
+		Kind:      LintRepoNoMatch,
+		Title:     fmt.Sprintf("did you mean %s?", best),
+		Message:   fmt.Sprintf("No repository matches `repo:%s`. Did you mean `%s`?", value, best),
+		Suggested: suggested,
+	}, true
+}
+
+// RepoFilterLiteral returns the literal repository name in the repo: filter
+// value, with anchors and escapes removed. It returns "" if the value uses
+// other regular expression syntax.
+func RepoFilterLiteral(value string) string {
+	if i := strings.Index(value, "@"); i >= 0 {
+		value = value[:i]
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "^"), "$")
+	if value == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) && strings.ContainsRune(`.-/\`, rune(value[i+1])) {
+			i++
+			b.WriteByte(value[i])
+			continue
+		}
+		if strings.ContainsRune(`\.+*?()|[]{}^$`, rune(c)) {
+			// A dot is commonly used unescaped to mean itself.
+			if c == '.' {
+				b.WriteByte(c)
+				continue
+			}
+			return ""
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, which is the Levenshtein distance counting transpositions of adjacent
+// characters as a single edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min(a int, rest ...int) int {
+	for _, b := range rest {
+		if b < a {
+			a = b
+		}
+	}
+	return a
+}
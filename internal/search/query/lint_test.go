@@ -0,0 +1,86 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLintBasic(t *testing.T) {
+	cases := []struct {
+		query      string
+		searchType SearchType
+		want       []string // suggested expressions
+	}{
+		{query: "repo:foo bar", searchType: SearchTypeLiteral},
+		{query: "repoo:foo bar", searchType: SearchTypeLiteral, want: []string{"repo:foo"}},
+		{query: "-fiel:foo bar", searchType: SearchTypeLiteral, want: []string{"-file:foo"}},
+		{query: "langauge:go bar", searchType: SearchTypeLiteral, want: []string{"lang:go"}},
+		{query: "reopsitory:foo", searchType: SearchTypeLiteral},
+		{query: "repoo:foo -fiel:bar", searchType: SearchTypeLiteral, want: []string{"repo:foo", "-file:bar"}},
+		{query: "go:generate", searchType: SearchTypeLiteral},
+		{query: "https://example.com", searchType: SearchTypeLiteral},
+		{query: "func.*Foo", searchType: SearchTypeLiteral, want: []string{"patterntype:regexp"}},
+		{query: `\d+ items`, searchType: SearchTypeLiteral, want: []string{"patterntype:regexp"}},
+		{query: "(foo|bar)", searchType: SearchTypeLiteral, want: []string{"patterntype:regexp"}},
+		{query: "func.*Foo", searchType: SearchTypeRegex},
+		{query: "a.b", searchType: SearchTypeLiteral},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			plan, err := Pipeline(Init(tc.query, tc.searchType))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, b := range plan {
+				for _, l := range LintBasic(b) {
+					got = append(got, l.Suggested)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLintRepo(t *testing.T) {
+	names := []string{"github.com/sourcegraph/sourcegraph", "github.com/sourcegraph/zoekt", "github.com/golang/go"}
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{value: "sourcegrpah/zoekt", want: `repo:^github\.com/sourcegraph/zoekt$`},
+		{value: `^github\.com/sourcegraph/zoket$`, want: `repo:^github\.com/sourcegraph/zoekt$`},
+		{value: "sourcegraph/sourcegarph@main", want: `repo:^github\.com/sourcegraph/sourcegraph$`},
+		{value: "kubernetes/kubernetes"},
+		{value: "sourcegraph/(zoekt|go)"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			l, ok := LintRepo(tc.value, names)
+			if got := l.Suggested; got != tc.want || ok != (tc.want != "") {
+				t.Errorf("got %q (%v), want %q", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepoFilterLiteral(t *testing.T) {
+	cases := map[string]string{
+		"foo/bar":               "foo/bar",
+		`^github\.com/foo/bar$`: "github.com/foo/bar",
+		"github.com/foo@v1":     "github.com/foo",
+		"foo.*":                 "",
+		"(foo|bar)":             "",
+		`foo\-bar`:              "foo-bar",
+		`github\.com/foo/b[a]r`: "",
+		"^$":                    "",
+	}
+	for value, want := range cases {
+		if got := RepoFilterLiteral(value); got != want {
+			t.Errorf("RepoFilterLiteral(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
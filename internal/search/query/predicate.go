@@ -31,10 +31,13 @@ var DefaultPredicateRegistry = PredicateRegistry{
 		"contains.file":         func() Predicate { return &RepoContainsFilePredicate{} },
 		"contains.content":      func() Predicate { return &RepoContainsContentPredicate{} },
 		"contains.commit.after": func() Predicate { return &RepoContainsCommitAfterPredicate{} },
+		"has.topic":             func() Predicate { return &RepoHasTopicPredicate{} },
+		"has.meta":              func() Predicate { return &RepoHasKVPPredicate{} },
 	},
 	FieldFile: {
 		"contains.content": func() Predicate { return &FileContainsContentPredicate{} },
 		"contains":         func() Predicate { return &FileContainsContentPredicate{} },
+		"has.owner":        func() Predicate { return &FileHasOwnerPredicate{} },
 	},
 }
 
@@ -261,6 +264,87 @@ func (f *RepoContainsCommitAfterPredicate) Plan(parent Basic) (Plan, error) {
 	return ToPlan(Dnf(nodes))
 }
 
+/* repo:has.topic(name) */
+
+type RepoHasTopicPredicate struct {
+	Topic string
+}
+
+func (f *RepoHasTopicPredicate) ParseParams(params string) error {
+	if params == "" {
+		return errors.New("has.topic argument should not be empty")
+	}
+	f.Topic = params
+	return nil
+}
+
+func (f *RepoHasTopicPredicate) Field() string { return FieldRepo }
+func (f *RepoHasTopicPredicate) Name() string  { return "has.topic" }
+func (f *RepoHasTopicPredicate) Plan(parent Basic) (Plan, error) {
+	nodes := make([]Node, 0, 3)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldRepoHasTopic,
+		Value: f.Topic,
+	})
+
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
+/* repo:has.meta(key:value) */
+
+// RepoHasKVPPredicate represents the `repo:has.meta()` predicate, which
+// filters to repos that have the key-value pair Key:Value in their metadata.
+// If Value is nil, any value of Key matches.
+type RepoHasKVPPredicate struct {
+	Key   string
+	Value *string
+}
+
+func (f *RepoHasKVPPredicate) ParseParams(params string) error {
+	key, value := ParseKeyValuePair(params)
+	if key == "" {
+		return errors.New("has.meta argument should have the form key or key:value")
+	}
+	f.Key, f.Value = key, value
+	return nil
+}
+
+func (f *RepoHasKVPPredicate) Field() string { return FieldRepo }
+func (f *RepoHasKVPPredicate) Name() string  { return "has.meta" }
+func (f *RepoHasKVPPredicate) Plan(parent Basic) (Plan, error) {
+	value := f.Key
+	if f.Value != nil {
+		value += ":" + *f.Value
+	}
+
+	nodes := make([]Node, 0, 3)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldRepoHasKVP,
+		Value: value,
+	})
+
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
+// ParseKeyValuePair splits a value of the form key or key:value at the first
+// colon. The returned value is nil if s has no colon.
+func ParseKeyValuePair(s string) (key string, value *string) {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return s, nil
+	}
+	v := s[i+1:]
+	return s[:i], &v
+}
+
 type FileContainsContentPredicate struct {
 	Pattern string
 }
@@ -296,6 +380,53 @@ func (f *FileContainsContentPredicate) Plan(parent Basic) (Plan, error) {
 	return ToPlan(Dnf(nodes))
 }
 
+/* file:has.owner(owner) */
+
+// FileHasOwnerPredicate represents the `file:has.owner()` predicate, which
+// filters to files owned by Owner according to the CODEOWNERS file of their
+// repository.
+type FileHasOwnerPredicate struct {
+	Owner string
+}
+
+func (f *FileHasOwnerPredicate) ParseParams(params string) error {
+	if strings.TrimLeft(params, "@") == "" {
+		return errors.New("file:has.owner argument should not be empty")
+	}
+	f.Owner = params
+	return nil
+}
+
+func (f FileHasOwnerPredicate) Field() string { return FieldFile }
+func (f FileHasOwnerPredicate) Name() string  { return "has.owner" }
+
+func (f *FileHasOwnerPredicate) Plan(parent Basic) (Plan, error) {
+	nodes := make([]Node, 0, 4)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldType,
+		Value: "path",
+	}, Parameter{
+		Field: FieldFileHasOwner,
+		Value: f.Owner,
+	})
+
+	files := nonPredicateFiles(parent)
+	if len(files) == 0 {
+		// A file filter is required to search paths without a pattern.
+		files = append(files, Parameter{
+			Field: FieldFile,
+			Value: ".",
+		})
+	}
+	nodes = append(nodes, files...)
+
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
 // nonPredicateRepos returns the repo nodes in a query that aren't predicates,
 // respecting parameters that determine repo results.
 func nonPredicateRepos(q Basic) []Node {
@@ -325,3 +456,20 @@ func nonPredicateRepos(q Basic) []Node {
 	})
 	return res
 }
+
+// nonPredicateFiles returns the file nodes in a query that aren't predicates.
+func nonPredicateFiles(q Basic) []Node {
+	var res []Node
+	VisitParameter(q.ToParseTree(), func(field, value string, negated bool, ann Annotation) {
+		if ann.Labels.IsSet(IsPredicate) || field != FieldFile {
+			return
+		}
+		res = append(res, Parameter{
+			Field:      field,
+			Value:      value,
+			Negated:    negated,
+			Annotation: ann,
+		})
+	})
+	return res
+}
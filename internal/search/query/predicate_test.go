@@ -56,6 +56,56 @@ func TestRepoContainsPredicate(t *testing.T) {
 	})
 }
 
+func TestRepoHasKVPPredicate(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	valid := []struct {
+		params   string
+		expected *RepoHasKVPPredicate
+	}{
+		{`team`, &RepoHasKVPPredicate{Key: "team"}},
+		{`team:search`, &RepoHasKVPPredicate{Key: "team", Value: value("search")}},
+		{`team:`, &RepoHasKVPPredicate{Key: "team", Value: value("")}},
+		{`url:https://example.com`, &RepoHasKVPPredicate{Key: "url", Value: value("https://example.com")}},
+	}
+
+	for _, tc := range valid {
+		t.Run(tc.params, func(t *testing.T) {
+			p := &RepoHasKVPPredicate{}
+			if err := p.ParseParams(tc.params); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, p) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, p)
+			}
+		})
+	}
+
+	for _, params := range []string{``, `:search`} {
+		t.Run(params, func(t *testing.T) {
+			p := &RepoHasKVPPredicate{}
+			if err := p.ParseParams(params); err == nil {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		nodes, err := Parse(`repo:has.meta(team:search) foo`, SearchTypeLiteral)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		VisitParameter(nodes, func(field, value string, _ bool, ann Annotation) {
+			found = found || (field == FieldRepo && value == "has.meta(team:search)" && ann.Labels.IsSet(IsPredicate))
+		})
+		if !found {
+			t.Fatalf("expected has.meta predicate in %v", nodes)
+		}
+	})
+}
+
 func TestParseAsPredicate(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -80,3 +130,44 @@ func TestParseAsPredicate(t *testing.T) {
 	}
 
 }
+
+func TestFileHasOwnerPredicate(t *testing.T) {
+	for _, params := range []string{``, `@`} {
+		t.Run(params, func(t *testing.T) {
+			p := &FileHasOwnerPredicate{}
+			if err := p.ParseParams(params); err == nil {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+
+	test := func(input string) string {
+		q, err := ParseLiteral(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ToBasicQuery(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &FileHasOwnerPredicate{}
+		if err := p.ParseParams("@team-payments"); err != nil {
+			t.Fatal(err)
+		}
+		plan, err := p.Plan(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plan.ToParseTree().String()
+	}
+
+	got := test(`repo:foo file:has.owner(@team-payments) charge`)
+	if want := `(and "count:99999" "type:path" "filehasowner:@team-payments" "file:." "repo:foo")`; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	got = test(`repo:foo file:\.go$ -file:_test file:has.owner(@team-payments)`)
+	if want := `(and "count:99999" "type:path" "filehasowner:@team-payments" "file:\\.go$" "-file:_test" "repo:foo")`; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
@@ -41,16 +41,32 @@ func LowercaseFieldNames(nodes []Node) []Node {
 	})
 }
 
+// countAll is the value count:all is substituted with.
+const countAll = "99999999"
+
 // SubstituteCountAll replaces count:all with count:99999999.
 func SubstituteCountAll(nodes []Node) []Node {
 	return MapParameter(nodes, func(field, value string, negated bool, annotation Annotation) Node {
 		if field == FieldCount && strings.ToLower(value) == "all" {
-			return Parameter{Field: field, Value: "99999999", Negated: negated, Annotation: annotation}
+			return Parameter{Field: field, Value: countAll, Negated: negated, Annotation: annotation}
 		}
 		return Parameter{Field: field, Value: value, Negated: negated, Annotation: annotation}
 	})
 }
 
+// Exhaustive adds count:all to queries that do not specify a count, so that
+// the search finds all results instead of stopping at the default limit.
+func Exhaustive(nodes []Node) ([]Node, error) {
+	hasCount := false
+	VisitField(nodes, FieldCount, func(string, bool, Annotation) {
+		hasCount = true
+	})
+	if hasCount {
+		return nodes, nil
+	}
+	return append(nodes, Parameter{Field: FieldCount, Value: countAll}), nil
+}
+
 var ErrBadGlobPattern = errors.New("syntax error in glob pattern")
 
 // translateCharacterClass translates character classes like [a-zA-Z].
@@ -964,3 +964,15 @@ func TestSubstituteCountAll(t *testing.T) {
 	autogold.Want("with integer count", `(and "count:3" "foo")`).Equal(t, test("foo count:3"))
 	autogold.Want("subexpressions", `(or (and "count:3" "foo") (and "count:99999999" "bar"))`).Equal(t, test("(foo count:3) or (bar count:all)"))
 }
+
+func TestExhaustive(t *testing.T) {
+	test := func(input string) string {
+		query, _ := Parse(input, SearchTypeLiteral)
+		q, _ := Exhaustive(query)
+		return toString(q)
+	}
+
+	autogold.Want("no count", `"foo" "count:99999999"`).Equal(t, test("foo"))
+	autogold.Want("with count", `(and "count:3" "foo")`).Equal(t, test("foo count:3"))
+	autogold.Want("or", `(or "foo" "bar") "count:99999999"`).Equal(t, test("foo or bar"))
+}
@@ -402,6 +402,9 @@ func (q Q) valueToTypedValue(field, value string, label labels) []*Value {
 
 	case
 		FieldRepoHasCommitAfter,
+		FieldRepoHasTopic,
+		FieldRepoHasKVP,
+		FieldFileHasOwner,
 		FieldBefore, "until",
 		FieldAfter, "since":
 		return []*Value{{String: &value}}
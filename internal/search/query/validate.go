@@ -323,6 +323,11 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 	case
 		FieldRepoHasCommitAfter:
 		return satisfies(isSingular, isNotNegated)
+	case
+		FieldRepoHasTopic,
+		FieldRepoHasKVP,
+		FieldFileHasOwner:
+		return satisfies(isNotNegated)
 	case
 		FieldBefore,
 		FieldAfter:
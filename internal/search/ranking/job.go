@@ -0,0 +1,123 @@
+package ranking
+
+import (
+	"context"
+	"time"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/worker/shared"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+type config struct {
+	env.BaseConfig
+
+	Interval  time.Duration
+	BatchSize int
+}
+
+var configInst = &config{}
+
+func (c *config) Load() {
+	c.Interval = c.GetInterval("REPO_RANKING_INTERVAL", "6h", "How frequently to recompute the ranks of repositories.")
+	c.BatchSize = c.GetInt("REPO_RANKING_BATCH_SIZE", "500", "The number of repositories to rank at a time.")
+}
+
+// NewRankingJob returns the worker job which periodically computes the ranks
+// of all cloned repositories.
+func NewRankingJob() shared.Job {
+	return &rankingJob{}
+}
+
+type rankingJob struct{}
+
+func (j *rankingJob) Config() []env.Config {
+	return []env.Config{configInst}
+}
+
+func (j *rankingJob) Routines(_ context.Context) ([]goroutine.BackgroundRoutine, error) {
+	db, err := shared.InitDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	handler := goroutine.NewHandlerWithErrorMessage("compute repository ranks", func(ctx context.Context) error {
+		return rankRepos(ctx, db, configInst.BatchSize, time.Now())
+	})
+
+	return []goroutine.BackgroundRoutine{
+		// Pass a fresh context, see docs for shared.Job
+		goroutine.NewPeriodicGoroutine(context.Background(), configInst.Interval, handler),
+	}, nil
+}
+
+// rankRepos computes and stores the ranks of all cloned repositories.
+func rankRepos(ctx context.Context, db dbutil.DB, batchSize int, now time.Time) error {
+	store := database.RepoRanks(db)
+
+	inboundReferences, err := store.InboundReferenceCounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	after := now.Add(-RecentCommitWindow).Format(time.RFC3339)
+
+	for offset := 0; ; offset += batchSize {
+		repos, err := database.Repos(db).List(ctx, database.ReposListOptions{
+			OnlyCloned:  true,
+			OrderBy:     database.RepoListOrderBy{{Field: database.RepoListID}},
+			LimitOffset: &database.LimitOffset{Limit: batchSize, Offset: offset},
+		})
+		if err != nil {
+			return err
+		}
+
+		ranks := make([]*types.RepoRank, 0, len(repos))
+		for _, repo := range repos {
+			signals := Signals{
+				Stars:                 repo.Stars,
+				RecentCommitCount:     recentCommitCount(ctx, repo.Name, after),
+				InboundReferenceCount: inboundReferences[repo.ID],
+			}
+			ranks = append(ranks, &types.RepoRank{
+				RepoID:                repo.ID,
+				Score:                 Score(signals),
+				Stars:                 signals.Stars,
+				RecentCommitCount:     signals.RecentCommitCount,
+				InboundReferenceCount: signals.InboundReferenceCount,
+			})
+		}
+		if err := store.Upsert(ctx, ranks...); err != nil {
+			return err
+		}
+
+		if len(repos) < batchSize {
+			return nil
+		}
+	}
+}
+
+// recentCommitCount returns the number of commits to the default branch of
+// the repository after the given date. Failures, e.g. for empty repositories,
+// count as no activity rather than failing the whole run.
+func recentCommitCount(ctx context.Context, repo api.RepoName, after string) int {
+	count, err := git.CommitCount(ctx, repo, git.CommitsOptions{
+		Range:            "HEAD",
+		After:            after,
+		NoEnsureRevision: true,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log15.Warn("ranking: failed to count recent commits", "repo", repo, "error", err)
+		}
+		return 0
+	}
+	return int(count)
+}
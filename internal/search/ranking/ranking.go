@@ -0,0 +1,45 @@
+// Package ranking computes the ranks of repositories, which are used to order
+// search results. Higher ranked repositories are searched first by Zoekt, and
+// are listed first when resolving the repositories of a search.
+package ranking
+
+import (
+	"math"
+	"time"
+)
+
+// Signals are the inputs to the rank of a repository.
+type Signals struct {
+	// Stars is the star count the repository has on the code host.
+	Stars int
+	// RecentCommitCount is the number of commits to the default branch
+	// within RecentCommitWindow.
+	RecentCommitCount int
+	// InboundReferenceCount is the number of other repositories whose
+	// precise code intel references packages of the repository.
+	InboundReferenceCount int
+}
+
+// RecentCommitWindow is how far back we count commits of a repository.
+const RecentCommitWindow = 90 * 24 * time.Hour
+
+const (
+	// recentCommitWeight scales the logarithm of the recent commit count, so
+	// that very busy repositories do not dominate popular ones.
+	recentCommitWeight = 100.0
+
+	// inboundReferenceWeight is the score of a single repository referencing
+	// packages of the repository.
+	inboundReferenceWeight = 100.0
+)
+
+// Score returns the rank of a repository with the given signals. Higher ranks
+// first.
+//
+// Stars contribute linearly, so that adding the boost configured by site
+// admins preserves the priority we passed to Zoekt before ranks were computed.
+func Score(s Signals) float64 {
+	return float64(s.Stars) +
+		recentCommitWeight*math.Log2(1+float64(s.RecentCommitCount)) +
+		inboundReferenceWeight*float64(s.InboundReferenceCount)
+}
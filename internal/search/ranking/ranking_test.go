@@ -0,0 +1,29 @@
+package ranking
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		signals Signals
+		want    float64
+	}{
+		{"none", Signals{}, 0},
+		{"stars", Signals{Stars: 10}, 10},
+		{"recent commits", Signals{RecentCommitCount: 3}, 200},
+		{"inbound references", Signals{InboundReferenceCount: 2}, 200},
+		{"all", Signals{Stars: 1, RecentCommitCount: 1, InboundReferenceCount: 1}, 201},
+	}
+	for _, tc := range cases {
+		if got := Score(tc.signals); got != tc.want {
+			t.Errorf("%s: got score %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Activity is dampened: the busier repository ranks higher, but not
+	// proportionally.
+	busy, quiet := Score(Signals{RecentCommitCount: 1000}), Score(Signals{RecentCommitCount: 10})
+	if busy <= quiet || busy >= 10*quiet {
+		t.Errorf("got score %v for 1000 commits and %v for 10 commits", busy, quiet)
+	}
+}
@@ -107,7 +107,8 @@ func (r *Resolver) Resolve(ctx context.Context, op search.RepoOptions) (Resolved
 
 	var searchableRepos []types.RepoName
 
-	if envvar.SourcegraphDotComMode() && len(includePatterns) == 0 && !query.HasTypeRepo(op.Query) && searchcontexts.IsGlobalSearchContext(searchContext) {
+	hasMetadataFilters := len(op.HasTopics) > 0 || len(op.HasKVPs) > 0
+	if envvar.SourcegraphDotComMode() && len(includePatterns) == 0 && !hasMetadataFilters && !query.HasTypeRepo(op.Query) && searchcontexts.IsGlobalSearchContext(searchContext) {
 		start := time.Now()
 		searchableRepos, err = searchableRepositories(ctx, r.SearchableReposFunc, excludePatterns)
 		if err != nil {
@@ -142,6 +143,8 @@ func (r *Resolver) Resolve(ctx context.Context, op search.RepoOptions) (Resolved
 			OnlyArchived: op.OnlyArchived,
 			NoPrivate:    op.Visibility == query.Public,
 			OnlyPrivate:  op.Visibility == query.Private,
+			Topics:       op.HasTopics,
+			KVPs:         op.HasKVPs,
 		}
 
 		if searchContext.ID != 0 {
@@ -153,6 +156,11 @@ func (r *Resolver) Resolve(ctx context.Context, op search.RepoOptions) (Resolved
 
 		if op.Ranked {
 			options.OrderBy = database.RepoListOrderBy{
+				{
+					Field:      database.RepoListRank,
+					Descending: true,
+					Nulls:      "LAST",
+				},
 				{
 					Field:      database.RepoListStars,
 					Descending: true,
@@ -0,0 +1,56 @@
+package result
+
+import (
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// OwnerMatch is a code owner of files in a repository, as produced by
+// select:file.owners.
+type OwnerMatch struct {
+	Repo     types.RepoName
+	CommitID api.CommitID
+
+	// Owner is the owner as written in the CODEOWNERS file, e.g. @org/team or
+	// an email address.
+	Owner string
+}
+
+func (o *OwnerMatch) RepoName() types.RepoName {
+	return o.Repo
+}
+
+func (o *OwnerMatch) Limit(limit int) int {
+	// Always represents one result and limit > 0 so we just return limit - 1.
+	return limit - 1
+}
+
+func (o *OwnerMatch) ResultCount() int {
+	return 1
+}
+
+func (o *OwnerMatch) Select(path filter.SelectPath) Match {
+	switch path.Root() {
+	case filter.Repository:
+		return &RepoMatch{
+			Name: o.Repo.Name,
+			ID:   o.Repo.ID,
+		}
+	case filter.File:
+		if len(path) > 1 && path[1] == "owners" {
+			return o
+		}
+	}
+	return nil
+}
+
+func (o *OwnerMatch) Key() Key {
+	return Key{
+		TypeRank: rankOwnerMatch,
+		Repo:     o.Repo.Name,
+		Owner:    o.Owner,
+	}
+}
+
+func (o *OwnerMatch) searchResultMarker() {}
@@ -26,6 +26,20 @@ type Symbol struct {
 	Signature  string
 	Pattern    string
 
+	// Character is the 0-based offset of the symbol name on Line. It is only
+	// set by parsers which know the exact position of symbols, otherwise it
+	// is derived from Pattern.
+	Character int
+
+	// EndLine and EndCharacter are the end of the symbol's definition, e.g.
+	// the closing brace of a function. EndLine is 0 if the end is unknown.
+	EndLine      int
+	EndCharacter int
+
+	// Scope is the fully qualified scope the symbol is defined in, e.g.
+	// "pkg.Type" for a Go method. Parent is the innermost part of Scope.
+	Scope string
+
 	FileLimited bool
 }
 
@@ -109,11 +123,14 @@ func (s Symbol) LSPKind() lsp.SymbolKind {
 	return 0
 }
 
-// offset calculates a symbol offset based on the the only Symbol
-// data member that currently exposes line content: the symbols Pattern member,
-// which has the form /^ ... $/. We find the offset of the symbol name in this
-// line, after escaping the Pattern.
+// offset returns Character if it is known. Otherwise it calculates a symbol
+// offset based on the the only Symbol data member that currently exposes line
+// content: the symbols Pattern member, which has the form /^ ... $/. We find
+// the offset of the symbol name in this line, after escaping the Pattern.
 func (s *Symbol) offset() int {
+	if s.Character > 0 {
+		return s.Character
+	}
 	if s.Pattern == "" {
 		return 0
 	}
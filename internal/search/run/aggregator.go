@@ -29,6 +29,7 @@ func NewAggregator(db dbutil.DB, stream streaming.Sender) *Aggregator {
 type Aggregator struct {
 	parentStream streaming.Sender
 	db           dbutil.DB
+	filter       func([]result.Match) []result.Match
 
 	mu         sync.Mutex
 	results    []result.Match
@@ -46,7 +47,18 @@ func (a *Aggregator) Get() ([]result.Match, streaming.Stats, int, *multierror.Er
 	return a.results, a.stats, a.matchCount, a.errors
 }
 
+// SetFilter sets a function which filters the results of every event sent to
+// the aggregator, before they are streamed or aggregated. It must be called
+// before any event is sent.
+func (a *Aggregator) SetFilter(filter func([]result.Match) []result.Match) {
+	a.filter = filter
+}
+
 func (a *Aggregator) Send(event streaming.SearchEvent) {
+	if a.filter != nil && len(event.Results) > 0 {
+		event.Results = a.filter(event.Results)
+	}
+
 	if a.parentStream != nil {
 		a.parentStream.Send(event)
 	}
@@ -58,6 +58,8 @@ func SearchRepositories(ctx context.Context, args *search.TextParameters, limit
 		query.FieldCase:               {},
 		query.FieldRepoHasFile:        {},
 		query.FieldRepoHasCommitAfter: {},
+		query.FieldRepoHasTopic:       {},
+		query.FieldRepoHasKVP:         {},
 		query.FieldPatternType:        {},
 		query.FieldSelect:             {},
 	}
@@ -0,0 +1,210 @@
+package streaming
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// AggregationMode is the dimension by which a SearchAggregation groups
+// matches.
+type AggregationMode string
+
+const (
+	// AggregationModeRepo groups matches by repository.
+	AggregationModeRepo AggregationMode = "repo"
+	// AggregationModePath groups matches by file. Only file matches are
+	// counted.
+	AggregationModePath AggregationMode = "path"
+	// AggregationModeAuthor groups matches by commit author. Only commit and
+	// diff matches are counted.
+	AggregationModeAuthor AggregationMode = "author"
+	// AggregationModeCapture groups matches by the value of a named capture
+	// group of a regular expression pattern in the query. Only content
+	// matches are counted.
+	AggregationModeCapture AggregationMode = "capture"
+)
+
+// ParseAggregation parses a value like "repo" or "capture:version" into an
+// aggregation mode and, for AggregationModeCapture, the name of the capture
+// group.
+func ParseAggregation(s string) (mode AggregationMode, group string, err error) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		s, group = s[:i], s[i+1:]
+	}
+
+	switch mode = AggregationMode(s); mode {
+	case AggregationModeRepo, AggregationModePath, AggregationModeAuthor:
+		if group != "" {
+			return "", "", errors.Errorf("aggregation mode %q does not take a capture group", mode)
+		}
+	case AggregationModeCapture:
+		if group == "" {
+			return "", "", errors.New(`aggregation mode "capture" requires a capture group name, e.g. "capture:version"`)
+		}
+	default:
+		return "", "", errors.Errorf("unknown aggregation mode %q, must be one of repo, path, author or capture:<name>", s)
+	}
+	return mode, group, nil
+}
+
+// AggregationGroup is the number of matches which share the same value of the
+// aggregated dimension.
+type AggregationGroup struct {
+	// Label is the value of the group, e.g. the repository name for
+	// AggregationModeRepo or the captured text for AggregationModeCapture.
+	Label string
+	// Repository is the name of the repository containing the file for
+	// AggregationModePath. It is empty for all other modes.
+	Repository string
+	// Count is the number of matches in the group.
+	Count int
+}
+
+// SearchAggregation counts the matches of a search by the value of an
+// AggregationMode. Unlike SearchFilters it is meant to see every match of an
+// exhaustive search, so its counts are exact unless LimitHit is true.
+type SearchAggregation struct {
+	Mode AggregationMode
+
+	// capture and captureIndex are the pattern and index of the capture group
+	// for AggregationModeCapture.
+	capture      *regexp.Regexp
+	captureIndex int
+
+	groups   map[aggregationKey]int
+	limitHit bool
+}
+
+type aggregationKey struct {
+	repo  string
+	label string
+}
+
+// NewSearchAggregation returns a SearchAggregation for mode. For
+// AggregationModeCapture, group names a capture group of a regular expression
+// pattern in q.
+func NewSearchAggregation(mode AggregationMode, group string, q query.Q) (*SearchAggregation, error) {
+	a := &SearchAggregation{
+		Mode:   mode,
+		groups: make(map[aggregationKey]int),
+	}
+
+	if mode != AggregationModeCapture {
+		return a, nil
+	}
+
+	var flags string
+	if !q.IsCaseSensitive() {
+		flags = "(?i)"
+	}
+	var err error
+	query.VisitPattern(q, func(value string, negated bool, annotation query.Annotation) {
+		if a.capture != nil || negated || !annotation.Labels.IsSet(query.Regexp) {
+			return
+		}
+		re, compileErr := regexp.Compile(flags + value)
+		if compileErr != nil {
+			err = compileErr
+			return
+		}
+		if i := re.SubexpIndex(group); i >= 0 {
+			a.capture, a.captureIndex = re, i
+		}
+	})
+	if a.capture == nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Errorf("no regular expression pattern in the query has a capture group named %q", group)
+	}
+	return a, nil
+}
+
+// Update internal state for the results in event.
+func (a *SearchAggregation) Update(event SearchEvent) {
+	if event.Stats.IsLimitHit {
+		a.limitHit = true
+	}
+
+	for _, match := range event.Results {
+		switch v := match.(type) {
+		case *result.FileMatch:
+			if v.LimitHit {
+				a.limitHit = true
+			}
+			switch a.Mode {
+			case AggregationModeRepo:
+				a.add("", string(v.Repo.Name), v.ResultCount())
+			case AggregationModePath:
+				a.add(string(v.Repo.Name), v.Path, v.ResultCount())
+			case AggregationModeCapture:
+				for _, lm := range v.LineMatches {
+					for _, m := range a.capture.FindAllStringSubmatch(lm.Preview, -1) {
+						if value := m[a.captureIndex]; value != "" {
+							a.add("", value, 1)
+						}
+					}
+				}
+			}
+		case *result.RepoMatch:
+			if a.Mode == AggregationModeRepo {
+				a.add("", string(v.Name), 1)
+			}
+		case *result.OwnerMatch:
+			if a.Mode == AggregationModeRepo {
+				a.add("", string(v.Repo.Name), 1)
+			}
+		case *result.CommitMatch:
+			switch a.Mode {
+			case AggregationModeRepo:
+				a.add("", string(v.Repo.Name), v.ResultCount())
+			case AggregationModeAuthor:
+				author := v.Commit.Author.Name
+				if v.Commit.Author.Email != "" {
+					author = fmt.Sprintf("%s <%s>", author, v.Commit.Author.Email)
+				}
+				a.add("", author, v.ResultCount())
+			}
+		}
+	}
+}
+
+func (a *SearchAggregation) add(repo, label string, count int) {
+	a.groups[aggregationKey{repo: repo, label: label}] += count
+}
+
+// LimitHit returns true if a search limit was hit, in which case the counts
+// are not exact.
+func (a *SearchAggregation) LimitHit() bool {
+	return a.limitHit
+}
+
+// Compute returns the groups, ordered by descending count.
+func (a *SearchAggregation) Compute() []*AggregationGroup {
+	groups := make([]*AggregationGroup, 0, len(a.groups))
+	for k, count := range a.groups {
+		groups = append(groups, &AggregationGroup{
+			Label:      k.label,
+			Repository: k.repo,
+			Count:      count,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if groups[i].Repository != groups[j].Repository {
+			return groups[i].Repository < groups[j].Repository
+		}
+		return groups[i].Label < groups[j].Label
+	})
+	return groups
+}
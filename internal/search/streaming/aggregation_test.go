@@ -0,0 +1,181 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git/gitapi"
+)
+
+func TestParseAggregation(t *testing.T) {
+	cases := []struct {
+		in        string
+		wantMode  AggregationMode
+		wantGroup string
+		wantErr   bool
+	}{
+		{in: "repo", wantMode: AggregationModeRepo},
+		{in: "path", wantMode: AggregationModePath},
+		{in: "author", wantMode: AggregationModeAuthor},
+		{in: "capture:version", wantMode: AggregationModeCapture, wantGroup: "version"},
+		{in: "capture", wantErr: true},
+		{in: "capture:", wantErr: true},
+		{in: "repo:foo", wantErr: true},
+		{in: "language", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			mode, group, err := ParseAggregation(c.in)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("got error %v, want error %t", err, c.wantErr)
+			}
+			if mode != c.wantMode || group != c.wantGroup {
+				t.Fatalf("got (%q, %q), want (%q, %q)", mode, group, c.wantMode, c.wantGroup)
+			}
+		})
+	}
+}
+
+func TestSearchAggregation(t *testing.T) {
+	fooRepo := types.RepoName{ID: 1, Name: "foo"}
+	barRepo := types.RepoName{ID: 2, Name: "bar"}
+
+	fileMatch := func(repo types.RepoName, path string, lines ...string) *result.FileMatch {
+		fm := &result.FileMatch{File: result.File{Repo: repo, Path: path}}
+		for _, l := range lines {
+			fm.LineMatches = append(fm.LineMatches, &result.LineMatch{
+				Preview:          l,
+				OffsetAndLengths: [][2]int32{{0, int32(len(l))}},
+			})
+		}
+		return fm
+	}
+	commitMatch := func(repo types.RepoName, name, email string) *result.CommitMatch {
+		return &result.CommitMatch{
+			Repo:   repo,
+			Commit: gitapi.Commit{Author: gitapi.Signature{Name: name, Email: email}},
+		}
+	}
+
+	events := []SearchEvent{{
+		Results: []result.Match{
+			fileMatch(fooRepo, "go.mod", "github.com/lib/pq v1.10.0", "github.com/Lib/pq v1.2.0"),
+			fileMatch(barRepo, "go.mod", "github.com/lib/pq v1.10.0"),
+			&result.RepoMatch{ID: 2, Name: "bar"},
+		},
+	}, {
+		Results: []result.Match{
+			fileMatch(barRepo, "vendor/go.mod", "github.com/lib/pq v1.10.0"),
+			commitMatch(fooRepo, "Alice", "alice@example.com"),
+			commitMatch(barRepo, "Alice", "alice@example.com"),
+			commitMatch(barRepo, "Bob", ""),
+		},
+	}}
+
+	q, err := query.ParseRegexp(`github\.com/lib/pq v(?P<version>[\d.]+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		mode  AggregationMode
+		group string
+		q     query.Q
+		want  []*AggregationGroup
+	}{{
+		mode: AggregationModeRepo,
+		want: []*AggregationGroup{
+			{Label: "bar", Count: 5},
+			{Label: "foo", Count: 3},
+		},
+	}, {
+		mode: AggregationModePath,
+		want: []*AggregationGroup{
+			{Repository: "foo", Label: "go.mod", Count: 2},
+			{Repository: "bar", Label: "go.mod", Count: 1},
+			{Repository: "bar", Label: "vendor/go.mod", Count: 1},
+		},
+	}, {
+		mode: AggregationModeAuthor,
+		want: []*AggregationGroup{
+			{Label: "Alice <alice@example.com>", Count: 2},
+			{Label: "Bob", Count: 1},
+		},
+	}, {
+		mode:  AggregationModeCapture,
+		group: "version",
+		q:     q,
+		want: []*AggregationGroup{
+			{Label: "1.10.0", Count: 3},
+			{Label: "1.2.0", Count: 1},
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(string(c.mode), func(t *testing.T) {
+			a, err := NewSearchAggregation(c.mode, c.group, c.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range events {
+				a.Update(event)
+			}
+			if diff := cmp.Diff(c.want, a.Compute()); diff != "" {
+				t.Fatalf("unexpected groups (-want +got):\n%s", diff)
+			}
+			if a.LimitHit() {
+				t.Fatal("unexpected limit hit")
+			}
+		})
+	}
+
+	t.Run("limit hit", func(t *testing.T) {
+		a, err := NewSearchAggregation(AggregationModeRepo, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fm := fileMatch(fooRepo, "go.mod", "github.com/lib/pq v1.10.0")
+		fm.LimitHit = true
+		a.Update(SearchEvent{Results: []result.Match{fm}})
+		if !a.LimitHit() {
+			t.Fatal("expected limit hit")
+		}
+	})
+}
+
+func TestNewSearchAggregation_Capture(t *testing.T) {
+	cases := []struct {
+		name    string
+		query   string
+		literal bool
+		wantErr bool
+	}{
+		{name: "named group", query: `lib/pq v(?P<version>\S+)`},
+		{name: "other group", query: `lib/pq v(?P<other>\S+)`, wantErr: true},
+		{name: "negated pattern", query: `-content:v(?P<version>\S+) foo`, wantErr: true},
+		{name: "literal search", query: `lib/pq v(?P<version>\S+)`, literal: true, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			searchType := query.SearchTypeRegex
+			if c.literal {
+				searchType = query.SearchTypeLiteral
+			}
+			plan, err := query.Pipeline(query.Init(c.query, searchType))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewSearchAggregation(AggregationModeCapture, "version", plan.ToParseTree())
+			if (err != nil) != c.wantErr {
+				t.Fatalf("got error %v, want error %t", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ func BuildProgressEvent(stats ProgressStats) Progress {
 			skipped = append(skipped, sk)
 		}
 	}
+	skipped = append(skipped, stats.Suggestions...)
 
 	return Progress{
 		RepositoriesCount: stats.RepositoriesCount,
@@ -48,6 +49,10 @@ type ProgressStats struct {
 	Trace string // only filled if requested
 
 	DisplayLimit int
+
+	// Suggestions are likely mistakes in the query with suggested fixes. They
+	// are listed after the other reasons for skipping.
+	Suggestions []Skipped
 }
 
 func skippedReposHandler(repos []Namer, titleVerb, messageReason string, base Skipped) (Skipped, bool) {
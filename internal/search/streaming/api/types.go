@@ -80,6 +80,15 @@ const (
 	// ExcludedArchive is when we did not search a repository because it is
 	// archived.
 	ExcludedArchive SkippedReason = "excluded-archive"
+	// QueryUnknownField is when a term of the query looks like a misspelled
+	// filter, so it was searched for as text.
+	QueryUnknownField SkippedReason = "query-unknown-field"
+	// QueryRegexpInLiteral is when a pattern looks like a regular expression,
+	// but was searched for literally.
+	QueryRegexpInLiteral SkippedReason = "query-regexp-in-literal"
+	// QueryRepoNoMatch is when a repo: filter matched no repository, but it is
+	// close to the name of one.
+	QueryRepoNoMatch SkippedReason = "query-repo-no-match"
 )
 
 // SkippedSeverity is an enum for Skipped.Severity.
@@ -56,6 +56,15 @@ func TestFrontendClient(t *testing.T) {
 		}, {
 			Value: "filter-2",
 		}},
+	}, {
+		Name: "aggregations",
+		Value: &EventAggregation{
+			Mode: "repo",
+			Groups: []EventAggregationGroup{{
+				Label: "test",
+				Count: 2,
+			}},
+		},
 	}, {
 		Name: "alert",
 		Value: &EventAlert{
@@ -102,6 +111,9 @@ func TestFrontendClient(t *testing.T) {
 		OnFilters: func(d []*EventFilter) {
 			got = append(got, Event{Name: "filters", Value: d})
 		},
+		OnAggregations: func(d *EventAggregation) {
+			got = append(got, Event{Name: "aggregations", Value: d})
+		},
 		OnAlert: func(d *EventAlert) {
 			got = append(got, Event{Name: "alert", Value: d})
 		},
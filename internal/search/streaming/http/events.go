@@ -146,6 +146,22 @@ type EventCommitMatch struct {
 
 func (e *EventCommitMatch) eventMatch() {}
 
+// EventOwnerMatch is a code owner of files in a repository, as produced by
+// select:file.owners.
+type EventOwnerMatch struct {
+	// Type is always OwnerMatchType. Included here for marshalling.
+	Type MatchType `json:"type"`
+
+	Owner           string     `json:"owner"`
+	RepositoryID    int32      `json:"repositoryID"`
+	Repository      string     `json:"repository"`
+	RepoStars       int        `json:"repoStars,omitempty"`
+	RepoLastFetched *time.Time `json:"repoLastFetched,omitempty"`
+	Commit          string     `json:"commit,omitempty"`
+}
+
+func (e *EventOwnerMatch) eventMatch() {}
+
 // EventFilter is a suggestion for a search filter. Currently has a 1-1
 // correspondance with the SearchFilter graphql type.
 type EventFilter struct {
@@ -156,6 +172,27 @@ type EventFilter struct {
 	Kind     string `json:"kind"`
 }
 
+// EventAggregation is the result of a search run in aggregation mode. It
+// counts all matches of the search grouped by Mode.
+type EventAggregation struct {
+	// Mode is the aggregation mode, one of "repo", "path", "author" or
+	// "capture".
+	Mode   string                  `json:"mode"`
+	Groups []EventAggregationGroup `json:"groups"`
+	// LimitHit is true if the search hit a limit, in which case the counts
+	// are lower bounds.
+	LimitHit bool `json:"limitHit"`
+}
+
+// EventAggregationGroup is the number of matches which share the same value
+// of the aggregated dimension.
+type EventAggregationGroup struct {
+	Label string `json:"label"`
+	// Repository is the repository containing the file for the "path" mode.
+	Repository string `json:"repository,omitempty"`
+	Count      int    `json:"count"`
+}
+
 // EventAlert is GQL.SearchAlert. It replaces when sent to match existing
 // behaviour.
 type EventAlert struct {
@@ -184,6 +221,7 @@ const (
 	SymbolMatchType
 	CommitMatchType
 	PathMatchType
+	OwnerMatchType
 )
 
 func (t MatchType) MarshalJSON() ([]byte, error) {
@@ -198,6 +236,8 @@ func (t MatchType) MarshalJSON() ([]byte, error) {
 		return []byte(`"commit"`), nil
 	case PathMatchType:
 		return []byte(`"path"`), nil
+	case OwnerMatchType:
+		return []byte(`"owner"`), nil
 	default:
 		return nil, errors.Errorf("unknown MatchType: %d", t)
 	}
@@ -214,6 +254,8 @@ func (t *MatchType) UnmarshalJSON(b []byte) error {
 		*t = CommitMatchType
 	} else if bytes.Equal(b, []byte(`"path"`)) {
 		*t = PathMatchType
+	} else if bytes.Equal(b, []byte(`"owner"`)) {
+		*t = OwnerMatchType
 	} else {
 		return errors.Errorf("unknown MatchType: %s", b)
 	}
@@ -10,6 +10,7 @@ import (
 	zoektquery "github.com/google/zoekt/query"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/endpoint"
 	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
@@ -300,6 +301,8 @@ type RepoOptions struct {
 	NoArchived        bool
 	OnlyArchived      bool
 	CommitAfter       string
+	HasTopics         []string
+	HasKVPs           []database.RepoKVPFilter
 	Visibility        query.RepoVisibility
 	Ranked            bool // Return results ordered by rank
 	Limit             int
@@ -328,6 +331,12 @@ func (op *RepoOptions) String() string {
 	if op.CommitAfter != "" {
 		_, _ = fmt.Fprintf(&b, " CommitAfter=%q", op.CommitAfter)
 	}
+	if len(op.HasTopics) > 0 {
+		_, _ = fmt.Fprintf(&b, " HasTopics=%q", op.HasTopics)
+	}
+	for _, kvp := range op.HasKVPs {
+		_, _ = fmt.Fprintf(&b, " HasKVP=%q", kvp.String())
+	}
 
 	if op.NoForks {
 		b.WriteString(" NoForks")
@@ -0,0 +1,121 @@
+package unindexed
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// rankOrderedSender forwards the results of searches over a list of
+// repositories in the order of the list, which is the rank order of the
+// repositories as resolved by search/repos. This mirrors how we reorder the
+// results of Zoekt by repository priority.
+//
+// Results of a repository are held back until all repositories before it
+// are done. To bound latency, all held back results are sent once there are
+// more than maxQueueSize of them. A negative maxQueueSize is unbounded.
+//
+// maxQueueSize is experimentalFeatures.ranking.maxReorderQueueSize. Unlike
+// Zoekt, which reports the priority of its pending shards, we can't tell how
+// far a result is out of order, so if it is unset we use
+// defaultMaxQueueSize rather than sending results as they come.
+type rankOrderedSender struct {
+	parent       streaming.Sender
+	maxQueueSize int
+
+	mu        sync.Mutex
+	next      int // index of the first repository which is not done
+	done      map[int]bool
+	queue     map[int][]result.Match
+	queueSize int
+}
+
+// defaultMaxQueueSize is the number of results rankOrderedSender holds back
+// if maxReorderQueueSize is not configured.
+const defaultMaxQueueSize = 1000
+
+func newRankOrderedSender(parent streaming.Sender) *rankOrderedSender {
+	maxQueueSize := 0
+	siteConfig := conf.Get().SiteConfiguration
+	if siteConfig.ExperimentalFeatures != nil && siteConfig.ExperimentalFeatures.Ranking != nil {
+		maxQueueSize = siteConfig.ExperimentalFeatures.Ranking.MaxReorderQueueSize
+	}
+	if maxQueueSize == 0 {
+		maxQueueSize = defaultMaxQueueSize
+	}
+
+	return &rankOrderedSender{
+		parent:       parent,
+		maxQueueSize: maxQueueSize,
+		done:         map[int]bool{},
+		queue:        map[int][]result.Match{},
+	}
+}
+
+// Sender returns the sender for the results of the i-th repository.
+func (s *rankOrderedSender) Sender(i int) streaming.Sender {
+	return streaming.StreamFunc(func(event streaming.SearchEvent) {
+		s.send(i, event)
+	})
+}
+
+func (s *rankOrderedSender) send(i int, event streaming.SearchEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i == s.next || len(event.Results) == 0 {
+		s.parent.Send(event)
+		return
+	}
+
+	// Stats are never held back.
+	if !event.Stats.Zero() {
+		s.parent.Send(streaming.SearchEvent{Stats: event.Stats})
+	}
+
+	s.queue[i] = append(s.queue[i], event.Results...)
+	s.queueSize += len(event.Results)
+	if s.maxQueueSize >= 0 && s.queueSize > s.maxQueueSize {
+		s.flush()
+	}
+}
+
+// Done marks the search of the i-th repository as done, which releases the
+// held back results of the repositories after it.
+func (s *rankOrderedSender) Done(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.done[i] = true
+	for s.done[s.next] {
+		delete(s.done, s.next)
+		s.next++
+		s.sendQueued(s.next)
+	}
+}
+
+// flush sends all held back results in order.
+func (s *rankOrderedSender) flush() {
+	indexes := make([]int, 0, len(s.queue))
+	for i := range s.queue {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	for _, i := range indexes {
+		s.sendQueued(i)
+	}
+}
+
+func (s *rankOrderedSender) sendQueued(i int) {
+	matches, ok := s.queue[i]
+	if !ok {
+		return
+	}
+	delete(s.queue, i)
+	s.queueSize -= len(matches)
+	s.parent.Send(streaming.SearchEvent{Results: matches})
+}
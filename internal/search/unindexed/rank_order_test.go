@@ -0,0 +1,55 @@
+package unindexed
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestRankOrderedSender(t *testing.T) {
+	match := func(repo string) result.Match {
+		return &result.FileMatch{File: result.File{Repo: types.RepoName{Name: api.RepoName("repo" + repo)}}}
+	}
+
+	run := func(maxQueueSize int) []string {
+		var got []string
+		parent := streaming.StreamFunc(func(event streaming.SearchEvent) {
+			for _, m := range event.Results {
+				got = append(got, string(m.(*result.FileMatch).Repo.Name))
+			}
+		})
+
+		s := newRankOrderedSender(parent)
+		s.maxQueueSize = maxQueueSize
+
+		// Repositories finish out of order.
+		s.Sender(2).Send(streaming.SearchEvent{Results: []result.Match{match("2")}})
+		s.Sender(1).Send(streaming.SearchEvent{Results: []result.Match{match("1")}})
+		s.Done(2)
+		s.Sender(0).Send(streaming.SearchEvent{Results: []result.Match{match("0")}})
+		s.Sender(1).Send(streaming.SearchEvent{Results: []result.Match{match("1")}})
+		s.Done(0)
+		s.Sender(1).Send(streaming.SearchEvent{Results: []result.Match{match("1")}})
+		s.Done(1)
+		return got
+	}
+
+	if diff := cmp.Diff([]string{"repo0", "repo1", "repo1", "repo1", "repo2"}, run(-1)); diff != "" {
+		t.Errorf("unbounded queue mismatch (-want +got):\n%s", diff)
+	}
+
+	// Once the queue is full, held back results are sent in order.
+	if diff := cmp.Diff([]string{"repo1", "repo2", "repo0", "repo1", "repo1"}, run(1)); diff != "" {
+		t.Errorf("bounded queue mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without configuration, results are held back up to a default.
+	if have := newRankOrderedSender(nil).maxQueueSize; have != defaultMaxQueueSize {
+		t.Errorf("got default queue size %d, want %d", have, defaultMaxQueueSize)
+	}
+}
@@ -257,6 +257,11 @@ func callSearcherOverRepos(
 	}
 	textSearchLimiter.SetLimit(len(eps) * 32)
 
+	// searcherRepos are in rank order, which we preserve in the results we
+	// stream.
+	ordered := newRankOrderedSender(stream)
+	next := 0
+
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		for _, repoAllRevs := range searcherRepos {
@@ -277,11 +282,14 @@ func callSearcherOverRepos(
 
 				// Make a new repoRev for just the operation of searching this revspec.
 				repoRev := &search.RepositoryRevisions{Repo: repoAllRevs.Repo, Revs: []search.RevisionSpecifier{{RevSpec: rev}}}
+				i := next
+				next++
 				g.Go(func() error {
 					ctx, done := limitCtx, limitDone
 					defer done()
+					defer ordered.Done(i)
 
-					repoLimitHit, err := searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], index, args.PatternInfo, fetchTimeout, stream)
+					repoLimitHit, err := searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], index, args.PatternInfo, fetchTimeout, ordered.Sender(i))
 					if err != nil {
 						tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
 						log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
@@ -27,3 +27,15 @@ type PerforceConnection struct {
 	URN string
 	*schema.PerforceConnection
 }
+
+type BitbucketCloudConnection struct {
+	// The unique resource identifier of the external service.
+	URN string
+	*schema.BitbucketCloudConnection
+}
+
+type GitoliteConnection struct {
+	// The unique resource identifier of the external service.
+	URN string
+	*schema.GitoliteConnection
+}
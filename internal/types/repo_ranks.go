@@ -0,0 +1,26 @@
+package types
+
+import (
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// RepoRank is the rank of a repository, used to order search results, and
+// the signals it was computed from.
+type RepoRank struct {
+	RepoID api.RepoID
+	// Score is the rank of the repository, without the boost configured by
+	// site admins. Higher ranks first.
+	Score float64
+
+	Stars int
+	// RecentCommitCount is the number of commits to the default branch in
+	// the recent past.
+	RecentCommitCount int
+	// InboundReferenceCount is the number of other repositories whose precise
+	// code intel references packages of the repository.
+	InboundReferenceCount int
+
+	UpdatedAt time.Time
+}
@@ -0,0 +1,60 @@
+package types
+
+import "time"
+
+// SavedSearchExecution is a run of a saved search, which looks for results
+// that are newer than the latest result we notified about.
+type SavedSearchExecution struct {
+	ID            int64
+	SavedSearchID int32
+	// QueryString is the query that was run, including the after: filter.
+	QueryString *string
+	// NumResults is the number of new results.
+	NumResults *int32
+	// ApproximateResultCount is the approximate number of new results, as
+	// shown in notifications.
+	ApproximateResultCount *string
+	State                  string
+	FailureMessage         *string
+	StartedAt              *time.Time
+	FinishedAt             *time.Time
+	NumResets              int32
+	NumFailures            int32
+	CreatedAt              time.Time
+}
+
+// RecordID implements workerutil.Record.
+func (e *SavedSearchExecution) RecordID() int {
+	return int(e.ID)
+}
+
+// SavedSearchActionKind is the kind of notification sent by a saved search
+// action job.
+type SavedSearchActionKind string
+
+const (
+	SavedSearchActionEmail SavedSearchActionKind = "email"
+	SavedSearchActionSlack SavedSearchActionKind = "slack"
+)
+
+// SavedSearchActionJob is a notification about the new results of a saved
+// search execution.
+type SavedSearchActionJob struct {
+	ID          int64
+	ExecutionID int64
+	Kind        SavedSearchActionKind
+	// UserID is the recipient of an email notification.
+	UserID         *int32
+	State          string
+	FailureMessage *string
+	StartedAt      *time.Time
+	FinishedAt     *time.Time
+	NumResets      int32
+	NumFailures    int32
+	CreatedAt      time.Time
+}
+
+// RecordID implements workerutil.Record.
+func (j *SavedSearchActionJob) RecordID() int {
+	return int(j.ID)
+}
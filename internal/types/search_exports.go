@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+// SearchExportFormat is the file format of a search export.
+type SearchExportFormat string
+
+const (
+	SearchExportFormatCSV   SearchExportFormat = "csv"
+	SearchExportFormatJSONL SearchExportFormat = "jsonl"
+)
+
+// SearchExportState is the state of a search export.
+type SearchExportState string
+
+const (
+	SearchExportStateQueued     SearchExportState = "queued"
+	SearchExportStateProcessing SearchExportState = "processing"
+	SearchExportStateCompleted  SearchExportState = "completed"
+	SearchExportStateErrored    SearchExportState = "errored"
+	SearchExportStateFailed     SearchExportState = "failed"
+)
+
+// SearchExport is an export of all results of a search query, which is run in
+// the background on behalf of a user.
+type SearchExport struct {
+	ID             int64
+	UserID         int32  // the user the search is run on behalf of
+	Query          string // the search query, run as if it contained count:all
+	PatternType    string // the pattern type of the query, e.g. "literal" or "regexp"
+	Format         SearchExportFormat
+	ResultCount    int32   // the number of rows in the exported file
+	ObjectKey      *string // the key of the exported file in the search export store
+	State          SearchExportState
+	FailureMessage *string
+	StartedAt      *time.Time
+	FinishedAt     *time.Time
+	NumResets      int32
+	NumFailures    int32
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RecordID implements workerutil.Record.
+func (e *SearchExport) RecordID() int {
+	return int(e.ID)
+}
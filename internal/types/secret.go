@@ -53,6 +53,34 @@ func (e *ExternalService) RedactConfigSecrets() (string, error) {
 		newCfg, err = redactField(e.Config, fields...)
 	case *schema.BitbucketCloudConnection:
 		newCfg, err = redactField(e.Config, []string{"appPassword"})
+	case *schema.GiteaConnection:
+		// Gitea can be accessed anonymously
+		var fields [][]string
+		if cfg.Token != "" {
+			fields = append(fields, []string{"token"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.GerritConnection:
+		// Gerrit can be accessed anonymously
+		var fields [][]string
+		if cfg.Password != "" {
+			fields = append(fields, []string{"password"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.SubversionConnection:
+		// Subversion can be accessed anonymously
+		var fields [][]string
+		if cfg.Password != "" {
+			fields = append(fields, []string{"password"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.MercurialConnection:
+		// Mercurial can be accessed anonymously
+		var fields [][]string
+		if cfg.Password != "" {
+			fields = append(fields, []string{"password"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
 	case *schema.AWSCodeCommitConnection:
 		newCfg, err = redactField(e.Config, []string{"secretAccessKey"}, []string{"gitCredentials", "password"})
 	case *schema.PhabricatorConnection:
@@ -66,6 +94,10 @@ func (e *ExternalService) RedactConfigSecrets() (string, error) {
 		newCfg, err = redactField(e.Config, []string{"url"})
 	case *schema.JVMPackagesConnection:
 		newCfg, err = e.Config, nil
+	case *schema.NpmPackagesConnection:
+		newCfg, err = redactField(e.Config, []string{"credentials"})
+	case *schema.RustPackagesConnection, *schema.PythonPackagesConnection, *schema.RubyPackagesConnection:
+		newCfg, err = e.Config, nil
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("RedactExternalServiceConfig: kind %q not implemented", e.Kind)
@@ -129,6 +161,30 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	case *schema.BitbucketCloudConnection:
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"appPassword"}, &cfg.AppPassword})
+	case *schema.GiteaConnection:
+		var fields []jsonStringField
+		if cfg.Token != "" {
+			fields = append(fields, jsonStringField{[]string{"token"}, &cfg.Token})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.GerritConnection:
+		var fields []jsonStringField
+		if cfg.Password != "" {
+			fields = append(fields, jsonStringField{[]string{"password"}, &cfg.Password})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.SubversionConnection:
+		var fields []jsonStringField
+		if cfg.Password != "" {
+			fields = append(fields, jsonStringField{[]string{"password"}, &cfg.Password})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.MercurialConnection:
+		var fields []jsonStringField
+		if cfg.Password != "" {
+			fields = append(fields, jsonStringField{[]string{"password"}, &cfg.Password})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	case *schema.AWSCodeCommitConnection:
 		unredacted, err = unredactField(old.Config,
 			e.Config,
@@ -147,6 +203,10 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"url"}, &cfg.Url})
 	case *schema.JVMPackagesConnection:
 		unredacted, err = e.Config, nil
+	case *schema.NpmPackagesConnection:
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"credentials"}, &cfg.Credentials})
+	case *schema.RustPackagesConnection, *schema.PythonPackagesConnection, *schema.RubyPackagesConnection:
+		unredacted, err = e.Config, nil
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("UnRedactExternalServiceConfig: kind %q not implemented", e.Kind)
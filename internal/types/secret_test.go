@@ -55,6 +55,10 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 		Url:                   someSecret,
 		RepositoryPathPattern: "foo",
 	}
+	npmPackagesConfig := schema.NpmPackagesConnection{
+		Credentials: someSecret,
+		Registry:    "https://registry.npmjs.org",
+	}
 	var tc = []struct {
 		kind        string
 		config      interface{} // the config for the service kind
@@ -121,6 +125,12 @@ func TestRoundTripRedactExternalServiceConfig(t *testing.T) {
 			editField:   &perforceConfig.P4User,
 			secretField: &perforceConfig.P4Passwd,
 		},
+		{
+			kind:        extsvc.KindNpmPackages,
+			config:      &npmPackagesConfig,
+			editField:   &npmPackagesConfig.Registry,
+			secretField: &npmPackagesConfig.Credentials,
+		},
 		{
 			kind:        extsvc.KindOther,
 			config:      &otherConfig,
@@ -460,7 +460,12 @@ type GitserverRepo struct {
 	LastFetched time.Time
 	// The last time a fetch updated the repository.
 	LastChanged time.Time
-	UpdatedAt   time.Time
+	// The last time a backup of the repository was written or confirmed to be
+	// up to date.
+	LastBackupAt time.Time
+	// The error of the last backup attempt or empty if it was successful
+	LastBackupError string
+	UpdatedAt       time.Time
 }
 
 // ExternalService is a connection to an external service.
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/sourcegraph/go-diff/diff"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git/gitapi"
+)
+
+// LineHistoryOptions configures a line-range history search.
+type LineHistoryOptions struct {
+	Commit api.CommitID // the commit at which Path and the line range are given
+	Path   string
+
+	StartLine int // 1-indexed start line (inclusive)
+	EndLine   int // 1-indexed end line (inclusive)
+
+	N    uint // limit the number of returned commits to this many (0 means no limit)
+	Skip uint // skip this many commits at the beginning
+}
+
+// LineHistoryEntry is a commit which changed lines of the range passed to
+// LineHistory.
+type LineHistoryEntry struct {
+	Commit *gitapi.Commit
+
+	// Path is the path of the file at Commit. It differs from the path passed
+	// to LineHistory if the file was renamed since.
+	Path string
+
+	// OldPath is the path of the file in the parent of Commit, or "" if
+	// Commit added the file.
+	OldPath string
+
+	// Hunks are the changes Commit made to the lines of the range.
+	Hunks []*diff.Hunk
+}
+
+// LineHistoryEvent is emitted by LineHistoryStream. Exactly one of its fields
+// is set.
+type LineHistoryEvent struct {
+	Entry *LineHistoryEntry
+
+	// Error is non-nil if an error occurred. It is the last event if set.
+	Error error
+}
+
+// LineHistory wraps LineHistoryStream providing a blocking API. See
+// LineHistoryStream.
+func LineHistory(ctx context.Context, repo api.RepoName, opt LineHistoryOptions) ([]*LineHistoryEntry, error) {
+	if Mocks.LineHistory != nil {
+		return Mocks.LineHistory(repo, opt)
+	}
+
+	var entries []*LineHistoryEntry
+	for event := range LineHistoryStream(ctx, repo, opt) {
+		if event.Error != nil {
+			return nil, event.Error
+		}
+		entries = append(entries, event.Entry)
+	}
+	return entries, nil
+}
+
+// LineHistoryStream returns the commits which changed lines StartLine to
+// EndLine of the file at Path, as of Commit, newest first. It is backed by
+// `git log -L`, which follows the lines across renames of the file.
+//
+// The returned channel must be read until closed, otherwise you may leak
+// resources.
+func LineHistoryStream(ctx context.Context, repo api.RepoName, opt LineHistoryOptions) <-chan LineHistoryEvent {
+	c := make(chan LineHistoryEvent)
+	go func() {
+		defer close(c)
+		if err := doLineHistoryStream(ctx, repo, opt, c); err != nil {
+			c <- LineHistoryEvent{Error: err}
+		}
+	}()
+	return c
+}
+
+func doLineHistoryStream(ctx context.Context, repo api.RepoName, opt LineHistoryOptions, c chan<- LineHistoryEvent) (err error) {
+	count := 0
+	tr, ctx := trace.New(ctx, "Git: LineHistory", fmt.Sprintf("%+v", opt))
+	defer func() {
+		tr.LazyPrintf("%d commits, err=%v", count, err)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	args, err := lineHistoryArgs(opt)
+	if err != nil {
+		return err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	cmd.EnsureRevision = string(opt.Commit)
+	r, err := gitserver.StdoutReader(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	next := lineHistoryScanner(r)
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("git command %v failed", cmd.Args))
+		}
+
+		select {
+		case c <- LineHistoryEvent{Entry: entry}:
+			count++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lineHistoryRecordSeparator starts the output of every commit in the output
+// of `git log -L`, which is otherwise ambiguous since it contains arbitrary
+// diff output.
+const lineHistoryRecordSeparator = '\x1e'
+
+func lineHistoryArgs(opt LineHistoryOptions) ([]string, error) {
+	if opt.Commit == "" {
+		return nil, errors.New("a commit is required")
+	}
+	if err := checkSpecArgSafety(string(opt.Commit)); err != nil {
+		return nil, err
+	}
+	if opt.Path == "" {
+		return nil, errors.New("a path is required")
+	}
+	if opt.StartLine < 1 || opt.EndLine < opt.StartLine {
+		return nil, errors.Errorf("invalid line range %d-%d", opt.StartLine, opt.EndLine)
+	}
+
+	args := []string{
+		"log",
+		// Follow the lines across renames of the file.
+		"-M",
+		"--format=format:%x1e" + strings.TrimPrefix(logFormatWithoutRefs, "--format=format:"),
+		fmt.Sprintf("-L%d,%d:%s", opt.StartLine, opt.EndLine, opt.Path),
+	}
+	if opt.N != 0 {
+		args = append(args, "-n", strconv.FormatUint(uint64(opt.N), 10))
+	}
+	if opt.Skip != 0 {
+		args = append(args, "--skip="+strconv.FormatUint(uint64(opt.Skip), 10))
+	}
+	return append(args, string(opt.Commit)), nil
+}
+
+// lineHistoryScanner returns a function which parses the next commit from the
+// output of `git log -L` formatted by lineHistoryArgs. It returns io.EOF
+// when there are no more commits.
+func lineHistoryScanner(r io.Reader) func() (*LineHistoryEntry, error) {
+	br := bufio.NewReader(r)
+
+	// Discard everything up to the first record.
+	if _, err := br.ReadBytes(lineHistoryRecordSeparator); err != nil && err != io.EOF {
+		return func() (*LineHistoryEntry, error) { return nil, err }
+	}
+
+	return func() (*LineHistoryEntry, error) {
+		record, err := br.ReadBytes(lineHistoryRecordSeparator)
+		if err == io.EOF {
+			if len(record) == 0 {
+				return nil, io.EOF
+			}
+		} else if err != nil {
+			return nil, err
+		} else {
+			record = record[:len(record)-1]
+		}
+		return parseLineHistoryRecord(record)
+	}
+}
+
+// parseLineHistoryRecord parses the commit and the diff of the line range of
+// a single commit in the output of `git log -L`.
+func parseLineHistoryRecord(record []byte) (*LineHistoryEntry, error) {
+	commit, _, rest, err := parseCommitFromLog(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// The diff is preceded by a newline, and followed by an empty line unless
+	// it is the last one.
+	rawDiff := bytes.TrimPrefix(rest, []byte("\n"))
+	if bytes.HasSuffix(rawDiff, []byte("\n\n")) {
+		rawDiff = rawDiff[:len(rawDiff)-1]
+	}
+	fileDiff, err := diff.ParseFileDiff(rawDiff)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing diff of commit %s", commit.ID)
+	}
+
+	var oldPath string
+	if fileDiff.OrigName != "/dev/null" {
+		oldPath = strings.TrimPrefix(fileDiff.OrigName, "a/")
+	}
+	return &LineHistoryEntry{
+		Commit:  commit,
+		Path:    strings.TrimPrefix(fileDiff.NewName, "b/"),
+		OldPath: oldPath,
+		Hunks:   fileDiff.Hunks,
+	}, nil
+}
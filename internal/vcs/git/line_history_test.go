@@ -0,0 +1,79 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestLineHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	repo := MakeGitRepository(t,
+		"printf 'a\\nb\\nc\\nd\\n' > f.txt",
+		"git add f.txt",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m one --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"printf 'a\\nB\\nc\\nd\\n' > f.txt",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:06Z git commit -am two --author='a <a@a.com>' --date 2006-01-02T15:04:06Z",
+		"git mv f.txt g.txt",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:07Z git commit -m rename --author='a <a@a.com>' --date 2006-01-02T15:04:07Z",
+		"printf 'a\\nB\\nC\\nd\\n' > g.txt",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:08Z git commit -am three --author='a <a@a.com>' --date 2006-01-02T15:04:08Z",
+	)
+	head, err := ResolveRevision(ctx, repo, "HEAD", ResolveRevisionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		Message string
+		Path    string
+		Hunks   []string
+	}
+	summarize := func(entries []*LineHistoryEntry) []summary {
+		var summaries []summary
+		for _, e := range entries {
+			s := summary{Message: string(e.Commit.Message), Path: e.Path}
+			for _, h := range e.Hunks {
+				s.Hunks = append(s.Hunks, string(h.Body))
+			}
+			summaries = append(summaries, s)
+		}
+		return summaries
+	}
+
+	entries, err := LineHistory(ctx, repo, LineHistoryOptions{Commit: head, Path: "g.txt", StartLine: 2, EndLine: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []summary{
+		{Message: "three", Path: "g.txt", Hunks: []string{" B\n-c\n+C\n"}},
+		{Message: "two", Path: "f.txt", Hunks: []string{"-b\n+B\n c\n"}},
+		{Message: "one", Path: "f.txt", Hunks: []string{"+b\n+c\n"}},
+	}
+	if diff := cmp.Diff(want, summarize(entries)); diff != "" {
+		t.Fatalf("unexpected line history (-want +got):\n%s", diff)
+	}
+
+	entries, err = LineHistory(ctx, repo, LineHistoryOptions{Commit: head, Path: "g.txt", StartLine: 2, EndLine: 3, N: 1, Skip: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1:2], summarize(entries)); diff != "" {
+		t.Fatalf("unexpected paginated line history (-want +got):\n%s", diff)
+	}
+
+	for name, opt := range map[string]LineHistoryOptions{
+		"missing path":  {Commit: head, Path: "nope.txt", StartLine: 1, EndLine: 1},
+		"invalid range": {Commit: head, Path: "g.txt", StartLine: 3, EndLine: 2},
+		"unsafe commit": {Commit: api.CommitID("-foo"), Path: "g.txt", StartLine: 1, EndLine: 1},
+	} {
+		if _, err := LineHistory(ctx, repo, opt); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package inference
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/autoindex/config"
+)
+
+func PythonPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		pathPattern(rawPattern("setup.py")),
+		pathPattern(rawPattern("pyproject.toml")),
+		pathPattern(rawPattern("requirements.txt")),
+	}
+}
+
+func CanIndexPythonRepo(gitclient GitClient, paths []string) bool {
+	for _, path := range paths {
+		if isPythonProjectPath(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const lsifPyImage = "sourcegraph/lsif-py:latest"
+
+// InferPythonIndexJobs returns one index job per directory containing a
+// setup.py or pyproject.toml file.
+func InferPythonIndexJobs(gitclient GitClient, paths []string) (indexes []config.IndexJob) {
+	seen := map[string]struct{}{}
+
+	for _, path := range paths {
+		if !isPythonProjectPath(path) {
+			continue
+		}
+
+		root := dirWithoutDot(path)
+		if _, ok := seen[root]; ok {
+			// Projects can have both a setup.py and a pyproject.toml file.
+			continue
+		}
+		seen[root] = struct{}{}
+
+		commands := []string{"pip install ."}
+		if contains(paths, filepath.Join(root, "requirements.txt")) {
+			commands = append([]string{"pip install -r requirements.txt"}, commands...)
+		}
+
+		indexes = append(indexes, config.IndexJob{
+			Steps: []config.DockerStep{
+				{
+					Root:     root,
+					Image:    lsifPyImage,
+					Commands: commands,
+				},
+			},
+			Root:        root,
+			Indexer:     lsifPyImage,
+			IndexerArgs: []string{"lsif-py", "--file", "dump.lsif", "."},
+			Outfile:     "dump.lsif",
+		})
+	}
+
+	return indexes
+}
+
+var pythonSegmentBlockList = append([]string{"site-packages", "venv", ".venv", ".tox"}, segmentBlockList...)
+
+func isPythonProjectPath(path string) bool {
+	base := filepath.Base(path)
+	return (base == "setup.py" || base == "pyproject.toml") && containsNoSegments(path, pythonSegmentBlockList...)
+}
@@ -0,0 +1,76 @@
+package inference
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/autoindex/config"
+)
+
+func TestCanIndexPythonRepo(t *testing.T) {
+	testCases := []struct {
+		paths    []string
+		expected bool
+	}{
+		{paths: []string{"setup.py"}, expected: true},
+		{paths: []string{"pyproject.toml"}, expected: true},
+		{paths: []string{"pkg/setup.py"}, expected: true},
+		{paths: []string{"requirements.txt"}, expected: false},
+		{paths: []string{"venv/lib/foo/setup.py"}, expected: false},
+		{paths: []string{"lib/python3.9/site-packages/foo/setup.py"}, expected: false},
+		{paths: []string{"main.py"}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		name := strings.Join(testCase.paths, ", ")
+
+		t.Run(name, func(t *testing.T) {
+			if value := CanIndexPythonRepo(NewMockGitClient(), testCase.paths); value != testCase.expected {
+				t.Errorf("unexpected result from CanIndex. want=%v have=%v", testCase.expected, value)
+			}
+		})
+	}
+}
+
+func TestInferPythonIndexJobs(t *testing.T) {
+	paths := []string{
+		"setup.py",
+		"pyproject.toml",
+		"requirements.txt",
+		"tools/pyproject.toml",
+	}
+
+	expectedIndexJobs := []config.IndexJob{
+		{
+			Steps: []config.DockerStep{
+				{
+					Root:     "",
+					Image:    lsifPyImage,
+					Commands: []string{"pip install -r requirements.txt", "pip install ."},
+				},
+			},
+			Root:        "",
+			Indexer:     lsifPyImage,
+			IndexerArgs: []string{"lsif-py", "--file", "dump.lsif", "."},
+			Outfile:     "dump.lsif",
+		},
+		{
+			Steps: []config.DockerStep{
+				{
+					Root:     "tools",
+					Image:    lsifPyImage,
+					Commands: []string{"pip install ."},
+				},
+			},
+			Root:        "tools",
+			Indexer:     lsifPyImage,
+			IndexerArgs: []string{"lsif-py", "--file", "dump.lsif", "."},
+			Outfile:     "dump.lsif",
+		},
+	}
+	if diff := cmp.Diff(expectedIndexJobs, InferPythonIndexJobs(NewMockGitClient(), paths)); diff != "" {
+		t.Errorf("unexpected index jobs (-want +got):\n%s", diff)
+	}
+}
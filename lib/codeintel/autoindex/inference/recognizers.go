@@ -28,6 +28,9 @@ var Recognizers = map[string]IndexJobRecognizer{
 	"go":   recognizer{GoPatterns, CanIndexGoRepo, InferGoIndexJobs},
 	"tsc":  recognizer{TypeScriptPatterns, CanIndexTypeScriptRepo, InferTypeScriptIndexJobs},
 	"java": recognizer{JavaPatterns, CanIndexJavaRepo, InferJavaIndexJobs},
+	"rust": recognizer{RustPatterns, CanIndexRustRepo, InferRustIndexJobs},
+	"py":   recognizer{PythonPatterns, CanIndexPythonRepo, InferPythonIndexJobs},
+	"ruby": recognizer{RubyPatterns, CanIndexRubyRepo, InferRubyIndexJobs},
 }
 
 type recognizer struct {
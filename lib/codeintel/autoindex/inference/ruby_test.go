@@ -0,0 +1,73 @@
+package inference
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/autoindex/config"
+)
+
+func TestCanIndexRubyRepo(t *testing.T) {
+	testCases := []struct {
+		paths    []string
+		expected bool
+	}{
+		{paths: []string{"Gemfile"}, expected: true},
+		{paths: []string{"app/Gemfile"}, expected: true},
+		{paths: []string{"vendor/bundle/foo/Gemfile"}, expected: false},
+		{paths: []string{"Gemfile.lock"}, expected: false},
+		{paths: []string{"foo.gemspec"}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		name := strings.Join(testCase.paths, ", ")
+
+		t.Run(name, func(t *testing.T) {
+			if value := CanIndexRubyRepo(NewMockGitClient(), testCase.paths); value != testCase.expected {
+				t.Errorf("unexpected result from CanIndex. want=%v have=%v", testCase.expected, value)
+			}
+		})
+	}
+}
+
+func TestInferRubyIndexJobs(t *testing.T) {
+	paths := []string{
+		"Gemfile",
+		"Gemfile.lock",
+		"docs/Gemfile",
+	}
+
+	expectedIndexJobs := []config.IndexJob{
+		{
+			Steps: []config.DockerStep{
+				{
+					Root:     "",
+					Image:    lsifRubyImage,
+					Commands: []string{"bundle install --frozen"},
+				},
+			},
+			Root:        "",
+			Indexer:     lsifRubyImage,
+			IndexerArgs: []string{"lsif-ruby", "--output", "dump.lsif"},
+			Outfile:     "dump.lsif",
+		},
+		{
+			Steps: []config.DockerStep{
+				{
+					Root:     "docs",
+					Image:    lsifRubyImage,
+					Commands: []string{"bundle install"},
+				},
+			},
+			Root:        "docs",
+			Indexer:     lsifRubyImage,
+			IndexerArgs: []string{"lsif-ruby", "--output", "dump.lsif"},
+			Outfile:     "dump.lsif",
+		},
+	}
+	if diff := cmp.Diff(expectedIndexJobs, InferRubyIndexJobs(NewMockGitClient(), paths)); diff != "" {
+		t.Errorf("unexpected index jobs (-want +got):\n%s", diff)
+	}
+}
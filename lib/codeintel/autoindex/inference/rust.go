@@ -0,0 +1,208 @@
+package inference
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/autoindex/config"
+)
+
+func RustPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		pathPattern(rawPattern("Cargo.toml")),
+	}
+}
+
+func CanIndexRustRepo(gitclient GitClient, paths []string) bool {
+	for _, path := range paths {
+		if isCargoManifestPath(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const lsifRustImage = "sourcegraph/lsif-rust:latest"
+
+// InferRustIndexJobs returns one index job per Cargo workspace or standalone
+// crate. Indexing the root of a workspace covers all of its member crates, so
+// the manifests of workspace members do not produce a job of their own.
+func InferRustIndexJobs(gitclient GitClient, paths []string) (indexes []config.IndexJob) {
+	for _, path := range paths {
+		if !isCargoManifestPath(path) || isCargoWorkspaceMember(gitclient, path, paths) {
+			continue
+		}
+
+		root := dirWithoutDot(path)
+
+		indexes = append(indexes, config.IndexJob{
+			Steps: []config.DockerStep{
+				{
+					Root:     root,
+					Image:    lsifRustImage,
+					Commands: []string{"cargo fetch"},
+				},
+			},
+			Root:        root,
+			Indexer:     lsifRustImage,
+			IndexerArgs: []string{"lsif-rust", "index"},
+			Outfile:     "dump.lsif",
+		})
+	}
+
+	return indexes
+}
+
+// isCargoWorkspaceMember returns true if the given manifest belongs to the
+// workspace declared by the closest ancestor manifest with a [workspace]
+// table. Like cargo, only the closest workspace root is considered.
+func isCargoWorkspaceMember(gitclient GitClient, path string, paths []string) bool {
+	dir := dirWithoutDot(path)
+	if dir == "" {
+		return false
+	}
+
+	for _, ancestor := range ancestorDirs(dir) {
+		manifestPath := filepath.Join(ancestor, "Cargo.toml")
+		if !contains(paths, manifestPath) {
+			continue
+		}
+
+		b, err := gitclient.RawContents(context.TODO(), manifestPath)
+		if err != nil {
+			continue
+		}
+
+		workspace, ok := parseCargoWorkspace(b)
+		if !ok {
+			continue
+		}
+
+		rel := dir
+		if ancestor != "" {
+			rel = strings.TrimPrefix(dir, ancestor+"/")
+		}
+		return matchesAnyCargoPattern(workspace.members, rel) && !matchesAnyCargoPattern(workspace.exclude, rel)
+	}
+
+	return false
+}
+
+type cargoWorkspace struct {
+	members []string
+	exclude []string
+}
+
+var (
+	tomlTableHeaderPattern = regexp.MustCompile(`^\[\[?\s*([^\]]+?)\s*\]\]?$`)
+	tomlStringPattern      = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+)
+
+// parseCargoWorkspace extracts the members and exclude arrays of the
+// [workspace] table of the given manifest. It understands just enough TOML to
+// read string arrays, which may span multiple lines. The second return value
+// is false if the manifest has no [workspace] table.
+func parseCargoWorkspace(manifest []byte) (workspace cargoWorkspace, ok bool) {
+	var (
+		inWorkspace bool
+		current     *[]string
+	)
+
+	for _, line := range strings.Split(string(manifest), "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+
+		if current != nil {
+			line = collectTOMLStrings(current, line)
+			if strings.Contains(line, "]") {
+				current = nil
+			}
+			continue
+		}
+
+		if match := tomlTableHeaderPattern.FindStringSubmatch(line); match != nil {
+			inWorkspace = match[1] == "workspace"
+			ok = ok || inWorkspace
+			continue
+		}
+		if !inWorkspace {
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			continue
+		}
+
+		var target *[]string
+		switch strings.TrimSpace(line[:i]) {
+		case "members":
+			target = &workspace.members
+		case "exclude":
+			target = &workspace.exclude
+		default:
+			continue
+		}
+
+		value := strings.TrimSpace(line[i+1:])
+		if !strings.HasPrefix(value, "[") {
+			continue
+		}
+		if rest := collectTOMLStrings(target, value[1:]); !strings.Contains(rest, "]") {
+			current = target
+		}
+	}
+
+	return workspace, ok
+}
+
+// collectTOMLStrings appends the string literals in the given line to values
+// and returns the line with the literals removed.
+func collectTOMLStrings(values *[]string, line string) string {
+	for _, match := range tomlStringPattern.FindAllStringSubmatch(line, -1) {
+		*values = append(*values, match[1]+match[2])
+	}
+	return tomlStringPattern.ReplaceAllString(line, "")
+}
+
+// stripTOMLComment removes a trailing comment from the given line, ignoring
+// '#' characters inside string literals.
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// matchesAnyCargoPattern returns true if the given directory, relative to the
+// workspace root, matches one of the given member or exclude patterns.
+func matchesAnyCargoPattern(patterns []string, dir string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.Clean(pattern), "./"), "/")
+		if matched, _ := filepath.Match(pattern, dir); matched {
+			return true
+		}
+	}
+	return false
+}
+
+var rustSegmentBlockList = append([]string{"target", "vendor"}, segmentBlockList...)
+
+func isCargoManifestPath(path string) bool {
+	return filepath.Base(path) == "Cargo.toml" && containsNoSegments(path, rustSegmentBlockList...)
+}
@@ -0,0 +1,121 @@
+package inference
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/autoindex/config"
+)
+
+func TestCanIndexRustRepo(t *testing.T) {
+	testCases := []struct {
+		paths    []string
+		expected bool
+	}{
+		{paths: []string{"Cargo.toml"}, expected: true},
+		{paths: []string{"crates/a/Cargo.toml"}, expected: true},
+		{paths: []string{"target/debug/build/Cargo.toml"}, expected: false},
+		{paths: []string{"vendor/foo/Cargo.toml"}, expected: false},
+		{paths: []string{"Cargo.lock"}, expected: false},
+		{paths: []string{"main.rs"}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		name := strings.Join(testCase.paths, ", ")
+
+		t.Run(name, func(t *testing.T) {
+			if value := CanIndexRustRepo(NewMockGitClient(), testCase.paths); value != testCase.expected {
+				t.Errorf("unexpected result from CanIndex. want=%v have=%v", testCase.expected, value)
+			}
+		})
+	}
+}
+
+func rustIndexJob(root string) config.IndexJob {
+	return config.IndexJob{
+		Steps: []config.DockerStep{
+			{
+				Root:     root,
+				Image:    lsifRustImage,
+				Commands: []string{"cargo fetch"},
+			},
+		},
+		Root:        root,
+		Indexer:     lsifRustImage,
+		IndexerArgs: []string{"lsif-rust", "index"},
+		Outfile:     "dump.lsif",
+	}
+}
+
+func mockCargoManifests(manifests map[string]string) *MockGitClient {
+	mockGit := NewMockGitClient()
+	mockGit.RawContentsFunc.SetDefaultHook(func(_ context.Context, file string) ([]byte, error) {
+		return []byte(manifests[file]), nil
+	})
+	return mockGit
+}
+
+func TestInferRustIndexJobsWorkspace(t *testing.T) {
+	manifests := map[string]string{
+		"Cargo.toml": `
+[workspace]
+members = [
+    "crates/*", # all crates
+    "tools/cli",
+]
+exclude = ["crates/experimental"]
+
+[[bin]]
+name = "members"
+`,
+		"crates/a/Cargo.toml":            "[package]\nname = \"a\"\n",
+		"crates/b/Cargo.toml":            "[package]\nname = \"b\"\n",
+		"crates/experimental/Cargo.toml": "[package]\nname = \"experimental\"\n",
+		"tools/cli/Cargo.toml":           "[package]\nname = \"cli\"\n",
+		"standalone/demo/Cargo.toml":     "[package]\nname = \"demo\"\n",
+	}
+	paths := []string{
+		"Cargo.toml",
+		"crates/a/Cargo.toml",
+		"crates/b/Cargo.toml",
+		"crates/experimental/Cargo.toml",
+		"tools/cli/Cargo.toml",
+		"standalone/demo/Cargo.toml",
+	}
+
+	expectedIndexJobs := []config.IndexJob{
+		rustIndexJob(""),
+		rustIndexJob("crates/experimental"),
+		rustIndexJob("standalone/demo"),
+	}
+	if diff := cmp.Diff(expectedIndexJobs, InferRustIndexJobs(mockCargoManifests(manifests), paths)); diff != "" {
+		t.Errorf("unexpected index jobs (-want +got):\n%s", diff)
+	}
+}
+
+func TestInferRustIndexJobsNestedCrates(t *testing.T) {
+	manifests := map[string]string{
+		"a/Cargo.toml":        "[package]\nname = \"a\"\n\n[workspace]\nmembers = [\"nested\"]\n",
+		"a/nested/Cargo.toml": "[package]\nname = \"nested\"\n",
+		"b/Cargo.toml":        "[package]\nname = \"b\"\n",
+		"b/nested/Cargo.toml": "[package]\nname = \"independent\"\n",
+	}
+	paths := []string{
+		"a/Cargo.toml",
+		"a/nested/Cargo.toml",
+		"b/Cargo.toml",
+		"b/nested/Cargo.toml",
+	}
+
+	expectedIndexJobs := []config.IndexJob{
+		rustIndexJob("a"),
+		rustIndexJob("b"),
+		rustIndexJob("b/nested"),
+	}
+	if diff := cmp.Diff(expectedIndexJobs, InferRustIndexJobs(mockCargoManifests(manifests), paths)); diff != "" {
+		t.Errorf("unexpected index jobs (-want +got):\n%s", diff)
+	}
+}
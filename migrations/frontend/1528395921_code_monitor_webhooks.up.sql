@@ -0,0 +1,68 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cm_slack_webhooks (
+    id bigserial PRIMARY KEY,
+    monitor bigint NOT NULL,
+    url text NOT NULL,
+    enabled boolean NOT NULL,
+    created_by integer NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    changed_by integer NOT NULL,
+    changed_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT cm_slack_webhooks_monitor FOREIGN KEY (monitor) REFERENCES cm_monitors(id) ON DELETE CASCADE,
+    CONSTRAINT cm_slack_webhooks_created_by_fk FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT cm_slack_webhooks_changed_by_fk FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS cm_slack_webhooks_monitor_idx ON cm_slack_webhooks (monitor);
+
+COMMENT ON TABLE cm_slack_webhooks IS 'Slack webhook actions configured on code monitors';
+COMMENT ON COLUMN cm_slack_webhooks.url IS 'The Slack incoming webhook URL we send the code monitor event to';
+
+CREATE TABLE IF NOT EXISTS cm_webhooks (
+    id bigserial PRIMARY KEY,
+    monitor bigint NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL DEFAULT '',
+    encryption_key_id text NOT NULL DEFAULT '',
+    enabled boolean NOT NULL,
+    created_by integer NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    changed_by integer NOT NULL,
+    changed_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT cm_webhooks_monitor FOREIGN KEY (monitor) REFERENCES cm_monitors(id) ON DELETE CASCADE,
+    CONSTRAINT cm_webhooks_created_by_fk FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT cm_webhooks_changed_by_fk FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS cm_webhooks_monitor_idx ON cm_webhooks (monitor);
+
+COMMENT ON TABLE cm_webhooks IS 'Generic HTTP webhook actions configured on code monitors';
+COMMENT ON COLUMN cm_webhooks.url IS 'The URL we send the code monitor event to';
+COMMENT ON COLUMN cm_webhooks.secret IS 'The secret used to sign the payload sent to the URL. Empty if the payload is not signed';
+COMMENT ON COLUMN cm_webhooks.encryption_key_id IS 'The ID of the key the secret is encrypted with. Empty if the secret is not encrypted';
+
+ALTER TABLE cm_action_jobs
+    ALTER COLUMN email DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS slack_webhook bigint,
+    ADD COLUMN IF NOT EXISTS webhook bigint,
+    ADD CONSTRAINT cm_action_jobs_slack_webhook_fk FOREIGN KEY (slack_webhook) REFERENCES cm_slack_webhooks(id) ON DELETE CASCADE,
+    ADD CONSTRAINT cm_action_jobs_webhook_fk FOREIGN KEY (webhook) REFERENCES cm_webhooks(id) ON DELETE CASCADE,
+    ADD CONSTRAINT cm_action_jobs_only_one_action_type CHECK (
+        (
+            CASE WHEN email IS NULL THEN 0 ELSE 1 END
+            + CASE WHEN slack_webhook IS NULL THEN 0 ELSE 1 END
+            + CASE WHEN webhook IS NULL THEN 0 ELSE 1 END
+        ) = 1
+    );
+
+COMMENT ON COLUMN cm_action_jobs.email IS 'The ID of the cm_emails action to execute if this is an email job. Mutually exclusive with slack_webhook and webhook';
+COMMENT ON COLUMN cm_action_jobs.slack_webhook IS 'The ID of the cm_slack_webhooks action to execute if this is a Slack webhook job. Mutually exclusive with email and webhook';
+COMMENT ON COLUMN cm_action_jobs.webhook IS 'The ID of the cm_webhooks action to execute if this is a webhook job. Mutually exclusive with email and slack_webhook';
+
+ALTER TABLE cm_trigger_jobs
+    ADD COLUMN IF NOT EXISTS search_results jsonb;
+
+COMMENT ON COLUMN cm_trigger_jobs.search_results IS 'The new search results of the run, which are included in the payload of webhook actions';
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS changeset_merge_queues (
+    id bigserial PRIMARY KEY,
+    batch_change_id bigint NOT NULL REFERENCES batch_changes(id) ON DELETE CASCADE DEFERRABLE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE DEFERRABLE,
+    state text NOT NULL DEFAULT 'active',
+    merge_order text NOT NULL DEFAULT 'created_at',
+    concurrency_per_code_host integer NOT NULL DEFAULT 1,
+    squash boolean NOT NULL DEFAULT false,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT changeset_merge_queues_concurrency_positive CHECK (concurrency_per_code_host > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS changeset_merge_queues_batch_change_id_unique ON changeset_merge_queues (batch_change_id);
+
+COMMENT ON TABLE changeset_merge_queues IS 'Merge queues automatically merging the approved changesets with passing checks of a batch change';
+COMMENT ON COLUMN changeset_merge_queues.user_id IS 'The user who enabled the merge queue. Changesets are merged on behalf of this user';
+COMMENT ON COLUMN changeset_merge_queues.merge_order IS 'The order in which eligible changesets are merged: created_at, repository_name or diff_size';
+COMMENT ON COLUMN changeset_merge_queues.concurrency_per_code_host IS 'The maximum number of changesets of the batch change being merged at the same time on a single code host';
+
+COMMIT;
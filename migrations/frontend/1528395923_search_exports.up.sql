@@ -0,0 +1,33 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS search_exports (
+    id bigserial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE DEFERRABLE,
+    query text NOT NULL,
+    pattern_type text NOT NULL,
+    format text NOT NULL,
+    result_count integer NOT NULL DEFAULT 0,
+    object_key text,
+    state text NOT NULL DEFAULT 'queued',
+    failure_message text,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    process_after timestamp with time zone,
+    num_resets integer NOT NULL DEFAULT 0,
+    num_failures integer NOT NULL DEFAULT 0,
+    execution_logs json[],
+    worker_hostname text NOT NULL DEFAULT '',
+    last_heartbeat_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS search_exports_state_idx ON search_exports (state);
+CREATE INDEX IF NOT EXISTS search_exports_user_id_idx ON search_exports (user_id);
+
+COMMENT ON TABLE search_exports IS 'Exports of all results of a search query, run in the background on behalf of a user';
+COMMENT ON COLUMN search_exports.format IS 'The file format of the export: csv or jsonl';
+COMMENT ON COLUMN search_exports.result_count IS 'The number of rows in the exported file';
+COMMENT ON COLUMN search_exports.object_key IS 'The key of the gzip compressed exported file in the search export store';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS repo_kvps;
+DROP TABLE IF EXISTS repo_topics;
+
+COMMIT;
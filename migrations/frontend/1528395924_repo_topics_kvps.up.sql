@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_topics (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE DEFERRABLE,
+    topic text NOT NULL,
+    PRIMARY KEY (repo_id, topic)
+);
+
+CREATE INDEX IF NOT EXISTS repo_topics_topic_idx ON repo_topics (topic);
+
+COMMENT ON TABLE repo_topics IS 'Topics of repositories, synced from the code host';
+COMMENT ON COLUMN repo_topics.topic IS 'The lower case name of the topic';
+
+CREATE TABLE IF NOT EXISTS repo_kvps (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE DEFERRABLE,
+    key text NOT NULL,
+    value text,
+    PRIMARY KEY (repo_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS repo_kvps_key_value_idx ON repo_kvps (key, value);
+
+COMMENT ON TABLE repo_kvps IS 'Custom key-value metadata of repositories, set by site admins';
+COMMENT ON COLUMN repo_kvps.value IS 'The value of the key, or NULL if the key is set without a value';
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS saved_search_action_jobs;
+DROP TABLE IF EXISTS saved_search_executions;
+
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS latest_result;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS next_run;
+
+COMMIT;
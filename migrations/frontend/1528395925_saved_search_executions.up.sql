@@ -0,0 +1,62 @@
+BEGIN;
+
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS latest_result timestamp with time zone;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS next_run timestamp with time zone;
+
+COMMENT ON COLUMN saved_searches.latest_result IS 'The time of the latest result we notified about. Only results after it are new.';
+COMMENT ON COLUMN saved_searches.next_run IS 'The earliest time at which the saved search is run again';
+
+CREATE TABLE IF NOT EXISTS saved_search_executions (
+    id bigserial PRIMARY KEY,
+    saved_search_id integer NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE DEFERRABLE,
+    query_string text,
+    num_results integer,
+    approximate_result_count text,
+    state text NOT NULL DEFAULT 'queued',
+    failure_message text,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    process_after timestamp with time zone,
+    num_resets integer NOT NULL DEFAULT 0,
+    num_failures integer NOT NULL DEFAULT 0,
+    execution_logs json[],
+    worker_hostname text NOT NULL DEFAULT '',
+    last_heartbeat_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS saved_search_executions_state_idx ON saved_search_executions (state);
+CREATE INDEX IF NOT EXISTS saved_search_executions_saved_search_id_idx ON saved_search_executions (saved_search_id);
+CREATE UNIQUE INDEX IF NOT EXISTS saved_search_executions_active_idx ON saved_search_executions (saved_search_id) WHERE state IN ('queued', 'processing', 'errored');
+
+COMMENT ON TABLE saved_search_executions IS 'Runs of saved searches which notify their subscribers about new results';
+COMMENT ON COLUMN saved_search_executions.query_string IS 'The query that was run, including the after: filter';
+COMMENT ON COLUMN saved_search_executions.num_results IS 'The number of new results';
+COMMENT ON COLUMN saved_search_executions.approximate_result_count IS 'The approximate number of new results, as shown in notifications';
+
+CREATE TABLE IF NOT EXISTS saved_search_action_jobs (
+    id bigserial PRIMARY KEY,
+    execution_id bigint NOT NULL REFERENCES saved_search_executions(id) ON DELETE CASCADE DEFERRABLE,
+    kind text NOT NULL,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE DEFERRABLE,
+    state text NOT NULL DEFAULT 'queued',
+    failure_message text,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    process_after timestamp with time zone,
+    num_resets integer NOT NULL DEFAULT 0,
+    num_failures integer NOT NULL DEFAULT 0,
+    execution_logs json[],
+    worker_hostname text NOT NULL DEFAULT '',
+    last_heartbeat_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS saved_search_action_jobs_state_idx ON saved_search_action_jobs (state);
+CREATE INDEX IF NOT EXISTS saved_search_action_jobs_execution_id_idx ON saved_search_action_jobs (execution_id);
+
+COMMENT ON TABLE saved_search_action_jobs IS 'Notifications about the new results of a saved search execution';
+COMMENT ON COLUMN saved_search_action_jobs.kind IS 'The kind of notification: email or slack';
+COMMENT ON COLUMN saved_search_action_jobs.user_id IS 'The recipient of an email notification';
+
+COMMIT;
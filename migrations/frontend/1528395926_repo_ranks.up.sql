@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_ranks (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE DEFERRABLE,
+    score double precision NOT NULL,
+    stars integer NOT NULL DEFAULT 0,
+    recent_commit_count integer NOT NULL DEFAULT 0,
+    inbound_reference_count integer NOT NULL DEFAULT 0,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS repo_ranks_score_idx ON repo_ranks (score DESC);
+
+COMMENT ON TABLE repo_ranks IS 'Ranks of repositories used to order search results, computed periodically by the worker';
+COMMENT ON COLUMN repo_ranks.score IS 'The rank of the repository computed from the other columns, without the boost configured by site admins. Higher ranks first';
+COMMENT ON COLUMN repo_ranks.recent_commit_count IS 'The number of commits to the default branch in the recent past';
+COMMENT ON COLUMN repo_ranks.inbound_reference_count IS 'The number of other repositories whose precise code intel references packages of the repository';
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_update_schedule (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE DEFERRABLE,
+    scheduled boolean NOT NULL DEFAULT TRUE,
+    interval_seconds integer NOT NULL,
+    due_at timestamp with time zone NOT NULL,
+    queued_at timestamp with time zone,
+    priority integer NOT NULL DEFAULT 0,
+    lease_owner text,
+    lease_expires_at timestamp with time zone,
+    last_updated_at timestamp with time zone,
+    last_error text
+);
+
+CREATE INDEX IF NOT EXISTS repo_update_schedule_due_at_idx ON repo_update_schedule (due_at) WHERE scheduled AND queued_at IS NULL;
+CREATE INDEX IF NOT EXISTS repo_update_schedule_queued_idx ON repo_update_schedule (priority DESC, queued_at) WHERE queued_at IS NOT NULL;
+
+COMMENT ON TABLE repo_update_schedule IS 'When repo-updater next asks gitserver to fetch each repository, shared by all repo-updater replicas';
+COMMENT ON COLUMN repo_update_schedule.scheduled IS 'Whether the repository is updated periodically. Otherwise the row only exists while a single update is queued';
+COMMENT ON COLUMN repo_update_schedule.interval_seconds IS 'How regularly the repository is updated. Backs off on errors and for repositories without recent commits';
+COMMENT ON COLUMN repo_update_schedule.due_at IS 'The next time the repository is queued for an update';
+COMMENT ON COLUMN repo_update_schedule.queued_at IS 'When the repository was queued for an update, or NULL if it is not queued';
+COMMENT ON COLUMN repo_update_schedule.priority IS 'The priority of the queued update. Higher priorities first';
+COMMENT ON COLUMN repo_update_schedule.lease_owner IS 'The repo-updater replica which is updating the repository';
+COMMENT ON COLUMN repo_update_schedule.lease_expires_at IS 'When other replicas may take over the update if the owner did not finish it';
+COMMENT ON COLUMN repo_update_schedule.last_error IS 'The error of the last update, or NULL if it succeeded';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE gitserver_repos DROP COLUMN IF EXISTS last_backup_at;
+ALTER TABLE gitserver_repos DROP COLUMN IF EXISTS last_backup_error;
+
+COMMIT;
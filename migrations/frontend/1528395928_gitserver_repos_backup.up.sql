@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE gitserver_repos ADD COLUMN IF NOT EXISTS last_backup_at timestamp with time zone;
+ALTER TABLE gitserver_repos ADD COLUMN IF NOT EXISTS last_backup_error text;
+
+COMMENT ON COLUMN gitserver_repos.last_backup_at IS 'The last time gitserver wrote a backup of the repository, or confirmed the latest backup is up to date';
+COMMENT ON COLUMN gitserver_repos.last_backup_error IS 'The error of the last backup attempt, or NULL if it succeeded';
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS changeset_merge_queue_removals (
+    merge_queue_id bigint NOT NULL REFERENCES changeset_merge_queues(id) ON DELETE CASCADE DEFERRABLE,
+    changeset_id bigint NOT NULL REFERENCES changesets(id) ON DELETE CASCADE DEFERRABLE,
+    reason text NOT NULL,
+    changeset_updated_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (merge_queue_id, changeset_id)
+);
+
+COMMENT ON TABLE changeset_merge_queue_removals IS 'Changesets taken out of a merge queue because the code host reported that they cannot be merged';
+COMMENT ON COLUMN changeset_merge_queue_removals.reason IS 'The error the code host returned when merging the changeset';
+COMMENT ON COLUMN changeset_merge_queue_removals.changeset_updated_at IS 'The external_updated_at of the changeset when it was removed. The changeset is merged again once it has been updated on the code host';
+
+COMMIT;
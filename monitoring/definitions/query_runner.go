@@ -11,7 +11,7 @@ func QueryRunner() *monitoring.Container {
 	return &monitoring.Container{
 		Name:        "query-runner",
 		Title:       "Query Runner",
-		Description: "Periodically runs saved searches and instructs the frontend to send out notifications.",
+		Description: "Periodically runs saved searches and sends out notifications about their new results.",
 		Groups: []monitoring.Group{
 			shared.NewFrontendInternalAPIErrorResponseMonitoringGroup(containerName, monitoring.ObservableOwnerSearch, nil),
 			shared.NewContainerMonitoringGroup(containerName, monitoring.ObservableOwnerSearch, nil),
@@ -179,12 +179,20 @@ type BatchSpec struct {
 	Workspaces []*WorkspaceConfiguration `json:"workspaces,omitempty"`
 }
 
+// BitbucketCloudAuthorization description: If non-null, enforces Bitbucket Cloud repository permissions. The app password must belong to an administrator of the workspaces listed in "teams", and of the workspace of "username".
+type BitbucketCloudAuthorization struct {
+	// IdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Bitbucket Cloud identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and the nicknames of Bitbucket Cloud workspace members, and `auth.enableUsernameChanges` must be set to false for security reasons.
+	IdentityProvider BitbucketCloudIdentityProvider `json:"identityProvider"`
+}
+
 // BitbucketCloudConnection description: Configuration for a connection to Bitbucket Cloud.
 type BitbucketCloudConnection struct {
 	// ApiURL description: The API URL of Bitbucket Cloud, such as https://api.bitbucket.org. Generally, admin should not modify the value of this option because Bitbucket Cloud is a public hosting platform.
 	ApiURL string `json:"apiURL,omitempty"`
 	// AppPassword description: The app password to use when authenticating to the Bitbucket Cloud. Also set the corresponding "username" field.
 	AppPassword string `json:"appPassword"`
+	// Authorization description: If non-null, enforces Bitbucket Cloud repository permissions. The app password must belong to an administrator of the workspaces listed in "teams", and of the workspace of "username".
+	Authorization *BitbucketCloudAuthorization `json:"authorization,omitempty"`
 	// Exclude description: A list of repositories to never mirror from Bitbucket Cloud. Takes precedence over "teams" configuration.
 	//
 	// Supports excluding by name ({"name": "myorg/myrepo"}) or by UUID ({"uuid": "{fceb73c7-cef6-4abe-956d-e471281126bd}"}).
@@ -211,6 +219,13 @@ type BitbucketCloudConnection struct {
 	Url string `json:"url"`
 	// Username description: The username to use when authenticating to the Bitbucket Cloud. Also set the corresponding "appPassword" field.
 	Username string `json:"username"`
+	// WebhookSecret description: A shared secret used to authenticate incoming webhook requests from Bitbucket Cloud. The same secret must be configured on the Bitbucket Cloud webhooks pointing at https://SOURCEGRAPH_URL/.api/bitbucket-cloud-webhooks. Webhooks are used by batch changes to keep changesets up to date.
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+// BitbucketCloudIdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Bitbucket Cloud identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and the nicknames of Bitbucket Cloud workspace members, and `auth.enableUsernameChanges` must be set to false for security reasons.
+type BitbucketCloudIdentityProvider struct {
+	Type string `json:"type"`
 }
 
 // BitbucketCloudRateLimit description: Rate limit applied when making background API requests to Bitbucket Cloud.
@@ -445,6 +460,7 @@ type EncryptionKey struct {
 	Cloudkms *CloudKMSEncryptionKey
 	Awskms   *AWSKMSEncryptionKey
 	Mounted  *MountedEncryptionKey
+	Vault    *VaultTransitEncryptionKey
 	Noop     *NoOpEncryptionKey
 }
 
@@ -458,6 +474,9 @@ func (v EncryptionKey) MarshalJSON() ([]byte, error) {
 	if v.Mounted != nil {
 		return json.Marshal(v.Mounted)
 	}
+	if v.Vault != nil {
+		return json.Marshal(v.Vault)
+	}
 	if v.Noop != nil {
 		return json.Marshal(v.Noop)
 	}
@@ -479,15 +498,18 @@ func (v *EncryptionKey) UnmarshalJSON(data []byte) error {
 		return json.Unmarshal(data, &v.Mounted)
 	case "noop":
 		return json.Unmarshal(data, &v.Noop)
+	case "vault":
+		return json.Unmarshal(data, &v.Vault)
 	}
-	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"cloudkms", "awskms", "mounted", "noop"})
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"cloudkms", "awskms", "mounted", "vault", "noop"})
 }
 
 // EncryptionKeys description: Configuration for encryption keys used to encrypt data at rest in the database.
 type EncryptionKeys struct {
 	BatchChangesCredentialKey *EncryptionKey `json:"batchChangesCredentialKey,omitempty"`
 	// CacheSize description: number of values to keep in LRU cache
-	CacheSize int `json:"cacheSize,omitempty"`
+	CacheSize             int            `json:"cacheSize,omitempty"`
+	CodeMonitorWebhookKey *EncryptionKey `json:"codeMonitorWebhookKey,omitempty"`
 	// EnableCache description: enable LRU cache for decryption APIs
 	EnableCache            bool           `json:"enableCache,omitempty"`
 	ExternalServiceKey     *EncryptionKey `json:"externalServiceKey,omitempty"`
@@ -515,6 +537,12 @@ type ExcludedBitbucketServerRepo struct {
 	// Pattern description: Regular expression which matches against the name of a Bitbucket Server repo.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedGerritProject struct {
+	// Name description: The name of a Gerrit project ("mygroup/myproject") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gerrit project to exclude from mirroring.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitHubRepo struct {
 	// Archived description: If set to true, archived repositories will be excluded.
 	Archived bool `json:"archived,omitempty"`
@@ -533,12 +561,38 @@ type ExcludedGitLabProject struct {
 	// Name description: The name of a GitLab project ("group/name") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
 }
+type ExcludedGiteaRepo struct {
+	// Archived description: If set to true, archived repositories will be excluded.
+	Archived bool `json:"archived,omitempty"`
+	// Forks description: If set to true, forks will be excluded.
+	Forks bool `json:"forks,omitempty"`
+	// Id description: The ID of a Gitea repository (as returned by the Gitea API) to exclude from mirroring.
+	Id int `json:"id,omitempty"`
+	// Mirrors description: If set to true, repositories mirrored by Gitea from another code host will be excluded.
+	Mirrors bool `json:"mirrors,omitempty"`
+	// Name description: The name of a Gitea repository ("owner/name") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gitea repository ("owner/name").
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitoliteRepo struct {
 	// Name description: The name of a Gitolite repo ("my-repo") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
 	// Pattern description: Regular expression which matches against the name of a Gitolite repo to exclude from mirroring.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedMercurialRepo struct {
+	// Name description: The path of a Mercurial repository, relative to "url", to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the path of a Mercurial repository to exclude from mirroring.
+	Pattern string `json:"pattern,omitempty"`
+}
+type ExcludedSubversionRepo struct {
+	// Name description: The path of a Subversion repository, relative to "url", to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the path of a Subversion repository to exclude from mirroring.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExistingChangesetSpec struct {
 	// BaseRepository description: The GraphQL ID of the repository that contains the existing changeset on the code host.
 	BaseRepository string `json:"baseRepository"`
@@ -574,12 +628,20 @@ type ExperimentalFeatures struct {
 	EventLogging string `json:"eventLogging,omitempty"`
 	// JvmPackages description: Allow adding JVM packages code host connections
 	JvmPackages string `json:"jvmPackages,omitempty"`
+	// NpmPackages description: Allow adding npm packages code host connections
+	NpmPackages string `json:"npmPackages,omitempty"`
 	// Perforce description: Allow adding Perforce code host connections
 	Perforce string `json:"perforce,omitempty"`
+	// PythonPackages description: Allow adding Python packages code host connections
+	PythonPackages string `json:"pythonPackages,omitempty"`
 	// Ranking description: Experimental search result ranking options.
 	Ranking *Ranking `json:"ranking,omitempty"`
 	// RateLimitAnonymous description: Configures the hourly rate limits for anonymous calls to the GraphQL API. Setting limit to 0 disables the limiter. This is only relevant if unauthenticated calls to the API are permitted.
 	RateLimitAnonymous int `json:"rateLimitAnonymous,omitempty"`
+	// RubyPackages description: Allow adding Ruby packages code host connections
+	RubyPackages string `json:"rubyPackages,omitempty"`
+	// RustPackages description: Allow adding Rust packages code host connections
+	RustPackages string `json:"rustPackages,omitempty"`
 	// SearchIndexBranches description: A map from repository name to a list of extra revs (branch, ref, tag, commit sha, etc) to index for a repository. We always index the default branch ("HEAD") and revisions in version contexts. This allows specifying additional revisions. Sourcegraph can index up to 64 branches per repository.
 	SearchIndexBranches map[string][]string `json:"search.index.branches,omitempty"`
 	// SearchMultipleRevisionsPerRepository description: DEPRECATED. Always on. Will be removed in 3.19.
@@ -635,6 +697,30 @@ type FusionClient struct {
 	Retries int `json:"retries,omitempty"`
 }
 
+// GerritConnection description: Configuration for a connection to Gerrit.
+type GerritConnection struct {
+	// Exclude description: A list of projects to never mirror from this Gerrit instance. Takes precedence over "projects" configuration.
+	//
+	// Supports excluding by name ({"name": "myproject"}) and by regular expression ({"pattern": "^secret/.*"}).
+	Exclude []*ExcludedGerritProject `json:"exclude,omitempty"`
+	// Password description: The HTTP password of the Gerrit account, as generated in the account settings of Gerrit. Also set the corresponding "username" field.
+	Password string `json:"password,omitempty"`
+	// Projects description: An array of project names specifying which Gerrit projects to mirror on Sourcegraph. If omitted, all projects readable by the configured account are mirrored.
+	Projects []string `json:"projects,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gerrit project. In the pattern, the variable "{host}" is replaced with the Gerrit URL's host (such as gerrit.example.com), and "{name}" is replaced with the Gerrit project's name (such as "mygroup/myproject").
+	//
+	// For example, if your Gerrit URL is https://gerrit.example.com and your Sourcegraph URL is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that a Gerrit project named mygroup/myproject is available on Sourcegraph at https://src.example.com/gerrit.example.com/mygroup/myproject.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Url description: URL of a Gerrit instance, such as https://gerrit.example.com.
+	Url string `json:"url"`
+	// Username description: The username of the Gerrit account used to list and clone projects. Also set the corresponding "password" field.
+	//
+	// If omitted, only projects that anonymous users can read are mirrored.
+	Username string `json:"username,omitempty"`
+}
+
 // GitCommitAuthor description: The author of the Git commit.
 type GitCommitAuthor struct {
 	// Email description: The Git commit author email.
@@ -844,8 +930,52 @@ type GitLabWebhook struct {
 	Secret string `json:"secret"`
 }
 
+// GiteaConnection description: Configuration for a connection to Gitea.
+type GiteaConnection struct {
+	// Exclude description: A list of repositories to never mirror from this Gitea instance. Takes precedence over "orgs", "repos", and "repositoryQuery" configuration.
+	//
+	// Supports excluding by name ({"name": "owner/name"}), by ID ({"id": 42}), by regular expression ({"pattern": "^owner/.*"}), and excluding archived repositories ({"archived": true}), forks ({"forks": true}) or mirrors ({"mirrors": true}).
+	Exclude []*ExcludedGiteaRepo `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.
+	//
+	// If "http", Sourcegraph will access Gitea repositories using Git URLs of the form https://gitea.example.com/myorg/myrepo.git (using the token, if set).
+	//
+	// If "ssh", Sourcegraph will access Gitea repositories using Git URLs of the form git@gitea.example.com:myorg/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Orgs description: An array of organization names identifying Gitea organizations whose repositories should be mirrored on Sourcegraph.
+	Orgs []string `json:"orgs,omitempty"`
+	// Repos description: An array of repository "owner/name" strings specifying which Gitea repositories to mirror on Sourcegraph.
+	Repos []string `json:"repos,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository. In the pattern, the variable "{host}" is replaced with the Gitea URL's host (such as gitea.example.com), and "{nameWithOwner}" is replaced with the Gitea repository's "owner/name" (such as "myorg/myrepo").
+	//
+	// For example, if your Gitea URL is https://gitea.example.com and your Sourcegraph URL is https://src.example.com, then a repositoryPathPattern of "{host}/{nameWithOwner}" would mean that a Gitea repository at https://gitea.example.com/myorg/myrepo is available on Sourcegraph at https://src.example.com/gitea.example.com/myorg/myrepo.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// RepositoryQuery description: An array of strings specifying which Gitea repositories to mirror on Sourcegraph. The valid values are:
+	//
+	// - `affiliated` mirrors all repositories the user of the token owns or is a collaborator or an organization member of.
+	//
+	// - `all` mirrors all repositories visible to the token (requires a token of an administrator to include private repositories of other users).
+	//
+	// - `none` mirrors no repositories (except those specified in the `orgs` or `repos` configuration property).
+	RepositoryQuery []string `json:"repositoryQuery,omitempty"`
+	// Token description: An access token for a Gitea user. Private repositories are only mirrored if the user has read access to them.
+	//
+	// If omitted, only public repositories are mirrored.
+	Token string `json:"token,omitempty"`
+	// Url description: URL of a Gitea instance, such as https://gitea.example.com or https://example.com/gitea.
+	Url string `json:"url"`
+}
+
+// GitoliteAuthorization description: If non-null, enforces Gitolite repository permissions. Permissions are read from the access rules of the gitolite-admin repository, which must be mirrored by this connection. Sourcegraph assumes usernames are identical in Sourcegraph and Gitolite, and `auth.enableUsernameChanges` must be set to false for security reasons.
+type GitoliteAuthorization struct {
+}
+
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
+	// Authorization description: If non-null, enforces Gitolite repository permissions. Permissions are read from the access rules of the gitolite-admin repository, which must be mirrored by this connection. Sourcegraph assumes usernames are identical in Sourcegraph and Gitolite, and `auth.enableUsernameChanges` must be set to false for security reasons.
+	Authorization *GitoliteAuthorization `json:"authorization,omitempty"`
 	// Exclude description: A list of repositories to never mirror from this Gitolite instance. Supports excluding by exact name ({"name": "foo"}).
 	Exclude []*ExcludedGitoliteRepo `json:"exclude,omitempty"`
 	// Host description: Gitolite host that stores the repositories (e.g., git@gitolite.example.com, ssh://git@gitolite.example.com:2222/).
@@ -981,6 +1111,34 @@ type MavenRateLimit struct {
 	RequestsPerHour float64 `json:"requestsPerHour"`
 }
 
+// MercurialConnection description: Configuration for a connection to a Mercurial server.
+type MercurialConnection struct {
+	// Authors description: Maps Mercurial commit authors to Git authors of the form "Full Name <email>". Authors that are not mapped are converted as they are.
+	Authors map[string]string `json:"authors,omitempty"`
+	// Exclude description: A list of repositories to never mirror from this Mercurial server. Takes precedence over "repos" configuration.
+	//
+	// Supports excluding by name ({"name": "project"}) and by regular expression ({"pattern": "^archive/.*"}).
+	Exclude []*ExcludedMercurialRepo `json:"exclude,omitempty"`
+	// Password description: The password of the Mercurial user. Also set the corresponding "username" field.
+	Password string `json:"password,omitempty"`
+	// Repos description: An array of repository paths, relative to "url", specifying which repositories to mirror on Sourcegraph. If omitted, all repositories listed by the hgweb index are mirrored.
+	Repos []string `json:"repos,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Mercurial repository. In the pattern, the variable "{host}" is replaced with the host of the Mercurial URL (such as hg.example.com), and "{name}" is replaced with the path of the repository relative to the Mercurial URL (such as "team/project").
+	//
+	// For example, if your Mercurial URL is https://hg.example.com/ and your Sourcegraph URL is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that the Mercurial repository at https://hg.example.com/team/project is available on Sourcegraph at https://src.example.com/hg.example.com/team/project.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Url description: URL of the hgweb index that lists the repositories, such as https://hg.example.com/.
+	//
+	// Unless "repos" is set, the repositories are listed from this index.
+	Url string `json:"url"`
+	// Username description: The username used to list and convert repositories. Also set the corresponding "password" field.
+	//
+	// If omitted, repositories are accessed anonymously.
+	Username string `json:"username,omitempty"`
+}
+
 // MountedEncryptionKey description: This encryption key is mounted from a given file path or an environment variable.
 type MountedEncryptionKey struct {
 	EnvVarName string `json:"envVarName,omitempty"`
@@ -1103,6 +1261,26 @@ type NotifierWebhook struct {
 	Url         string `json:"url"`
 	Username    string `json:"username,omitempty"`
 }
+
+// NpmPackagesConnection description: Configuration for a connection to an npm packages registry.
+type NpmPackagesConnection struct {
+	// Credentials description: Access token for logging into the npm registry. It is sent as a bearer token to the registry and to tarball URLs on the same host.
+	Credentials string `json:"credentials,omitempty"`
+	// Dependencies description: An array of "(@scope/)?packageName@version" strings specifying which npm packages to mirror on Sourcegraph.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the npm registry.
+	RateLimit *NpmRateLimit `json:"rateLimit,omitempty"`
+	// Registry description: The URL at which the npm registry can be found.
+	Registry string `json:"registry"`
+}
+
+// NpmRateLimit description: Rate limit applied when making background API requests to the npm registry.
+type NpmRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
 type OAuthIdentity struct {
 	Type string `json:"type"`
 }
@@ -1256,6 +1434,24 @@ type PhabricatorConnection struct {
 	// Url description: URL of a Phabricator instance, such as https://phabricator.example.com
 	Url string `json:"url,omitempty"`
 }
+
+// PythonPackagesConnection description: Configuration for a connection to a Python package registry, such as PyPI.
+type PythonPackagesConnection struct {
+	// Dependencies description: An array of "packageName==version" strings specifying which Python packages to mirror on Sourcegraph.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the Python package registry.
+	RateLimit *PythonRateLimit `json:"rateLimit,omitempty"`
+	// Registry description: The URL at which the Python package registry can be found.
+	Registry string `json:"registry"`
+}
+
+// PythonRateLimit description: Rate limit applied when making background API requests to the Python package registry.
+type PythonRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
 type QuickLink struct {
 	// Description description: A description for this quick link
 	Description string `json:"description,omitempty"`
@@ -1288,6 +1484,42 @@ type Responders struct {
 // SAMLAuthProvider description: Configures the SAML authentication provider for SSO.
 //
 // Note: if you are using IdP-initiated login, you must have *at most one* SAMLAuthProvider in the `auth.providers` array.
+
+// RubyPackagesConnection description: Configuration for a connection to a Ruby package registry, such as RubyGems.
+type RubyPackagesConnection struct {
+	// Dependencies description: An array of "packageName@version" strings specifying which Ruby packages to mirror on Sourcegraph.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the Ruby package registry.
+	RateLimit *RubyRateLimit `json:"rateLimit,omitempty"`
+	// Registry description: The URL at which the Ruby package registry can be found.
+	Registry string `json:"registry"`
+}
+
+// RubyRateLimit description: Rate limit applied when making background API requests to the Ruby package registry.
+type RubyRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
+
+// RustPackagesConnection description: Configuration for a connection to a Rust package registry, such as crates.io.
+type RustPackagesConnection struct {
+	// Dependencies description: An array of "packageName@version" strings specifying which Rust packages to mirror on Sourcegraph.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the Rust package registry.
+	RateLimit *RustRateLimit `json:"rateLimit,omitempty"`
+	// Registry description: The URL at which the Rust package registry can be found.
+	Registry string `json:"registry"`
+}
+
+// RustRateLimit description: Rate limit applied when making background API requests to the Rust package registry.
+type RustRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
 type SAMLAuthProvider struct {
 	// AllowSignup description: Allows new visitors to sign up for accounts via SAML authentication. If false, users signing in via SAML must have an existing Sourcegraph account, which will be linked to their SAML identity after sign-in.
 	AllowSignup *bool `json:"allowSignup,omitempty"`
@@ -1680,6 +1912,38 @@ type Step struct {
 	Run string `json:"run"`
 }
 
+// SubversionConnection description: Configuration for a connection to a Subversion server.
+type SubversionConnection struct {
+	// Authors description: Maps Subversion usernames to Git authors of the form "Full Name <email>". Commits by usernames that are not mapped are attributed to "username <username>".
+	Authors map[string]string `json:"authors,omitempty"`
+	// Exclude description: A list of repositories to never mirror from this Subversion server. Takes precedence over "repos" configuration.
+	//
+	// Supports excluding by name ({"name": "project"}) and by regular expression ({"pattern": "^archive/.*"}).
+	Exclude []*ExcludedSubversionRepo `json:"exclude,omitempty"`
+	// Layout description: The layout of the repositories.
+	//
+	// "standard" converts trunk to the master branch, the directories in branches/ to branches, and the directories in tags/ to tags. "root" converts the repository root to the master branch.
+	Layout string `json:"layout,omitempty"`
+	// Password description: The password of the Subversion user. Also set the corresponding "username" field.
+	Password string `json:"password,omitempty"`
+	// Repos description: An array of repository paths, relative to "url", specifying which repositories to mirror on Sourcegraph. If omitted, all repositories listed by the server are mirrored.
+	Repos []string `json:"repos,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Subversion repository. In the pattern, the variable "{host}" is replaced with the host of the Subversion URL (such as svn.example.com), and "{name}" is replaced with the path of the repository relative to the Subversion URL (such as "team/project").
+	//
+	// For example, if your Subversion URL is https://svn.example.com/repos/ and your Sourcegraph URL is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that the Subversion repository at https://svn.example.com/repos/team/project is available on Sourcegraph at https://src.example.com/svn.example.com/team/project.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Url description: URL of the Subversion server path that contains the repositories, such as https://svn.example.com/repos/.
+	//
+	// Unless "repos" is set, the repositories are listed from the index the server serves at this URL (as enabled by SVNListParentPath in Apache). Listing requires an http(s) URL.
+	Url string `json:"url"`
+	// Username description: The username used to list and convert repositories. Also set the corresponding "password" field.
+	//
+	// If omitted, repositories are accessed anonymously.
+	Username string `json:"username,omitempty"`
+}
+
 // TlsExternal description: Global TLS/SSL settings for Sourcegraph to use when communicating with code hosts.
 type TlsExternal struct {
 	// Certificates description: TLS certificates to accept. This is only necessary if you are using self-signed certificates or an internal CA. Can be an internal CA certificate or a self-signed certificate. To get the certificate of a webserver run `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
@@ -1712,6 +1976,30 @@ type UsernameIdentity struct {
 	Type string `json:"type"`
 }
 
+// VaultAppRoleAuth description: Authenticate to Vault using the AppRole auth method.
+type VaultAppRoleAuth struct {
+	// MountPath description: The path the AppRole auth method is mounted at.
+	MountPath string `json:"mountPath,omitempty"`
+	RoleID    string `json:"roleID"`
+	SecretID  string `json:"secretID"`
+}
+
+// VaultTransitEncryptionKey description: HashiCorp Vault transit secrets engine key. Data is encrypted and decrypted by Vault, the key itself never leaves Vault. Exactly one of token or approle must be set.
+type VaultTransitEncryptionKey struct {
+	// Address description: The URL of the Vault server.
+	Address string            `json:"address"`
+	Approle *VaultAppRoleAuth `json:"approle,omitempty"`
+	// Keyname description: The name of the transit key.
+	Keyname string `json:"keyname"`
+	// MountPath description: The path the transit secrets engine is mounted at.
+	MountPath string `json:"mountPath,omitempty"`
+	// Namespace description: The Vault Enterprise namespace the transit secrets engine lives in.
+	Namespace string `json:"namespace,omitempty"`
+	// Token description: A Vault token with permission to encrypt and decrypt with the key and to read the key.
+	Token string `json:"token,omitempty"`
+	Type  string `json:"type"`
+}
+
 // VersionContext description: Configuration of the version context
 type VersionContext struct {
 	// Description description: Description of the version context
@@ -22,6 +22,10 @@ var BitbucketServerSchemaJSON string
 //go:embed changeset_spec.schema.json
 var ChangesetSpecSchemaJSON string
 
+// GerritSchemaJSON is the content of the file "gerrit.schema.json".
+//go:embed gerrit.schema.json
+var GerritSchemaJSON string
+
 // GitHubSchemaJSON is the content of the file "github.schema.json".
 //go:embed github.schema.json
 var GitHubSchemaJSON string
@@ -30,6 +34,10 @@ var GitHubSchemaJSON string
 //go:embed gitlab.schema.json
 var GitLabSchemaJSON string
 
+// GiteaSchemaJSON is the content of the file "gitea.schema.json".
+//go:embed gitea.schema.json
+var GiteaSchemaJSON string
+
 // GitoliteSchemaJSON is the content of the file "gitolite.schema.json".
 //go:embed gitolite.schema.json
 var GitoliteSchemaJSON string
@@ -38,6 +46,14 @@ var GitoliteSchemaJSON string
 //go:embed jvm-packages.schema.json
 var JVMPackagesSchemaJSON string
 
+// MercurialSchemaJSON is the content of the file "mercurial.schema.json".
+//go:embed mercurial.schema.json
+var MercurialSchemaJSON string
+
+// NpmPackagesSchemaJSON is the content of the file "npm-packages.schema.json".
+//go:embed npm-packages.schema.json
+var NpmPackagesSchemaJSON string
+
 // OtherExternalServiceSchemaJSON is the content of the file "other_external_service.schema.json".
 //go:embed other_external_service.schema.json
 var OtherExternalServiceSchemaJSON string
@@ -50,6 +66,18 @@ var PerforceSchemaJSON string
 //go:embed phabricator.schema.json
 var PhabricatorSchemaJSON string
 
+// PythonPackagesSchemaJSON is the content of the file "python-packages.schema.json".
+//go:embed python-packages.schema.json
+var PythonPackagesSchemaJSON string
+
+// RubyPackagesSchemaJSON is the content of the file "ruby-packages.schema.json".
+//go:embed ruby-packages.schema.json
+var RubyPackagesSchemaJSON string
+
+// RustPackagesSchemaJSON is the content of the file "rust-packages.schema.json".
+//go:embed rust-packages.schema.json
+var RustPackagesSchemaJSON string
+
 // SettingsSchemaJSON is the content of the file "settings.schema.json".
 //go:embed settings.schema.json
 var SettingsSchemaJSON string
@@ -57,3 +85,7 @@ var SettingsSchemaJSON string
 // SiteSchemaJSON is the content of the file "site.schema.json".
 //go:embed site.schema.json
 var SiteSchemaJSON string
+
+// SubversionSchemaJSON is the content of the file "subversion.schema.json".
+//go:embed subversion.schema.json
+var SubversionSchemaJSON string